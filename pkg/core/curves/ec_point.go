@@ -92,6 +92,9 @@ func (a *EcPoint) MarshalBinary() ([]byte, error) {
 }
 
 func (a *EcPoint) UnmarshalBinary(data []byte) error {
+	if len(data) != 65 {
+		return fmt.Errorf("invalid point length %d", len(data))
+	}
 	if mapper, ok := curveIdToName[data[0]]; ok {
 		a.Curve = mapper()
 		a.X = new(big.Int).SetBytes(data[1:33])
@@ -0,0 +1,222 @@
+//
+// Copyright Coinbase, Inc. All Rights Reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+// Package polynomial implements fast arithmetic for polynomials with
+// coefficients in the scalar field of a curve. Polynomials are slices of
+// coefficients with the lowest degree first.
+//
+// Fields with a large power of two dividing p-1, such as BLS12-381 Fr and the
+// Pallas scalar field, get a radix-2 number theoretic transform so multiplication
+// costs O(n log n). Division, multipoint evaluation and interpolation are built
+// on top with subproduct trees. Other fields fall back to schoolbook multiplication.
+//
+// - https://cr.yp.to/lineartime/multapps-20080515.pdf
+package polynomial
+
+import (
+	"fmt"
+	"math/big"
+	"sync"
+
+	"github.com/nerifnetwork/kryptology/pkg/core/curves"
+)
+
+// field caches the 2-adic structure of a scalar field
+type field struct {
+	// twoAdicity is the largest s such that 2^s divides p-1
+	twoAdicity int
+	// root is a primitive 2^s root of unity
+	root curves.Scalar
+}
+
+var fields sync.Map
+
+// getField computes or returns the cached 2-adic structure of the scalar's field
+func getField(s curves.Scalar) *field {
+	// p = -1 + 1
+	pMinus1 := s.One().Neg().BigInt()
+	key := pMinus1.String()
+	if f, ok := fields.Load(key); ok {
+		return f.(*field)
+	}
+	twoAdicity := 0
+	for pMinus1.Bit(twoAdicity) == 0 {
+		twoAdicity++
+	}
+	t := new(big.Int).Rsh(pMinus1, uint(twoAdicity))
+	half := new(big.Int).Rsh(pMinus1, 1)
+	minusOne := s.One().Neg()
+	// Any quadratic non-residue g gives a primitive 2^s root of unity g^t
+	root := s.One()
+	for g := 2; ; g++ {
+		candidate := s.New(g)
+		if pow(candidate, half).Cmp(minusOne) == 0 {
+			root = pow(candidate, t)
+			break
+		}
+	}
+	f := &field{twoAdicity, root}
+	fields.Store(key, f)
+	return f
+}
+
+// pow computes base^e by square and multiply
+func pow(base curves.Scalar, e *big.Int) curves.Scalar {
+	result := base.One()
+	for i := e.BitLen() - 1; i >= 0; i-- {
+		result = result.Square()
+		if e.Bit(i) == 1 {
+			result = result.Mul(base)
+		}
+	}
+	return result
+}
+
+// TwoAdicity returns the largest s such that the field of s supports NTTs of size 2^s
+func TwoAdicity(s curves.Scalar) int {
+	return getField(s).twoAdicity
+}
+
+// RootOfUnity returns a primitive root of unity of order n in the field of s.
+// n must be a power of two no larger than 2^TwoAdicity.
+func RootOfUnity(s curves.Scalar, n int) (curves.Scalar, error) {
+	if n < 1 || n&(n-1) != 0 {
+		return nil, fmt.Errorf("order must be a power of two")
+	}
+	f := getField(s)
+	k := log2(n)
+	if k > f.twoAdicity {
+		return nil, fmt.Errorf("field only has roots of unity up to order 2^%d", f.twoAdicity)
+	}
+	root := f.root
+	for i := k; i < f.twoAdicity; i++ {
+		root = root.Square()
+	}
+	return root, nil
+}
+
+// Domain is the multiplicative subgroup {1, ω, ω^2, ..., ω^(n-1)} of a
+// power of two size n used to evaluate and interpolate with the NTT
+type Domain struct {
+	Size      int
+	Generator curves.Scalar
+	// powers[i] = ω^i for i < n/2, the twiddle factors of the transform
+	powers    []curves.Scalar
+	invPowers []curves.Scalar
+	sizeInv   curves.Scalar
+}
+
+// NewDomain creates an evaluation domain of size n in the field of s
+func NewDomain(s curves.Scalar, n int) (*Domain, error) {
+	omega, err := RootOfUnity(s, n)
+	if err != nil {
+		return nil, err
+	}
+	omegaInv, err := omega.Invert()
+	if err != nil {
+		return nil, err
+	}
+	sizeInv, err := s.New(n).Invert()
+	if err != nil {
+		return nil, err
+	}
+	half := n / 2
+	if half == 0 {
+		half = 1
+	}
+	powers := make([]curves.Scalar, half)
+	invPowers := make([]curves.Scalar, half)
+	powers[0] = s.One()
+	invPowers[0] = s.One()
+	for i := 1; i < half; i++ {
+		powers[i] = powers[i-1].Mul(omega)
+		invPowers[i] = invPowers[i-1].Mul(omegaInv)
+	}
+	return &Domain{
+		Size:      n,
+		Generator: omega,
+		powers:    powers,
+		invPowers: invPowers,
+		sizeInv:   sizeInv,
+	}, nil
+}
+
+// Elements returns ω^i for every i in the domain
+func (d Domain) Elements() []curves.Scalar {
+	out := make([]curves.Scalar, d.Size)
+	out[0] = d.Generator.One()
+	for i := 1; i < d.Size; i++ {
+		out[i] = out[i-1].Mul(d.Generator)
+	}
+	return out
+}
+
+// Evaluate returns f(ω^i) for every i in the domain. The polynomial
+// can have at most Size coefficients.
+func (d Domain) Evaluate(coefficients []curves.Scalar) ([]curves.Scalar, error) {
+	if len(coefficients) == 0 || len(coefficients) > d.Size {
+		return nil, fmt.Errorf("polynomial must have between 1 and %d coefficients", d.Size)
+	}
+	values := make([]curves.Scalar, d.Size)
+	copy(values, coefficients)
+	zero := coefficients[0].Zero()
+	for i := len(coefficients); i < d.Size; i++ {
+		values[i] = zero
+	}
+	d.ntt(values, d.powers)
+	return values, nil
+}
+
+// Interpolate returns the coefficients of the polynomial that takes
+// values[i] at ω^i. The inverse of Evaluate.
+func (d Domain) Interpolate(values []curves.Scalar) ([]curves.Scalar, error) {
+	if len(values) != d.Size {
+		return nil, fmt.Errorf("expected %d values", d.Size)
+	}
+	coefficients := make([]curves.Scalar, d.Size)
+	copy(coefficients, values)
+	d.ntt(coefficients, d.invPowers)
+	for i, c := range coefficients {
+		coefficients[i] = c.Mul(d.sizeInv)
+	}
+	return coefficients, nil
+}
+
+// ntt is the in place iterative Cooley-Tukey transform
+func (d Domain) ntt(a []curves.Scalar, powers []curves.Scalar) {
+	n := len(a)
+	// bit reversal permutation
+	for i, j := 1, 0; i < n; i++ {
+		bit := n >> 1
+		for ; j&bit != 0; bit >>= 1 {
+			j ^= bit
+		}
+		j ^= bit
+		if i < j {
+			a[i], a[j] = a[j], a[i]
+		}
+	}
+	for length := 2; length <= n; length <<= 1 {
+		step := n / length
+		half := length / 2
+		for start := 0; start < n; start += length {
+			for k := 0; k < half; k++ {
+				u := a[start+k]
+				v := a[start+k+half].Mul(powers[k*step])
+				a[start+k] = u.Add(v)
+				a[start+k+half] = u.Sub(v)
+			}
+		}
+	}
+}
+
+func log2(n int) int {
+	k := 0
+	for 1<<k < n {
+		k++
+	}
+	return k
+}
@@ -0,0 +1,85 @@
+//
+// Copyright Coinbase, Inc. All Rights Reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package polynomial
+
+import (
+	crand "crypto/rand"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/nerifnetwork/kryptology/pkg/core/curves"
+)
+
+func randomPoly(curve *curves.Curve, n int) []curves.Scalar {
+	out := make([]curves.Scalar, n)
+	for i := range out {
+		out[i] = curve.Scalar.Random(crand.Reader)
+	}
+	return out
+}
+
+func requireScalarsEqual(t *testing.T, expected, actual []curves.Scalar) {
+	require.Len(t, actual, len(expected))
+	for i := range expected {
+		require.Equal(t, 0, expected[i].Cmp(actual[i]), "index %d", i)
+	}
+}
+
+func TestTwoAdicity(t *testing.T) {
+	require.Equal(t, 32, TwoAdicity(curves.BLS12381G1().Scalar))
+	require.Equal(t, 32, TwoAdicity(curves.PALLAS().Scalar))
+	require.Equal(t, 47, TwoAdicity(curves.BLS12377G1().Scalar))
+	require.Equal(t, 6, TwoAdicity(curves.K256().Scalar))
+	require.Equal(t, 4, TwoAdicity(curves.P256().Scalar))
+	require.Equal(t, 2, TwoAdicity(curves.ED25519().Scalar))
+}
+
+func TestRootOfUnity(t *testing.T) {
+	for _, curve := range []*curves.Curve{curves.BLS12381G1(), curves.PALLAS(), curves.K256()} {
+		s := TwoAdicity(curve.Scalar)
+		for _, k := range []int{1, 2, s} {
+			root, err := RootOfUnity(curve.Scalar, 1<<k)
+			require.NoError(t, err)
+			// root^(2^k) = 1 and root^(2^(k-1)) = -1
+			x := root
+			for i := 1; i < k; i++ {
+				x = x.Square()
+			}
+			require.Equal(t, 0, x.Cmp(curve.Scalar.One().Neg()))
+			require.True(t, x.Square().IsOne())
+		}
+		_, err := RootOfUnity(curve.Scalar, 1<<(s+1))
+		require.Error(t, err)
+		_, err = RootOfUnity(curve.Scalar, 6)
+		require.Error(t, err)
+	}
+}
+
+func TestDomainEvaluateInterpolate(t *testing.T) {
+	for _, curve := range []*curves.Curve{curves.BLS12381G1(), curves.PALLAS(), curves.K256()} {
+		for _, n := range []int{1, 2, 8, 64} {
+			if log2(n) > TwoAdicity(curve.Scalar) {
+				continue
+			}
+			d, err := NewDomain(curve.Scalar, n)
+			require.NoError(t, err)
+			f := randomPoly(curve, n-n/4)
+			values, err := d.Evaluate(f)
+			require.NoError(t, err)
+			for i, x := range d.Elements() {
+				require.Equal(t, 0, Evaluate(f, x).Cmp(values[i]))
+			}
+			coefficients, err := d.Interpolate(values)
+			require.NoError(t, err)
+			for i := len(f); i < n; i++ {
+				f = append(f, curve.Scalar.Zero())
+			}
+			requireScalarsEqual(t, f, coefficients)
+		}
+	}
+}
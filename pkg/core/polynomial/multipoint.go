@@ -0,0 +1,155 @@
+//
+// Copyright Coinbase, Inc. All Rights Reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package polynomial
+
+import (
+	"fmt"
+
+	"github.com/nerifnetwork/kryptology/internal"
+	"github.com/nerifnetwork/kryptology/pkg/core/curves"
+)
+
+// subproductTree holds the products of (x - x_i) over ever larger ranges of points.
+// levels[0] are the linear factors and the last level is the vanishing polynomial.
+type subproductTree struct {
+	levels [][][]curves.Scalar
+}
+
+func newSubproductTree(xs []curves.Scalar) *subproductTree {
+	leaves := make([][]curves.Scalar, len(xs))
+	for i, x := range xs {
+		leaves[i] = []curves.Scalar{x.Neg(), x.One()}
+	}
+	levels := [][][]curves.Scalar{leaves}
+	for len(levels[len(levels)-1]) > 1 {
+		prev := levels[len(levels)-1]
+		next := make([][]curves.Scalar, (len(prev)+1)/2)
+		for i := range next {
+			if 2*i+1 < len(prev) {
+				next[i] = Mul(prev[2*i], prev[2*i+1])
+			} else {
+				next[i] = prev[2*i]
+			}
+		}
+		levels = append(levels, next)
+	}
+	return &subproductTree{levels}
+}
+
+func (t subproductTree) root() []curves.Scalar {
+	return t.levels[len(t.levels)-1][0]
+}
+
+// evaluate reduces f down the tree so the leaves hold f(x_i)
+func (t subproductTree) evaluate(f []curves.Scalar) ([]curves.Scalar, error) {
+	_, r, err := DivMod(f, t.root())
+	if err != nil {
+		return nil, err
+	}
+	current := [][]curves.Scalar{r}
+	for l := len(t.levels) - 2; l >= 0; l-- {
+		nodes := t.levels[l]
+		next := make([][]curves.Scalar, len(nodes))
+		for i, node := range nodes {
+			_, next[i], err = DivMod(current[i/2], node)
+			if err != nil {
+				return nil, err
+			}
+		}
+		current = next
+	}
+	out := make([]curves.Scalar, len(current))
+	for i, c := range current {
+		out[i] = c[0]
+	}
+	return out, nil
+}
+
+// combine computes Σ c_i * Π_{j != i} (x - x_j) up the tree
+func (t subproductTree) combine(cs []curves.Scalar) []curves.Scalar {
+	current := make([][]curves.Scalar, len(cs))
+	for i, c := range cs {
+		current[i] = []curves.Scalar{c}
+	}
+	for l := 0; l < len(t.levels)-1; l++ {
+		nodes := t.levels[l]
+		next := make([][]curves.Scalar, (len(current)+1)/2)
+		for i := range next {
+			if 2*i+1 < len(current) {
+				next[i] = Add(Mul(current[2*i], nodes[2*i+1]), Mul(current[2*i+1], nodes[2*i]))
+			} else {
+				next[i] = current[2*i]
+			}
+		}
+		current = next
+	}
+	return current[0]
+}
+
+// Vanishing returns Z(x) = Π (x - x_i)
+func Vanishing(xs []curves.Scalar) ([]curves.Scalar, error) {
+	if len(xs) == 0 {
+		return nil, internal.ErrNilArguments
+	}
+	return newSubproductTree(xs).root(), nil
+}
+
+// EvaluateMany returns f(x_i) for every point with a subproduct tree
+// in O(n log^2 n) instead of O(n * deg f) for Horner at every point.
+// It uses Horner when there are few points or the field has no NTT large
+// enough for the tree, where the tree would be slower.
+func EvaluateMany(f []curves.Scalar, xs []curves.Scalar) ([]curves.Scalar, error) {
+	if len(f) == 0 || len(xs) == 0 {
+		return nil, internal.ErrNilArguments
+	}
+	if len(xs) < nttThreshold || log2(len(xs)+len(f)) > TwoAdicity(f[0]) {
+		out := make([]curves.Scalar, len(xs))
+		for i, x := range xs {
+			out[i] = Evaluate(f, x)
+		}
+		return out, nil
+	}
+	return newSubproductTree(xs).evaluate(f)
+}
+
+// Interpolate returns the coefficients of the polynomial of degree
+// len(xs)-1 through the points (xs, ys) in O(n log^2 n).
+// The points must be distinct.
+func Interpolate(xs, ys []curves.Scalar) ([]curves.Scalar, error) {
+	if len(xs) == 0 || len(xs) != len(ys) {
+		return nil, internal.ErrIncorrectCount
+	}
+	for i := range xs {
+		if xs[i] == nil || ys[i] == nil {
+			return nil, internal.ErrNilArguments
+		}
+	}
+	tree := newSubproductTree(xs)
+	// The lagrange weights are 1 / Z'(x_i)
+	var weights []curves.Scalar
+	var err error
+	if len(xs) < nttThreshold {
+		weights = make([]curves.Scalar, len(xs))
+		dz := Derivative(tree.root())
+		for i, x := range xs {
+			weights[i] = Evaluate(dz, x)
+		}
+	} else {
+		weights, err = tree.evaluate(Derivative(tree.root()))
+		if err != nil {
+			return nil, err
+		}
+	}
+	cs := make([]curves.Scalar, len(xs))
+	for i, w := range weights {
+		if w.IsZero() {
+			return nil, fmt.Errorf("duplicate points")
+		}
+		cs[i] = ys[i].Div(w)
+	}
+	return tree.combine(cs), nil
+}
@@ -0,0 +1,79 @@
+//
+// Copyright Coinbase, Inc. All Rights Reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package polynomial
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/nerifnetwork/kryptology/pkg/core/curves"
+)
+
+func sharePoints(curve *curves.Curve, n int) []curves.Scalar {
+	xs := make([]curves.Scalar, n)
+	for i := range xs {
+		xs[i] = curve.Scalar.New(i + 1)
+	}
+	return xs
+}
+
+func TestEvaluateMany(t *testing.T) {
+	for _, curve := range []*curves.Curve{curves.BLS12381G1(), curves.PALLAS(), curves.ED25519()} {
+		for _, n := range [][2]int{{1, 3}, {10, 20}, {100, 300}} {
+			f := randomPoly(curve, n[0])
+			xs := sharePoints(curve, n[1])
+			values, err := EvaluateMany(f, xs)
+			require.NoError(t, err)
+			for i, x := range xs {
+				require.Equal(t, 0, Evaluate(f, x).Cmp(values[i]))
+			}
+		}
+	}
+}
+
+func TestInterpolate(t *testing.T) {
+	for _, curve := range []*curves.Curve{curves.BLS12381G1(), curves.K256()} {
+		for _, n := range []int{1, 2, 17, 150} {
+			f := randomPoly(curve, n)
+			xs := randomPoly(curve, n)
+			ys := make([]curves.Scalar, n)
+			for i, x := range xs {
+				ys[i] = Evaluate(f, x)
+			}
+			result, err := Interpolate(xs, ys)
+			require.NoError(t, err)
+			requireScalarsEqual(t, f, result)
+		}
+		xs := sharePoints(curve, 3)
+		xs[2] = xs[0]
+		_, err := Interpolate(xs, randomPoly(curve, 3))
+		require.Error(t, err)
+	}
+}
+
+func TestVanishing(t *testing.T) {
+	curve := curves.PALLAS()
+	xs := sharePoints(curve, 70)
+	z, err := Vanishing(xs)
+	require.NoError(t, err)
+	require.Len(t, z, 71)
+	for _, x := range xs {
+		require.True(t, Evaluate(z, x).IsZero())
+	}
+	require.False(t, Evaluate(z, curve.Scalar.New(71)).IsZero())
+}
+
+func BenchmarkInterpolate1024(b *testing.B) {
+	curve := curves.BLS12381G1()
+	xs := sharePoints(curve, 1024)
+	ys := randomPoly(curve, 1024)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, _ = Interpolate(xs, ys)
+	}
+}
@@ -0,0 +1,243 @@
+//
+// Copyright Coinbase, Inc. All Rights Reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package polynomial
+
+import (
+	"fmt"
+
+	"github.com/nerifnetwork/kryptology/internal"
+	"github.com/nerifnetwork/kryptology/pkg/core/curves"
+)
+
+// nttThreshold is the product length below which schoolbook multiplication is faster
+const nttThreshold = 64
+
+// Evaluate returns f(x) using Horner's method
+func Evaluate(f []curves.Scalar, x curves.Scalar) curves.Scalar {
+	if len(f) == 0 {
+		return x.Zero()
+	}
+	out := f[len(f)-1].Clone()
+	for i := len(f) - 2; i >= 0; i-- {
+		out = out.Mul(x).Add(f[i])
+	}
+	return out
+}
+
+// Add returns f + g
+func Add(f, g []curves.Scalar) []curves.Scalar {
+	if len(f) < len(g) {
+		f, g = g, f
+	}
+	out := make([]curves.Scalar, len(f))
+	copy(out, f)
+	for i, c := range g {
+		out[i] = out[i].Add(c)
+	}
+	return out
+}
+
+// Sub returns f - g
+func Sub(f, g []curves.Scalar) []curves.Scalar {
+	n := len(f)
+	if len(g) > n {
+		n = len(g)
+	}
+	out := make([]curves.Scalar, n)
+	for i := range out {
+		switch {
+		case i < len(f) && i < len(g):
+			out[i] = f[i].Sub(g[i])
+		case i < len(f):
+			out[i] = f[i]
+		default:
+			out[i] = g[i].Neg()
+		}
+	}
+	return out
+}
+
+// Scale returns c * f
+func Scale(f []curves.Scalar, c curves.Scalar) []curves.Scalar {
+	out := make([]curves.Scalar, len(f))
+	for i, fi := range f {
+		out[i] = fi.Mul(c)
+	}
+	return out
+}
+
+// Derivative returns the formal derivative of f
+func Derivative(f []curves.Scalar) []curves.Scalar {
+	if len(f) == 0 {
+		return nil
+	}
+	if len(f) == 1 {
+		return []curves.Scalar{f[0].Zero()}
+	}
+	out := make([]curves.Scalar, len(f)-1)
+	for i := range out {
+		out[i] = f[i+1].Mul(f[i+1].New(i + 1))
+	}
+	return out
+}
+
+// Mul returns f * g. It uses the NTT when the field supports a domain
+// large enough for the product and schoolbook multiplication otherwise.
+func Mul(f, g []curves.Scalar) []curves.Scalar {
+	if len(f) == 0 || len(g) == 0 {
+		return nil
+	}
+	n := len(f) + len(g) - 1
+	if n >= nttThreshold {
+		size := 1 << log2(n)
+		if log2(size) <= TwoAdicity(f[0]) {
+			if out, err := mulNtt(f, g, size); err == nil {
+				return out[:n]
+			}
+		}
+	}
+	return mulSchoolbook(f, g)
+}
+
+func mulSchoolbook(f, g []curves.Scalar) []curves.Scalar {
+	out := make([]curves.Scalar, len(f)+len(g)-1)
+	zero := f[0].Zero()
+	for i := range out {
+		out[i] = zero
+	}
+	for i, fi := range f {
+		for j, gj := range g {
+			out[i+j] = out[i+j].Add(fi.Mul(gj))
+		}
+	}
+	return out
+}
+
+func mulNtt(f, g []curves.Scalar, size int) ([]curves.Scalar, error) {
+	d, err := NewDomain(f[0], size)
+	if err != nil {
+		return nil, err
+	}
+	fe, err := d.Evaluate(f)
+	if err != nil {
+		return nil, err
+	}
+	ge, err := d.Evaluate(g)
+	if err != nil {
+		return nil, err
+	}
+	for i := range fe {
+		fe[i] = fe[i].Mul(ge[i])
+	}
+	return d.Interpolate(fe)
+}
+
+// DivMod returns the quotient and remainder of f / g. The remainder
+// has exactly len(g)-1 coefficients. Large divisions use Newton iteration
+// on the reversed polynomials so they cost the same as a multiplication.
+func DivMod(f, g []curves.Scalar) ([]curves.Scalar, []curves.Scalar, error) {
+	g = trim(g)
+	if len(g) == 0 || g[len(g)-1].IsZero() {
+		return nil, nil, fmt.Errorf("division by zero polynomial")
+	}
+	if len(f) == 0 {
+		return nil, nil, internal.ErrNilArguments
+	}
+	zero := g[0].Zero()
+	if len(f) < len(g) {
+		r := make([]curves.Scalar, len(g)-1)
+		for i := range r {
+			if i < len(f) {
+				r[i] = f[i]
+			} else {
+				r[i] = zero
+			}
+		}
+		return []curves.Scalar{zero}, r, nil
+	}
+	m := len(f) - len(g) + 1
+	var q []curves.Scalar
+	if m >= nttThreshold && len(g) >= nttThreshold {
+		// rev(q) = rev(f) * rev(g)^-1 mod x^m
+		inv, err := inverseSeries(reverse(g), m)
+		if err != nil {
+			return nil, nil, err
+		}
+		q = reverse(Mul(reverse(f)[:m], inv)[:m])
+	} else {
+		q = divSchoolbook(f, g)
+	}
+	if len(g) == 1 {
+		return q, []curves.Scalar{}, nil
+	}
+	r := Sub(f, Mul(q, g))[:len(g)-1]
+	return q, r, nil
+}
+
+func divSchoolbook(f, g []curves.Scalar) []curves.Scalar {
+	r := make([]curves.Scalar, len(f))
+	copy(r, f)
+	q := make([]curves.Scalar, len(f)-len(g)+1)
+	lead, _ := g[len(g)-1].Invert()
+	for i := len(q) - 1; i >= 0; i-- {
+		c := r[i+len(g)-1].Mul(lead)
+		q[i] = c
+		for j, gj := range g {
+			r[i+j] = r[i+j].Sub(c.Mul(gj))
+		}
+	}
+	return q
+}
+
+// inverseSeries returns h such that f * h = 1 mod x^n
+func inverseSeries(f []curves.Scalar, n int) ([]curves.Scalar, error) {
+	h0, err := f[0].Invert()
+	if err != nil {
+		return nil, fmt.Errorf("constant term is not invertible")
+	}
+	h := []curves.Scalar{h0}
+	two := f[0].New(2)
+	for k := 1; k < n; {
+		k *= 2
+		if k > n {
+			k = n
+		}
+		// h = h * (2 - f * h) mod x^k
+		fk := f
+		if len(fk) > k {
+			fk = fk[:k]
+		}
+		e := Mul(fk, h)
+		if len(e) > k {
+			e = e[:k]
+		}
+		e = Scale(e, f[0].One().Neg())
+		e[0] = e[0].Add(two)
+		h = Mul(h, e)
+		if len(h) > k {
+			h = h[:k]
+		}
+	}
+	return h, nil
+}
+
+func reverse(f []curves.Scalar) []curves.Scalar {
+	out := make([]curves.Scalar, len(f))
+	for i, c := range f {
+		out[len(f)-1-i] = c
+	}
+	return out
+}
+
+// trim removes leading zero coefficients
+func trim(f []curves.Scalar) []curves.Scalar {
+	n := len(f)
+	for n > 1 && f[n-1].IsZero() {
+		n--
+	}
+	return f[:n]
+}
@@ -0,0 +1,52 @@
+//
+// Copyright Coinbase, Inc. All Rights Reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package polynomial
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/nerifnetwork/kryptology/pkg/core/curves"
+)
+
+func TestMul(t *testing.T) {
+	for _, curve := range []*curves.Curve{curves.BLS12381G1(), curves.K256()} {
+		for _, n := range [][2]int{{1, 1}, {3, 5}, {40, 40}, {200, 77}} {
+			f := randomPoly(curve, n[0])
+			g := randomPoly(curve, n[1])
+			requireScalarsEqual(t, mulSchoolbook(f, g), Mul(f, g))
+		}
+	}
+}
+
+func TestDivMod(t *testing.T) {
+	for _, curve := range []*curves.Curve{curves.BLS12381G1(), curves.PALLAS(), curves.P256()} {
+		for _, n := range [][2]int{{5, 1}, {5, 2}, {3, 7}, {300, 100}, {150, 70}} {
+			f := randomPoly(curve, n[0])
+			g := randomPoly(curve, n[1])
+			q, r, err := DivMod(f, g)
+			require.NoError(t, err)
+			require.Len(t, r, n[1]-1)
+			// f = q * g + r
+			back := Add(Mul(q, g), r)
+			requireScalarsEqual(t, f, back[:len(f)])
+			for _, c := range back[len(f):] {
+				require.True(t, c.IsZero())
+			}
+		}
+		_, _, err := DivMod(randomPoly(curve, 3), []curves.Scalar{curve.Scalar.Zero()})
+		require.Error(t, err)
+	}
+}
+
+func TestDerivative(t *testing.T) {
+	curve := curves.BLS12381G1()
+	// 1 + 2x + 3x^2 -> 2 + 6x
+	f := []curves.Scalar{curve.Scalar.New(1), curve.Scalar.New(2), curve.Scalar.New(3)}
+	requireScalarsEqual(t, []curves.Scalar{curve.Scalar.New(2), curve.Scalar.New(6)}, Derivative(f))
+}
@@ -0,0 +1,41 @@
+//
+// Copyright Coinbase, Inc. All Rights Reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package protocol
+
+import (
+	"bytes"
+	"encoding/gob"
+	"fmt"
+)
+
+// CheckVersion returns an error if version is not one of the supported versions.
+func CheckVersion(version uint, supported []uint) error {
+	if !containsVersion(supported, version) {
+		return fmt.Errorf("unsupported version %d", version)
+	}
+	return nil
+}
+
+// EncodePayload gob encodes a round output. Points and scalars in it must be registered with gob.
+func EncodePayload(value interface{}) ([]byte, error) {
+	buf := &bytes.Buffer{}
+	if err := gob.NewEncoder(buf).Encode(value); err != nil {
+		return nil, fmt.Errorf("couldn't encode payload: %v", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// DecodePayload decodes a payload created by EncodePayload into value.
+func DecodePayload(data []byte, value interface{}) error {
+	if len(data) == 0 {
+		return fmt.Errorf("empty payload")
+	}
+	if err := gob.NewDecoder(bytes.NewReader(data)).Decode(value); err != nil {
+		return fmt.Errorf("couldn't decode payload: %v", err)
+	}
+	return nil
+}
@@ -25,6 +25,33 @@ const (
 	// Dkls18Refresh specifies the DKG protocol of the DKLs18 potocol.
 	Dkls18Refresh = "DKLs18-Refresh"
 
+	// Dkls18Export specifies the key export protocol of the DKLs18 protocol.
+	Dkls18Export = "DKLs18-Export"
+
+	// DklsThresholdDkg specifies the t-of-n DKG protocol of DKLs.
+	DklsThresholdDkg = "DKLs-Threshold-DKG"
+
+	// DklsThresholdSign specifies the t-of-n signing protocol of DKLs.
+	DklsThresholdSign = "DKLs-Threshold-Sign"
+
+	// DklsThresholdRefresh specifies the t-of-n key refresh protocol of DKLs.
+	DklsThresholdRefresh = "DKLs-Threshold-Refresh"
+
+	// FrostDkg specifies the DKG protocol of FROST.
+	FrostDkg = "FROST-DKG"
+
+	// FrostSign specifies the signing protocol of FROST.
+	FrostSign = "FROST-Sign"
+
+	// Gg20Dkg specifies the DKG protocol of GG20.
+	Gg20Dkg = "GG20-DKG"
+
+	// Gg20Sign specifies the signing protocol of GG20.
+	Gg20Sign = "GG20-Sign"
+
+	// Gg20Refresh specifies the key refresh protocol of GG20.
+	Gg20Refresh = "GG20-Refresh"
+
 	// versions will increment in 100 intervals, to leave room for adding other versions in between them if it is
 	// ever needed in the future.
 
@@ -33,6 +60,9 @@ const (
 
 	// Version1 is version 2!
 	Version1 = 200
+
+	// Version2 is version 3! DKLs uses it for the language neutral BARE encoding of its payloads.
+	Version2 = 300
 )
 
 // Message provides serializers and deserializer for the inputs and outputs of each step of the protocol.
@@ -53,7 +83,7 @@ type Message struct {
 	Metadata map[string]string
 }
 
-// Iterator an interface for the DKLs18 protocols that follows the iterator pattern.
+// Iterator an interface for the MPC protocols that follows the iterator pattern.
 type Iterator interface {
 	// Next runs the next round of the protocol.
 	// Returns `ErrProtocolFinished` when protocol has completed.
@@ -0,0 +1,157 @@
+//
+// Copyright Coinbase, Inc. All Rights Reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package protocol
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+const (
+	// BroadcastKey is the payload key of an output addressed to all participants.
+	// Any other payload key of an output is the id of its single recipient.
+	BroadcastKey = "broadcast"
+
+	// RoundKey is the metadata key of the round name.
+	RoundKey = "round"
+
+	broadcastPrefix = "broadcast/"
+	directPrefix    = "direct/"
+)
+
+// RecipientKey returns the payload key of an output addressed to a single participant.
+func RecipientKey(id uint32) string {
+	return strconv.FormatUint(uint64(id), 10)
+}
+
+// Route builds the input of the next round for the recipient from the outputs
+// of all participants, keyed by the id of their sender. The input holds every
+// broadcast, including the recipient's own, under "broadcast/<sender>" and every
+// payload addressed to the recipient under "direct/<sender>".
+func Route(recipient uint32, outputs map[uint32]*Message) (*Message, error) {
+	var input *Message
+	for sender, output := range outputs {
+		if output == nil {
+			continue
+		}
+		if input == nil {
+			input = &Message{
+				Protocol: output.Protocol,
+				Version:  output.Version,
+				Payloads: make(map[string][]byte, len(outputs)),
+				Metadata: make(map[string]string, len(output.Metadata)),
+			}
+			for k, v := range output.Metadata {
+				input.Metadata[k] = v
+			}
+		}
+		if output.Protocol != input.Protocol || output.Version != input.Version {
+			return nil, fmt.Errorf("participant %d sent a message of %s version %d, expected %s version %d",
+				sender, output.Protocol, output.Version, input.Protocol, input.Version)
+		}
+		if output.Metadata[RoundKey] != input.Metadata[RoundKey] {
+			return nil, fmt.Errorf("participant %d sent a message of another round", sender)
+		}
+		if payload, ok := output.Payloads[BroadcastKey]; ok {
+			input.Payloads[broadcastPrefix+RecipientKey(sender)] = payload
+		}
+		if payload, ok := output.Payloads[RecipientKey(recipient)]; ok && sender != recipient {
+			input.Payloads[directPrefix+RecipientKey(sender)] = payload
+		}
+	}
+	if input == nil {
+		return nil, fmt.Errorf("no messages to route")
+	}
+	return input, nil
+}
+
+// Inbound splits an input built by Route into the broadcast and direct payloads keyed by sender.
+func Inbound(input *Message) (broadcasts, direct map[uint32][]byte, err error) {
+	if input == nil {
+		return nil, nil, fmt.Errorf("message cannot be nil")
+	}
+	broadcasts = make(map[uint32][]byte, len(input.Payloads))
+	direct = make(map[uint32][]byte, len(input.Payloads))
+	for key, payload := range input.Payloads {
+		var target map[uint32][]byte
+		switch {
+		case strings.HasPrefix(key, broadcastPrefix):
+			target = broadcasts
+			key = strings.TrimPrefix(key, broadcastPrefix)
+		case strings.HasPrefix(key, directPrefix):
+			target = direct
+			key = strings.TrimPrefix(key, directPrefix)
+		default:
+			return nil, nil, fmt.Errorf("invalid payload key %s", key)
+		}
+		sender, err := strconv.ParseUint(key, 10, 32)
+		if err != nil || sender == 0 {
+			return nil, nil, fmt.Errorf("invalid sender id %s", key)
+		}
+		target[uint32(sender)] = payload
+	}
+	return broadcasts, direct, nil
+}
+
+// NegotiateVersion returns the highest version supported by every participant.
+// Each argument is the list of versions supported by one participant.
+func NegotiateVersion(supported ...[]uint) (uint, error) {
+	if len(supported) == 0 {
+		return 0, fmt.Errorf("no versions to negotiate")
+	}
+	var best uint
+	for _, v := range supported[0] {
+		common := true
+		for _, other := range supported[1:] {
+			if !containsVersion(other, v) {
+				common = false
+				break
+			}
+		}
+		if common && v > best {
+			best = v
+		}
+	}
+	if best == 0 {
+		return 0, fmt.Errorf("participants do not support a common version")
+	}
+	return best, nil
+}
+
+func containsVersion(versions []uint, version uint) bool {
+	for _, v := range versions {
+		if v == version {
+			return true
+		}
+	}
+	return false
+}
+
+// Stepper implements Next by running a fixed list of steps in order.
+type Stepper struct {
+	Steps []func(input *Message) (*Message, error)
+	step  int
+}
+
+// Next runs the next step and only moves on if it succeeds.
+func (s *Stepper) Next(input *Message) (*Message, error) {
+	if s.Complete() {
+		return nil, ErrProtocolFinished
+	}
+	output, err := s.Steps[s.step](input)
+	if err != nil {
+		return nil, err
+	}
+	s.step++
+	return output, nil
+}
+
+// Complete reports whether all the steps have run.
+func (s *Stepper) Complete() bool {
+	return s.step >= len(s.Steps)
+}
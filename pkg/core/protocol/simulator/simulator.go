@@ -0,0 +1,452 @@
+//
+// Copyright Coinbase, Inc. All Rights Reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+// Package simulator runs the parties of a protocol.Iterator based protocol in one process.
+// Each party runs in its own goroutine and exchanges messages with the others over a
+// simulated network that can delay, drop, reorder and duplicate messages. A byzantine
+// party can be simulated by tampering with its outgoing messages.
+package simulator
+
+import (
+	"fmt"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/nerifnetwork/kryptology/pkg/core/protocol"
+)
+
+const (
+	defaultTimeout  = time.Second
+	defaultMaxSteps = 64
+)
+
+// Router builds the input of the recipient from the outputs of one step, keyed by sender.
+type Router func(recipient uint32, outputs map[uint32]*protocol.Message) (*protocol.Message, error)
+
+// Tamper changes the copy of the output of a byzantine party for a single recipient.
+// Step is the index of the call to Next that produced the output. Returning nil withholds
+// the message from the recipient, returning a different message to each recipient equivocates.
+type Tamper func(step int, recipient uint32, output *protocol.Message) *protocol.Message
+
+// Party is a participant of the simulated protocol
+type Party struct {
+	Id       uint32
+	Iterator protocol.Iterator
+	// Responder parties take the outputs of the same step of the other parties as input
+	// instead of the outputs of the previous step. This is the second party of the
+	// two-party protocols in which the messages alternate between the parties.
+	Responder bool
+}
+
+// Config describes the behaviour of the simulated network. The zero value is a
+// reliable network that routes the messages with protocol.Route.
+type Config struct {
+	// Router builds the input of every step, protocol.Route if nil
+	Router Router
+	// MinDelay and MaxDelay bound the delay of every message
+	MinDelay, MaxDelay time.Duration
+	// Drop is the probability that a message is lost
+	Drop float64
+	// Duplicate is the probability that a message is delivered twice
+	Duplicate float64
+	// Reorder is the probability that a message is held back until the next message of the
+	// sender to the same recipient, which usually belongs to a later step, has been delivered.
+	// A held message is released after half the timeout if the sender sends nothing else.
+	Reorder float64
+	// Byzantine tampers with the outputs of the parties it lists
+	Byzantine map[uint32]Tamper
+	// Timeout is how long a party waits for the messages of a step
+	// before it goes on with the messages it has, one second if zero
+	Timeout time.Duration
+	// MaxSteps is the number of steps after which a party is stopped, 64 if zero
+	MaxSteps int
+	// Seed seeds the random choices of the network
+	Seed int64
+}
+
+// Outcome is how a party left the protocol
+type Outcome struct {
+	// Err is nil if the party finished the protocol, otherwise the error that stopped it
+	Err error
+	// Steps is the number of steps the party completed
+	Steps int
+}
+
+// Network is a simulated network that runs the parties of a protocol
+type Network struct {
+	config Config
+	router Router
+	mu     sync.Mutex
+	rng    *rand.Rand
+	// held are the messages held back on every link
+	held map[link][]*envelope
+	// overtaken counts the held messages that were delivered after a message of a later step
+	overtaken int
+}
+
+// link is the direction of the messages from a sender to a recipient
+type link struct {
+	sender, recipient uint32
+}
+
+// envelope carries the output of a step of the sender. A nil message means the sender
+// has no output for the step. When done is set the sender stopped before the step.
+type envelope struct {
+	sender uint32
+	step   int
+	msg    *protocol.Message
+	done   bool
+}
+
+// mailbox is the unbounded inbox of a party
+type mailbox struct {
+	mu     sync.Mutex
+	queue  []*envelope
+	signal chan struct{}
+}
+
+func newMailbox() *mailbox {
+	return &mailbox{signal: make(chan struct{}, 1)}
+}
+
+func (m *mailbox) put(e *envelope) {
+	m.mu.Lock()
+	m.queue = append(m.queue, e)
+	m.mu.Unlock()
+	select {
+	case m.signal <- struct{}{}:
+	default:
+	}
+}
+
+func (m *mailbox) take() []*envelope {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	queue := m.queue
+	m.queue = nil
+	return queue
+}
+
+// NewNetwork creates a simulated network with the config
+func NewNetwork(config Config) (*Network, error) {
+	for _, p := range []float64{config.Drop, config.Duplicate, config.Reorder} {
+		if p < 0 || p > 1 {
+			return nil, fmt.Errorf("probabilities must be between 0 and 1")
+		}
+	}
+	if config.MinDelay < 0 || config.MaxDelay < config.MinDelay {
+		return nil, fmt.Errorf("invalid delay bounds")
+	}
+	if config.Timeout < 0 || config.MaxSteps < 0 {
+		return nil, fmt.Errorf("timeout and max steps cannot be negative")
+	}
+	if config.Timeout == 0 {
+		config.Timeout = defaultTimeout
+	}
+	if config.MaxSteps == 0 {
+		config.MaxSteps = defaultMaxSteps
+	}
+	router := config.Router
+	if router == nil {
+		router = protocol.Route
+	}
+	return &Network{
+		config: config,
+		router: router,
+		rng:    rand.New(rand.NewSource(config.Seed)),
+		held:   make(map[link][]*envelope),
+	}, nil
+}
+
+// Run runs the parties until every one of them has finished or failed and returns their outcomes.
+// The results of the parties are read from their iterators afterwards.
+func (n *Network) Run(parties ...*Party) (map[uint32]*Outcome, error) {
+	if len(parties) < 2 {
+		return nil, fmt.Errorf("at least two parties are required")
+	}
+	boxes := make(map[uint32]*mailbox, len(parties))
+	for _, p := range parties {
+		if p == nil || p.Iterator == nil {
+			return nil, fmt.Errorf("party cannot be nil")
+		}
+		if _, ok := boxes[p.Id]; ok {
+			return nil, fmt.Errorf("duplicate party id %d", p.Id)
+		}
+		boxes[p.Id] = newMailbox()
+	}
+
+	outcomes := make(map[uint32]*Outcome, len(parties))
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	wg.Add(len(parties))
+	for _, p := range parties {
+		go func(p *Party) {
+			defer wg.Done()
+			outcome := n.runParty(p, boxes)
+			mu.Lock()
+			outcomes[p.Id] = outcome
+			mu.Unlock()
+		}(p)
+	}
+	wg.Wait()
+	return outcomes, nil
+}
+
+// runParty is the loop of a single party. Before step k a party collects the outputs
+// of step k-1 from every other party, or of step k for a responder, and routes them
+// together with its own output of that step.
+func (n *Network) runParty(p *Party, boxes map[uint32]*mailbox) *Outcome {
+	var (
+		own      = make(map[int]*protocol.Message)
+		received = make(map[int]map[uint32]*protocol.Message)
+		stopped  = make(map[uint32]int)
+		step     int
+		err      error
+	)
+	box := boxes[p.Id]
+	for ; step < n.config.MaxSteps; step++ {
+		var input *protocol.Message
+		source := step - 1
+		if p.Responder {
+			source = step
+		}
+		if source >= 0 {
+			n.collect(p.Id, box, boxes, source, received, stopped)
+			outputs := make(map[uint32]*protocol.Message, len(boxes))
+			for sender, msg := range received[source] {
+				if msg != nil {
+					outputs[sender] = msg
+				}
+			}
+			if msg := own[source]; msg != nil {
+				outputs[p.Id] = msg
+			}
+			delete(received, source)
+			if len(outputs) > 0 {
+				if input, err = n.router(p.Id, outputs); err != nil {
+					break
+				}
+			}
+		}
+
+		var output *protocol.Message
+		if output, err = next(p.Iterator, input); err != nil {
+			break
+		}
+		own[step] = output
+		n.send(p.Id, step, output, boxes)
+	}
+	if err == nil {
+		err = fmt.Errorf("party %d did not finish after %d steps", p.Id, step)
+	}
+	for id, b := range boxes {
+		if id != p.Id {
+			n.deliver(link{p.Id, id}, b, &envelope{sender: p.Id, step: step, done: true})
+		}
+	}
+	if err == protocol.ErrProtocolFinished {
+		err = nil
+	}
+	return &Outcome{Err: err, Steps: step}
+}
+
+// collect waits until every other party has delivered its output of the step,
+// has stopped before it, or until the timeout expires
+func (n *Network) collect(id uint32, box *mailbox, boxes map[uint32]*mailbox, step int, received map[int]map[uint32]*protocol.Message, stopped map[uint32]int) {
+	timer := time.NewTimer(n.config.Timeout)
+	defer timer.Stop()
+	for {
+		for _, e := range box.take() {
+			if e.done {
+				stopped[e.sender] = e.step
+				continue
+			}
+			// Stale and duplicate messages are ignored
+			if e.step < step {
+				continue
+			}
+			if received[e.step] == nil {
+				received[e.step] = make(map[uint32]*protocol.Message)
+			}
+			if _, ok := received[e.step][e.sender]; !ok {
+				received[e.step][e.sender] = e.msg
+			}
+		}
+		complete := true
+		for sender := range boxes {
+			if sender == id {
+				continue
+			}
+			if _, ok := received[step][sender]; ok {
+				continue
+			}
+			if last, ok := stopped[sender]; ok && last <= step {
+				continue
+			}
+			complete = false
+			break
+		}
+		if complete {
+			return
+		}
+		select {
+		case <-box.signal:
+		case <-timer.C:
+			return
+		}
+	}
+}
+
+// send delivers a copy of the output to every other party through the faults of the network
+func (n *Network) send(sender uint32, step int, output *protocol.Message, boxes map[uint32]*mailbox) {
+	tamper := n.config.Byzantine[sender]
+	for recipient, box := range boxes {
+		if recipient == sender {
+			continue
+		}
+		if output == nil {
+			n.deliver(link{sender, recipient}, box, &envelope{sender: sender, step: step})
+			continue
+		}
+		msg := clone(output)
+		if tamper != nil {
+			if msg = tamper(step, recipient, msg); msg == nil {
+				continue
+			}
+		}
+		if n.chance(n.config.Drop) {
+			continue
+		}
+		copies := 1
+		if n.chance(n.config.Duplicate) {
+			copies++
+		}
+		for i := 0; i < copies; i++ {
+			n.deliver(link{sender, recipient}, box, &envelope{sender: sender, step: step, msg: clone(msg)})
+		}
+	}
+}
+
+// deliver puts the envelope in the mailbox of the recipient after a random delay. A message
+// may be held back instead, in which case it follows the next envelope sent on the link.
+// Envelopes without a message are not delayed but still release the held messages.
+func (n *Network) deliver(l link, box *mailbox, e *envelope) {
+	if e.msg != nil && n.chance(n.config.Reorder) {
+		n.mu.Lock()
+		n.held[l] = append(n.held[l], e)
+		n.mu.Unlock()
+		time.AfterFunc(n.config.Timeout/2, func() { n.release(l, box, e) })
+		return
+	}
+
+	n.mu.Lock()
+	batch := append([]*envelope{e}, n.held[l]...)
+	for _, h := range n.held[l] {
+		if h.step < e.step {
+			n.overtaken++
+		}
+	}
+	delete(n.held, l)
+	n.mu.Unlock()
+
+	var delay time.Duration
+	if e.msg != nil {
+		delay = n.delay()
+	}
+	if delay == 0 {
+		for _, b := range batch {
+			box.put(b)
+		}
+		return
+	}
+	time.AfterFunc(delay, func() {
+		for _, b := range batch {
+			box.put(b)
+		}
+	})
+}
+
+// release delivers a held message whose sender sent nothing else on the link
+func (n *Network) release(l link, box *mailbox, e *envelope) {
+	n.mu.Lock()
+	held := n.held[l]
+	found := false
+	for i, h := range held {
+		if h == e {
+			n.held[l] = append(held[:i:i], held[i+1:]...)
+			found = true
+			break
+		}
+	}
+	if len(n.held[l]) == 0 {
+		delete(n.held, l)
+	}
+	n.mu.Unlock()
+	if found {
+		box.put(e)
+	}
+}
+
+func (n *Network) chance(p float64) bool {
+	if p == 0 {
+		return false
+	}
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return n.rng.Float64() < p
+}
+
+func (n *Network) delay() time.Duration {
+	spread := n.config.MaxDelay - n.config.MinDelay
+	if spread == 0 {
+		return n.config.MinDelay
+	}
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return n.config.MinDelay + time.Duration(n.rng.Int63n(int64(spread)+1))
+}
+
+// next runs the next step of the iterator and reports a panic as an error
+func next(it protocol.Iterator, input *protocol.Message) (output *protocol.Message, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			output, err = nil, fmt.Errorf("panic: %v", r)
+		}
+	}()
+	return it.Next(input)
+}
+
+// Forward is the Router of two-party protocols whose outputs go unchanged to the other party
+func Forward(recipient uint32, outputs map[uint32]*protocol.Message) (*protocol.Message, error) {
+	var input *protocol.Message
+	for sender, output := range outputs {
+		if sender == recipient {
+			continue
+		}
+		if input != nil {
+			return nil, fmt.Errorf("forward expects a single other party")
+		}
+		input = output
+	}
+	return input, nil
+}
+
+func clone(msg *protocol.Message) *protocol.Message {
+	c := &protocol.Message{
+		Protocol: msg.Protocol,
+		Version:  msg.Version,
+		Payloads: make(map[string][]byte, len(msg.Payloads)),
+		Metadata: make(map[string]string, len(msg.Metadata)),
+	}
+	for k, v := range msg.Payloads {
+		c.Payloads[k] = append([]byte(nil), v...)
+	}
+	for k, v := range msg.Metadata {
+		c.Metadata[k] = v
+	}
+	return c
+}
@@ -0,0 +1,329 @@
+//
+// Copyright Coinbase, Inc. All Rights Reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package simulator
+
+import (
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/sha3"
+
+	"github.com/nerifnetwork/kryptology/pkg/core/curves"
+	"github.com/nerifnetwork/kryptology/pkg/core/protocol"
+	dkg "github.com/nerifnetwork/kryptology/pkg/dkg/frost"
+	dkls "github.com/nerifnetwork/kryptology/pkg/tecdsa/dkls/v1"
+	"github.com/nerifnetwork/kryptology/pkg/ted25519/frost"
+)
+
+const ctx = "simulator test"
+
+func newDkgParties(t *testing.T, threshold, n uint32) ([]*Party, map[uint32]*dkg.DkgIterator) {
+	parties := make([]*Party, 0, n)
+	iterators := make(map[uint32]*dkg.DkgIterator, n)
+	for i := uint32(1); i <= n; i++ {
+		var others []uint32
+		for j := uint32(1); j <= n; j++ {
+			if j != i {
+				others = append(others, j)
+			}
+		}
+		it, err := dkg.NewDkgIterator(i, threshold, ctx, curves.ED25519(), protocol.Version1, others...)
+		require.NoError(t, err)
+		iterators[i] = it
+		parties = append(parties, &Party{Id: i, Iterator: it})
+	}
+	return parties, iterators
+}
+
+func run(t *testing.T, config Config, parties ...*Party) map[uint32]*Outcome {
+	network, err := NewNetwork(config)
+	require.NoError(t, err)
+	outcomes, err := network.Run(parties...)
+	require.NoError(t, err)
+	require.Len(t, outcomes, len(parties))
+	return outcomes
+}
+
+func requireSameKey(t *testing.T, iterators map[uint32]*dkg.DkgIterator, ids ...uint32) *dkg.DkgResult {
+	var first *dkg.DkgResult
+	for _, id := range ids {
+		msg, err := iterators[id].Result(protocol.Version1)
+		require.NoError(t, err)
+		result, err := dkg.DecodeDkgResult(msg)
+		require.NoError(t, err)
+		if first == nil {
+			first = result
+		}
+		require.True(t, first.VerificationKey.Equal(result.VerificationKey))
+	}
+	return first
+}
+
+func TestFrostDkgReliableNetwork(t *testing.T) {
+	parties, iterators := newDkgParties(t, 3, 5)
+	outcomes := run(t, Config{}, parties...)
+	for id, outcome := range outcomes {
+		require.NoError(t, outcome.Err, "participant %d", id)
+		require.Equal(t, 4, outcome.Steps)
+	}
+	requireSameKey(t, iterators, 1, 2, 3, 4, 5)
+}
+
+func TestFrostDkgUnreliableNetwork(t *testing.T) {
+	parties, iterators := newDkgParties(t, 3, 5)
+	network, err := NewNetwork(Config{
+		MaxDelay:  5 * time.Millisecond,
+		Duplicate: 0.3,
+		Reorder:   0.3,
+		Seed:      7,
+	})
+	require.NoError(t, err)
+	outcomes, err := network.Run(parties...)
+	require.NoError(t, err)
+	for id, outcome := range outcomes {
+		require.NoError(t, outcome.Err, "participant %d", id)
+	}
+	requireSameKey(t, iterators, 1, 2, 3, 4, 5)
+	// Some messages of a round arrived after messages of the next round
+	require.Greater(t, network.overtaken, 0)
+}
+
+func TestReorderedMessageFollowsNextMessage(t *testing.T) {
+	network, err := NewNetwork(Config{Reorder: 1})
+	require.NoError(t, err)
+	box := newMailbox()
+	l := link{1, 2}
+	network.deliver(l, box, &envelope{sender: 1, step: 0, msg: &protocol.Message{}})
+	require.Empty(t, box.take())
+
+	network.config.Reorder = 0
+	network.deliver(l, box, &envelope{sender: 1, step: 1, msg: &protocol.Message{}})
+	delivered := box.take()
+	require.Len(t, delivered, 2)
+	require.Equal(t, 1, delivered[0].step)
+	require.Equal(t, 0, delivered[1].step)
+	require.Equal(t, 1, network.overtaken)
+}
+
+func TestHeldMessageIsReleased(t *testing.T) {
+	network, err := NewNetwork(Config{Reorder: 1, Timeout: 20 * time.Millisecond})
+	require.NoError(t, err)
+	box := newMailbox()
+	network.deliver(link{1, 2}, box, &envelope{sender: 1, step: 0, msg: &protocol.Message{}})
+	require.Empty(t, box.take())
+	<-box.signal
+	delivered := box.take()
+	require.Len(t, delivered, 1)
+	require.Equal(t, 0, delivered[0].step)
+	require.Zero(t, network.overtaken)
+}
+
+func TestFrostDkgSilentDealerDisqualified(t *testing.T) {
+	parties, iterators := newDkgParties(t, 3, 5)
+	outcomes := run(t, Config{
+		Timeout: 100 * time.Millisecond,
+		Byzantine: map[uint32]Tamper{
+			// Participant 4 withholds its share to 1 and then ignores the complaint
+			4: func(step int, recipient uint32, output *protocol.Message) *protocol.Message {
+				switch step {
+				case 0:
+					delete(output.Payloads, protocol.RecipientKey(1))
+				case 2:
+					return nil
+				}
+				return output
+			},
+		},
+	}, parties...)
+	honest := []uint32{1, 2, 3, 5}
+	for _, id := range honest {
+		require.NoError(t, outcomes[id].Err, "participant %d", id)
+		require.Equal(t, []uint32{4}, iterators[id].Disqualified())
+	}
+	requireSameKey(t, iterators, honest...)
+}
+
+func TestFrostDkgCorruptedPayloadDisqualified(t *testing.T) {
+	parties, iterators := newDkgParties(t, 2, 3)
+	outcomes := run(t, Config{
+		Byzantine: map[uint32]Tamper{
+			2: func(step int, recipient uint32, output *protocol.Message) *protocol.Message {
+				if step == 0 {
+					output.Payloads[protocol.BroadcastKey] = []byte{1, 2, 3}
+				}
+				return output
+			},
+		},
+	}, parties...)
+	for _, id := range []uint32{1, 3} {
+		require.NoError(t, outcomes[id].Err, "participant %d", id)
+		require.Equal(t, []uint32{2}, iterators[id].Disqualified())
+	}
+	requireSameKey(t, iterators, 1, 3)
+}
+
+func TestFrostDkgCorruptedShareComplaint(t *testing.T) {
+	parties, iterators := newDkgParties(t, 2, 3)
+	outcomes := run(t, Config{
+		Byzantine: map[uint32]Tamper{
+			// Participant 2 garbles its share to 1 and then answers the complaint with the valid share
+			2: func(step int, recipient uint32, output *protocol.Message) *protocol.Message {
+				if step == 0 && recipient == 1 {
+					output.Payloads[protocol.RecipientKey(1)] = []byte{1, 2, 3}
+				}
+				return output
+			},
+		},
+	}, parties...)
+	for id, outcome := range outcomes {
+		require.NoError(t, outcome.Err, "participant %d", id)
+		require.Empty(t, iterators[id].Disqualified())
+	}
+	requireSameKey(t, iterators, 1, 2, 3)
+}
+
+func TestFrostDkgDroppedMessagesAbort(t *testing.T) {
+	parties, _ := newDkgParties(t, 2, 3)
+	outcomes := run(t, Config{Drop: 1, Timeout: 20 * time.Millisecond}, parties...)
+	for id, outcome := range outcomes {
+		require.Error(t, outcome.Err, "participant %d", id)
+	}
+}
+
+func TestFrostSign(t *testing.T) {
+	parties, iterators := newDkgParties(t, 2, 3)
+	outcomes := run(t, Config{MaxDelay: 2 * time.Millisecond}, parties...)
+	for _, outcome := range outcomes {
+		require.NoError(t, outcome.Err)
+	}
+	vk := requireSameKey(t, iterators, 1, 2, 3).VerificationKey
+
+	msg := []byte("message")
+	cosigners := []uint32{1, 3}
+	signers := make([]*Party, 0, len(cosigners))
+	for _, id := range cosigners {
+		result, err := iterators[id].Result(protocol.Version1)
+		require.NoError(t, err)
+		signer, err := frost.NewSignerIterator(result, cosigners, msg, &frost.Ed25519ChallengeDeriver{}, protocol.Version1)
+		require.NoError(t, err)
+		signers = append(signers, &Party{Id: id, Iterator: signer})
+	}
+	outcomes = run(t, Config{MaxDelay: 2 * time.Millisecond, Duplicate: 0.5, Seed: 3}, signers...)
+	for _, signer := range signers {
+		require.NoError(t, outcomes[signer.Id].Err)
+		result, err := signer.Iterator.Result(protocol.Version1)
+		require.NoError(t, err)
+		signature, err := frost.DecodeSignResult(result)
+		require.NoError(t, err)
+		ok, err := frost.Verify(curves.ED25519(), &frost.Ed25519ChallengeDeriver{}, vk, msg, &frost.Signature{Z: signature.Z, C: signature.C})
+		require.NoError(t, err)
+		require.True(t, ok)
+	}
+}
+
+func TestDklsDkgAndSign(t *testing.T) {
+	curve := curves.K256()
+	alice := dkls.NewAliceDkg(curve, protocol.Version1)
+	bob := dkls.NewBobDkg(curve, protocol.Version1)
+	config := Config{Router: Forward, MaxDelay: 2 * time.Millisecond, Duplicate: 0.3}
+	outcomes := run(t, config,
+		&Party{Id: 1, Iterator: alice, Responder: true},
+		&Party{Id: 2, Iterator: bob},
+	)
+	require.NoError(t, outcomes[1].Err)
+	require.NoError(t, outcomes[2].Err)
+
+	aliceResult, err := alice.Result(protocol.Version1)
+	require.NoError(t, err)
+	bobResult, err := bob.Result(protocol.Version1)
+	require.NoError(t, err)
+
+	msg := []byte("message")
+	aliceSign, err := dkls.NewAliceSign(curve, sha3.New256(), msg, aliceResult, protocol.Version1)
+	require.NoError(t, err)
+	bobSign, err := dkls.NewBobSign(curve, sha3.New256(), msg, bobResult, protocol.Version1)
+	require.NoError(t, err)
+	outcomes = run(t, config,
+		&Party{Id: 1, Iterator: aliceSign},
+		&Party{Id: 2, Iterator: bobSign, Responder: true},
+	)
+	require.NoError(t, outcomes[1].Err)
+	require.NoError(t, outcomes[2].Err)
+
+	result, err := bobSign.Result(protocol.Version1)
+	require.NoError(t, err)
+	signature, err := dkls.DecodeSignature(result)
+	require.NoError(t, err)
+
+	digest := sha3.Sum256(msg)
+	pk := alice.Output().PublicKey.ToAffineUncompressed()
+	ecCurve, err := curve.ToEllipticCurve()
+	require.NoError(t, err)
+	publicKey := &curves.EcPoint{
+		Curve: ecCurve,
+		X:     new(big.Int).SetBytes(pk[1:33]),
+		Y:     new(big.Int).SetBytes(pk[33:]),
+	}
+	require.True(t, curves.VerifyEcdsa(publicKey, digest[:], signature))
+}
+
+func TestDklsTamperedMessageAborts(t *testing.T) {
+	curve := curves.K256()
+	outcomes := run(t, Config{
+		Router: Forward,
+		Byzantine: map[uint32]Tamper{
+			2: func(step int, recipient uint32, output *protocol.Message) *protocol.Message {
+				for k := range output.Payloads {
+					output.Payloads[k] = []byte{0}
+				}
+				return output
+			},
+		},
+	},
+		&Party{Id: 1, Iterator: dkls.NewAliceDkg(curve, protocol.Version1), Responder: true},
+		&Party{Id: 2, Iterator: dkls.NewBobDkg(curve, protocol.Version1)},
+	)
+	require.Error(t, outcomes[1].Err)
+	require.Equal(t, 0, outcomes[1].Steps)
+}
+
+func TestInvalidConfig(t *testing.T) {
+	_, err := NewNetwork(Config{Drop: 2})
+	require.Error(t, err)
+	_, err = NewNetwork(Config{MinDelay: time.Second})
+	require.Error(t, err)
+	_, err = NewNetwork(Config{Timeout: -1})
+	require.Error(t, err)
+
+	network, err := NewNetwork(Config{})
+	require.NoError(t, err)
+	it := dkls.NewBobDkg(curves.K256(), protocol.Version1)
+	_, err = network.Run(&Party{Id: 1, Iterator: it})
+	require.Error(t, err)
+	_, err = network.Run(&Party{Id: 1, Iterator: it}, &Party{Id: 1, Iterator: it})
+	require.Error(t, err)
+	_, err = network.Run(&Party{Id: 1, Iterator: it}, nil)
+	require.Error(t, err)
+}
+
+type panicIterator struct{}
+
+func (panicIterator) Next(*protocol.Message) (*protocol.Message, error) { panic("bad input") }
+
+func (panicIterator) Result(uint) (*protocol.Message, error) { return nil, nil }
+
+func TestPanicIsReported(t *testing.T) {
+	outcomes := run(t, Config{Router: Forward, Timeout: 20 * time.Millisecond},
+		&Party{Id: 1, Iterator: panicIterator{}},
+		&Party{Id: 2, Iterator: dkls.NewBobDkg(curves.K256(), protocol.Version1)},
+	)
+	require.Error(t, outcomes[1].Err)
+	require.Contains(t, outcomes[1].Err.Error(), "bad input")
+	require.Error(t, outcomes[2].Err)
+}
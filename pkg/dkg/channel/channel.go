@@ -0,0 +1,243 @@
+//
+// Copyright Coinbase, Inc. All Rights Reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+// Package channel encrypts the point to point messages of a DKG so they can be
+// delivered through an untrusted relay or a broadcast bus.
+//
+// Every participant has a static key pair and knows the public keys of the others.
+// A message is encrypted like HPKE in auth mode: the sender combines a Diffie-Hellman
+// exchange between a fresh ephemeral key and the receiver's static key with one
+// between its own static key and the receiver's static key, derives an AES-GCM key
+// with HKDF-SHA256 and seals the message. The session id, the sender and receiver
+// ids and all public keys are bound to the key and authenticated as associated data,
+// so an envelope can't be replayed in another session or attributed to another sender.
+//
+// - https://www.rfc-editor.org/rfc/rfc9180.html
+package channel
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"sort"
+
+	"golang.org/x/crypto/hkdf"
+
+	"github.com/nerifnetwork/kryptology/internal"
+	"github.com/nerifnetwork/kryptology/pkg/core/curves"
+)
+
+const domainSeparator = "kryptology dkg channel v1"
+
+// StaticKey is a participant's long term key pair
+type StaticKey struct {
+	Secret curves.Scalar
+	Public curves.Point
+}
+
+// NewStaticKey creates a random key pair on the curve
+func NewStaticKey(curve *curves.Curve, reader io.Reader) (*StaticKey, error) {
+	if curve == nil || reader == nil {
+		return nil, internal.ErrNilArguments
+	}
+	secret := curve.Scalar.Random(reader)
+	if secret.IsZero() {
+		return nil, internal.ErrZeroValue
+	}
+	return &StaticKey{
+		Secret: secret,
+		Public: curve.ScalarBaseMult(secret),
+	}, nil
+}
+
+// Channel seals messages from one participant to the others in a single session
+type Channel struct {
+	sessionId []byte
+	id        uint32
+	key       *StaticKey
+	peers     map[uint32]curves.Point
+}
+
+// NewChannel creates the channel of participant id for a session.
+// peers holds the static public keys of the other participants.
+// The session id must be unique to the DKG run and agreed on by all participants.
+func NewChannel(sessionId []byte, id uint32, key *StaticKey, peers map[uint32]curves.Point) (*Channel, error) {
+	if len(sessionId) == 0 || key == nil || key.Secret == nil || key.Public == nil || len(peers) == 0 {
+		return nil, internal.ErrNilArguments
+	}
+	if id == 0 {
+		return nil, fmt.Errorf("invalid participant id")
+	}
+	if !key.Public.Equal(key.Public.Generator().Mul(key.Secret)) {
+		return nil, fmt.Errorf("public key does not match the secret key")
+	}
+	curveName := key.Public.CurveName()
+	keys := make(map[uint32]curves.Point, len(peers))
+	for peer, pk := range peers {
+		if peer == id {
+			continue
+		}
+		if peer == 0 {
+			return nil, fmt.Errorf("invalid participant id")
+		}
+		if err := checkPoint(pk, curveName); err != nil {
+			return nil, fmt.Errorf("invalid public key for participant %d: %v", peer, err)
+		}
+		keys[peer] = pk
+	}
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("channel has no peers")
+	}
+	sid := make([]byte, len(sessionId))
+	copy(sid, sessionId)
+	return &Channel{
+		sessionId: sid,
+		id:        id,
+		key:       key,
+		peers:     keys,
+	}, nil
+}
+
+// Seal encrypts the plaintext to the receiver
+func (c Channel) Seal(receiver uint32, plaintext []byte, reader io.Reader) (*Envelope, error) {
+	if reader == nil {
+		return nil, internal.ErrNilArguments
+	}
+	pk, ok := c.peers[receiver]
+	if !ok {
+		return nil, fmt.Errorf("unknown receiver %d", receiver)
+	}
+	curve := curves.GetCurveByName(pk.CurveName())
+	e := curve.Scalar.Random(reader)
+	if e.IsZero() {
+		return nil, internal.ErrZeroValue
+	}
+	ephemeral := curve.ScalarBaseMult(e)
+	aead, aad, err := c.aead(c.id, receiver, ephemeral, c.key.Public, pk, pk.Mul(e), pk.Mul(c.key.Secret))
+	if err != nil {
+		return nil, err
+	}
+	return &Envelope{
+		Sender:     c.id,
+		Receiver:   receiver,
+		Ephemeral:  ephemeral,
+		Ciphertext: aead.Seal(nil, make([]byte, aead.NonceSize()), plaintext, aad),
+	}, nil
+}
+
+// Open decrypts an envelope sent to this participant and checks it came from its claimed sender
+func (c Channel) Open(envelope *Envelope) ([]byte, error) {
+	if envelope == nil || envelope.Ephemeral == nil {
+		return nil, internal.ErrNilArguments
+	}
+	if envelope.Receiver != c.id {
+		return nil, fmt.Errorf("envelope is for participant %d", envelope.Receiver)
+	}
+	pk, ok := c.peers[envelope.Sender]
+	if !ok {
+		return nil, fmt.Errorf("unknown sender %d", envelope.Sender)
+	}
+	if err := checkPoint(envelope.Ephemeral, pk.CurveName()); err != nil {
+		return nil, fmt.Errorf("invalid ephemeral key: %v", err)
+	}
+	aead, aad, err := c.aead(envelope.Sender, c.id, envelope.Ephemeral, pk, c.key.Public,
+		envelope.Ephemeral.Mul(c.key.Secret), pk.Mul(c.key.Secret))
+	if err != nil {
+		return nil, err
+	}
+	plaintext, err := aead.Open(nil, make([]byte, aead.NonceSize()), envelope.Ciphertext, aad)
+	if err != nil {
+		return nil, fmt.Errorf("unable to decrypt message from participant %d", envelope.Sender)
+	}
+	return plaintext, nil
+}
+
+// SealAll encrypts each message to the participant it is keyed by
+func (c Channel) SealAll(messages map[uint32][]byte, reader io.Reader) (map[uint32]*Envelope, error) {
+	envelopes := make(map[uint32]*Envelope, len(messages))
+	for receiver, msg := range messages {
+		envelope, err := c.Seal(receiver, msg, reader)
+		if err != nil {
+			return nil, err
+		}
+		envelopes[receiver] = envelope
+	}
+	return envelopes, nil
+}
+
+// OpenAll decrypts envelopes keyed by the participant that sent them.
+// It also returns the sorted senders whose envelope could not be opened,
+// so the caller can complain about them instead of aborting.
+func (c Channel) OpenAll(envelopes map[uint32]*Envelope) (map[uint32][]byte, []uint32) {
+	messages := make(map[uint32][]byte, len(envelopes))
+	var failed []uint32
+	for sender, envelope := range envelopes {
+		if envelope == nil || envelope.Sender != sender {
+			failed = append(failed, sender)
+			continue
+		}
+		msg, err := c.Open(envelope)
+		if err != nil {
+			failed = append(failed, sender)
+			continue
+		}
+		messages[sender] = msg
+	}
+	sort.Slice(failed, func(i, j int) bool { return failed[i] < failed[j] })
+	return messages, failed
+}
+
+// aead derives the key of one envelope and returns the cipher with the associated data
+// context = separator || len(sid) || sid || sender || receiver || E || pk_S || pk_R
+// key = HKDF-SHA256(dh_1 || dh_2, context)
+func (c Channel) aead(sender, receiver uint32, ephemeral, senderKey, receiverKey, dh1, dh2 curves.Point) (cipher.AEAD, []byte, error) {
+	if dh1.IsIdentity() || dh2.IsIdentity() {
+		return nil, nil, fmt.Errorf("invalid shared secret")
+	}
+	var buf [4]byte
+	context := []byte(domainSeparator)
+	binary.BigEndian.PutUint32(buf[:], uint32(len(c.sessionId)))
+	context = append(context, buf[:]...)
+	context = append(context, c.sessionId...)
+	binary.BigEndian.PutUint32(buf[:], sender)
+	context = append(context, buf[:]...)
+	binary.BigEndian.PutUint32(buf[:], receiver)
+	context = append(context, buf[:]...)
+	context = append(context, ephemeral.ToAffineCompressed()...)
+	context = append(context, senderKey.ToAffineCompressed()...)
+	context = append(context, receiverKey.ToAffineCompressed()...)
+
+	secret := append(dh1.ToAffineCompressed(), dh2.ToAffineCompressed()...)
+	key := make([]byte, 32)
+	if _, err := io.ReadFull(hkdf.New(sha256.New, secret, nil, context), key); err != nil {
+		return nil, nil, err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, nil, err
+	}
+	return aead, context, nil
+}
+
+func checkPoint(p curves.Point, curveName string) error {
+	if p == nil {
+		return internal.ErrNilArguments
+	}
+	if p.CurveName() != curveName {
+		return fmt.Errorf("point is not on %s", curveName)
+	}
+	if !p.IsOnCurve() || p.IsIdentity() {
+		return internal.ErrNotOnCurve
+	}
+	return nil
+}
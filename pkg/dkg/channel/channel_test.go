@@ -0,0 +1,139 @@
+//
+// Copyright Coinbase, Inc. All Rights Reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package channel
+
+import (
+	crand "crypto/rand"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/nerifnetwork/kryptology/pkg/core/curves"
+)
+
+func newTestChannels(t *testing.T, curve *curves.Curve, sessionId []byte, n uint32) map[uint32]*Channel {
+	keys := make(map[uint32]*StaticKey, n)
+	public := make(map[uint32]curves.Point, n)
+	for i := uint32(1); i <= n; i++ {
+		key, err := NewStaticKey(curve, crand.Reader)
+		require.NoError(t, err)
+		keys[i] = key
+		public[i] = key.Public
+	}
+	channels := make(map[uint32]*Channel, n)
+	for i, key := range keys {
+		ch, err := NewChannel(sessionId, i, key, public)
+		require.NoError(t, err)
+		channels[i] = ch
+	}
+	return channels
+}
+
+func TestChannelSealOpen(t *testing.T) {
+	for _, curve := range []*curves.Curve{curves.K256(), curves.P256(), curves.ED25519(), curves.PALLAS(), curves.BLS12381G1()} {
+		channels := newTestChannels(t, curve, []byte("session"), 3)
+		msg := []byte("a secret share")
+		envelope, err := channels[1].Seal(2, msg, crand.Reader)
+		require.NoError(t, err)
+		require.NotContains(t, string(envelope.Ciphertext), string(msg))
+
+		data, err := envelope.MarshalBinary()
+		require.NoError(t, err)
+		decoded := new(Envelope)
+		require.NoError(t, decoded.UnmarshalBinary(data))
+
+		opened, err := channels[2].Open(decoded)
+		require.NoError(t, err)
+		require.Equal(t, msg, opened)
+
+		// Only the receiver can open it
+		_, err = channels[3].Open(decoded)
+		require.Error(t, err)
+		_, err = channels[1].Open(decoded)
+		require.Error(t, err)
+	}
+}
+
+func TestChannelBindsContext(t *testing.T) {
+	curve := curves.K256()
+	channels := newTestChannels(t, curve, []byte("session"), 3)
+	envelope, err := channels[1].Seal(2, []byte("share"), crand.Reader)
+	require.NoError(t, err)
+
+	// Claimed sender
+	forged := *envelope
+	forged.Sender = 3
+	_, err = channels[2].Open(&forged)
+	require.Error(t, err)
+
+	// Receiver with the same keys in another session
+	other, err := NewChannel([]byte("other session"), 2, channels[2].key, map[uint32]curves.Point{
+		1: channels[1].key.Public,
+		3: channels[3].key.Public,
+	})
+	require.NoError(t, err)
+	_, err = other.Open(envelope)
+	require.Error(t, err)
+
+	// Tampered ciphertext
+	tampered := *envelope
+	tampered.Ciphertext = append([]byte{}, envelope.Ciphertext...)
+	tampered.Ciphertext[0] ^= 1
+	_, err = channels[2].Open(&tampered)
+	require.Error(t, err)
+
+	// Another ephemeral key
+	tampered = *envelope
+	tampered.Ephemeral = curve.Point.Random(crand.Reader)
+	_, err = channels[2].Open(&tampered)
+	require.Error(t, err)
+	tampered.Ephemeral = curve.NewIdentityPoint()
+	_, err = channels[2].Open(&tampered)
+	require.Error(t, err)
+}
+
+func TestChannelAll(t *testing.T) {
+	channels := newTestChannels(t, curves.ED25519(), []byte("session"), 3)
+	envelopes, err := channels[1].SealAll(map[uint32][]byte{
+		2: []byte("to 2"),
+		3: []byte("to 3"),
+	}, crand.Reader)
+	require.NoError(t, err)
+	for _, id := range []uint32{2, 3} {
+		messages, failed := channels[id].OpenAll(map[uint32]*Envelope{1: envelopes[id]})
+		require.Empty(t, failed)
+		require.Equal(t, []byte("to "+string(rune('0'+id))), messages[1])
+	}
+
+	// The envelopes that don't open are reported without losing the others
+	fromThree, err := channels[3].SealAll(map[uint32][]byte{2: []byte("from 3")}, crand.Reader)
+	require.NoError(t, err)
+	messages, failed := channels[2].OpenAll(map[uint32]*Envelope{1: envelopes[3], 3: fromThree[2], 4: nil})
+	require.Equal(t, []uint32{1, 4}, failed)
+	require.Equal(t, map[uint32][]byte{3: []byte("from 3")}, messages)
+	_, err = channels[1].SealAll(map[uint32][]byte{4: []byte("unknown")}, crand.Reader)
+	require.Error(t, err)
+}
+
+func TestNewChannelBadInput(t *testing.T) {
+	key, err := NewStaticKey(curves.K256(), crand.Reader)
+	require.NoError(t, err)
+	peer, err := NewStaticKey(curves.P256(), crand.Reader)
+	require.NoError(t, err)
+	_, err = NewChannel(nil, 1, key, map[uint32]curves.Point{2: key.Public})
+	require.Error(t, err)
+	_, err = NewChannel([]byte("session"), 0, key, map[uint32]curves.Point{2: key.Public})
+	require.Error(t, err)
+	_, err = NewChannel([]byte("session"), 1, key, map[uint32]curves.Point{1: key.Public})
+	require.Error(t, err)
+	_, err = NewChannel([]byte("session"), 1, key, map[uint32]curves.Point{2: peer.Public})
+	require.Error(t, err)
+	_, err = NewChannel([]byte("session"), 1, key, map[uint32]curves.Point{2: curves.K256().NewIdentityPoint()})
+	require.Error(t, err)
+	_, err = NewChannel([]byte("session"), 1, &StaticKey{key.Secret, peer.Public}, map[uint32]curves.Point{2: key.Public})
+	require.Error(t, err)
+}
@@ -0,0 +1,65 @@
+//
+// Copyright Coinbase, Inc. All Rights Reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package channel
+
+import (
+	"fmt"
+
+	"git.sr.ht/~sircmpwn/go-bare"
+
+	"github.com/nerifnetwork/kryptology/pkg/core/curves"
+)
+
+// Envelope is an encrypted point to point message
+type Envelope struct {
+	Sender, Receiver uint32
+	Ephemeral        curves.Point
+	Ciphertext       []byte
+}
+
+type envelopeMarshal struct {
+	Sender     uint32 `bare:"sender"`
+	Receiver   uint32 `bare:"receiver"`
+	Curve      string `bare:"curve"`
+	Ephemeral  []byte `bare:"ephemeral"`
+	Ciphertext []byte `bare:"ciphertext"`
+}
+
+// MarshalBinary serializes the envelope
+func (e Envelope) MarshalBinary() ([]byte, error) {
+	if e.Ephemeral == nil {
+		return nil, fmt.Errorf("invalid envelope")
+	}
+	return bare.Marshal(&envelopeMarshal{
+		Sender:     e.Sender,
+		Receiver:   e.Receiver,
+		Curve:      e.Ephemeral.CurveName(),
+		Ephemeral:  e.Ephemeral.ToAffineCompressed(),
+		Ciphertext: e.Ciphertext,
+	})
+}
+
+// UnmarshalBinary deserializes the envelope
+func (e *Envelope) UnmarshalBinary(data []byte) error {
+	em := new(envelopeMarshal)
+	if err := bare.Unmarshal(data, em); err != nil {
+		return err
+	}
+	curve := curves.GetCurveByName(em.Curve)
+	if curve == nil {
+		return fmt.Errorf("unknown curve")
+	}
+	ephemeral, err := curve.Point.FromAffineCompressed(em.Ephemeral)
+	if err != nil {
+		return err
+	}
+	e.Sender = em.Sender
+	e.Receiver = em.Receiver
+	e.Ephemeral = ephemeral
+	e.Ciphertext = em.Ciphertext
+	return nil
+}
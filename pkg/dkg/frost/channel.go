@@ -0,0 +1,55 @@
+//
+// Copyright Coinbase, Inc. All Rights Reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package frost
+
+import (
+	"encoding/binary"
+	"io"
+	"sort"
+
+	"github.com/nerifnetwork/kryptology/internal"
+	"github.com/nerifnetwork/kryptology/pkg/dkg/channel"
+	"github.com/nerifnetwork/kryptology/pkg/sharing"
+)
+
+// SealRound1P2PSend encrypts each share of Round1 to its recipient
+func SealRound1P2PSend(ch *channel.Channel, p2p Round1P2PSend, reader io.Reader) (map[uint32]*channel.Envelope, error) {
+	if ch == nil || p2p == nil {
+		return nil, internal.ErrNilArguments
+	}
+	messages := make(map[uint32][]byte, len(p2p))
+	for id, share := range p2p {
+		if share == nil {
+			return nil, internal.ErrNilArguments
+		}
+		messages[id] = share.Bytes()
+	}
+	return ch.SealAll(messages, reader)
+}
+
+// OpenRound1P2PSend decrypts the shares sent to this participant, keyed by sender,
+// so they can be passed to Round2 or Complain. It also returns the sorted senders
+// whose share could not be opened, which Complain accuses when their share is left out.
+func OpenRound1P2PSend(ch *channel.Channel, envelopes map[uint32]*channel.Envelope) (map[uint32]*sharing.ShamirShare, []uint32, error) {
+	if ch == nil || envelopes == nil {
+		return nil, nil, internal.ErrNilArguments
+	}
+	messages, failed := ch.OpenAll(envelopes)
+	shares := make(map[uint32]*sharing.ShamirShare, len(messages))
+	for id, msg := range messages {
+		if len(msg) < 4 {
+			failed = append(failed, id)
+			continue
+		}
+		shares[id] = &sharing.ShamirShare{
+			Id:    binary.BigEndian.Uint32(msg[:4]),
+			Value: msg[4:],
+		}
+	}
+	sort.Slice(failed, func(i, j int) bool { return failed[i] < failed[j] })
+	return shares, failed, nil
+}
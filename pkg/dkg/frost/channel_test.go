@@ -0,0 +1,87 @@
+//
+// Copyright Coinbase, Inc. All Rights Reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package frost
+
+import (
+	crand "crypto/rand"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/nerifnetwork/kryptology/pkg/core/curves"
+	"github.com/nerifnetwork/kryptology/pkg/dkg/channel"
+)
+
+func TestDkgRound1P2PSendChannel(t *testing.T) {
+	p1, p2, bcast1, bcast2, p2psend1, p2psend2 := PrepareRound2Input(t)
+	keys := make(map[uint32]*channel.StaticKey, 2)
+	public := make(map[uint32]curves.Point, 2)
+	for _, id := range []uint32{1, 2} {
+		key, err := channel.NewStaticKey(curves.K256(), crand.Reader)
+		require.NoError(t, err)
+		keys[id] = key
+		public[id] = key.Public
+	}
+	ch1, err := channel.NewChannel([]byte(Ctx), 1, keys[1], public)
+	require.NoError(t, err)
+	ch2, err := channel.NewChannel([]byte(Ctx), 2, keys[2], public)
+	require.NoError(t, err)
+
+	sealed1, err := SealRound1P2PSend(ch1, p2psend1, crand.Reader)
+	require.NoError(t, err)
+	sealed2, err := SealRound1P2PSend(ch2, p2psend2, crand.Reader)
+	require.NoError(t, err)
+
+	opened1, failed, err := OpenRound1P2PSend(ch1, map[uint32]*channel.Envelope{2: sealed2[1]})
+	require.NoError(t, err)
+	require.Empty(t, failed)
+	require.Equal(t, p2psend2[1], opened1[2])
+	opened2, failed, err := OpenRound1P2PSend(ch2, map[uint32]*channel.Envelope{1: sealed1[2]})
+	require.NoError(t, err)
+	require.Empty(t, failed)
+	require.Equal(t, p2psend1[2], opened2[1])
+
+	bcast := map[uint32]*Round1Bcast{1: bcast1, 2: bcast2}
+	out1, err := p1.Round2(bcast, opened1)
+	require.NoError(t, err)
+	out2, err := p2.Round2(bcast, opened2)
+	require.NoError(t, err)
+	require.True(t, out1.VerificationKey.Equal(out2.VerificationKey))
+
+	// An envelope can't be opened by a participant it was not sealed for
+	_, failed, err = OpenRound1P2PSend(ch2, map[uint32]*channel.Envelope{2: sealed2[1]})
+	require.NoError(t, err)
+	require.Equal(t, []uint32{2}, failed)
+}
+
+func TestDkgRound1P2PSendChannelComplaint(t *testing.T) {
+	p1, _, bcast1, bcast2, _, p2psend2 := PrepareRound2Input(t)
+	keys := make(map[uint32]*channel.StaticKey, 2)
+	public := make(map[uint32]curves.Point, 2)
+	for _, id := range []uint32{1, 2} {
+		key, err := channel.NewStaticKey(curves.K256(), crand.Reader)
+		require.NoError(t, err)
+		keys[id] = key
+		public[id] = key.Public
+	}
+	ch1, err := channel.NewChannel([]byte(Ctx), 1, keys[1], public)
+	require.NoError(t, err)
+	ch2, err := channel.NewChannel([]byte(Ctx), 2, keys[2], public)
+	require.NoError(t, err)
+	sealed2, err := SealRound1P2PSend(ch2, p2psend2, crand.Reader)
+	require.NoError(t, err)
+
+	// A corrupted envelope leaves the share out and its sender is accused
+	sealed2[1].Ciphertext[0] ^= 1
+	opened, failed, err := OpenRound1P2PSend(ch1, map[uint32]*channel.Envelope{2: sealed2[1]})
+	require.NoError(t, err)
+	require.Equal(t, []uint32{2}, failed)
+	require.Empty(t, opened)
+	complaint, err := p1.Complain(map[uint32]*Round1Bcast{1: bcast1, 2: bcast2}, opened)
+	require.NoError(t, err)
+	require.Equal(t, []uint32{2}, complaint.Accused)
+}
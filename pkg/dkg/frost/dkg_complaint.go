@@ -0,0 +1,269 @@
+//
+// Copyright Coinbase, Inc. All Rights Reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package frost
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/nerifnetwork/kryptology/internal"
+	"github.com/nerifnetwork/kryptology/pkg/core/curves"
+	"github.com/nerifnetwork/kryptology/pkg/sharing"
+)
+
+// Rounds of the complaint flow. Round2 goes from round 2 straight to 3,
+// Complain, Respond and Finalize go from round 2 to 3 through these.
+const (
+	respondRound  = 4
+	finalizeRound = 5
+)
+
+// ComplaintBcast is broadcast by every participant after Complain.
+// Accused lists the dealers whose share to the participant was missing
+// or failed Feldman verification, and is empty when all shares were valid.
+type ComplaintBcast struct {
+	Accused []uint32
+}
+
+// ComplaintResponse is broadcast by every participant after Respond.
+// Shares reveals the shares the participant dealt to everyone who accused it.
+type ComplaintResponse struct {
+	Shares map[uint32]*sharing.ShamirShare
+}
+
+// DisqualifiedError lists the dealers that were excluded from the DKG
+// because their round 1 broadcast was invalid or because they failed
+// to answer a complaint with a valid share.
+type DisqualifiedError struct {
+	Culprits []uint32
+}
+
+func (e DisqualifiedError) Error() string {
+	return fmt.Sprintf("participants %v were disqualified", e.Culprits)
+}
+
+// complaintState is what the complaint flow keeps between rounds
+type complaintState struct {
+	bcast      map[uint32]*Round1Bcast
+	shares     map[uint32]*sharing.ShamirShare
+	faulty     map[uint32]bool
+	accused    []uint32
+	complaints map[uint32]*ComplaintBcast
+}
+
+// Complain is the tolerant alternative to Round2. Instead of failing on the first
+// invalid input it checks every round 1 message and returns a complaint against
+// the dealers whose share to this participant is invalid. Dealers whose broadcast
+// is invalid can be checked by everyone and are excluded without a complaint.
+// The complaint must be broadcast to all participants, including this one.
+func (dp *DkgParticipant) Complain(bcast map[uint32]*Round1Bcast, p2psend map[uint32]*sharing.ShamirShare) (*ComplaintBcast, error) {
+	if dp == nil || dp.Curve == nil {
+		return nil, internal.ErrNilArguments
+	}
+	if dp.round != 2 {
+		return nil, internal.ErrInvalidRound
+	}
+	if bcast == nil || p2psend == nil {
+		return nil, internal.ErrNilArguments
+	}
+	if uint32(len(bcast)) > dp.feldman.Limit {
+		return nil, fmt.Errorf("invalid broadcast length")
+	}
+
+	state := &complaintState{
+		bcast:  make(map[uint32]*Round1Bcast, len(bcast)),
+		shares: make(map[uint32]*sharing.ShamirShare, len(bcast)),
+		faulty: make(map[uint32]bool),
+	}
+	state.shares[dp.Id] = dp.secretShares[dp.Id-1]
+	for id, b := range bcast {
+		if id == dp.Id {
+			continue
+		}
+		if id == 0 || id > dp.feldman.Limit {
+			return nil, fmt.Errorf("invalid participant id %d", id)
+		}
+		state.bcast[id] = b
+		if err := dp.checkRound1Bcast(id, b); err != nil {
+			state.faulty[id] = true
+			continue
+		}
+		share := p2psend[id]
+		if share == nil || share.Id != dp.Id || b.Verifiers.Verify(share) != nil {
+			state.accused = append(state.accused, id)
+			continue
+		}
+		state.shares[id] = share
+	}
+	sort.Slice(state.accused, func(i, j int) bool { return state.accused[i] < state.accused[j] })
+
+	dp.complaint = state
+	dp.round = respondRound
+	return &ComplaintBcast{Accused: state.accused}, nil
+}
+
+// Respond takes the complaints broadcast by all participants and reveals the
+// shares this participant dealt to its accusers. The response must be broadcast
+// to all participants, including this one, even when nobody complained.
+func (dp *DkgParticipant) Respond(complaints map[uint32]*ComplaintBcast) (*ComplaintResponse, error) {
+	if dp == nil || dp.Curve == nil {
+		return nil, internal.ErrNilArguments
+	}
+	if dp.round != respondRound {
+		return nil, internal.ErrInvalidRound
+	}
+	state := dp.complaint
+	// Complaints from invalid ids are dropped here so Finalize never counts them
+	state.complaints = make(map[uint32]*ComplaintBcast, len(complaints)+1)
+	for id, c := range complaints {
+		if c != nil && id != 0 && id <= dp.feldman.Limit && id != dp.Id {
+			state.complaints[id] = c
+		}
+	}
+	state.complaints[dp.Id] = &ComplaintBcast{Accused: state.accused}
+
+	response := &ComplaintResponse{
+		Shares: make(map[uint32]*sharing.ShamirShare),
+	}
+	for id, c := range state.complaints {
+		if id == dp.Id {
+			continue
+		}
+		for _, accused := range c.Accused {
+			if accused == dp.Id {
+				response.Shares[id] = dp.secretShares[id-1]
+			}
+		}
+	}
+	dp.round = finalizeRound
+	return response, nil
+}
+
+// Finalize takes the responses broadcast by all participants and disqualifies
+// every dealer that did not answer a complaint against it with a valid share.
+// All honest participants see the same broadcasts so they disqualify the same dealers.
+// The keys are computed from the remaining dealers as in Round2. When some dealers
+// were disqualified the result is returned together with a DisqualifiedError.
+// If fewer than threshold dealers remain the DKG fails.
+func (dp *DkgParticipant) Finalize(responses map[uint32]*ComplaintResponse) (*Round2Bcast, error) {
+	if dp == nil || dp.Curve == nil {
+		return nil, internal.ErrNilArguments
+	}
+	if dp.round != finalizeRound {
+		return nil, internal.ErrInvalidRound
+	}
+	state := dp.complaint
+
+	for complainer, c := range state.complaints {
+		for _, accused := range c.Accused {
+			if accused == complainer || state.faulty[accused] {
+				continue
+			}
+			verifiers := dp.verifiers
+			if accused != dp.Id {
+				b, ok := state.bcast[accused]
+				if !ok {
+					continue
+				}
+				verifiers = b.Verifiers
+			}
+			var share *sharing.ShamirShare
+			if r := responses[accused]; r != nil {
+				share = r.Shares[complainer]
+			}
+			if share == nil || share.Id != complainer || verifiers.Verify(share) != nil {
+				state.faulty[accused] = true
+				continue
+			}
+			if complainer == dp.Id {
+				state.shares[accused] = share
+			}
+		}
+	}
+
+	qualified := make([]uint32, 0, len(state.bcast)+1)
+	culprits := make([]uint32, 0, len(state.faulty))
+	for _, id := range dp.dealers() {
+		if state.faulty[id] {
+			culprits = append(culprits, id)
+		} else {
+			qualified = append(qualified, id)
+		}
+	}
+	if uint32(len(qualified)) < dp.feldman.Threshold {
+		return nil, fmt.Errorf("only %d dealers qualified, need %d: %w", len(qualified), dp.feldman.Threshold, &DisqualifiedError{culprits})
+	}
+
+	sk := dp.Curve.Scalar.Zero()
+	vk := dp.Curve.NewIdentityPoint()
+	commitments := make([][]curves.Point, 0, len(qualified))
+	for _, id := range qualified {
+		share, ok := state.shares[id]
+		if !ok {
+			return nil, fmt.Errorf("missing share from participant %d", id)
+		}
+		s, err := dp.Curve.Scalar.SetBytes(share.Value)
+		if err != nil {
+			return nil, err
+		}
+		sk = sk.Add(s)
+		vk = vk.Add(dp.commitments(id)[0])
+		commitments = append(commitments, dp.commitments(id))
+	}
+
+	dp.SkShare = sk
+	dp.VkShare = dp.Curve.ScalarBaseMult(sk)
+	dp.VerificationKey = vk
+	dp.VkShares = dp.vkShares(commitments)
+	dp.complaint = nil
+	dp.round = 3
+
+	result := &Round2Bcast{
+		vk,
+		dp.VkShare,
+	}
+	if len(culprits) > 0 {
+		return result, &DisqualifiedError{culprits}
+	}
+	return result, nil
+}
+
+// checkRound1Bcast checks everything in a round 1 broadcast that all participants can check
+func (dp *DkgParticipant) checkRound1Bcast(id uint32, bcast *Round1Bcast) error {
+	if bcast == nil || bcast.Verifiers == nil || bcast.Wi == nil || bcast.Ci == nil {
+		return internal.ErrNilArguments
+	}
+	if uint32(len(bcast.Verifiers.Commitments)) != dp.feldman.Threshold {
+		return fmt.Errorf("invalid number of commitments from participant %d", id)
+	}
+	if bcast.Ci.IsZero() {
+		return fmt.Errorf("ci should not be zero from participant %d", id)
+	}
+	for _, com := range bcast.Verifiers.Commitments {
+		if com == nil || com.CurveName() != dp.Curve.Name || !com.IsOnCurve() || com.IsIdentity() {
+			return fmt.Errorf("some commitment is not on curve from participant %d", id)
+		}
+	}
+	return dp.verifyProof(id, bcast)
+}
+
+// dealers returns the sorted ids of all participants that sent a round 1 broadcast
+func (dp *DkgParticipant) dealers() []uint32 {
+	ids := []uint32{dp.Id}
+	for id := range dp.complaint.bcast {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return ids
+}
+
+func (dp *DkgParticipant) commitments(id uint32) []curves.Point {
+	if id == dp.Id {
+		return dp.verifiers.Commitments
+	}
+	return dp.complaint.bcast[id].Verifiers.Commitments
+}
@@ -0,0 +1,186 @@
+//
+// Copyright Coinbase, Inc. All Rights Reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package frost
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/nerifnetwork/kryptology/pkg/sharing"
+)
+
+type complaintTestNetwork struct {
+	participants map[uint32]*DkgParticipant
+	bcast        map[uint32]*Round1Bcast
+	p2p          map[uint32]Round1P2PSend
+	// forged are extra complaints broadcast under the given ids
+	forged map[uint32]*ComplaintBcast
+}
+
+func newComplaintTestNetwork(t *testing.T, threshold, n uint32) *complaintTestNetwork {
+	net := &complaintTestNetwork{
+		participants: make(map[uint32]*DkgParticipant, n),
+		bcast:        make(map[uint32]*Round1Bcast, n),
+		p2p:          make(map[uint32]Round1P2PSend, n),
+	}
+	for i := uint32(1); i <= n; i++ {
+		var others []uint32
+		for j := uint32(1); j <= n; j++ {
+			if j != i {
+				others = append(others, j)
+			}
+		}
+		p, err := NewDkgParticipant(i, threshold, Ctx, testCurve, others...)
+		require.NoError(t, err)
+		net.participants[i] = p
+		net.bcast[i], net.p2p[i], err = p.Round1(nil)
+		require.NoError(t, err)
+	}
+	return net
+}
+
+// run completes the complaint flow, withholding the responses of the silent dealers
+func (net *complaintTestNetwork) run(t *testing.T, silent ...uint32) (map[uint32]*Round2Bcast, map[uint32]error) {
+	complaints := make(map[uint32]*ComplaintBcast)
+	for id, p := range net.participants {
+		received := make(map[uint32]*sharing.ShamirShare)
+		for dealer, shares := range net.p2p {
+			if dealer != id {
+				received[dealer] = shares[id]
+			}
+		}
+		c, err := p.Complain(net.bcast, received)
+		require.NoError(t, err)
+		complaints[id] = c
+	}
+	for id, c := range net.forged {
+		complaints[id] = c
+	}
+	responses := make(map[uint32]*ComplaintResponse)
+	for id, p := range net.participants {
+		r, err := p.Respond(complaints)
+		require.NoError(t, err)
+		responses[id] = r
+	}
+	for _, id := range silent {
+		delete(responses, id)
+	}
+	results := make(map[uint32]*Round2Bcast)
+	errs := make(map[uint32]error)
+	for id, p := range net.participants {
+		results[id], errs[id] = p.Finalize(responses)
+	}
+	return results, errs
+}
+
+func (net *complaintTestNetwork) requireKey(t *testing.T, threshold uint32, results map[uint32]*Round2Bcast, ids ...uint32) {
+	s, err := sharing.NewShamir(threshold, uint32(len(net.participants)), testCurve)
+	require.NoError(t, err)
+	shares := make([]*sharing.ShamirShare, 0, len(ids))
+	for _, id := range ids {
+		p := net.participants[id]
+		require.True(t, results[id].VerificationKey.Equal(results[ids[0]].VerificationKey))
+		shares = append(shares, &sharing.ShamirShare{Id: id, Value: p.SkShare.Bytes()})
+	}
+	sk, err := s.Combine(shares[:threshold]...)
+	require.NoError(t, err)
+	require.True(t, testCurve.ScalarBaseMult(sk).Equal(results[ids[0]].VerificationKey))
+}
+
+func TestDkgComplaintAllHonest(t *testing.T) {
+	net := newComplaintTestNetwork(t, 3, 5)
+	results, errs := net.run(t)
+	for _, err := range errs {
+		require.NoError(t, err)
+	}
+	net.requireKey(t, 3, results, 1, 2, 3, 4, 5)
+	net.requireKey(t, 3, results, 5, 3, 1)
+}
+
+func TestDkgComplaintResolved(t *testing.T) {
+	net := newComplaintTestNetwork(t, 3, 5)
+	// Dealer 2 sends a bad share to 1 but reveals the right one
+	net.p2p[2][1] = &sharing.ShamirShare{Id: 1, Value: testCurve.Scalar.One().Bytes()}
+	results, errs := net.run(t)
+	for _, err := range errs {
+		require.NoError(t, err)
+	}
+	net.requireKey(t, 3, results, 1, 2, 3, 4, 5)
+}
+
+func TestDkgComplaintDisqualifies(t *testing.T) {
+	net := newComplaintTestNetwork(t, 3, 5)
+	// Dealer 3 sends a bad share to 1 and does not answer the complaint
+	net.p2p[3][1] = &sharing.ShamirShare{Id: 1, Value: testCurve.Scalar.One().Bytes()}
+	// Dealer 4 sends a broadcast with an invalid proof
+	net.bcast[4].Wi = net.bcast[4].Wi.Add(testCurve.Scalar.One())
+	// Dealer 5 does not send a share to 2
+	delete(net.p2p[5], 2)
+	results, errs := net.run(t, 3)
+	honest := []uint32{1, 2, 5}
+	for _, id := range honest {
+		var disqualified *DisqualifiedError
+		require.True(t, errors.As(errs[id], &disqualified), "participant %d", id)
+		require.Equal(t, []uint32{3, 4}, disqualified.Culprits)
+		require.NotNil(t, results[id])
+	}
+	net.requireKey(t, 3, results, honest...)
+
+	// The key is the sum of the qualified secrets only
+	expected := testCurve.NewIdentityPoint()
+	for _, id := range honest {
+		expected = expected.Add(net.bcast[id].Verifiers.Commitments[0])
+	}
+	require.True(t, expected.Equal(results[1].VerificationKey))
+}
+
+func TestDkgComplaintInvalidComplainer(t *testing.T) {
+	net := newComplaintTestNetwork(t, 3, 5)
+	// Complaints under ids that are not participants are ignored by everyone
+	net.forged = map[uint32]*ComplaintBcast{
+		0: {Accused: []uint32{2}},
+		9: {Accused: []uint32{2, 3}},
+	}
+	results, errs := net.run(t, 2, 3)
+	for _, err := range errs {
+		require.NoError(t, err)
+	}
+	net.requireKey(t, 3, results, 1, 2, 3, 4, 5)
+}
+
+func TestDkgComplaintTooManyCulprits(t *testing.T) {
+	net := newComplaintTestNetwork(t, 3, 4)
+	net.p2p[3][1] = &sharing.ShamirShare{Id: 1, Value: testCurve.Scalar.One().Bytes()}
+	net.p2p[4][2] = &sharing.ShamirShare{Id: 2, Value: testCurve.Scalar.One().Bytes()}
+	results, errs := net.run(t, 3, 4)
+	for _, id := range []uint32{1, 2} {
+		err := errs[id]
+		require.Error(t, err)
+		require.Nil(t, results[id])
+		var disqualified *DisqualifiedError
+		require.True(t, errors.As(err, &disqualified))
+		require.Equal(t, []uint32{3, 4}, disqualified.Culprits)
+	}
+}
+
+func TestDkgComplaintInvalidRound(t *testing.T) {
+	p1, _, bcast1, bcast2, _, p2psend2 := PrepareRound2Input(t)
+	_, err := p1.Respond(nil)
+	require.Error(t, err)
+	_, err = p1.Finalize(nil)
+	require.Error(t, err)
+	bcast := map[uint32]*Round1Bcast{1: bcast1, 2: bcast2}
+	p2p := map[uint32]*sharing.ShamirShare{2: p2psend2[1]}
+	_, err = p1.Complain(bcast, p2p)
+	require.NoError(t, err)
+	_, err = p1.Complain(bcast, p2p)
+	require.Error(t, err)
+	_, err = p1.Round2(bcast, p2p)
+	require.Error(t, err)
+}
@@ -74,36 +74,8 @@ func (dp *DkgParticipant) Round2(bcast map[uint32]*Round1Bcast, p2psend map[uint
 		}
 
 		// Step 4 - Check equation c_j = H(j, CTX, A_{j,0}, g^{w_j}*A_{j,0}^{-c_j}
-		// Get Aj0
-		Aj0 := bcast[id].Verifiers.Commitments[0]
-		// Compute g^{w_j}
-		prod1 := dp.Curve.ScalarBaseMult(bcast[id].Wi)
-		// Compute A_{j,0}^{-c_j}
-		prod2 := Aj0.Mul(bcast[id].Ci.Neg())
-
-		// We need to check Aj0 and prod2 are points on the same curve.
-		if !Aj0.IsOnCurve() || Aj0.IsIdentity() || !prod2.IsOnCurve() || prod2.IsIdentity() || Aj0.CurveName() != prod2.CurveName() {
-			return nil, fmt.Errorf("invalid Aj0 or prod2 which is not on the same curve")
-		}
-		if prod2 == nil {
-			return nil, fmt.Errorf("invalid should not be nil")
-		}
-
-		prod := prod1.Add(prod2)
-		var msg []byte
-		// Append participant id
-		msg = append(msg, byte(id))
-		// Append CTX
-		msg = append(msg, dp.ctx)
-		// Append Aj0
-		msg = append(msg, Aj0.ToAffineCompressed()...)
-		// Append prod
-		msg = append(msg, prod.ToAffineCompressed()...)
-		// Hash the message and get cj
-		cj := dp.Curve.Scalar.Hash(msg)
-		// Check equation
-		if cj.Cmp(bcast[id].Ci) != 0 {
-			return nil, fmt.Errorf("Hash check fails for participant with id %d\n", id)
+		if err = dp.verifyProof(id, bcast[id]); err != nil {
+			return nil, err
 		}
 
 		// Step 5 - FeldmanVerify
@@ -147,6 +119,14 @@ func (dp *DkgParticipant) Round2(bcast map[uint32]*Round1Bcast, p2psend map[uint
 	// Store verification key
 	dp.VerificationKey = vk
 
+	commitments := [][]curves.Point{dp.verifiers.Commitments}
+	for id := range bcast {
+		if id != dp.Id {
+			commitments = append(commitments, bcast[id].Verifiers.Commitments)
+		}
+	}
+	dp.VkShares = dp.vkShares(commitments)
+
 	// Update round number
 	dp.round = 3
 
@@ -156,3 +136,40 @@ func (dp *DkgParticipant) Round2(bcast map[uint32]*Round1Bcast, p2psend map[uint
 		dp.VkShare,
 	}, nil
 }
+
+// verifyProof checks the proof of knowledge of the secret in a round 1 broadcast
+// with c_j = H(j, CTX, A_{j,0}, g^{w_j}*A_{j,0}^{-c_j})
+func (dp *DkgParticipant) verifyProof(id uint32, bcast *Round1Bcast) error {
+	// Get Aj0
+	Aj0 := bcast.Verifiers.Commitments[0]
+	// Compute g^{w_j}
+	prod1 := dp.Curve.ScalarBaseMult(bcast.Wi)
+	// Compute A_{j,0}^{-c_j}
+	prod2 := Aj0.Mul(bcast.Ci.Neg())
+
+	// We need to check Aj0 and prod2 are points on the same curve.
+	if prod2 == nil {
+		return fmt.Errorf("invalid should not be nil")
+	}
+	if !Aj0.IsOnCurve() || Aj0.IsIdentity() || !prod2.IsOnCurve() || prod2.IsIdentity() || Aj0.CurveName() != prod2.CurveName() {
+		return fmt.Errorf("invalid Aj0 or prod2 which is not on the same curve")
+	}
+
+	prod := prod1.Add(prod2)
+	var msg []byte
+	// Append participant id
+	msg = append(msg, byte(id))
+	// Append CTX
+	msg = append(msg, dp.ctx)
+	// Append Aj0
+	msg = append(msg, Aj0.ToAffineCompressed()...)
+	// Append prod
+	msg = append(msg, prod.ToAffineCompressed()...)
+	// Hash the message and get cj
+	cj := dp.Curve.Scalar.Hash(msg)
+	// Check equation
+	if cj.Cmp(bcast.Ci) != 0 {
+		return fmt.Errorf("Hash check fails for participant with id %d\n", id)
+	}
+	return nil
+}
@@ -0,0 +1,348 @@
+//
+// Copyright Coinbase, Inc. All Rights Reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package frost
+
+import (
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/nerifnetwork/kryptology/internal"
+	"github.com/nerifnetwork/kryptology/pkg/core/curves"
+	"github.com/nerifnetwork/kryptology/pkg/sharing"
+)
+
+// The enrollment protocol lets threshold helpers give a new participant a share of the
+// existing key, see the repairable threshold scheme of https://eprint.iacr.org/2017/1155.pdf.
+// Each helper i splits λ_i * sk_i, where λ_i is its Lagrange coefficient at the new id,
+// into random pieces, one for every helper, and commits to them. Each helper sends the sum
+// of the pieces it received to the new participant, whose share is the sum of the sums.
+// No one learns anything but the new participant, who learns only its share.
+
+// EnrollRound1Bcast is broadcast by every helper to the other helpers and the new participant.
+// Commitments are the pieces times the generator, keyed by the helper they are sent to.
+type EnrollRound1Bcast struct {
+	Commitments map[uint32]curves.Point
+}
+
+// EnrollRound1P2PSend are the pieces a helper sends to each of the other helpers
+type EnrollRound1P2PSend = map[uint32]curves.Scalar
+
+// EnrollRound2P2PSend is the sum of the pieces a helper received,
+// sent to the new participant
+type EnrollRound2P2PSend = curves.Scalar
+
+// enrollmentState is what a helper keeps between the enrollment rounds
+type enrollmentState struct {
+	id      uint32
+	helpers []uint32
+	piece   curves.Scalar
+	bcast   *EnrollRound1Bcast
+}
+
+// EnrollRound1 starts the enrollment of a new participant with id as one of the helpers.
+// It can only be called after the DKG completed. The helpers must be threshold existing
+// participants, including this one. The pieces must be sent over a private channel.
+func (dp *DkgParticipant) EnrollRound1(id uint32, helpers []uint32, reader io.Reader) (*EnrollRound1Bcast, EnrollRound1P2PSend, error) {
+	if dp == nil || dp.Curve == nil || reader == nil {
+		return nil, nil, internal.ErrNilArguments
+	}
+	if dp.round != 3 || dp.enrollment != nil {
+		return nil, nil, internal.ErrInvalidRound
+	}
+	helpers, err := dp.checkEnrollment(id, helpers)
+	if err != nil {
+		return nil, nil, err
+	}
+	found := false
+	for _, h := range helpers {
+		found = found || h == dp.Id
+	}
+	if !found {
+		return nil, nil, fmt.Errorf("participant %d is not a helper", dp.Id)
+	}
+
+	lambdas, err := lagrangeAt(dp.Curve, id, helpers)
+	if err != nil {
+		return nil, nil, err
+	}
+	// δ_i = λ_i * sk_i = Σ_j δ_ij
+	delta := lambdas[dp.Id].Mul(dp.SkShare)
+	bcast := &EnrollRound1Bcast{Commitments: make(map[uint32]curves.Point, len(helpers))}
+	p2p := make(EnrollRound1P2PSend, len(helpers)-1)
+	var piece curves.Scalar
+	for i, h := range helpers {
+		var p curves.Scalar
+		if i == len(helpers)-1 {
+			p = delta
+		} else {
+			p = dp.Curve.Scalar.Random(reader)
+			delta = delta.Sub(p)
+		}
+		bcast.Commitments[h] = dp.Curve.ScalarBaseMult(p)
+		if h == dp.Id {
+			piece = p
+		} else {
+			p2p[h] = p
+		}
+	}
+
+	dp.enrollment = &enrollmentState{
+		id:      id,
+		helpers: helpers,
+		piece:   piece,
+		bcast:   bcast,
+	}
+	return bcast, p2p, nil
+}
+
+// EnrollRound2 checks the pieces from the other helpers and returns the sum of the pieces
+// to send privately to the new participant. bcast and p2p are keyed by the helper that sent them.
+// The enrollment ends here whether it succeeds or not, so a failed one can be started again.
+// The new participant is added to VkShares by Enroll once it confirmed its share.
+func (dp *DkgParticipant) EnrollRound2(bcast map[uint32]*EnrollRound1Bcast, p2p map[uint32]EnrollRound1P2PSend) (EnrollRound2P2PSend, error) {
+	if dp == nil || dp.Curve == nil || bcast == nil || p2p == nil {
+		return nil, internal.ErrNilArguments
+	}
+	state := dp.enrollment
+	if dp.round != 3 || state == nil {
+		return nil, internal.ErrInvalidRound
+	}
+	defer dp.AbortEnrollment()
+	all := make(map[uint32]*EnrollRound1Bcast, len(bcast)+1)
+	for h, b := range bcast {
+		all[h] = b
+	}
+	all[dp.Id] = state.bcast
+	if err := checkEnrollRound1Bcast(dp.Curve, state.id, state.helpers, dp.VkShares, all); err != nil {
+		return nil, err
+	}
+
+	sum := state.piece
+	for _, h := range state.helpers {
+		if h == dp.Id {
+			continue
+		}
+		piece, ok := p2p[h][dp.Id]
+		if !ok || piece == nil {
+			return nil, fmt.Errorf("missing enrollment piece from participant %d", h)
+		}
+		if !dp.Curve.ScalarBaseMult(piece).Equal(all[h].Commitments[dp.Id]) {
+			return nil, fmt.Errorf("invalid enrollment piece from participant %d", h)
+		}
+		sum = sum.Add(piece)
+	}
+	return sum, nil
+}
+
+// AbortEnrollment drops the state of an enrollment started with EnrollRound1,
+// for example when the other helpers never send their pieces.
+func (dp *DkgParticipant) AbortEnrollment() {
+	if dp != nil {
+		dp.enrollment = nil
+	}
+}
+
+// Enroll adds the verification key share of a new participant with id to VkShares.
+// Every existing participant, helpers included, calls it once the new participant
+// confirmed that NewEnrolledParticipant accepted its share.
+func (dp *DkgParticipant) Enroll(id uint32) error {
+	if dp == nil || dp.Curve == nil {
+		return internal.ErrNilArguments
+	}
+	if dp.round != 3 {
+		return internal.ErrInvalidRound
+	}
+	if id == 0 {
+		return fmt.Errorf("invalid id")
+	}
+	if _, ok := dp.VkShares[id]; ok {
+		return nil
+	}
+	vkShare, err := interpolateVkShare(dp.Curve, id, dp.feldman.Threshold, dp.VkShares)
+	if err != nil {
+		return err
+	}
+	dp.VkShares[id] = vkShare
+	return nil
+}
+
+// NewEnrolledParticipant completes the enrollment for the new participant with id.
+// verificationKey and vkShares are the public output of the DKG, bcast are the helpers'
+// EnrollRound1Bcast and sums their EnrollRound2P2PSend, both keyed by helper.
+// The result can sign and help with later enrollments like any other participant.
+func NewEnrolledParticipant(id, threshold uint32, curve *curves.Curve, verificationKey curves.Point, vkShares map[uint32]curves.Point,
+	bcast map[uint32]*EnrollRound1Bcast, sums map[uint32]EnrollRound2P2PSend,
+) (*DkgParticipant, error) {
+	if curve == nil || verificationKey == nil || vkShares == nil || bcast == nil || sums == nil {
+		return nil, internal.ErrNilArguments
+	}
+	if _, ok := vkShares[id]; ok || id == 0 {
+		return nil, fmt.Errorf("invalid id %d", id)
+	}
+	feldman, err := sharing.NewFeldman(threshold, uint32(len(vkShares))+1, curve)
+	if err != nil {
+		return nil, err
+	}
+	helpers := make([]uint32, 0, len(bcast))
+	for h := range bcast {
+		helpers = append(helpers, h)
+	}
+	sort.Slice(helpers, func(i, j int) bool { return helpers[i] < helpers[j] })
+	if uint32(len(helpers)) != threshold {
+		return nil, fmt.Errorf("need %d helpers", threshold)
+	}
+	if err := checkEnrollRound1Bcast(curve, id, helpers, vkShares, bcast); err != nil {
+		return nil, err
+	}
+
+	sk := curve.Scalar.Zero()
+	for _, h := range helpers {
+		sum, ok := sums[h]
+		if !ok || sum == nil {
+			return nil, fmt.Errorf("missing enrollment sum from participant %d", h)
+		}
+		// σ_j * G = Σ_i D_ij
+		expected := curve.NewIdentityPoint()
+		for _, i := range helpers {
+			expected = expected.Add(bcast[i].Commitments[h])
+		}
+		if !curve.ScalarBaseMult(sum).Equal(expected) {
+			return nil, fmt.Errorf("invalid enrollment sum from participant %d", h)
+		}
+		sk = sk.Add(sum)
+	}
+	vkShare, err := interpolateVkShare(curve, id, threshold, vkShares)
+	if err != nil {
+		return nil, err
+	}
+	if !curve.ScalarBaseMult(sk).Equal(vkShare) {
+		return nil, fmt.Errorf("enrolled share does not match the verification key shares")
+	}
+
+	dp := &DkgParticipant{
+		round:                  3,
+		Curve:                  curve,
+		otherParticipantShares: make(map[uint32]*dkgParticipantData, len(vkShares)),
+		Id:                     id,
+		SkShare:                sk,
+		VerificationKey:        verificationKey,
+		VkShare:                vkShare,
+		VkShares:               make(map[uint32]curves.Point, len(vkShares)+1),
+		feldman:                feldman,
+	}
+	for other, share := range vkShares {
+		dp.otherParticipantShares[other] = &dkgParticipantData{Id: other}
+		dp.VkShares[other] = share
+	}
+	dp.VkShares[id] = vkShare
+	return dp, nil
+}
+
+// checkEnrollment checks the new id and the helpers and returns the helpers sorted
+func (dp *DkgParticipant) checkEnrollment(id uint32, helpers []uint32) ([]uint32, error) {
+	if id == 0 {
+		return nil, fmt.Errorf("invalid id")
+	}
+	if _, ok := dp.VkShares[id]; ok {
+		return nil, fmt.Errorf("participant %d already exists", id)
+	}
+	if uint32(len(helpers)) != dp.feldman.Threshold {
+		return nil, fmt.Errorf("need %d helpers", dp.feldman.Threshold)
+	}
+	sorted := make([]uint32, len(helpers))
+	copy(sorted, helpers)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	for i, h := range sorted {
+		if _, ok := dp.VkShares[h]; !ok {
+			return nil, fmt.Errorf("unknown helper %d", h)
+		}
+		if i > 0 && sorted[i-1] == h {
+			return nil, fmt.Errorf("duplicate helper %d", h)
+		}
+	}
+	return sorted, nil
+}
+
+// checkEnrollRound1Bcast checks that every helper committed to one piece per helper and
+// that the pieces of helper i sum to λ_i * sk_i: Σ_j D_ij = λ_i * VkShare_i
+func checkEnrollRound1Bcast(curve *curves.Curve, id uint32, helpers []uint32, vkShares map[uint32]curves.Point, bcast map[uint32]*EnrollRound1Bcast) error {
+	lambdas, err := lagrangeAt(curve, id, helpers)
+	if err != nil {
+		return err
+	}
+	for _, i := range helpers {
+		b, ok := bcast[i]
+		if !ok || b == nil || len(b.Commitments) != len(helpers) {
+			return fmt.Errorf("invalid enrollment broadcast from participant %d", i)
+		}
+		vkShare, ok := vkShares[i]
+		if !ok {
+			return fmt.Errorf("unknown helper %d", i)
+		}
+		sum := curve.NewIdentityPoint()
+		for _, j := range helpers {
+			c, ok := b.Commitments[j]
+			if !ok || c == nil || c.CurveName() != curve.Name || !c.IsOnCurve() {
+				return fmt.Errorf("invalid enrollment broadcast from participant %d", i)
+			}
+			sum = sum.Add(c)
+		}
+		if !sum.Equal(vkShare.Mul(lambdas[i])) {
+			return fmt.Errorf("invalid enrollment broadcast from participant %d", i)
+		}
+	}
+	return nil
+}
+
+// interpolateVkShare computes the verification key share of id from threshold known ones
+func interpolateVkShare(curve *curves.Curve, id, threshold uint32, vkShares map[uint32]curves.Point) (curves.Point, error) {
+	if uint32(len(vkShares)) < threshold {
+		return nil, fmt.Errorf("need %d verification key shares", threshold)
+	}
+	ids := make([]uint32, 0, len(vkShares))
+	for i := range vkShares {
+		ids = append(ids, i)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	ids = ids[:threshold]
+	lambdas, err := lagrangeAt(curve, id, ids)
+	if err != nil {
+		return nil, err
+	}
+	points := make([]curves.Point, len(ids))
+	scalars := make([]curves.Scalar, len(ids))
+	for i, x := range ids {
+		points[i] = vkShares[x]
+		scalars[i] = lambdas[x]
+	}
+	return curve.Point.SumOfProducts(points, scalars), nil
+}
+
+// lagrangeAt returns the Lagrange coefficients of ids evaluated at x
+func lagrangeAt(curve *curves.Curve, x uint32, ids []uint32) (map[uint32]curves.Scalar, error) {
+	xs := curve.Scalar.New(int(x))
+	out := make(map[uint32]curves.Scalar, len(ids))
+	for _, i := range ids {
+		xi := curve.Scalar.New(int(i))
+		num := curve.Scalar.One()
+		den := curve.Scalar.One()
+		for _, j := range ids {
+			if i == j {
+				continue
+			}
+			xj := curve.Scalar.New(int(j))
+			num = num.Mul(xs.Sub(xj))
+			den = den.Mul(xi.Sub(xj))
+		}
+		if den.IsZero() {
+			return nil, fmt.Errorf("duplicate id %d", i)
+		}
+		out[i] = num.Div(den)
+	}
+	return out, nil
+}
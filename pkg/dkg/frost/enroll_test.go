@@ -0,0 +1,198 @@
+//
+// Copyright Coinbase, Inc. All Rights Reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package frost
+
+import (
+	crand "crypto/rand"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/nerifnetwork/kryptology/pkg/core/curves"
+	"github.com/nerifnetwork/kryptology/pkg/sharing"
+)
+
+// runTestDkg runs Round1 and Round2 for n participants
+func runTestDkg(t *testing.T, threshold, n uint32) map[uint32]*DkgParticipant {
+	net := newComplaintTestNetwork(t, threshold, n)
+	for id, p := range net.participants {
+		received := make(map[uint32]*sharing.ShamirShare)
+		for dealer, shares := range net.p2p {
+			if dealer != id {
+				received[dealer] = shares[id]
+			}
+		}
+		_, err := p.Round2(net.bcast, received)
+		require.NoError(t, err)
+	}
+	return net.participants
+}
+
+// runEnrollment enrolls id with the helpers and returns the new participant
+func runEnrollment(t *testing.T, participants map[uint32]*DkgParticipant, id uint32, helpers ...uint32) (*DkgParticipant, error) {
+	bcast := make(map[uint32]*EnrollRound1Bcast, len(helpers))
+	p2p := make(map[uint32]EnrollRound1P2PSend, len(helpers))
+	for _, h := range helpers {
+		b, s, err := participants[h].EnrollRound1(id, helpers, crand.Reader)
+		require.NoError(t, err)
+		bcast[h] = b
+		p2p[h] = s
+	}
+	sums := make(map[uint32]EnrollRound2P2PSend, len(helpers))
+	for _, h := range helpers {
+		others := make(map[uint32]*EnrollRound1Bcast, len(helpers)-1)
+		for other, b := range bcast {
+			if other != h {
+				others[other] = b
+			}
+		}
+		sum, err := participants[h].EnrollRound2(others, p2p)
+		if err != nil {
+			return nil, err
+		}
+		sums[h] = sum
+	}
+	p := participants[helpers[0]]
+	vkShares := make(map[uint32]curves.Point, len(participants))
+	for other := range participants {
+		vkShares[other] = p.VkShares[other]
+	}
+	return NewEnrolledParticipant(id, p.feldman.Threshold, testCurve, p.VerificationKey, vkShares, bcast, sums)
+}
+
+func TestDkgVkShares(t *testing.T) {
+	participants := runTestDkg(t, 3, 5)
+	for _, p := range participants {
+		require.Len(t, p.VkShares, 5)
+		for id, other := range participants {
+			require.True(t, other.VkShare.Equal(p.VkShares[id]))
+		}
+	}
+
+	net := newComplaintTestNetwork(t, 2, 3)
+	_, errs := net.run(t)
+	for id, p := range net.participants {
+		require.NoError(t, errs[id])
+		for other, q := range net.participants {
+			require.True(t, q.VkShare.Equal(p.VkShares[other]))
+		}
+	}
+}
+
+func TestEnrollParticipant(t *testing.T) {
+	participants := runTestDkg(t, 3, 5)
+	vk := participants[1].VerificationKey
+
+	enrolled, err := runEnrollment(t, participants, 6, 5, 1, 3)
+	require.NoError(t, err)
+	require.Equal(t, uint32(6), enrolled.Id)
+	require.True(t, enrolled.VerificationKey.Equal(vk))
+	require.True(t, testCurve.ScalarBaseMult(enrolled.SkShare).Equal(enrolled.VkShare))
+	require.Len(t, enrolled.VkShares, 6)
+
+	// Nobody adds the new participant before it confirmed its share
+	for _, p := range participants {
+		require.Len(t, p.VkShares, 5)
+	}
+	for _, p := range participants {
+		require.NoError(t, p.Enroll(6))
+	}
+	for _, p := range participants {
+		require.Len(t, p.VkShares, 6)
+		require.True(t, p.VkShares[6].Equal(enrolled.VkShare))
+	}
+
+	// The new share is a share of the same key
+	s, err := sharing.NewShamir(3, 6, testCurve)
+	require.NoError(t, err)
+	sk, err := s.Combine(
+		&sharing.ShamirShare{Id: 6, Value: enrolled.SkShare.Bytes()},
+		&sharing.ShamirShare{Id: 2, Value: participants[2].SkShare.Bytes()},
+		&sharing.ShamirShare{Id: 4, Value: participants[4].SkShare.Bytes()},
+	)
+	require.NoError(t, err)
+	require.True(t, testCurve.ScalarBaseMult(sk).Equal(vk))
+
+	// The new participant can help with the next enrollment
+	participants[6] = enrolled
+	again, err := runEnrollment(t, participants, 7, 6, 2, 4)
+	require.NoError(t, err)
+	require.True(t, again.VkShares[6].Equal(enrolled.VkShare))
+	for _, p := range participants {
+		require.NoError(t, p.Enroll(7))
+	}
+	require.True(t, testCurve.ScalarBaseMult(again.SkShare).Equal(participants[2].VkShares[7]))
+}
+
+func TestEnrollInvalidPiece(t *testing.T) {
+	participants := runTestDkg(t, 2, 3)
+	helpers := []uint32{1, 2}
+	b1, p1, err := participants[1].EnrollRound1(4, helpers, crand.Reader)
+	require.NoError(t, err)
+	b2, p2, err := participants[2].EnrollRound1(4, helpers, crand.Reader)
+	require.NoError(t, err)
+
+	// A piece that doesn't match its commitment
+	tampered := EnrollRound1P2PSend{1: p2[1].Add(testCurve.Scalar.One())}
+	_, err = participants[1].EnrollRound2(map[uint32]*EnrollRound1Bcast{2: b2}, map[uint32]EnrollRound1P2PSend{2: tampered})
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "participant 2")
+
+	// Commitments that don't add up to the helper's verification key share
+	bad := &EnrollRound1Bcast{Commitments: map[uint32]curves.Point{
+		1: b1.Commitments[1].Double(),
+		2: b1.Commitments[2],
+	}}
+	_, err = participants[2].EnrollRound2(map[uint32]*EnrollRound1Bcast{1: bad}, map[uint32]EnrollRound1P2PSend{1: p1})
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "participant 1")
+
+	// The failed enrollment can be started again and completes
+	for _, p := range participants {
+		require.Len(t, p.VkShares, 3)
+	}
+	enrolled, err := runEnrollment(t, participants, 4, 1, 2)
+	require.NoError(t, err)
+	require.True(t, testCurve.ScalarBaseMult(enrolled.SkShare).Equal(enrolled.VkShare))
+}
+
+func TestEnrollAbort(t *testing.T) {
+	participants := runTestDkg(t, 2, 3)
+	_, _, err := participants[1].EnrollRound1(4, []uint32{1, 2}, crand.Reader)
+	require.NoError(t, err)
+	_, _, err = participants[1].EnrollRound1(4, []uint32{1, 3}, crand.Reader)
+	require.Error(t, err)
+
+	participants[1].AbortEnrollment()
+	_, err = participants[1].EnrollRound2(map[uint32]*EnrollRound1Bcast{}, map[uint32]EnrollRound1P2PSend{})
+	require.Error(t, err)
+	enrolled, err := runEnrollment(t, participants, 4, 1, 3)
+	require.NoError(t, err)
+	require.Equal(t, uint32(4), enrolled.Id)
+}
+
+func TestEnrollInvalidInput(t *testing.T) {
+	participants := runTestDkg(t, 2, 3)
+	_, _, err := participants[1].EnrollRound1(3, []uint32{1, 2}, crand.Reader)
+	require.Error(t, err)
+	_, _, err = participants[1].EnrollRound1(4, []uint32{1}, crand.Reader)
+	require.Error(t, err)
+	_, _, err = participants[1].EnrollRound1(4, []uint32{2, 3}, crand.Reader)
+	require.Error(t, err)
+	_, _, err = participants[1].EnrollRound1(4, []uint32{1, 1}, crand.Reader)
+	require.Error(t, err)
+	_, _, err = participants[1].EnrollRound1(4, []uint32{1, 5}, crand.Reader)
+	require.Error(t, err)
+	_, err = participants[1].EnrollRound2(map[uint32]*EnrollRound1Bcast{}, map[uint32]EnrollRound1P2PSend{})
+	require.Error(t, err)
+
+	p, err := NewDkgParticipant(1, 2, Ctx, testCurve, 2)
+	require.NoError(t, err)
+	_, _, err = p.EnrollRound1(3, []uint32{1, 2}, crand.Reader)
+	require.Error(t, err)
+	require.Error(t, p.Enroll(3))
+}
@@ -23,10 +23,14 @@ type DkgParticipant struct {
 	SkShare                curves.Scalar
 	VerificationKey        curves.Point
 	VkShare                curves.Point
-	feldman                *sharing.Feldman
-	verifiers              *sharing.FeldmanVerifier
-	secretShares           []*sharing.ShamirShare
-	ctx                    byte
+	// VkShares are the verification key shares of all participants
+	VkShares     map[uint32]curves.Point
+	feldman      *sharing.Feldman
+	verifiers    *sharing.FeldmanVerifier
+	secretShares []*sharing.ShamirShare
+	ctx          byte
+	complaint    *complaintState
+	enrollment   *enrollmentState
 }
 
 type dkgParticipantData struct {
@@ -63,3 +67,30 @@ func NewDkgParticipant(id, threshold uint32, ctx string, curve *curves.Curve, ot
 		ctx:                    byte(ctxV),
 	}, nil
 }
+
+// vkShares evaluates the sum of the dealers' commitments at every participant's id
+func (dp *DkgParticipant) vkShares(commitments [][]curves.Point) map[uint32]curves.Point {
+	joint := make([]curves.Point, len(commitments[0]))
+	for k := range joint {
+		joint[k] = dp.Curve.NewIdentityPoint()
+		for _, c := range commitments {
+			joint[k] = joint[k].Add(c[k])
+		}
+	}
+	ids := []uint32{dp.Id}
+	for id := range dp.otherParticipantShares {
+		ids = append(ids, id)
+	}
+	shares := make(map[uint32]curves.Point, len(ids))
+	for _, id := range ids {
+		x := dp.Curve.Scalar.New(int(id))
+		power := dp.Curve.Scalar.One()
+		scalars := make([]curves.Scalar, len(joint))
+		for k := range scalars {
+			scalars[k] = power
+			power = power.Mul(x)
+		}
+		shares[id] = dp.Curve.Point.SumOfProducts(joint, scalars)
+	}
+	return shares
+}
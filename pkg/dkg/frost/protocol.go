@@ -0,0 +1,283 @@
+//
+// Copyright Coinbase, Inc. All Rights Reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package frost
+
+import (
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/nerifnetwork/kryptology/pkg/core/curves"
+	"github.com/nerifnetwork/kryptology/pkg/core/protocol"
+	"github.com/nerifnetwork/kryptology/pkg/sharing"
+)
+
+// SupportedVersions are the message versions understood by DkgIterator
+var SupportedVersions = []uint{protocol.Version1}
+
+// DisqualifiedKey is the metadata key of the last DkgIterator message
+// that lists the disqualified participants
+const DisqualifiedKey = "disqualified"
+
+// DkgIterator runs the FROST DKG with the complaint round through the protocol.Iterator interface.
+// The rounds are Round1, Complain, Respond and Finalize. The inputs of each round
+// are the outputs of all participants combined with protocol.Route.
+// A payload that does not decode counts as invalid: a broadcast disqualifies its sender
+// and a share is complained about, as if the sender had sent a message that fails verification.
+type DkgIterator struct {
+	protocol.Stepper
+	participant  *DkgParticipant
+	version      uint
+	disqualified []uint32
+}
+
+// DkgResult is the output of the DKG that a participant needs to sign
+type DkgResult struct {
+	Id              uint32
+	Threshold       uint32
+	SkShare         curves.Scalar
+	VkShare         curves.Point
+	VerificationKey curves.Point
+	// VkShares are the verification key shares of all participants
+	VkShares map[uint32]curves.Point
+}
+
+var _ protocol.Iterator = &DkgIterator{}
+
+// NewDkgIterator creates a DKG participant that satisfies the protocol iterator interface.
+// The version should be negotiated with protocol.NegotiateVersion and SupportedVersions.
+func NewDkgIterator(id, threshold uint32, ctx string, curve *curves.Curve, version uint, otherParticipants ...uint32) (*DkgIterator, error) {
+	if err := protocol.CheckVersion(version, SupportedVersions); err != nil {
+		return nil, err
+	}
+	participant, err := NewDkgParticipant(id, threshold, ctx, curve, otherParticipants...)
+	if err != nil {
+		return nil, err
+	}
+	RegisterTypes(curve)
+	d := &DkgIterator{participant: participant, version: version}
+	d.Steps = []func(*protocol.Message) (*protocol.Message, error){
+		func(*protocol.Message) (*protocol.Message, error) {
+			bcast, p2p, err := d.participant.Round1(nil)
+			if err != nil {
+				return nil, err
+			}
+			output, err := d.newMessage("1", bcast)
+			if err != nil {
+				return nil, err
+			}
+			for recipient, share := range p2p {
+				if output.Payloads[protocol.RecipientKey(recipient)], err = protocol.EncodePayload(share); err != nil {
+					return nil, err
+				}
+			}
+			return output, nil
+		},
+		func(input *protocol.Message) (*protocol.Message, error) {
+			bcast := make(map[uint32]*Round1Bcast)
+			p2p := make(map[uint32]*sharing.ShamirShare)
+			err := d.decodeInput(input, "1",
+				func(id uint32, data []byte) {
+					// Complain disqualifies the sender of a nil broadcast
+					bcast[id] = new(Round1Bcast)
+					if protocol.DecodePayload(data, bcast[id]) != nil {
+						bcast[id] = nil
+					}
+				},
+				func(id uint32, data []byte) {
+					// Complain accuses the sender of a missing share
+					share := new(sharing.ShamirShare)
+					if protocol.DecodePayload(data, share) == nil {
+						p2p[id] = share
+					}
+				})
+			if err != nil {
+				return nil, err
+			}
+			complaint, err := d.participant.Complain(bcast, p2p)
+			if err != nil {
+				return nil, err
+			}
+			return d.newMessage("2", complaint)
+		},
+		func(input *protocol.Message) (*protocol.Message, error) {
+			complaints := make(map[uint32]*ComplaintBcast)
+			err := d.decodeInput(input, "2", func(id uint32, data []byte) {
+				// a complaint that does not decode accuses nobody
+				complaint := new(ComplaintBcast)
+				if protocol.DecodePayload(data, complaint) == nil {
+					complaints[id] = complaint
+				}
+			}, nil)
+			if err != nil {
+				return nil, err
+			}
+			response, err := d.participant.Respond(complaints)
+			if err != nil {
+				return nil, err
+			}
+			return d.newMessage("3", response)
+		},
+		func(input *protocol.Message) (*protocol.Message, error) {
+			responses := make(map[uint32]*ComplaintResponse)
+			err := d.decodeInput(input, "3", func(id uint32, data []byte) {
+				// Finalize disqualifies an accused participant without a response
+				response := new(ComplaintResponse)
+				if protocol.DecodePayload(data, response) == nil {
+					responses[id] = response
+				}
+			}, nil)
+			if err != nil {
+				return nil, err
+			}
+			result, err := d.participant.Finalize(responses)
+			var disqualified *DisqualifiedError
+			if errors.As(err, &disqualified) && result != nil {
+				d.disqualified = disqualified.Culprits
+			} else if err != nil {
+				return nil, err
+			}
+			output, err := d.newMessage("4", result)
+			if err != nil {
+				return nil, err
+			}
+			if len(d.disqualified) > 0 {
+				ids := make([]string, len(d.disqualified))
+				for i, id := range d.disqualified {
+					ids[i] = strconv.FormatUint(uint64(id), 10)
+				}
+				output.Metadata[DisqualifiedKey] = strings.Join(ids, ",")
+			}
+			return output, nil
+		},
+	}
+	return d, nil
+}
+
+// Disqualified returns the participants excluded from the DKG once it has completed
+func (d *DkgIterator) Disqualified() []uint32 {
+	return d.disqualified
+}
+
+// Result returns the encoded DkgResult that can be used to create a signer.
+// Returns nil if the DKG has not completed.
+func (d *DkgIterator) Result(version uint) (*protocol.Message, error) {
+	if !d.Complete() {
+		return nil, nil
+	}
+	dp := d.participant
+	if dp == nil {
+		return nil, protocol.ErrNotInitialized
+	}
+	if err := protocol.CheckVersion(version, SupportedVersions); err != nil {
+		return nil, err
+	}
+	payload, err := protocol.EncodePayload(&DkgResult{
+		Id:              dp.Id,
+		Threshold:       dp.feldman.Threshold,
+		SkShare:         dp.SkShare,
+		VkShare:         dp.VkShare,
+		VerificationKey: dp.VerificationKey,
+		VkShares:        dp.VkShares,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &protocol.Message{
+		Protocol: protocol.FrostDkg,
+		Version:  version,
+		Payloads: map[string][]byte{"result": payload},
+		Metadata: map[string]string{protocol.RoundKey: "result"},
+	}, nil
+}
+
+// DecodeDkgResult decodes the result of a DkgIterator
+func DecodeDkgResult(m *protocol.Message) (*DkgResult, error) {
+	if m == nil {
+		return nil, fmt.Errorf("message cannot be nil")
+	}
+	if m.Protocol != protocol.FrostDkg {
+		return nil, fmt.Errorf("expected a %s message", protocol.FrostDkg)
+	}
+	if err := protocol.CheckVersion(m.Version, SupportedVersions); err != nil {
+		return nil, err
+	}
+	result := new(DkgResult)
+	if err := protocol.DecodePayload(m.Payloads["result"], result); err != nil {
+		return nil, err
+	}
+	if result.SkShare == nil || result.VkShare == nil || result.VerificationKey == nil {
+		return nil, fmt.Errorf("incomplete dkg result")
+	}
+	return result, nil
+}
+
+// RegisterTypes registers the point and scalar types of the curve with gob
+// so payloads that contain them can be decoded
+func RegisterTypes(curve *curves.Curve) {
+	gob.Register(curve.Scalar)
+	gob.Register(curve.Point)
+}
+
+func init() {
+	RegisterTypes(curves.ED25519())
+	RegisterTypes(curves.K256())
+	RegisterTypes(curves.P256())
+}
+
+func (d *DkgIterator) newMessage(round string, broadcast interface{}) (*protocol.Message, error) {
+	payload, err := protocol.EncodePayload(broadcast)
+	if err != nil {
+		return nil, err
+	}
+	return &protocol.Message{
+		Protocol: protocol.FrostDkg,
+		Version:  d.version,
+		Payloads: map[string][]byte{protocol.BroadcastKey: payload},
+		Metadata: map[string]string{protocol.RoundKey: round},
+	}, nil
+}
+
+// decodeInput checks the routed input of a round and passes its payloads in order of sender id
+// to the decoders, which leave out the payloads that do not decode
+func (d *DkgIterator) decodeInput(input *protocol.Message, round string, broadcast, direct func(uint32, []byte)) error {
+	if input == nil {
+		return fmt.Errorf("message cannot be nil")
+	}
+	if input.Protocol != protocol.FrostDkg || input.Version != d.version {
+		return fmt.Errorf("expected %s version %d", protocol.FrostDkg, d.version)
+	}
+	if input.Metadata[protocol.RoundKey] != round {
+		return fmt.Errorf("expected the messages of round %s", round)
+	}
+	broadcasts, p2p, err := protocol.Inbound(input)
+	if err != nil {
+		return err
+	}
+	if direct == nil && len(p2p) > 0 {
+		return fmt.Errorf("unexpected direct message in round %s", round)
+	}
+	for _, id := range sortedIds(broadcasts) {
+		broadcast(id, broadcasts[id])
+	}
+	for _, id := range sortedIds(p2p) {
+		direct(id, p2p[id])
+	}
+	return nil
+}
+
+func sortedIds(payloads map[uint32][]byte) []uint32 {
+	ids := make([]uint32, 0, len(payloads))
+	for id := range payloads {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return ids
+}
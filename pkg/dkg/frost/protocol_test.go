@@ -0,0 +1,132 @@
+//
+// Copyright Coinbase, Inc. All Rights Reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package frost
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/nerifnetwork/kryptology/pkg/core/protocol"
+	"github.com/nerifnetwork/kryptology/pkg/sharing"
+)
+
+func newTestDkgIterators(t *testing.T, threshold, n uint32) map[uint32]*DkgIterator {
+	version, err := protocol.NegotiateVersion(SupportedVersions, []uint{protocol.Version0, protocol.Version1})
+	require.NoError(t, err)
+	iterators := make(map[uint32]*DkgIterator, n)
+	for i := uint32(1); i <= n; i++ {
+		var others []uint32
+		for j := uint32(1); j <= n; j++ {
+			if j != i {
+				others = append(others, j)
+			}
+		}
+		iterators[i], err = NewDkgIterator(i, threshold, Ctx, testCurve, version, others...)
+		require.NoError(t, err)
+	}
+	return iterators
+}
+
+// runTestDkgIterators routes the outputs of every round to all participants until they finish
+func runTestDkgIterators(t *testing.T, iterators map[uint32]*DkgIterator) map[uint32]*protocol.Message {
+	inputs := make(map[uint32]*protocol.Message, len(iterators))
+	for {
+		outputs := make(map[uint32]*protocol.Message, len(iterators))
+		finished := 0
+		for id, it := range iterators {
+			output, err := it.Next(inputs[id])
+			if err == protocol.ErrProtocolFinished {
+				finished++
+				continue
+			}
+			require.NoError(t, err)
+			outputs[id] = output
+		}
+		if finished == len(iterators) {
+			return outputs
+		}
+		require.Equal(t, 0, finished)
+		for id := range iterators {
+			var err error
+			inputs[id], err = protocol.Route(id, outputs)
+			require.NoError(t, err)
+		}
+	}
+}
+
+func TestDkgIterator(t *testing.T) {
+	iterators := newTestDkgIterators(t, 3, 4)
+	for _, it := range iterators {
+		result, err := it.Result(protocol.Version1)
+		require.NoError(t, err)
+		require.Nil(t, result)
+	}
+	runTestDkgIterators(t, iterators)
+
+	scheme, err := sharing.NewShamir(3, 4, testCurve)
+	require.NoError(t, err)
+	var shares []*sharing.ShamirShare
+	var results []*DkgResult
+	for id, it := range iterators {
+		require.Empty(t, it.Disqualified())
+		message, err := it.Result(protocol.Version1)
+		require.NoError(t, err)
+		result, err := DecodeDkgResult(message)
+		require.NoError(t, err)
+		require.Equal(t, id, result.Id)
+		require.Equal(t, uint32(3), result.Threshold)
+		require.True(t, result.VkShare.Equal(testCurve.ScalarBaseMult(result.SkShare)))
+		require.True(t, result.VkShares[result.Id].Equal(result.VkShare))
+		results = append(results, result)
+		shares = append(shares, &sharing.ShamirShare{Id: id, Value: result.SkShare.Bytes()})
+	}
+	for _, result := range results[1:] {
+		require.True(t, result.VerificationKey.Equal(results[0].VerificationKey))
+	}
+	sk, err := scheme.Combine(shares...)
+	require.NoError(t, err)
+	require.True(t, testCurve.ScalarBaseMult(sk).Equal(results[0].VerificationKey))
+}
+
+func TestDkgIteratorBadInput(t *testing.T) {
+	_, err := NewDkgIterator(1, 2, Ctx, testCurve, protocol.Version0, 2)
+	require.Error(t, err)
+
+	iterators := newTestDkgIterators(t, 2, 2)
+	outputs := make(map[uint32]*protocol.Message)
+	for id, it := range iterators {
+		outputs[id], err = it.Next(nil)
+		require.NoError(t, err)
+	}
+	input, err := protocol.Route(1, outputs)
+	require.NoError(t, err)
+
+	_, err = iterators[1].Next(nil)
+	require.Error(t, err)
+	wrongRound := *input
+	wrongRound.Metadata = map[string]string{protocol.RoundKey: "2"}
+	_, err = iterators[1].Next(&wrongRound)
+	require.Error(t, err)
+	wrongVersion := *input
+	wrongVersion.Version = protocol.Version0
+	_, err = iterators[1].Next(&wrongVersion)
+	require.Error(t, err)
+
+	_, err = iterators[1].Next(input)
+	require.NoError(t, err)
+
+	_, err = DecodeDkgResult(input)
+	require.Error(t, err)
+}
+
+func TestNegotiateVersion(t *testing.T) {
+	_, err := protocol.NegotiateVersion([]uint{protocol.Version0}, SupportedVersions)
+	require.Error(t, err)
+	_, err = protocol.NegotiateVersion()
+	require.Error(t, err)
+}
@@ -0,0 +1,97 @@
+//
+// Copyright Coinbase, Inc. All Rights Reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package v2
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"sort"
+
+	"git.sr.ht/~sircmpwn/go-bare"
+
+	"github.com/nerifnetwork/kryptology/internal"
+	"github.com/nerifnetwork/kryptology/pkg/core/curves"
+	"github.com/nerifnetwork/kryptology/pkg/dkg/channel"
+	"github.com/nerifnetwork/kryptology/pkg/sharing"
+)
+
+type packetMarshal struct {
+	SecretShare   []byte `bare:"secretShare"`
+	BlindingShare []byte `bare:"blindingShare"`
+}
+
+// SealRound1P2PSend encrypts each packet of Round1 to its recipient
+func (dp *Participant) SealRound1P2PSend(ch *channel.Channel, p2p Round1P2PSend, reader io.Reader) (map[uint32]*channel.Envelope, error) {
+	if ch == nil || p2p == nil {
+		return nil, internal.ErrNilArguments
+	}
+	messages := make(map[uint32][]byte, len(p2p))
+	for id, packet := range p2p {
+		if packet == nil || packet.SecretShare == nil || packet.BlindingShare == nil {
+			return nil, internal.ErrNilArguments
+		}
+		data, err := bare.Marshal(&packetMarshal{
+			SecretShare:   packet.SecretShare.Bytes(),
+			BlindingShare: packet.BlindingShare.Bytes(),
+		})
+		if err != nil {
+			return nil, err
+		}
+		messages[id] = data
+	}
+	return ch.SealAll(messages, reader)
+}
+
+// OpenRound1P2PSend decrypts the packets sent to this participant, keyed by sender,
+// so they can be passed to Round2. It also returns the sorted senders whose packet
+// could not be opened or decoded.
+func (dp *Participant) OpenRound1P2PSend(ch *channel.Channel, envelopes map[uint32]*channel.Envelope) (map[uint32]*Round1P2PSendPacket, []uint32, error) {
+	if ch == nil || envelopes == nil {
+		return nil, nil, internal.ErrNilArguments
+	}
+	messages, failed := ch.OpenAll(envelopes)
+	packets := make(map[uint32]*Round1P2PSendPacket, len(messages))
+	for id, msg := range messages {
+		pm := new(packetMarshal)
+		if err := bare.Unmarshal(msg, pm); err != nil {
+			failed = append(failed, id)
+			continue
+		}
+		secretShare, err := shareFromBytes(dp.curve, pm.SecretShare)
+		if err != nil {
+			failed = append(failed, id)
+			continue
+		}
+		blindingShare, err := shareFromBytes(dp.curve, pm.BlindingShare)
+		if err != nil {
+			failed = append(failed, id)
+			continue
+		}
+		packets[id] = &Round1P2PSendPacket{
+			SecretShare:   secretShare,
+			BlindingShare: blindingShare,
+		}
+	}
+	sort.Slice(failed, func(i, j int) bool { return failed[i] < failed[j] })
+	return packets, failed, nil
+}
+
+// shareFromBytes is the inverse of sharing.ShamirShare.Bytes
+func shareFromBytes(curve *curves.Curve, data []byte) (*sharing.ShamirShare, error) {
+	if len(data) < 5 {
+		return nil, fmt.Errorf("invalid share length")
+	}
+	share := &sharing.ShamirShare{
+		Id:    binary.BigEndian.Uint32(data[:4]),
+		Value: data[4:],
+	}
+	if err := share.Validate(curve); err != nil {
+		return nil, err
+	}
+	return share, nil
+}
@@ -0,0 +1,53 @@
+//
+// Copyright Coinbase, Inc. All Rights Reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package v2
+
+import (
+	crand "crypto/rand"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/nerifnetwork/kryptology/pkg/core/curves"
+	"github.com/nerifnetwork/kryptology/pkg/dkg/channel"
+)
+
+func TestRound1P2PSendChannel(t *testing.T) {
+	curve := curves.ED25519()
+	participants := newTestParticipants(t, curve, 2, 2)
+	bcast, p2p := runRound1(t, participants, nil)
+
+	keys := make(map[uint32]*channel.StaticKey, 2)
+	public := make(map[uint32]curves.Point, 2)
+	for _, id := range []uint32{1, 2} {
+		key, err := channel.NewStaticKey(curve, crand.Reader)
+		require.NoError(t, err)
+		keys[id] = key
+		public[id] = key.Public
+	}
+	ch1, err := channel.NewChannel([]byte("session"), 1, keys[1], public)
+	require.NoError(t, err)
+	ch2, err := channel.NewChannel([]byte("session"), 2, keys[2], public)
+	require.NoError(t, err)
+
+	sealed, err := participants[2].SealRound1P2PSend(ch2, p2p[2], crand.Reader)
+	require.NoError(t, err)
+	opened, failed, err := participants[1].OpenRound1P2PSend(ch1, map[uint32]*channel.Envelope{2: sealed[1]})
+	require.NoError(t, err)
+	require.Empty(t, failed)
+	require.Equal(t, p2p[2][1].SecretShare.Bytes(), opened[2].SecretShare.Bytes())
+	require.Equal(t, p2p[2][1].BlindingShare.Bytes(), opened[2].BlindingShare.Bytes())
+
+	_, err = participants[1].Round2(bcast, opened)
+	require.NoError(t, err)
+
+	// an envelope delivered as from another sender is reported, not opened
+	opened, failed, err = participants[1].OpenRound1P2PSend(ch1, map[uint32]*channel.Envelope{1: sealed[1]})
+	require.NoError(t, err)
+	require.Equal(t, []uint32{1}, failed)
+	require.Empty(t, opened)
+}
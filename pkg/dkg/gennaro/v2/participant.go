@@ -0,0 +1,119 @@
+//
+// Copyright Coinbase, Inc. All Rights Reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+// Package v2 is an implementation of the DKG part of https://eprint.iacr.org/2020/540.pdf
+// on the curves.Point and curves.Scalar interfaces, so it works with any curve in
+// package curves. It runs the same rounds as package gennaro.
+package v2
+
+import (
+	"fmt"
+
+	"github.com/nerifnetwork/kryptology/internal"
+	"github.com/nerifnetwork/kryptology/pkg/core/curves"
+	"github.com/nerifnetwork/kryptology/pkg/sharing"
+)
+
+// Participant is a DKG player that contains information needed to perform DKG rounds
+// and yield a secret key share and public key when finished
+type Participant struct {
+	round                  int
+	curve                  *curves.Curve
+	otherParticipantShares map[uint32]*dkgParticipantData
+	id                     uint32
+	skShare                curves.Scalar
+	verificationKey        curves.Point
+	feldman                *sharing.Feldman
+	pedersen               *sharing.Pedersen
+	pedersenResult         *sharing.PedersenResult
+	generator              curves.Point
+}
+
+// NewParticipant creates a participant ready to perform a DKG
+// `id` is the integer value identifier for this participant
+// `threshold` is the minimum bound for the secret sharing scheme
+// `generator` is the blinding factor generator used by pedersen's verifiable secret sharing.
+// Its discrete log to the curve's generator must be unknown, e.g. hash a string to the curve.
+// `otherParticipants` is the integer value identifiers for the other participants
+// `id` and `otherParticipants` must be the set of integers 1,2,....,n
+func NewParticipant(id, threshold uint32, generator curves.Point, otherParticipants ...uint32) (*Participant, error) {
+	if generator == nil || len(otherParticipants) == 0 {
+		return nil, internal.ErrNilArguments
+	}
+	err := validIds(append(otherParticipants, id))
+	if err != nil {
+		return nil, err
+	}
+	curve := curves.GetCurveByName(generator.CurveName())
+	if curve == nil {
+		return nil, fmt.Errorf("invalid curve")
+	}
+	if generator.Equal(curve.Point.Generator()) {
+		return nil, fmt.Errorf("generator cannot be the curve's generator")
+	}
+
+	limit := uint32(len(otherParticipants)) + 1
+	feldman, err := sharing.NewFeldman(threshold, limit, curve)
+	if err != nil {
+		return nil, err
+	}
+	pedersen, err := sharing.NewPedersen(threshold, limit, generator)
+	if err != nil {
+		return nil, err
+	}
+
+	otherParticipantShares := make(map[uint32]*dkgParticipantData, len(otherParticipants))
+	for _, id := range otherParticipants {
+		otherParticipantShares[id] = &dkgParticipantData{
+			Id: id,
+		}
+	}
+
+	return &Participant{
+		id:                     id,
+		round:                  1,
+		curve:                  curve,
+		feldman:                feldman,
+		pedersen:               pedersen,
+		otherParticipantShares: otherParticipantShares,
+		generator:              generator,
+	}, nil
+}
+
+// Determines if the SSIDs are exactly the values 1..n.
+func validIds(ids []uint32) error {
+	// Index
+	idMap := make(map[uint32]bool, len(ids))
+	for _, id := range ids {
+		idMap[id] = true
+	}
+	// Check
+	for i := 1; i <= len(ids); i++ {
+		if ok := idMap[uint32(i)]; !ok {
+			return fmt.Errorf("the ID list %v is invalid. Values must be 1,2,..,n.", ids)
+		}
+	}
+	return nil
+}
+
+// checkCommitments makes sure the commitments from participant id are well formed
+func (dp *Participant) checkCommitments(id uint32, commitments []curves.Point) error {
+	if uint32(len(commitments)) != dp.feldman.Threshold {
+		return fmt.Errorf("invalid number of commitments from participant %d", id)
+	}
+	for _, c := range commitments {
+		if c == nil || c.CurveName() != dp.curve.Name || !c.IsOnCurve() || c.IsIdentity() {
+			return fmt.Errorf("some commitment is not on curve from participant %d", id)
+		}
+	}
+	return nil
+}
+
+type dkgParticipantData struct {
+	Id        uint32
+	Share     *sharing.ShamirShare
+	Verifiers []curves.Point
+}
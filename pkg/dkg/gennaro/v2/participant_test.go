@@ -0,0 +1,53 @@
+//
+// Copyright Coinbase, Inc. All Rights Reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package v2
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/nerifnetwork/kryptology/internal"
+	"github.com/nerifnetwork/kryptology/pkg/core/curves"
+)
+
+func testGenerator(curve *curves.Curve) curves.Point {
+	return curve.Point.Hash([]byte("gennaro test generator"))
+}
+
+func TestNewParticipantWorks(t *testing.T) {
+	curve := curves.K256()
+	p, err := NewParticipant(1, 2, testGenerator(curve), 2)
+	require.NoError(t, err)
+	require.NotNil(t, p)
+	require.Equal(t, p.id, uint32(1))
+	require.Equal(t, p.round, 1)
+	require.Equal(t, p.curve.Name, curve.Name)
+	require.NotNil(t, p.pedersen)
+	require.NotNil(t, p.feldman)
+	require.Nil(t, p.pedersenResult)
+	_, ok := p.otherParticipantShares[2]
+	require.True(t, ok)
+}
+
+func TestNewParticipantBadInputs(t *testing.T) {
+	curve := curves.K256()
+	_, err := NewParticipant(1, 2, nil, 2)
+	require.Equal(t, err, internal.ErrNilArguments)
+	_, err = NewParticipant(1, 2, testGenerator(curve))
+	require.Equal(t, err, internal.ErrNilArguments)
+	_, err = NewParticipant(1, 2, curve.Point.Generator(), 2)
+	require.Error(t, err)
+	_, err = NewParticipant(1, 3, testGenerator(curve), 2)
+	require.Error(t, err)
+	_, err = NewParticipant(3, 2, testGenerator(curve), 4)
+	require.Error(t, err)
+	_, err = NewParticipant(0, 2, testGenerator(curve), 1)
+	require.Error(t, err)
+	_, err = NewParticipant(2, 2, testGenerator(curve), 2, 3, 5)
+	require.Error(t, err)
+}
@@ -0,0 +1,108 @@
+//
+// Copyright Coinbase, Inc. All Rights Reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package v2
+
+import (
+	"fmt"
+
+	"github.com/nerifnetwork/kryptology/internal"
+	"github.com/nerifnetwork/kryptology/pkg/core/curves"
+	"github.com/nerifnetwork/kryptology/pkg/sharing"
+)
+
+// Round2Bcast are the values that are broadcast to all other participants
+// after round2 completes
+type Round2Bcast = []curves.Point
+
+// Round2 computes the second round for Gennaro DKG
+// Algorithm 3 - Gennaro DKG Round 2
+// bcast contains all Round1 broadcast from other participants to this participant
+// p2p contains all Round1 P2P send message from other participants to this participant
+func (dp *Participant) Round2(bcast map[uint32]Round1Bcast, p2p map[uint32]*Round1P2PSendPacket) (Round2Bcast, error) {
+	// Check participant is not empty
+	if dp == nil || dp.curve == nil {
+		return nil, internal.ErrNilArguments
+	}
+
+	// Check participant has the correct dkg round number
+	if dp.round != 2 {
+		return nil, internal.ErrInvalidRound
+	}
+
+	// Check the input is valid
+	if bcast == nil || p2p == nil || len(bcast) == 0 || len(p2p) == 0 {
+		return nil, internal.ErrNilArguments
+	}
+
+	// Every other participant must have dealt, otherwise round 3 would find no share for it
+	for id := range dp.otherParticipantShares {
+		if _, ok := bcast[id]; !ok {
+			return nil, fmt.Errorf("missing broadcast from participant id=%v", id)
+		}
+		if _, ok := p2p[id]; !ok {
+			return nil, fmt.Errorf("missing p2p packet for id=%v", id)
+		}
+	}
+
+	// 1. set sk = x_{ii}
+	sk, err := dp.curve.Scalar.SetBytes(dp.pedersenResult.SecretShares[dp.id-1].Value)
+	if err != nil {
+		return nil, err
+	}
+
+	// 2. for j in 1,...,n
+	for id := range bcast {
+		// 3. if i = j continue
+		if id == dp.id {
+			continue
+		}
+		if _, ok := dp.otherParticipantShares[id]; !ok {
+			return nil, fmt.Errorf("unknown participant id=%v", id)
+		}
+
+		// Ensure a valid p2p entry exists
+		if p2p[id] == nil || p2p[id].SecretShare == nil || p2p[id].BlindingShare == nil {
+			return nil, fmt.Errorf("missing p2p packet for id=%v", id)
+		}
+		if err := dp.checkCommitments(id, bcast[id]); err != nil {
+			return nil, err
+		}
+
+		// 4. If PedersenVerify(E, Q, x_ji, r_ji, {X_ji,...,X_jt}) = false, abort
+		xji := p2p[id].SecretShare
+		rji := p2p[id].BlindingShare
+		if xji.Id != dp.id || rji.Id != dp.id {
+			return nil, fmt.Errorf("invalid share for participant id=%v", id)
+		}
+		verifier := &sharing.PedersenVerifier{
+			Generator:   dp.generator,
+			Commitments: bcast[id],
+		}
+		if err := verifier.Verify(xji, rji); err != nil {
+			return nil, fmt.Errorf("invalid share for participant id=%v", id)
+		}
+
+		// Store other participants' shares xji for usage in round 3
+		dp.otherParticipantShares[id].Share = xji
+
+		// 5. sk = (sk+xji) mod q
+		x, err := dp.curve.Scalar.SetBytes(xji.Value)
+		if err != nil {
+			return nil, err
+		}
+		sk = sk.Add(x)
+	}
+
+	// Update internal state
+	dp.round = 3
+
+	// 7. Store ski as participant i's secret key share
+	dp.skShare = sk
+
+	// 6. EchoBroadcast {R_1,...,R_t} to all other participants.
+	return dp.pedersenResult.FeldmanVerifier.Commitments, nil
+}
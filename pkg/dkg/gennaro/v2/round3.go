@@ -0,0 +1,83 @@
+//
+// Copyright Coinbase, Inc. All Rights Reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package v2
+
+import (
+	"fmt"
+
+	"github.com/nerifnetwork/kryptology/internal"
+	"github.com/nerifnetwork/kryptology/pkg/core/curves"
+	"github.com/nerifnetwork/kryptology/pkg/sharing"
+)
+
+// Round3Bcast contains values that will be broadcast to other participants.
+type Round3Bcast = curves.Point
+
+// Round3 computes the third round for Gennaro DKG
+// Algorithm 4 - Gennaro DKG Round 3
+// bcast contains all Round2 broadcast from other participants to this participant.
+func (dp *Participant) Round3(bcast map[uint32]Round2Bcast) (Round3Bcast, *sharing.ShamirShare, error) {
+	// Check participant is not empty
+	if dp == nil || dp.curve == nil {
+		return nil, nil, internal.ErrNilArguments
+	}
+
+	// Check participant has the correct dkg round number
+	if dp.round != 3 {
+		return nil, nil, internal.ErrInvalidRound
+	}
+
+	// Check the input is valid
+	if len(bcast) == 0 {
+		return nil, nil, internal.ErrNilArguments
+	}
+
+	// 1. SetBigInt Pk = R_i1
+	pk := dp.pedersenResult.FeldmanVerifier.Commitments[0]
+
+	// 2. for j in 1,...,n
+	for id := range dp.otherParticipantShares {
+		vs, ok := bcast[id]
+		if !ok {
+			return nil, nil, fmt.Errorf("missing broadcast from participant id=%v", id)
+		}
+		if err := dp.checkCommitments(id, vs); err != nil {
+			return nil, nil, err
+		}
+
+		// 4. If FeldmanVerify(E, xji, {R_j1,...,R_jt}) = false; abort
+		xji := dp.otherParticipantShares[id].Share
+		if xji == nil {
+			return nil, nil, fmt.Errorf("missing share from participant id=%v", id)
+		}
+		verifier := &sharing.FeldmanVerifier{Commitments: vs}
+		if err := verifier.Verify(xji); err != nil {
+			return nil, nil, fmt.Errorf("invalid share for participant id=%v", id)
+		}
+
+		// Store the feldman verifiers for round 4
+		dp.otherParticipantShares[id].Verifiers = vs
+
+		// 5. Pk = Pk+R_j1
+		pk = pk.Add(vs[0])
+	}
+
+	// This is a sanity check to make sure nothing went wrong
+	// when computing the public key
+	if !pk.IsOnCurve() || pk.IsIdentity() {
+		return nil, nil, fmt.Errorf("invalid public key")
+	}
+
+	// 6. Store Pk as the public verification key
+	dp.verificationKey = pk
+
+	// Update internal state
+	dp.round = 4
+
+	// Output Pk as the public verification key
+	return pk, &sharing.ShamirShare{Id: dp.id, Value: dp.skShare.Bytes()}, nil
+}
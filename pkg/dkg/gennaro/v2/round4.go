@@ -0,0 +1,52 @@
+//
+// Copyright Coinbase, Inc. All Rights Reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package v2
+
+import (
+	"github.com/nerifnetwork/kryptology/internal"
+	"github.com/nerifnetwork/kryptology/pkg/core/curves"
+)
+
+// Round4 computes the public shares W_j = sk_j * G of all participants, which let
+// anyone check the output of a participant and are converted to additive shares once
+// the signing participants are known. This function is idempotent
+func (dp *Participant) Round4() (map[uint32]curves.Point, error) {
+	// Check participant is not empty
+	if dp == nil || dp.curve == nil {
+		return nil, internal.ErrNilArguments
+	}
+
+	// Check participant has the correct dkg round number
+	if dp.round != 4 {
+		return nil, internal.ErrInvalidRound
+	}
+
+	// 1. C_k = Σ_i R_ik are the commitments to the joint polynomial
+	commitments := make([]curves.Point, len(dp.pedersenResult.FeldmanVerifier.Commitments))
+	copy(commitments, dp.pedersenResult.FeldmanVerifier.Commitments)
+	for _, data := range dp.otherParticipantShares {
+		for k, r := range data.Verifiers {
+			commitments[k] = commitments[k].Add(r)
+		}
+	}
+
+	// 2. for j in 1,...,n
+	n := len(dp.otherParticipantShares) + 1
+	publicShares := make(map[uint32]curves.Point, n)
+	for j := 1; j <= n; j++ {
+		// 3. W_j = Σ_k j^k * C_k
+		x := dp.curve.Scalar.New(j)
+		scalars := make([]curves.Scalar, len(commitments))
+		power := dp.curve.Scalar.One()
+		for k := range scalars {
+			scalars[k] = power
+			power = power.Mul(x)
+		}
+		publicShares[uint32(j)] = dp.curve.Point.SumOfProducts(commitments, scalars)
+	}
+	return publicShares, nil
+}
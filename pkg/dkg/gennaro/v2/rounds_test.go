@@ -0,0 +1,203 @@
+//
+// Copyright Coinbase, Inc. All Rights Reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package v2
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/nerifnetwork/kryptology/pkg/core/curves"
+	"github.com/nerifnetwork/kryptology/pkg/sharing"
+)
+
+var testCurves = []*curves.Curve{
+	curves.K256(),
+	curves.P256(),
+	curves.ED25519(),
+	curves.PALLAS(),
+	curves.BLS12381G1(),
+	curves.BLS12381G2(),
+}
+
+func newTestParticipants(t *testing.T, curve *curves.Curve, threshold, n uint32) map[uint32]*Participant {
+	participants := make(map[uint32]*Participant, n)
+	for id := uint32(1); id <= n; id++ {
+		others := make([]uint32, 0, n-1)
+		for other := uint32(1); other <= n; other++ {
+			if other != id {
+				others = append(others, other)
+			}
+		}
+		p, err := NewParticipant(id, threshold, testGenerator(curve), others...)
+		require.NoError(t, err)
+		participants[id] = p
+	}
+	return participants
+}
+
+func runRound1(t *testing.T, participants map[uint32]*Participant, secrets map[uint32]curves.Scalar) (map[uint32]Round1Bcast, map[uint32]Round1P2PSend) {
+	bcast := make(map[uint32]Round1Bcast, len(participants))
+	p2p := make(map[uint32]Round1P2PSend, len(participants))
+	for id, p := range participants {
+		b, s, err := p.Round1(secrets[id])
+		require.NoError(t, err)
+		bcast[id] = b
+		p2p[id] = s
+	}
+	return bcast, p2p
+}
+
+func p2pFor(id uint32, p2p map[uint32]Round1P2PSend) map[uint32]*Round1P2PSendPacket {
+	out := make(map[uint32]*Round1P2PSendPacket, len(p2p)-1)
+	for sender, packets := range p2p {
+		if sender != id {
+			out[sender] = packets[id]
+		}
+	}
+	return out
+}
+
+func runDkg(t *testing.T, participants map[uint32]*Participant, secrets map[uint32]curves.Scalar) (curves.Point, map[uint32]*sharing.ShamirShare) {
+	bcast, p2p := runRound1(t, participants, secrets)
+	round2 := make(map[uint32]Round2Bcast, len(participants))
+	for id, p := range participants {
+		b, err := p.Round2(bcast, p2pFor(id, p2p))
+		require.NoError(t, err)
+		round2[id] = b
+	}
+	var pk curves.Point
+	shares := make(map[uint32]*sharing.ShamirShare, len(participants))
+	for id, p := range participants {
+		key, share, err := p.Round3(round2)
+		require.NoError(t, err)
+		if pk == nil {
+			pk = key
+		}
+		require.True(t, pk.Equal(key))
+		shares[id] = share
+	}
+	return pk, shares
+}
+
+func TestAllGennaroDkgRounds(t *testing.T) {
+	for _, curve := range testCurves {
+		participants := newTestParticipants(t, curve, 3, 5)
+		pk, shares := runDkg(t, participants, nil)
+
+		shamir, err := sharing.NewShamir(3, 5, curve)
+		require.NoError(t, err)
+		sk, err := shamir.Combine(shares[1], shares[3], shares[5])
+		require.NoError(t, err)
+		require.True(t, curve.ScalarBaseMult(sk).Equal(pk), curve.Name)
+
+		publicShares, err := participants[2].Round4()
+		require.NoError(t, err)
+		require.Len(t, publicShares, 5)
+		for id, share := range shares {
+			s, err := curve.Scalar.SetBytes(share.Value)
+			require.NoError(t, err)
+			require.True(t, curve.ScalarBaseMult(s).Equal(publicShares[id]))
+		}
+		// Round4 is idempotent and the same for everyone
+		again, err := participants[4].Round4()
+		require.NoError(t, err)
+		for id, w := range publicShares {
+			require.True(t, w.Equal(again[id]))
+		}
+	}
+}
+
+func TestGennaroDkgResharing(t *testing.T) {
+	curve := curves.ED25519()
+	pk, shares := runDkg(t, newTestParticipants(t, curve, 2, 3), nil)
+
+	// Every participant deals its share weighted by its Lagrange coefficient
+	shamir, err := sharing.NewShamir(2, 3, curve)
+	require.NoError(t, err)
+	lambdas, err := shamir.LagrangeCoeffs([]uint32{1, 2, 3})
+	require.NoError(t, err)
+	secrets := make(map[uint32]curves.Scalar, 3)
+	for id, share := range shares {
+		s, err := curve.Scalar.SetBytes(share.Value)
+		require.NoError(t, err)
+		secrets[id] = s.Mul(lambdas[id])
+	}
+	participants := newTestParticipants(t, curve, 2, 3)
+	newPk, newShares := runDkg(t, participants, secrets)
+	require.True(t, pk.Equal(newPk))
+	sk, err := shamir.Combine(newShares[1], newShares[3])
+	require.NoError(t, err)
+	require.True(t, curve.ScalarBaseMult(sk).Equal(pk))
+	require.NotEqual(t, shares[1].Value, newShares[1].Value)
+}
+
+func TestParticipantRoundOrder(t *testing.T) {
+	curve := curves.P256()
+	participants := newTestParticipants(t, curve, 2, 2)
+	_, err := participants[1].Round2(nil, nil)
+	require.Error(t, err)
+	_, _, err = participants[1].Round3(nil)
+	require.Error(t, err)
+	_, err = participants[1].Round4()
+	require.Error(t, err)
+	_, _, err = participants[1].Round1(nil)
+	require.NoError(t, err)
+	_, _, err = participants[1].Round1(nil)
+	require.Error(t, err)
+}
+
+func TestParticipantBadSecret(t *testing.T) {
+	curve := curves.K256()
+	p, err := NewParticipant(1, 2, testGenerator(curve), 2)
+	require.NoError(t, err)
+	_, _, err = p.Round1(curve.Scalar.Zero())
+	require.Error(t, err)
+	_, _, err = p.Round1(curves.P256().Scalar.One())
+	require.Error(t, err)
+}
+
+func TestParticipantBadShares(t *testing.T) {
+	curve := curves.PALLAS()
+	participants := newTestParticipants(t, curve, 2, 3)
+	bcast, p2p := runRound1(t, participants, nil)
+
+	// A share that doesn't match the Pedersen commitments
+	packets := p2pFor(1, p2p)
+	s, err := curve.Scalar.SetBytes(packets[2].SecretShare.Value)
+	require.NoError(t, err)
+	packets[2] = &Round1P2PSendPacket{
+		SecretShare:   &sharing.ShamirShare{Id: 1, Value: s.Add(curve.Scalar.One()).Bytes()},
+		BlindingShare: packets[2].BlindingShare,
+	}
+	_, err = participants[1].Round2(bcast, packets)
+	require.Error(t, err)
+
+	// Missing packet
+	packets = p2pFor(2, p2p)
+	delete(packets, 3)
+	_, err = participants[2].Round2(bcast, packets)
+	require.Error(t, err)
+
+	// A participant that sent nothing at all
+	partial := map[uint32]Round1Bcast{2: bcast[2], 3: bcast[3]}
+	packets = p2pFor(3, p2p)
+	delete(packets, 1)
+	_, err = participants[3].Round2(partial, packets)
+	require.Error(t, err)
+
+	// Feldman commitments that don't match the Pedersen ones
+	round2 := make(map[uint32]Round2Bcast, 3)
+	for _, id := range []uint32{1, 3} {
+		b, err := participants[id].Round2(bcast, p2pFor(id, p2p))
+		require.NoError(t, err)
+		round2[id] = b
+	}
+	round2[3] = []curves.Point{round2[3][0].Double(), round2[3][1]}
+	_, _, err = participants[1].Round3(round2)
+	require.Error(t, err)
+}
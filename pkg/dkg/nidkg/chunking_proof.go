@@ -0,0 +1,210 @@
+//
+// Copyright Coinbase, Inc. All Rights Reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package nidkg
+
+import (
+	crand "crypto/rand"
+	"fmt"
+	"io"
+	"math/big"
+
+	"github.com/nerifnetwork/kryptology/pkg/core/curves"
+)
+
+// ChunkingProof shows that the chunks encrypted in C_ij = r_j * y_i + s_ij * G1 with
+// R_j = r_j * G1 are in [0, 2^16), so every receiver can decrypt them.
+//
+// The dealer commits to the bits of each chunk with B_ijk = b_ijk * G1 + t_ijk * y_i where
+// Σ_k 2^k * t_ijk = r_j, so Σ_k 2^k * B_ijk = C_ij, and proves for every commitment that
+// B_ijk = t * y_i or B_ijk - G1 = t * y_i. Unlike the approximate proof of section 6.5 of
+// https://eprint.iacr.org/2021/339.pdf it bounds the chunks exactly, at the cost of a proof
+// that grows with the bits of the shares.
+type ChunkingProof struct {
+	// Bits are the commitments B_ijk of receiver i at j * 16 + k, in the order of the receiver ids
+	Bits [][]curves.Point
+	// T0 and T1 are the commitments of the cases b_ijk = 0 and b_ijk = 1. C0 are the challenges
+	// of the case 0, the case 1 has the challenge of the proof minus C0, and S0 and S1 are the
+	// responses of both cases.
+	T0, T1     [][]curves.Point
+	C0, S0, S1 [][]curves.Scalar
+}
+
+// chunkingStatement is what the chunking proof is about
+type chunkingStatement struct {
+	instance    []byte
+	keys        []curves.Point
+	randomizers []curves.Point
+	ciphertexts [][]curves.Point
+}
+
+// numBits is the number of bit commitments of a receiver
+const numBits = numChunks * chunkBits
+
+func proveChunking(st *chunkingStatement, r []curves.Scalar, chunks [][]*big.Int, reader io.Reader) (*ChunkingProof, error) {
+	g1 := curves.BLS12381G1()
+	g := g1.Point.Generator()
+	n := len(st.keys)
+	proof := &ChunkingProof{
+		Bits: make([][]curves.Point, n),
+		T0:   make([][]curves.Point, n),
+		T1:   make([][]curves.Point, n),
+		C0:   make([][]curves.Scalar, n),
+		S0:   make([][]curves.Scalar, n),
+		S1:   make([][]curves.Scalar, n),
+	}
+	// the blindings t_ijk and nonces w_ijk of the real case of each bit
+	blindings := make([][]curves.Scalar, n)
+	nonces := make([][]curves.Scalar, n)
+	for i, y := range st.keys {
+		proof.Bits[i] = make([]curves.Point, numBits)
+		proof.T0[i] = make([]curves.Point, numBits)
+		proof.T1[i] = make([]curves.Point, numBits)
+		proof.C0[i] = make([]curves.Scalar, numBits)
+		proof.S0[i] = make([]curves.Scalar, numBits)
+		proof.S1[i] = make([]curves.Scalar, numBits)
+		blindings[i] = make([]curves.Scalar, numBits)
+		nonces[i] = make([]curves.Scalar, numBits)
+		for j := 0; j < numChunks; j++ {
+			// t_ij0 = r_j - Σ_{k>0} 2^k * t_ijk
+			t := r[j]
+			power := g1.Scalar.One()
+			for k := 1; k < chunkBits; k++ {
+				power = power.Double()
+				blindings[i][j*chunkBits+k] = g1.Scalar.Random(reader)
+				t = t.Sub(power.Mul(blindings[i][j*chunkBits+k]))
+			}
+			blindings[i][j*chunkBits] = t
+
+			for k := 0; k < chunkBits; k++ {
+				b := j*chunkBits + k
+				bit := chunks[i][j].Bit(k) == 1
+				proof.Bits[i][b] = y.Mul(blindings[i][b])
+				if bit {
+					proof.Bits[i][b] = proof.Bits[i][b].Add(g)
+				}
+				nonces[i][b] = g1.Scalar.Random(reader)
+
+				// simulate the other case with a random challenge and response,
+				// T = s * y_i - c * (B - b' * G1) = (s - c * t) * y_i -/+ c * G1
+				c, s := g1.Scalar.Random(reader), g1.Scalar.Random(reader)
+				fake := y.Mul(s.Sub(c.Mul(blindings[i][b])))
+				if bit {
+					proof.C0[i][b], proof.S0[i][b] = c, s
+					proof.T0[i][b] = fake.Sub(g.Mul(c))
+					proof.T1[i][b] = y.Mul(nonces[i][b])
+				} else {
+					// C0 holds the challenge of the case 1 until the challenge is known
+					proof.C0[i][b], proof.S1[i][b] = c, s
+					proof.T0[i][b] = y.Mul(nonces[i][b])
+					proof.T1[i][b] = fake.Add(g.Mul(c))
+				}
+			}
+		}
+	}
+
+	c := st.challenge(proof)
+	for i := range st.keys {
+		for j := 0; j < numChunks; j++ {
+			for k := 0; k < chunkBits; k++ {
+				b := j*chunkBits + k
+				if chunks[i][j].Bit(k) == 1 {
+					// s1 = w + (c - c0) * t
+					proof.S1[i][b] = nonces[i][b].Add(c.Sub(proof.C0[i][b]).Mul(blindings[i][b]))
+				} else {
+					// c0 = c - c1, s0 = w + c0 * t
+					proof.C0[i][b] = c.Sub(proof.C0[i][b])
+					proof.S0[i][b] = nonces[i][b].Add(proof.C0[i][b].Mul(blindings[i][b]))
+				}
+			}
+		}
+	}
+	return proof, nil
+}
+
+func (p ChunkingProof) verify(st *chunkingStatement) error {
+	n := len(st.keys)
+	if len(p.Bits) != n || len(p.T0) != n || len(p.T1) != n || len(p.C0) != n || len(p.S0) != n || len(p.S1) != n {
+		return fmt.Errorf("invalid chunking proof length")
+	}
+	for i := 0; i < n; i++ {
+		if len(p.Bits[i]) != numBits || len(p.T0[i]) != numBits || len(p.T1[i]) != numBits ||
+			len(p.C0[i]) != numBits || len(p.S0[i]) != numBits || len(p.S1[i]) != numBits {
+			return fmt.Errorf("invalid chunking proof length")
+		}
+		if err := checkPoints(curves.BLS12381G1Name, append(append(append([]curves.Point{}, p.Bits[i]...), p.T0[i]...), p.T1[i]...)...); err != nil {
+			return err
+		}
+		if err := checkScalars(append(append(append([]curves.Scalar{}, p.C0[i]...), p.S0[i]...), p.S1[i]...)...); err != nil {
+			return err
+		}
+	}
+	for i := range st.keys {
+		for j := 0; j < numChunks; j++ {
+			// C_ij = Σ_k 2^k * B_ijk
+			if !combineBits(p.Bits[i][j*chunkBits : (j+1)*chunkBits]).Equal(st.ciphertexts[i][j]) {
+				return fmt.Errorf("invalid chunking proof")
+			}
+		}
+	}
+
+	// Every bit satisfies T0 = s0 * y_i - c0 * B and T1 = s1 * y_i - (c - c0) * (B - G1),
+	// which are checked at once in a random linear combination with weights ρ0 and ρ1:
+	// Σ_i (Σ_b ρ0 * s0 + ρ1 * s1) * y_i - Σ_ib (ρ0 * c0 + ρ1 * c1) * B + (Σ_ib ρ1 * c1) * G1 - Σ_ib ρ0 * T0 + ρ1 * T1 = 0
+	g1 := curves.BLS12381G1()
+	c := st.challenge(&p)
+	points := make([]curves.Point, 0, n*(3*numBits+1)+1)
+	scalars := make([]curves.Scalar, 0, n*(3*numBits+1)+1)
+	g := g1.Scalar.Zero()
+	for i, y := range st.keys {
+		yi := g1.Scalar.Zero()
+		for b := range p.Bits[i] {
+			rho0, rho1 := g1.Scalar.Random(crand.Reader), g1.Scalar.Random(crand.Reader)
+			c1 := c.Sub(p.C0[i][b])
+			yi = yi.Add(rho0.Mul(p.S0[i][b])).Add(rho1.Mul(p.S1[i][b]))
+			g = g.Add(rho1.Mul(c1))
+			points = append(points, p.Bits[i][b], p.T0[i][b], p.T1[i][b])
+			scalars = append(scalars, rho0.Mul(p.C0[i][b]).Add(rho1.Mul(c1)).Neg(), rho0.Neg(), rho1.Neg())
+		}
+		points = append(points, y)
+		scalars = append(scalars, yi)
+	}
+	points = append(points, g1.Point.Generator())
+	scalars = append(scalars, g)
+	if !g1.Point.SumOfProducts(points, scalars).IsIdentity() {
+		return fmt.Errorf("invalid chunking proof")
+	}
+	return nil
+}
+
+// combineBits returns Σ_k 2^k * B_k
+func combineBits(bits []curves.Point) curves.Point {
+	out := bits[len(bits)-1]
+	for k := len(bits) - 2; k >= 0; k-- {
+		out = out.Double().Add(bits[k])
+	}
+	return out
+}
+
+func (st chunkingStatement) challenge(p *ChunkingProof) curves.Scalar {
+	msg := append([]byte("kryptology nidkg chunking"), st.instance...)
+	for _, r := range st.randomizers {
+		msg = append(msg, r.ToAffineCompressed()...)
+	}
+	for _, row := range st.ciphertexts {
+		for _, c := range row {
+			msg = append(msg, c.ToAffineCompressed()...)
+		}
+	}
+	for _, points := range [][][]curves.Point{p.Bits, p.T0, p.T1} {
+		for _, row := range points {
+			for _, point := range row {
+				msg = append(msg, point.ToAffineCompressed()...)
+			}
+		}
+	}
+	return curves.BLS12381G1().Scalar.Hash(msg)
+}
@@ -0,0 +1,211 @@
+//
+// Copyright Coinbase, Inc. All Rights Reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package nidkg
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math/big"
+
+	"github.com/nerifnetwork/kryptology/internal"
+	"github.com/nerifnetwork/kryptology/pkg/core/curves"
+	"github.com/nerifnetwork/kryptology/pkg/sharing"
+)
+
+// Dealing is the single message a dealer publishes
+type Dealing struct {
+	// Commitments are the Feldman commitments A_k = a_k * G2 to the polynomial
+	Commitments []curves.Point
+	// Randomizers are R_j = r_j * G1 shared by all receivers
+	Randomizers []curves.Point
+	// Ciphertexts are C_ij = r_j * y_i + s_ij * G1 for the chunks of the share of receiver i
+	Ciphertexts   map[uint32][]curves.Point
+	SharingProof  *SharingProof
+	ChunkingProof *ChunkingProof
+}
+
+// Deal shares secret among the receivers. A nil secret deals a random one. To reshare,
+// a member of the previous committee passes its share and its previous id as the dealer,
+// and the dealings are combined with AggregateReshare instead of Aggregate.
+func (n Nidkg) Deal(dealer uint32, secret curves.Scalar, reader io.Reader) (*Dealing, error) {
+	return n.deal(dealer, secret, reader, splitChunks)
+}
+
+func (n Nidkg) deal(dealer uint32, secret curves.Scalar, reader io.Reader, split func(curves.Scalar) []*big.Int) (*Dealing, error) {
+	if reader == nil {
+		return nil, internal.ErrNilArguments
+	}
+	g1 := curves.BLS12381G1()
+	g2 := curves.BLS12381G2()
+	if secret == nil {
+		secret = g1.Scalar.Random(reader)
+	}
+	if err := checkScalars(secret); err != nil {
+		return nil, err
+	}
+
+	poly := new(sharing.Polynomial).Init(secret, n.threshold, reader)
+	commitments := make([]curves.Point, len(poly.Coefficients))
+	for k, a := range poly.Coefficients {
+		commitments[k] = g2.ScalarBaseMult(a)
+	}
+
+	r := make([]curves.Scalar, numChunks)
+	randomizers := make([]curves.Point, numChunks)
+	for j := range r {
+		r[j] = g1.Scalar.Random(reader)
+		randomizers[j] = g1.ScalarBaseMult(r[j])
+	}
+
+	shares := make([]curves.Scalar, len(n.receivers))
+	chunks := make([][]*big.Int, len(n.receivers))
+	ciphertexts := make(map[uint32][]curves.Point, len(n.receivers))
+	for i, id := range n.receivers {
+		shares[i] = poly.Evaluate(g1.Scalar.New(int(id)))
+		chunks[i] = split(shares[i])
+		ciphertexts[id] = make([]curves.Point, numChunks)
+		for j, chunk := range chunks[i] {
+			ciphertexts[id][j] = n.keys[id].Mul(r[j]).Add(g1.ScalarBaseMult(scalarFromBig(chunk)))
+		}
+	}
+
+	dealing := &Dealing{
+		Commitments: commitments,
+		Randomizers: randomizers,
+		Ciphertexts: ciphertexts,
+	}
+	instance := n.instance(dealer, dealing)
+
+	// r = Σ_j B^j * r_j opens the combined ciphertexts
+	combinedR := g1.Scalar.Zero()
+	for j := numChunks - 1; j >= 0; j-- {
+		combinedR = combinedR.Mul(chunkBase()).Add(r[j])
+	}
+	dealing.SharingProof = proveSharing(n.sharingStatement(instance, dealing), combinedR, shares, reader)
+
+	var err error
+	dealing.ChunkingProof, err = proveChunking(n.chunkingStatement(instance, dealing), r, chunks, reader)
+	if err != nil {
+		return nil, err
+	}
+	return dealing, nil
+}
+
+// Verify checks that the dealing from dealer is well formed and that every receiver
+// can decrypt a share consistent with the commitments
+func (n Nidkg) Verify(dealer uint32, dealing *Dealing) error {
+	if dealing == nil || dealing.SharingProof == nil || dealing.ChunkingProof == nil {
+		return internal.ErrNilArguments
+	}
+	if len(dealing.Commitments) != int(n.threshold) {
+		return fmt.Errorf("expected %d commitments", n.threshold)
+	}
+	if err := checkPoints(curves.BLS12381G2Name, dealing.Commitments...); err != nil {
+		return err
+	}
+	if len(dealing.Randomizers) != numChunks {
+		return fmt.Errorf("expected %d randomizers", numChunks)
+	}
+	if err := checkPoints(curves.BLS12381G1Name, dealing.Randomizers...); err != nil {
+		return err
+	}
+	if len(dealing.Ciphertexts) != len(n.receivers) {
+		return fmt.Errorf("expected ciphertexts for %d receivers", len(n.receivers))
+	}
+	for _, id := range n.receivers {
+		c, ok := dealing.Ciphertexts[id]
+		if !ok || len(c) != numChunks {
+			return fmt.Errorf("invalid ciphertexts for receiver %d", id)
+		}
+		if err := checkPoints(curves.BLS12381G1Name, c...); err != nil {
+			return err
+		}
+	}
+
+	instance := n.instance(dealer, dealing)
+	if err := dealing.SharingProof.verify(n.sharingStatement(instance, dealing)); err != nil {
+		return err
+	}
+	return dealing.ChunkingProof.verify(n.chunkingStatement(instance, dealing))
+}
+
+// instance binds the proofs to the setting, the dealer and the dealing
+func (n Nidkg) instance(dealer uint32, dealing *Dealing) []byte {
+	var buf [4]byte
+	msg := []byte("kryptology nidkg instance")
+	msg = append(msg, n.sessionId...)
+	binary.BigEndian.PutUint32(buf[:], dealer)
+	msg = append(msg, buf[:]...)
+	binary.BigEndian.PutUint32(buf[:], n.threshold)
+	msg = append(msg, buf[:]...)
+	for _, id := range n.receivers {
+		binary.BigEndian.PutUint32(buf[:], id)
+		msg = append(msg, buf[:]...)
+		msg = append(msg, n.keys[id].ToAffineCompressed()...)
+	}
+	for _, a := range dealing.Commitments {
+		msg = append(msg, a.ToAffineCompressed()...)
+	}
+	return msg
+}
+
+func (n Nidkg) sharingStatement(instance []byte, dealing *Dealing) *sharingStatement {
+	st := &sharingStatement{
+		instance:    instance,
+		keys:        make([]curves.Point, len(n.receivers)),
+		commitments: dealing.Commitments,
+		ids:         n.receivers,
+		r:           combine(dealing.Randomizers),
+		ciphertexts: make([]curves.Point, len(n.receivers)),
+	}
+	for i, id := range n.receivers {
+		st.keys[i] = n.keys[id]
+		st.ciphertexts[i] = combine(dealing.Ciphertexts[id])
+	}
+	return st
+}
+
+func (n Nidkg) chunkingStatement(instance []byte, dealing *Dealing) *chunkingStatement {
+	st := &chunkingStatement{
+		instance:    instance,
+		keys:        make([]curves.Point, len(n.receivers)),
+		randomizers: dealing.Randomizers,
+		ciphertexts: make([][]curves.Point, len(n.receivers)),
+	}
+	for i, id := range n.receivers {
+		st.keys[i] = n.keys[id]
+		st.ciphertexts[i] = dealing.Ciphertexts[id]
+	}
+	return st
+}
+
+// combine returns Σ_j B^j * P_j
+func combine(points []curves.Point) curves.Point {
+	out := points[0].Identity()
+	for j := len(points) - 1; j >= 0; j-- {
+		out = out.Mul(chunkBase()).Add(points[j])
+	}
+	return out
+}
+
+// chunkBase is B = 2^16 as a scalar
+func chunkBase() curves.Scalar {
+	return curves.BLS12381G1().Scalar.New(chunkSize)
+}
+
+// splitChunks writes s = Σ_j B^j * s_j with s_j in [0, B)
+func splitChunks(s curves.Scalar) []*big.Int {
+	v := new(big.Int).Set(s.BigInt())
+	mask := big.NewInt(chunkSize - 1)
+	chunks := make([]*big.Int, numChunks)
+	for j := range chunks {
+		chunks[j] = new(big.Int).And(v, mask)
+		v.Rsh(v, chunkBits)
+	}
+	return chunks
+}
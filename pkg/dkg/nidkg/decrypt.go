@@ -0,0 +1,77 @@
+//
+// Copyright Coinbase, Inc. All Rights Reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package nidkg
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/nerifnetwork/kryptology/pkg/core/curves"
+)
+
+// babyBits is the size of the baby steps table for solving discrete logs
+const (
+	babyBits = 12
+	babySize = 1 << babyBits
+)
+
+var (
+	babyStepsOnce sync.Once
+	// babySteps maps j * G1 to j for j in [0, 2^12)
+	babySteps map[string]int64
+)
+
+func lookupBabyStep(p curves.Point) (int64, bool) {
+	babyStepsOnce.Do(func() {
+		g1 := curves.BLS12381G1()
+		babySteps = make(map[string]int64, babySize)
+		p := g1.Point.Identity()
+		for j := int64(0); j < babySize; j++ {
+			babySteps[string(p.ToAffineCompressed())] = j
+			p = p.Add(g1.Point.Generator())
+		}
+	})
+	j, ok := babySteps[string(p.ToAffineCompressed())]
+	return j, ok
+}
+
+// decryptDealing recovers the share of receiver id from a verified dealing
+func (n Nidkg) decryptDealing(id uint32, dk *DecryptionKey, dealing *Dealing) (curves.Scalar, error) {
+	ciphertexts, ok := dealing.Ciphertexts[id]
+	if !ok {
+		return nil, fmt.Errorf("no ciphertexts for receiver %d", id)
+	}
+	share := curves.BLS12381G1().Scalar.Zero()
+	for j := numChunks - 1; j >= 0; j-- {
+		// s_ij * G1 = C_ij - sk * R_j
+		m := ciphertexts[j].Sub(dealing.Randomizers[j].Mul(dk.Value))
+		chunk, err := solveChunk(m)
+		if err != nil {
+			return nil, err
+		}
+		share = share.Mul(chunkBase()).Add(chunk)
+	}
+	if !curves.BLS12381G2().ScalarBaseMult(share).Equal(publicKeyShare(dealing.Commitments, id)) {
+		return nil, fmt.Errorf("decrypted share does not match the commitments")
+	}
+	return share, nil
+}
+
+// solveChunk finds the discrete log of m in [0, 2^16), the range the chunking proof
+// guarantees, with at most 2^4 giant steps
+func solveChunk(m curves.Point) (curves.Scalar, error) {
+	g1 := curves.BLS12381G1()
+	step := g1.Point.Generator().Mul(g1.Scalar.New(babySize))
+	p := m
+	for g := int64(0); g < chunkSize/babySize; g++ {
+		if j, ok := lookupBabyStep(p); ok {
+			return g1.Scalar.New(int(g*babySize + j)), nil
+		}
+		p = p.Sub(step)
+	}
+	return nil, fmt.Errorf("chunk is out of range")
+}
@@ -0,0 +1,129 @@
+//
+// Copyright Coinbase, Inc. All Rights Reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package nidkg
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+
+	"git.sr.ht/~sircmpwn/go-bare"
+
+	"github.com/nerifnetwork/kryptology/internal"
+	"github.com/nerifnetwork/kryptology/pkg/core/curves"
+)
+
+// EncryptionKey is the public key of a receiver in G1 with a proof of possession
+// of the decryption key, which prevents receivers from choosing keys related to others'
+type EncryptionKey struct {
+	Value curves.Point
+	Proof *KeyProof
+}
+
+// KeyProof is a Schnorr proof of knowledge of the decryption key
+type KeyProof struct {
+	C, Z curves.Scalar
+}
+
+// DecryptionKey is the secret key of a receiver
+type DecryptionKey struct {
+	Value curves.Scalar
+}
+
+type encryptionKeyMarshal struct {
+	Value []byte `bare:"value"`
+	C     []byte `bare:"c"`
+	Z     []byte `bare:"z"`
+}
+
+// NewKeys creates the key pair of the receiver with the given id
+func NewKeys(id uint32, reader io.Reader) (*EncryptionKey, *DecryptionKey, error) {
+	if reader == nil {
+		return nil, nil, internal.ErrNilArguments
+	}
+	g1 := curves.BLS12381G1()
+	sk := g1.Scalar.Random(reader)
+	if sk.IsZero() {
+		return nil, nil, internal.ErrZeroValue
+	}
+	pk := g1.ScalarBaseMult(sk)
+	k := g1.Scalar.Random(reader)
+	c := keyChallenge(id, pk, g1.ScalarBaseMult(k))
+	return &EncryptionKey{
+		Value: pk,
+		Proof: &KeyProof{C: c, Z: c.MulAdd(sk, k)},
+	}, &DecryptionKey{Value: sk}, nil
+}
+
+// Verify checks the proof of possession of the key of the receiver with the given id
+func (ek EncryptionKey) Verify(id uint32) error {
+	if ek.Value == nil || ek.Proof == nil {
+		return internal.ErrNilArguments
+	}
+	if err := checkPoints(curves.BLS12381G1Name, ek.Value); err != nil {
+		return err
+	}
+	if ek.Value.IsIdentity() {
+		return fmt.Errorf("key cannot be the identity")
+	}
+	if err := checkScalars(ek.Proof.C, ek.Proof.Z); err != nil {
+		return err
+	}
+	// T = z * G - c * pk
+	g1 := curves.BLS12381G1()
+	t := g1.ScalarBaseMult(ek.Proof.Z).Sub(ek.Value.Mul(ek.Proof.C))
+	if keyChallenge(id, ek.Value, t).Cmp(ek.Proof.C) != 0 {
+		return fmt.Errorf("invalid proof of possession")
+	}
+	return nil
+}
+
+// MarshalBinary serializes the key so it can be published
+func (ek EncryptionKey) MarshalBinary() ([]byte, error) {
+	if ek.Value == nil || ek.Proof == nil || ek.Proof.C == nil || ek.Proof.Z == nil {
+		return nil, internal.ErrNilArguments
+	}
+	return bare.Marshal(&encryptionKeyMarshal{
+		Value: ek.Value.ToAffineCompressed(),
+		C:     ek.Proof.C.Bytes(),
+		Z:     ek.Proof.Z.Bytes(),
+	})
+}
+
+// UnmarshalBinary deserializes the key. The proof still has to be checked with Verify.
+func (ek *EncryptionKey) UnmarshalBinary(data []byte) error {
+	em := new(encryptionKeyMarshal)
+	if err := bare.Unmarshal(data, em); err != nil {
+		return err
+	}
+	g1 := curves.BLS12381G1()
+	value, err := g1.Point.FromAffineCompressed(em.Value)
+	if err != nil {
+		return err
+	}
+	c, err := g1.Scalar.SetBytes(em.C)
+	if err != nil {
+		return err
+	}
+	z, err := g1.Scalar.SetBytes(em.Z)
+	if err != nil {
+		return err
+	}
+	ek.Value = value
+	ek.Proof = &KeyProof{C: c, Z: z}
+	return nil
+}
+
+func keyChallenge(id uint32, pk, t curves.Point) curves.Scalar {
+	var buf [4]byte
+	binary.BigEndian.PutUint32(buf[:], id)
+	msg := []byte("kryptology nidkg key")
+	msg = append(msg, buf[:]...)
+	msg = append(msg, pk.ToAffineCompressed()...)
+	msg = append(msg, t.ToAffineCompressed()...)
+	return curves.BLS12381G1().Scalar.Hash(msg)
+}
@@ -0,0 +1,245 @@
+//
+// Copyright Coinbase, Inc. All Rights Reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package nidkg
+
+import (
+	"fmt"
+	"sort"
+
+	"git.sr.ht/~sircmpwn/go-bare"
+
+	"github.com/nerifnetwork/kryptology/internal"
+	"github.com/nerifnetwork/kryptology/pkg/core/curves"
+)
+
+type dealingMarshal struct {
+	Commitments [][]byte   `bare:"commitments"`
+	Randomizers [][]byte   `bare:"randomizers"`
+	Receivers   []uint32   `bare:"receivers"`
+	Ciphertexts [][][]byte `bare:"ciphertexts"`
+	SharingF    []byte     `bare:"sharingF"`
+	SharingA    []byte     `bare:"sharingA"`
+	SharingY    []byte     `bare:"sharingY"`
+	SharingZr   []byte     `bare:"sharingZr"`
+	SharingZa   []byte     `bare:"sharingZa"`
+	ChunkingB   [][][]byte `bare:"chunkingB"`
+	ChunkingT0  [][][]byte `bare:"chunkingT0"`
+	ChunkingT1  [][][]byte `bare:"chunkingT1"`
+	ChunkingC0  [][][]byte `bare:"chunkingC0"`
+	ChunkingS0  [][][]byte `bare:"chunkingS0"`
+	ChunkingS1  [][][]byte `bare:"chunkingS1"`
+}
+
+// MarshalBinary serializes the dealing so it can be published. The encoding is
+// deterministic so the same dealing always has the same bytes.
+func (d Dealing) MarshalBinary() ([]byte, error) {
+	if d.SharingProof == nil || d.ChunkingProof == nil {
+		return nil, internal.ErrNilArguments
+	}
+	sp, cp := d.SharingProof, d.ChunkingProof
+	if _, err := marshalPoints([]curves.Point{sp.F, sp.A, sp.Y}); err != nil {
+		return nil, err
+	}
+	if _, err := marshalScalars([]curves.Scalar{sp.Zr, sp.Za}); err != nil {
+		return nil, err
+	}
+	dm := &dealingMarshal{
+		Receivers:  make([]uint32, 0, len(d.Ciphertexts)),
+		SharingF:   sp.F.ToAffineCompressed(),
+		SharingA:   sp.A.ToAffineCompressed(),
+		SharingY:   sp.Y.ToAffineCompressed(),
+		SharingZr:  sp.Zr.Bytes(),
+		SharingZa:  sp.Za.Bytes(),
+		ChunkingB:  make([][][]byte, len(cp.Bits)),
+		ChunkingT0: make([][][]byte, len(cp.T0)),
+		ChunkingT1: make([][][]byte, len(cp.T1)),
+		ChunkingC0: make([][][]byte, len(cp.C0)),
+		ChunkingS0: make([][][]byte, len(cp.S0)),
+		ChunkingS1: make([][][]byte, len(cp.S1)),
+	}
+	var err error
+	if dm.Commitments, err = marshalPoints(d.Commitments); err != nil {
+		return nil, err
+	}
+	if dm.Randomizers, err = marshalPoints(d.Randomizers); err != nil {
+		return nil, err
+	}
+	for id := range d.Ciphertexts {
+		dm.Receivers = append(dm.Receivers, id)
+	}
+	sort.Slice(dm.Receivers, func(i, j int) bool { return dm.Receivers[i] < dm.Receivers[j] })
+	dm.Ciphertexts = make([][][]byte, len(dm.Receivers))
+	for i, id := range dm.Receivers {
+		if dm.Ciphertexts[i], err = marshalPoints(d.Ciphertexts[id]); err != nil {
+			return nil, err
+		}
+	}
+	for i := range cp.Bits {
+		if dm.ChunkingB[i], err = marshalPoints(cp.Bits[i]); err != nil {
+			return nil, err
+		}
+	}
+	for i := range cp.T0 {
+		if dm.ChunkingT0[i], err = marshalPoints(cp.T0[i]); err != nil {
+			return nil, err
+		}
+	}
+	for i := range cp.T1 {
+		if dm.ChunkingT1[i], err = marshalPoints(cp.T1[i]); err != nil {
+			return nil, err
+		}
+	}
+	for i := range cp.C0 {
+		if dm.ChunkingC0[i], err = marshalScalars(cp.C0[i]); err != nil {
+			return nil, err
+		}
+	}
+	for i := range cp.S0 {
+		if dm.ChunkingS0[i], err = marshalScalars(cp.S0[i]); err != nil {
+			return nil, err
+		}
+	}
+	for i := range cp.S1 {
+		if dm.ChunkingS1[i], err = marshalScalars(cp.S1[i]); err != nil {
+			return nil, err
+		}
+	}
+	return bare.Marshal(dm)
+}
+
+// UnmarshalBinary deserializes the dealing. It still has to be checked with Verify.
+func (d *Dealing) UnmarshalBinary(data []byte) error {
+	dm := new(dealingMarshal)
+	if err := bare.Unmarshal(data, dm); err != nil {
+		return err
+	}
+	if len(dm.Receivers) != len(dm.Ciphertexts) {
+		return fmt.Errorf("invalid ciphertexts")
+	}
+	g1 := curves.BLS12381G1()
+	g2 := curves.BLS12381G2()
+	var err error
+	out := &Dealing{
+		Ciphertexts:   make(map[uint32][]curves.Point, len(dm.Receivers)),
+		SharingProof:  new(SharingProof),
+		ChunkingProof: new(ChunkingProof),
+	}
+	if out.Commitments, err = unmarshalPoints(g2, dm.Commitments); err != nil {
+		return err
+	}
+	if out.Randomizers, err = unmarshalPoints(g1, dm.Randomizers); err != nil {
+		return err
+	}
+	for i, id := range dm.Receivers {
+		if out.Ciphertexts[id], err = unmarshalPoints(g1, dm.Ciphertexts[i]); err != nil {
+			return err
+		}
+	}
+
+	sp := out.SharingProof
+	if sp.F, err = g1.Point.FromAffineCompressed(dm.SharingF); err != nil {
+		return err
+	}
+	if sp.A, err = g2.Point.FromAffineCompressed(dm.SharingA); err != nil {
+		return err
+	}
+	if sp.Y, err = g1.Point.FromAffineCompressed(dm.SharingY); err != nil {
+		return err
+	}
+	if sp.Zr, err = g1.Scalar.SetBytes(dm.SharingZr); err != nil {
+		return err
+	}
+	if sp.Za, err = g1.Scalar.SetBytes(dm.SharingZa); err != nil {
+		return err
+	}
+
+	cp := out.ChunkingProof
+	cp.Bits = make([][]curves.Point, len(dm.ChunkingB))
+	for i := range dm.ChunkingB {
+		if cp.Bits[i], err = unmarshalPoints(g1, dm.ChunkingB[i]); err != nil {
+			return err
+		}
+	}
+	cp.T0 = make([][]curves.Point, len(dm.ChunkingT0))
+	for i := range dm.ChunkingT0 {
+		if cp.T0[i], err = unmarshalPoints(g1, dm.ChunkingT0[i]); err != nil {
+			return err
+		}
+	}
+	cp.T1 = make([][]curves.Point, len(dm.ChunkingT1))
+	for i := range dm.ChunkingT1 {
+		if cp.T1[i], err = unmarshalPoints(g1, dm.ChunkingT1[i]); err != nil {
+			return err
+		}
+	}
+	cp.C0 = make([][]curves.Scalar, len(dm.ChunkingC0))
+	for i := range dm.ChunkingC0 {
+		if cp.C0[i], err = unmarshalScalars(g1, dm.ChunkingC0[i]); err != nil {
+			return err
+		}
+	}
+	cp.S0 = make([][]curves.Scalar, len(dm.ChunkingS0))
+	for i := range dm.ChunkingS0 {
+		if cp.S0[i], err = unmarshalScalars(g1, dm.ChunkingS0[i]); err != nil {
+			return err
+		}
+	}
+	cp.S1 = make([][]curves.Scalar, len(dm.ChunkingS1))
+	for i := range dm.ChunkingS1 {
+		if cp.S1[i], err = unmarshalScalars(g1, dm.ChunkingS1[i]); err != nil {
+			return err
+		}
+	}
+	*d = *out
+	return nil
+}
+
+func marshalPoints(points []curves.Point) ([][]byte, error) {
+	out := make([][]byte, len(points))
+	for i, p := range points {
+		if p == nil {
+			return nil, internal.ErrNilArguments
+		}
+		out[i] = p.ToAffineCompressed()
+	}
+	return out, nil
+}
+
+func marshalScalars(scalars []curves.Scalar) ([][]byte, error) {
+	out := make([][]byte, len(scalars))
+	for i, s := range scalars {
+		if s == nil {
+			return nil, internal.ErrNilArguments
+		}
+		out[i] = s.Bytes()
+	}
+	return out, nil
+}
+
+func unmarshalPoints(curve *curves.Curve, data [][]byte) ([]curves.Point, error) {
+	out := make([]curves.Point, len(data))
+	for i, b := range data {
+		p, err := curve.Point.FromAffineCompressed(b)
+		if err != nil {
+			return nil, err
+		}
+		out[i] = p
+	}
+	return out, nil
+}
+
+func unmarshalScalars(curve *curves.Curve, data [][]byte) ([]curves.Scalar, error) {
+	out := make([]curves.Scalar, len(data))
+	for i, b := range data {
+		s, err := curve.Scalar.SetBytes(b)
+		if err != nil {
+			return nil, err
+		}
+		out[i] = s
+	}
+	return out, nil
+}
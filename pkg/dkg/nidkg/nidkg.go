@@ -0,0 +1,140 @@
+//
+// Copyright Coinbase, Inc. All Rights Reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+// Package nidkg is a non-interactive DKG over BLS12-381 in the style of
+// https://eprint.iacr.org/2021/339.pdf
+//
+// Every dealer publishes a single Dealing: Feldman commitments in G2 to a random
+// polynomial and, for every receiver, the share split into 16 bit chunks that are
+// ElGamal encrypted in the exponent of G1 to the receiver's key. A proof of correct
+// sharing shows the ciphertexts hold the committed shares and a proof of correct
+// chunking shows the chunks are in [0, 2^16), so they can always be decrypted. Anyone
+// can verify the dealings and aggregate the valid ones into a Transcript from which each
+// receiver decrypts its share of the joint secret. No private channels or further rounds are needed.
+// The key can later be reshared to a new committee with AggregateReshare.
+//
+// The receiver keys are static so, unlike the paper, the encryption is not forward secure.
+package nidkg
+
+import (
+	"fmt"
+	"math/big"
+	"sort"
+
+	"github.com/nerifnetwork/kryptology/internal"
+	"github.com/nerifnetwork/kryptology/pkg/core/curves"
+)
+
+const (
+	// chunkBits is the size of the chunks that the shares are split into
+	chunkBits = 16
+	chunkSize = 1 << chunkBits
+	// numChunks is enough chunks to hold a 256 bit scalar
+	numChunks = 256 / chunkBits
+)
+
+// Nidkg is the setting of a DKG: the threshold, the receivers and their keys.
+// All dealers and verifiers must use the same setting and session id.
+type Nidkg struct {
+	threshold uint32
+	receivers []uint32
+	keys      map[uint32]curves.Point
+	sessionId []byte
+}
+
+// NewNidkg creates a DKG setting for the receivers' encryption keys.
+// The proofs of possession of the keys are checked here. The session id must
+// be unique to the DKG run, e.g. the epoch of the committee.
+func NewNidkg(threshold uint32, keys map[uint32]*EncryptionKey, sessionId []byte) (*Nidkg, error) {
+	if len(keys) == 0 || len(sessionId) == 0 {
+		return nil, internal.ErrNilArguments
+	}
+	if threshold < 2 || threshold > uint32(len(keys)) {
+		return nil, fmt.Errorf("threshold must be between 2 and %d", len(keys))
+	}
+	n := &Nidkg{
+		threshold: threshold,
+		receivers: make([]uint32, 0, len(keys)),
+		keys:      make(map[uint32]curves.Point, len(keys)),
+		sessionId: make([]byte, len(sessionId)),
+	}
+	copy(n.sessionId, sessionId)
+	for id, key := range keys {
+		if id == 0 {
+			return nil, fmt.Errorf("invalid receiver id")
+		}
+		if key == nil {
+			return nil, internal.ErrNilArguments
+		}
+		if err := key.Verify(id); err != nil {
+			return nil, fmt.Errorf("invalid key for receiver %d: %v", id, err)
+		}
+		n.receivers = append(n.receivers, id)
+		n.keys[id] = key.Value
+	}
+	sort.Slice(n.receivers, func(i, j int) bool { return n.receivers[i] < n.receivers[j] })
+	return n, nil
+}
+
+// Threshold is the number of shares needed to reconstruct the secret
+func (n Nidkg) Threshold() uint32 {
+	return n.threshold
+}
+
+// Receivers are the sorted ids of the receivers
+func (n Nidkg) Receivers() []uint32 {
+	out := make([]uint32, len(n.receivers))
+	copy(out, n.receivers)
+	return out
+}
+
+// publicKeyShare evaluates the commitments at id: s_id * G2 = Σ id^k * A_k
+func publicKeyShare(commitments []curves.Point, id uint32) curves.Point {
+	x := curves.BLS12381G1().Scalar.New(int(id))
+	scalars := make([]curves.Scalar, len(commitments))
+	power := x.One()
+	for k := range scalars {
+		scalars[k] = power
+		power = power.Mul(x)
+	}
+	return commitments[0].SumOfProducts(commitments, scalars)
+}
+
+// scalarFromBig returns v mod r as a BLS12-381 scalar
+func scalarFromBig(v *big.Int) curves.Scalar {
+	s, _ := curves.BLS12381G1().Scalar.SetBigInt(new(big.Int).Mod(v, fieldOrder()))
+	return s
+}
+
+// fieldOrder is r, the order of the BLS12-381 groups
+func fieldOrder() *big.Int {
+	minusOne := curves.BLS12381G1().Scalar.One().Neg().BigInt()
+	return minusOne.Add(minusOne, big.NewInt(1))
+}
+
+func checkPoints(name string, points ...curves.Point) error {
+	for _, p := range points {
+		if p == nil {
+			return internal.ErrNilArguments
+		}
+		if p.CurveName() != name || !p.IsOnCurve() {
+			return fmt.Errorf("invalid %s point", name)
+		}
+	}
+	return nil
+}
+
+func checkScalars(scalars ...curves.Scalar) error {
+	for _, s := range scalars {
+		if s == nil {
+			return internal.ErrNilArguments
+		}
+		if _, ok := s.(*curves.ScalarBls12381); !ok {
+			return fmt.Errorf("invalid scalar")
+		}
+	}
+	return nil
+}
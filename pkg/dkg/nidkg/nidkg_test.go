@@ -0,0 +1,271 @@
+//
+// Copyright Coinbase, Inc. All Rights Reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package nidkg
+
+import (
+	crand "crypto/rand"
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/nerifnetwork/kryptology/pkg/core/curves"
+	"github.com/nerifnetwork/kryptology/pkg/sharing"
+)
+
+func newTestNidkg(t *testing.T, threshold, n uint32) (*Nidkg, map[uint32]*DecryptionKey) {
+	eks := make(map[uint32]*EncryptionKey, n)
+	dks := make(map[uint32]*DecryptionKey, n)
+	for i := uint32(1); i <= n; i++ {
+		ek, dk, err := NewKeys(i, crand.Reader)
+		require.NoError(t, err)
+		eks[i] = ek
+		dks[i] = dk
+	}
+	dkg, err := NewNidkg(threshold, eks, []byte("epoch 1"))
+	require.NoError(t, err)
+	return dkg, dks
+}
+
+func TestNewNidkg(t *testing.T) {
+	ek1, _, err := NewKeys(1, crand.Reader)
+	require.NoError(t, err)
+	ek2, _, err := NewKeys(2, crand.Reader)
+	require.NoError(t, err)
+
+	_, err = NewNidkg(2, map[uint32]*EncryptionKey{1: ek1, 2: ek2}, []byte("epoch 1"))
+	require.NoError(t, err)
+	_, err = NewNidkg(3, map[uint32]*EncryptionKey{1: ek1, 2: ek2}, []byte("epoch 1"))
+	require.Error(t, err)
+	_, err = NewNidkg(1, map[uint32]*EncryptionKey{1: ek1, 2: ek2}, []byte("epoch 1"))
+	require.Error(t, err)
+	_, err = NewNidkg(2, map[uint32]*EncryptionKey{1: ek1, 2: ek2}, nil)
+	require.Error(t, err)
+	// The proof of possession is bound to the id
+	_, err = NewNidkg(2, map[uint32]*EncryptionKey{1: ek2, 2: ek1}, []byte("epoch 1"))
+	require.Error(t, err)
+}
+
+func TestEncryptionKeyMarshal(t *testing.T) {
+	ek, _, err := NewKeys(1, crand.Reader)
+	require.NoError(t, err)
+	data, err := ek.MarshalBinary()
+	require.NoError(t, err)
+	decoded := new(EncryptionKey)
+	require.NoError(t, decoded.UnmarshalBinary(data))
+	require.True(t, decoded.Value.Equal(ek.Value))
+	require.NoError(t, decoded.Verify(1))
+	require.Error(t, decoded.Verify(2))
+}
+
+func TestNidkgFull(t *testing.T) {
+	if testing.Short() {
+		// Every dealer proves the bits of the chunks of every receiver
+		t.Skip("skipping the full nidkg in short mode.")
+	}
+	dkg, dks := newTestNidkg(t, 3, 5)
+
+	dealings := make(map[uint32]*Dealing, 5)
+	for _, dealer := range dkg.Receivers() {
+		dealing, err := dkg.Deal(dealer, nil, crand.Reader)
+		require.NoError(t, err)
+		require.NoError(t, dkg.Verify(dealer, dealing))
+		dealings[dealer] = dealing
+	}
+	transcript, err := dkg.Aggregate(dealings)
+	require.NoError(t, err)
+	require.Len(t, transcript.Dealings, 5)
+
+	shares := make([]*sharing.ShamirShare, 0, 5)
+	for _, id := range dkg.Receivers() {
+		share, err := dkg.Decrypt(id, dks[id], transcript)
+		require.NoError(t, err)
+		s, err := curves.BLS12381G1().Scalar.SetBytes(share.Value)
+		require.NoError(t, err)
+		require.True(t, curves.BLS12381G2().ScalarBaseMult(s).Equal(transcript.PublicKeyShare(id)))
+		shares = append(shares, share)
+	}
+
+	shamir, err := sharing.NewShamir(3, 5, curves.BLS12381G1())
+	require.NoError(t, err)
+	secret, err := shamir.Combine(shares[1], shares[3], shares[4])
+	require.NoError(t, err)
+	require.True(t, curves.BLS12381G2().ScalarBaseMult(secret).Equal(transcript.PublicKey()))
+
+	// A receiver can't decrypt with someone else's key
+	_, err = dkg.Decrypt(1, dks[2], transcript)
+	require.Error(t, err)
+}
+
+func TestNidkgInvalidDealings(t *testing.T) {
+	dkg, _ := newTestNidkg(t, 2, 3)
+	g1 := curves.BLS12381G1()
+
+	dealing, err := dkg.Deal(1, nil, crand.Reader)
+	require.NoError(t, err)
+	require.NoError(t, dkg.Verify(1, dealing))
+	// The proofs are bound to the dealer
+	require.Error(t, dkg.Verify(2, dealing))
+
+	ciphertext := dealing.Ciphertexts[2][3]
+	dealing.Ciphertexts[2][3] = ciphertext.Add(g1.Point.Generator())
+	require.Error(t, dkg.Verify(1, dealing))
+	dealing.Ciphertexts[2][3] = ciphertext
+
+	commitment := dealing.Commitments[1]
+	dealing.Commitments[1] = commitment.Double()
+	require.Error(t, dkg.Verify(1, dealing))
+	dealing.Commitments[1] = commitment
+
+	s0 := dealing.ChunkingProof.S0[1][5]
+	dealing.ChunkingProof.S0[1][5] = s0.Add(g1.Scalar.One())
+	require.Error(t, dkg.Verify(1, dealing))
+	dealing.ChunkingProof.S0[1][5] = s0
+
+	// A commitment to the bit 2 moves the chunk out of range
+	bits := dealing.ChunkingProof.Bits[1]
+	bits[3], bits[4] = bits[3].Add(g1.Point.Generator().Double()), bits[4].Sub(g1.Point.Generator())
+	require.Error(t, dkg.Verify(1, dealing))
+	bits[3], bits[4] = bits[3].Sub(g1.Point.Generator().Double()), bits[4].Add(g1.Point.Generator())
+	require.NoError(t, dkg.Verify(1, dealing))
+
+	za := dealing.SharingProof.Za
+	dealing.SharingProof.Za = za.Add(g1.Scalar.One())
+	require.Error(t, dkg.Verify(1, dealing))
+	dealing.SharingProof.Za = za
+
+	delete(dealing.Ciphertexts, 3)
+	require.Error(t, dkg.Verify(1, dealing))
+
+	// Not enough valid dealings
+	_, err = dkg.Aggregate(map[uint32]*Dealing{1: dealing})
+	require.Error(t, err)
+}
+
+func TestNidkgOutOfRangeChunks(t *testing.T) {
+	dkg, dks := newTestNidkg(t, 2, 3)
+	dealings := make(map[uint32]*Dealing, 4)
+	for _, dealer := range []uint32{1, 2} {
+		dealing, err := dkg.Deal(dealer, nil, crand.Reader)
+		require.NoError(t, err)
+		dealings[dealer] = dealing
+	}
+
+	// Chunks of the same share outside [0, 2^16), slightly and by far, fail the chunking proof
+	for dealer, offset := range map[uint32]int64{3: chunkSize, 4: 1 << 20} {
+		split := func(s curves.Scalar) []*big.Int {
+			chunks := splitChunks(s)
+			chunks[0].Add(chunks[0], big.NewInt(offset))
+			chunks[1].Sub(chunks[1], big.NewInt(offset>>chunkBits))
+			return chunks
+		}
+		dealing, err := dkg.deal(dealer, nil, crand.Reader, split)
+		require.NoError(t, err)
+		require.Error(t, dkg.Verify(dealer, dealing))
+		dealings[dealer] = dealing
+	}
+
+	// Aggregate drops them, so every receiver decrypts its share
+	transcript, err := dkg.Aggregate(dealings)
+	require.NoError(t, err)
+	require.Len(t, transcript.Dealings, 2)
+	for _, id := range dkg.Receivers() {
+		share, err := dkg.Decrypt(id, dks[id], transcript)
+		require.NoError(t, err)
+		s, err := curves.BLS12381G1().Scalar.SetBytes(share.Value)
+		require.NoError(t, err)
+		require.True(t, curves.BLS12381G2().ScalarBaseMult(s).Equal(transcript.PublicKeyShare(id)))
+	}
+}
+
+func TestDealingMarshal(t *testing.T) {
+	dkg, _ := newTestNidkg(t, 2, 3)
+	dealing, err := dkg.Deal(1, nil, crand.Reader)
+	require.NoError(t, err)
+
+	data, err := dealing.MarshalBinary()
+	require.NoError(t, err)
+	again, err := dealing.MarshalBinary()
+	require.NoError(t, err)
+	require.Equal(t, data, again)
+
+	decoded := new(Dealing)
+	require.NoError(t, decoded.UnmarshalBinary(data))
+	require.NoError(t, dkg.Verify(1, decoded))
+
+	require.Error(t, decoded.UnmarshalBinary(data[:len(data)-1]))
+}
+
+func TestNidkgReshare(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping the nidkg resharing in short mode.")
+	}
+	old, oldDks := newTestNidkg(t, 2, 3)
+	dealings := make(map[uint32]*Dealing, 3)
+	for _, dealer := range old.Receivers() {
+		dealing, err := old.Deal(dealer, nil, crand.Reader)
+		require.NoError(t, err)
+		dealings[dealer] = dealing
+	}
+	previous, err := old.Aggregate(dealings)
+	require.NoError(t, err)
+
+	next, nextDks := newTestNidkg(t, 3, 4)
+	reshares := make(map[uint32]*Dealing, 3)
+	for _, dealer := range old.Receivers() {
+		share, err := old.Decrypt(dealer, oldDks[dealer], previous)
+		require.NoError(t, err)
+		s, err := curves.BLS12381G1().Scalar.SetBytes(share.Value)
+		require.NoError(t, err)
+		reshares[dealer], err = next.Deal(dealer, s, crand.Reader)
+		require.NoError(t, err)
+		require.NoError(t, next.VerifyReshare(dealer, reshares[dealer], previous))
+	}
+	// A dealing of a fresh secret is not a resharing
+	fresh, err := next.Deal(2, nil, crand.Reader)
+	require.NoError(t, err)
+	require.NoError(t, next.Verify(2, fresh))
+	require.Error(t, next.VerifyReshare(2, fresh, previous))
+	reshares[2] = fresh
+
+	transcript, err := next.AggregateReshare(reshares, previous)
+	require.NoError(t, err)
+	require.Len(t, transcript.Dealings, 2)
+	require.True(t, transcript.PublicKey().Equal(previous.PublicKey()))
+
+	shares := make([]*sharing.ShamirShare, 0, 4)
+	for _, id := range next.Receivers() {
+		share, err := next.Decrypt(id, nextDks[id], transcript)
+		require.NoError(t, err)
+		s, err := curves.BLS12381G1().Scalar.SetBytes(share.Value)
+		require.NoError(t, err)
+		require.True(t, curves.BLS12381G2().ScalarBaseMult(s).Equal(transcript.PublicKeyShare(id)))
+		shares = append(shares, share)
+	}
+	shamir, err := sharing.NewShamir(3, 4, curves.BLS12381G1())
+	require.NoError(t, err)
+	secret, err := shamir.Combine(shares[0], shares[2], shares[3])
+	require.NoError(t, err)
+	require.True(t, curves.BLS12381G2().ScalarBaseMult(secret).Equal(previous.PublicKey()))
+
+	// Not enough resharing dealings
+	delete(reshares, 3)
+	_, err = next.AggregateReshare(reshares, previous)
+	require.Error(t, err)
+}
+
+func TestSolveChunk(t *testing.T) {
+	g1 := curves.BLS12381G1()
+	for _, v := range []int{0, 5, babySize, chunkSize - 1} {
+		chunk, err := solveChunk(g1.ScalarBaseMult(g1.Scalar.New(v)))
+		require.NoError(t, err)
+		require.Equal(t, g1.Scalar.New(v), chunk)
+	}
+	// The chunking proof rules out larger chunks
+	_, err := solveChunk(g1.ScalarBaseMult(g1.Scalar.New(chunkSize)))
+	require.Error(t, err)
+}
@@ -0,0 +1,134 @@
+//
+// Copyright Coinbase, Inc. All Rights Reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package nidkg
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/nerifnetwork/kryptology/pkg/core/curves"
+)
+
+// SharingProof shows that the combined ciphertexts C_i = r * y_i + s_i * G1 with
+// R = r * G1 encrypt the shares committed to in G2. The statements of all
+// receivers are batched with powers of a challenge x.
+type SharingProof struct {
+	F, A, Y curves.Point
+	Zr, Za  curves.Scalar
+}
+
+// sharingStatement is what the sharing proof is about
+type sharingStatement struct {
+	instance    []byte
+	keys        []curves.Point
+	commitments []curves.Point
+	ids         []uint32
+	r           curves.Point
+	ciphertexts []curves.Point
+}
+
+func proveSharing(st *sharingStatement, r curves.Scalar, shares []curves.Scalar, reader io.Reader) *SharingProof {
+	g1 := curves.BLS12381G1()
+	g2 := curves.BLS12381G2()
+	x := st.challenge()
+	xs := powers(x, len(st.keys))
+
+	rho := g1.Scalar.Random(reader)
+	alpha := g1.Scalar.Random(reader)
+	f := g1.ScalarBaseMult(rho)
+	a := g2.ScalarBaseMult(alpha)
+	y := g1.Point.SumOfProducts(st.keys, xs).Mul(rho).Add(g1.ScalarBaseMult(alpha))
+	c := st.responseChallenge(x, f, a, y)
+
+	sum := g1.Scalar.Zero()
+	for i, s := range shares {
+		sum = sum.Add(xs[i].Mul(s))
+	}
+	return &SharingProof{
+		F:  f,
+		A:  a,
+		Y:  y,
+		Zr: c.MulAdd(r, rho),
+		Za: c.MulAdd(sum, alpha),
+	}
+}
+
+func (p SharingProof) verify(st *sharingStatement) error {
+	if err := checkPoints(curves.BLS12381G1Name, p.F, p.Y); err != nil {
+		return err
+	}
+	if err := checkPoints(curves.BLS12381G2Name, p.A); err != nil {
+		return err
+	}
+	if err := checkScalars(p.Zr, p.Za); err != nil {
+		return err
+	}
+	g1 := curves.BLS12381G1()
+	g2 := curves.BLS12381G2()
+	x := st.challenge()
+	xs := powers(x, len(st.keys))
+	c := st.responseChallenge(x, p.F, p.A, p.Y)
+
+	// c * R + F = z_r * G1
+	if !st.r.Mul(c).Add(p.F).Equal(g1.ScalarBaseMult(p.Zr)) {
+		return fmt.Errorf("invalid sharing proof")
+	}
+
+	// c * Σ_i x^i * Σ_k id_i^k * A_k + A = z_a * G2
+	coefficients := make([]curves.Scalar, len(st.commitments))
+	for k := range coefficients {
+		coefficients[k] = g1.Scalar.Zero()
+	}
+	for i, id := range st.ids {
+		idPower := xs[i]
+		scalar := g1.Scalar.New(int(id))
+		for k := range coefficients {
+			coefficients[k] = coefficients[k].Add(idPower)
+			idPower = idPower.Mul(scalar)
+		}
+	}
+	lhs := g2.Point.SumOfProducts(st.commitments, coefficients).Mul(c).Add(p.A)
+	if !lhs.Equal(g2.ScalarBaseMult(p.Za)) {
+		return fmt.Errorf("invalid sharing proof")
+	}
+
+	// c * Σ_i x^i * C_i + Y = z_r * Σ_i x^i * y_i + z_a * G1
+	lhs = g1.Point.SumOfProducts(st.ciphertexts, xs).Mul(c).Add(p.Y)
+	rhs := g1.Point.SumOfProducts(st.keys, xs).Mul(p.Zr).Add(g1.ScalarBaseMult(p.Za))
+	if !lhs.Equal(rhs) {
+		return fmt.Errorf("invalid sharing proof")
+	}
+	return nil
+}
+
+func (st sharingStatement) challenge() curves.Scalar {
+	msg := append([]byte("kryptology nidkg sharing"), st.instance...)
+	msg = append(msg, st.r.ToAffineCompressed()...)
+	for _, c := range st.ciphertexts {
+		msg = append(msg, c.ToAffineCompressed()...)
+	}
+	return curves.BLS12381G1().Scalar.Hash(msg)
+}
+
+func (st sharingStatement) responseChallenge(x curves.Scalar, f, a, y curves.Point) curves.Scalar {
+	msg := append([]byte("kryptology nidkg sharing response"), x.Bytes()...)
+	msg = append(msg, f.ToAffineCompressed()...)
+	msg = append(msg, a.ToAffineCompressed()...)
+	msg = append(msg, y.ToAffineCompressed()...)
+	return curves.BLS12381G1().Scalar.Hash(msg)
+}
+
+// powers returns x, x^2, ..., x^n
+func powers(x curves.Scalar, n int) []curves.Scalar {
+	out := make([]curves.Scalar, n)
+	power := x
+	for i := range out {
+		out[i] = power
+		power = power.Mul(x)
+	}
+	return out
+}
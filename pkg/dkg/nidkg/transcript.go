@@ -0,0 +1,183 @@
+//
+// Copyright Coinbase, Inc. All Rights Reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package nidkg
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/nerifnetwork/kryptology/internal"
+	"github.com/nerifnetwork/kryptology/pkg/core/curves"
+	"github.com/nerifnetwork/kryptology/pkg/sharing"
+)
+
+// Transcript is the outcome of the DKG: the valid dealings and the commitments
+// to the joint polynomial
+type Transcript struct {
+	Commitments []curves.Point
+	Dealings    map[uint32]*Dealing
+	// Weights are the Lagrange coefficients of the dealers of a resharing.
+	// They are nil for a fresh DKG where the dealings are simply added.
+	Weights map[uint32]curves.Scalar
+}
+
+// Aggregate verifies the dealings and combines the valid ones. Invalid dealings are
+// discarded, which every verifier agrees on, but at least threshold must remain
+// so that one of them is from an honest dealer.
+func (n Nidkg) Aggregate(dealings map[uint32]*Dealing) (*Transcript, error) {
+	if len(dealings) == 0 {
+		return nil, internal.ErrNilArguments
+	}
+	dealers := make([]uint32, 0, len(dealings))
+	for dealer := range dealings {
+		dealers = append(dealers, dealer)
+	}
+	sort.Slice(dealers, func(i, j int) bool { return dealers[i] < dealers[j] })
+
+	t := &Transcript{
+		Commitments: make([]curves.Point, n.threshold),
+		Dealings:    make(map[uint32]*Dealing, len(dealings)),
+	}
+	for k := range t.Commitments {
+		t.Commitments[k] = curves.BLS12381G2().Point.Identity()
+	}
+	for _, dealer := range dealers {
+		dealing := dealings[dealer]
+		if err := n.Verify(dealer, dealing); err != nil {
+			continue
+		}
+		for k, a := range dealing.Commitments {
+			t.Commitments[k] = t.Commitments[k].Add(a)
+		}
+		t.Dealings[dealer] = dealing
+	}
+	if len(t.Dealings) < int(n.threshold) {
+		return nil, fmt.Errorf("only %d valid dealings, need %d", len(t.Dealings), n.threshold)
+	}
+	return t, nil
+}
+
+// VerifyReshare checks a dealing of a resharing like Verify and also that
+// it shares the dealer's share of the previous transcript. The dealer id is
+// the dealer's id in the previous committee.
+func (n Nidkg) VerifyReshare(dealer uint32, dealing *Dealing, previous *Transcript) error {
+	if previous == nil || len(previous.Commitments) == 0 {
+		return internal.ErrNilArguments
+	}
+	if err := n.Verify(dealer, dealing); err != nil {
+		return err
+	}
+	if !dealing.Commitments[0].Equal(previous.PublicKeyShare(dealer)) {
+		return fmt.Errorf("dealing does not share the previous share of %d", dealer)
+	}
+	return nil
+}
+
+// AggregateReshare combines the dealings of a resharing of the previous transcript
+// to this setting. The previous committee deals its shares with Deal and the first
+// threshold of the previous transcript valid dealings, in order of dealer id, are
+// combined with their Lagrange coefficients so the joint secret and public key stay the same.
+func (n Nidkg) AggregateReshare(dealings map[uint32]*Dealing, previous *Transcript) (*Transcript, error) {
+	if len(dealings) == 0 || previous == nil || len(previous.Commitments) == 0 {
+		return nil, internal.ErrNilArguments
+	}
+	dealers := make([]uint32, 0, len(dealings))
+	for dealer := range dealings {
+		dealers = append(dealers, dealer)
+	}
+	sort.Slice(dealers, func(i, j int) bool { return dealers[i] < dealers[j] })
+
+	previousThreshold := len(previous.Commitments)
+	valid := make([]uint32, 0, previousThreshold)
+	for _, dealer := range dealers {
+		if len(valid) == previousThreshold {
+			break
+		}
+		if dealer == 0 || n.VerifyReshare(dealer, dealings[dealer], previous) != nil {
+			continue
+		}
+		valid = append(valid, dealer)
+	}
+	if len(valid) < previousThreshold {
+		return nil, fmt.Errorf("only %d valid dealings, need %d", len(valid), previousThreshold)
+	}
+
+	t := &Transcript{
+		Commitments: make([]curves.Point, n.threshold),
+		Dealings:    make(map[uint32]*Dealing, len(valid)),
+		Weights:     lagrangeAtZero(valid),
+	}
+	for k := range t.Commitments {
+		t.Commitments[k] = curves.BLS12381G2().Point.Identity()
+	}
+	for _, dealer := range valid {
+		for k, a := range dealings[dealer].Commitments {
+			t.Commitments[k] = t.Commitments[k].Add(a.Mul(t.Weights[dealer]))
+		}
+		t.Dealings[dealer] = dealings[dealer]
+	}
+	return t, nil
+}
+
+// lagrangeAtZero returns the Lagrange coefficients at 0 for the distinct ids
+func lagrangeAtZero(ids []uint32) map[uint32]curves.Scalar {
+	scalar := curves.BLS12381G1().Scalar
+	out := make(map[uint32]curves.Scalar, len(ids))
+	for _, i := range ids {
+		num := scalar.One()
+		den := scalar.One()
+		for _, j := range ids {
+			if i == j {
+				continue
+			}
+			xj := scalar.New(int(j))
+			num = num.Mul(xj)
+			den = den.Mul(xj.Sub(scalar.New(int(i))))
+		}
+		out[i] = num.Div(den)
+	}
+	return out
+}
+
+// PublicKey is the joint public key in G2
+func (t Transcript) PublicKey() curves.Point {
+	return t.Commitments[0]
+}
+
+// PublicKeyShare is the public key in G2 of the share of receiver id
+func (t Transcript) PublicKeyShare(id uint32) curves.Point {
+	return publicKeyShare(t.Commitments, id)
+}
+
+// Decrypt recovers the share of the joint secret of receiver id from the transcript
+func (n Nidkg) Decrypt(id uint32, dk *DecryptionKey, t *Transcript) (*sharing.ShamirShare, error) {
+	if dk == nil || dk.Value == nil || t == nil {
+		return nil, internal.ErrNilArguments
+	}
+	if _, ok := n.keys[id]; !ok {
+		return nil, fmt.Errorf("unknown receiver %d", id)
+	}
+	if !curves.BLS12381G1().ScalarBaseMult(dk.Value).Equal(n.keys[id]) {
+		return nil, fmt.Errorf("decryption key does not match receiver %d", id)
+	}
+	share := curves.BLS12381G1().Scalar.Zero()
+	for dealer, dealing := range t.Dealings {
+		s, err := n.decryptDealing(id, dk, dealing)
+		if err != nil {
+			return nil, fmt.Errorf("dealing from %d: %v", dealer, err)
+		}
+		if t.Weights != nil {
+			w, ok := t.Weights[dealer]
+			if !ok {
+				return nil, fmt.Errorf("missing weight of dealer %d", dealer)
+			}
+			s = s.Mul(w)
+		}
+		share = share.Add(s)
+	}
+	return &sharing.ShamirShare{Id: id, Value: share.Bytes()}, nil
+}
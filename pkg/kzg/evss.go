@@ -0,0 +1,219 @@
+//
+// Copyright Coinbase, Inc. All Rights Reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package kzg
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/nerifnetwork/kryptology/internal"
+	"github.com/nerifnetwork/kryptology/pkg/core/curves"
+	"github.com/nerifnetwork/kryptology/pkg/core/polynomial"
+	"github.com/nerifnetwork/kryptology/pkg/sharing"
+)
+
+// Evss is verifiable secret sharing with constant size commitments (eVSS).
+// It replaces the threshold Feldman commitments with a single KZG commitment
+// and gives every share its own opening proof, so the broadcast no longer
+// grows with the threshold.
+//
+// - https://cacr.uwaterloo.ca/techreports/2010/cacr2010-10.pdf
+type Evss struct {
+	threshold, limit uint32
+	kzg              *Kzg
+}
+
+// EvssVerifier is the broadcast part of an eVSS dealing.
+// SecretCommitment is secret * G1 and SecretProof shows it is f(0) * G1.
+// ShiftedCommitment is τ^(D-t+1) * f(τ) * G1 for the largest degree D of the srs
+// and DegreeProof shows it commits to x^(D-t+1) * f(x), which bounds the degree of f by t-1.
+type EvssVerifier struct {
+	Commitment        curves.Point
+	SecretCommitment  curves.Point
+	SecretProof       curves.Point
+	ShiftedCommitment curves.Point
+	DegreeProof       curves.Point
+}
+
+// EvssShare is a share with the proof that it is the committed polynomial evaluated at the share identifier
+type EvssShare struct {
+	*sharing.ShamirShare
+	Proof curves.Point
+}
+
+// NewEvss creates an eVSS scheme. The srs must have at least threshold G1 powers.
+// Share openings alone do not bound the degree of the committed polynomial, so the
+// dealer also commits to f shifted to the largest degree of the srs, which is only
+// possible when f has degree at most threshold-1.
+func NewEvss(threshold, limit uint32, srs *Srs) (*Evss, error) {
+	if _, err := sharing.NewFeldman(threshold, limit, curves.BLS12381G1()); err != nil {
+		return nil, err
+	}
+	k, err := NewKzg(srs)
+	if err != nil {
+		return nil, err
+	}
+	if int(threshold)-1 > k.MaxDegree() {
+		return nil, fmt.Errorf("srs is too small for threshold %d", threshold)
+	}
+	return &Evss{threshold, limit, k}, nil
+}
+
+// Split shares the secret and opens the polynomial at every share identifier
+func (e Evss) Split(secret curves.Scalar, reader io.Reader) (*EvssVerifier, []*EvssShare, error) {
+	if secret == nil || reader == nil {
+		return nil, nil, internal.ErrNilArguments
+	}
+	if secret.IsZero() {
+		return nil, nil, fmt.Errorf("invalid secret")
+	}
+	curve := curves.BLS12381G1()
+	poly := new(sharing.Polynomial).Init(secret, e.threshold, reader)
+	commitment, err := e.kzg.Commit(poly)
+	if err != nil {
+		return nil, nil, err
+	}
+	_, secretProof, err := e.kzg.Open(poly, curve.Scalar.Zero())
+	if err != nil {
+		return nil, nil, err
+	}
+	shifted, degreeProof, err := e.proveDegree(poly, commitment)
+	if err != nil {
+		return nil, nil, err
+	}
+	shares := make([]*EvssShare, e.limit)
+	for i := range shares {
+		x := curve.Scalar.New(i + 1)
+		y, proof, err := e.kzg.Open(poly, x)
+		if err != nil {
+			return nil, nil, err
+		}
+		shares[i] = &EvssShare{
+			ShamirShare: &sharing.ShamirShare{
+				Id:    uint32(i + 1),
+				Value: y.Bytes(),
+			},
+			Proof: proof,
+		}
+	}
+	return &EvssVerifier{
+		Commitment:        commitment,
+		SecretCommitment:  curve.ScalarBaseMult(secret),
+		SecretProof:       secretProof,
+		ShiftedCommitment: shifted,
+		DegreeProof:       degreeProof,
+	}, shares, nil
+}
+
+// Verify checks the share against the commitment
+func (e Evss) Verify(verifier *EvssVerifier, share *EvssShare) error {
+	if verifier == nil || share == nil || share.ShamirShare == nil {
+		return internal.ErrNilArguments
+	}
+	curve := curves.BLS12381G1()
+	if err := share.Validate(curve); err != nil {
+		return err
+	}
+	if share.Id > e.limit {
+		return fmt.Errorf("invalid share identifier")
+	}
+	if err := e.verifyDegree(verifier); err != nil {
+		return err
+	}
+	y, _ := curve.Scalar.SetBytes(share.Value)
+	return e.kzg.Verify(verifier.Commitment, curve.Scalar.New(int(share.Id)), y, share.Proof)
+}
+
+// VerifySecretCommitment checks that SecretCommitment is the commitment to the shared secret
+func (e Evss) VerifySecretCommitment(verifier *EvssVerifier) error {
+	if verifier == nil {
+		return internal.ErrNilArguments
+	}
+	if err := e.verifyDegree(verifier); err != nil {
+		return err
+	}
+	return e.kzg.VerifyInExponent(verifier.Commitment, curves.BLS12381G1().Scalar.Zero(), verifier.SecretCommitment, verifier.SecretProof)
+}
+
+// proveDegree commits to g(x) = x^s * f(x) with s = D-t+1 and opens
+// g(x) - z^s * f(x) to zero at a challenge z, which shows g(z) = z^s * f(z)
+// without revealing f(z). As g has degree at most D, f has degree at most t-1.
+func (e Evss) proveDegree(poly *sharing.Polynomial, commitment curves.Point) (curves.Point, curves.Point, error) {
+	shift := e.shift()
+	g := make([]curves.Scalar, shift+len(poly.Coefficients))
+	for i := 0; i < shift; i++ {
+		g[i] = poly.Coefficients[0].Zero()
+	}
+	copy(g[shift:], poly.Coefficients)
+	shifted := e.kzg.commit(g)
+
+	z := degreeChallenge(commitment, shifted)
+	zs := power(z, shift)
+	h := make([]curves.Scalar, len(g))
+	copy(h, g)
+	for i, c := range poly.Coefficients {
+		h[i] = h[i].Sub(zs.Mul(c))
+	}
+	q, _, err := polynomial.DivMod(h, []curves.Scalar{z.Neg(), z.One()})
+	if err != nil {
+		return nil, nil, err
+	}
+	return shifted, e.kzg.commit(q), nil
+}
+
+// verifyDegree checks e(S - z^s * C, G2) = e(π, τ * G2 - z * G2)
+func (e Evss) verifyDegree(verifier *EvssVerifier) error {
+	if verifier.Commitment == nil || verifier.ShiftedCommitment == nil || verifier.DegreeProof == nil {
+		return internal.ErrNilArguments
+	}
+	if err := checkG1(verifier.Commitment, verifier.ShiftedCommitment, verifier.DegreeProof); err != nil {
+		return err
+	}
+	z := degreeChallenge(verifier.Commitment, verifier.ShiftedCommitment)
+	lhs := verifier.ShiftedCommitment.Sub(verifier.Commitment.Mul(power(z, e.shift())))
+	rhs := e.kzg.srs.G2[1].Sub(e.kzg.srs.G2[0].Mul(z))
+	if !pairingsEqual(lhs, e.kzg.srs.G2[0], verifier.DegreeProof, rhs) {
+		return fmt.Errorf("invalid degree proof")
+	}
+	return nil
+}
+
+// shift is the power that moves a polynomial of degree t-1 to the largest degree of the srs
+func (e Evss) shift() int {
+	return e.kzg.MaxDegree() - int(e.threshold) + 1
+}
+
+func degreeChallenge(commitment, shifted curves.Point) curves.Scalar {
+	transcript := []byte("kryptology evss degree")
+	transcript = append(transcript, commitment.ToAffineCompressed()...)
+	transcript = append(transcript, shifted.ToAffineCompressed()...)
+	return curves.BLS12381G1().Scalar.Hash(transcript)
+}
+
+func power(x curves.Scalar, n int) curves.Scalar {
+	out := x.One()
+	for i := 0; i < n; i++ {
+		out = out.Mul(x)
+	}
+	return out
+}
+
+// Combine reconstructs the secret from at least threshold shares
+func (e Evss) Combine(shares ...*EvssShare) (curves.Scalar, error) {
+	shamir, err := sharing.NewShamir(e.threshold, e.limit, curves.BLS12381G1())
+	if err != nil {
+		return nil, err
+	}
+	ss := make([]*sharing.ShamirShare, len(shares))
+	for i, s := range shares {
+		if s == nil || s.ShamirShare == nil {
+			return nil, internal.ErrNilArguments
+		}
+		ss[i] = s.ShamirShare
+	}
+	return shamir.Combine(ss...)
+}
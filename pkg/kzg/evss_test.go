@@ -0,0 +1,109 @@
+//
+// Copyright Coinbase, Inc. All Rights Reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package kzg
+
+import (
+	crand "crypto/rand"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/nerifnetwork/kryptology/pkg/core/curves"
+	"github.com/nerifnetwork/kryptology/pkg/sharing"
+)
+
+func TestEvssSplitVerifyCombine(t *testing.T) {
+	srs, err := NewSrs(4, 2, crand.Reader)
+	require.NoError(t, err)
+	_, err = NewEvss(5, 7, srs)
+	require.Error(t, err)
+
+	scheme, err := NewEvss(4, 7, srs)
+	require.NoError(t, err)
+	curve := curves.BLS12381G1()
+	secret := curve.Scalar.Random(crand.Reader)
+	verifier, shares, err := scheme.Split(secret, crand.Reader)
+	require.NoError(t, err)
+	require.True(t, verifier.SecretCommitment.Equal(curve.ScalarBaseMult(secret)))
+	require.NoError(t, scheme.VerifySecretCommitment(verifier))
+	for _, s := range shares {
+		require.NoError(t, scheme.Verify(verifier, s))
+	}
+
+	result, err := scheme.Combine(shares[1], shares[3], shares[5], shares[6])
+	require.NoError(t, err)
+	require.Equal(t, secret, result)
+
+	// a share moved to another identifier
+	bad := &EvssShare{ShamirShare: shares[1].ShamirShare, Proof: shares[2].Proof}
+	require.Error(t, scheme.Verify(verifier, bad))
+
+	// a commitment to another secret
+	verifier.SecretCommitment = verifier.SecretCommitment.Double()
+	require.Error(t, scheme.VerifySecretCommitment(verifier))
+}
+
+func TestEvssLargerSrs(t *testing.T) {
+	srs, err := NewSrs(10, 2, crand.Reader)
+	require.NoError(t, err)
+	scheme, err := NewEvss(4, 7, srs)
+	require.NoError(t, err)
+	curve := curves.BLS12381G1()
+	secret := curve.Scalar.Random(crand.Reader)
+	verifier, shares, err := scheme.Split(secret, crand.Reader)
+	require.NoError(t, err)
+	require.NoError(t, scheme.VerifySecretCommitment(verifier))
+	for _, s := range shares {
+		require.NoError(t, scheme.Verify(verifier, s))
+	}
+	result, err := scheme.Combine(shares[0], shares[2], shares[4], shares[6])
+	require.NoError(t, err)
+	require.Equal(t, secret, result)
+
+	// the shifted commitment must belong to the commitment
+	forged := *verifier
+	forged.ShiftedCommitment = verifier.Commitment
+	require.Error(t, scheme.VerifySecretCommitment(&forged))
+	require.Error(t, scheme.Verify(&forged, shares[0]))
+	forged = *verifier
+	forged.DegreeProof = verifier.SecretProof
+	require.Error(t, scheme.VerifySecretCommitment(&forged))
+}
+
+func TestEvssRejectsHigherDegree(t *testing.T) {
+	srs, err := NewSrs(10, 2, crand.Reader)
+	require.NoError(t, err)
+	scheme, err := NewEvss(4, 7, srs)
+	require.NoError(t, err)
+	k, err := NewKzg(srs)
+	require.NoError(t, err)
+	curve := curves.BLS12381G1()
+
+	// a dealer commits to a polynomial of degree 4 that the srs can hold
+	poly := new(sharing.Polynomial).Init(curve.Scalar.Random(crand.Reader), 5, crand.Reader)
+	commitment, err := k.Commit(poly)
+	require.NoError(t, err)
+	_, secretProof, err := k.Open(poly, curve.Scalar.Zero())
+	require.NoError(t, err)
+	verifier := &EvssVerifier{
+		Commitment:       commitment,
+		SecretCommitment: curve.ScalarBaseMult(poly.Coefficients[0]),
+		SecretProof:      secretProof,
+	}
+	// and shifts it as far as the srs allows, dropping the leading coefficient
+	truncated := &sharing.Polynomial{Coefficients: poly.Coefficients[:4]}
+	verifier.ShiftedCommitment, verifier.DegreeProof, err = scheme.proveDegree(truncated, commitment)
+	require.NoError(t, err)
+	require.Error(t, scheme.VerifySecretCommitment(verifier))
+
+	// every share opens correctly but the degree bound fails
+	y, proof, err := k.Open(poly, curve.Scalar.One())
+	require.NoError(t, err)
+	share := &EvssShare{ShamirShare: &sharing.ShamirShare{Id: 1, Value: y.Bytes()}, Proof: proof}
+	require.NoError(t, k.Verify(commitment, curve.Scalar.One(), y, proof))
+	require.Error(t, scheme.Verify(verifier, share))
+}
@@ -0,0 +1,235 @@
+//
+// Copyright Coinbase, Inc. All Rights Reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+// Package kzg is an implementation of KZG polynomial commitments over BLS12-381.
+// A commitment to a polynomial of any degree is a single G1 point and so is
+// the proof of its evaluation at one or several points.
+//
+// - https://www.iacr.org/archive/asiacrypt2010/6477178/6477178.pdf
+package kzg
+
+import (
+	"fmt"
+
+	"github.com/nerifnetwork/kryptology/internal"
+	"github.com/nerifnetwork/kryptology/pkg/core/curves"
+	"github.com/nerifnetwork/kryptology/pkg/core/polynomial"
+	"github.com/nerifnetwork/kryptology/pkg/sharing"
+)
+
+// Kzg commits to and opens polynomials with a structured reference string
+type Kzg struct {
+	srs *Srs
+}
+
+// Opening is a claim that a committed polynomial evaluates to Value at Point
+type Opening struct {
+	Commitment curves.Point
+	Point      curves.Scalar
+	Value      curves.Scalar
+	Proof      curves.Point
+}
+
+// NewKzg creates a KZG commitment scheme for the srs
+func NewKzg(srs *Srs) (*Kzg, error) {
+	if srs == nil || len(srs.G1) < 2 || len(srs.G2) < 2 {
+		return nil, fmt.Errorf("invalid srs")
+	}
+	return &Kzg{srs}, nil
+}
+
+// MaxDegree is the largest degree of polynomial that can be committed
+func (k Kzg) MaxDegree() int {
+	return len(k.srs.G1) - 1
+}
+
+// Commit returns C = f(τ) * G1
+func (k Kzg) Commit(poly *sharing.Polynomial) (curves.Point, error) {
+	if err := k.checkPolynomial(poly); err != nil {
+		return nil, err
+	}
+	return k.commit(poly.Coefficients), nil
+}
+
+// Open evaluates the polynomial at z and proves the evaluation with
+// π = q(τ) * G1 where q(x) = (f(x) - f(z)) / (x - z)
+func (k Kzg) Open(poly *sharing.Polynomial, z curves.Scalar) (curves.Scalar, curves.Point, error) {
+	if err := k.checkPolynomial(poly); err != nil {
+		return nil, nil, err
+	}
+	if z == nil {
+		return nil, nil, internal.ErrNilArguments
+	}
+	y := poly.Evaluate(z)
+	q, _, err := polynomial.DivMod(poly.Coefficients, []curves.Scalar{z.Neg(), z.One()})
+	if err != nil {
+		return nil, nil, err
+	}
+	return y, k.commit(q), nil
+}
+
+// Verify checks a single point opening with e(C - y * G1, G2) = e(π, τ * G2 - z * G2)
+func (k Kzg) Verify(commitment curves.Point, z, y curves.Scalar, proof curves.Point) error {
+	if commitment == nil || z == nil || y == nil || proof == nil {
+		return internal.ErrNilArguments
+	}
+	return k.VerifyInExponent(commitment, z, k.srs.G1[0].Mul(y), proof)
+}
+
+// VerifyInExponent checks an opening where only Y = y * G1 is known instead of y itself
+// with e(C - Y, G2) = e(π, τ * G2 - z * G2). This lets a dealer prove a public key
+// without revealing the secret.
+func (k Kzg) VerifyInExponent(commitment curves.Point, z curves.Scalar, y, proof curves.Point) error {
+	if commitment == nil || z == nil || y == nil || proof == nil {
+		return internal.ErrNilArguments
+	}
+	if err := checkG1(commitment, y, proof); err != nil {
+		return err
+	}
+	lhs := commitment.Sub(y)
+	rhs := k.srs.G2[1].Sub(k.srs.G2[0].Mul(z))
+	if !pairingsEqual(lhs, k.srs.G2[0], proof, rhs) {
+		return fmt.Errorf("invalid opening")
+	}
+	return nil
+}
+
+// OpenMulti evaluates the polynomial at every point in zs and proves all the
+// evaluations with π = q(τ) * G1 where q(x) = (f(x) - I(x)) / Z(x),
+// I interpolates the evaluations and Z vanishes on zs.
+func (k Kzg) OpenMulti(poly *sharing.Polynomial, zs []curves.Scalar) ([]curves.Scalar, curves.Point, error) {
+	if err := k.checkPolynomial(poly); err != nil {
+		return nil, nil, err
+	}
+	if len(zs) == 0 || len(zs) >= len(k.srs.G2) {
+		return nil, nil, fmt.Errorf("number of points must be between 1 and %d", len(k.srs.G2)-1)
+	}
+	ys := make([]curves.Scalar, len(zs))
+	for i, z := range zs {
+		if z == nil {
+			return nil, nil, internal.ErrNilArguments
+		}
+		ys[i] = poly.Evaluate(z)
+	}
+	z, err := polynomial.Vanishing(zs)
+	if err != nil {
+		return nil, nil, err
+	}
+	q, _, err := polynomial.DivMod(poly.Coefficients, z)
+	if err != nil {
+		return nil, nil, err
+	}
+	return ys, k.commit(q), nil
+}
+
+// VerifyMulti checks a multi point opening with e(C - I(τ) * G1, G2) = e(π, Z(τ) * G2)
+func (k Kzg) VerifyMulti(commitment curves.Point, zs, ys []curves.Scalar, proof curves.Point) error {
+	if commitment == nil || proof == nil {
+		return internal.ErrNilArguments
+	}
+	if len(zs) == 0 || len(zs) != len(ys) || len(zs) >= len(k.srs.G2) {
+		return internal.ErrIncorrectCount
+	}
+	if err := checkG1(commitment, proof); err != nil {
+		return err
+	}
+	i, err := polynomial.Interpolate(zs, ys)
+	if err != nil {
+		return err
+	}
+	z, err := polynomial.Vanishing(zs)
+	if err != nil {
+		return err
+	}
+	lhs := commitment.Sub(k.commit(i))
+	rhs := k.srs.G2[0].SumOfProducts(k.srs.G2[:len(z)], z)
+	if !pairingsEqual(lhs, k.srs.G2[0], proof, rhs) {
+		return fmt.Errorf("invalid opening")
+	}
+	return nil
+}
+
+// BatchVerify checks many single point openings, possibly of different
+// commitments, with two pairings. The openings are combined with powers
+// of a challenge derived from all of them:
+// e(Σ r_i * π_i, τ * G2) = e(Σ r_i * (C_i - y_i * G1 + z_i * π_i), G2)
+func (k Kzg) BatchVerify(openings []*Opening) error {
+	if len(openings) == 0 {
+		return internal.ErrIncorrectCount
+	}
+	transcript := []byte("kryptology kzg batch")
+	for _, o := range openings {
+		if o == nil || o.Commitment == nil || o.Point == nil || o.Value == nil || o.Proof == nil {
+			return internal.ErrNilArguments
+		}
+		if err := checkG1(o.Commitment, o.Proof); err != nil {
+			return err
+		}
+		transcript = append(transcript, o.Commitment.ToAffineCompressed()...)
+		transcript = append(transcript, o.Point.Bytes()...)
+		transcript = append(transcript, o.Value.Bytes()...)
+		transcript = append(transcript, o.Proof.ToAffineCompressed()...)
+	}
+	r := curves.BLS12381G1().Scalar.Hash(transcript)
+
+	proofs := make([]curves.Point, 0, len(openings))
+	points := make([]curves.Point, 0, 3*len(openings))
+	proofScalars := make([]curves.Scalar, 0, len(openings))
+	scalars := make([]curves.Scalar, 0, 3*len(openings))
+	sumY := r.Zero()
+	ri := r.One()
+	for _, o := range openings {
+		proofs = append(proofs, o.Proof)
+		proofScalars = append(proofScalars, ri)
+		points = append(points, o.Commitment, o.Proof)
+		scalars = append(scalars, ri, ri.Mul(o.Point))
+		sumY = sumY.Add(ri.Mul(o.Value))
+		ri = ri.Mul(r)
+	}
+	points = append(points, k.srs.G1[0])
+	scalars = append(scalars, sumY.Neg())
+
+	lhs := k.srs.G1[0].SumOfProducts(proofs, proofScalars)
+	rhs := k.srs.G1[0].SumOfProducts(points, scalars)
+	if !pairingsEqual(lhs, k.srs.G2[1], rhs, k.srs.G2[0]) {
+		return fmt.Errorf("invalid opening")
+	}
+	return nil
+}
+
+func (k Kzg) commit(coefficients []curves.Scalar) curves.Point {
+	if len(coefficients) == 0 {
+		return k.srs.G1[0].Identity()
+	}
+	return k.srs.G1[0].SumOfProducts(k.srs.G1[:len(coefficients)], coefficients)
+}
+
+func (k Kzg) checkPolynomial(poly *sharing.Polynomial) error {
+	if poly == nil || len(poly.Coefficients) == 0 {
+		return internal.ErrNilArguments
+	}
+	if len(poly.Coefficients) > len(k.srs.G1) {
+		return fmt.Errorf("polynomial degree exceeds %d", k.MaxDegree())
+	}
+	for _, c := range poly.Coefficients {
+		if c == nil {
+			return internal.ErrNilArguments
+		}
+		if _, ok := c.(*curves.ScalarBls12381); !ok {
+			return fmt.Errorf("coefficients must be bls12-381 scalars")
+		}
+	}
+	return nil
+}
+
+func checkG1(points ...curves.Point) error {
+	for _, p := range points {
+		if p.CurveName() != curves.BLS12381G1Name || !p.IsOnCurve() {
+			return fmt.Errorf("invalid g1 point")
+		}
+	}
+	return nil
+}
@@ -0,0 +1,110 @@
+//
+// Copyright Coinbase, Inc. All Rights Reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package kzg
+
+import (
+	crand "crypto/rand"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/nerifnetwork/kryptology/pkg/core/curves"
+	"github.com/nerifnetwork/kryptology/pkg/sharing"
+)
+
+func newTestKzg(t *testing.T) *Kzg {
+	srs, err := NewSrs(16, 8, crand.Reader)
+	require.NoError(t, err)
+	k, err := NewKzg(srs)
+	require.NoError(t, err)
+	return k
+}
+
+func randomPoly(degree uint32) *sharing.Polynomial {
+	curve := curves.BLS12381G1()
+	return new(sharing.Polynomial).Init(curve.Scalar.Random(crand.Reader), degree+1, crand.Reader)
+}
+
+func TestKzgOpenVerify(t *testing.T) {
+	k := newTestKzg(t)
+	curve := curves.BLS12381G1()
+	for _, degree := range []uint32{0, 1, 7, 15} {
+		poly := randomPoly(degree)
+		commitment, err := k.Commit(poly)
+		require.NoError(t, err)
+
+		z := curve.Scalar.Random(crand.Reader)
+		y, proof, err := k.Open(poly, z)
+		require.NoError(t, err)
+		require.Equal(t, poly.Evaluate(z), y)
+		require.NoError(t, k.Verify(commitment, z, y, proof))
+		require.NoError(t, k.VerifyInExponent(commitment, z, curve.ScalarBaseMult(y), proof))
+
+		require.Error(t, k.Verify(commitment, z, y.Add(curve.Scalar.One()), proof))
+		require.Error(t, k.Verify(commitment.Double(), z, y, proof))
+		// a constant polynomial has the identity as proof at every point
+		if degree > 0 {
+			require.Error(t, k.Verify(commitment, z.Add(curve.Scalar.One()), y, proof))
+			require.Error(t, k.Verify(commitment, z, y, proof.Double()))
+		}
+	}
+
+	_, err := k.Commit(randomPoly(16))
+	require.Error(t, err)
+	_, err = k.Commit(new(sharing.Polynomial).Init(curves.K256().Scalar.One(), 2, crand.Reader))
+	require.Error(t, err)
+}
+
+func TestKzgOpenMulti(t *testing.T) {
+	k := newTestKzg(t)
+	curve := curves.BLS12381G1()
+	poly := randomPoly(10)
+	commitment, err := k.Commit(poly)
+	require.NoError(t, err)
+
+	for _, n := range []int{1, 3, 7} {
+		zs := make([]curves.Scalar, n)
+		for i := range zs {
+			zs[i] = curve.Scalar.Random(crand.Reader)
+		}
+		ys, proof, err := k.OpenMulti(poly, zs)
+		require.NoError(t, err)
+		require.NoError(t, k.VerifyMulti(commitment, zs, ys, proof))
+
+		ys[n-1] = ys[n-1].Add(curve.Scalar.One())
+		require.Error(t, k.VerifyMulti(commitment, zs, ys, proof))
+	}
+
+	// the srs only has 8 g2 powers
+	zs := make([]curves.Scalar, 8)
+	for i := range zs {
+		zs[i] = curve.Scalar.New(i)
+	}
+	_, _, err = k.OpenMulti(poly, zs)
+	require.Error(t, err)
+}
+
+func TestKzgBatchVerify(t *testing.T) {
+	k := newTestKzg(t)
+	curve := curves.BLS12381G1()
+	openings := make([]*Opening, 0, 12)
+	for i := 0; i < 4; i++ {
+		poly := randomPoly(uint32(3 * i))
+		commitment, err := k.Commit(poly)
+		require.NoError(t, err)
+		for j := 0; j < 3; j++ {
+			z := curve.Scalar.Random(crand.Reader)
+			y, proof, err := k.Open(poly, z)
+			require.NoError(t, err)
+			openings = append(openings, &Opening{commitment, z, y, proof})
+		}
+	}
+	require.NoError(t, k.BatchVerify(openings))
+
+	openings[5].Value = openings[5].Value.Add(curve.Scalar.One())
+	require.Error(t, k.BatchVerify(openings))
+}
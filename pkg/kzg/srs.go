@@ -0,0 +1,219 @@
+//
+// Copyright Coinbase, Inc. All Rights Reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package kzg
+
+import (
+	"bufio"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/nerifnetwork/kryptology/pkg/core/curves"
+)
+
+const (
+	g1CompressedBytes = 48
+	g2CompressedBytes = 96
+)
+
+// Srs is the structured reference string produced by a trusted setup.
+// G1[i] = τ^i * G1 and G2[i] = τ^i * G2 for a secret τ that nobody knows.
+// Polynomials up to degree len(G1)-1 can be committed and multi-point
+// openings can cover up to len(G2)-1 points.
+type Srs struct {
+	G1 []curves.Point
+	G2 []curves.Point
+}
+
+// NewSrs runs a single party setup with a random τ that is discarded afterwards.
+// Whoever runs it learns τ and can forge proofs, so it is only suitable for
+// tests or for deployments where the dealer is trusted anyway.
+// Use ReadEthereumSetup for a setup produced by a public ceremony.
+func NewSrs(g1Count, g2Count int, reader io.Reader) (*Srs, error) {
+	if reader == nil {
+		return nil, fmt.Errorf("invalid reader")
+	}
+	tau := curves.BLS12381G1().Scalar.Random(reader)
+	return newSrsFromSecret(tau, g1Count, g2Count)
+}
+
+func newSrsFromSecret(tau curves.Scalar, g1Count, g2Count int) (*Srs, error) {
+	if g1Count < 2 || g2Count < 2 {
+		return nil, fmt.Errorf("srs needs at least 2 powers in each group")
+	}
+	g1 := curves.BLS12381G1().NewGeneratorPoint()
+	g2 := curves.BLS12381G2().NewGeneratorPoint()
+	srs := &Srs{
+		G1: make([]curves.Point, g1Count),
+		G2: make([]curves.Point, g2Count),
+	}
+	power := tau.One()
+	for i := 0; i < g1Count || i < g2Count; i++ {
+		if i < g1Count {
+			srs.G1[i] = g1.Mul(power)
+		}
+		if i < g2Count {
+			srs.G2[i] = g2.Mul(power)
+		}
+		power = power.Mul(tau)
+	}
+	return srs, nil
+}
+
+// Validate checks that the srs is a well formed sequence of powers of a single τ.
+// All the pairs of consecutive powers are checked at once with a random linear combination.
+func (srs Srs) Validate(reader io.Reader) error {
+	if len(srs.G1) < 2 || len(srs.G2) < 2 {
+		return fmt.Errorf("srs needs at least 2 powers in each group")
+	}
+	g1 := curves.BLS12381G1()
+	g2 := curves.BLS12381G2()
+	for _, p := range srs.G1 {
+		if p == nil || p.CurveName() != g1.Name || p.IsIdentity() {
+			return fmt.Errorf("invalid g1 power")
+		}
+	}
+	for _, p := range srs.G2 {
+		if p == nil || p.CurveName() != g2.Name || p.IsIdentity() {
+			return fmt.Errorf("invalid g2 power")
+		}
+	}
+	if !srs.G1[0].Equal(g1.NewGeneratorPoint()) || !srs.G2[0].Equal(g2.NewGeneratorPoint()) {
+		return fmt.Errorf("srs does not start with the generators")
+	}
+
+	// e(Σ r_i * G1[i+1], G2[0]) = e(Σ r_i * G1[i], G2[1])
+	rs := make([]curves.Scalar, len(srs.G1)-1)
+	for i := range rs {
+		rs[i] = g1.Scalar.Random(reader)
+	}
+	lhs := g1.Point.SumOfProducts(srs.G1[1:], rs)
+	rhs := g1.Point.SumOfProducts(srs.G1[:len(srs.G1)-1], rs)
+	if !pairingsEqual(lhs, srs.G2[0], rhs, srs.G2[1]) {
+		return fmt.Errorf("g1 powers are inconsistent")
+	}
+
+	// e(G1[0], Σ r_i * G2[i+1]) = e(G1[1], Σ r_i * G2[i])
+	rs = make([]curves.Scalar, len(srs.G2)-1)
+	for i := range rs {
+		rs[i] = g2.Scalar.Random(reader)
+	}
+	lhs = g2.Point.SumOfProducts(srs.G2[1:], rs)
+	rhs = g2.Point.SumOfProducts(srs.G2[:len(srs.G2)-1], rs)
+	if !pairingsEqual(srs.G1[0], lhs, srs.G1[1], rhs) {
+		return fmt.Errorf("g2 powers are inconsistent")
+	}
+	return nil
+}
+
+// ethereumSetupJson is the format of trusted_setup_4096.json in the consensus specs
+type ethereumSetupJson struct {
+	G1Monomial []string `json:"g1_monomial"`
+	G1Lagrange []string `json:"g1_lagrange"`
+	G2Monomial []string `json:"g2_monomial"`
+}
+
+// ReadEthereumSetupJson reads the output of the Ethereum KZG ceremony in the
+// JSON format published with the consensus specs. Only the monomial powers are used.
+func ReadEthereumSetupJson(reader io.Reader) (*Srs, error) {
+	var setup ethereumSetupJson
+	if err := json.NewDecoder(reader).Decode(&setup); err != nil {
+		return nil, err
+	}
+	if len(setup.G1Monomial) == 0 {
+		return nil, fmt.Errorf("setup does not contain g1 monomial powers")
+	}
+	return decodeSetup(setup.G1Monomial, setup.G2Monomial)
+}
+
+// ReadEthereumSetup reads the output of the Ethereum KZG ceremony in the
+// trusted_setup.txt format used by c-kzg-4844. The file holds the number of
+// g1 and g2 points, the g1 points in lagrange form, the g2 points in monomial
+// form and finally the g1 points in monomial form. Only the monomial powers are used.
+func ReadEthereumSetup(reader io.Reader) (*Srs, error) {
+	scanner := bufio.NewScanner(reader)
+	var lines []string
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line != "" {
+			lines = append(lines, line)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if len(lines) < 2 {
+		return nil, fmt.Errorf("invalid setup header")
+	}
+	g1Count, err := strconv.Atoi(lines[0])
+	if err != nil || g1Count < 2 {
+		return nil, fmt.Errorf("invalid number of g1 points")
+	}
+	g2Count, err := strconv.Atoi(lines[1])
+	if err != nil || g2Count < 2 {
+		return nil, fmt.Errorf("invalid number of g2 points")
+	}
+	lines = lines[2:]
+	if len(lines) != 2*g1Count+g2Count {
+		return nil, fmt.Errorf("setup does not contain g1 monomial powers")
+	}
+	return decodeSetup(lines[g1Count+g2Count:], lines[g1Count:g1Count+g2Count])
+}
+
+func decodeSetup(g1Hex, g2Hex []string) (*Srs, error) {
+	g1 := curves.BLS12381G1()
+	g2 := curves.BLS12381G2()
+	srs := &Srs{
+		G1: make([]curves.Point, len(g1Hex)),
+		G2: make([]curves.Point, len(g2Hex)),
+	}
+	for i, h := range g1Hex {
+		p, err := decodePoint(g1, h, g1CompressedBytes)
+		if err != nil {
+			return nil, fmt.Errorf("g1 point %d: %v", i, err)
+		}
+		srs.G1[i] = p
+	}
+	for i, h := range g2Hex {
+		p, err := decodePoint(g2, h, g2CompressedBytes)
+		if err != nil {
+			return nil, fmt.Errorf("g2 point %d: %v", i, err)
+		}
+		srs.G2[i] = p
+	}
+	if len(srs.G1) < 2 || len(srs.G2) < 2 {
+		return nil, fmt.Errorf("srs needs at least 2 powers in each group")
+	}
+	return srs, nil
+}
+
+func decodePoint(curve *curves.Curve, h string, length int) (curves.Point, error) {
+	data, err := hex.DecodeString(strings.TrimPrefix(h, "0x"))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) != length {
+		return nil, fmt.Errorf("invalid point length")
+	}
+	return curve.Point.FromAffineCompressed(data)
+}
+
+// pairingsEqual checks e(a1, b1) = e(a2, b2)
+func pairingsEqual(a1, b1, a2, b2 curves.Point) bool {
+	pa1, ok1 := a1.(curves.PairingPoint)
+	pb1, ok2 := b1.(curves.PairingPoint)
+	pa2, ok3 := a2.(curves.PairingPoint)
+	pb2, ok4 := b2.(curves.PairingPoint)
+	if !ok1 || !ok2 || !ok3 || !ok4 {
+		return false
+	}
+	result := pa1.MultiPairing(pa1, pb1, pa2.Neg().(curves.PairingPoint), pb2)
+	return result != nil && result.IsOne()
+}
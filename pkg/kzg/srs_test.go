@@ -0,0 +1,113 @@
+//
+// Copyright Coinbase, Inc. All Rights Reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package kzg
+
+import (
+	"bytes"
+	crand "crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/nerifnetwork/kryptology/pkg/core/curves"
+)
+
+func hexPoints(points []curves.Point) []string {
+	out := make([]string, len(points))
+	for i, p := range points {
+		out[i] = hex.EncodeToString(p.ToAffineCompressed())
+	}
+	return out
+}
+
+func requireSrsEqual(t *testing.T, expected, actual *Srs) {
+	require.Len(t, actual.G1, len(expected.G1))
+	require.Len(t, actual.G2, len(expected.G2))
+	for i, p := range expected.G1 {
+		require.True(t, p.Equal(actual.G1[i]))
+	}
+	for i, p := range expected.G2 {
+		require.True(t, p.Equal(actual.G2[i]))
+	}
+}
+
+func TestSrsValidate(t *testing.T) {
+	srs, err := NewSrs(8, 3, crand.Reader)
+	require.NoError(t, err)
+	require.NoError(t, srs.Validate(crand.Reader))
+
+	bad := &Srs{G1: append([]curves.Point{}, srs.G1...), G2: srs.G2}
+	bad.G1[4] = bad.G1[4].Double()
+	require.Error(t, bad.Validate(crand.Reader))
+
+	bad = &Srs{G1: srs.G1, G2: append([]curves.Point{}, srs.G2...)}
+	bad.G2[2] = bad.G2[2].Double()
+	require.Error(t, bad.Validate(crand.Reader))
+
+	_, err = NewSrs(1, 2, crand.Reader)
+	require.Error(t, err)
+}
+
+func TestReadEthereumSetup(t *testing.T) {
+	srs, err := NewSrs(4, 2, crand.Reader)
+	require.NoError(t, err)
+
+	// The lagrange points are not used so any valid points will do in this test
+	var buf strings.Builder
+	_, _ = fmt.Fprintf(&buf, "%d\n%d\n", len(srs.G1), len(srs.G2))
+	for _, p := range hexPoints(srs.G1) {
+		_, _ = fmt.Fprintln(&buf, p)
+	}
+	for _, p := range hexPoints(srs.G2) {
+		_, _ = fmt.Fprintln(&buf, p)
+	}
+	for _, p := range hexPoints(srs.G1) {
+		_, _ = fmt.Fprintln(&buf, p)
+	}
+	read, err := ReadEthereumSetup(strings.NewReader(buf.String()))
+	require.NoError(t, err)
+	require.NoError(t, read.Validate(crand.Reader))
+	requireSrsEqual(t, srs, read)
+
+	// without the monomial section
+	lines := strings.Split(buf.String(), "\n")
+	_, err = ReadEthereumSetup(strings.NewReader(strings.Join(lines[:2+len(srs.G1)+len(srs.G2)], "\n")))
+	require.Error(t, err)
+}
+
+func TestReadEthereumSetupJson(t *testing.T) {
+	srs, err := NewSrs(4, 2, crand.Reader)
+	require.NoError(t, err)
+	withPrefix := func(in []string) []string {
+		for i := range in {
+			in[i] = "0x" + in[i]
+		}
+		return in
+	}
+	data, err := json.Marshal(map[string][]string{
+		"g1_monomial": withPrefix(hexPoints(srs.G1)),
+		"g1_lagrange": withPrefix(hexPoints(srs.G1)),
+		"g2_monomial": withPrefix(hexPoints(srs.G2)),
+	})
+	require.NoError(t, err)
+	read, err := ReadEthereumSetupJson(bytes.NewReader(data))
+	require.NoError(t, err)
+	requireSrsEqual(t, srs, read)
+
+	// g2 points in place of g1 points
+	data, err = json.Marshal(map[string][]string{
+		"g1_monomial": hexPoints(srs.G2),
+		"g2_monomial": hexPoints(srs.G2),
+	})
+	require.NoError(t, err)
+	_, err = ReadEthereumSetupJson(bytes.NewReader(data))
+	require.Error(t, err)
+}
@@ -0,0 +1,234 @@
+//
+// Copyright Coinbase, Inc. All Rights Reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// This file contains proofs that Paillier moduli have no small factors:
+// [CGGMP21] https://eprint.iacr.org/2021/060.pdf fig 28
+
+package paillier
+
+import (
+	"crypto/elliptic"
+	crand "crypto/rand"
+	"fmt"
+	"math/big"
+
+	"github.com/nerifnetwork/kryptology/internal"
+	crypto "github.com/nerifnetwork/kryptology/pkg/core"
+)
+
+const (
+	// FacProofL is the bit length ℓ of the factors ruled out by the no small factor proof
+	FacProofL = 256
+	// FacProofEpsilon is the slack ε of the no small factor proof
+	FacProofEpsilon = 512
+)
+
+// FacProofParams contains the inputs to the no small factor proof.
+// NTilde, H1 and H2 are the ring-Pedersen proof params of the verifier.
+type FacProofParams struct {
+	Curve          elliptic.Curve
+	SecretKey      *SecretKey
+	Pi             uint32
+	NTilde, H1, H2 *big.Int
+}
+
+// FacVerifyParams contains the inputs to verify the no small factor proof.
+// NTilde, H1 and H2 are the ring-Pedersen proof params of the verifier.
+type FacVerifyParams struct {
+	Curve          elliptic.Curve
+	PublicKey      *PublicKey
+	Pi             uint32
+	NTilde, H1, H2 *big.Int
+}
+
+// FacProof proves that N = pq with p, q > 2^ℓ to the holder of the proof params
+type FacProof struct {
+	P, Q, A, B, T, Sigma *big.Int
+	Z1, Z2, W1, W2, V    *big.Int
+}
+
+// Prove that a Paillier modulus has no small factors
+// [CGGMP21] fig 28
+func (p *FacProofParams) Prove() (*FacProof, error) {
+	if p.Curve == nil ||
+		p.SecretKey == nil ||
+		p.SecretKey.N == nil ||
+		p.SecretKey.Totient == nil ||
+		p.Pi == 0 ||
+		crypto.AnyNil(p.NTilde, p.H1, p.H2) {
+		return nil, internal.ErrNilArguments
+	}
+	n0 := p.SecretKey.N
+	nTilde := p.NTilde
+	if nTilde.Cmp(crypto.One) != 1 {
+		return nil, fmt.Errorf("invalid proof params")
+	}
+	primeP, primeQ, err := p.SecretKey.primes()
+	if err != nil {
+		return nil, err
+	}
+
+	// 1. Sample
+	// α, β <- ±2^{ℓ+ε}·√N0
+	// μ, ν <- ±2^ℓ·Ñ
+	// σ <- ±2^ℓ·N0·Ñ
+	// r <- ±2^{ℓ+ε}·N0·Ñ
+	// x, y <- ±2^{ℓ+ε}·Ñ
+	sqrtN0 := new(big.Int).Sqrt(n0)
+	n0NTilde := new(big.Int).Mul(n0, nTilde)
+	var alpha, beta, mu, nu, sigma, r, x, y *big.Int
+	for _, v := range []struct {
+		value **big.Int
+		bound *big.Int
+		bits  uint
+	}{
+		{&alpha, sqrtN0, FacProofL + FacProofEpsilon},
+		{&beta, sqrtN0, FacProofL + FacProofEpsilon},
+		{&mu, nTilde, FacProofL},
+		{&nu, nTilde, FacProofL},
+		{&sigma, n0NTilde, FacProofL},
+		{&r, n0NTilde, FacProofL + FacProofEpsilon},
+		{&x, nTilde, FacProofL + FacProofEpsilon},
+		{&y, nTilde, FacProofL + FacProofEpsilon},
+	} {
+		if *v.value, err = randSigned(new(big.Int).Lsh(v.bound, v.bits)); err != nil {
+			return nil, err
+		}
+	}
+
+	// 2. P = s^p t^μ, Q = s^q t^ν, A = s^α t^x, B = s^β t^y, T = Q^α t^r mod Ñ
+	pedersen := func(a, b *big.Int) (*big.Int, error) {
+		return ringPedersen(p.H1, a, p.H2, b, nTilde)
+	}
+	proof := &FacProof{Sigma: sigma}
+	if proof.P, err = pedersen(primeP, mu); err != nil {
+		return nil, err
+	}
+	if proof.Q, err = pedersen(primeQ, nu); err != nil {
+		return nil, err
+	}
+	if proof.A, err = pedersen(alpha, x); err != nil {
+		return nil, err
+	}
+	if proof.B, err = pedersen(beta, y); err != nil {
+		return nil, err
+	}
+	if proof.T, err = ringPedersen(proof.Q, alpha, p.H2, r, nTilde); err != nil {
+		return nil, err
+	}
+
+	// 3. e <- FS-HASH(g, q, p_i, N0, Ñ, s, t, P, Q, A, B, T, σ)
+	e, err := proof.challenge(p.Curve.Params(), p.Pi, n0, nTilde, p.H1, p.H2)
+	if err != nil {
+		return nil, err
+	}
+
+	// 4. σ' = σ - νp, z1 = α + ep, z2 = β + eq, w1 = x + eμ, w2 = y + eν, v = r + eσ'
+	sigmaTick := new(big.Int).Sub(sigma, new(big.Int).Mul(nu, primeP))
+	proof.Z1 = new(big.Int).Add(alpha, new(big.Int).Mul(e, primeP))
+	proof.Z2 = new(big.Int).Add(beta, new(big.Int).Mul(e, primeQ))
+	proof.W1 = new(big.Int).Add(x, new(big.Int).Mul(e, mu))
+	proof.W2 = new(big.Int).Add(y, new(big.Int).Mul(e, nu))
+	proof.V = new(big.Int).Add(r, new(big.Int).Mul(e, sigmaTick))
+	return proof, nil
+}
+
+// Verify that a Paillier modulus has no small factors
+// [CGGMP21] fig 28
+func (p *FacProof) Verify(fac *FacVerifyParams) error {
+	if p == nil ||
+		crypto.AnyNil(p.P, p.Q, p.A, p.B, p.T, p.Sigma, p.Z1, p.Z2, p.W1, p.W2, p.V) ||
+		fac == nil ||
+		fac.Curve == nil ||
+		fac.PublicKey == nil ||
+		fac.PublicKey.N == nil ||
+		fac.Pi == 0 ||
+		crypto.AnyNil(fac.NTilde, fac.H1, fac.H2) {
+		return internal.ErrNilArguments
+	}
+	n0 := fac.PublicKey.N
+	nTilde := fac.NTilde
+	if nTilde.Cmp(crypto.One) != 1 || n0.Sign() != 1 {
+		return fmt.Errorf("invalid proof params")
+	}
+	for _, v := range []*big.Int{p.P, p.Q, p.A, p.B, p.T} {
+		if v.Sign() != 1 || v.Cmp(nTilde) != -1 {
+			return fmt.Errorf("invalid commitment")
+		}
+	}
+
+	// z1, z2 ∈ ±√N0·2^{ℓ+ε}
+	bound := new(big.Int).Lsh(new(big.Int).Sqrt(n0), FacProofL+FacProofEpsilon)
+	if new(big.Int).Abs(p.Z1).Cmp(bound) == 1 || new(big.Int).Abs(p.Z2).Cmp(bound) == 1 {
+		return fmt.Errorf("response is out of range")
+	}
+
+	e, err := p.challenge(fac.Curve.Params(), fac.Pi, n0, nTilde, fac.H1, fac.H2)
+	if err != nil {
+		return err
+	}
+
+	// R = s^N0 t^σ
+	R, err := ringPedersen(fac.H1, n0, fac.H2, p.Sigma, nTilde)
+	if err != nil {
+		return err
+	}
+	for _, check := range []struct {
+		base1, exp1, base2, exp2, commitment, value *big.Int
+	}{
+		// s^z1 t^w1 = A P^e
+		{fac.H1, p.Z1, fac.H2, p.W1, p.A, p.P},
+		// s^z2 t^w2 = B Q^e
+		{fac.H1, p.Z2, fac.H2, p.W2, p.B, p.Q},
+		// Q^z1 t^v = T R^e
+		{p.Q, p.Z1, fac.H2, p.V, p.T, R},
+	} {
+		lhs, err := ringPedersen(check.base1, check.exp1, check.base2, check.exp2, nTilde)
+		if err != nil {
+			return err
+		}
+		rhs := new(big.Int).Exp(check.value, e, nTilde)
+		rhs.Mul(rhs, check.commitment)
+		rhs.Mod(rhs, nTilde)
+		if lhs.Cmp(rhs) != 0 {
+			return fmt.Errorf("no small factor proof is not valid")
+		}
+	}
+	return nil
+}
+
+// challenge computes the Fiat-Shamir challenge e ∈ Z_q of the proof
+func (p *FacProof) challenge(params *elliptic.CurveParams, pi uint32, n0, nTilde, h1, h2 *big.Int) (*big.Int, error) {
+	// The sign of σ is hashed separately since FiatShamir only hashes absolute values
+	sign := big.NewInt(int64(p.Sigma.Sign() + 1))
+	Pi := new(big.Int).SetUint64(uint64(pi))
+	res, err := crypto.FiatShamir(params.Gx, params.Gy, params.N, Pi, n0, nTilde, h1, h2,
+		p.P, p.Q, p.A, p.B, p.T, new(big.Int).Abs(p.Sigma), sign)
+	if err != nil {
+		return nil, err
+	}
+	return new(big.Int).Mod(new(big.Int).SetBytes(res), params.N), nil
+}
+
+// ringPedersen computes b1^e1 b2^e2 mod n, the exponents can be negative
+func ringPedersen(b1, e1, b2, e2, n *big.Int) (*big.Int, error) {
+	// Exp returns nil for a negative exponent of a base that is not invertible
+	x := new(big.Int).Exp(b1, e1, n)
+	y := new(big.Int).Exp(b2, e2, n)
+	if x == nil || y == nil {
+		return nil, fmt.Errorf("base is not invertible")
+	}
+	x.Mul(x, y)
+	return x.Mod(x, n), nil
+}
+
+// randSigned returns a uniform random integer in [-bound, bound]
+func randSigned(bound *big.Int) (*big.Int, error) {
+	r, err := crand.Int(crand.Reader, new(big.Int).Add(new(big.Int).Lsh(bound, 1), crypto.One))
+	if err != nil {
+		return nil, err
+	}
+	return r.Sub(r, bound), nil
+}
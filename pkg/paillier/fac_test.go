@@ -0,0 +1,92 @@
+//
+// Copyright Coinbase, Inc. All Rights Reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package paillier
+
+import (
+	crand "crypto/rand"
+	"math/big"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcec"
+	"github.com/stretchr/testify/require"
+
+	crypto "github.com/nerifnetwork/kryptology/pkg/core"
+)
+
+// facTestParams returns ring-Pedersen proof params of a verifier
+func facTestParams(t *testing.T) (*big.Int, *big.Int, *big.Int) {
+	nTilde := new(big.Int).Mul(testPrimes[2], testPrimes[3])
+	f, err := crypto.Rand(nTilde)
+	require.NoError(t, err)
+	alpha, err := crypto.Rand(nTilde)
+	require.NoError(t, err)
+	h1 := new(big.Int).Exp(f, big.NewInt(2), nTilde)
+	return nTilde, h1, new(big.Int).Exp(h1, alpha, nTilde)
+}
+
+func TestFacProofWorks(t *testing.T) {
+	sk, err := NewSecretKey(testPrimes[0], testPrimes[1])
+	require.NoError(t, err)
+	curve := btcec.S256()
+	nTilde, h1, h2 := facTestParams(t)
+	proof, err := (&FacProofParams{Curve: curve, SecretKey: sk, Pi: 1, NTilde: nTilde, H1: h1, H2: h2}).Prove()
+	require.NoError(t, err)
+	verify := FacVerifyParams{Curve: curve, PublicKey: &sk.PublicKey, Pi: 1, NTilde: nTilde, H1: h1, H2: h2}
+	require.NoError(t, proof.Verify(&verify))
+
+	// The proof is bound to the prover, the modulus and the proof params of the verifier
+	wrongPi := verify
+	wrongPi.Pi = 2
+	require.Error(t, proof.Verify(&wrongPi))
+	other, err := NewSecretKey(testPrimes[4], testPrimes[5])
+	require.NoError(t, err)
+	wrongKey := verify
+	wrongKey.PublicKey = &other.PublicKey
+	require.Error(t, proof.Verify(&wrongKey))
+	wrongParams := verify
+	wrongParams.H1, wrongParams.H2 = h2, h1
+	require.Error(t, proof.Verify(&wrongParams))
+}
+
+func TestFacProofTampered(t *testing.T) {
+	sk, err := NewSecretKey(testPrimes[0], testPrimes[1])
+	require.NoError(t, err)
+	curve := btcec.S256()
+	nTilde, h1, h2 := facTestParams(t)
+	verify := &FacVerifyParams{Curve: curve, PublicKey: &sk.PublicKey, Pi: 1, NTilde: nTilde, H1: h1, H2: h2}
+	for _, tamper := range []func(p *FacProof){
+		func(p *FacProof) { p.Sigma = new(big.Int).Neg(p.Sigma) },
+		func(p *FacProof) { p.Z1 = new(big.Int).Add(p.Z1, big.NewInt(1)) },
+		func(p *FacProof) { p.W2 = new(big.Int).Add(p.W2, big.NewInt(1)) },
+		func(p *FacProof) { p.V = new(big.Int).Add(p.V, big.NewInt(1)) },
+		func(p *FacProof) { p.T = new(big.Int).Add(p.T, big.NewInt(1)) },
+		func(p *FacProof) { p.P = new(big.Int).Set(nTilde) },
+		func(p *FacProof) { p.Q = nil },
+	} {
+		proof, err := (&FacProofParams{Curve: curve, SecretKey: sk, Pi: 1, NTilde: nTilde, H1: h1, H2: h2}).Prove()
+		require.NoError(t, err)
+		tamper(proof)
+		require.Error(t, proof.Verify(verify))
+	}
+	require.Error(t, (*FacProof)(nil).Verify(verify))
+}
+
+func TestFacProofRejectsSmallFactor(t *testing.T) {
+	// N = pq of the size of a paillier key with a 128-bit factor p
+	p, err := crand.Prime(crand.Reader, 128)
+	require.NoError(t, err)
+	q, err := crand.Prime(crand.Reader, 2*PaillierPrimeBits-128)
+	require.NoError(t, err)
+	sk, err := NewSecretKey(p, q)
+	require.NoError(t, err)
+
+	curve := btcec.S256()
+	nTilde, h1, h2 := facTestParams(t)
+	proof, err := (&FacProofParams{Curve: curve, SecretKey: sk, Pi: 1, NTilde: nTilde, H1: h1, H2: h2}).Prove()
+	require.NoError(t, err)
+	require.Error(t, proof.Verify(&FacVerifyParams{Curve: curve, PublicKey: &sk.PublicKey, Pi: 1, NTilde: nTilde, H1: h1, H2: h2}))
+}
@@ -0,0 +1,276 @@
+//
+// Copyright Coinbase, Inc. All Rights Reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// This file contains proofs that Paillier moduli are Paillier-Blum moduli:
+// [CGGMP21] https://eprint.iacr.org/2021/060.pdf fig 16
+
+package paillier
+
+import (
+	"crypto/elliptic"
+	"fmt"
+	"math/big"
+
+	"github.com/nerifnetwork/kryptology/internal"
+	crypto "github.com/nerifnetwork/kryptology/pkg/core"
+)
+
+// ModProofIterations is the number of challenges m of the Paillier-Blum modulus proof,
+// each halves the probability that a cheating prover succeeds
+const ModProofIterations = 80
+
+// ModProofParams contains the inputs to the Paillier-Blum modulus proof
+type ModProofParams struct {
+	Curve     elliptic.Curve
+	SecretKey *SecretKey
+	Pi        uint32
+}
+
+// ModVerifyParams contains the inputs to verify the Paillier-Blum modulus proof
+type ModVerifyParams struct {
+	Curve     elliptic.Curve
+	PublicKey *PublicKey
+	Pi        uint32
+}
+
+// ModProof proves that N = pq with p ≡ q ≡ 3 mod 4 and gcd(N, φ(N)) = 1
+type ModProof struct {
+	// W is a random value with Jacobi symbol -1
+	W *big.Int
+	// X are the fourth roots x_i^4 = (-1)^A_i W^B_i y_i mod N of the challenges y_i
+	X []*big.Int
+	A []bool
+	B []bool
+	// Z are the N-th roots z_i^N = y_i mod N of the challenges y_i
+	Z []*big.Int
+}
+
+// Prove that a Paillier modulus is a Paillier-Blum modulus
+// [CGGMP21] fig 16
+func (p *ModProofParams) Prove() (*ModProof, error) {
+	if p.Curve == nil ||
+		p.SecretKey == nil ||
+		p.SecretKey.N == nil ||
+		p.SecretKey.Totient == nil ||
+		p.Pi == 0 {
+		return nil, internal.ErrNilArguments
+	}
+	n := p.SecretKey.N
+	primeP, primeQ, err := p.SecretKey.primes()
+	if err != nil {
+		return nil, err
+	}
+	three := big.NewInt(3)
+	four := big.NewInt(4)
+	if new(big.Int).Mod(primeP, four).Cmp(three) != 0 || new(big.Int).Mod(primeQ, four).Cmp(three) != 0 {
+		return nil, fmt.Errorf("paillier primes are not 3 mod 4")
+	}
+
+	// 1. Sample w ∈ Z_N with Jacobi symbol (w|N) = -1
+	var w *big.Int
+	for w == nil || big.Jacobi(w, n) != -1 {
+		if w, err = crypto.Rand(n); err != nil {
+			return nil, err
+		}
+	}
+
+	// 2. y_i <- FS-HASH(g, q, p_i, N, w, i) ∈ Z_N
+	y, err := modChallenges(p.Curve.Params(), n, p.Pi, w)
+	if err != nil {
+		return nil, err
+	}
+
+	// M = N^{-1} mod φ(N)
+	m, err := crypto.Inv(n, p.SecretKey.Totient)
+	if err != nil {
+		return nil, err
+	}
+
+	// Exponents of the fourth roots modulo p and q: ((p+1)/4)^2 mod (p-1)
+	expP := fourthRootExp(primeP)
+	expQ := fourthRootExp(primeQ)
+	minusOne := new(big.Int).Sub(n, crypto.One)
+
+	proof := &ModProof{
+		W: w,
+		X: make([]*big.Int, ModProofIterations),
+		A: make([]bool, ModProofIterations),
+		B: make([]bool, ModProofIterations),
+		Z: make([]*big.Int, ModProofIterations),
+	}
+	for i, yi := range y {
+		// 3. Compute z_i = y_i^M mod N
+		proof.Z[i] = new(big.Int).Exp(yi, m, n)
+
+		// 4. Find the unique a_i, b_i such that y_i' = (-1)^a_i w^b_i y_i is a quadratic residue mod N
+		found := false
+		for _, a := range []bool{false, true} {
+			for _, b := range []bool{false, true} {
+				yTick := new(big.Int).Set(yi)
+				if a {
+					yTick.Mul(yTick, minusOne)
+				}
+				if b {
+					yTick.Mul(yTick, w)
+				}
+				yTick.Mod(yTick, n)
+				if found || big.Jacobi(yTick, primeP) != 1 || big.Jacobi(yTick, primeQ) != 1 {
+					continue
+				}
+
+				// 5. Compute x_i = y_i'^{1/4} mod N
+				xp := new(big.Int).Exp(yTick, expP, primeP)
+				xq := new(big.Int).Exp(yTick, expQ, primeQ)
+				proof.X[i] = crt(xp, xq, primeP, primeQ)
+				proof.A[i], proof.B[i] = a, b
+				found = true
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("challenge %d has no fourth root", i)
+		}
+	}
+	return proof, nil
+}
+
+// Verify that a Paillier modulus is a Paillier-Blum modulus
+// [CGGMP21] fig 16
+func (p *ModProof) Verify(mod *ModVerifyParams) error {
+	if p == nil || p.W == nil ||
+		mod == nil ||
+		mod.Curve == nil ||
+		mod.PublicKey == nil ||
+		mod.PublicKey.N == nil ||
+		mod.Pi == 0 {
+		return internal.ErrNilArguments
+	}
+	n := mod.PublicKey.N
+
+	// N must be an odd composite number
+	if n.Bit(0) != 1 || n.ProbablyPrime(20) {
+		return fmt.Errorf("paillier modulus is prime or even")
+	}
+	if len(p.X) != ModProofIterations ||
+		len(p.A) != ModProofIterations ||
+		len(p.B) != ModProofIterations ||
+		len(p.Z) != ModProofIterations {
+		return fmt.Errorf("invalid number of challenges")
+	}
+	if p.W.Sign() != 1 || p.W.Cmp(n) != -1 || big.Jacobi(p.W, n) != -1 {
+		return fmt.Errorf("invalid w")
+	}
+
+	y, err := modChallenges(mod.Curve.Params(), n, mod.Pi, p.W)
+	if err != nil {
+		return err
+	}
+
+	four := big.NewInt(4)
+	for i, yi := range y {
+		if crypto.AnyNil(p.X[i], p.Z[i]) {
+			return internal.ErrNilArguments
+		}
+
+		// z_i^N = y_i mod N
+		if p.Z[i].Sign() != 1 || p.Z[i].Cmp(n) != -1 ||
+			new(big.Int).Exp(p.Z[i], n, n).Cmp(yi) != 0 {
+			return fmt.Errorf("invalid N-th root at %d", i)
+		}
+
+		// x_i^4 = (-1)^a_i w^b_i y_i mod N
+		rhs := new(big.Int).Set(yi)
+		if p.A[i] {
+			rhs.Neg(rhs)
+		}
+		if p.B[i] {
+			rhs.Mul(rhs, p.W)
+		}
+		rhs.Mod(rhs, n)
+		if p.X[i].Sign() != 1 || p.X[i].Cmp(n) != -1 ||
+			new(big.Int).Exp(p.X[i], four, n).Cmp(rhs) != 0 {
+			return fmt.Errorf("invalid fourth root at %d", i)
+		}
+	}
+	return nil
+}
+
+// primes recovers the factors of N from N and φ(N)
+func (sk *SecretKey) primes() (*big.Int, *big.Int, error) {
+	// p + q = N - φ(N) + 1
+	sum := new(big.Int).Sub(sk.N, sk.Totient)
+	sum.Add(sum, crypto.One)
+
+	// p - q = sqrt((p + q)^2 - 4N)
+	d := new(big.Int).Mul(sum, sum)
+	d.Sub(d, new(big.Int).Lsh(sk.N, 2))
+	if d.Sign() < 0 {
+		return nil, nil, fmt.Errorf("invalid paillier secret key")
+	}
+	d.Sqrt(d)
+
+	p := new(big.Int).Add(sum, d)
+	p.Rsh(p, 1)
+	q := new(big.Int).Sub(sum, d)
+	q.Rsh(q, 1)
+	if new(big.Int).Mul(p, q).Cmp(sk.N) != 0 {
+		return nil, nil, fmt.Errorf("invalid paillier secret key")
+	}
+	return p, q, nil
+}
+
+// fourthRootExp returns e such that x^e is the fourth root of a quartic residue x mod p for p ≡ 3 mod 4
+func fourthRootExp(p *big.Int) *big.Int {
+	e := new(big.Int).Add(p, crypto.One)
+	e.Rsh(e, 2)
+	e.Mul(e, e)
+	return e.Mod(e, new(big.Int).Sub(p, crypto.One))
+}
+
+// crt returns x mod pq such that x = xp mod p and x = xq mod q
+func crt(xp, xq, p, q *big.Int) *big.Int {
+	// x = xq + q * ((xp - xq) * q^-1 mod p)
+	qInv := new(big.Int).ModInverse(q, p)
+	h := new(big.Int).Sub(xp, xq)
+	h.Mul(h, qInv)
+	h.Mod(h, p)
+	h.Mul(h, q)
+	return h.Add(h, xq)
+}
+
+// modChallenges computes ModProofIterations deterministic challenges in Z_N*
+// as in GenerateChallenges of the square-free proof
+func modChallenges(params *elliptic.CurveParams, n *big.Int, pi uint32, w *big.Int) ([]*big.Int, error) {
+	b := n.BitLen()
+	if b < 8 {
+		return nil, internal.ErrNilArguments
+	}
+	// Number of sha256 outputs required to obtain b bits
+	s := int64((b + 255) / 256)
+	Pi := new(big.Int).SetUint64(uint64(pi))
+
+	y := make([]*big.Int, 0, ModProofIterations)
+	m := big.NewInt(0)
+	for j := int64(0); len(y) < ModProofIterations; {
+		var ej []byte
+		for k := int64(1); k <= s; k++ {
+			res, err := crypto.FiatShamir(params.Gx, params.Gy, params.N, Pi, n, w, big.NewInt(j), big.NewInt(k), m)
+			if err != nil {
+				return nil, err
+			}
+			ej = append(ej, res...)
+		}
+		yj := new(big.Int).SetBytes(ej[:b/8])
+
+		// Accept y_j ∈ Z_N*, else try the next m
+		if yj.Sign() == 1 && yj.Cmp(n) == -1 && new(big.Int).GCD(nil, nil, yj, n).Cmp(crypto.One) == 0 {
+			y = append(y, yj)
+			j++
+			m = big.NewInt(0)
+		} else {
+			m.Add(m, crypto.One)
+		}
+	}
+	return y, nil
+}
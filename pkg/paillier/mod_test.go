@@ -0,0 +1,72 @@
+//
+// Copyright Coinbase, Inc. All Rights Reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package paillier
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcec"
+	"github.com/stretchr/testify/require"
+)
+
+func TestModProofWorks(t *testing.T) {
+	sk, err := NewSecretKey(testPrimes[0], testPrimes[1])
+	require.NoError(t, err)
+	curve := btcec.S256()
+	proof, err := (&ModProofParams{Curve: curve, SecretKey: sk, Pi: 1}).Prove()
+	require.NoError(t, err)
+	require.NoError(t, proof.Verify(&ModVerifyParams{Curve: curve, PublicKey: &sk.PublicKey, Pi: 1}))
+
+	// The proof is bound to the prover and the modulus
+	require.Error(t, proof.Verify(&ModVerifyParams{Curve: curve, PublicKey: &sk.PublicKey, Pi: 2}))
+	other, err := NewSecretKey(testPrimes[2], testPrimes[3])
+	require.NoError(t, err)
+	require.Error(t, proof.Verify(&ModVerifyParams{Curve: curve, PublicKey: &other.PublicKey, Pi: 1}))
+}
+
+func TestModProofTampered(t *testing.T) {
+	sk, err := NewSecretKey(testPrimes[0], testPrimes[1])
+	require.NoError(t, err)
+	curve := btcec.S256()
+	verify := &ModVerifyParams{Curve: curve, PublicKey: &sk.PublicKey, Pi: 1}
+	for _, tamper := range []func(p *ModProof){
+		func(p *ModProof) { p.X[3] = new(big.Int).Add(p.X[3], big.NewInt(1)) },
+		func(p *ModProof) { p.Z[7] = new(big.Int).Add(p.Z[7], big.NewInt(1)) },
+		func(p *ModProof) { p.A[0] = !p.A[0] },
+		func(p *ModProof) { p.B[0] = !p.B[0] },
+		func(p *ModProof) { p.W = new(big.Int).Sub(sk.N, p.W) },
+		func(p *ModProof) { p.X = p.X[1:] },
+		func(p *ModProof) { p.Z[0] = nil },
+	} {
+		proof, err := (&ModProofParams{Curve: curve, SecretKey: sk, Pi: 1}).Prove()
+		require.NoError(t, err)
+		tamper(proof)
+		require.Error(t, proof.Verify(verify))
+	}
+	require.Error(t, (*ModProof)(nil).Verify(verify))
+}
+
+func TestModProofRejectsModuli(t *testing.T) {
+	curve := btcec.S256()
+	// Primes that are 1 mod 4 cannot be proven
+	sk, err := NewSecretKey(big.NewInt(13), big.NewInt(7))
+	require.NoError(t, err)
+	_, err = (&ModProofParams{Curve: curve, SecretKey: sk, Pi: 1}).Prove()
+	require.Error(t, err)
+
+	// Prime and even moduli are rejected
+	sk, err = NewSecretKey(testPrimes[0], testPrimes[1])
+	require.NoError(t, err)
+	proof, err := (&ModProofParams{Curve: curve, SecretKey: sk, Pi: 1}).Prove()
+	require.NoError(t, err)
+	for _, n := range []*big.Int{testPrimes[0], new(big.Int).Lsh(sk.N, 1)} {
+		require.Error(t, proof.Verify(&ModVerifyParams{Curve: curve, PublicKey: &PublicKey{N: n}, Pi: 1}))
+	}
+	_, err = (&ModProofParams{Curve: curve, Pi: 1}).Prove()
+	require.Error(t, err)
+}
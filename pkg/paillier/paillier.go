@@ -293,6 +293,13 @@ func (pk *PublicKey) Encrypt(msg *big.Int) (Ciphertext, *big.Int, error) {
 	return ct, r, err
 }
 
+// EncryptWithNonce produces the ciphertext of msg with the nonce r.
+// Together with the nonce returned by Encrypt or DecryptWithNonce it proves
+// that a ciphertext holds msg.
+func (pk *PublicKey) EncryptWithNonce(msg, r *big.Int) (Ciphertext, error) {
+	return pk.encrypt(msg, r)
+}
+
 // encrypt produces a ciphertext on input a message and nonce.
 func (pk *PublicKey) encrypt(msg, r *big.Int) (Ciphertext, error) {
 	if msg == nil || r == nil {
@@ -358,6 +365,31 @@ func (sk *SecretKey) Decrypt(c Ciphertext) (*big.Int, error) {
 	return m, nil
 }
 
+// DecryptWithNonce decrypts c and also recovers the nonce r such that
+// c = (N+1)^m * r^N mod N², so that the decryption can be checked by anyone
+// with EncryptWithNonce.
+func (sk *SecretKey) DecryptWithNonce(c Ciphertext) (*big.Int, *big.Int, error) {
+	m, err := sk.Decrypt(c)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// r^N ≡ c * (N+1)^{-m} ≡ c * (1 - mN)	mod N²
+	g := new(big.Int).Mul(m, sk.N)
+	g.Sub(sk.N2, g)
+	g.Add(g, core.One)
+	rN := new(big.Int).Mul(c, g)
+	rN.Mod(rN, sk.N2)
+
+	// r ≡ (r^N)^{N^{-1} mod φ(N)}	mod N
+	nInv := new(big.Int).ModInverse(sk.N, sk.Totient)
+	if nInv == nil {
+		return nil, nil, fmt.Errorf("N is not invertible modulo φ(N)")
+	}
+	r := new(big.Int).Exp(rN.Mod(rN, sk.N), nInv, sk.N)
+	return m, r, nil
+}
+
 // MarshalJSON converts the secret key into json format.
 func (sk SecretKey) MarshalJSON() ([]byte, error) {
 	data := SecretKeyJson{
@@ -1083,3 +1083,35 @@ func TestNewSecretKeyErrorConditions(t *testing.T) {
 		require.Contains(t, err.Error(), "arguments cannot be nil")
 	}
 }
+
+// DecryptWithNonce recovers the nonce used by Encrypt
+func TestDecryptWithNonce(t *testing.T) {
+	p := tt.B10("133788347333574532510542341875219452703250094560184213896952738579939377079849213618116996737030817731544214409221015150233522821287955673536671953914660520267670984696713816508768479853621956967492030516224494353641551367310541202655075859386716364585825364092974073148178887544704793573033779774765431460367")
+	q := tt.B10("121400263190232595456200749546561304956161672968687911935494950378721768184159069938532869288284686583150658925255722159156454219960265942696166947144912738151554579878178746701374346180640493532962639632666540478486867810588884360492830920363713588684509182704981665082591486786717530494254613085570321507623")
+	sk, err := NewSecretKey(p, q)
+	require.NoError(t, err)
+
+	for _, m := range []*big.Int{crypto.Zero, crypto.One, x, new(big.Int).Sub(sk.N, crypto.One)} {
+		c, r, err := sk.PublicKey.Encrypt(m)
+		require.NoError(t, err)
+		actual, nonce, err := sk.DecryptWithNonce(c)
+		require.NoError(t, err)
+		require.Equal(t, m, actual)
+		require.Equal(t, r, nonce)
+
+		c2, err := sk.PublicKey.EncryptWithNonce(actual, nonce)
+		require.NoError(t, err)
+		require.Equal(t, c, c2)
+	}
+
+	// A ciphertext computed homomorphically also has a nonce
+	c, _, err := sk.PublicKey.Encrypt(x)
+	require.NoError(t, err)
+	c, err = sk.PublicKey.Mul(y, c)
+	require.NoError(t, err)
+	m, nonce, err := sk.DecryptWithNonce(c)
+	require.NoError(t, err)
+	c2, err := sk.PublicKey.EncryptWithNonce(m, nonce)
+	require.NoError(t, err)
+	require.Equal(t, c, c2)
+}
@@ -11,6 +11,7 @@ import (
 	"io"
 
 	"github.com/nerifnetwork/kryptology/pkg/core/curves"
+	"github.com/nerifnetwork/kryptology/pkg/core/polynomial"
 )
 
 type FeldmanVerifier struct {
@@ -41,6 +42,59 @@ func (v FeldmanVerifier) Verify(share *ShamirShare) error {
 	}
 }
 
+// VerifyShares checks many shares at once. It interpolates the shares and compares
+// the coefficients to the commitments, which costs threshold base point multiplications
+// instead of threshold multiplications per share. It does not tell which share is
+// wrong, use Verify on each share for that.
+func (v FeldmanVerifier) VerifyShares(shares ...*ShamirShare) error {
+	if len(v.Commitments) == 0 || len(shares) == 0 {
+		return fmt.Errorf("invalid arguments")
+	}
+	if len(shares) < len(v.Commitments) {
+		for _, share := range shares {
+			if err := v.Verify(share); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	curve := curves.GetCurveByName(v.Commitments[0].CurveName())
+	coefficients, err := interpolateShares(curve, shares)
+	if err != nil {
+		return err
+	}
+	for k, c := range coefficients {
+		// The coefficients above the degree of the commitments vanish
+		if k >= len(v.Commitments) {
+			if !c.IsZero() {
+				return fmt.Errorf("not equal")
+			}
+			continue
+		}
+		if !v.Commitments[0].Generator().Mul(c).Equal(v.Commitments[k]) {
+			return fmt.Errorf("not equal")
+		}
+	}
+	return nil
+}
+
+// interpolateShares returns the coefficients of the polynomial through the shares
+func interpolateShares(curve *curves.Curve, shares []*ShamirShare) ([]curves.Scalar, error) {
+	xs := make([]curves.Scalar, len(shares))
+	ys := make([]curves.Scalar, len(shares))
+	for i, share := range shares {
+		if share == nil {
+			return nil, fmt.Errorf("invalid share")
+		}
+		if err := share.Validate(curve); err != nil {
+			return nil, err
+		}
+		xs[i] = curve.Scalar.New(int(share.Id))
+		ys[i], _ = curve.Scalar.SetBytes(share.Value)
+	}
+	return polynomial.Interpolate(xs, ys)
+}
+
 type Feldman struct {
 	Threshold, Limit uint32
 	Curve            *curves.Curve
@@ -0,0 +1,39 @@
+//
+// Copyright Coinbase, Inc. All Rights Reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package sharing
+
+import (
+	crand "crypto/rand"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/nerifnetwork/kryptology/pkg/core/curves"
+)
+
+func TestFeldmanVerifyShares(t *testing.T) {
+	// BLS12-381 splits with the subproduct tree, ED25519 with Horner
+	for _, curve := range []*curves.Curve{curves.ED25519(), curves.BLS12381G1()} {
+		scheme, err := NewFeldman(100, 200, curve)
+		require.NoError(t, err)
+		secret := curve.Scalar.Random(crand.Reader)
+		verifier, shares, err := scheme.Split(secret, crand.Reader)
+		require.NoError(t, err)
+		require.NoError(t, verifier.Verify(shares[150]))
+		require.NoError(t, verifier.VerifyShares(shares...))
+		require.NoError(t, verifier.VerifyShares(shares[50:150]...))
+		require.NoError(t, verifier.VerifyShares(shares[:3]...))
+		result, err := scheme.Combine(shares[100:]...)
+		require.NoError(t, err)
+		require.Equal(t, secret, result)
+
+		shares[7] = &ShamirShare{Id: shares[7].Id, Value: curve.Scalar.One().Bytes()}
+		require.Error(t, verifier.VerifyShares(shares...))
+		require.Error(t, verifier.VerifyShares(shares[5:8]...))
+		require.NoError(t, verifier.VerifyShares(shares[8:]...))
+	}
+}
@@ -0,0 +1,70 @@
+//
+// Copyright Coinbase, Inc. All Rights Reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package gf256
+
+// The field is GF(2^8) with the AES reduction polynomial x^8 + x^4 + x^3 + x + 1,
+// which is also the field used by SLIP-39. Addition and subtraction are xor.
+// Multiplication and inversion avoid lookup tables and secret dependent branches
+// so the running time does not depend on the bytes of the secret.
+
+// mul returns a*b in GF(2^8)
+func mul(a, b byte) byte {
+	var r byte
+	for i := 0; i < 8; i++ {
+		// mask is 0xff when the low bit of b is set, 0 otherwise
+		r ^= -(b & 1) & a
+		b >>= 1
+		// multiply a by x and reduce when the high bit falls off
+		a = (a << 1) ^ (-(a >> 7) & 0x1b)
+	}
+	return r
+}
+
+// inv returns a^-1 in GF(2^8) computed as a^254. inv(0) returns 0.
+func inv(a byte) byte {
+	// 254 = 0b11111110
+	a2 := mul(a, a)
+	a3 := mul(a2, a)
+	a6 := mul(a3, a3)
+	a7 := mul(a6, a)
+	a14 := mul(a7, a7)
+	a15 := mul(a14, a)
+	a30 := mul(a15, a15)
+	a31 := mul(a30, a)
+	a62 := mul(a31, a31)
+	a63 := mul(a62, a)
+	a126 := mul(a63, a63)
+	a127 := mul(a126, a)
+	return mul(a127, a127)
+}
+
+// div returns a/b in GF(2^8)
+func div(a, b byte) byte {
+	return mul(a, inv(b))
+}
+
+// interpolate evaluates at x the polynomials defined bytewise by the points (xs, ys).
+// Every ys[i] must have the same length.
+func interpolate(xs []byte, ys [][]byte, x byte) []byte {
+	result := make([]byte, len(ys[0]))
+	for i, xi := range xs {
+		num := byte(1)
+		den := byte(1)
+		for j, xj := range xs {
+			if i == j {
+				continue
+			}
+			num = mul(num, x^xj)
+			den = mul(den, xi^xj)
+		}
+		basis := div(num, den)
+		for k, y := range ys[i] {
+			result[k] ^= mul(y, basis)
+		}
+	}
+	return result
+}
@@ -0,0 +1,196 @@
+//
+// Copyright Coinbase, Inc. All Rights Reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+// Package gf256 is an implementation of shamir secret sharing over GF(2^8)
+// for secrets that are arbitrary byte strings such as seeds, mnemonic entropy
+// or encrypted backups. Every byte of the secret is shared with an independent
+// polynomial so a share is only four bytes longer than the secret.
+//
+// It also implements the SLIP-39 mnemonic share format of the following specification.
+//
+// - https://github.com/satoshilabs/slips/blob/master/slip-0039.md
+package gf256
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"fmt"
+	"io"
+)
+
+const (
+	// checksumLength is the number of bytes appended to each serialized share
+	checksumLength = 4
+	// digestLength is the number of bytes of the secret digest shared along with the secret
+	digestLength = 4
+)
+
+// Share is a single share of a byte string secret
+type Share struct {
+	Id    uint32 `json:"identifier"`
+	Value []byte `json:"value"`
+}
+
+// Validate checks the share has a usable identifier and value
+func (s Share) Validate() error {
+	if s.Id == 0 || s.Id > 255 {
+		return fmt.Errorf("invalid identifier")
+	}
+	if len(s.Value) <= digestLength {
+		return fmt.Errorf("invalid share")
+	}
+	return nil
+}
+
+// MarshalBinary encodes the share as identifier || value || checksum
+// where the checksum is the first 4 bytes of SHA-256(identifier || value)
+func (s Share) MarshalBinary() ([]byte, error) {
+	if err := s.Validate(); err != nil {
+		return nil, err
+	}
+	out := make([]byte, 0, 1+len(s.Value)+checksumLength)
+	out = append(out, byte(s.Id))
+	out = append(out, s.Value...)
+	return append(out, shareChecksum(out)...), nil
+}
+
+// UnmarshalBinary decodes a share and rejects it if the checksum does not match
+func (s *Share) UnmarshalBinary(data []byte) error {
+	if len(data) < 1+digestLength+1+checksumLength {
+		return fmt.Errorf("invalid share length")
+	}
+	body := data[:len(data)-checksumLength]
+	if subtle.ConstantTimeCompare(shareChecksum(body), data[len(body):]) != 1 {
+		return fmt.Errorf("invalid share checksum")
+	}
+	share := Share{
+		Id:    uint32(body[0]),
+		Value: make([]byte, len(body)-1),
+	}
+	copy(share.Value, body[1:])
+	if err := share.Validate(); err != nil {
+		return err
+	}
+	*s = share
+	return nil
+}
+
+func shareChecksum(data []byte) []byte {
+	h := sha256.Sum256(data)
+	return h[:checksumLength]
+}
+
+// Shamir splits byte strings into threshold of limit shares
+type Shamir struct {
+	threshold, limit uint32
+}
+
+// NewShamir creates a byte string secret sharing scheme
+func NewShamir(threshold, limit uint32) (*Shamir, error) {
+	if limit < threshold {
+		return nil, fmt.Errorf("limit cannot be less than threshold")
+	}
+	if threshold < 2 {
+		return nil, fmt.Errorf("threshold cannot be less than 2")
+	}
+	if limit > 255 {
+		return nil, fmt.Errorf("cannot exceed 255 shares")
+	}
+	return &Shamir{threshold, limit}, nil
+}
+
+// Split divides the secret into shares. A 4 byte digest of the secret is
+// shared along with it so Combine can detect wrong or mismatched shares.
+func (s Shamir) Split(secret []byte, reader io.Reader) ([]*Share, error) {
+	if len(secret) == 0 {
+		return nil, fmt.Errorf("invalid secret")
+	}
+	if reader == nil {
+		return nil, fmt.Errorf("invalid reader")
+	}
+	digest := sha256.Sum256(secret)
+	value := make([]byte, 0, len(secret)+digestLength)
+	value = append(value, secret...)
+	value = append(value, digest[:digestLength]...)
+
+	values, err := splitBytes(s.threshold, s.limit, value, reader)
+	if err != nil {
+		return nil, err
+	}
+	shares := make([]*Share, s.limit)
+	for i := range shares {
+		shares[i] = &Share{
+			Id:    uint32(i + 1),
+			Value: values[i],
+		}
+	}
+	return shares, nil
+}
+
+// Combine reconstructs the secret from at least threshold shares
+func (s Shamir) Combine(shares ...*Share) ([]byte, error) {
+	if len(shares) < int(s.threshold) {
+		return nil, fmt.Errorf("invalid number of shares")
+	}
+	dups := make(map[uint32]bool, len(shares))
+	xs := make([]byte, len(shares))
+	ys := make([][]byte, len(shares))
+	for i, share := range shares {
+		if share == nil {
+			return nil, fmt.Errorf("invalid share")
+		}
+		if err := share.Validate(); err != nil {
+			return nil, err
+		}
+		if share.Id > s.limit {
+			return nil, fmt.Errorf("invalid share identifier")
+		}
+		if _, in := dups[share.Id]; in {
+			return nil, fmt.Errorf("duplicate share")
+		}
+		if len(share.Value) != len(shares[0].Value) {
+			return nil, fmt.Errorf("share lengths do not match")
+		}
+		dups[share.Id] = true
+		xs[i] = byte(share.Id)
+		ys[i] = share.Value
+	}
+	value := interpolate(xs, ys, 0)
+	secret := value[:len(value)-digestLength]
+	digest := sha256.Sum256(secret)
+	if subtle.ConstantTimeCompare(digest[:digestLength], value[len(secret):]) != 1 {
+		return nil, fmt.Errorf("invalid secret digest")
+	}
+	return secret, nil
+}
+
+// splitBytes shares secret bytewise with random polynomials of degree threshold-1
+// and returns the evaluations at 1, ..., limit
+func splitBytes(threshold, limit uint32, secret []byte, reader io.Reader) ([][]byte, error) {
+	// coefficients[0] is the secret, the rest are random
+	coefficients := make([][]byte, threshold)
+	coefficients[0] = secret
+	for i := 1; i < len(coefficients); i++ {
+		coefficients[i] = make([]byte, len(secret))
+		if _, err := io.ReadFull(reader, coefficients[i]); err != nil {
+			return nil, err
+		}
+	}
+	values := make([][]byte, limit)
+	for i := range values {
+		x := byte(i + 1)
+		values[i] = make([]byte, len(secret))
+		for k := range secret {
+			// horner evaluation
+			out := coefficients[threshold-1][k]
+			for j := int(threshold) - 2; j >= 0; j-- {
+				out = mul(out, x) ^ coefficients[j][k]
+			}
+			values[i][k] = out
+		}
+	}
+	return values, nil
+}
@@ -0,0 +1,124 @@
+//
+// Copyright Coinbase, Inc. All Rights Reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package gf256
+
+import (
+	crand "crypto/rand"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// slowMul is the textbook carry-less multiply and reduce
+func slowMul(a, b byte) byte {
+	p := uint16(0)
+	for i := 0; i < 8; i++ {
+		if b&(1<<i) != 0 {
+			p ^= uint16(a) << i
+		}
+	}
+	for i := 15; i >= 8; i-- {
+		if p&(1<<i) != 0 {
+			p ^= 0x11b << (i - 8)
+		}
+	}
+	return byte(p)
+}
+
+func TestFieldMulInv(t *testing.T) {
+	for a := 0; a < 256; a++ {
+		for b := 0; b < 256; b++ {
+			require.Equal(t, slowMul(byte(a), byte(b)), mul(byte(a), byte(b)))
+		}
+		if a != 0 {
+			require.Equal(t, byte(1), mul(byte(a), inv(byte(a))))
+		}
+	}
+	require.Equal(t, byte(0), inv(0))
+}
+
+func TestShamirInvalidArgs(t *testing.T) {
+	_, err := NewShamir(0, 0)
+	require.NotNil(t, err)
+	_, err = NewShamir(3, 2)
+	require.NotNil(t, err)
+	_, err = NewShamir(1, 10)
+	require.NotNil(t, err)
+	_, err = NewShamir(2, 256)
+	require.NotNil(t, err)
+	scheme, err := NewShamir(2, 3)
+	require.Nil(t, err)
+	_, err = scheme.Split(nil, crand.Reader)
+	require.NotNil(t, err)
+}
+
+func TestShamirSplitCombine(t *testing.T) {
+	scheme, err := NewShamir(3, 5)
+	require.Nil(t, err)
+	for _, l := range []int{1, 16, 32, 33, 1000} {
+		secret := make([]byte, l)
+		_, _ = crand.Read(secret)
+		shares, err := scheme.Split(secret, crand.Reader)
+		require.Nil(t, err)
+		require.Len(t, shares, 5)
+
+		result, err := scheme.Combine(shares[:3]...)
+		require.Nil(t, err)
+		require.Equal(t, secret, result)
+
+		result, err = scheme.Combine(shares[4], shares[1], shares[2])
+		require.Nil(t, err)
+		require.Equal(t, secret, result)
+
+		result, err = scheme.Combine(shares...)
+		require.Nil(t, err)
+		require.Equal(t, secret, result)
+
+		_, err = scheme.Combine(shares[:2]...)
+		require.NotNil(t, err)
+	}
+}
+
+func TestShamirCombineBadShares(t *testing.T) {
+	scheme, err := NewShamir(2, 3)
+	require.Nil(t, err)
+	secret := []byte("correct horse battery staple")
+	shares, err := scheme.Split(secret, crand.Reader)
+	require.Nil(t, err)
+
+	_, err = scheme.Combine(shares[0], shares[0])
+	require.NotNil(t, err)
+
+	other, err := scheme.Split(secret, crand.Reader)
+	require.Nil(t, err)
+	_, err = scheme.Combine(shares[0], other[1])
+	require.NotNil(t, err)
+
+	_, err = scheme.Combine(shares[0], &Share{Id: 4, Value: shares[1].Value})
+	require.NotNil(t, err)
+
+	_, err = scheme.Combine(shares[0], &Share{Id: 2, Value: shares[1].Value[1:]})
+	require.NotNil(t, err)
+}
+
+func TestShareMarshalBinary(t *testing.T) {
+	scheme, err := NewShamir(2, 3)
+	require.Nil(t, err)
+	shares, err := scheme.Split([]byte("seed"), crand.Reader)
+	require.Nil(t, err)
+	for _, s := range shares {
+		data, err := s.MarshalBinary()
+		require.Nil(t, err)
+		share := new(Share)
+		require.Nil(t, share.UnmarshalBinary(data))
+		require.Equal(t, s, share)
+
+		data[1] ^= 1
+		require.NotNil(t, new(Share).UnmarshalBinary(data))
+	}
+	require.NotNil(t, new(Share).UnmarshalBinary([]byte{1, 2, 3}))
+}
@@ -0,0 +1,495 @@
+//
+// Copyright Coinbase, Inc. All Rights Reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package gf256
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"strings"
+
+	"golang.org/x/crypto/pbkdf2"
+)
+
+const (
+	slip39RadixBits          = 10
+	slip39IdExpLengthWords   = 2
+	slip39ChecksumWords      = 3
+	slip39MetadataWords      = slip39IdExpLengthWords + 2 + slip39ChecksumWords
+	slip39MinSecretBytes     = 16
+	slip39MaxShareCount      = 16
+	slip39MaxIterationExp    = 15
+	slip39BaseIterationCount = 10000
+	slip39RoundCount         = 4
+	slip39DigestLength       = 4
+	slip39DigestIndex        = 254
+	slip39SecretIndex        = 255
+	slip39Customization      = "shamir"
+	slip39CustomizationExt   = "shamir_extendable"
+)
+
+var slip39Words = func() map[string]uint16 {
+	words := make(map[string]uint16, len(slip39Wordlist))
+	for i, w := range slip39Wordlist {
+		words[w] = uint16(i)
+	}
+	return words
+}()
+
+// Slip39Group is the member threshold and member count of one SLIP-39 group
+type Slip39Group struct {
+	Threshold, Count byte
+}
+
+// Slip39Share is a single member share in the SLIP-39 format.
+// Thresholds and counts are stored as their actual values,
+// not the value minus one that appears in the encoding.
+type Slip39Share struct {
+	Identifier        uint16
+	Extendable        bool
+	IterationExponent byte
+	GroupIndex        byte
+	GroupThreshold    byte
+	GroupCount        byte
+	MemberIndex       byte
+	MemberThreshold   byte
+	Value             []byte
+}
+
+// SplitSlip39 encrypts the master secret with the passphrase and splits it into
+// groups of member shares. Any groupThreshold groups, each with at least its
+// member threshold of shares, recover the master secret.
+// The result is indexed by group then by member.
+func SplitSlip39(groupThreshold byte, groups []Slip39Group, masterSecret, passphrase []byte, iterationExponent byte, reader io.Reader) ([][]*Slip39Share, error) {
+	if reader == nil {
+		return nil, fmt.Errorf("invalid reader")
+	}
+	if len(masterSecret) < slip39MinSecretBytes || len(masterSecret)%2 != 0 {
+		return nil, fmt.Errorf("master secret must be an even number of bytes and at least %d bytes", slip39MinSecretBytes)
+	}
+	if iterationExponent > slip39MaxIterationExp {
+		return nil, fmt.Errorf("iteration exponent cannot exceed %d", slip39MaxIterationExp)
+	}
+	if groupThreshold < 1 || int(groupThreshold) > len(groups) {
+		return nil, fmt.Errorf("invalid group threshold")
+	}
+	if len(groups) > slip39MaxShareCount {
+		return nil, fmt.Errorf("cannot exceed %d groups", slip39MaxShareCount)
+	}
+	for _, g := range groups {
+		if g.Threshold == 1 && g.Count > 1 {
+			return nil, fmt.Errorf("a member threshold of 1 requires a member count of 1")
+		}
+	}
+	if err := checkPassphrase(passphrase); err != nil {
+		return nil, err
+	}
+
+	var id [2]byte
+	if _, err := io.ReadFull(reader, id[:]); err != nil {
+		return nil, err
+	}
+	identifier := binary.BigEndian.Uint16(id[:]) & 0x7fff
+	ems := slip39Encrypt(masterSecret, passphrase, iterationExponent, identifier, false)
+
+	groupValues, err := slip39SplitSecret(groupThreshold, byte(len(groups)), ems, reader)
+	if err != nil {
+		return nil, err
+	}
+	result := make([][]*Slip39Share, len(groups))
+	for gi, g := range groups {
+		memberValues, err := slip39SplitSecret(g.Threshold, g.Count, groupValues[gi], reader)
+		if err != nil {
+			return nil, err
+		}
+		result[gi] = make([]*Slip39Share, g.Count)
+		for mi, v := range memberValues {
+			result[gi][mi] = &Slip39Share{
+				Identifier:        identifier,
+				IterationExponent: iterationExponent,
+				GroupIndex:        byte(gi),
+				GroupThreshold:    groupThreshold,
+				GroupCount:        byte(len(groups)),
+				MemberIndex:       byte(mi),
+				MemberThreshold:   g.Threshold,
+				Value:             v,
+			}
+		}
+	}
+	return result, nil
+}
+
+// CombineSlip39 recovers the master secret from SLIP-39 shares and the passphrase.
+// A wrong passphrase yields a different master secret and is not detected.
+func CombineSlip39(shares []*Slip39Share, passphrase []byte) ([]byte, error) {
+	if len(shares) == 0 {
+		return nil, fmt.Errorf("invalid number of shares")
+	}
+	if err := checkPassphrase(passphrase); err != nil {
+		return nil, err
+	}
+	first := shares[0]
+	groups := make(map[byte][]*Slip39Share)
+	for _, s := range shares {
+		if s == nil {
+			return nil, fmt.Errorf("invalid share")
+		}
+		if err := s.validate(); err != nil {
+			return nil, err
+		}
+		if s.Identifier != first.Identifier || s.Extendable != first.Extendable ||
+			s.IterationExponent != first.IterationExponent ||
+			s.GroupThreshold != first.GroupThreshold || s.GroupCount != first.GroupCount ||
+			len(s.Value) != len(first.Value) {
+			return nil, fmt.Errorf("shares do not belong to the same secret")
+		}
+		for _, o := range groups[s.GroupIndex] {
+			if o.MemberThreshold != s.MemberThreshold {
+				return nil, fmt.Errorf("member thresholds do not match")
+			}
+			if o.MemberIndex == s.MemberIndex {
+				return nil, fmt.Errorf("duplicate share")
+			}
+		}
+		groups[s.GroupIndex] = append(groups[s.GroupIndex], s)
+	}
+
+	xs := make([]byte, 0, len(groups))
+	ys := make([][]byte, 0, len(groups))
+	for gi, members := range groups {
+		if len(members) < int(members[0].MemberThreshold) {
+			continue
+		}
+		mxs := make([]byte, len(members))
+		mys := make([][]byte, len(members))
+		for i, m := range members {
+			mxs[i] = m.MemberIndex
+			mys[i] = m.Value
+		}
+		v, err := slip39RecoverSecret(members[0].MemberThreshold, mxs, mys)
+		if err != nil {
+			return nil, err
+		}
+		xs = append(xs, gi)
+		ys = append(ys, v)
+	}
+	if len(xs) < int(first.GroupThreshold) {
+		return nil, fmt.Errorf("insufficient number of groups")
+	}
+	ems, err := slip39RecoverSecret(first.GroupThreshold, xs, ys)
+	if err != nil {
+		return nil, err
+	}
+	return slip39Decrypt(ems, passphrase, first.IterationExponent, first.Identifier, first.Extendable), nil
+}
+
+func (s Slip39Share) validate() error {
+	if s.Identifier > 0x7fff {
+		return fmt.Errorf("invalid identifier")
+	}
+	if s.IterationExponent > slip39MaxIterationExp {
+		return fmt.Errorf("invalid iteration exponent")
+	}
+	if s.GroupCount < 1 || s.GroupCount > slip39MaxShareCount ||
+		s.GroupThreshold < 1 || s.GroupThreshold > s.GroupCount || s.GroupIndex >= s.GroupCount {
+		return fmt.Errorf("invalid group parameters")
+	}
+	if s.MemberThreshold < 1 || s.MemberThreshold > slip39MaxShareCount || s.MemberIndex >= slip39MaxShareCount {
+		return fmt.Errorf("invalid member parameters")
+	}
+	if len(s.Value) < slip39MinSecretBytes || len(s.Value)%2 != 0 {
+		return fmt.Errorf("invalid share value")
+	}
+	return nil
+}
+
+// Mnemonic encodes the share as a SLIP-39 mnemonic sentence
+func (s Slip39Share) Mnemonic() (string, error) {
+	if err := s.validate(); err != nil {
+		return "", err
+	}
+	ext := uint64(0)
+	if s.Extendable {
+		ext = 1
+	}
+	// id (15) || ext (1) || e (4) || gi (4) || gt-1 (4) || gc-1 (4) || mi (4) || mt-1 (4)
+	header := uint64(s.Identifier)<<25 | ext<<24 | uint64(s.IterationExponent)<<20 |
+		uint64(s.GroupIndex)<<16 | uint64(s.GroupThreshold-1)<<12 | uint64(s.GroupCount-1)<<8 |
+		uint64(s.MemberIndex)<<4 | uint64(s.MemberThreshold-1)
+	words := make([]uint16, 0, slip39MetadataWords+(8*len(s.Value)+slip39RadixBits-1)/slip39RadixBits)
+	for i := 3; i >= 0; i-- {
+		words = append(words, uint16(header>>(slip39RadixBits*i))&0x3ff)
+	}
+	words = append(words, bytesToWords(s.Value)...)
+	words = append(words, rs1024CreateChecksum(words, customization(s.Extendable))...)
+
+	out := make([]string, len(words))
+	for i, w := range words {
+		out[i] = slip39Wordlist[w]
+	}
+	return strings.Join(out, " "), nil
+}
+
+// ParseSlip39Mnemonic decodes and checks a SLIP-39 mnemonic sentence
+func ParseSlip39Mnemonic(mnemonic string) (*Slip39Share, error) {
+	fields := strings.Fields(strings.ToLower(mnemonic))
+	if len(fields) < slip39MetadataWords+(8*slip39MinSecretBytes+slip39RadixBits-1)/slip39RadixBits {
+		return nil, fmt.Errorf("invalid mnemonic length")
+	}
+	words := make([]uint16, len(fields))
+	for i, f := range fields {
+		w, ok := slip39Words[f]
+		if !ok {
+			return nil, fmt.Errorf("invalid mnemonic word %q", f)
+		}
+		words[i] = w
+	}
+	extendable := words[1]&(1<<4) != 0
+	if !rs1024VerifyChecksum(words, customization(extendable)) {
+		return nil, fmt.Errorf("invalid mnemonic checksum")
+	}
+	header := uint64(0)
+	for _, w := range words[:4] {
+		header = header<<slip39RadixBits | uint64(w)
+	}
+	value, err := wordsToBytes(words[4 : len(words)-slip39ChecksumWords])
+	if err != nil {
+		return nil, err
+	}
+	share := &Slip39Share{
+		Identifier:        uint16(header >> 25),
+		Extendable:        extendable,
+		IterationExponent: byte(header>>20) & 0xf,
+		GroupIndex:        byte(header>>16) & 0xf,
+		GroupThreshold:    byte(header>>12)&0xf + 1,
+		GroupCount:        byte(header>>8)&0xf + 1,
+		MemberIndex:       byte(header>>4) & 0xf,
+		MemberThreshold:   byte(header)&0xf + 1,
+		Value:             value,
+	}
+	if err = share.validate(); err != nil {
+		return nil, err
+	}
+	return share, nil
+}
+
+// bytesToWords packs data into 10 bit words with zero padding at the front
+func bytesToWords(data []byte) []uint16 {
+	count := (8*len(data) + slip39RadixBits - 1) / slip39RadixBits
+	words := make([]uint16, 0, count)
+	acc := uint32(0)
+	bits := count*slip39RadixBits - 8*len(data)
+	for _, b := range data {
+		acc = acc<<8 | uint32(b)
+		bits += 8
+		for bits >= slip39RadixBits {
+			bits -= slip39RadixBits
+			words = append(words, uint16(acc>>bits)&0x3ff)
+			acc &= 1<<bits - 1
+		}
+	}
+	return words
+}
+
+// wordsToBytes is the inverse of bytesToWords and checks the padding is zero
+func wordsToBytes(words []uint16) ([]byte, error) {
+	padding := (slip39RadixBits * len(words)) % 16
+	if padding > 8 {
+		return nil, fmt.Errorf("invalid mnemonic length")
+	}
+	if words[0]>>(slip39RadixBits-padding) != 0 {
+		return nil, fmt.Errorf("invalid mnemonic padding")
+	}
+	out := make([]byte, 0, (slip39RadixBits*len(words)-padding)/8)
+	acc := uint32(words[0]) & (1<<(slip39RadixBits-padding) - 1)
+	bits := slip39RadixBits - padding
+	for i := 1; ; i++ {
+		for bits >= 8 {
+			bits -= 8
+			out = append(out, byte(acc>>bits))
+			acc &= 1<<bits - 1
+		}
+		if i == len(words) {
+			break
+		}
+		acc = acc<<slip39RadixBits | uint32(words[i])
+		bits += slip39RadixBits
+	}
+	return out, nil
+}
+
+func customization(extendable bool) string {
+	if extendable {
+		return slip39CustomizationExt
+	}
+	return slip39Customization
+}
+
+func checkPassphrase(passphrase []byte) error {
+	for _, c := range passphrase {
+		if c < 32 || c > 126 {
+			return fmt.Errorf("passphrase must only contain printable ascii characters")
+		}
+	}
+	return nil
+}
+
+// slip39SplitSecret shares secret with the digest construction of SLIP-39
+// where the secret sits at x = 255 and its digest at x = 254
+func slip39SplitSecret(threshold, count byte, secret []byte, reader io.Reader) ([][]byte, error) {
+	if threshold < 1 || threshold > count || count > slip39MaxShareCount {
+		return nil, fmt.Errorf("invalid threshold or count")
+	}
+	values := make([][]byte, count)
+	if threshold == 1 {
+		for i := range values {
+			values[i] = make([]byte, len(secret))
+			copy(values[i], secret)
+		}
+		return values, nil
+	}
+
+	random := int(threshold) - 2
+	xs := make([]byte, 0, threshold)
+	ys := make([][]byte, 0, threshold)
+	for i := 0; i < random; i++ {
+		values[i] = make([]byte, len(secret))
+		if _, err := io.ReadFull(reader, values[i]); err != nil {
+			return nil, err
+		}
+		xs = append(xs, byte(i))
+		ys = append(ys, values[i])
+	}
+	digestShare := make([]byte, len(secret))
+	if _, err := io.ReadFull(reader, digestShare[slip39DigestLength:]); err != nil {
+		return nil, err
+	}
+	copy(digestShare, slip39Digest(digestShare[slip39DigestLength:], secret))
+	xs = append(xs, slip39DigestIndex, slip39SecretIndex)
+	ys = append(ys, digestShare, secret)
+
+	for i := random; i < int(count); i++ {
+		values[i] = interpolate(xs, ys, byte(i))
+	}
+	return values, nil
+}
+
+func slip39RecoverSecret(threshold byte, xs []byte, ys [][]byte) ([]byte, error) {
+	if len(xs) < int(threshold) {
+		return nil, fmt.Errorf("insufficient number of shares")
+	}
+	if threshold == 1 {
+		return ys[0], nil
+	}
+	secret := interpolate(xs, ys, slip39SecretIndex)
+	digestShare := interpolate(xs, ys, slip39DigestIndex)
+	digest := slip39Digest(digestShare[slip39DigestLength:], secret)
+	if subtle.ConstantTimeCompare(digest, digestShare[:slip39DigestLength]) != 1 {
+		return nil, fmt.Errorf("invalid secret digest")
+	}
+	return secret, nil
+}
+
+func slip39Digest(random, secret []byte) []byte {
+	mac := hmac.New(sha256.New, random)
+	_, _ = mac.Write(secret)
+	return mac.Sum(nil)[:slip39DigestLength]
+}
+
+// slip39Encrypt is the four round Feistel cipher that turns the master secret
+// into the encrypted master secret that is actually shared
+func slip39Encrypt(masterSecret, passphrase []byte, e byte, identifier uint16, extendable bool) []byte {
+	half := len(masterSecret) / 2
+	l := append([]byte{}, masterSecret[:half]...)
+	r := append([]byte{}, masterSecret[half:]...)
+	salt := slip39Salt(identifier, extendable)
+	for i := byte(0); i < slip39RoundCount; i++ {
+		f := slip39Round(i, passphrase, e, salt, r)
+		l, r = r, xorBytes(l, f)
+	}
+	return append(r, l...)
+}
+
+func slip39Decrypt(ems, passphrase []byte, e byte, identifier uint16, extendable bool) []byte {
+	half := len(ems) / 2
+	l := append([]byte{}, ems[:half]...)
+	r := append([]byte{}, ems[half:]...)
+	salt := slip39Salt(identifier, extendable)
+	for i := slip39RoundCount - 1; i >= 0; i-- {
+		f := slip39Round(byte(i), passphrase, e, salt, r)
+		l, r = r, xorBytes(l, f)
+	}
+	return append(r, l...)
+}
+
+func slip39Salt(identifier uint16, extendable bool) []byte {
+	if extendable {
+		return nil
+	}
+	salt := []byte(slip39Customization)
+	return binary.BigEndian.AppendUint16(salt, identifier)
+}
+
+func slip39Round(i byte, passphrase []byte, e byte, salt, r []byte) []byte {
+	password := append([]byte{i}, passphrase...)
+	s := make([]byte, 0, len(salt)+len(r))
+	s = append(append(s, salt...), r...)
+	iterations := (slip39BaseIterationCount << e) / slip39RoundCount
+	return pbkdf2.Key(password, s, iterations, len(r), sha256.New)
+}
+
+func xorBytes(a, b []byte) []byte {
+	out := make([]byte, len(a))
+	for i := range a {
+		out[i] = a[i] ^ b[i]
+	}
+	return out
+}
+
+var rs1024Generator = [10]uint32{
+	0xe0e040, 0x1c1c080, 0x3838100, 0x7070200, 0xe0e0009,
+	0x1c0c2412, 0x38086c24, 0x3090fc48, 0x21b1f890, 0x3f3f120,
+}
+
+func rs1024Polymod(values []uint16) uint32 {
+	chk := uint32(1)
+	for _, v := range values {
+		b := chk >> 20
+		chk = (chk&0xfffff)<<10 ^ uint32(v)
+		for i, g := range rs1024Generator {
+			if (b>>i)&1 == 1 {
+				chk ^= g
+			}
+		}
+	}
+	return chk
+}
+
+func rs1024Values(custom string, data []uint16) []uint16 {
+	values := make([]uint16, 0, len(custom)+len(data)+slip39ChecksumWords)
+	for _, c := range []byte(custom) {
+		values = append(values, uint16(c))
+	}
+	return append(values, data...)
+}
+
+func rs1024CreateChecksum(data []uint16, custom string) []uint16 {
+	values := append(rs1024Values(custom, data), make([]uint16, slip39ChecksumWords)...)
+	polymod := rs1024Polymod(values) ^ 1
+	out := make([]uint16, slip39ChecksumWords)
+	for i := range out {
+		out[i] = uint16(polymod>>(slip39RadixBits*(slip39ChecksumWords-1-i))) & 0x3ff
+	}
+	return out
+}
+
+func rs1024VerifyChecksum(data []uint16, custom string) bool {
+	return rs1024Polymod(rs1024Values(custom, data)) == 1
+}
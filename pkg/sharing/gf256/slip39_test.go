@@ -0,0 +1,137 @@
+//
+// Copyright Coinbase, Inc. All Rights Reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package gf256
+
+import (
+	crand "crypto/rand"
+	"encoding/hex"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSlip39Vectors(t *testing.T) {
+	// from https://github.com/trezor/python-shamir-mnemonic/blob/master/vectors.json
+	tests := []struct {
+		mnemonics []string
+		secret    string
+	}{
+		{
+			mnemonics: []string{
+				"duckling enlarge academic academic agency result length solution fridge kidney coal piece deal husband erode duke ajar critical decision keyboard",
+			},
+			secret: "bb54aac4b89dc868ba37d9cc21b2cece",
+		},
+		{
+			mnemonics: []string{
+				"shadow pistol academic always adequate wildlife fancy gross oasis cylinder mustang wrist rescue view short owner flip making coding armed",
+				"shadow pistol academic acid actress prayer class unknown daughter sweater depict flip twice unkind craft early superior advocate guest smoking",
+			},
+			secret: "b43ceb7e57a0ea8766221624d01b0864",
+		},
+	}
+	for _, test := range tests {
+		shares := make([]*Slip39Share, len(test.mnemonics))
+		for i, m := range test.mnemonics {
+			share, err := ParseSlip39Mnemonic(m)
+			require.Nil(t, err)
+			mnemonic, err := share.Mnemonic()
+			require.Nil(t, err)
+			require.Equal(t, m, mnemonic)
+			shares[i] = share
+		}
+		secret, err := CombineSlip39(shares, []byte("TREZOR"))
+		require.Nil(t, err)
+		require.Equal(t, test.secret, hex.EncodeToString(secret))
+	}
+}
+
+func TestSlip39InvalidMnemonic(t *testing.T) {
+	valid := "duckling enlarge academic academic agency result length solution fridge kidney coal piece deal husband erode duke ajar critical decision keyboard"
+	// wrong checksum
+	_, err := ParseSlip39Mnemonic("duckling enlarge academic academic agency result length solution fridge kidney coal piece deal husband erode duke ajar critical decision kidney")
+	require.NotNil(t, err)
+	// unknown word
+	_, err = ParseSlip39Mnemonic(valid[:len(valid)-len("keyboard")] + "keyboards")
+	require.NotNil(t, err)
+	// too short
+	_, err = ParseSlip39Mnemonic("duckling enlarge academic")
+	require.NotNil(t, err)
+}
+
+func TestSlip39SplitCombine(t *testing.T) {
+	secret := make([]byte, 32)
+	_, _ = crand.Read(secret)
+	passphrase := []byte("passphrase")
+	groups := []Slip39Group{{1, 1}, {2, 3}, {3, 5}}
+	shares, err := SplitSlip39(2, groups, secret, passphrase, 0, crand.Reader)
+	require.Nil(t, err)
+	require.Len(t, shares, 3)
+
+	// round trip every share through its mnemonic
+	for gi, g := range shares {
+		require.Len(t, g, int(groups[gi].Count))
+		for mi, s := range g {
+			m, err := s.Mnemonic()
+			require.Nil(t, err)
+			parsed, err := ParseSlip39Mnemonic(m)
+			require.Nil(t, err)
+			require.Equal(t, s, parsed)
+			shares[gi][mi] = parsed
+		}
+	}
+
+	result, err := CombineSlip39([]*Slip39Share{shares[0][0], shares[1][2], shares[1][0]}, passphrase)
+	require.Nil(t, err)
+	require.Equal(t, secret, result)
+
+	result, err = CombineSlip39([]*Slip39Share{shares[2][4], shares[1][1], shares[2][0], shares[1][2], shares[2][2]}, passphrase)
+	require.Nil(t, err)
+	require.Equal(t, secret, result)
+
+	// the passphrase is not authenticated
+	result, err = CombineSlip39([]*Slip39Share{shares[0][0], shares[1][2], shares[1][0]}, []byte("wrong"))
+	require.Nil(t, err)
+	require.NotEqual(t, secret, result)
+
+	// only one complete group
+	_, err = CombineSlip39([]*Slip39Share{shares[0][0], shares[1][2], shares[2][0], shares[2][1]}, passphrase)
+	require.NotNil(t, err)
+
+	// tampered share
+	bad := *shares[1][0]
+	bad.Value = append([]byte{}, bad.Value...)
+	bad.Value[0] ^= 1
+	_, err = CombineSlip39([]*Slip39Share{shares[0][0], shares[1][2], &bad}, passphrase)
+	require.NotNil(t, err)
+}
+
+func TestSlip39SplitInvalidArgs(t *testing.T) {
+	secret := make([]byte, 16)
+	_, err := SplitSlip39(1, []Slip39Group{{2, 3}}, secret[:15], nil, 0, crand.Reader)
+	require.NotNil(t, err)
+	_, err = SplitSlip39(2, []Slip39Group{{2, 3}}, secret, nil, 0, crand.Reader)
+	require.NotNil(t, err)
+	_, err = SplitSlip39(1, []Slip39Group{{1, 3}}, secret, nil, 0, crand.Reader)
+	require.NotNil(t, err)
+	_, err = SplitSlip39(1, []Slip39Group{{4, 3}}, secret, nil, 0, crand.Reader)
+	require.NotNil(t, err)
+	_, err = SplitSlip39(1, []Slip39Group{{2, 3}}, secret, []byte("\x01"), 0, crand.Reader)
+	require.NotNil(t, err)
+	_, err = SplitSlip39(1, []Slip39Group{{2, 3}}, secret, nil, 16, crand.Reader)
+	require.NotNil(t, err)
+}
+
+func TestBytesToWords(t *testing.T) {
+	for _, l := range []int{16, 18, 32, 64} {
+		data := make([]byte, l)
+		_, _ = crand.Read(data)
+		result, err := wordsToBytes(bytesToWords(data))
+		require.Nil(t, err)
+		require.Equal(t, data, result)
+	}
+}
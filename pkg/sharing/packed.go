@@ -0,0 +1,182 @@
+//
+// Copyright Coinbase, Inc. All Rights Reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package sharing
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/nerifnetwork/kryptology/pkg/core/curves"
+	"github.com/nerifnetwork/kryptology/pkg/core/polynomial"
+)
+
+// PackedShamir is the Franklin-Yung packed secret sharing scheme.
+// Several secrets are embedded into a single polynomial at the reserved
+// evaluation points 0, -1, ..., -(packing-1) and shares are the evaluations
+// at 1, ..., limit. Any threshold shares reconstruct all the secrets while
+// any threshold-packing shares reveal nothing about them.
+//
+// See https://dl.acm.org/doi/10.1145/129712.129780
+type PackedShamir struct {
+	threshold, packing, limit uint32
+	curve                     *curves.Curve
+}
+
+// NewPackedShamir creates a packed VSS that shares `packing` secrets with
+// a reconstruction threshold of `threshold` out of `limit` shares.
+func NewPackedShamir(threshold, packing, limit uint32, curve *curves.Curve) (*PackedShamir, error) {
+	if limit < threshold {
+		return nil, fmt.Errorf("limit cannot be less than threshold")
+	}
+	if packing < 1 {
+		return nil, fmt.Errorf("packing cannot be less than 1")
+	}
+	if threshold <= packing {
+		return nil, fmt.Errorf("threshold must be greater than packing")
+	}
+	if limit > 255 {
+		return nil, fmt.Errorf("cannot exceed 255 shares")
+	}
+	if curve == nil {
+		return nil, fmt.Errorf("invalid curve")
+	}
+	return &PackedShamir{threshold, packing, limit, curve}, nil
+}
+
+// SecretPoints returns the reserved evaluation points that hold the secrets
+func (ps PackedShamir) SecretPoints() []curves.Scalar {
+	xs := make([]curves.Scalar, ps.packing)
+	for i := range xs {
+		xs[i] = ps.curve.Scalar.New(i).Neg()
+	}
+	return xs
+}
+
+// Split embeds the secrets into a random polynomial of degree threshold-1
+// and returns the shares along with the Feldman commitments to the polynomial.
+func (ps PackedShamir) Split(secrets []curves.Scalar, reader io.Reader) (*FeldmanVerifier, []*ShamirShare, error) {
+	if len(secrets) != int(ps.packing) {
+		return nil, nil, fmt.Errorf("expected %d secrets, got %d", ps.packing, len(secrets))
+	}
+	// The polynomial is fixed by the secrets at the reserved points
+	// and by random values at the next threshold-packing points.
+	xs := ps.SecretPoints()
+	ys := make([]curves.Scalar, len(xs), ps.threshold)
+	for i, s := range secrets {
+		if s == nil {
+			return nil, nil, fmt.Errorf("invalid secret")
+		}
+		ys[i] = s.Clone()
+	}
+	for i := ps.packing; i < ps.threshold; i++ {
+		xs = append(xs, ps.curve.Scalar.New(int(i)).Neg())
+		ys = append(ys, ps.curve.Scalar.Random(reader))
+	}
+	coefficients, err := polynomial.Interpolate(xs, ys)
+	if err != nil {
+		return nil, nil, err
+	}
+	poly := &Polynomial{Coefficients: coefficients}
+
+	shares := make([]*ShamirShare, ps.limit)
+	for i := range shares {
+		x := ps.curve.Scalar.New(i + 1)
+		shares[i] = &ShamirShare{
+			Id:    uint32(i + 1),
+			Value: poly.Evaluate(x).Bytes(),
+		}
+	}
+	verifier := new(FeldmanVerifier)
+	verifier.Commitments = make([]curves.Point, ps.threshold)
+	for i := range verifier.Commitments {
+		verifier.Commitments[i] = ps.curve.ScalarBaseMult(poly.Coefficients[i])
+	}
+	return verifier, shares, nil
+}
+
+// SecretCommitments computes the commitments g^s_i to each packed secret
+// from the Feldman commitments returned by Split.
+func (ps PackedShamir) SecretCommitments(verifier *FeldmanVerifier) ([]curves.Point, error) {
+	if verifier == nil || len(verifier.Commitments) != int(ps.threshold) {
+		return nil, fmt.Errorf("invalid verifier")
+	}
+	xs := ps.SecretPoints()
+	result := make([]curves.Point, len(xs))
+	for k, x := range xs {
+		i := ps.curve.Scalar.One()
+		c := verifier.Commitments[0]
+		for j := 1; j < len(verifier.Commitments); j++ {
+			i = i.Mul(x)
+			c = c.Add(verifier.Commitments[j].Mul(i))
+		}
+		result[k] = c
+	}
+	return result, nil
+}
+
+// Combine reconstructs all the packed secrets from at least threshold shares
+func (ps PackedShamir) Combine(shares ...*ShamirShare) ([]curves.Scalar, error) {
+	xs, ys, err := ps.parseShares(shares)
+	if err != nil {
+		return nil, err
+	}
+	secrets := make([]curves.Scalar, ps.packing)
+	for i, x := range ps.SecretPoints() {
+		secrets[i], err = lagrangeEvaluate(xs, ys, x)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return secrets, nil
+}
+
+func (ps PackedShamir) parseShares(shares []*ShamirShare) ([]curves.Scalar, []curves.Scalar, error) {
+	if len(shares) < int(ps.threshold) {
+		return nil, nil, fmt.Errorf("invalid number of shares")
+	}
+	dups := make(map[uint32]bool, len(shares))
+	xs := make([]curves.Scalar, len(shares))
+	ys := make([]curves.Scalar, len(shares))
+
+	for i, share := range shares {
+		err := share.Validate(ps.curve)
+		if err != nil {
+			return nil, nil, err
+		}
+		if share.Id > ps.limit {
+			return nil, nil, fmt.Errorf("invalid share identifier")
+		}
+		if _, in := dups[share.Id]; in {
+			return nil, nil, fmt.Errorf("duplicate share")
+		}
+		dups[share.Id] = true
+		ys[i], _ = ps.curve.Scalar.SetBytes(share.Value)
+		xs[i] = ps.curve.Scalar.New(int(share.Id))
+	}
+	return xs, ys, nil
+}
+
+// lagrangeEvaluate computes f(x) for the polynomial f through the points (xs, ys)
+func lagrangeEvaluate(xs, ys []curves.Scalar, x curves.Scalar) (curves.Scalar, error) {
+	result := x.Zero()
+	for i, xi := range xs {
+		num := x.One()
+		den := x.One()
+		for j, xj := range xs {
+			if i == j {
+				continue
+			}
+			num = num.Mul(x.Sub(xj))
+			den = den.Mul(xi.Sub(xj))
+		}
+		if den.IsZero() {
+			return nil, fmt.Errorf("divide by zero")
+		}
+		result = result.Add(ys[i].Mul(num.Div(den)))
+	}
+	return result, nil
+}
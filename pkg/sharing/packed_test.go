@@ -0,0 +1,105 @@
+//
+// Copyright Coinbase, Inc. All Rights Reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package sharing
+
+import (
+	crand "crypto/rand"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/nerifnetwork/kryptology/pkg/core/curves"
+)
+
+func packedTestSecrets(curve *curves.Curve, n int) []curves.Scalar {
+	secrets := make([]curves.Scalar, n)
+	for i := range secrets {
+		secrets[i] = curve.Scalar.Random(crand.Reader)
+	}
+	return secrets
+}
+
+func TestPackedShamirInvalidArgs(t *testing.T) {
+	curve := curves.K256()
+	_, err := NewPackedShamir(3, 1, 2, curve)
+	require.NotNil(t, err)
+	_, err = NewPackedShamir(3, 0, 5, curve)
+	require.NotNil(t, err)
+	_, err = NewPackedShamir(3, 3, 5, curve)
+	require.NotNil(t, err)
+	_, err = NewPackedShamir(3, 2, 256, curve)
+	require.NotNil(t, err)
+	_, err = NewPackedShamir(3, 2, 5, nil)
+	require.NotNil(t, err)
+	scheme, err := NewPackedShamir(3, 2, 5, curve)
+	require.Nil(t, err)
+	_, _, err = scheme.Split(packedTestSecrets(curve, 3), crand.Reader)
+	require.NotNil(t, err)
+}
+
+func TestPackedShamirSplitCombine(t *testing.T) {
+	for _, curve := range []*curves.Curve{curves.K256(), curves.ED25519(), curves.BLS12381G1()} {
+		scheme, err := NewPackedShamir(6, 4, 10, curve)
+		require.Nil(t, err)
+		secrets := packedTestSecrets(curve, 4)
+		verifier, shares, err := scheme.Split(secrets, crand.Reader)
+		require.Nil(t, err)
+		require.Len(t, shares, 10)
+		require.Len(t, verifier.Commitments, 6)
+
+		for _, s := range shares {
+			require.Nil(t, verifier.Verify(s))
+		}
+
+		result, err := scheme.Combine(shares[:6]...)
+		require.Nil(t, err)
+		require.Equal(t, secrets, result)
+
+		result, err = scheme.Combine(shares[2], shares[9], shares[4], shares[7], shares[1], shares[5])
+		require.Nil(t, err)
+		require.Equal(t, secrets, result)
+
+		result, err = scheme.Combine(shares...)
+		require.Nil(t, err)
+		require.Equal(t, secrets, result)
+
+		_, err = scheme.Combine(shares[:5]...)
+		require.NotNil(t, err)
+
+		commitments, err := scheme.SecretCommitments(verifier)
+		require.Nil(t, err)
+		for i, c := range commitments {
+			require.True(t, c.Equal(curve.ScalarBaseMult(secrets[i])))
+		}
+	}
+}
+
+func TestPackedShamirTamperedShare(t *testing.T) {
+	curve := curves.P256()
+	scheme, err := NewPackedShamir(3, 2, 5, curve)
+	require.Nil(t, err)
+	secrets := packedTestSecrets(curve, 2)
+	verifier, shares, err := scheme.Split(secrets, crand.Reader)
+	require.Nil(t, err)
+
+	shares[1].Value = curve.Scalar.Random(crand.Reader).Bytes()
+	require.NotNil(t, verifier.Verify(shares[1]))
+
+	result, err := scheme.Combine(shares[:3]...)
+	require.Nil(t, err)
+	require.NotEqual(t, secrets, result)
+}
+
+func TestPackedShamirCombineDuplicateShare(t *testing.T) {
+	curve := curves.ED25519()
+	scheme, err := NewPackedShamir(3, 2, 5, curve)
+	require.Nil(t, err)
+	_, shares, err := scheme.Split(packedTestSecrets(curve, 2), crand.Reader)
+	require.Nil(t, err)
+	_, err = scheme.Combine(shares[0], shares[1], shares[1])
+	require.NotNil(t, err)
+}
@@ -56,6 +56,51 @@ func (pv PedersenVerifier) Verify(share, blindShare *ShamirShare) error {
 	}
 }
 
+// VerifyShares checks many shares with their blinding shares at once like
+// FeldmanVerifier.VerifyShares. blindShares[i] is the blinding share of shares[i].
+func (pv PedersenVerifier) VerifyShares(shares, blindShares []*ShamirShare) error {
+	if len(pv.Commitments) == 0 || len(shares) == 0 || len(shares) != len(blindShares) {
+		return fmt.Errorf("invalid arguments")
+	}
+	if len(shares) < len(pv.Commitments) {
+		for i := range shares {
+			if err := pv.Verify(shares[i], blindShares[i]); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	for i := range shares {
+		if shares[i] == nil || blindShares[i] == nil || shares[i].Id != blindShares[i].Id {
+			return fmt.Errorf("invalid share")
+		}
+	}
+	curve := curves.GetCurveByName(pv.Generator.CurveName())
+	coefficients, err := interpolateShares(curve, shares)
+	if err != nil {
+		return err
+	}
+	blindCoefficients, err := interpolateShares(curve, blindShares)
+	if err != nil {
+		return err
+	}
+	for k, c := range coefficients {
+		// The coefficients above the degree of the commitments vanish
+		if k >= len(pv.Commitments) {
+			if !c.IsZero() || !blindCoefficients[k].IsZero() {
+				return fmt.Errorf("not equal")
+			}
+			continue
+		}
+		g := pv.Commitments[0].Generator().Mul(c)
+		h := pv.Generator.Mul(blindCoefficients[k])
+		if !g.Add(h).Equal(pv.Commitments[k]) {
+			return fmt.Errorf("not equal")
+		}
+	}
+	return nil
+}
+
 // PedersenResult contains all the data from calling Split
 type PedersenResult struct {
 	Blinding                     curves.Scalar
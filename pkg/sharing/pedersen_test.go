@@ -0,0 +1,33 @@
+//
+// Copyright Coinbase, Inc. All Rights Reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package sharing
+
+import (
+	crand "crypto/rand"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/nerifnetwork/kryptology/pkg/core/curves"
+)
+
+func TestPedersenVerifyShares(t *testing.T) {
+	curve := curves.BLS12381G1()
+	scheme, err := NewPedersen(3, 5, curve.Point.Hash([]byte("pedersen test generator")))
+	require.NoError(t, err)
+	result, err := scheme.Split(curve.Scalar.Random(crand.Reader), crand.Reader)
+	require.NoError(t, err)
+	require.NoError(t, result.PedersenVerifier.VerifyShares(result.SecretShares, result.BlindingShares))
+	require.NoError(t, result.PedersenVerifier.VerifyShares(result.SecretShares[:2], result.BlindingShares[:2]))
+	require.Error(t, result.PedersenVerifier.VerifyShares(result.SecretShares, result.BlindingShares[:4]))
+
+	result.BlindingShares[2], result.BlindingShares[3] = result.BlindingShares[3], result.BlindingShares[2]
+	require.Error(t, result.PedersenVerifier.VerifyShares(result.SecretShares, result.BlindingShares))
+	result.BlindingShares[2], result.BlindingShares[3] = result.BlindingShares[3], result.BlindingShares[2]
+	result.SecretShares[4] = result.SecretShares[3]
+	require.Error(t, result.PedersenVerifier.VerifyShares(result.SecretShares, result.BlindingShares))
+}
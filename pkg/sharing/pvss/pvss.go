@@ -0,0 +1,337 @@
+//
+// Copyright Coinbase, Inc. All Rights Reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+// Package pvss is an implementation of publicly verifiable secret sharing
+// in the style of Schoenmakers and SCRAPE. The dealer splits a secret s with
+// Feldman VSS and encrypts every share s_i to its recipient with El-Gamal in
+// the exponent of an independent generator H, C_i = (r_i * G, s_i * H + r_i * Y_i).
+// It publishes a single proof that each ciphertext encrypts the share committed
+// to by the Feldman verifier. Anyone can check the proof, not just the recipients.
+//
+// Recipients decrypt S_i = s_i * H from exactly the proven ciphertext, so a
+// dealing that passes Verify can always be decrypted. Decrypted shares carry a
+// proof of correct decryption and combine to the shared secret s * H.
+//
+// - https://www.win.tue.nl/~berry/papers/crypto99.pdf
+// - https://eprint.iacr.org/2017/216.pdf
+package pvss
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/nerifnetwork/kryptology/internal"
+	"github.com/nerifnetwork/kryptology/pkg/core/curves"
+	"github.com/nerifnetwork/kryptology/pkg/sharing"
+	"github.com/nerifnetwork/kryptology/pkg/verenc/elgamal"
+)
+
+// Pvss deals and verifies threshold of limit publicly verifiable sharings
+type Pvss struct {
+	threshold, limit uint32
+	curve            *curves.Curve
+}
+
+// Transcript is the public output of the dealer
+type Transcript struct {
+	Verifier *sharing.FeldmanVerifier
+	// Ciphertexts is the encrypted share of each recipient keyed by share identifier
+	Ciphertexts map[uint32]*elgamal.HomomorphicCipherText
+	Proof       *Proof
+}
+
+// Proof shows that every ciphertext C_i = (r_i * G, s_i * H + r_i * Y_i)
+// encrypts the share s_i committed in the Feldman verifier as X_i = s_i * G.
+// It is a batched proof of knowledge of r_i and s_i with one challenge for all recipients.
+type Proof struct {
+	Challenge curves.Scalar
+	// BlindingResponses are the responses for r_i and ShareResponses for s_i
+	BlindingResponses map[uint32]curves.Scalar
+	ShareResponses    map[uint32]curves.Scalar
+}
+
+// DecryptedShare is the share S_i = s_i * H of recipient Id with a proof of
+// correct decryption. It can be published and checked with VerifyShare.
+type DecryptedShare struct {
+	Id    uint32
+	Value curves.Point
+	Proof *elgamal.ProofDecrypt
+}
+
+// NewPvss creates a new PVSS scheme
+func NewPvss(threshold, limit uint32, curve *curves.Curve) (*Pvss, error) {
+	// use the same checks as Feldman
+	if _, err := sharing.NewFeldman(threshold, limit, curve); err != nil {
+		return nil, err
+	}
+	return &Pvss{threshold, limit, curve}, nil
+}
+
+// SecretGenerator returns H, the generator of the shared secret s * H. Nobody
+// knows its discrete log with respect to G.
+func (p Pvss) SecretGenerator() curves.Point {
+	return p.curve.Point.Hash([]byte("kryptology pvss secret generator"))
+}
+
+// Deal splits the secret and encrypts share i to keys[i].
+// There must be exactly one key for each identifier 1, ..., limit.
+// The session id binds the proof to a context and must be unique per dealing.
+func (p Pvss) Deal(secret curves.Scalar, keys map[uint32]*elgamal.EncryptionKey, sessionId []byte, reader io.Reader) (*Transcript, error) {
+	if secret == nil || reader == nil {
+		return nil, internal.ErrNilArguments
+	}
+	if err := p.checkKeys(keys); err != nil {
+		return nil, err
+	}
+	feldman, err := sharing.NewFeldman(p.threshold, p.limit, p.curve)
+	if err != nil {
+		return nil, err
+	}
+	verifier, shares, err := feldman.Split(secret, reader)
+	if err != nil {
+		return nil, err
+	}
+
+	h := p.SecretGenerator()
+	ciphertexts := make(map[uint32]*elgamal.HomomorphicCipherText, len(shares))
+	blindings := make(map[uint32]curves.Scalar, len(shares))
+	values := make(map[uint32]curves.Scalar, len(shares))
+	for _, share := range shares {
+		value, err := p.curve.Scalar.SetBytes(share.Value)
+		if err != nil {
+			return nil, err
+		}
+		r := p.randomNonZero(reader)
+		ciphertexts[share.Id] = &elgamal.HomomorphicCipherText{
+			C1: p.curve.ScalarBaseMult(r),
+			C2: h.Mul(value).Add(keys[share.Id].Value.Mul(r)),
+		}
+		blindings[share.Id] = r
+		values[share.Id] = value
+	}
+
+	// Prove C1_i = r_i * G, X_i = s_i * G and C2_i = s_i * H + r_i * Y_i for all i
+	blindingNonces := make(map[uint32]curves.Scalar, len(shares))
+	shareNonces := make(map[uint32]curves.Scalar, len(shares))
+	commitments := make(map[uint32][3]curves.Point, len(shares))
+	for id := range ciphertexts {
+		w := p.curve.Scalar.Random(reader)
+		v := p.curve.Scalar.Random(reader)
+		blindingNonces[id] = w
+		shareNonces[id] = v
+		commitments[id] = [3]curves.Point{
+			p.curve.ScalarBaseMult(w),
+			p.curve.ScalarBaseMult(v),
+			h.Mul(v).Add(keys[id].Value.Mul(w)),
+		}
+	}
+	challenge := p.challenge(sessionId, verifier, keys, ciphertexts, commitments)
+	proof := &Proof{
+		Challenge:         challenge,
+		BlindingResponses: make(map[uint32]curves.Scalar, len(shares)),
+		ShareResponses:    make(map[uint32]curves.Scalar, len(shares)),
+	}
+	for id := range ciphertexts {
+		proof.BlindingResponses[id] = blindingNonces[id].Sub(challenge.Mul(blindings[id]))
+		proof.ShareResponses[id] = shareNonces[id].Sub(challenge.Mul(values[id]))
+	}
+	return &Transcript{
+		Verifier:    verifier,
+		Ciphertexts: ciphertexts,
+		Proof:       proof,
+	}, nil
+}
+
+// Verify checks that the transcript is a correct sharing of a single secret
+// to the given keys. Anyone who knows the recipient encryption keys can run it.
+func (p Pvss) Verify(transcript *Transcript, keys map[uint32]*elgamal.EncryptionKey, sessionId []byte) error {
+	if transcript == nil || transcript.Verifier == nil || transcript.Proof == nil ||
+		transcript.Proof.Challenge == nil {
+		return internal.ErrNilArguments
+	}
+	if err := p.checkKeys(keys); err != nil {
+		return err
+	}
+	if len(transcript.Verifier.Commitments) != int(p.threshold) {
+		return fmt.Errorf("invalid number of commitments")
+	}
+	for _, c := range transcript.Verifier.Commitments {
+		if c == nil || !c.IsOnCurve() || c.CurveName() != p.curve.Name {
+			return fmt.Errorf("invalid commitment")
+		}
+	}
+	if len(transcript.Ciphertexts) != int(p.limit) || len(transcript.Proof.BlindingResponses) != int(p.limit) ||
+		len(transcript.Proof.ShareResponses) != int(p.limit) {
+		return internal.ErrIncorrectCount
+	}
+
+	h := p.SecretGenerator()
+	commitments := make(map[uint32][3]curves.Point, p.limit)
+	c := transcript.Proof.Challenge
+	for id := uint32(1); id <= p.limit; id++ {
+		ct, ok := transcript.Ciphertexts[id]
+		if !ok || ct == nil || ct.C1 == nil || ct.C2 == nil {
+			return fmt.Errorf("missing ciphertext for %d", id)
+		}
+		if ct.C1.CurveName() != p.curve.Name || ct.C2.CurveName() != p.curve.Name {
+			return fmt.Errorf("invalid ciphertext for %d", id)
+		}
+		z, ok := transcript.Proof.BlindingResponses[id]
+		if !ok || z == nil {
+			return fmt.Errorf("missing response for %d", id)
+		}
+		u, ok := transcript.Proof.ShareResponses[id]
+		if !ok || u == nil {
+			return fmt.Errorf("missing response for %d", id)
+		}
+		x := p.committedShare(transcript.Verifier, id)
+		commitments[id] = [3]curves.Point{
+			// z_i * G + c * C1_i
+			p.curve.ScalarBaseMult(z).Add(ct.C1.Mul(c)),
+			// u_i * G + c * X_i
+			p.curve.ScalarBaseMult(u).Add(x.Mul(c)),
+			// u_i * H + z_i * Y_i + c * C2_i
+			h.Mul(u).Add(keys[id].Value.Mul(z)).Add(ct.C2.Mul(c)),
+		}
+	}
+	challenge := p.challenge(sessionId, transcript.Verifier, keys, transcript.Ciphertexts, commitments)
+	if challenge.Cmp(c) != 0 {
+		return fmt.Errorf("invalid proof")
+	}
+	return nil
+}
+
+// DecryptShare decrypts the share S_i = s_i * H of recipient id from a
+// verified transcript. It always succeeds for a transcript that passed Verify.
+// The session id is the one of the dealing, it binds the decryption proof.
+func (p Pvss) DecryptShare(id uint32, dk *elgamal.DecryptionKey, transcript *Transcript, sessionId []byte) (*DecryptedShare, error) {
+	if dk == nil || transcript == nil {
+		return nil, internal.ErrNilArguments
+	}
+	ct, ok := transcript.Ciphertexts[id]
+	if !ok || ct == nil {
+		return nil, fmt.Errorf("missing ciphertext for %d", id)
+	}
+	value, proof, err := dk.DecryptWithProof(ct, p.decryptionDomain(sessionId, id))
+	if err != nil {
+		return nil, err
+	}
+	return &DecryptedShare{
+		Id:    id,
+		Value: value,
+		Proof: proof,
+	}, nil
+}
+
+// VerifyShare checks that share is the decryption of the ciphertext of its
+// recipient in a verified transcript. ek is the key of the recipient.
+func (p Pvss) VerifyShare(share *DecryptedShare, ek *elgamal.EncryptionKey, transcript *Transcript, sessionId []byte) error {
+	if share == nil || ek == nil || transcript == nil {
+		return internal.ErrNilArguments
+	}
+	ct, ok := transcript.Ciphertexts[share.Id]
+	if !ok || ct == nil {
+		return fmt.Errorf("missing ciphertext for %d", share.Id)
+	}
+	return ek.VerifyDecryption(ct, share.Value, share.Proof, p.decryptionDomain(sessionId, share.Id))
+}
+
+// Combine reconstructs the secret s * H from at least threshold decrypted shares.
+// The shares should be checked with VerifyShare first.
+func (p Pvss) Combine(shares ...*DecryptedShare) (curves.Point, error) {
+	if len(shares) < int(p.threshold) {
+		return nil, fmt.Errorf("invalid number of shares")
+	}
+	ids := make([]uint32, len(shares))
+	values := make(map[uint32]curves.Point, len(shares))
+	for i, share := range shares {
+		if share == nil || share.Value == nil {
+			return nil, internal.ErrNilArguments
+		}
+		if share.Id == 0 || share.Id > p.limit {
+			return nil, fmt.Errorf("invalid share identifier")
+		}
+		if _, ok := values[share.Id]; ok {
+			return nil, fmt.Errorf("duplicate share")
+		}
+		ids[i] = share.Id
+		values[share.Id] = share.Value
+	}
+	shamir, err := sharing.NewShamir(p.threshold, p.limit, p.curve)
+	if err != nil {
+		return nil, err
+	}
+	lambdas, err := shamir.LagrangeCoeffs(ids)
+	if err != nil {
+		return nil, err
+	}
+	result := p.curve.NewIdentityPoint()
+	for id, value := range values {
+		result = result.Add(value.Mul(lambdas[id]))
+	}
+	return result, nil
+}
+
+func (p Pvss) checkKeys(keys map[uint32]*elgamal.EncryptionKey) error {
+	if len(keys) != int(p.limit) {
+		return internal.ErrIncorrectCount
+	}
+	for id := uint32(1); id <= p.limit; id++ {
+		k, ok := keys[id]
+		if !ok || k == nil || k.Value == nil {
+			return fmt.Errorf("missing encryption key for %d", id)
+		}
+		if k.Value.CurveName() != p.curve.Name || k.Value.IsIdentity() || !k.Value.IsOnCurve() {
+			return fmt.Errorf("invalid encryption key for %d", id)
+		}
+	}
+	return nil
+}
+
+// committedShare computes X_i = s_i * G from the Feldman commitments
+func (p Pvss) committedShare(verifier *sharing.FeldmanVerifier, id uint32) curves.Point {
+	x := p.curve.Scalar.New(int(id))
+	i := p.curve.Scalar.One()
+	result := verifier.Commitments[0]
+	for j := 1; j < len(verifier.Commitments); j++ {
+		i = i.Mul(x)
+		result = result.Add(verifier.Commitments[j].Mul(i))
+	}
+	return result
+}
+
+func (p Pvss) randomNonZero(reader io.Reader) curves.Scalar {
+	r := p.curve.Scalar.Random(reader)
+	for r.IsZero() {
+		r = p.curve.Scalar.Random(reader)
+	}
+	return r
+}
+
+func (p Pvss) decryptionDomain(sessionId []byte, id uint32) []byte {
+	domain := append([]byte("kryptology pvss decryption"), sessionId...)
+	return append(domain, byte(id>>24), byte(id>>16), byte(id>>8), byte(id))
+}
+
+// challenge hashes the statement and the prover commitments in identifier order
+func (p Pvss) challenge(sessionId []byte, verifier *sharing.FeldmanVerifier, keys map[uint32]*elgamal.EncryptionKey,
+	ciphertexts map[uint32]*elgamal.HomomorphicCipherText, commitments map[uint32][3]curves.Point,
+) curves.Scalar {
+	transcript := []byte("kryptology pvss")
+	transcript = append(transcript, sessionId...)
+	for _, c := range verifier.Commitments {
+		transcript = append(transcript, c.ToAffineCompressed()...)
+	}
+	for id := uint32(1); id <= p.limit; id++ {
+		transcript = append(transcript, keys[id].Value.ToAffineCompressed()...)
+		transcript = append(transcript, ciphertexts[id].C1.ToAffineCompressed()...)
+		transcript = append(transcript, ciphertexts[id].C2.ToAffineCompressed()...)
+		for _, c := range commitments[id] {
+			transcript = append(transcript, c.ToAffineCompressed()...)
+		}
+	}
+	return p.curve.Scalar.Hash(transcript)
+}
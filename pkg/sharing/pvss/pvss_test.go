@@ -0,0 +1,143 @@
+//
+// Copyright Coinbase, Inc. All Rights Reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package pvss
+
+import (
+	crand "crypto/rand"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/nerifnetwork/kryptology/pkg/core/curves"
+	"github.com/nerifnetwork/kryptology/pkg/verenc/elgamal"
+)
+
+var testSessionId = []byte("pvss test session")
+
+func newTestKeys(t *testing.T, curve *curves.Curve, n uint32) (map[uint32]*elgamal.EncryptionKey, map[uint32]*elgamal.DecryptionKey) {
+	eks := make(map[uint32]*elgamal.EncryptionKey, n)
+	dks := make(map[uint32]*elgamal.DecryptionKey, n)
+	for i := uint32(1); i <= n; i++ {
+		ek, dk, err := elgamal.NewKeys(curve)
+		require.NoError(t, err)
+		eks[i] = ek
+		dks[i] = dk
+	}
+	return eks, dks
+}
+
+func TestPvssDealVerifyCombine(t *testing.T) {
+	for _, curve := range []*curves.Curve{curves.K256(), curves.ED25519(), curves.BLS12381G1()} {
+		scheme, err := NewPvss(3, 5, curve)
+		require.NoError(t, err)
+		eks, dks := newTestKeys(t, curve, 5)
+		secret := curve.Scalar.Random(crand.Reader)
+
+		transcript, err := scheme.Deal(secret, eks, testSessionId, crand.Reader)
+		require.NoError(t, err)
+		require.NoError(t, scheme.Verify(transcript, eks, testSessionId))
+
+		shares := make([]*DecryptedShare, 0, 5)
+		for id, dk := range dks {
+			share, err := scheme.DecryptShare(id, dk, transcript, testSessionId)
+			require.NoError(t, err)
+			require.NoError(t, scheme.VerifyShare(share, eks[id], transcript, testSessionId))
+			shares = append(shares, share)
+		}
+		result, err := scheme.Combine(shares[:3]...)
+		require.NoError(t, err)
+		require.True(t, scheme.SecretGenerator().Mul(secret).Equal(result))
+		result, err = scheme.Combine(shares[2:]...)
+		require.NoError(t, err)
+		require.True(t, scheme.SecretGenerator().Mul(secret).Equal(result))
+		_, err = scheme.Combine(shares[:2]...)
+		require.Error(t, err)
+		_, err = scheme.Combine(shares[0], shares[0], shares[1])
+		require.Error(t, err)
+	}
+}
+
+func TestPvssVerifyRejectsTampering(t *testing.T) {
+	curve := curves.K256()
+	scheme, err := NewPvss(2, 3, curve)
+	require.NoError(t, err)
+	eks, dks := newTestKeys(t, curve, 3)
+
+	deal := func() *Transcript {
+		transcript, err := scheme.Deal(curve.Scalar.Random(crand.Reader), eks, testSessionId, crand.Reader)
+		require.NoError(t, err)
+		return transcript
+	}
+
+	// wrong session
+	transcript := deal()
+	require.Error(t, scheme.Verify(transcript, eks, []byte("other session")))
+
+	// a ciphertext from another dealing
+	transcript = deal()
+	transcript.Ciphertexts[2] = deal().Ciphertexts[2]
+	require.Error(t, scheme.Verify(transcript, eks, testSessionId))
+
+	// a modified commitment
+	transcript = deal()
+	transcript.Verifier.Commitments[1] = transcript.Verifier.Commitments[1].Double()
+	require.Error(t, scheme.Verify(transcript, eks, testSessionId))
+
+	// modified responses
+	transcript = deal()
+	transcript.Proof.BlindingResponses[1] = transcript.Proof.BlindingResponses[1].Add(curve.Scalar.One())
+	require.Error(t, scheme.Verify(transcript, eks, testSessionId))
+	transcript = deal()
+	transcript.Proof.ShareResponses[1] = transcript.Proof.ShareResponses[1].Add(curve.Scalar.One())
+	require.Error(t, scheme.Verify(transcript, eks, testSessionId))
+
+	// a share encrypted under the right key but not matching its commitment
+	transcript = deal()
+	ct := transcript.Ciphertexts[3]
+	transcript.Ciphertexts[3] = &elgamal.HomomorphicCipherText{C1: ct.C1, C2: ct.C2.Add(scheme.SecretGenerator())}
+	require.Error(t, scheme.Verify(transcript, eks, testSessionId))
+
+	// swapped recipient keys
+	transcript = deal()
+	swapped := map[uint32]*elgamal.EncryptionKey{1: eks[2], 2: eks[1], 3: eks[3]}
+	require.Error(t, scheme.Verify(transcript, swapped, testSessionId))
+
+	// a share decrypted with the wrong key or for another session does not verify
+	transcript = deal()
+	share, err := scheme.DecryptShare(1, dks[2], transcript, testSessionId)
+	require.NoError(t, err)
+	require.Error(t, scheme.VerifyShare(share, eks[1], transcript, testSessionId))
+	share, err = scheme.DecryptShare(1, dks[1], transcript, testSessionId)
+	require.NoError(t, err)
+	require.NoError(t, scheme.VerifyShare(share, eks[1], transcript, testSessionId))
+	require.Error(t, scheme.VerifyShare(share, eks[1], transcript, []byte("other session")))
+	share.Value = share.Value.Double()
+	require.Error(t, scheme.VerifyShare(share, eks[1], transcript, testSessionId))
+
+	// missing key
+	delete(eks, 3)
+	_, err = scheme.Deal(curve.Scalar.Random(crand.Reader), eks, testSessionId, crand.Reader)
+	require.Error(t, err)
+}
+
+func TestPvssTranscriptMarshal(t *testing.T) {
+	curve := curves.P256()
+	scheme, err := NewPvss(2, 4, curve)
+	require.NoError(t, err)
+	eks, dks := newTestKeys(t, curve, 4)
+	transcript, err := scheme.Deal(curve.Scalar.Random(crand.Reader), eks, testSessionId, crand.Reader)
+	require.NoError(t, err)
+
+	data, err := transcript.MarshalBinary()
+	require.NoError(t, err)
+	decoded := new(Transcript)
+	require.NoError(t, decoded.UnmarshalBinary(data))
+	require.NoError(t, scheme.Verify(decoded, eks, testSessionId))
+	share, err := scheme.DecryptShare(4, dks[4], decoded, testSessionId)
+	require.NoError(t, err)
+	require.NoError(t, scheme.VerifyShare(share, eks[4], transcript, testSessionId))
+}
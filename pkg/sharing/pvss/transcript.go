@@ -0,0 +1,130 @@
+//
+// Copyright Coinbase, Inc. All Rights Reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package pvss
+
+import (
+	"fmt"
+
+	"git.sr.ht/~sircmpwn/go-bare"
+
+	"github.com/nerifnetwork/kryptology/pkg/core/curves"
+	"github.com/nerifnetwork/kryptology/pkg/sharing"
+	"github.com/nerifnetwork/kryptology/pkg/verenc/elgamal"
+)
+
+// transcriptMarshal stores the ciphertexts and responses in identifier order 1, ..., n
+type transcriptMarshal struct {
+	Curve       string   `bare:"curve"`
+	Commitments [][]byte `bare:"commitments"`
+	Ciphertexts [][]byte `bare:"ciphertexts"`
+	Challenge   []byte   `bare:"challenge"`
+	Blindings   [][]byte `bare:"blindings"`
+	Shares      [][]byte `bare:"shares"`
+}
+
+// MarshalBinary serializes the transcript so it can be published
+func (t Transcript) MarshalBinary() ([]byte, error) {
+	if t.Verifier == nil || len(t.Verifier.Commitments) == 0 || t.Proof == nil || t.Proof.Challenge == nil {
+		return nil, fmt.Errorf("invalid transcript")
+	}
+	n := len(t.Ciphertexts)
+	if len(t.Proof.BlindingResponses) != n || len(t.Proof.ShareResponses) != n {
+		return nil, fmt.Errorf("invalid transcript")
+	}
+	tv := new(transcriptMarshal)
+	tv.Curve = t.Verifier.Commitments[0].CurveName()
+	tv.Commitments = make([][]byte, len(t.Verifier.Commitments))
+	for i, c := range t.Verifier.Commitments {
+		tv.Commitments[i] = c.ToAffineCompressed()
+	}
+	tv.Ciphertexts = make([][]byte, n)
+	tv.Blindings = make([][]byte, n)
+	tv.Shares = make([][]byte, n)
+	for i := 0; i < n; i++ {
+		id := uint32(i + 1)
+		ct, ok := t.Ciphertexts[id]
+		if !ok || ct == nil {
+			return nil, fmt.Errorf("missing ciphertext for %d", id)
+		}
+		z, ok := t.Proof.BlindingResponses[id]
+		if !ok || z == nil {
+			return nil, fmt.Errorf("missing response for %d", id)
+		}
+		u, ok := t.Proof.ShareResponses[id]
+		if !ok || u == nil {
+			return nil, fmt.Errorf("missing response for %d", id)
+		}
+		data, err := ct.MarshalBinary()
+		if err != nil {
+			return nil, err
+		}
+		tv.Ciphertexts[i] = data
+		tv.Blindings[i] = z.Bytes()
+		tv.Shares[i] = u.Bytes()
+	}
+	tv.Challenge = t.Proof.Challenge.Bytes()
+	return bare.Marshal(tv)
+}
+
+// UnmarshalBinary deserializes a transcript. The result still has to be checked with Pvss.Verify.
+func (t *Transcript) UnmarshalBinary(data []byte) error {
+	tv := new(transcriptMarshal)
+	if err := bare.Unmarshal(data, tv); err != nil {
+		return err
+	}
+	curve := curves.GetCurveByName(tv.Curve)
+	if curve == nil {
+		return fmt.Errorf("unknown curve")
+	}
+	if len(tv.Ciphertexts) != len(tv.Blindings) || len(tv.Ciphertexts) != len(tv.Shares) {
+		return fmt.Errorf("invalid transcript")
+	}
+	commitments := make([]curves.Point, len(tv.Commitments))
+	for i, c := range tv.Commitments {
+		p, err := curve.Point.FromAffineCompressed(c)
+		if err != nil {
+			return err
+		}
+		commitments[i] = p
+	}
+	ciphertexts := make(map[uint32]*elgamal.HomomorphicCipherText, len(tv.Ciphertexts))
+	blindings := make(map[uint32]curves.Scalar, len(tv.Blindings))
+	shares := make(map[uint32]curves.Scalar, len(tv.Shares))
+	for i := range tv.Ciphertexts {
+		id := uint32(i + 1)
+		ct := new(elgamal.HomomorphicCipherText)
+		if err := ct.UnmarshalBinary(tv.Ciphertexts[i]); err != nil {
+			return err
+		}
+		if ct.C1.CurveName() != curve.Name || ct.C2.CurveName() != curve.Name {
+			return fmt.Errorf("invalid ciphertext curve")
+		}
+		z, err := curve.Scalar.SetBytes(tv.Blindings[i])
+		if err != nil {
+			return err
+		}
+		u, err := curve.Scalar.SetBytes(tv.Shares[i])
+		if err != nil {
+			return err
+		}
+		ciphertexts[id] = ct
+		blindings[id] = z
+		shares[id] = u
+	}
+	challenge, err := curve.Scalar.SetBytes(tv.Challenge)
+	if err != nil {
+		return err
+	}
+	t.Verifier = &sharing.FeldmanVerifier{Commitments: commitments}
+	t.Ciphertexts = ciphertexts
+	t.Proof = &Proof{
+		Challenge:         challenge,
+		BlindingResponses: blindings,
+		ShareResponses:    shares,
+	}
+	return nil
+}
@@ -17,6 +17,7 @@ import (
 	"io"
 
 	"github.com/nerifnetwork/kryptology/pkg/core/curves"
+	"github.com/nerifnetwork/kryptology/pkg/core/polynomial"
 )
 
 type ShamirShare struct {
@@ -75,12 +76,17 @@ func (s Shamir) Split(secret curves.Scalar, reader io.Reader) ([]*ShamirShare, e
 
 func (s Shamir) getPolyAndShares(secret curves.Scalar, reader io.Reader) ([]*ShamirShare, *Polynomial) {
 	poly := new(Polynomial).Init(secret, s.threshold, reader)
+	xs := make([]curves.Scalar, s.limit)
+	for i := range xs {
+		xs[i] = s.curve.Scalar.New(i + 1)
+	}
+	// EvaluateMany only errors on empty input which NewShamir rules out
+	ys, _ := polynomial.EvaluateMany(poly.Coefficients, xs)
 	shares := make([]*ShamirShare, s.limit)
 	for i := range shares {
-		x := s.curve.Scalar.New(i + 1)
 		shares[i] = &ShamirShare{
 			Id:    uint32(i + 1),
-			Value: poly.Evaluate(x).Bytes(),
+			Value: ys[i].Bytes(),
 		}
 	}
 	return shares, poly
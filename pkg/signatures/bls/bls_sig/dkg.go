@@ -0,0 +1,62 @@
+//
+// Copyright Coinbase, Inc. All Rights Reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package bls_sig
+
+import (
+	"fmt"
+
+	"github.com/nerifnetwork/kryptology/pkg/core/curves"
+)
+
+// NewSecretKeyShare creates a secret key share from the output of a DKG run over BLS12-381
+// such as dkg/frost or dkg/gennaro/v2, so threshold signing doesn't need a trusted dealer. id is the
+// participant's identifier and share its share of the secret key.
+func NewSecretKeyShare(id uint32, share curves.Scalar) (*SecretKeyShare, error) {
+	if id == 0 || id > 255 {
+		return nil, fmt.Errorf("identifier must be between 1 and 255")
+	}
+	s, ok := share.(*curves.ScalarBls12381)
+	if !ok || s.Value == nil {
+		return nil, fmt.Errorf("share must be a BLS12-381 scalar")
+	}
+	if s.IsZero() {
+		return nil, fmt.Errorf("share cannot be zero")
+	}
+	return &SecretKeyShare{identifier: byte(id), value: s.Bytes()}, nil
+}
+
+// NewPublicKey creates a public key from a point in G1, such as the verification key or
+// a participant's verification key share output by a DKG run over BLS12381G1
+func NewPublicKey(point curves.Point) (*PublicKey, error) {
+	p, ok := point.(*curves.PointBls12381G1)
+	if !ok || p.Value == nil {
+		return nil, fmt.Errorf("public key must be a BLS12-381 G1 point")
+	}
+	if p.Value.IsIdentity() == 1 {
+		return nil, fmt.Errorf("public keys cannot be zero")
+	}
+	if p.Value.InCorrectSubgroup() == 0 {
+		return nil, fmt.Errorf("public key is not in the correct subgroup")
+	}
+	return &PublicKey{value: *p.Value}, nil
+}
+
+// NewPublicKeyVt creates a public key from a point in G2, such as the verification key or
+// a participant's verification key share output by a DKG run over BLS12381G2
+func NewPublicKeyVt(point curves.Point) (*PublicKeyVt, error) {
+	p, ok := point.(*curves.PointBls12381G2)
+	if !ok || p.Value == nil {
+		return nil, fmt.Errorf("public key must be a BLS12-381 G2 point")
+	}
+	if p.Value.IsIdentity() == 1 {
+		return nil, fmt.Errorf("public keys cannot be zero")
+	}
+	if p.Value.InCorrectSubgroup() == 0 {
+		return nil, fmt.Errorf("public key is not in the correct subgroup")
+	}
+	return &PublicKeyVt{value: *p.Value}, nil
+}
@@ -0,0 +1,165 @@
+//
+// Copyright Coinbase, Inc. All Rights Reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package bls_sig
+
+import (
+	"testing"
+
+	"github.com/nerifnetwork/kryptology/pkg/core/curves"
+	"github.com/nerifnetwork/kryptology/pkg/dkg/frost"
+	"github.com/nerifnetwork/kryptology/pkg/sharing"
+)
+
+// runFrostDkg runs a 2 of 3 FROST DKG and returns the participants and their round 2 broadcasts
+func runFrostDkg(t *testing.T, curve *curves.Curve) (map[uint32]*frost.DkgParticipant, map[uint32]*frost.Round2Bcast) {
+	ids := []uint32{1, 2, 3}
+	participants := make(map[uint32]*frost.DkgParticipant, len(ids))
+	for _, id := range ids {
+		others := make([]uint32, 0, len(ids)-1)
+		for _, other := range ids {
+			if other != id {
+				others = append(others, other)
+			}
+		}
+		p, err := frost.NewDkgParticipant(id, 2, "0", curve, others...)
+		if err != nil {
+			t.Fatalf("NewDkgParticipant failed: %v", err)
+		}
+		participants[id] = p
+	}
+
+	bcast := make(map[uint32]*frost.Round1Bcast, len(ids))
+	p2p := make(map[uint32]frost.Round1P2PSend, len(ids))
+	for id, p := range participants {
+		b, s, err := p.Round1(nil)
+		if err != nil {
+			t.Fatalf("Round1 failed: %v", err)
+		}
+		bcast[id] = b
+		p2p[id] = s
+	}
+
+	round2 := make(map[uint32]*frost.Round2Bcast, len(ids))
+	for id, p := range participants {
+		shares := make(map[uint32]*sharing.ShamirShare, len(ids)-1)
+		for sender, s := range p2p {
+			if sender != id {
+				shares[sender] = s[id]
+			}
+		}
+		b, err := p.Round2(bcast, shares)
+		if err != nil {
+			t.Fatalf("Round2 failed: %v", err)
+		}
+		round2[id] = b
+	}
+	return participants, round2
+}
+
+func TestThresholdSignFromDkgG1(t *testing.T) {
+	participants, round2 := runFrostDkg(t, curves.BLS12381G1())
+	scheme := NewSigPop()
+	msg := []byte("signed without a dealer")
+
+	pk, err := NewPublicKey(participants[1].VerificationKey)
+	if err != nil {
+		t.Fatalf("NewPublicKey failed: %v", err)
+	}
+	partials := make([]*PartialSignature, 0, 2)
+	for _, id := range []uint32{1, 3} {
+		sks, err := NewSecretKeyShare(id, participants[id].SkShare)
+		if err != nil {
+			t.Fatalf("NewSecretKeyShare failed: %v", err)
+		}
+		partial, err := scheme.PartialSign(sks, msg)
+		if err != nil {
+			t.Fatalf("PartialSign failed: %v", err)
+		}
+		pkShare, err := NewPublicKey(round2[id].VkShare)
+		if err != nil {
+			t.Fatalf("NewPublicKey failed: %v", err)
+		}
+		if ok, err := scheme.PartialVerify(pkShare, msg, partial); err != nil || !ok {
+			t.Errorf("PartialVerify failed for participant %d", id)
+		}
+		// A partial signature doesn't verify under another participant's share
+		other, _ := NewPublicKey(round2[2].VkShare)
+		if ok, _ := scheme.PartialVerify(other, msg, partial); ok {
+			t.Errorf("PartialVerify passed for the wrong share")
+		}
+		partials = append(partials, partial)
+	}
+	sig, err := scheme.CombineSignatures(partials...)
+	if err != nil {
+		t.Fatalf("CombineSignatures failed: %v", err)
+	}
+	if ok, err := scheme.Verify(pk, msg, sig); err != nil || !ok {
+		t.Errorf("Verify failed")
+	}
+}
+
+func TestThresholdSignFromDkgG2(t *testing.T) {
+	participants, round2 := runFrostDkg(t, curves.BLS12381G2())
+	scheme := NewSigAugVt()
+	msg := []byte("signed without a dealer")
+
+	pk, err := NewPublicKeyVt(participants[2].VerificationKey)
+	if err != nil {
+		t.Fatalf("NewPublicKeyVt failed: %v", err)
+	}
+	partials := make([]*PartialSignatureVt, 0, 2)
+	for _, id := range []uint32{2, 3} {
+		sks, err := NewSecretKeyShare(id, participants[id].SkShare)
+		if err != nil {
+			t.Fatalf("NewSecretKeyShare failed: %v", err)
+		}
+		partial, err := scheme.PartialSign(sks, pk, msg)
+		if err != nil {
+			t.Fatalf("PartialSign failed: %v", err)
+		}
+		pkShare, err := NewPublicKeyVt(round2[id].VkShare)
+		if err != nil {
+			t.Fatalf("NewPublicKeyVt failed: %v", err)
+		}
+		if ok, err := scheme.PartialVerify(pkShare, pk, msg, partial); err != nil || !ok {
+			t.Errorf("PartialVerify failed for participant %d", id)
+		}
+		partials = append(partials, partial)
+	}
+	sig, err := scheme.CombineSignatures(partials...)
+	if err != nil {
+		t.Fatalf("CombineSignatures failed: %v", err)
+	}
+	if ok, err := scheme.Verify(pk, msg, sig); err != nil || !ok {
+		t.Errorf("Verify failed")
+	}
+}
+
+func TestNewKeysFromDkgInvalid(t *testing.T) {
+	g1 := curves.BLS12381G1()
+	if _, err := NewSecretKeyShare(0, g1.Scalar.One()); err == nil {
+		t.Errorf("expected an error for a zero identifier")
+	}
+	if _, err := NewSecretKeyShare(256, g1.Scalar.One()); err == nil {
+		t.Errorf("expected an error for a large identifier")
+	}
+	if _, err := NewSecretKeyShare(1, g1.Scalar.Zero()); err == nil {
+		t.Errorf("expected an error for a zero share")
+	}
+	if _, err := NewSecretKeyShare(1, curves.K256().Scalar.One()); err == nil {
+		t.Errorf("expected an error for a share on another curve")
+	}
+	if _, err := NewPublicKey(g1.Point.Identity()); err == nil {
+		t.Errorf("expected an error for the identity")
+	}
+	if _, err := NewPublicKey(curves.BLS12381G2().Point.Generator()); err == nil {
+		t.Errorf("expected an error for a G2 point")
+	}
+	if _, err := NewPublicKeyVt(g1.Point.Generator()); err == nil {
+		t.Errorf("expected an error for a G1 point")
+	}
+}
@@ -142,6 +142,15 @@ func (b SigBasicVt) CombineSignatures(sigs ...*PartialSignatureVt) (*SignatureVt
 	return combineSigsVt(sigs)
 }
 
+// PartialVerify checks that a partial signature is valid for the message under the
+// public key share of its signer
+func (b SigBasicVt) PartialVerify(pkShare *PublicKeyVt, msg []byte, sig *PartialSignatureVt) (bool, error) {
+	if pkShare == nil || sig == nil {
+		return false, fmt.Errorf("public key and signature cannot be nil")
+	}
+	return pkShare.verifySignatureVt(msg, &SignatureVt{value: sig.signature}, b.dst)
+}
+
 // Checks that a signature is valid for the message under the public key pk
 func (b SigBasicVt) Verify(pk *PublicKeyVt, msg []byte, sig *SignatureVt) (bool, error) {
 	return pk.verifySignatureVt(msg, sig, b.dst)
@@ -237,6 +246,20 @@ func (b SigAugVt) CombineSignatures(sigs ...*PartialSignatureVt) (*SignatureVt,
 	return combineSigsVt(sigs)
 }
 
+// PartialVerify checks that a partial signature is valid for the message under the
+// public key share of its signer, pk is the public key that the signers share
+func (b SigAugVt) PartialVerify(pkShare, pk *PublicKeyVt, msg []byte, sig *PartialSignatureVt) (bool, error) {
+	if pkShare == nil || pk == nil || sig == nil {
+		return false, fmt.Errorf("public keys and signature cannot be nil")
+	}
+	bytes, err := pk.MarshalBinary()
+	if err != nil {
+		return false, err
+	}
+	bytes = append(bytes, msg...)
+	return pkShare.verifySignatureVt(bytes, &SignatureVt{value: sig.signature}, b.dst)
+}
+
 // Checks that a signature is valid for the message under the public key pk
 // See section 3.2.2 from
 // https://tools.ietf.org/html/draft-irtf-cfrg-bls-signature-03
@@ -343,6 +366,15 @@ func (b SigPopVt) CombineSignatures(sigs ...*PartialSignatureVt) (*SignatureVt,
 	return combineSigsVt(sigs)
 }
 
+// PartialVerify checks that a partial signature is valid for the message under the
+// public key share of its signer
+func (b SigPopVt) PartialVerify(pkShare *PublicKeyVt, msg []byte, sig *PartialSignatureVt) (bool, error) {
+	if pkShare == nil || sig == nil {
+		return false, fmt.Errorf("public key and signature cannot be nil")
+	}
+	return pkShare.verifySignatureVt(msg, &SignatureVt{value: sig.signature}, b.sigDst)
+}
+
 // Checks that a signature is valid for the message under the public key pk
 // See section 2.7 from
 // https://tools.ietf.org/html/draft-irtf-cfrg-bls-signature-03
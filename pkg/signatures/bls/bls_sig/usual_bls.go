@@ -142,6 +142,15 @@ func (b SigBasic) CombineSignatures(sigs ...*PartialSignature) (*Signature, erro
 	return combineSigs(sigs)
 }
 
+// PartialVerify checks that a partial signature is valid for the message under the
+// public key share of its signer
+func (b SigBasic) PartialVerify(pkShare *PublicKey, msg []byte, sig *PartialSignature) (bool, error) {
+	if pkShare == nil || sig == nil {
+		return false, fmt.Errorf("public key and signature cannot be nil")
+	}
+	return pkShare.verifySignature(msg, &Signature{Value: sig.Signature}, b.dst)
+}
+
 // Checks that a signature is valid for the message under the public key pk
 func (b SigBasic) Verify(pk *PublicKey, msg []byte, sig *Signature) (bool, error) {
 	return pk.verifySignature(msg, sig, b.dst)
@@ -242,6 +251,20 @@ func (b SigAug) CombineSignatures(sigs ...*PartialSignature) (*Signature, error)
 	return combineSigs(sigs)
 }
 
+// PartialVerify checks that a partial signature is valid for the message under the
+// public key share of its signer, pk is the public key that the signers share
+func (b SigAug) PartialVerify(pkShare, pk *PublicKey, msg []byte, sig *PartialSignature) (bool, error) {
+	if pkShare == nil || pk == nil || sig == nil {
+		return false, fmt.Errorf("public keys and signature cannot be nil")
+	}
+	bytes, err := pk.MarshalBinary()
+	if err != nil {
+		return false, err
+	}
+	bytes = append(bytes, msg...)
+	return pkShare.verifySignature(bytes, &Signature{Value: sig.Signature}, b.dst)
+}
+
 // Checks that a signature is valid for the message under the public key pk
 // See section 3.2.2 from
 // https://tools.ietf.org/html/draft-irtf-cfrg-bls-signature-03
@@ -348,6 +371,15 @@ func (b SigPop) CombineSignatures(sigs ...*PartialSignature) (*Signature, error)
 	return combineSigs(sigs)
 }
 
+// PartialVerify checks that a partial signature is valid for the message under the
+// public key share of its signer
+func (b SigPop) PartialVerify(pkShare *PublicKey, msg []byte, sig *PartialSignature) (bool, error) {
+	if pkShare == nil || sig == nil {
+		return false, fmt.Errorf("public key and signature cannot be nil")
+	}
+	return pkShare.verifySignature(msg, &Signature{Value: sig.Signature}, b.sigDst)
+}
+
 // Checks that a signature is valid for the message under the public key pk
 // See section 2.7 from
 // https://tools.ietf.org/html/draft-irtf-cfrg-bls-signature-03
@@ -0,0 +1,135 @@
+//
+// Copyright Coinbase, Inc. All Rights Reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+// Package bip32 implements the public child key derivation of
+// [BIP-32](https://github.com/bitcoin/bips/blob/master/bip-0032.mediawiki) for threshold ECDSA keys.
+// Non-hardened derivation only needs the public key and the chain code, so every party computes the same
+// public tweak and adjusts its share of the secret key without another DKG.
+package bip32
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha512"
+	"encoding/binary"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+
+	"github.com/nerifnetwork/kryptology/internal"
+	"github.com/nerifnetwork/kryptology/pkg/core/curves"
+)
+
+const (
+	// ChainCodeSize is the size of a chain code in bytes.
+	ChainCodeSize = 32
+
+	// HardenedOffset is the first hardened index. Hardened children need the secret key and cannot be derived.
+	HardenedOffset uint32 = 0x80000000
+)
+
+// Derivation is the child key at the end of a path.
+type Derivation struct {
+	// Tweak is the sum of the tweaks along the path. The child secret key is the parent secret key plus Tweak.
+	Tweak curves.Scalar
+
+	// PublicKey is the child public key, the parent public key plus Tweak.G.
+	PublicKey curves.Point
+
+	// ChainCode is the chain code of the child key.
+	ChainCode []byte
+}
+
+// NewChainCode returns a random chain code for keys that are not generated by a DKG, for example by a dealer.
+func NewChainCode() ([]byte, error) {
+	chainCode := make([]byte, ChainCodeSize)
+	if _, err := rand.Read(chainCode); err != nil {
+		return nil, err
+	}
+	return chainCode, nil
+}
+
+// Derive computes the non-hardened child of the public key along the path, see CKDpub in BIP-32.
+// The public key must be on secp256k1 or P-256.
+func Derive(publicKey curves.Point, chainCode []byte, path []uint32) (*Derivation, error) {
+	if publicKey == nil {
+		return nil, internal.ErrNilArguments
+	}
+	if len(chainCode) != ChainCodeSize {
+		return nil, fmt.Errorf("chain code must be %d bytes", ChainCodeSize)
+	}
+	curve := curves.GetCurveByName(publicKey.CurveName())
+	if curve == nil {
+		return nil, fmt.Errorf("unknown curve %s", publicKey.CurveName())
+	}
+	ellipticCurve, err := curve.ToEllipticCurve()
+	if err != nil {
+		return nil, err
+	}
+	if publicKey.IsIdentity() || !publicKey.IsOnCurve() {
+		return nil, fmt.Errorf("invalid public key")
+	}
+	n := ellipticCurve.Params().N
+
+	key := publicKey
+	tweak := curve.Scalar.Zero()
+	code := make([]byte, ChainCodeSize)
+	copy(code, chainCode)
+	for _, index := range path {
+		if index >= HardenedOffset {
+			return nil, fmt.Errorf("hardened index %d cannot be derived from the public key", index-HardenedOffset)
+		}
+		// I = HMAC-SHA512(Key = c_par, Data = serP(K_par) || ser32(i))
+		mac := hmac.New(sha512.New, code)
+		_, _ = mac.Write(key.ToAffineCompressed())
+		_ = binary.Write(mac, binary.BigEndian, index)
+		sum := mac.Sum(nil)
+
+		il := new(big.Int).SetBytes(sum[:32])
+		if il.Cmp(n) >= 0 {
+			return nil, fmt.Errorf("index %d does not have a valid child key", index)
+		}
+		t, err := curve.Scalar.SetBigInt(il)
+		if err != nil {
+			return nil, err
+		}
+		key = key.Add(curve.ScalarBaseMult(t))
+		if key.IsIdentity() {
+			return nil, fmt.Errorf("index %d does not have a valid child key", index)
+		}
+		tweak = tweak.Add(t)
+		code = sum[32:]
+	}
+	return &Derivation{
+		Tweak:     tweak,
+		PublicKey: key,
+		ChainCode: code,
+	}, nil
+}
+
+// ParsePath parses a path of non-hardened indices like "m/44/0/7". The path "m" is the key itself.
+func ParsePath(path string) ([]uint32, error) {
+	parts := strings.Split(path, "/")
+	if parts[0] != "m" {
+		return nil, fmt.Errorf("path must start with m")
+	}
+	indices := make([]uint32, 0, len(parts)-1)
+	for _, part := range parts[1:] {
+		if strings.HasSuffix(part, "'") || strings.HasSuffix(part, "h") || strings.HasSuffix(part, "H") {
+			return nil, fmt.Errorf("hardened index %s cannot be derived from the public key", part)
+		}
+		index, err := strconv.ParseUint(part, 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("invalid index %q", part)
+		}
+		if uint32(index) >= HardenedOffset {
+			return nil, fmt.Errorf("hardened index %s cannot be derived from the public key", part)
+		}
+		indices = append(indices, uint32(index))
+	}
+	return indices, nil
+}
@@ -0,0 +1,134 @@
+//
+// Copyright Coinbase, Inc. All Rights Reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package bip32
+
+import (
+	crand "crypto/rand"
+	"testing"
+
+	"github.com/btcsuite/btcutil/base58"
+	"github.com/stretchr/testify/require"
+
+	"github.com/nerifnetwork/kryptology/pkg/core/curves"
+)
+
+// decodeXpub returns the public key and the chain code of a base58 encoded extended public key
+func decodeXpub(t *testing.T, xpub string) (curves.Point, []byte) {
+	// version (4) || depth (1) || fingerprint (4) || index (4) || chain code (32) || key (33) || checksum (4)
+	decoded := base58.Decode(xpub)
+	require.Len(t, decoded, 82)
+	publicKey, err := curves.K256().Point.FromAffineCompressed(decoded[45:78])
+	require.NoError(t, err)
+	return publicKey, decoded[13:45]
+}
+
+func TestDeriveVectors(t *testing.T) {
+	// Test vectors 1 and 2 of BIP-32
+	tests := []struct {
+		parent, child string
+		path          []uint32
+	}{
+		{
+			"xpub68Gmy5EdvgibQVfPdqkBBCHxA5htiqg55crXYuXoQRKfDBFA1WEjWgP6LHhwBZeNK1VTsfTFUHCdrfp1bgwQ9xv5ski8PX9rL2dZXvgGDnw",
+			"xpub6ASuArnXKPbfEwhqN6e3mwBcDTgzisQN1wXN9BJcM47sSikHjJf3UFHKkNAWbWMiGj7Wf5uMash7SyYq527Hqck2AxYysAA7xmALppuCkwQ",
+			[]uint32{1},
+		},
+		{
+			"xpub661MyMwAqRbcFtXgS5sYJABqqG9YLmC4Q1Rdap9gSE8NqtwybGhePY2gZ29ESFjqJoCu1Rupje8YtGqsefD265TMg7usUDFdp6W1EGMcet8",
+			"xpub68Gmy5EVb2BdFbj2LpWrk1M7obNuaPTpT5oh9QCCo5sRfqSHVYWex97WpDZzszdzHzxXDAzPLVSwybe4uPYkSk4G3gnrPqqkV9RyNzAcNJ1",
+			[]uint32{0},
+		},
+		{
+			"xpub68Gmy5EdvgibQVfPdqkBBCHxA5htiqg55crXYuXoQRKfDBFA1WEjWgP6LHhwBZeNK1VTsfTFUHCdrfp1bgwQ9xv5ski8PX9rL2dZXvgGDnw",
+			"xpub6FMiTLEbxVfbmDQQbb4DyRrQCuL3qqhRhkFqiv5ZSLKnRoVik6ky4XwsZqLyccxai2SjLfJJRv6WBdNav4JiorKKhPATq2pYN2S4jNMXoz9",
+			[]uint32{1, 2, 1000000000},
+		},
+		{
+			"xpub661MyMwAqRbcFW31YEwpkMuc5THy2PSt5bDMsktWQcFF8syAmRUapSCGu8ED9W6oDMSgv6Zz8idoc4a6mr8BDzTJY47LJhkJ8UB7WEGuduB",
+			"xpub69H7F5d8KSRgmmdJg2KhpAK8SR3DjMwAdkxj3ZuxV27CprR9LgpeyGmXUbC6wb7ERfvrnKZjXoUmmDznezpbZb7ap6r1D3tgFxHmwMkQTPH",
+			[]uint32{0},
+		},
+		{
+			"xpub69H7F5dGf6xew3Np66QFxdnHJ951dwaMYr4EenxpNQCtjKm9bC17GdLJhM1sYGyeZuE2mwbXgYpaH9PdAN3PTnD2htW8QSGHca5mThjovwQ",
+			"xpub6AVDm6Pmis2JLAixFJ8noSzGcss2rzRTPnpKuQbu1CBX7sCUvUpJbc7CJTyjWFdmYR5SCvRJWrsv83iuvrZKimiWgR9RXqaeTsMF8AsUzcY",
+			[]uint32{1},
+		},
+	}
+	for _, test := range tests {
+		parentKey, parentChainCode := decodeXpub(t, test.parent)
+		childKey, childChainCode := decodeXpub(t, test.child)
+		derivation, err := Derive(parentKey, parentChainCode, test.path)
+		require.NoError(t, err)
+		require.True(t, childKey.Equal(derivation.PublicKey))
+		require.Equal(t, childChainCode, derivation.ChainCode)
+		require.True(t, childKey.Equal(parentKey.Add(curves.K256().ScalarBaseMult(derivation.Tweak))))
+	}
+}
+
+func TestDerivePath(t *testing.T) {
+	for _, curve := range []*curves.Curve{curves.K256(), curves.P256()} {
+		publicKey := curve.Point.Random(crand.Reader)
+		chainCode, err := NewChainCode()
+		require.NoError(t, err)
+
+		derivation, err := Derive(publicKey, chainCode, nil)
+		require.NoError(t, err)
+		require.True(t, publicKey.Equal(derivation.PublicKey))
+		require.True(t, derivation.Tweak.IsZero())
+		require.Equal(t, chainCode, derivation.ChainCode)
+
+		// Deriving a path in one step or one index at a time gives the same key and the sum of the tweaks
+		path := []uint32{44, 0, 7}
+		derivation, err = Derive(publicKey, chainCode, path)
+		require.NoError(t, err)
+		key, code, tweak := publicKey, chainCode, curve.Scalar.Zero()
+		for _, index := range path {
+			step, err := Derive(key, code, []uint32{index})
+			require.NoError(t, err)
+			key, code, tweak = step.PublicKey, step.ChainCode, tweak.Add(step.Tweak)
+		}
+		require.True(t, key.Equal(derivation.PublicKey))
+		require.Equal(t, code, derivation.ChainCode)
+		require.Equal(t, 0, tweak.Cmp(derivation.Tweak))
+		require.True(t, derivation.PublicKey.Equal(publicKey.Add(curve.ScalarBaseMult(tweak))))
+
+		other, err := Derive(publicKey, chainCode, []uint32{44, 0, 8})
+		require.NoError(t, err)
+		require.False(t, other.PublicKey.Equal(derivation.PublicKey))
+	}
+}
+
+func TestDeriveInvalidArguments(t *testing.T) {
+	curve := curves.K256()
+	publicKey := curve.Point.Generator()
+	chainCode := make([]byte, ChainCodeSize)
+
+	_, err := Derive(nil, chainCode, []uint32{0})
+	require.Error(t, err)
+	_, err = Derive(publicKey, chainCode[1:], []uint32{0})
+	require.Error(t, err)
+	_, err = Derive(curve.Point.Identity(), chainCode, []uint32{0})
+	require.Error(t, err)
+	_, err = Derive(curves.ED25519().Point.Generator(), chainCode, []uint32{0})
+	require.Error(t, err)
+	_, err = Derive(publicKey, chainCode, []uint32{0, HardenedOffset})
+	require.Error(t, err)
+}
+
+func TestParsePath(t *testing.T) {
+	path, err := ParsePath("m")
+	require.NoError(t, err)
+	require.Empty(t, path)
+	path, err = ParsePath("m/44/0/2147483647")
+	require.NoError(t, err)
+	require.Equal(t, []uint32{44, 0, 2147483647}, path)
+
+	for _, invalid := range []string{"", "0/1", "m/", "m/a", "m/-1", "m/0'", "m/0h", "m/0H", "m/2147483648", "m/4294967296"} {
+		_, err = ParsePath(invalid)
+		require.Error(t, err, invalid)
+	}
+}
@@ -0,0 +1,206 @@
+//
+// Copyright Coinbase, Inc. All Rights Reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package cggmp
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/nerifnetwork/kryptology/internal"
+)
+
+// PresignAbortBcast contains the encryptions H_i of k_i γ_i and Ĥ_i of k_i w_i of a signer
+type PresignAbortBcast struct {
+	H, HHat *big.Int
+}
+
+// PresignAbortP2PSend proves to a signer that H_i and Ĥ_i are well formed and that δ_i and Ŝ_i
+// match the plaintexts of H_i and Ĥ_i plus the conversions of the signer
+type PresignAbortP2PSend struct {
+	Mul, MulHat *MulStarProof
+	Dec, DecHat *DecProof
+}
+
+// abort discards the presignature and moves the signer to the abort round
+func (p *Presigner) abort() error {
+	// The signer must not use k_i or χ_i again
+	p.k, p.chi = nil, nil
+	p.round = 6
+	return ErrPresignAbort
+}
+
+// Abort proves that the δ_i and Ŝ_i of this signer are well formed after Output failed with ErrPresignAbort
+// [CGGMP21] fig 7: Output 2 and §4.3
+//
+// The plaintexts of C_i = H_i Π D_{i,j} F_{j,i}^-1 and Ĉ_i = Ĥ_i Π D̂_{i,j} F̂_{j,i}^-1 are δ_i and χ_i,
+// and all signers can compute them from the broadcasts of round 2.
+func (p *Presigner) Abort() (*PresignAbortBcast, map[uint32]*PresignAbortP2PSend, error) {
+	if p == nil || p.share == nil {
+		return nil, nil, internal.ErrNilArguments
+	}
+	if p.round != 6 {
+		return nil, nil, internal.ErrInvalidRound
+	}
+	curve := p.share.Curve
+	aux := p.share.Aux
+	sk := aux.SecretKey
+	pki := &sk.PublicKey
+	id := p.share.Id
+	g := curve.Point.Generator()
+
+	// H_i = K_i^γ_i r^N, Ĥ_i = K_i^w_i r̂^N
+	r, err := randUnit(pki.N)
+	if err != nil {
+		return nil, nil, err
+	}
+	rHat, err := randUnit(pki.N)
+	if err != nil {
+		return nil, nil, err
+	}
+	bcast := new(PresignAbortBcast)
+	if bcast.H, err = expMul(p.bigK[id], p.gamma.BigInt(), r, pki.N, pki.N2); err != nil {
+		return nil, nil, err
+	}
+	if bcast.HHat, err = expMul(p.bigK[id], p.w.BigInt(), rHat, pki.N, pki.N2); err != nil {
+		return nil, nil, err
+	}
+
+	// The plaintexts and nonces of C_i and Ĉ_i
+	C, err := p.shareCiphertext(id, bcast.H, false)
+	if err != nil {
+		return nil, nil, err
+	}
+	CHat, err := p.shareCiphertext(id, bcast.HHat, true)
+	if err != nil {
+		return nil, nil, err
+	}
+	delta, err := decrypt(sk, C)
+	if err != nil {
+		return nil, nil, err
+	}
+	chi, err := decrypt(sk, CHat)
+	if err != nil {
+		return nil, nil, err
+	}
+	rho, err := randomness(sk, C, delta)
+	if err != nil {
+		return nil, nil, err
+	}
+	rhoHat, err := randomness(sk, CHat, chi)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	out := make(map[uint32]*PresignAbortP2PSend, len(p.signers)-1)
+	for _, j := range p.signers {
+		if j == id {
+			continue
+		}
+		ped := aux.Pedersen[j]
+		msg := new(PresignAbortP2PSend)
+		if msg.Mul, err = proveMulStar(p.sid, id, curve, pki, ped, p.bigK[id], bcast.H, p.bigGamma[id], p.gamma.BigInt(), r); err != nil {
+			return nil, nil, err
+		}
+		if msg.MulHat, err = proveMulStar(p.sid, id, curve, pki, ped, p.bigK[id], bcast.HHat, p.W[id], p.w.BigInt(), rHat); err != nil {
+			return nil, nil, err
+		}
+		if msg.Dec, err = proveDec(p.sid, id, curve, pki, ped, C, g.Mul(p.round3[id].Delta), g, delta, rho); err != nil {
+			return nil, nil, err
+		}
+		if msg.DecHat, err = proveDec(p.sid, id, curve, pki, ped, CHat, p.round3[id].SHat, p.sumGamma, chi, rhoHat); err != nil {
+			return nil, nil, err
+		}
+		out[j] = msg
+	}
+	p.round = 7
+	return bcast, out, nil
+}
+
+// Identify checks the abort proofs of the other signers and returns an AbortError with the signers
+// whose δ_j or Ŝ_j is not well formed
+func (p *Presigner) Identify(bcast map[uint32]*PresignAbortBcast, p2p map[uint32]*PresignAbortP2PSend) error {
+	if p == nil || p.share == nil {
+		return internal.ErrNilArguments
+	}
+	if p.round != 7 {
+		return internal.ErrInvalidRound
+	}
+	curve := p.share.Curve
+	aux := p.share.Aux
+	ped := aux.Pedersen[p.share.Id]
+	g := curve.Point.Generator()
+	f := make(faults)
+	for _, j := range p.signers {
+		if j == p.share.Id {
+			continue
+		}
+		in, proofs := bcast[j], p2p[j]
+		if in == nil || proofs == nil {
+			f.add(j, fmt.Errorf("missing message"))
+			continue
+		}
+		pkj := aux.PublicKeys[j]
+		if !isUnit(in.H, pkj.N2) || !isUnit(in.HHat, pkj.N2) {
+			f.add(j, fmt.Errorf("invalid ciphertext"))
+			continue
+		}
+		if err := proofs.Mul.Verify(p.sid, j, curve, pkj, ped, p.bigK[j], in.H, p.bigGamma[j]); err != nil {
+			f.add(j, err)
+			continue
+		}
+		if err := proofs.MulHat.Verify(p.sid, j, curve, pkj, ped, p.bigK[j], in.HHat, p.W[j]); err != nil {
+			f.add(j, err)
+			continue
+		}
+		C, err := p.shareCiphertext(j, in.H, false)
+		if err != nil {
+			f.add(j, err)
+			continue
+		}
+		CHat, err := p.shareCiphertext(j, in.HHat, true)
+		if err != nil {
+			f.add(j, err)
+			continue
+		}
+		if err = proofs.Dec.Verify(p.sid, j, curve, pkj, ped, C, g.Mul(p.round3[j].Delta), g); err != nil {
+			f.add(j, fmt.Errorf("wrong δ: %v", err))
+			continue
+		}
+		if err = proofs.DecHat.Verify(p.sid, j, curve, pkj, ped, CHat, p.round3[j].SHat, p.sumGamma); err != nil {
+			f.add(j, fmt.Errorf("wrong Ŝ: %v", err))
+			continue
+		}
+	}
+	p.round = 8
+	if err := f.err(); err != nil {
+		return err
+	}
+	return fmt.Errorf("no signer could be identified")
+}
+
+// shareCiphertext returns C_i = H_i Π D_{i,j} F_{j,i}^-1 of signer i, or Ĉ_i with the conversions of w
+func (p *Presigner) shareCiphertext(i uint32, h *big.Int, hat bool) (*big.Int, error) {
+	pk := p.share.Aux.PublicKeys[i]
+	c := new(big.Int).Set(h)
+	for _, j := range p.signers {
+		if j == i {
+			continue
+		}
+		// D_{i,j} is the conversion of j to i and F_{j,i} the one of i to j, both under the key of i
+		D, F := p.round2[j].D[i], p.round2[i].F[j]
+		if hat {
+			D, F = p.round2[j].DHat[i], p.round2[i].FHat[j]
+		}
+		if !isUnit(D, pk.N2) || !isUnit(F, pk.N2) {
+			return nil, fmt.Errorf("invalid conversion")
+		}
+		c.Mul(c, D)
+		c.Mul(c, new(big.Int).ModInverse(F, pk.N2))
+		c.Mod(c, pk.N2)
+	}
+	return c, nil
+}
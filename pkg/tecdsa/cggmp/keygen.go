@@ -0,0 +1,316 @@
+//
+// Copyright Coinbase, Inc. All Rights Reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package cggmp
+
+import (
+	crand "crypto/rand"
+	"crypto/subtle"
+	"fmt"
+
+	"github.com/nerifnetwork/kryptology/internal"
+	"github.com/nerifnetwork/kryptology/pkg/core/curves"
+	"github.com/nerifnetwork/kryptology/pkg/sharing"
+	"github.com/nerifnetwork/kryptology/pkg/zkp/schnorr"
+)
+
+// hashSize is the size of the commitments and random identifiers
+const hashSize = 32
+
+// KeyGen is a participant of the key generation
+// [CGGMP21] fig 5, where every participant Feldman shares its part of the key
+type KeyGen struct {
+	curve     *curves.Curve
+	sid       []byte
+	id        uint32
+	ids       []uint32
+	threshold uint32
+	round     uint
+	// Round 1 values of this participant
+	secret   curves.Scalar
+	verifier *sharing.FeldmanVerifier
+	shares   []*sharing.ShamirShare
+	rid, u   []byte
+	// Values of the other participants
+	hashes      map[uint32][]byte
+	commitments map[uint32][]curves.Point
+	// The random identifier of the key from round 3
+	sumRid []byte
+}
+
+// KeyGenRound1Bcast commits to the values of round 2
+type KeyGenRound1Bcast struct {
+	V []byte
+}
+
+// KeyGenRound2Bcast reveals the Feldman commitments and the random identifier of a participant
+type KeyGenRound2Bcast struct {
+	Rid         []byte
+	Commitments []curves.Point
+	U           []byte
+}
+
+// KeyGenRound3Bcast proves knowledge of the part of the key of a participant
+type KeyGenRound3Bcast struct {
+	Proof *schnorr.Proof
+}
+
+// NewKeyGen creates a participant of the key generation. The session id must be unique and known to
+// all participants, and ids are the identifiers 1, 2, ..., n of all participants including id.
+// Any threshold of the participants can sign with the key.
+func NewKeyGen(curve *curves.Curve, sid []byte, id, threshold uint32, ids []uint32) (*KeyGen, error) {
+	if err := checkCurve(curve); err != nil {
+		return nil, err
+	}
+	if len(sid) == 0 {
+		return nil, internal.ErrNilArguments
+	}
+	if err := validIds(ids); err != nil {
+		return nil, err
+	}
+	if id == 0 || id > uint32(len(ids)) {
+		return nil, fmt.Errorf("invalid participant id %d", id)
+	}
+	if _, err := sharing.NewFeldman(threshold, uint32(len(ids)), curve); err != nil {
+		return nil, err
+	}
+	return &KeyGen{
+		curve:     curve,
+		sid:       sid,
+		id:        id,
+		ids:       ids,
+		threshold: threshold,
+		round:     1,
+	}, nil
+}
+
+// Round1 shares a random part of the key and commits to the Feldman commitments
+// [CGGMP21] fig 5: Round 1
+func (kg *KeyGen) Round1() (*KeyGenRound1Bcast, error) {
+	if kg == nil || kg.curve == nil {
+		return nil, internal.ErrNilArguments
+	}
+	if kg.round != 1 {
+		return nil, internal.ErrInvalidRound
+	}
+	feldman, err := sharing.NewFeldman(kg.threshold, uint32(len(kg.ids)), kg.curve)
+	if err != nil {
+		return nil, err
+	}
+	kg.secret = kg.curve.Scalar.Random(crand.Reader)
+	kg.verifier, kg.shares, err = feldman.Split(kg.secret, crand.Reader)
+	if err != nil {
+		return nil, err
+	}
+
+	// Sample rid_i and u_i, V_i = H(sid, i, rid_i, F_i, u_i)
+	kg.rid = make([]byte, hashSize)
+	kg.u = make([]byte, hashSize)
+	for _, b := range [][]byte{kg.rid, kg.u} {
+		if _, err = crand.Read(b); err != nil {
+			return nil, err
+		}
+	}
+	kg.round = 2
+	return &KeyGenRound1Bcast{
+		V: keyGenHash(kg.sid, kg.id, kg.rid, kg.verifier.Commitments, kg.u),
+	}, nil
+}
+
+// Round2 stores the commitments of the other participants and reveals the committed values
+// [CGGMP21] fig 5: Round 2
+func (kg *KeyGen) Round2(in map[uint32]*KeyGenRound1Bcast) (*KeyGenRound2Bcast, error) {
+	if kg == nil || kg.curve == nil {
+		return nil, internal.ErrNilArguments
+	}
+	if kg.round != 2 {
+		return nil, internal.ErrInvalidRound
+	}
+	f := make(faults)
+	kg.hashes = make(map[uint32][]byte, len(kg.ids))
+	for _, j := range kg.ids {
+		if j == kg.id {
+			continue
+		}
+		if in[j] == nil || len(in[j].V) != hashSize {
+			f.add(j, fmt.Errorf("missing commitment"))
+			continue
+		}
+		kg.hashes[j] = in[j].V
+	}
+	if err := f.err(); err != nil {
+		return nil, err
+	}
+	kg.round = 3
+	return &KeyGenRound2Bcast{
+		Rid:         kg.rid,
+		Commitments: kg.verifier.Commitments,
+		U:           kg.u,
+	}, nil
+}
+
+// Round3 opens the commitments of the other participants, agrees on the random identifier
+// and proves knowledge of the part of the key of this participant. The shares must be sent
+// to the other participants over private and authenticated channels.
+// [CGGMP21] fig 5: Round 3
+func (kg *KeyGen) Round3(in map[uint32]*KeyGenRound2Bcast) (*KeyGenRound3Bcast, map[uint32]*sharing.ShamirShare, error) {
+	if kg == nil || kg.curve == nil {
+		return nil, nil, internal.ErrNilArguments
+	}
+	if kg.round != 3 {
+		return nil, nil, internal.ErrInvalidRound
+	}
+	f := make(faults)
+	kg.commitments = make(map[uint32][]curves.Point, len(kg.ids))
+	kg.commitments[kg.id] = kg.verifier.Commitments
+	rid := append([]byte{}, kg.rid...)
+	for _, j := range kg.ids {
+		if j == kg.id {
+			continue
+		}
+		msg := in[j]
+		if msg == nil {
+			f.add(j, fmt.Errorf("missing message"))
+			continue
+		}
+		if len(msg.Rid) != hashSize || len(msg.U) != hashSize {
+			f.add(j, fmt.Errorf("invalid random identifier"))
+			continue
+		}
+		if err := checkCommitments(kg.curve, kg.threshold, msg.Commitments); err != nil {
+			f.add(j, err)
+			continue
+		}
+		// V_j = H(sid, j, rid_j, F_j, u_j)
+		v := keyGenHash(kg.sid, j, msg.Rid, msg.Commitments, msg.U)
+		if subtle.ConstantTimeCompare(v, kg.hashes[j]) != 1 {
+			f.add(j, fmt.Errorf("invalid decommitment"))
+			continue
+		}
+		kg.commitments[j] = msg.Commitments
+		// rid = ⊕ rid_j
+		for k := range rid {
+			rid[k] ^= msg.Rid[k]
+		}
+	}
+	if err := f.err(); err != nil {
+		return nil, nil, err
+	}
+	kg.sumRid = rid
+
+	// ψ_i = Π^sch(sid, i, rid; X_i; x_i)
+	prover := schnorr.NewProver(kg.curve, nil, proofSession(kg.sid, rid, kg.id))
+	proof, err := prover.Prove(kg.secret)
+	if err != nil {
+		return nil, nil, err
+	}
+	p2p := make(map[uint32]*sharing.ShamirShare, len(kg.ids)-1)
+	for _, j := range kg.ids {
+		if j != kg.id {
+			p2p[j] = kg.shares[j-1]
+		}
+	}
+	kg.round = 4
+	return &KeyGenRound3Bcast{Proof: proof}, p2p, nil
+}
+
+// Output verifies the proofs and the shares of the other participants and returns the key share
+// of this participant. The key share has no auxiliary info, run a key refresh before presigning.
+// [CGGMP21] fig 5: Output
+func (kg *KeyGen) Output(bcast map[uint32]*KeyGenRound3Bcast, p2p map[uint32]*sharing.ShamirShare) (*KeyShare, error) {
+	if kg == nil || kg.curve == nil {
+		return nil, internal.ErrNilArguments
+	}
+	if kg.round != 4 {
+		return nil, internal.ErrInvalidRound
+	}
+	f := make(faults)
+	sum, err := kg.curve.Scalar.SetBytes(kg.shares[kg.id-1].Value)
+	if err != nil {
+		return nil, err
+	}
+	for _, j := range kg.ids {
+		if j == kg.id {
+			continue
+		}
+		if bcast[j] == nil || bcast[j].Proof == nil || p2p[j] == nil {
+			f.add(j, fmt.Errorf("missing message"))
+			continue
+		}
+		// Verify ψ_j for X_j = F_j(0)
+		proof := bcast[j].Proof
+		if proof.C == nil || proof.S == nil || !onCurve(kg.curve, proof.Statement) ||
+			!proof.Statement.Equal(kg.commitments[j][0]) {
+			f.add(j, fmt.Errorf("invalid schnorr proof"))
+			continue
+		}
+		if err := schnorr.Verify(proof, kg.curve, nil, proofSession(kg.sid, kg.sumRid, j)); err != nil {
+			f.add(j, err)
+			continue
+		}
+		// The share of participant j for this participant must match F_j
+		if p2p[j].Id != kg.id {
+			f.add(j, fmt.Errorf("share has the wrong identifier"))
+			continue
+		}
+		verifier := &sharing.FeldmanVerifier{Commitments: kg.commitments[j]}
+		if err := verifier.Verify(p2p[j]); err != nil {
+			f.add(j, fmt.Errorf("invalid share: %v", err))
+			continue
+		}
+		value, err := kg.curve.Scalar.SetBytes(p2p[j].Value)
+		if err != nil {
+			f.add(j, err)
+			continue
+		}
+		sum = sum.Add(value)
+	}
+	if err := f.err(); err != nil {
+		return nil, err
+	}
+
+	// The public key and public shares follow from the sum of the Feldman commitments
+	commitments := make([]curves.Point, kg.threshold)
+	for k := range commitments {
+		commitments[k] = kg.curve.NewIdentityPoint()
+		for _, c := range kg.commitments {
+			commitments[k] = commitments[k].Add(c[k])
+		}
+	}
+	publicShares := make(map[uint32]curves.Point, len(kg.ids))
+	for _, j := range kg.ids {
+		publicShares[j] = evaluate(kg.curve, commitments, j)
+	}
+	if commitments[0].IsIdentity() || !publicShares[kg.id].Equal(kg.curve.ScalarBaseMult(sum)) {
+		return nil, fmt.Errorf("invalid key share")
+	}
+	kg.round = 5
+	return &KeyShare{
+		Curve:        kg.curve,
+		Id:           kg.id,
+		Threshold:    kg.threshold,
+		Share:        sum,
+		PublicKey:    commitments[0],
+		PublicShares: publicShares,
+		Rid:          kg.sumRid,
+	}, nil
+}
+
+// keyGenHash computes the commitment V = H(sid, id, rid, F, u) of round 1
+func keyGenHash(sid []byte, id uint32, rid []byte, commitments []curves.Point, u []byte) []byte {
+	t := newTranscript("keygen", sid, id)
+	t.t.AppendMessage([]byte("rid"), rid)
+	t.points(commitments...)
+	t.t.AppendMessage([]byte("u"), u)
+	return t.t.ExtractBytes([]byte("commitment"), hashSize)
+}
+
+// proofSession is the session id of a Schnorr proof of participant id
+func proofSession(sid, rid []byte, id uint32) []byte {
+	t := newTranscript("sch", sid, id)
+	t.t.AppendMessage([]byte("rid"), rid)
+	return t.t.ExtractBytes([]byte("session"), hashSize)
+}
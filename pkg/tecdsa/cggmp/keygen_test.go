@@ -0,0 +1,176 @@
+//
+// Copyright Coinbase, Inc. All Rights Reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package cggmp
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/nerifnetwork/kryptology/internal"
+	"github.com/nerifnetwork/kryptology/pkg/core/curves"
+	"github.com/nerifnetwork/kryptology/pkg/sharing"
+)
+
+// keyGenRound3 runs the key generation up to round 3 and returns the messages of round 3
+func keyGenRound3(t *testing.T, curve *curves.Curve, threshold, n uint32) (map[uint32]*KeyGen, map[uint32]*KeyGenRound3Bcast, map[uint32]map[uint32]*sharing.ShamirShare) {
+	ids := testIds(n)
+	participants := make(map[uint32]*KeyGen, n)
+	for _, id := range ids {
+		kg, err := NewKeyGen(curve, testSid, id, threshold, ids)
+		require.NoError(t, err)
+		participants[id] = kg
+	}
+	r1 := make(map[uint32]*KeyGenRound1Bcast, n)
+	for id, kg := range participants {
+		out, err := kg.Round1()
+		require.NoError(t, err)
+		r1[id] = out
+	}
+	r2 := make(map[uint32]*KeyGenRound2Bcast, n)
+	for id, kg := range participants {
+		out, err := kg.Round2(r1)
+		require.NoError(t, err)
+		r2[id] = out
+	}
+	r3 := make(map[uint32]*KeyGenRound3Bcast, n)
+	p2p := make(map[uint32]map[uint32]*sharing.ShamirShare, n)
+	for id, kg := range participants {
+		bcast, shares, err := kg.Round3(r2)
+		require.NoError(t, err)
+		r3[id] = bcast
+		for j, share := range shares {
+			if p2p[j] == nil {
+				p2p[j] = make(map[uint32]*sharing.ShamirShare, n)
+			}
+			p2p[j][id] = share
+		}
+	}
+	return participants, r3, p2p
+}
+
+// runKeyGen returns the key shares of a key generation without auxiliary info
+func runKeyGen(t *testing.T, curve *curves.Curve, threshold, n uint32) map[uint32]*KeyShare {
+	participants, r3, p2p := keyGenRound3(t, curve, threshold, n)
+	shares := make(map[uint32]*KeyShare, n)
+	for id, kg := range participants {
+		share, err := kg.Output(r3, p2p[id])
+		require.NoError(t, err)
+		shares[id] = share
+	}
+	return shares
+}
+
+func TestKeyGen(t *testing.T) {
+	for _, curve := range []*curves.Curve{curves.K256(), curves.P256()} {
+		shares := runKeyGen(t, curve, 2, 3)
+		publicKey := shares[1].PublicKey
+		for id, share := range shares {
+			require.Equal(t, id, share.Id)
+			require.True(t, publicKey.Equal(share.PublicKey))
+			require.Equal(t, shares[1].Rid, share.Rid)
+			require.Nil(t, share.Aux)
+			for j, publicShare := range share.PublicShares {
+				require.True(t, publicShare.Equal(curve.ScalarBaseMult(shares[j].Share)))
+			}
+		}
+		// Any two participants recover the secret key
+		for _, ids := range [][]uint32{{1, 2}, {1, 3}, {2, 3}} {
+			require.True(t, publicKey.Equal(curve.ScalarBaseMult(combineShares(t, shares, ids))))
+		}
+	}
+}
+
+func TestKeyGenInvalidArguments(t *testing.T) {
+	curve := curves.K256()
+	ids := testIds(3)
+	_, err := NewKeyGen(nil, testSid, 1, 2, ids)
+	require.Error(t, err)
+	_, err = NewKeyGen(curves.ED25519(), testSid, 1, 2, ids)
+	require.Error(t, err)
+	_, err = NewKeyGen(curve, nil, 1, 2, ids)
+	require.Error(t, err)
+	_, err = NewKeyGen(curve, testSid, 4, 2, ids)
+	require.Error(t, err)
+	_, err = NewKeyGen(curve, testSid, 1, 4, ids)
+	require.Error(t, err)
+	_, err = NewKeyGen(curve, testSid, 1, 2, []uint32{1, 2, 4})
+	require.Error(t, err)
+
+	kg, err := NewKeyGen(curve, testSid, 1, 2, ids)
+	require.NoError(t, err)
+	_, err = kg.Round2(nil)
+	require.Equal(t, internal.ErrInvalidRound, err)
+	_, _, err = kg.Round3(nil)
+	require.Equal(t, internal.ErrInvalidRound, err)
+	_, err = kg.Output(nil, nil)
+	require.Equal(t, internal.ErrInvalidRound, err)
+}
+
+func TestKeyGenRound2MissingCommitment(t *testing.T) {
+	ids := testIds(3)
+	participants := make(map[uint32]*KeyGen)
+	r1 := make(map[uint32]*KeyGenRound1Bcast)
+	for _, id := range ids {
+		kg, err := NewKeyGen(curves.K256(), testSid, id, 2, ids)
+		require.NoError(t, err)
+		participants[id] = kg
+		r1[id], err = kg.Round1()
+		require.NoError(t, err)
+	}
+	delete(r1, 3)
+	_, err := participants[1].Round2(r1)
+	requireCulprits(t, err, 3)
+}
+
+func TestKeyGenInvalidDecommitment(t *testing.T) {
+	curve := curves.K256()
+	ids := testIds(3)
+	participants := make(map[uint32]*KeyGen)
+	r1 := make(map[uint32]*KeyGenRound1Bcast)
+	for _, id := range ids {
+		kg, err := NewKeyGen(curve, testSid, id, 2, ids)
+		require.NoError(t, err)
+		participants[id] = kg
+		r1[id], err = kg.Round1()
+		require.NoError(t, err)
+	}
+	r2 := make(map[uint32]*KeyGenRound2Bcast)
+	for id, kg := range participants {
+		var err error
+		r2[id], err = kg.Round2(r1)
+		require.NoError(t, err)
+	}
+
+	// Participant 2 changes its commitments after round 1, participant 3 its random identifier
+	r2[2] = &KeyGenRound2Bcast{
+		Rid:         r2[2].Rid,
+		Commitments: []curves.Point{r2[2].Commitments[0].Double(), r2[2].Commitments[1]},
+		U:           r2[2].U,
+	}
+	rid := append([]byte{}, r2[3].Rid...)
+	rid[0] ^= 1
+	r2[3] = &KeyGenRound2Bcast{Rid: rid, Commitments: r2[3].Commitments, U: r2[3].U}
+	_, _, err := participants[1].Round3(r2)
+	requireCulprits(t, err, 2, 3)
+}
+
+func TestKeyGenInvalidProofAndShare(t *testing.T) {
+	curve := curves.K256()
+	participants, r3, p2p := keyGenRound3(t, curve, 2, 3)
+
+	// Participant 2 proves knowledge of another key and participant 3 sends a wrong share
+	_, r3Other, _ := keyGenRound3(t, curve, 2, 3)
+	r3[2] = r3Other[2]
+	p2p[1][3] = &sharing.ShamirShare{Id: 1, Value: p2p[2][3].Value}
+	_, err := participants[1].Output(r3, p2p[1])
+	requireCulprits(t, err, 2, 3)
+
+	// The others do not blame participant 3
+	_, err = participants[3].Output(r3, p2p[3])
+	requireCulprits(t, err, 2)
+}
@@ -0,0 +1,182 @@
+//
+// Copyright Coinbase, Inc. All Rights Reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+// Package cggmp implements the t-of-n threshold ECDSA protocol of
+// [CGGMP21] https://eprint.iacr.org/2021/060.pdf with key generation, key refresh with
+// auxiliary info, three round presigning and one round signing. Every message carries
+// zero-knowledge proofs, so a failed check identifies the participant that sent it.
+//
+// The key is Shamir shared with Feldman commitments. The signers of a presignature turn
+// their shares into additive shares of the key with Lagrange coefficients and run the
+// n-out-of-n presigning of [CGGMP21] fig 7 among themselves.
+package cggmp
+
+import (
+	"fmt"
+	"math/big"
+	"sort"
+	"strings"
+
+	"github.com/nerifnetwork/kryptology/internal"
+	"github.com/nerifnetwork/kryptology/pkg/core/curves"
+	"github.com/nerifnetwork/kryptology/pkg/paillier"
+)
+
+const (
+	// l, lPrime and epsilon are ℓ, ℓ' and ε of the range proofs of [CGGMP21] for 256 bit curves
+	l       = 256
+	lPrime  = 5 * l
+	epsilon = 2 * l
+	// minPaillierBits is the smallest accepted Paillier modulus
+	minPaillierBits = 2*paillier.PaillierPrimeBits - 1
+)
+
+// KeyShare is the share of the ECDSA key of a participant
+type KeyShare struct {
+	Curve     *curves.Curve
+	Id        uint32
+	Threshold uint32
+	// Share is the Shamir share of the secret key of this participant
+	Share curves.Scalar
+	// PublicKey is the ECDSA public key
+	PublicKey curves.Point
+	// PublicShares are g^share of all participants including this one
+	PublicShares map[uint32]curves.Point
+	// Rid is the random identifier that all participants agreed on in the key generation
+	Rid []byte
+	// Aux is set by the key refresh and needed for presigning
+	Aux *AuxInfo
+}
+
+// AuxInfo holds the Paillier keys and ring-Pedersen parameters of the participants
+type AuxInfo struct {
+	// SecretKey is the Paillier secret key of this participant
+	SecretKey  *paillier.SecretKey
+	PublicKeys map[uint32]*paillier.PublicKey
+	Pedersen   map[uint32]*PedersenParams
+}
+
+// AbortError is returned when messages from other participants fail the checks of a round.
+// Every message carries the proofs that the checks need, so all honest recipients blame
+// the same participants.
+type AbortError struct {
+	// Culprits are the participants that sent invalid or no messages in ascending order
+	Culprits []uint32
+	Reasons  map[uint32]error
+}
+
+func (e *AbortError) Error() string {
+	reasons := make([]string, len(e.Culprits))
+	for i, id := range e.Culprits {
+		reasons[i] = fmt.Sprintf("participant %d: %v", id, e.Reasons[id])
+	}
+	return fmt.Sprintf("protocol aborted by %s", strings.Join(reasons, ", "))
+}
+
+// faults collects the participants that failed the checks of a round
+type faults map[uint32]error
+
+func (f faults) add(id uint32, err error) {
+	if _, ok := f[id]; !ok {
+		f[id] = err
+	}
+}
+
+// err returns an AbortError for the collected faults or nil
+func (f faults) err() error {
+	if len(f) == 0 {
+		return nil
+	}
+	e := &AbortError{Reasons: f}
+	for id := range f {
+		e.Culprits = append(e.Culprits, id)
+	}
+	sortIds(e.Culprits)
+	return e
+}
+
+// sortIds sorts ids in ascending order
+func sortIds(ids []uint32) {
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+}
+
+// validIds checks that the ids are 1, 2, ..., n
+func validIds(ids []uint32) error {
+	seen := make(map[uint32]bool, len(ids))
+	for _, id := range ids {
+		if id == 0 || id > uint32(len(ids)) || seen[id] {
+			return fmt.Errorf("the ID list %v is invalid. Values must be 1,2,..,n", ids)
+		}
+		seen[id] = true
+	}
+	return nil
+}
+
+// checkCurve accepts the curves with a 256 bit order that ECDSA supports
+func checkCurve(curve *curves.Curve) error {
+	if curve == nil {
+		return internal.ErrNilArguments
+	}
+	if _, err := curve.ToEllipticCurve(); err != nil {
+		return err
+	}
+	if order(curve).BitLen() != l {
+		return fmt.Errorf("curve order must have %d bits", l)
+	}
+	return nil
+}
+
+// order returns the order q of the curve
+func order(curve *curves.Curve) *big.Int {
+	// -1 mod q
+	q := curve.Scalar.One().Neg().BigInt()
+	return q.Add(q, big.NewInt(1))
+}
+
+// scalar returns x mod q as a scalar, x can be negative
+func scalar(curve *curves.Curve, x *big.Int) curves.Scalar {
+	s, _ := curve.Scalar.SetBigInt(new(big.Int).Mod(x, order(curve)))
+	return s
+}
+
+// onCurve checks that p is a point of the curve
+func onCurve(curve *curves.Curve, p curves.Point) bool {
+	return p != nil && p.CurveName() == curve.Name && p.IsOnCurve()
+}
+
+// evaluate computes Σ commitments[k] x^k, the public share of x for Feldman commitments
+func evaluate(curve *curves.Curve, commitments []curves.Point, x uint32) curves.Point {
+	xs := curve.Scalar.New(int(x))
+	result := curve.NewIdentityPoint()
+	for k := len(commitments) - 1; k >= 0; k-- {
+		result = result.Mul(xs).Add(commitments[k])
+	}
+	return result
+}
+
+// checkCommitments makes sure the Feldman commitments of a participant are well formed
+func checkCommitments(curve *curves.Curve, threshold uint32, commitments []curves.Point) error {
+	if uint32(len(commitments)) != threshold {
+		return fmt.Errorf("invalid number of commitments")
+	}
+	for _, c := range commitments {
+		if !onCurve(curve, c) {
+			return fmt.Errorf("commitment is not on the curve")
+		}
+	}
+	return nil
+}
+
+// checkPaillierKey makes sure the Paillier modulus of a participant has the expected size
+func checkPaillierKey(n *big.Int) error {
+	if n == nil {
+		return internal.ErrNilArguments
+	}
+	if n.BitLen() < minPaillierBits {
+		return fmt.Errorf("paillier modulus is too small")
+	}
+	return nil
+}
@@ -0,0 +1,98 @@
+//
+// Copyright Coinbase, Inc. All Rights Reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package cggmp
+
+import (
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	tt "github.com/nerifnetwork/kryptology/internal"
+	"github.com/nerifnetwork/kryptology/pkg/core/curves"
+	"github.com/nerifnetwork/kryptology/pkg/paillier"
+	"github.com/nerifnetwork/kryptology/pkg/sharing"
+)
+
+// testPrimes are 1024 bit safe primes, generating them takes too long for tests
+var testPrimes = []*big.Int{
+	tt.B10("94210786053667323206442523040419729883258172350738703980637961803118626748668924192069593010365236618255120977661397310932923345291377692570649198560048403943687994859423283474169530971418656709749020402756179383990602363122039939937953514870699284906666247063852187255623958659551404494107714695311474384687"),
+	tt.B10("130291226847076770981564372061529572170236135412763130013877155698259035960569046218348763182598589633420963942796327547969527085797839549642610021986391589746295634536750785366034581957858065740296991986002552598751827526181747791647357767502200771965093659353354985289411489453223546075843993686648576029043"),
+	tt.B10("172938910323633442195852028319756134734590277522945546987913328782597284762767185925315797321999389252040294991952361905020940252121762387957669654615602135429944435719699091344247805645764550860505536884031064967454028383404046221898300153428182409080298694828920944094158777327533157774919783417586902830043"),
+	tt.B10("135841191929788643010555393808775051922265083622266098277752143441294911675705272940799534437169053045878247274810449617960047255023823301284034559807472662111224710158898548617194658983006262996831617082584649612602010680423107108651221824216065228161009680618243402116924511141821829055830713600437589058643"),
+	tt.B10("179677777376220950493907657233669314916823596507009854134559513388779535023958212632715646194917807302098015450071151245496651913873851032302340489007561121851068326577148680474495447007833318066335149850926605897908761267606415610900931306044455332084757793630487163583451178807470499389106913845684353833379"),
+	tt.B10("147653127360336844448178027222853805809444645720500374788954343695331927468524513989671450440433430392339037667457657655958027740671071573403925974795764987870476118984896439440386146680643457835633462311776946902713168513155240275028008685964121441954481847113848701823211862974120297600518927026940189810103"),
+	tt.B10("167562983031509383478485987630533113343120902430985961468758712448125734458812918541051012669749885569679178971612428577288632429606851871845164719448590160530844833425628143996971699662729056519326776907622035340086832629206691942750594912221135787534670122007438859975313187460872690748138136170080913902203"),
+	tt.B10("151715609132228776595716500435665208768897792993205818803431003524953811539898459230192282642811956896879836518212758893685104146944932088195466999437630114129887975508715417094019351746027667352287673763064246395392591213231796089814648654152625331299642171758052545451706130433176935280325874961374276589763"),
+}
+
+// testSecretKey returns a Paillier key of the fixtures for participant id
+func testSecretKey(t *testing.T, id uint32) *paillier.SecretKey {
+	i := int(id-1) % (len(testPrimes) / 2)
+	sk, err := paillier.NewSecretKey(testPrimes[2*i], testPrimes[2*i+1])
+	require.NoError(t, err)
+	return sk
+}
+
+func testIds(n uint32) []uint32 {
+	ids := make([]uint32, n)
+	for i := range ids {
+		ids[i] = uint32(i + 1)
+	}
+	return ids
+}
+
+// requireCulprits checks that err is an AbortError that blames exactly the culprits
+func requireCulprits(t *testing.T, err error, culprits ...uint32) {
+	require.Error(t, err)
+	var abort *AbortError
+	require.True(t, errors.As(err, &abort), err.Error())
+	require.Equal(t, culprits, abort.Culprits)
+}
+
+// combineShares recovers the secret key from the key shares with the given ids
+func combineShares(t *testing.T, shares map[uint32]*KeyShare, ids []uint32) curves.Scalar {
+	var curve *curves.Curve
+	var threshold uint32
+	ss := make([]*sharing.ShamirShare, 0, len(ids))
+	for _, id := range ids {
+		curve = shares[id].Curve
+		threshold = shares[id].Threshold
+		ss = append(ss, &sharing.ShamirShare{Id: id, Value: shares[id].Share.Bytes()})
+	}
+	shamir, err := sharing.NewShamir(threshold, uint32(len(shares)), curve)
+	require.NoError(t, err)
+	secret, err := shamir.Combine(ss...)
+	require.NoError(t, err)
+	return secret
+}
+
+func TestAbortErrorListsCulprits(t *testing.T) {
+	f := make(faults)
+	require.NoError(t, f.err())
+	f.add(3, errors.New("bad proof"))
+	f.add(1, errors.New("missing message"))
+	f.add(3, errors.New("ignored"))
+	err := f.err()
+	requireCulprits(t, err, 1, 3)
+	require.Equal(t, "protocol aborted by participant 1: missing message, participant 3: bad proof", err.Error())
+}
+
+func TestValidIds(t *testing.T) {
+	require.NoError(t, validIds([]uint32{2, 1, 3}))
+	require.Error(t, validIds([]uint32{0, 1}))
+	require.Error(t, validIds([]uint32{1, 3}))
+	require.Error(t, validIds([]uint32{1, 1}))
+}
+
+func TestCheckCurve(t *testing.T) {
+	require.NoError(t, checkCurve(curves.K256()))
+	require.NoError(t, checkCurve(curves.P256()))
+	require.Error(t, checkCurve(curves.ED25519()))
+	require.Error(t, checkCurve(nil))
+}
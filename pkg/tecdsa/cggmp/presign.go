@@ -0,0 +1,490 @@
+//
+// Copyright Coinbase, Inc. All Rights Reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package cggmp
+
+import (
+	crand "crypto/rand"
+	"fmt"
+	"math/big"
+
+	"github.com/nerifnetwork/kryptology/internal"
+	"github.com/nerifnetwork/kryptology/pkg/core/curves"
+	"github.com/nerifnetwork/kryptology/pkg/sharing"
+)
+
+// Presigner is a signer of the three round presigning
+// [CGGMP21] fig 7, with the Lagrange weighted key shares w_i of the signers as additive shares of the key
+type Presigner struct {
+	share   *KeyShare
+	sid     []byte
+	signers []uint32
+	round   uint
+	// w is the additive share of this signer and W are g^w of all signers
+	w curves.Scalar
+	W map[uint32]curves.Point
+	// Round 1 values of this signer
+	k, gamma      curves.Scalar
+	rho, nu       *big.Int
+	bigK, bigG    map[uint32]*big.Int
+	bigGamma      map[uint32]curves.Point
+	beta, betaHat map[uint32]*big.Int
+	// Round 3 values
+	delta, chi curves.Scalar
+	sumGamma   curves.Point
+	bigDelta   curves.Point
+	// The broadcasts of all signers that the abort needs
+	round2 map[uint32]*PresignRound2Bcast
+	round3 map[uint32]*PresignRound3Bcast
+}
+
+// ErrPresignAbort is returned by Output when the shares of δ or Ŝ of the signers are inconsistent.
+// The signers then run Abort and Identify to find the culprit.
+var ErrPresignAbort = fmt.Errorf("inconsistent presignature shares")
+
+// PresignRound1Bcast contains the encrypted nonce shares k_i and γ_i of a signer
+type PresignRound1Bcast struct {
+	K, G *big.Int
+}
+
+// PresignRound1P2PSend proves to a signer that K is in range
+type PresignRound1P2PSend struct {
+	Enc *EncProof
+}
+
+// PresignRound2Bcast reveals Γ_i = g^γ_i of a signer and contains the multiplicative to additive
+// conversions of k_j γ_i and k_j w_i for every other signer j. The conversions are broadcast
+// so that all signers can check the shares of an abort.
+type PresignRound2Bcast struct {
+	Gamma            curves.Point
+	D, F, DHat, FHat map[uint32]*big.Int
+}
+
+// PresignRound2P2PSend proves to a signer j that its conversions are well formed
+type PresignRound2P2PSend struct {
+	Affg, AffgHat *AffgProof
+	LogStar       *LogStarProof
+}
+
+// PresignRound3Bcast reveals the shares δ_i of kγ, Δ_i = Γ^k_i and Ŝ_i = Γ^χ_i of a signer
+type PresignRound3Bcast struct {
+	Delta    curves.Scalar
+	BigDelta curves.Point
+	SHat     curves.Point
+}
+
+// PresignRound3P2PSend proves to a signer that Δ_i is consistent with K_i
+type PresignRound3P2PSend struct {
+	LogStar *LogStarProof
+}
+
+// NewPresigner creates a signer of a presignature with a refreshed key share. The session id must be
+// unique and known to all signers, and signers are the ids of at least a threshold of participants
+// including this one.
+func NewPresigner(share *KeyShare, sid []byte, signers []uint32) (*Presigner, error) {
+	if share == nil || share.Share == nil || share.PublicKey == nil || len(sid) == 0 {
+		return nil, internal.ErrNilArguments
+	}
+	if share.Aux == nil || share.Aux.SecretKey == nil {
+		return nil, fmt.Errorf("key share has no auxiliary info, refresh it first")
+	}
+	if err := checkCurve(share.Curve); err != nil {
+		return nil, err
+	}
+	if uint32(len(signers)) < share.Threshold {
+		return nil, internal.ErrIncorrectCount
+	}
+	ids := append([]uint32{}, signers...)
+	sortIds(ids)
+	self := false
+	for i, id := range ids {
+		if i > 0 && ids[i-1] == id {
+			return nil, fmt.Errorf("duplicate signer %d", id)
+		}
+		if share.PublicShares[id] == nil || share.Aux.PublicKeys[id] == nil || share.Aux.Pedersen[id] == nil {
+			return nil, fmt.Errorf("unknown signer %d", id)
+		}
+		self = self || id == share.Id
+	}
+	if !self {
+		return nil, fmt.Errorf("signers do not include %d", share.Id)
+	}
+
+	// w_j = λ_j x_j is an additive share of the key among the signers
+	shamir, err := sharing.NewShamir(share.Threshold, uint32(len(share.PublicShares)), share.Curve)
+	if err != nil {
+		return nil, err
+	}
+	lambdas, err := shamir.LagrangeCoeffs(ids)
+	if err != nil {
+		return nil, err
+	}
+	W := make(map[uint32]curves.Point, len(ids))
+	for _, id := range ids {
+		W[id] = share.PublicShares[id].Mul(lambdas[id])
+	}
+	return &Presigner{
+		share:   share,
+		sid:     append(append([]byte{}, sid...), share.Rid...),
+		signers: ids,
+		round:   1,
+		w:       share.Share.Mul(lambdas[share.Id]),
+		W:       W,
+	}, nil
+}
+
+// Round1 encrypts the nonce shares of this signer and proves to every other signer that they are in range
+// [CGGMP21] fig 7: Round 1
+func (p *Presigner) Round1() (*PresignRound1Bcast, map[uint32]*PresignRound1P2PSend, error) {
+	if p == nil || p.share == nil {
+		return nil, nil, internal.ErrNilArguments
+	}
+	if p.round != 1 {
+		return nil, nil, internal.ErrInvalidRound
+	}
+	curve := p.share.Curve
+	aux := p.share.Aux
+	pk := &aux.SecretKey.PublicKey
+
+	// K_i = enc_i(k_i; ρ_i), G_i = enc_i(γ_i; ν_i)
+	var err error
+	p.k = curve.Scalar.Random(crand.Reader)
+	p.gamma = curve.Scalar.Random(crand.Reader)
+	if p.rho, err = randUnit(pk.N); err != nil {
+		return nil, nil, err
+	}
+	if p.nu, err = randUnit(pk.N); err != nil {
+		return nil, nil, err
+	}
+	bcast := &PresignRound1Bcast{
+		K: encrypt(pk, p.k.BigInt(), p.rho),
+		G: encrypt(pk, p.gamma.BigInt(), p.nu),
+	}
+
+	// ψ0_{j,i} = Π^enc_j(K_i; k_i, ρ_i)
+	p2p := make(map[uint32]*PresignRound1P2PSend, len(p.signers)-1)
+	for _, j := range p.signers {
+		if j == p.share.Id {
+			continue
+		}
+		proof, err := proveEnc(p.sid, p.share.Id, order(curve), pk, aux.Pedersen[j], bcast.K, p.k.BigInt(), p.rho)
+		if err != nil {
+			return nil, nil, err
+		}
+		p2p[j] = &PresignRound1P2PSend{Enc: proof}
+	}
+	p.bigK = map[uint32]*big.Int{p.share.Id: bcast.K}
+	p.bigG = map[uint32]*big.Int{p.share.Id: bcast.G}
+	p.round = 2
+	return bcast, p2p, nil
+}
+
+// Round2 checks the range proofs of the other signers and converts k_j γ_i and k_j w_i to additive shares
+// [CGGMP21] fig 7: Round 2
+func (p *Presigner) Round2(bcast map[uint32]*PresignRound1Bcast, p2p map[uint32]*PresignRound1P2PSend) (*PresignRound2Bcast, map[uint32]*PresignRound2P2PSend, error) {
+	if p == nil || p.share == nil {
+		return nil, nil, internal.ErrNilArguments
+	}
+	if p.round != 2 {
+		return nil, nil, internal.ErrInvalidRound
+	}
+	curve := p.share.Curve
+	aux := p.share.Aux
+	q := order(curve)
+	f := make(faults)
+	for _, j := range p.signers {
+		if j == p.share.Id {
+			continue
+		}
+		if bcast[j] == nil || p2p[j] == nil || bcast[j].G == nil {
+			f.add(j, fmt.Errorf("missing message"))
+			continue
+		}
+		pk := aux.PublicKeys[j]
+		if !isUnit(bcast[j].G, pk.N2) {
+			f.add(j, fmt.Errorf("invalid ciphertext"))
+			continue
+		}
+		if err := p2p[j].Enc.Verify(p.sid, j, q, pk, aux.Pedersen[p.share.Id], bcast[j].K); err != nil {
+			f.add(j, err)
+			continue
+		}
+		p.bigK[j] = bcast[j].K
+		p.bigG[j] = bcast[j].G
+	}
+	if err := f.err(); err != nil {
+		return nil, nil, err
+	}
+
+	pki := &aux.SecretKey.PublicKey
+	gammaPoint := curve.ScalarBaseMult(p.gamma)
+	p.beta = make(map[uint32]*big.Int, len(p.signers)-1)
+	p.betaHat = make(map[uint32]*big.Int, len(p.signers)-1)
+	bcastOut := &PresignRound2Bcast{
+		Gamma: gammaPoint,
+		D:     make(map[uint32]*big.Int, len(p.signers)-1),
+		F:     make(map[uint32]*big.Int, len(p.signers)-1),
+		DHat:  make(map[uint32]*big.Int, len(p.signers)-1),
+		FHat:  make(map[uint32]*big.Int, len(p.signers)-1),
+	}
+	out := make(map[uint32]*PresignRound2P2PSend, len(p.signers)-1)
+	for _, j := range p.signers {
+		if j == p.share.Id {
+			continue
+		}
+		msg := new(PresignRound2P2PSend)
+		var err error
+		// D_{j,i} = K_j^γ_i enc_j(-β_{i,j}; s), F_{j,i} = enc_i(-β_{i,j}; r) and the same for w_i
+		if bcastOut.D[j], bcastOut.F[j], msg.Affg, p.beta[j], err = p.convert(j, p.gamma, gammaPoint); err != nil {
+			return nil, nil, err
+		}
+		if bcastOut.DHat[j], bcastOut.FHat[j], msg.AffgHat, p.betaHat[j], err = p.convert(j, p.w, p.W[p.share.Id]); err != nil {
+			return nil, nil, err
+		}
+		// ψ'_{j,i} = Π^log*_j((G_i, Γ_i); (γ_i, ν_i))
+		if msg.LogStar, err = proveLogStar(p.sid, p.share.Id, curve, pki, aux.Pedersen[j],
+			p.bigG[p.share.Id], gammaPoint, curve.Point.Generator(), p.gamma.BigInt(), p.nu); err != nil {
+			return nil, nil, err
+		}
+		out[j] = msg
+	}
+	p.round2 = map[uint32]*PresignRound2Bcast{p.share.Id: bcastOut}
+	p.round = 3
+	return bcastOut, out, nil
+}
+
+// convert computes D = K_j^x enc_j(-β; s) and F = enc_i(-β; r) with the affine operation proof
+// for signer j and returns β
+func (p *Presigner) convert(j uint32, x curves.Scalar, X curves.Point) (D, F *big.Int, proof *AffgProof, beta *big.Int, err error) {
+	aux := p.share.Aux
+	pkj := aux.PublicKeys[j]
+	pki := &aux.SecretKey.PublicKey
+	// β <- ±2^ℓ'
+	if beta, err = randSigned(lPrime, big.NewInt(1)); err != nil {
+		return nil, nil, nil, nil, err
+	}
+	s, err := randUnit(pkj.N)
+	if err != nil {
+		return nil, nil, nil, nil, err
+	}
+	r, err := randUnit(pki.N)
+	if err != nil {
+		return nil, nil, nil, nil, err
+	}
+	y := new(big.Int).Neg(beta)
+	D = new(big.Int).Exp(p.bigK[j], x.BigInt(), pkj.N2)
+	D.Mul(D, encrypt(pkj, y, s))
+	D.Mod(D, pkj.N2)
+	F = encrypt(pki, y, r)
+	st := &affgStatement{pk0: pkj, pk1: pki, C: p.bigK[j], D: D, Y: F, X: X}
+	proof, err = proveAffg(p.sid, p.share.Id, p.share.Curve, aux.Pedersen[j], st,
+		&affgWitness{x: x.BigInt(), y: y, rho: s, rhoY: r})
+	if err != nil {
+		return nil, nil, nil, nil, err
+	}
+	return D, F, proof, beta, nil
+}
+
+// Round3 checks the conversions of the other signers and computes the shares δ_i of kγ and χ_i of kx
+// [CGGMP21] fig 7: Round 3
+func (p *Presigner) Round3(bcast map[uint32]*PresignRound2Bcast, p2p map[uint32]*PresignRound2P2PSend) (*PresignRound3Bcast, map[uint32]*PresignRound3P2PSend, error) {
+	if p == nil || p.share == nil {
+		return nil, nil, internal.ErrNilArguments
+	}
+	if p.round != 3 {
+		return nil, nil, internal.ErrInvalidRound
+	}
+	curve := p.share.Curve
+	aux := p.share.Aux
+	sk := aux.SecretKey
+	ped := aux.Pedersen[p.share.Id]
+	pki := &sk.PublicKey
+
+	// δ_i = γ_i k_i + Σ (α_{i,j} + β_{i,j}), χ_i = w_i k_i + Σ (α̂_{i,j} + β̂_{i,j})
+	delta := new(big.Int).Mul(p.gamma.BigInt(), p.k.BigInt())
+	chi := new(big.Int).Mul(p.w.BigInt(), p.k.BigInt())
+	p.bigGamma = map[uint32]curves.Point{p.share.Id: curve.ScalarBaseMult(p.gamma)}
+	f := make(faults)
+	for _, j := range p.signers {
+		if j == p.share.Id {
+			continue
+		}
+		in := p2p[j]
+		if bcast[j] == nil || !onCurve(curve, bcast[j].Gamma) || in == nil || !bcast[j].complete(p.signers, j) {
+			f.add(j, fmt.Errorf("missing message"))
+			continue
+		}
+		pkj := aux.PublicKeys[j]
+		D, F := bcast[j].D[p.share.Id], bcast[j].F[p.share.Id]
+		DHat, FHat := bcast[j].DHat[p.share.Id], bcast[j].FHat[p.share.Id]
+		// ψ_{i,j}, ψ̂_{i,j} and ψ'_{i,j}
+		if err := in.Affg.verify(p.sid, j, curve, ped, &affgStatement{
+			pk0: pki, pk1: pkj, C: p.bigK[p.share.Id], D: D, Y: F, X: bcast[j].Gamma,
+		}); err != nil {
+			f.add(j, err)
+			continue
+		}
+		if err := in.AffgHat.verify(p.sid, j, curve, ped, &affgStatement{
+			pk0: pki, pk1: pkj, C: p.bigK[p.share.Id], D: DHat, Y: FHat, X: p.W[j],
+		}); err != nil {
+			f.add(j, err)
+			continue
+		}
+		if err := in.LogStar.Verify(p.sid, j, curve, pkj, ped, p.bigG[j], bcast[j].Gamma, curve.Point.Generator()); err != nil {
+			f.add(j, err)
+			continue
+		}
+		alpha, err := decrypt(sk, D)
+		if err != nil {
+			f.add(j, err)
+			continue
+		}
+		alphaHat, err := decrypt(sk, DHat)
+		if err != nil {
+			f.add(j, err)
+			continue
+		}
+		// k_i γ_j - β_{j,i} of an honest signer is below 2^{ℓ'+1}, which bounds the
+		// shares for the decryption proofs of an abort
+		if !inRange(alpha, lPrime+1) || !inRange(alphaHat, lPrime+1) {
+			f.add(j, fmt.Errorf("conversion is out of range"))
+			continue
+		}
+		delta.Add(delta, alpha).Add(delta, p.beta[j])
+		chi.Add(chi, alphaHat).Add(chi, p.betaHat[j])
+		p.bigGamma[j] = bcast[j].Gamma
+		p.round2[j] = bcast[j]
+	}
+	if err := f.err(); err != nil {
+		return nil, nil, err
+	}
+	p.delta = scalar(curve, delta)
+	p.chi = scalar(curve, chi)
+
+	// Γ = Σ Γ_j, Δ_i = Γ^k_i, Ŝ_i = Γ^χ_i
+	p.sumGamma = curve.NewIdentityPoint()
+	for _, j := range p.signers {
+		p.sumGamma = p.sumGamma.Add(p.bigGamma[j])
+	}
+	p.bigDelta = p.sumGamma.Mul(p.k)
+
+	// ψ''_{j,i} = Π^log*_j((K_i, Δ_i); (k_i, ρ_i)) to the base Γ
+	out := make(map[uint32]*PresignRound3P2PSend, len(p.signers)-1)
+	for _, j := range p.signers {
+		if j == p.share.Id {
+			continue
+		}
+		proof, err := proveLogStar(p.sid, p.share.Id, curve, pki, aux.Pedersen[j],
+			p.bigK[p.share.Id], p.bigDelta, p.sumGamma, p.k.BigInt(), p.rho)
+		if err != nil {
+			return nil, nil, err
+		}
+		out[j] = &PresignRound3P2PSend{LogStar: proof}
+	}
+	bcastOut := &PresignRound3Bcast{
+		Delta:    p.delta,
+		BigDelta: p.bigDelta,
+		SHat:     p.sumGamma.Mul(p.chi),
+	}
+	p.round3 = map[uint32]*PresignRound3Bcast{p.share.Id: bcastOut}
+	p.round = 4
+	return bcastOut, out, nil
+}
+
+// complete checks that the broadcast has conversions for all other signers
+func (b *PresignRound2Bcast) complete(signers []uint32, sender uint32) bool {
+	for _, j := range signers {
+		if j != sender && (b.D[j] == nil || b.F[j] == nil || b.DHat[j] == nil || b.FHat[j] == nil) {
+			return false
+		}
+	}
+	return true
+}
+
+// Output checks the last proofs of the other signers and returns the presignature of this signer
+// [CGGMP21] fig 7: Output
+//
+// A signer that sends a wrong δ_j or Ŝ_j fails the checks of g^δ and Σ S_j without being identified.
+// Output then returns ErrPresignAbort, and Abort and Identify find the culprit.
+func (p *Presigner) Output(bcast map[uint32]*PresignRound3Bcast, p2p map[uint32]*PresignRound3P2PSend) (*Presignature, error) {
+	if p == nil || p.share == nil {
+		return nil, internal.ErrNilArguments
+	}
+	if p.round != 4 {
+		return nil, internal.ErrInvalidRound
+	}
+	curve := p.share.Curve
+	aux := p.share.Aux
+	ped := aux.Pedersen[p.share.Id]
+	f := make(faults)
+	sumDelta := p.delta
+	bigDelta := map[uint32]curves.Point{p.share.Id: p.bigDelta}
+	sHat := map[uint32]curves.Point{p.share.Id: p.sumGamma.Mul(p.chi)}
+	for _, j := range p.signers {
+		if j == p.share.Id {
+			continue
+		}
+		in := bcast[j]
+		if in == nil || in.Delta == nil || !onCurve(curve, in.BigDelta) || !onCurve(curve, in.SHat) || p2p[j] == nil {
+			f.add(j, fmt.Errorf("missing message"))
+			continue
+		}
+		if err := p2p[j].LogStar.Verify(p.sid, j, curve, aux.PublicKeys[j], ped, p.bigK[j], in.BigDelta, p.sumGamma); err != nil {
+			f.add(j, err)
+			continue
+		}
+		sumDelta = sumDelta.Add(in.Delta)
+		bigDelta[j] = in.BigDelta
+		sHat[j] = in.SHat
+		p.round3[j] = in
+	}
+	if err := f.err(); err != nil {
+		return nil, err
+	}
+
+	// g^δ = Σ Δ_j
+	sum := curve.NewIdentityPoint()
+	for _, j := range p.signers {
+		sum = sum.Add(bigDelta[j])
+	}
+	if !curve.ScalarBaseMult(sumDelta).Equal(sum) {
+		return nil, p.abort()
+	}
+	deltaInv, err := sumDelta.Invert()
+	if err != nil {
+		return nil, err
+	}
+
+	// R = Γ^δ^-1, K̃_j = Δ_j^δ^-1 = R^k_j and S_j = Ŝ_j^δ^-1 = R^χ_j
+	presig := &Presignature{
+		Curve:     curve,
+		SignerId:  p.share.Id,
+		Signers:   p.signers,
+		PublicKey: p.share.PublicKey,
+		R:         p.sumGamma.Mul(deltaInv),
+		K:         p.k,
+		Chi:       p.chi,
+		BigK:      make(map[uint32]curves.Point, len(p.signers)),
+		S:         make(map[uint32]curves.Point, len(p.signers)),
+	}
+	sumS := curve.NewIdentityPoint()
+	for _, j := range p.signers {
+		presig.BigK[j] = bigDelta[j].Mul(deltaInv)
+		presig.S[j] = sHat[j].Mul(deltaInv)
+		sumS = sumS.Add(presig.S[j])
+	}
+	if !sumS.Equal(p.share.PublicKey) {
+		return nil, p.abort()
+	}
+	if presig.R.IsIdentity() {
+		return nil, fmt.Errorf("invalid nonce")
+	}
+
+	// The signer must not use k_i or χ_i again
+	p.k, p.chi = nil, nil
+	p.round = 5
+	return presig, nil
+}
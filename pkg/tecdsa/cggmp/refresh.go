@@ -0,0 +1,387 @@
+//
+// Copyright Coinbase, Inc. All Rights Reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package cggmp
+
+import (
+	crand "crypto/rand"
+	"crypto/subtle"
+	"fmt"
+	"math/big"
+
+	"github.com/nerifnetwork/kryptology/internal"
+	"github.com/nerifnetwork/kryptology/pkg/core/curves"
+	"github.com/nerifnetwork/kryptology/pkg/paillier"
+	"github.com/nerifnetwork/kryptology/pkg/sharing"
+)
+
+// Refresh is a participant of the key refresh that re-randomizes the key shares and
+// generates new Paillier keys and ring-Pedersen parameters for all participants
+// [CGGMP21] fig 6, where the participants Feldman share zero instead of adding random additive shares
+type Refresh struct {
+	share *KeyShare
+	sid   []byte
+	ids   []uint32
+	round uint
+	// Round 1 values of this participant
+	sk          *paillier.SecretKey
+	ped         *PedersenParams
+	prm         *PrmProof
+	poly        *sharing.Polynomial
+	commitments []curves.Point
+	u           []byte
+	// Values of the other participants
+	hashes         map[uint32][]byte
+	publicKeys     map[uint32]*paillier.PublicKey
+	pedersen       map[uint32]*PedersenParams
+	allCommitments map[uint32][]curves.Point
+}
+
+// RefreshRound1Bcast commits to the values of round 2
+type RefreshRound1Bcast struct {
+	V []byte
+}
+
+// RefreshRound2Bcast reveals the new Paillier modulus and ring-Pedersen parameters of a participant
+// and the Feldman commitments to the coefficients 1, ..., t-1 of its zero sharing
+type RefreshRound2Bcast struct {
+	Pedersen    *PedersenParams
+	Prm         *PrmProof
+	Commitments []curves.Point
+	U           []byte
+}
+
+// RefreshRound3Bcast proves that the Paillier modulus of a participant is a Paillier-Blum modulus
+type RefreshRound3Bcast struct {
+	Mod *paillier.ModProof
+}
+
+// RefreshRound3P2PSend contains the zero share for a participant encrypted under its new Paillier key
+// and proves that the Paillier modulus of the sender has no small factors
+type RefreshRound3P2PSend struct {
+	Fac   *paillier.FacProof
+	Share *big.Int
+}
+
+// NewRefresh creates a participant of the key refresh for a key share.
+// All participants of the key generation take part with the same unique session id.
+func NewRefresh(share *KeyShare, sid []byte) (*Refresh, error) {
+	if share == nil || share.Share == nil || share.PublicKey == nil || len(sid) == 0 {
+		return nil, internal.ErrNilArguments
+	}
+	if err := checkCurve(share.Curve); err != nil {
+		return nil, err
+	}
+	ids := make([]uint32, 0, len(share.PublicShares))
+	for id := range share.PublicShares {
+		ids = append(ids, id)
+	}
+	if err := validIds(ids); err != nil {
+		return nil, err
+	}
+	if share.PublicShares[share.Id] == nil || share.Threshold < 2 || share.Threshold > uint32(len(ids)) {
+		return nil, fmt.Errorf("invalid key share")
+	}
+	sortIds(ids)
+	return &Refresh{
+		share: share,
+		// Bind the session to the key
+		sid:   append(append([]byte{}, sid...), share.Rid...),
+		ids:   ids,
+		round: 1,
+	}, nil
+}
+
+// Round1 generates the new Paillier key of this participant and commits to the values of round 2
+// [CGGMP21] fig 6: Round 1
+func (r *Refresh) Round1() (*RefreshRound1Bcast, error) {
+	if r == nil || r.share == nil {
+		return nil, internal.ErrNilArguments
+	}
+	if r.round != 1 {
+		return nil, internal.ErrInvalidRound
+	}
+	_, sk, err := paillier.NewKeys()
+	if err != nil {
+		return nil, err
+	}
+	return r.round1(sk)
+}
+
+// round1 runs round 1 with a given Paillier key
+func (r *Refresh) round1(sk *paillier.SecretKey) (*RefreshRound1Bcast, error) {
+	if r.round != 1 {
+		return nil, internal.ErrInvalidRound
+	}
+	if err := checkPaillierKey(sk.N); err != nil {
+		return nil, err
+	}
+	curve := r.share.Curve
+	ped, lambda, err := newPedersenParams(sk.N, sk.Totient)
+	if err != nil {
+		return nil, err
+	}
+	prm, err := provePrm(r.sid, r.share.Id, ped, lambda, sk.Totient)
+	if err != nil {
+		return nil, err
+	}
+
+	// Feldman share zero, the commitment to the constant term is the identity and not sent
+	poly := new(sharing.Polynomial).Init(curve.Scalar.Zero(), r.share.Threshold, crand.Reader)
+	commitments := make([]curves.Point, len(poly.Coefficients)-1)
+	for k := range commitments {
+		commitments[k] = curve.ScalarBaseMult(poly.Coefficients[k+1])
+	}
+	u := make([]byte, hashSize)
+	if _, err = crand.Read(u); err != nil {
+		return nil, err
+	}
+
+	r.sk, r.ped, r.prm, r.poly, r.commitments, r.u = sk, ped, prm, poly, commitments, u
+	r.round = 2
+	return &RefreshRound1Bcast{
+		V: refreshHash(r.sid, r.share.Id, ped, commitments, u),
+	}, nil
+}
+
+// Round2 stores the commitments of the other participants and reveals the committed values
+// [CGGMP21] fig 6: Round 2
+func (r *Refresh) Round2(in map[uint32]*RefreshRound1Bcast) (*RefreshRound2Bcast, error) {
+	if r == nil || r.share == nil {
+		return nil, internal.ErrNilArguments
+	}
+	if r.round != 2 {
+		return nil, internal.ErrInvalidRound
+	}
+	f := make(faults)
+	r.hashes = make(map[uint32][]byte, len(r.ids))
+	for _, j := range r.ids {
+		if j == r.share.Id {
+			continue
+		}
+		if in[j] == nil || len(in[j].V) != hashSize {
+			f.add(j, fmt.Errorf("missing commitment"))
+			continue
+		}
+		r.hashes[j] = in[j].V
+	}
+	if err := f.err(); err != nil {
+		return nil, err
+	}
+	r.round = 3
+	return &RefreshRound2Bcast{
+		Pedersen:    r.ped,
+		Prm:         r.prm,
+		Commitments: r.commitments,
+		U:           r.u,
+	}, nil
+}
+
+// Round3 opens the commitments and checks the ring-Pedersen parameters of the other participants,
+// proves that the new Paillier modulus of this participant is well formed and encrypts the zero
+// shares for the other participants under their new Paillier keys.
+// [CGGMP21] fig 6: Round 3
+func (r *Refresh) Round3(in map[uint32]*RefreshRound2Bcast) (*RefreshRound3Bcast, map[uint32]*RefreshRound3P2PSend, error) {
+	if r == nil || r.share == nil {
+		return nil, nil, internal.ErrNilArguments
+	}
+	if r.round != 3 {
+		return nil, nil, internal.ErrInvalidRound
+	}
+	curve := r.share.Curve
+	f := make(faults)
+	r.publicKeys = map[uint32]*paillier.PublicKey{r.share.Id: &r.sk.PublicKey}
+	r.pedersen = map[uint32]*PedersenParams{r.share.Id: r.ped}
+	r.allCommitments = map[uint32][]curves.Point{r.share.Id: r.commitments}
+	for _, j := range r.ids {
+		if j == r.share.Id {
+			continue
+		}
+		msg := in[j]
+		if msg == nil || msg.Pedersen == nil || len(msg.U) != hashSize {
+			f.add(j, fmt.Errorf("missing message"))
+			continue
+		}
+		if err := checkCommitments(curve, r.share.Threshold-1, msg.Commitments); err != nil {
+			f.add(j, err)
+			continue
+		}
+		if err := msg.Pedersen.validate(); err != nil {
+			f.add(j, err)
+			continue
+		}
+		v := refreshHash(r.sid, j, msg.Pedersen, msg.Commitments, msg.U)
+		if subtle.ConstantTimeCompare(v, r.hashes[j]) != 1 {
+			f.add(j, fmt.Errorf("invalid decommitment"))
+			continue
+		}
+		if err := checkPaillierKey(msg.Pedersen.N); err != nil {
+			f.add(j, err)
+			continue
+		}
+		if err := msg.Prm.Verify(r.sid, j, msg.Pedersen); err != nil {
+			f.add(j, err)
+			continue
+		}
+		pk, err := paillier.NewPubkey(msg.Pedersen.N)
+		if err != nil {
+			f.add(j, err)
+			continue
+		}
+		r.publicKeys[j] = pk
+		r.pedersen[j] = msg.Pedersen
+		r.allCommitments[j] = msg.Commitments
+	}
+	if err := f.err(); err != nil {
+		return nil, nil, err
+	}
+
+	ec, err := curve.ToEllipticCurve()
+	if err != nil {
+		return nil, nil, err
+	}
+	mod, err := (&paillier.ModProofParams{
+		Curve:     ec,
+		SecretKey: r.sk,
+		Pi:        r.share.Id,
+	}).Prove()
+	if err != nil {
+		return nil, nil, err
+	}
+	p2p := make(map[uint32]*RefreshRound3P2PSend, len(r.ids)-1)
+	for _, j := range r.ids {
+		if j == r.share.Id {
+			continue
+		}
+		fac, err := (&paillier.FacProofParams{
+			Curve:     ec,
+			SecretKey: r.sk,
+			Pi:        r.share.Id,
+			NTilde:    r.pedersen[j].N,
+			H1:        r.pedersen[j].S,
+			H2:        r.pedersen[j].T,
+		}).Prove()
+		if err != nil {
+			return nil, nil, err
+		}
+		c, _, err := r.publicKeys[j].Encrypt(r.poly.Evaluate(curve.Scalar.New(int(j))).BigInt())
+		if err != nil {
+			return nil, nil, err
+		}
+		p2p[j] = &RefreshRound3P2PSend{Fac: fac, Share: c}
+	}
+	r.round = 4
+	return &RefreshRound3Bcast{Mod: mod}, p2p, nil
+}
+
+// Output checks the Paillier moduli and zero shares of the other participants and returns the
+// refreshed key share with the new auxiliary info. The public key does not change.
+// [CGGMP21] fig 6: Output
+func (r *Refresh) Output(bcast map[uint32]*RefreshRound3Bcast, p2p map[uint32]*RefreshRound3P2PSend) (*KeyShare, error) {
+	if r == nil || r.share == nil {
+		return nil, internal.ErrNilArguments
+	}
+	if r.round != 4 {
+		return nil, internal.ErrInvalidRound
+	}
+	curve := r.share.Curve
+	ec, err := curve.ToEllipticCurve()
+	if err != nil {
+		return nil, err
+	}
+	q := order(curve)
+	f := make(faults)
+	share := r.share.Share.Add(r.poly.Evaluate(curve.Scalar.New(int(r.share.Id))))
+	for _, j := range r.ids {
+		if j == r.share.Id {
+			continue
+		}
+		if bcast[j] == nil || p2p[j] == nil || p2p[j].Share == nil {
+			f.add(j, fmt.Errorf("missing message"))
+			continue
+		}
+		if err := bcast[j].Mod.Verify(&paillier.ModVerifyParams{
+			Curve:     ec,
+			PublicKey: r.publicKeys[j],
+			Pi:        j,
+		}); err != nil {
+			f.add(j, err)
+			continue
+		}
+		if err := p2p[j].Fac.Verify(&paillier.FacVerifyParams{
+			Curve:     ec,
+			PublicKey: r.publicKeys[j],
+			Pi:        j,
+			NTilde:    r.ped.N,
+			H1:        r.ped.S,
+			H2:        r.ped.T,
+		}); err != nil {
+			f.add(j, err)
+			continue
+		}
+		// The decrypted share must match the Feldman commitments of participant j
+		x, err := r.sk.Decrypt(p2p[j].Share)
+		if err != nil {
+			f.add(j, err)
+			continue
+		}
+		if x.Cmp(q) != -1 {
+			f.add(j, fmt.Errorf("invalid zero share"))
+			continue
+		}
+		xs := scalar(curve, x)
+		if !curve.ScalarBaseMult(xs).Equal(r.zeroShare(j, r.share.Id)) {
+			f.add(j, fmt.Errorf("invalid zero share"))
+			continue
+		}
+		share = share.Add(xs)
+	}
+	if err := f.err(); err != nil {
+		return nil, err
+	}
+
+	publicShares := make(map[uint32]curves.Point, len(r.ids))
+	for _, k := range r.ids {
+		publicShares[k] = r.share.PublicShares[k]
+		for _, j := range r.ids {
+			publicShares[k] = publicShares[k].Add(r.zeroShare(j, k))
+		}
+	}
+	if !publicShares[r.share.Id].Equal(curve.ScalarBaseMult(share)) {
+		return nil, fmt.Errorf("invalid key share")
+	}
+	r.round = 5
+	return &KeyShare{
+		Curve:        curve,
+		Id:           r.share.Id,
+		Threshold:    r.share.Threshold,
+		Share:        share,
+		PublicKey:    r.share.PublicKey,
+		PublicShares: publicShares,
+		Rid:          r.share.Rid,
+		Aux: &AuxInfo{
+			SecretKey:  r.sk,
+			PublicKeys: r.publicKeys,
+			Pedersen:   r.pedersen,
+		},
+	}, nil
+}
+
+// zeroShare returns g^x for the zero share x of participant k from participant j
+func (r *Refresh) zeroShare(j, k uint32) curves.Point {
+	curve := r.share.Curve
+	commitments := append([]curves.Point{curve.NewIdentityPoint()}, r.allCommitments[j]...)
+	return evaluate(curve, commitments, k)
+}
+
+// refreshHash computes the commitment V = H(sid, id, N, s, t, F, u) of round 1
+func refreshHash(sid []byte, id uint32, ped *PedersenParams, commitments []curves.Point, u []byte) []byte {
+	t := newTranscript("refresh", sid, id)
+	t.ints(ped.N, ped.S, ped.T)
+	t.points(commitments...)
+	t.t.AppendMessage([]byte("u"), u)
+	return t.t.ExtractBytes([]byte("commitment"), hashSize)
+}
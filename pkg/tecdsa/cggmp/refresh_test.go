@@ -0,0 +1,160 @@
+//
+// Copyright Coinbase, Inc. All Rights Reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package cggmp
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/nerifnetwork/kryptology/internal"
+	"github.com/nerifnetwork/kryptology/pkg/core"
+	"github.com/nerifnetwork/kryptology/pkg/core/curves"
+)
+
+// refreshRound2 runs the key refresh with the fixture Paillier keys up to round 2
+func refreshRound2(t *testing.T, shares map[uint32]*KeyShare) (map[uint32]*Refresh, map[uint32]*RefreshRound2Bcast) {
+	participants := make(map[uint32]*Refresh, len(shares))
+	r1 := make(map[uint32]*RefreshRound1Bcast, len(shares))
+	for id, share := range shares {
+		r, err := NewRefresh(share, testSid)
+		require.NoError(t, err)
+		participants[id] = r
+		r1[id], err = r.round1(testSecretKey(t, id))
+		require.NoError(t, err)
+	}
+	r2 := make(map[uint32]*RefreshRound2Bcast, len(shares))
+	for id, r := range participants {
+		var err error
+		r2[id], err = r.Round2(r1)
+		require.NoError(t, err)
+	}
+	return participants, r2
+}
+
+// refreshRound3 runs the key refresh up to round 3 and returns the messages of round 3
+func refreshRound3(t *testing.T, shares map[uint32]*KeyShare) (map[uint32]*Refresh, map[uint32]*RefreshRound3Bcast, map[uint32]map[uint32]*RefreshRound3P2PSend) {
+	participants, r2 := refreshRound2(t, shares)
+	r3 := make(map[uint32]*RefreshRound3Bcast, len(shares))
+	p2p := make(map[uint32]map[uint32]*RefreshRound3P2PSend, len(shares))
+	for id, r := range participants {
+		bcast, out, err := r.Round3(r2)
+		require.NoError(t, err)
+		r3[id] = bcast
+		for j, msg := range out {
+			if p2p[j] == nil {
+				p2p[j] = make(map[uint32]*RefreshRound3P2PSend, len(shares))
+			}
+			p2p[j][id] = msg
+		}
+	}
+	return participants, r3, p2p
+}
+
+// runRefresh refreshes the key shares and returns them with auxiliary info
+func runRefresh(t *testing.T, shares map[uint32]*KeyShare) map[uint32]*KeyShare {
+	participants, r3, p2p := refreshRound3(t, shares)
+	refreshed := make(map[uint32]*KeyShare, len(shares))
+	for id, r := range participants {
+		share, err := r.Output(r3, p2p[id])
+		require.NoError(t, err)
+		refreshed[id] = share
+	}
+	return refreshed
+}
+
+func TestRefresh(t *testing.T) {
+	curve := curves.K256()
+	shares := runKeyGen(t, curve, 2, 3)
+	refreshed := runRefresh(t, shares)
+	for id, share := range refreshed {
+		require.True(t, shares[id].PublicKey.Equal(share.PublicKey))
+		require.False(t, shares[id].Share.Cmp(share.Share) == 0)
+		require.NotNil(t, share.Aux)
+		require.Equal(t, testSecretKey(t, id).N, share.Aux.SecretKey.N)
+		for j, publicShare := range share.PublicShares {
+			require.True(t, publicShare.Equal(curve.ScalarBaseMult(refreshed[j].Share)))
+			require.Equal(t, refreshed[j].Aux.SecretKey.N, share.Aux.PublicKeys[j].N)
+			require.Equal(t, refreshed[j].Aux.Pedersen[j], share.Aux.Pedersen[j])
+		}
+	}
+	for _, ids := range [][]uint32{{1, 2}, {1, 3}, {2, 3}} {
+		require.True(t, shares[1].PublicKey.Equal(curve.ScalarBaseMult(combineShares(t, refreshed, ids))))
+	}
+
+	// Old and new shares do not combine to the key
+	mixed := map[uint32]*KeyShare{1: shares[1], 2: refreshed[2], 3: refreshed[3]}
+	require.False(t, shares[1].PublicKey.Equal(curve.ScalarBaseMult(combineShares(t, mixed, []uint32{1, 2}))))
+}
+
+func TestRefreshInvalidArguments(t *testing.T) {
+	shares := runKeyGen(t, curves.K256(), 2, 3)
+	_, err := NewRefresh(nil, testSid)
+	require.Error(t, err)
+	_, err = NewRefresh(shares[1], nil)
+	require.Error(t, err)
+
+	r, err := NewRefresh(shares[1], testSid)
+	require.NoError(t, err)
+	_, err = r.Round2(nil)
+	require.Equal(t, internal.ErrInvalidRound, err)
+	_, err = r.Output(nil, nil)
+	require.Equal(t, internal.ErrInvalidRound, err)
+
+	// The Paillier modulus must be large enough
+	r, err = NewRefresh(shares[1], testSid)
+	require.NoError(t, err)
+	sk := testSecretKey(t, 1)
+	sk.N = new(big.Int).Rsh(sk.N, 1024)
+	_, err = r.round1(sk)
+	require.Error(t, err)
+}
+
+func TestRefreshInvalidPedersenParams(t *testing.T) {
+	shares := runKeyGen(t, curves.K256(), 2, 3)
+	participants, r2 := refreshRound2(t, shares)
+
+	// The ring-Pedersen proof of participant 2 does not match its parameters, participant 3 reuses them
+	tampered := *r2[2]
+	tampered.Prm = &PrmProof{A: r2[2].Prm.A, Z: append([]*big.Int{}, r2[2].Prm.Z...)}
+	tampered.Prm.Z[0] = new(big.Int).Add(tampered.Prm.Z[0], core.One)
+	r2[2] = &tampered
+	copied := *r2[3]
+	copied.Prm = r2[1].Prm
+	r2[3] = &copied
+	_, _, err := participants[1].Round3(r2)
+	requireCulprits(t, err, 2, 3)
+}
+
+func TestRefreshInvalidPaillierProofs(t *testing.T) {
+	shares := runKeyGen(t, curves.K256(), 2, 3)
+	participants, r3, p2p := refreshRound3(t, shares)
+
+	// Participant 2 sends the proof of participant 3 and participant 3 sends the
+	// no small factor proof for participant 2 to participant 1
+	r3[2] = r3[3]
+	p2p[1][3] = &RefreshRound3P2PSend{Fac: p2p[2][3].Fac, Share: p2p[1][3].Share}
+	_, err := participants[1].Output(r3, p2p[1])
+	requireCulprits(t, err, 2, 3)
+}
+
+func TestRefreshInvalidZeroShare(t *testing.T) {
+	shares := runKeyGen(t, curves.K256(), 2, 3)
+	participants, r3, p2p := refreshRound3(t, shares)
+
+	// Participant 3 encrypts a share that does not match its commitments
+	pk := participants[3].publicKeys[1]
+	c, _, err := pk.Encrypt(big.NewInt(42))
+	require.NoError(t, err)
+	p2p[1][3] = &RefreshRound3P2PSend{Fac: p2p[1][3].Fac, Share: c}
+	_, err = participants[1].Output(r3, p2p[1])
+	requireCulprits(t, err, 3)
+
+	_, err = participants[2].Output(r3, p2p[2])
+	require.NoError(t, err)
+}
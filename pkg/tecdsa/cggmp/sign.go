@@ -0,0 +1,166 @@
+//
+// Copyright Coinbase, Inc. All Rights Reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package cggmp
+
+import (
+	"crypto/ecdsa"
+	"fmt"
+	"math/big"
+
+	"github.com/nerifnetwork/kryptology/internal"
+	"github.com/nerifnetwork/kryptology/pkg/core/curves"
+)
+
+// Presignature is the output of the presigning for a fixed set of signers. It signs a
+// single message hash in one round: every signer broadcasts the SignatureShare of Sign
+// and combines the shares of all signers with Output.
+//
+// A presignature must never sign two messages, doing so reveals the secret key.
+// Sign erases the secret values of the presignature it is given, but any copy
+// made before stays usable. Callers that store presignatures must delete the
+// stored copy before calling Sign.
+type Presignature struct {
+	Curve *curves.Curve
+	// SignerId is the id of the signer that holds the presignature
+	SignerId uint32
+	// Signers are the ids of all signers including SignerId, in ascending order
+	Signers   []uint32
+	PublicKey curves.Point
+	// R is the signing nonce point R = g^{k^{-1}}
+	R curves.Point
+	// K and Chi are the secret k_i and χ_i of the signer, nil once used
+	K, Chi curves.Scalar
+	// BigK are R^k_j and S are R^χ_j of all signers, they identify the signers of invalid shares
+	BigK, S map[uint32]curves.Point
+	// MsgHash and Sigma are set by Sign
+	MsgHash []byte
+	Sigma   curves.Scalar
+}
+
+// SignatureShare is the share σ_i of the signature of a signer
+type SignatureShare struct {
+	Sigma curves.Scalar
+}
+
+// Sign signs the hash with the presignature and returns the share of the signature,
+// which must be broadcast to all signers. The presignature can only be used once.
+// [CGGMP21] fig 8: Round 1
+func Sign(presig *Presignature, hash []byte) (*SignatureShare, error) {
+	if presig == nil || presig.Curve == nil || presig.R == nil || len(hash) == 0 {
+		return nil, internal.ErrNilArguments
+	}
+	if presig.K == nil || presig.Chi == nil || presig.Sigma != nil {
+		return nil, fmt.Errorf("presignature has already been used")
+	}
+	r, err := presig.r()
+	if err != nil {
+		return nil, err
+	}
+
+	// σ_i = k_i m + r χ_i
+	sigma := presig.K.Mul(presig.message(hash)).Add(presig.Chi.Mul(r))
+	presig.K, presig.Chi = nil, nil
+	presig.MsgHash = hash
+	presig.Sigma = sigma
+	return &SignatureShare{Sigma: sigma}, nil
+}
+
+// Output combines the shares of the other signers with the share of Sign into the signature of the hash
+// and verifies it. A share σ_j that does not match R^σ_j = K̃_j^m S_j^r identifies signer j.
+// [CGGMP21] fig 8: Output
+func (presig *Presignature) Output(in map[uint32]*SignatureShare) (*curves.EcdsaSignature, error) {
+	if presig == nil || presig.Curve == nil || presig.R == nil || presig.PublicKey == nil {
+		return nil, internal.ErrNilArguments
+	}
+	if presig.Sigma == nil {
+		return nil, fmt.Errorf("presignature has not signed yet")
+	}
+	r, err := presig.r()
+	if err != nil {
+		return nil, err
+	}
+	m := presig.message(presig.MsgHash)
+	f := make(faults)
+	s := presig.Sigma
+	for _, j := range presig.Signers {
+		if j == presig.SignerId {
+			continue
+		}
+		if in[j] == nil || in[j].Sigma == nil {
+			f.add(j, fmt.Errorf("missing signature share"))
+			continue
+		}
+		if presig.BigK[j] == nil || presig.S[j] == nil {
+			return nil, fmt.Errorf("invalid presignature")
+		}
+		if !presig.R.Mul(in[j].Sigma).Equal(presig.BigK[j].Mul(m).Add(presig.S[j].Mul(r))) {
+			f.add(j, fmt.Errorf("invalid signature share"))
+			continue
+		}
+		s = s.Add(in[j].Sigma)
+	}
+	if err := f.err(); err != nil {
+		return nil, err
+	}
+
+	x, y, err := coordinates(presig.R)
+	if err != nil {
+		return nil, err
+	}
+	// Normalize the signature to a "low S" form, negating s flips the parity of R
+	q := order(presig.Curve)
+	sig := &curves.EcdsaSignature{
+		V: int(y.Bit(0)),
+		R: new(big.Int).Mod(x, q),
+		S: s.BigInt(),
+	}
+	if sig.S.Cmp(new(big.Int).Rsh(q, 1)) == 1 {
+		sig.S.Sub(q, sig.S)
+		sig.V ^= 1
+	}
+
+	ec, err := presig.Curve.ToEllipticCurve()
+	if err != nil {
+		return nil, err
+	}
+	pkx, pky, err := coordinates(presig.PublicKey)
+	if err != nil {
+		return nil, err
+	}
+	if !ecdsa.Verify(&ecdsa.PublicKey{Curve: ec, X: pkx, Y: pky}, presig.MsgHash, sig.R, sig.S) {
+		return nil, fmt.Errorf("signature is not valid")
+	}
+	return sig, nil
+}
+
+// r returns the x coordinate of R mod q
+func (presig *Presignature) r() (curves.Scalar, error) {
+	x, _, err := coordinates(presig.R)
+	if err != nil {
+		return nil, err
+	}
+	return scalar(presig.Curve, x), nil
+}
+
+// message converts the hash to a scalar like ECDSA, keeping the leftmost bits of the hash
+func (presig *Presignature) message(hash []byte) curves.Scalar {
+	q := order(presig.Curve)
+	m := new(big.Int).SetBytes(hash)
+	if excess := len(hash)*8 - q.BitLen(); excess > 0 {
+		m.Rsh(m, uint(excess))
+	}
+	return scalar(presig.Curve, m)
+}
+
+// coordinates returns the affine coordinates of a point
+func coordinates(p curves.Point) (*big.Int, *big.Int, error) {
+	b := p.ToAffineUncompressed()
+	if len(b) != 65 || b[0] != 4 {
+		return nil, nil, fmt.Errorf("invalid point")
+	}
+	return new(big.Int).SetBytes(b[1:33]), new(big.Int).SetBytes(b[33:]), nil
+}
@@ -0,0 +1,302 @@
+//
+// Copyright Coinbase, Inc. All Rights Reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package cggmp
+
+import (
+	"crypto/ecdsa"
+	"crypto/sha256"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/nerifnetwork/kryptology/internal"
+	"github.com/nerifnetwork/kryptology/pkg/core/curves"
+)
+
+// presignRound2 runs the presigning of the signers up to round 2 and returns the messages of round 2
+func presignRound2(t *testing.T, shares map[uint32]*KeyShare, signers []uint32) (map[uint32]*Presigner, map[uint32]*PresignRound2Bcast, map[uint32]map[uint32]*PresignRound2P2PSend) {
+	presigners := make(map[uint32]*Presigner, len(signers))
+	r1 := make(map[uint32]*PresignRound1Bcast, len(signers))
+	r1P2P := make(map[uint32]map[uint32]*PresignRound1P2PSend, len(signers))
+	for _, id := range signers {
+		p, err := NewPresigner(shares[id], testSid, signers)
+		require.NoError(t, err)
+		presigners[id] = p
+		bcast, p2p, err := p.Round1()
+		require.NoError(t, err)
+		r1[id] = bcast
+		for j, msg := range p2p {
+			if r1P2P[j] == nil {
+				r1P2P[j] = make(map[uint32]*PresignRound1P2PSend)
+			}
+			r1P2P[j][id] = msg
+		}
+	}
+	r2 := make(map[uint32]*PresignRound2Bcast, len(signers))
+	r2P2P := make(map[uint32]map[uint32]*PresignRound2P2PSend, len(signers))
+	for id, p := range presigners {
+		bcast, p2p, err := p.Round2(r1, r1P2P[id])
+		require.NoError(t, err)
+		r2[id] = bcast
+		for j, msg := range p2p {
+			if r2P2P[j] == nil {
+				r2P2P[j] = make(map[uint32]*PresignRound2P2PSend)
+			}
+			r2P2P[j][id] = msg
+		}
+	}
+	return presigners, r2, r2P2P
+}
+
+// presignRound3 runs the presigning of the signers up to round 3 and returns the messages of round 3
+func presignRound3(t *testing.T, shares map[uint32]*KeyShare, signers []uint32) (map[uint32]*Presigner, map[uint32]*PresignRound3Bcast, map[uint32]map[uint32]*PresignRound3P2PSend) {
+	presigners, r2, r2P2P := presignRound2(t, shares, signers)
+	r3 := make(map[uint32]*PresignRound3Bcast, len(signers))
+	r3P2P := make(map[uint32]map[uint32]*PresignRound3P2PSend, len(signers))
+	for id, p := range presigners {
+		bcast, p2p, err := p.Round3(r2, r2P2P[id])
+		require.NoError(t, err)
+		r3[id] = bcast
+		for j, msg := range p2p {
+			if r3P2P[j] == nil {
+				r3P2P[j] = make(map[uint32]*PresignRound3P2PSend)
+			}
+			r3P2P[j][id] = msg
+		}
+	}
+	return presigners, r3, r3P2P
+}
+
+// runPresign returns the presignatures of the signers
+func runPresign(t *testing.T, shares map[uint32]*KeyShare, signers []uint32) map[uint32]*Presignature {
+	presigners, r3, p2p := presignRound3(t, shares, signers)
+	presigs := make(map[uint32]*Presignature, len(signers))
+	for id, p := range presigners {
+		presig, err := p.Output(r3, p2p[id])
+		require.NoError(t, err)
+		presigs[id] = presig
+	}
+	return presigs
+}
+
+// sign signs the hash with the presignatures and returns the signature of every signer
+func sign(t *testing.T, presigs map[uint32]*Presignature, hash []byte) map[uint32]*curves.EcdsaSignature {
+	shares := make(map[uint32]*SignatureShare, len(presigs))
+	for id, presig := range presigs {
+		share, err := Sign(presig, hash)
+		require.NoError(t, err)
+		shares[id] = share
+	}
+	sigs := make(map[uint32]*curves.EcdsaSignature, len(presigs))
+	for id, presig := range presigs {
+		sig, err := presig.Output(shares)
+		require.NoError(t, err)
+		sigs[id] = sig
+	}
+	return sigs
+}
+
+func verify(t *testing.T, publicKey curves.Point, hash []byte, sig *curves.EcdsaSignature) {
+	x, y, err := coordinates(publicKey)
+	require.NoError(t, err)
+	ec, err := curves.K256().ToEllipticCurve()
+	require.NoError(t, err)
+	require.True(t, ecdsa.Verify(&ecdsa.PublicKey{Curve: ec, X: x, Y: y}, hash, sig.R, sig.S))
+}
+
+func TestSign(t *testing.T) {
+	shares := runRefresh(t, runKeyGen(t, curves.K256(), 2, 3))
+	hash := sha256.Sum256([]byte("cggmp"))
+	for _, signers := range [][]uint32{{1, 3}, {1, 2, 3}} {
+		presigs := runPresign(t, shares, signers)
+		sigs := sign(t, presigs, hash[:])
+		for _, sig := range sigs {
+			require.Equal(t, sigs[signers[0]], sig)
+			require.True(t, sig.S.Cmp(new(big.Int).Rsh(order(curves.K256()), 1)) != 1)
+			verify(t, shares[1].PublicKey, hash[:], sig)
+		}
+	}
+}
+
+func TestSignSingleUse(t *testing.T) {
+	shares := runRefresh(t, runKeyGen(t, curves.K256(), 2, 3))
+	presigs := runPresign(t, shares, []uint32{1, 2})
+	hash := sha256.Sum256([]byte("first"))
+	_, err := presigs[1].Output(nil)
+	require.Error(t, err)
+	_, err = Sign(presigs[1], hash[:])
+	require.NoError(t, err)
+	require.Nil(t, presigs[1].K)
+	require.Nil(t, presigs[1].Chi)
+	other := sha256.Sum256([]byte("second"))
+	_, err = Sign(presigs[1], other[:])
+	require.Error(t, err)
+}
+
+func TestSignIdentifiesInvalidShare(t *testing.T) {
+	shares := runRefresh(t, runKeyGen(t, curves.K256(), 2, 3))
+	presigs := runPresign(t, shares, []uint32{1, 2, 3})
+	hash := sha256.Sum256([]byte("cggmp"))
+	sigShares := make(map[uint32]*SignatureShare)
+	for id, presig := range presigs {
+		share, err := Sign(presig, hash[:])
+		require.NoError(t, err)
+		sigShares[id] = share
+	}
+	// Signer 3 sends a wrong share, signer 2 none
+	sigShares[3] = &SignatureShare{Sigma: sigShares[3].Sigma.Add(curves.K256().Scalar.One())}
+	delete(sigShares, 2)
+	_, err := presigs[1].Output(sigShares)
+	requireCulprits(t, err, 2, 3)
+}
+
+func TestPresignInvalidArguments(t *testing.T) {
+	shares := runKeyGen(t, curves.K256(), 2, 3)
+	_, err := NewPresigner(shares[1], testSid, []uint32{1, 2})
+	require.Error(t, err, "shares without auxiliary info")
+
+	refreshed := runRefresh(t, shares)
+	_, err = NewPresigner(refreshed[1], testSid, []uint32{1})
+	require.Error(t, err)
+	_, err = NewPresigner(refreshed[1], testSid, []uint32{2, 3})
+	require.Error(t, err)
+	_, err = NewPresigner(refreshed[1], testSid, []uint32{1, 1})
+	require.Error(t, err)
+	_, err = NewPresigner(refreshed[1], testSid, []uint32{1, 4})
+	require.Error(t, err)
+	_, err = NewPresigner(refreshed[1], nil, []uint32{1, 2})
+	require.Error(t, err)
+
+	p, err := NewPresigner(refreshed[1], testSid, []uint32{2, 1})
+	require.NoError(t, err)
+	_, _, err = p.Round2(nil, nil)
+	require.Equal(t, internal.ErrInvalidRound, err)
+	_, err = p.Output(nil, nil)
+	require.Equal(t, internal.ErrInvalidRound, err)
+}
+
+func TestPresignIdentifiesInvalidProofs(t *testing.T) {
+	shares := runRefresh(t, runKeyGen(t, curves.K256(), 2, 3))
+	presigners, bcast, p2p := presignRound2(t, shares, []uint32{1, 2, 3})
+
+	// Signer 2 claims another Γ_2 and signer 3 swaps its conversions to signer 1
+	gamma := *bcast[2]
+	gamma.Gamma = gamma.Gamma.Double()
+	bcast[2] = &gamma
+	swapped := *bcast[3]
+	swapped.D = map[uint32]*big.Int{1: bcast[3].DHat[1], 2: bcast[3].D[2]}
+	swapped.DHat = map[uint32]*big.Int{1: bcast[3].D[1], 2: bcast[3].DHat[2]}
+	bcast[3] = &swapped
+	_, _, err := presigners[1].Round3(bcast, p2p[1])
+	requireCulprits(t, err, 2, 3)
+
+	// Signer 3 leaves out the conversions to signer 2
+	partial := swapped
+	partial.F = map[uint32]*big.Int{1: bcast[3].F[1]}
+	bcast[3] = &partial
+	_, _, err = presigners[2].Round3(bcast, p2p[2])
+	requireCulprits(t, err, 3)
+}
+
+func TestPresignIdentifiesInvalidDelta(t *testing.T) {
+	shares := runRefresh(t, runKeyGen(t, curves.K256(), 2, 3))
+	presigners, r3, p2p := presignRound3(t, shares, []uint32{1, 2, 3})
+
+	// Signer 2 sends a Δ_2 that its log* proof does not match
+	r3[2] = &PresignRound3Bcast{Delta: r3[2].Delta, BigDelta: r3[2].BigDelta.Double(), SHat: r3[2].SHat}
+	_, err := presigners[1].Output(r3, p2p[1])
+	requireCulprits(t, err, 2)
+}
+
+// presignAbort runs Output, Abort and Identify with the messages of round 3, where the cheater
+// sent a wrong broadcast, and returns the errors of Identify
+func presignAbort(t *testing.T, presigners map[uint32]*Presigner, cheater uint32, r3 map[uint32]*PresignRound3Bcast, r3P2P map[uint32]map[uint32]*PresignRound3P2PSend) map[uint32]error {
+	for id, p := range presigners {
+		_, err := p.Output(r3, r3P2P[id])
+		if id == cheater {
+			// The cheater sees consistent shares and joins the abort with its wrong broadcast
+			require.NoError(t, err)
+			p.round3[id] = r3[id]
+			require.Equal(t, ErrPresignAbort, p.abort())
+			continue
+		}
+		require.True(t, errors.Is(err, ErrPresignAbort), err)
+	}
+	bcast := make(map[uint32]*PresignAbortBcast, len(presigners))
+	p2p := make(map[uint32]map[uint32]*PresignAbortP2PSend, len(presigners))
+	for id, p := range presigners {
+		b, msgs, err := p.Abort()
+		require.NoError(t, err)
+		bcast[id] = b
+		for j, msg := range msgs {
+			if p2p[j] == nil {
+				p2p[j] = make(map[uint32]*PresignAbortP2PSend)
+			}
+			p2p[j][id] = msg
+		}
+	}
+	errs := make(map[uint32]error, len(presigners))
+	for id, p := range presigners {
+		errs[id] = p.Identify(bcast, p2p[id])
+	}
+	return errs
+}
+
+func TestPresignIdentifiesWrongDelta(t *testing.T) {
+	shares := runRefresh(t, runKeyGen(t, curves.K256(), 2, 3))
+	presigners, r3, p2p := presignRound3(t, shares, []uint32{1, 2, 3})
+
+	// Signer 2 sends a wrong δ_2 with the right Δ_2, which only the abort identifies
+	wrong := *r3[2]
+	wrong.Delta = wrong.Delta.Add(curves.K256().Scalar.One())
+	r3[2] = &wrong
+	errs := presignAbort(t, presigners, 2, r3, p2p)
+	requireCulprits(t, errs[1], 2)
+	requireCulprits(t, errs[3], 2)
+	require.Nil(t, presigners[1].k)
+}
+
+func TestPresignIdentifiesWrongSHat(t *testing.T) {
+	shares := runRefresh(t, runKeyGen(t, curves.K256(), 2, 3))
+	presigners, r3, p2p := presignRound3(t, shares, []uint32{1, 2, 3})
+
+	// Signer 3 sends a wrong Ŝ_3
+	wrong := *r3[3]
+	wrong.SHat = wrong.SHat.Double()
+	r3[3] = &wrong
+	errs := presignAbort(t, presigners, 3, r3, p2p)
+	requireCulprits(t, errs[1], 3)
+	requireCulprits(t, errs[2], 3)
+
+	// The abort round only runs once
+	_, _, err := presigners[1].Abort()
+	require.Equal(t, internal.ErrInvalidRound, err)
+	require.Equal(t, internal.ErrInvalidRound, presigners[1].Identify(nil, nil))
+}
+
+func TestPresignAbortRounds(t *testing.T) {
+	shares := runRefresh(t, runKeyGen(t, curves.K256(), 2, 3))
+	presigners, r3, p2p := presignRound3(t, shares, []uint32{1, 2})
+
+	// There is nothing to identify after a successful presigning
+	_, err := presigners[1].Output(r3, p2p[1])
+	require.NoError(t, err)
+	_, _, err = presigners[1].Abort()
+	require.Equal(t, internal.ErrInvalidRound, err)
+
+	// A signer that sends no abort messages is blamed
+	wrong := *r3[1]
+	wrong.Delta = wrong.Delta.Add(curves.K256().Scalar.One())
+	r3[1] = &wrong
+	_, err = presigners[2].Output(r3, p2p[2])
+	require.True(t, errors.Is(err, ErrPresignAbort))
+	_, _, err = presigners[2].Abort()
+	require.NoError(t, err)
+	requireCulprits(t, presigners[2].Identify(nil, nil), 1)
+}
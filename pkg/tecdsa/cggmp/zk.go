@@ -0,0 +1,244 @@
+//
+// Copyright Coinbase, Inc. All Rights Reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package cggmp
+
+import (
+	crand "crypto/rand"
+	"encoding/binary"
+	"fmt"
+	"math/big"
+
+	"github.com/gtank/merlin"
+
+	"github.com/nerifnetwork/kryptology/internal"
+	"github.com/nerifnetwork/kryptology/pkg/core"
+	"github.com/nerifnetwork/kryptology/pkg/core/curves"
+)
+
+// PrmIterations is the number of challenges of the ring-Pedersen parameter proof,
+// each halves the probability that a cheating prover succeeds
+const PrmIterations = 80
+
+// PedersenParams are the ring-Pedersen parameters (N, s, t) of a participant with s = t^λ mod N.
+// The other participants use them to commit to values in their range proofs to this participant.
+// [CGGMP21] uses the Paillier modulus of the participant as N.
+type PedersenParams struct {
+	N, S, T *big.Int
+}
+
+// PrmProof proves that S is in the group generated by T
+// [CGGMP21] fig 17
+type PrmProof struct {
+	A []*big.Int
+	Z []*big.Int
+}
+
+// validate checks that the parameters are units of Z_N
+func (p *PedersenParams) validate() error {
+	if p == nil || core.AnyNil(p.N, p.S, p.T) {
+		return internal.ErrNilArguments
+	}
+	if p.N.Sign() != 1 || p.N.Bit(0) != 1 {
+		return fmt.Errorf("invalid ring-pedersen modulus")
+	}
+	if !isUnit(p.S, p.N) || !isUnit(p.T, p.N) || p.T.Cmp(core.One) == 0 {
+		return fmt.Errorf("invalid ring-pedersen parameters")
+	}
+	return nil
+}
+
+// commit computes s^x t^y mod N, the exponents can be negative
+func (p *PedersenParams) commit(x, y *big.Int) (*big.Int, error) {
+	return expMul(p.S, x, p.T, y, p.N)
+}
+
+// verify checks that s^x t^y = a b^e mod N
+func (p *PedersenParams) verify(x, y, a, b, e *big.Int) (bool, error) {
+	lhs, err := p.commit(x, y)
+	if err != nil {
+		return false, err
+	}
+	return lhs.Cmp(mulExp(a, b, e, p.N)) == 0, nil
+}
+
+// newPedersenParams samples ring-Pedersen parameters for the modulus n = pq with totient phi
+// and returns them together with their secret exponent λ
+// [CGGMP21] fig 6: Round 1
+func newPedersenParams(n, phi *big.Int) (*PedersenParams, *big.Int, error) {
+	r, err := randUnit(n)
+	if err != nil {
+		return nil, nil, err
+	}
+	t := new(big.Int).Exp(r, big.NewInt(2), n)
+	lambda, err := core.Rand(phi)
+	if err != nil {
+		return nil, nil, err
+	}
+	s := new(big.Int).Exp(t, lambda, n)
+	return &PedersenParams{N: n, S: s, T: t}, lambda, nil
+}
+
+// provePrm proves that the parameters are well formed with the secret exponent λ
+// [CGGMP21] fig 17
+func provePrm(sid []byte, id uint32, params *PedersenParams, lambda, phi *big.Int) (*PrmProof, error) {
+	proof := &PrmProof{
+		A: make([]*big.Int, PrmIterations),
+		Z: make([]*big.Int, PrmIterations),
+	}
+	a := make([]*big.Int, PrmIterations)
+	for i := range a {
+		var err error
+		if a[i], err = core.Rand(phi); err != nil {
+			return nil, err
+		}
+		proof.A[i] = new(big.Int).Exp(params.T, a[i], params.N)
+	}
+	e := proof.challenge(sid, id, params)
+	for i := range a {
+		proof.Z[i] = new(big.Int).Set(a[i])
+		if e[i] {
+			proof.Z[i].Add(proof.Z[i], lambda)
+			proof.Z[i].Mod(proof.Z[i], phi)
+		}
+	}
+	return proof, nil
+}
+
+// Verify checks that the ring-Pedersen parameters of participant id are well formed
+func (p *PrmProof) Verify(sid []byte, id uint32, params *PedersenParams) error {
+	if p == nil || len(p.A) != PrmIterations || len(p.Z) != PrmIterations {
+		return fmt.Errorf("invalid ring-pedersen parameter proof")
+	}
+	if err := params.validate(); err != nil {
+		return err
+	}
+	if core.AnyNil(p.A...) || core.AnyNil(p.Z...) {
+		return internal.ErrNilArguments
+	}
+	e := p.challenge(sid, id, params)
+	for i := range p.A {
+		// t^z_i = A_i s^e_i mod N
+		if p.Z[i].Sign() < 0 || !isUnit(p.A[i], params.N) {
+			return fmt.Errorf("invalid ring-pedersen parameter proof")
+		}
+		rhs := new(big.Int).Set(p.A[i])
+		if e[i] {
+			rhs.Mul(rhs, params.S)
+			rhs.Mod(rhs, params.N)
+		}
+		if new(big.Int).Exp(params.T, p.Z[i], params.N).Cmp(rhs) != 0 {
+			return fmt.Errorf("ring-pedersen parameter proof is not valid")
+		}
+	}
+	return nil
+}
+
+func (p *PrmProof) challenge(sid []byte, id uint32, params *PedersenParams) []bool {
+	t := newTranscript("prm", sid, id)
+	t.ints(params.N, params.S, params.T)
+	t.ints(p.A...)
+	return t.bits(PrmIterations)
+}
+
+// transcript is the Fiat-Shamir transcript of a proof, bound to the session and the prover
+type transcript struct {
+	t *merlin.Transcript
+}
+
+func newTranscript(proof string, sid []byte, prover uint32) *transcript {
+	t := merlin.NewTranscript("Coinbase_CGGMP21")
+	t.AppendMessage([]byte("proof"), []byte(proof))
+	t.AppendMessage([]byte("sid"), sid)
+	var id [4]byte
+	binary.BigEndian.PutUint32(id[:], prover)
+	t.AppendMessage([]byte("prover"), id[:])
+	return &transcript{t}
+}
+
+// ints appends signed integers to the transcript
+func (t *transcript) ints(values ...*big.Int) {
+	for _, v := range values {
+		sign := []byte{byte(v.Sign() + 1)}
+		t.t.AppendMessage([]byte("int"), append(sign, v.Bytes()...))
+	}
+}
+
+func (t *transcript) points(points ...curves.Point) {
+	for _, p := range points {
+		t.t.AppendMessage([]byte("point"), p.ToAffineCompressed())
+	}
+}
+
+// challenge returns a challenge in [0, q)
+func (t *transcript) challenge(q *big.Int) *big.Int {
+	// Extra 128 bits make the bias of the reduction negligible
+	b := t.t.ExtractBytes([]byte("challenge"), (q.BitLen()+128+7)/8)
+	return new(big.Int).Mod(new(big.Int).SetBytes(b), q)
+}
+
+// bits returns n challenge bits
+func (t *transcript) bits(n int) []bool {
+	b := t.t.ExtractBytes([]byte("challenge"), (n+7)/8)
+	e := make([]bool, n)
+	for i := range e {
+		e[i] = b[i/8]>>(i%8)&1 == 1
+	}
+	return e
+}
+
+// randSigned returns a uniform random integer in ±2^bits·m
+func randSigned(bits uint, m *big.Int) (*big.Int, error) {
+	bound := new(big.Int).Lsh(m, bits)
+	r, err := crand.Int(crand.Reader, new(big.Int).Add(new(big.Int).Lsh(bound, 1), core.One))
+	if err != nil {
+		return nil, err
+	}
+	return r.Sub(r, bound), nil
+}
+
+// randUnit returns a uniform random element of Z_n*
+func randUnit(n *big.Int) (*big.Int, error) {
+	for {
+		r, err := core.Rand(n)
+		if err != nil {
+			return nil, err
+		}
+		if isUnit(r, n) {
+			return r, nil
+		}
+	}
+}
+
+// isUnit checks that 0 < x < n and gcd(x, n) = 1
+func isUnit(x, n *big.Int) bool {
+	return x != nil && x.Sign() == 1 && x.Cmp(n) == -1 &&
+		new(big.Int).GCD(nil, nil, x, n).Cmp(core.One) == 0
+}
+
+// inRange checks that |x| <= 2^bits
+func inRange(x *big.Int, bits uint) bool {
+	return x != nil && new(big.Int).Abs(x).Cmp(new(big.Int).Lsh(core.One, bits)) != 1
+}
+
+// expMul computes b1^e1 b2^e2 mod n, the exponents can be negative
+func expMul(b1, e1, b2, e2, n *big.Int) (*big.Int, error) {
+	// Exp returns nil for a negative exponent of a base that is not invertible
+	x := new(big.Int).Exp(b1, e1, n)
+	y := new(big.Int).Exp(b2, e2, n)
+	if x == nil || y == nil {
+		return nil, fmt.Errorf("base is not invertible")
+	}
+	x.Mul(x, y)
+	return x.Mod(x, n), nil
+}
+
+// mulExp computes a b^e mod n for e >= 0
+func mulExp(a, b, e, n *big.Int) *big.Int {
+	x := new(big.Int).Exp(b, e, n)
+	x.Mul(x, a)
+	return x.Mod(x, n)
+}
@@ -0,0 +1,182 @@
+//
+// Copyright Coinbase, Inc. All Rights Reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package cggmp
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/nerifnetwork/kryptology/internal"
+	"github.com/nerifnetwork/kryptology/pkg/core"
+	"github.com/nerifnetwork/kryptology/pkg/core/curves"
+	"github.com/nerifnetwork/kryptology/pkg/paillier"
+)
+
+// AffgProof proves that D = C^x enc0(y; ρ) under the verifier's key N0 with X = g^x, x ∈ ±2^ℓ
+// and Y = enc1(y; ρy) under the prover's key N1 with y ∈ ±2^ℓ' to the holder of the
+// ring-Pedersen parameters
+// [CGGMP21] fig 15
+type AffgProof struct {
+	A  *big.Int
+	Bx curves.Point
+	By *big.Int
+	E  *big.Int
+	S  *big.Int
+	F  *big.Int
+	T  *big.Int
+	Z1 *big.Int
+	Z2 *big.Int
+	Z3 *big.Int
+	Z4 *big.Int
+	W  *big.Int
+	Wy *big.Int
+}
+
+// affgStatement is the statement of the affine operation proof
+type affgStatement struct {
+	// pk0 is the key of C and D, pk1 the key of Y
+	pk0, pk1 *paillier.PublicKey
+	C, D, Y  *big.Int
+	X        curves.Point
+}
+
+// affgWitness is the witness of the affine operation proof
+type affgWitness struct {
+	x, y, rho, rhoY *big.Int
+}
+
+func proveAffg(sid []byte, id uint32, curve *curves.Curve, ped *PedersenParams, st *affgStatement, w *affgWitness) (*AffgProof, error) {
+	q := order(curve)
+
+	// α <- ±2^{ℓ+ε}, β <- ±2^{ℓ'+ε}, r <- Z_N0*, ry <- Z_N1*,
+	// γ, δ <- ±2^{ℓ+ε}·N̂, m, μ <- ±2^ℓ·N̂
+	alpha, err := randSigned(l+epsilon, core.One)
+	if err != nil {
+		return nil, err
+	}
+	beta, err := randSigned(lPrime+epsilon, core.One)
+	if err != nil {
+		return nil, err
+	}
+	r, err := randUnit(st.pk0.N)
+	if err != nil {
+		return nil, err
+	}
+	rY, err := randUnit(st.pk1.N)
+	if err != nil {
+		return nil, err
+	}
+	var gamma, delta, m, mu *big.Int
+	for _, v := range []struct {
+		value **big.Int
+		bits  uint
+	}{
+		{&gamma, l + epsilon},
+		{&delta, l + epsilon},
+		{&m, l},
+		{&mu, l},
+	} {
+		if *v.value, err = randSigned(v.bits, ped.N); err != nil {
+			return nil, err
+		}
+	}
+
+	// A = C^α enc0(β; r), Bx = g^α, By = enc1(β; ry)
+	// E = s^α t^γ, S = s^x t^m, F = s^β t^δ, T = s^y t^μ
+	proof := &AffgProof{
+		Bx: curve.ScalarBaseMult(scalar(curve, alpha)),
+		By: encrypt(st.pk1, beta, rY),
+	}
+	cAlpha := new(big.Int).Exp(st.C, alpha, st.pk0.N2)
+	if cAlpha == nil {
+		return nil, fmt.Errorf("base is not invertible")
+	}
+	proof.A = cAlpha.Mul(cAlpha, encrypt(st.pk0, beta, r))
+	proof.A.Mod(proof.A, st.pk0.N2)
+	for _, v := range []struct {
+		value      **big.Int
+		exp, blind *big.Int
+	}{
+		{&proof.E, alpha, gamma},
+		{&proof.S, w.x, m},
+		{&proof.F, beta, delta},
+		{&proof.T, w.y, mu},
+	} {
+		if *v.value, err = ped.commit(v.exp, v.blind); err != nil {
+			return nil, err
+		}
+	}
+
+	// z1 = α + ex, z2 = β + ey, z3 = γ + em, z4 = δ + eμ, w = rρ^e mod N0, wy = ry ρy^e mod N1
+	e := proof.challenge(sid, id, q, ped, st)
+	proof.Z1 = new(big.Int).Add(alpha, new(big.Int).Mul(e, w.x))
+	proof.Z2 = new(big.Int).Add(beta, new(big.Int).Mul(e, w.y))
+	proof.Z3 = new(big.Int).Add(gamma, new(big.Int).Mul(e, m))
+	proof.Z4 = new(big.Int).Add(delta, new(big.Int).Mul(e, mu))
+	proof.W = mulExp(r, w.rho, e, st.pk0.N)
+	proof.Wy = mulExp(rY, w.rhoY, e, st.pk1.N)
+	return proof, nil
+}
+
+func (p *AffgProof) verify(sid []byte, id uint32, curve *curves.Curve, ped *PedersenParams, st *affgStatement) error {
+	if p == nil || core.AnyNil(p.A, p.By, p.E, p.S, p.F, p.T, p.Z1, p.Z2, p.Z3, p.Z4, p.W, p.Wy) || p.Bx == nil ||
+		core.AnyNil(st.C, st.D, st.Y) || st.X == nil || st.pk0 == nil || st.pk1 == nil || ped == nil {
+		return internal.ErrNilArguments
+	}
+	if !isUnit(p.A, st.pk0.N2) || !isUnit(st.C, st.pk0.N2) || !isUnit(st.D, st.pk0.N2) || !isUnit(p.W, st.pk0.N) ||
+		!isUnit(p.By, st.pk1.N2) || !isUnit(st.Y, st.pk1.N2) || !isUnit(p.Wy, st.pk1.N) ||
+		!isUnit(p.E, ped.N) || !isUnit(p.S, ped.N) || !isUnit(p.F, ped.N) || !isUnit(p.T, ped.N) ||
+		!onCurve(curve, p.Bx) || !onCurve(curve, st.X) {
+		return fmt.Errorf("invalid affine operation proof")
+	}
+	// z1 ∈ ±2^{ℓ+ε}, z2 ∈ ±2^{ℓ'+ε}
+	if !inRange(p.Z1, l+epsilon) || !inRange(p.Z2, lPrime+epsilon) {
+		return fmt.Errorf("affine operation is out of range")
+	}
+	e := p.challenge(sid, id, order(curve), ped, st)
+
+	// C^z1 enc0(z2; w) = A D^e mod N0²
+	lhs := new(big.Int).Exp(st.C, p.Z1, st.pk0.N2)
+	if lhs == nil {
+		return fmt.Errorf("base is not invertible")
+	}
+	lhs.Mul(lhs, encrypt(st.pk0, p.Z2, p.W))
+	lhs.Mod(lhs, st.pk0.N2)
+	if lhs.Cmp(mulExp(p.A, st.D, e, st.pk0.N2)) != 0 {
+		return fmt.Errorf("affine operation proof is not valid")
+	}
+	// g^z1 = Bx X^e
+	if !curve.ScalarBaseMult(scalar(curve, p.Z1)).Equal(p.Bx.Add(st.X.Mul(scalar(curve, e)))) {
+		return fmt.Errorf("affine operation proof is not valid")
+	}
+	// enc1(z2; wy) = By Y^e mod N1²
+	if encrypt(st.pk1, p.Z2, p.Wy).Cmp(mulExp(p.By, st.Y, e, st.pk1.N2)) != 0 {
+		return fmt.Errorf("affine operation proof is not valid")
+	}
+	// s^z1 t^z3 = E S^e, s^z2 t^z4 = F T^e mod N̂
+	for _, check := range []struct{ x, y, a, b *big.Int }{
+		{p.Z1, p.Z3, p.E, p.S},
+		{p.Z2, p.Z4, p.F, p.T},
+	} {
+		ok, err := ped.verify(check.x, check.y, check.a, check.b, e)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return fmt.Errorf("affine operation proof is not valid")
+		}
+	}
+	return nil
+}
+
+func (p *AffgProof) challenge(sid []byte, id uint32, q *big.Int, ped *PedersenParams, st *affgStatement) *big.Int {
+	t := newTranscript("aff-g", sid, id)
+	t.ints(st.pk0.N, st.pk1.N, ped.N, ped.S, ped.T, st.C, st.D, st.Y)
+	t.points(st.X, p.Bx)
+	t.ints(p.A, p.By, p.E, p.S, p.F, p.T)
+	return t.challenge(q)
+}
@@ -0,0 +1,140 @@
+//
+// Copyright Coinbase, Inc. All Rights Reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package cggmp
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/nerifnetwork/kryptology/internal"
+	"github.com/nerifnetwork/kryptology/pkg/core"
+	"github.com/nerifnetwork/kryptology/pkg/core/curves"
+	"github.com/nerifnetwork/kryptology/pkg/paillier"
+)
+
+const (
+	// decBits bounds the plaintexts of the decryption proof. The shares of kγ and kx of the
+	// presigning are sums of products below 2^2ℓ and of conversions below 2^{ℓ'+1}.
+	decBits = lPrime + epsilon/2
+	// decMask is the size of the masks of the decryption proof, it keeps z1 and the
+	// extracted plaintext well below N0/2, so the plaintext mod q is unique
+	decMask = decBits + l + epsilon/4
+)
+
+// DecProof proves that a Paillier ciphertext C decrypts to y ∈ ±2^decBits with Y = g^y
+// to the holder of the ring-Pedersen parameters
+// [CGGMP21] fig 28 with the plaintext mod q in the exponent of g
+type DecProof struct {
+	S, A       *big.Int
+	Y          curves.Point
+	D          *big.Int
+	Z1, Z2, Z3 *big.Int
+}
+
+// proveDec proves that C = enc(y; ρ) under pk and Y = g^y
+func proveDec(sid []byte, id uint32, curve *curves.Curve, pk *paillier.PublicKey, ped *PedersenParams,
+	C *big.Int, Y, g curves.Point, y, rho *big.Int) (*DecProof, error) {
+	q := order(curve)
+	if !inRange(y, decBits) {
+		return nil, fmt.Errorf("plaintext is out of range")
+	}
+
+	// α <- ±2^decMask, μ <- ±2^decBits·N̂, r <- Z_N0*, γ <- ±2^decMask·N̂
+	alpha, err := randSigned(decMask, core.One)
+	if err != nil {
+		return nil, err
+	}
+	mu, err := randSigned(decBits, ped.N)
+	if err != nil {
+		return nil, err
+	}
+	r, err := randUnit(pk.N)
+	if err != nil {
+		return nil, err
+	}
+	gamma, err := randSigned(decMask, ped.N)
+	if err != nil {
+		return nil, err
+	}
+
+	// S = s^y t^μ, A = enc(α; r), Y = g^α, D = s^α t^γ
+	proof := &DecProof{
+		A: encrypt(pk, alpha, r),
+		Y: g.Mul(scalar(curve, alpha)),
+	}
+	if proof.S, err = ped.commit(y, mu); err != nil {
+		return nil, err
+	}
+	if proof.D, err = ped.commit(alpha, gamma); err != nil {
+		return nil, err
+	}
+
+	// z1 = α + ey, z2 = rρ^e mod N0, z3 = γ + eμ
+	e := proof.challenge(sid, id, q, pk, ped, C, Y, g)
+	proof.Z1 = new(big.Int).Add(alpha, new(big.Int).Mul(e, y))
+	proof.Z2 = mulExp(r, rho, e, pk.N)
+	proof.Z3 = new(big.Int).Add(gamma, new(big.Int).Mul(e, mu))
+	return proof, nil
+}
+
+// Verify checks that the plaintext of C is the discrete log of Y to the base g mod q
+func (p *DecProof) Verify(sid []byte, id uint32, curve *curves.Curve, pk *paillier.PublicKey, ped *PedersenParams,
+	C *big.Int, Y, g curves.Point) error {
+	if p == nil || core.AnyNil(p.S, p.A, p.D, p.Z1, p.Z2, p.Z3, C) || p.Y == nil ||
+		Y == nil || g == nil || pk == nil || ped == nil {
+		return internal.ErrNilArguments
+	}
+	if !isUnit(p.A, pk.N2) || !isUnit(C, pk.N2) || !isUnit(p.Z2, pk.N) ||
+		!isUnit(p.S, ped.N) || !isUnit(p.D, ped.N) ||
+		!onCurve(curve, p.Y) || !onCurve(curve, Y) || !onCurve(curve, g) {
+		return fmt.Errorf("invalid decryption proof")
+	}
+	// z1 ∈ ±2^{decMask+1}
+	if !inRange(p.Z1, decMask+1) {
+		return fmt.Errorf("decrypted value is out of range")
+	}
+	e := p.challenge(sid, id, order(curve), pk, ped, C, Y, g)
+
+	// enc(z1; z2) = A C^e mod N0²
+	if encrypt(pk, p.Z1, p.Z2).Cmp(mulExp(p.A, C, e, pk.N2)) != 0 {
+		return fmt.Errorf("decryption proof is not valid")
+	}
+	// g^z1 = Y' Y^e
+	if !g.Mul(scalar(curve, p.Z1)).Equal(p.Y.Add(Y.Mul(scalar(curve, e)))) {
+		return fmt.Errorf("decryption proof is not valid")
+	}
+	// s^z1 t^z3 = D S^e mod N̂
+	ok, err := ped.verify(p.Z1, p.Z3, p.D, p.S, e)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return fmt.Errorf("decryption proof is not valid")
+	}
+	return nil
+}
+
+func (p *DecProof) challenge(sid []byte, id uint32, q *big.Int, pk *paillier.PublicKey, ped *PedersenParams,
+	C *big.Int, Y, g curves.Point) *big.Int {
+	t := newTranscript("dec", sid, id)
+	t.ints(pk.N, ped.N, ped.S, ped.T, C)
+	t.points(Y, g, p.Y)
+	t.ints(p.S, p.A, p.D)
+	return t.challenge(q)
+}
+
+// randomness returns the nonce ρ of a ciphertext c = (1+N)^m ρ^N with the plaintext m
+func randomness(sk *paillier.SecretKey, c, m *big.Int) (*big.Int, error) {
+	// ρ^N = c (1+N)^-m mod N, ρ = (ρ^N)^{N^-1 mod φ(N)} mod N
+	rhoN := new(big.Int).Mul(c, encrypt(&sk.PublicKey, new(big.Int).Neg(m), core.One))
+	rhoN.Mod(rhoN, sk.N)
+	nInv := new(big.Int).ModInverse(sk.N, sk.Totient)
+	if nInv == nil {
+		return nil, fmt.Errorf("invalid paillier key")
+	}
+	return rhoN.Exp(rhoN, nInv, sk.N), nil
+}
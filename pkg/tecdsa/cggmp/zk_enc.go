@@ -0,0 +1,119 @@
+//
+// Copyright Coinbase, Inc. All Rights Reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package cggmp
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/nerifnetwork/kryptology/internal"
+	"github.com/nerifnetwork/kryptology/pkg/core"
+	"github.com/nerifnetwork/kryptology/pkg/paillier"
+)
+
+// EncProof proves that a Paillier ciphertext K encrypts a plaintext in ±2^ℓ
+// to the holder of the ring-Pedersen parameters
+// [CGGMP21] fig 14
+type EncProof struct {
+	S, A, C    *big.Int
+	Z1, Z2, Z3 *big.Int
+}
+
+// proveEnc proves that K = enc(k; ρ) under pk
+func proveEnc(sid []byte, id uint32, q *big.Int, pk *paillier.PublicKey, ped *PedersenParams, K, k, rho *big.Int) (*EncProof, error) {
+	// α <- ±2^{ℓ+ε}, μ <- ±2^ℓ·N̂, r <- Z_N0*, γ <- ±2^{ℓ+ε}·N̂
+	alpha, err := randSigned(l+epsilon, core.One)
+	if err != nil {
+		return nil, err
+	}
+	mu, err := randSigned(l, ped.N)
+	if err != nil {
+		return nil, err
+	}
+	r, err := randUnit(pk.N)
+	if err != nil {
+		return nil, err
+	}
+	gamma, err := randSigned(l+epsilon, ped.N)
+	if err != nil {
+		return nil, err
+	}
+
+	// S = s^k t^μ, A = enc(α; r), C = s^α t^γ
+	proof := &EncProof{A: encrypt(pk, alpha, r)}
+	if proof.S, err = ped.commit(k, mu); err != nil {
+		return nil, err
+	}
+	if proof.C, err = ped.commit(alpha, gamma); err != nil {
+		return nil, err
+	}
+
+	// z1 = α + ek, z2 = rρ^e mod N0, z3 = γ + eμ
+	e := proof.challenge(sid, id, q, pk, ped, K)
+	proof.Z1 = new(big.Int).Add(alpha, new(big.Int).Mul(e, k))
+	proof.Z2 = mulExp(r, rho, e, pk.N)
+	proof.Z3 = new(big.Int).Add(gamma, new(big.Int).Mul(e, mu))
+	return proof, nil
+}
+
+// Verify checks that K is the encryption of a plaintext in range under pk
+func (p *EncProof) Verify(sid []byte, id uint32, q *big.Int, pk *paillier.PublicKey, ped *PedersenParams, K *big.Int) error {
+	if p == nil || core.AnyNil(p.S, p.A, p.C, p.Z1, p.Z2, p.Z3, K) || pk == nil || ped == nil {
+		return internal.ErrNilArguments
+	}
+	if !isUnit(p.A, pk.N2) || !isUnit(K, pk.N2) || !isUnit(p.Z2, pk.N) ||
+		!isUnit(p.S, ped.N) || !isUnit(p.C, ped.N) {
+		return fmt.Errorf("invalid encryption proof")
+	}
+	// z1 ∈ ±2^{ℓ+ε}
+	if !inRange(p.Z1, l+epsilon) {
+		return fmt.Errorf("encrypted value is out of range")
+	}
+	e := p.challenge(sid, id, q, pk, ped, K)
+
+	// enc(z1; z2) = A K^e mod N0²
+	if encrypt(pk, p.Z1, p.Z2).Cmp(mulExp(p.A, K, e, pk.N2)) != 0 {
+		return fmt.Errorf("encryption proof is not valid")
+	}
+	// s^z1 t^z3 = C S^e mod N̂
+	ok, err := ped.verify(p.Z1, p.Z3, p.C, p.S, e)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return fmt.Errorf("encryption proof is not valid")
+	}
+	return nil
+}
+
+func (p *EncProof) challenge(sid []byte, id uint32, q *big.Int, pk *paillier.PublicKey, ped *PedersenParams, K *big.Int) *big.Int {
+	t := newTranscript("enc", sid, id)
+	t.ints(pk.N, ped.N, ped.S, ped.T, K, p.S, p.A, p.C)
+	return t.challenge(q)
+}
+
+// encrypt computes (1+N)^m r^N mod N² for a signed plaintext m
+func encrypt(pk *paillier.PublicKey, m, r *big.Int) *big.Int {
+	// (1+N)^m = 1 + mN mod N²
+	c := new(big.Int).Mod(m, pk.N)
+	c.Mul(c, pk.N)
+	c.Add(c, core.One)
+	c.Mul(c, new(big.Int).Exp(r, pk.N, pk.N2))
+	return c.Mod(c, pk.N2)
+}
+
+// decrypt returns the plaintext of c in ±N/2
+func decrypt(sk *paillier.SecretKey, c *big.Int) (*big.Int, error) {
+	m, err := sk.Decrypt(c)
+	if err != nil {
+		return nil, err
+	}
+	if m.Cmp(new(big.Int).Rsh(sk.N, 1)) == 1 {
+		m.Sub(m, sk.N)
+	}
+	return m, nil
+}
@@ -0,0 +1,116 @@
+//
+// Copyright Coinbase, Inc. All Rights Reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package cggmp
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/nerifnetwork/kryptology/internal"
+	"github.com/nerifnetwork/kryptology/pkg/core"
+	"github.com/nerifnetwork/kryptology/pkg/core/curves"
+	"github.com/nerifnetwork/kryptology/pkg/paillier"
+)
+
+// LogStarProof proves that a Paillier ciphertext C encrypts the discrete log x in ±2^ℓ
+// of X = g^x to the holder of the ring-Pedersen parameters
+// [CGGMP21] fig 25
+type LogStarProof struct {
+	S, A       *big.Int
+	Y          curves.Point
+	D          *big.Int
+	Z1, Z2, Z3 *big.Int
+}
+
+// proveLogStar proves that C = enc(x; ρ) under pk and X = g^x
+func proveLogStar(sid []byte, id uint32, curve *curves.Curve, pk *paillier.PublicKey, ped *PedersenParams,
+	C *big.Int, X, g curves.Point, x, rho *big.Int) (*LogStarProof, error) {
+	q := order(curve)
+
+	// α <- ±2^{ℓ+ε}, μ <- ±2^ℓ·N̂, r <- Z_N0*, γ <- ±2^{ℓ+ε}·N̂
+	alpha, err := randSigned(l+epsilon, core.One)
+	if err != nil {
+		return nil, err
+	}
+	mu, err := randSigned(l, ped.N)
+	if err != nil {
+		return nil, err
+	}
+	r, err := randUnit(pk.N)
+	if err != nil {
+		return nil, err
+	}
+	gamma, err := randSigned(l+epsilon, ped.N)
+	if err != nil {
+		return nil, err
+	}
+
+	// S = s^x t^μ, A = enc(α; r), Y = g^α, D = s^α t^γ
+	proof := &LogStarProof{
+		A: encrypt(pk, alpha, r),
+		Y: g.Mul(scalar(curve, alpha)),
+	}
+	if proof.S, err = ped.commit(x, mu); err != nil {
+		return nil, err
+	}
+	if proof.D, err = ped.commit(alpha, gamma); err != nil {
+		return nil, err
+	}
+
+	// z1 = α + ex, z2 = rρ^e mod N0, z3 = γ + eμ
+	e := proof.challenge(sid, id, q, pk, ped, C, X, g)
+	proof.Z1 = new(big.Int).Add(alpha, new(big.Int).Mul(e, x))
+	proof.Z2 = mulExp(r, rho, e, pk.N)
+	proof.Z3 = new(big.Int).Add(gamma, new(big.Int).Mul(e, mu))
+	return proof, nil
+}
+
+// Verify checks that C encrypts the discrete log of X to the base g under pk
+func (p *LogStarProof) Verify(sid []byte, id uint32, curve *curves.Curve, pk *paillier.PublicKey, ped *PedersenParams,
+	C *big.Int, X, g curves.Point) error {
+	if p == nil || core.AnyNil(p.S, p.A, p.D, p.Z1, p.Z2, p.Z3, C) || p.Y == nil ||
+		X == nil || g == nil || pk == nil || ped == nil {
+		return internal.ErrNilArguments
+	}
+	if !isUnit(p.A, pk.N2) || !isUnit(C, pk.N2) || !isUnit(p.Z2, pk.N) ||
+		!isUnit(p.S, ped.N) || !isUnit(p.D, ped.N) ||
+		!onCurve(curve, p.Y) || !onCurve(curve, X) || !onCurve(curve, g) {
+		return fmt.Errorf("invalid discrete log proof")
+	}
+	// z1 ∈ ±2^{ℓ+ε}
+	if !inRange(p.Z1, l+epsilon) {
+		return fmt.Errorf("encrypted value is out of range")
+	}
+	e := p.challenge(sid, id, order(curve), pk, ped, C, X, g)
+
+	// enc(z1; z2) = A C^e mod N0²
+	if encrypt(pk, p.Z1, p.Z2).Cmp(mulExp(p.A, C, e, pk.N2)) != 0 {
+		return fmt.Errorf("discrete log proof is not valid")
+	}
+	// g^z1 = Y X^e
+	if !g.Mul(scalar(curve, p.Z1)).Equal(p.Y.Add(X.Mul(scalar(curve, e)))) {
+		return fmt.Errorf("discrete log proof is not valid")
+	}
+	// s^z1 t^z3 = D S^e mod N̂
+	ok, err := ped.verify(p.Z1, p.Z3, p.D, p.S, e)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return fmt.Errorf("discrete log proof is not valid")
+	}
+	return nil
+}
+
+func (p *LogStarProof) challenge(sid []byte, id uint32, q *big.Int, pk *paillier.PublicKey, ped *PedersenParams,
+	C *big.Int, X, g curves.Point) *big.Int {
+	t := newTranscript("log*", sid, id)
+	t.ints(pk.N, ped.N, ped.S, ped.T, C)
+	t.points(X, g, p.Y)
+	t.ints(p.S, p.A, p.D)
+	return t.challenge(q)
+}
@@ -0,0 +1,123 @@
+//
+// Copyright Coinbase, Inc. All Rights Reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package cggmp
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/nerifnetwork/kryptology/internal"
+	"github.com/nerifnetwork/kryptology/pkg/core"
+	"github.com/nerifnetwork/kryptology/pkg/core/curves"
+	"github.com/nerifnetwork/kryptology/pkg/paillier"
+)
+
+// MulStarProof proves that a Paillier ciphertext D = C^x ρ^N0 multiplies the plaintext of C by
+// the discrete log x in ±2^ℓ of X = g^x to the holder of the ring-Pedersen parameters
+// [CGGMP21] fig 31
+type MulStarProof struct {
+	A  *big.Int
+	Bx curves.Point
+	E  *big.Int
+	S  *big.Int
+	Z1 *big.Int
+	Z2 *big.Int
+	W  *big.Int
+}
+
+// proveMulStar proves that D = C^x ρ^N0 under pk and X = g^x
+func proveMulStar(sid []byte, id uint32, curve *curves.Curve, pk *paillier.PublicKey, ped *PedersenParams,
+	C, D *big.Int, X curves.Point, x, rho *big.Int) (*MulStarProof, error) {
+	q := order(curve)
+
+	// α <- ±2^{ℓ+ε}, r <- Z_N0*, γ <- ±2^{ℓ+ε}·N̂, m <- ±2^ℓ·N̂
+	alpha, err := randSigned(l+epsilon, core.One)
+	if err != nil {
+		return nil, err
+	}
+	r, err := randUnit(pk.N)
+	if err != nil {
+		return nil, err
+	}
+	gamma, err := randSigned(l+epsilon, ped.N)
+	if err != nil {
+		return nil, err
+	}
+	m, err := randSigned(l, ped.N)
+	if err != nil {
+		return nil, err
+	}
+
+	// A = C^α r^N0, Bx = g^α, E = s^α t^γ, S = s^x t^m
+	proof := &MulStarProof{Bx: curve.ScalarBaseMult(scalar(curve, alpha))}
+	if proof.A, err = expMul(C, alpha, r, pk.N, pk.N2); err != nil {
+		return nil, err
+	}
+	if proof.E, err = ped.commit(alpha, gamma); err != nil {
+		return nil, err
+	}
+	if proof.S, err = ped.commit(x, m); err != nil {
+		return nil, err
+	}
+
+	// z1 = α + ex, z2 = γ + em, w = rρ^e mod N0
+	e := proof.challenge(sid, id, q, pk, ped, C, D, X)
+	proof.Z1 = new(big.Int).Add(alpha, new(big.Int).Mul(e, x))
+	proof.Z2 = new(big.Int).Add(gamma, new(big.Int).Mul(e, m))
+	proof.W = mulExp(r, rho, e, pk.N)
+	return proof, nil
+}
+
+// Verify checks that D multiplies the plaintext of C by the discrete log of X under pk
+func (p *MulStarProof) Verify(sid []byte, id uint32, curve *curves.Curve, pk *paillier.PublicKey, ped *PedersenParams,
+	C, D *big.Int, X curves.Point) error {
+	if p == nil || core.AnyNil(p.A, p.E, p.S, p.Z1, p.Z2, p.W, C, D) || p.Bx == nil ||
+		X == nil || pk == nil || ped == nil {
+		return internal.ErrNilArguments
+	}
+	if !isUnit(p.A, pk.N2) || !isUnit(C, pk.N2) || !isUnit(D, pk.N2) || !isUnit(p.W, pk.N) ||
+		!isUnit(p.E, ped.N) || !isUnit(p.S, ped.N) ||
+		!onCurve(curve, p.Bx) || !onCurve(curve, X) {
+		return fmt.Errorf("invalid multiplication proof")
+	}
+	// z1 ∈ ±2^{ℓ+ε}
+	if !inRange(p.Z1, l+epsilon) {
+		return fmt.Errorf("multiplication is out of range")
+	}
+	e := p.challenge(sid, id, order(curve), pk, ped, C, D, X)
+
+	// C^z1 w^N0 = A D^e mod N0²
+	lhs, err := expMul(C, p.Z1, p.W, pk.N, pk.N2)
+	if err != nil {
+		return err
+	}
+	if lhs.Cmp(mulExp(p.A, D, e, pk.N2)) != 0 {
+		return fmt.Errorf("multiplication proof is not valid")
+	}
+	// g^z1 = Bx X^e
+	if !curve.ScalarBaseMult(scalar(curve, p.Z1)).Equal(p.Bx.Add(X.Mul(scalar(curve, e)))) {
+		return fmt.Errorf("multiplication proof is not valid")
+	}
+	// s^z1 t^z2 = E S^e mod N̂
+	ok, err := ped.verify(p.Z1, p.Z2, p.E, p.S, e)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return fmt.Errorf("multiplication proof is not valid")
+	}
+	return nil
+}
+
+func (p *MulStarProof) challenge(sid []byte, id uint32, q *big.Int, pk *paillier.PublicKey, ped *PedersenParams,
+	C, D *big.Int, X curves.Point) *big.Int {
+	t := newTranscript("mul*", sid, id)
+	t.ints(pk.N, ped.N, ped.S, ped.T, C, D)
+	t.points(X, p.Bx)
+	t.ints(p.A, p.E, p.S)
+	return t.challenge(q)
+}
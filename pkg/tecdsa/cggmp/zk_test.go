@@ -0,0 +1,214 @@
+//
+// Copyright Coinbase, Inc. All Rights Reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package cggmp
+
+import (
+	crand "crypto/rand"
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/nerifnetwork/kryptology/pkg/core"
+	"github.com/nerifnetwork/kryptology/pkg/core/curves"
+	"github.com/nerifnetwork/kryptology/pkg/paillier"
+)
+
+var testSid = []byte("cggmp test session")
+
+// testPedersen returns ring-Pedersen parameters of the fixtures
+func testPedersen(t *testing.T, id uint32) (*PedersenParams, *big.Int, *paillier.SecretKey) {
+	sk := testSecretKey(t, id)
+	ped, lambda, err := newPedersenParams(sk.N, sk.Totient)
+	require.NoError(t, err)
+	return ped, lambda, sk
+}
+
+func TestPrmProof(t *testing.T) {
+	ped, lambda, sk := testPedersen(t, 1)
+	proof, err := provePrm(testSid, 1, ped, lambda, sk.Totient)
+	require.NoError(t, err)
+	require.NoError(t, proof.Verify(testSid, 1, ped))
+
+	// The proof is bound to the session and the prover
+	require.Error(t, proof.Verify([]byte("other session"), 1, ped))
+	require.Error(t, proof.Verify(testSid, 2, ped))
+
+	// s is not in the group generated by t
+	bad := &PedersenParams{N: ped.N, S: new(big.Int).Sub(ped.N, ped.S), T: ped.T}
+	require.Error(t, proof.Verify(testSid, 1, bad))
+	proof.Z[3] = new(big.Int).Add(proof.Z[3], core.One)
+	require.Error(t, proof.Verify(testSid, 1, ped))
+	proof.Z = proof.Z[1:]
+	require.Error(t, proof.Verify(testSid, 1, ped))
+}
+
+func TestEncryptSigned(t *testing.T) {
+	sk := testSecretKey(t, 1)
+	for _, m := range []int64{0, 1, -1, 1 << 40, -(1 << 40)} {
+		r, err := randUnit(sk.N)
+		require.NoError(t, err)
+		c := encrypt(&sk.PublicKey, big.NewInt(m), r)
+		d, err := decrypt(sk, c)
+		require.NoError(t, err)
+		require.Equal(t, big.NewInt(m), d)
+	}
+}
+
+func TestEncProof(t *testing.T) {
+	curve := curves.K256()
+	q := order(curve)
+	ped, _, _ := testPedersen(t, 1)
+	sk := testSecretKey(t, 2)
+	pk := &sk.PublicKey
+
+	k := curve.Scalar.Random(crand.Reader).BigInt()
+	rho, err := randUnit(pk.N)
+	require.NoError(t, err)
+	K := encrypt(pk, k, rho)
+	proof, err := proveEnc(testSid, 2, q, pk, ped, K, k, rho)
+	require.NoError(t, err)
+	require.NoError(t, proof.Verify(testSid, 2, q, pk, ped, K))
+	require.Error(t, proof.Verify(testSid, 3, q, pk, ped, K))
+	require.Error(t, proof.Verify(testSid, 2, q, pk, ped, encrypt(pk, k, core.One)))
+
+	// A plaintext out of range fails
+	large := new(big.Int).Lsh(core.One, l+epsilon+1)
+	K = encrypt(pk, large, rho)
+	proof, err = proveEnc(testSid, 2, q, pk, ped, K, large, rho)
+	require.NoError(t, err)
+	require.Error(t, proof.Verify(testSid, 2, q, pk, ped, K))
+	require.Error(t, (*EncProof)(nil).Verify(testSid, 2, q, pk, ped, K))
+}
+
+func TestLogStarProof(t *testing.T) {
+	curve := curves.K256()
+	ped, _, _ := testPedersen(t, 1)
+	sk := testSecretKey(t, 2)
+	pk := &sk.PublicKey
+	g := curve.ScalarBaseMult(curve.Scalar.Random(crand.Reader))
+
+	x := curve.Scalar.Random(crand.Reader)
+	rho, err := randUnit(pk.N)
+	require.NoError(t, err)
+	C := encrypt(pk, x.BigInt(), rho)
+	X := g.Mul(x)
+	proof, err := proveLogStar(testSid, 2, curve, pk, ped, C, X, g, x.BigInt(), rho)
+	require.NoError(t, err)
+	require.NoError(t, proof.Verify(testSid, 2, curve, pk, ped, C, X, g))
+	require.Error(t, proof.Verify(testSid, 2, curve, pk, ped, C, X.Add(g), g))
+	require.Error(t, proof.Verify(testSid, 2, curve, pk, ped, C, X, curve.Point.Generator()))
+	require.Error(t, proof.Verify(testSid, 1, curve, pk, ped, C, X, g))
+	proof.Y = nil
+	require.Error(t, proof.Verify(testSid, 2, curve, pk, ped, C, X, g))
+}
+
+func TestAffgProof(t *testing.T) {
+	curve := curves.K256()
+	ped, _, sk0 := testPedersen(t, 1)
+	sk1 := testSecretKey(t, 2)
+	pk0, pk1 := &sk0.PublicKey, &sk1.PublicKey
+
+	// C = enc0(k), D = C^x enc0(y), Y = enc1(y)
+	k := curve.Scalar.Random(crand.Reader).BigInt()
+	r0, err := randUnit(pk0.N)
+	require.NoError(t, err)
+	C := encrypt(pk0, k, r0)
+	x := curve.Scalar.Random(crand.Reader)
+	y, err := randSigned(lPrime, core.One)
+	require.NoError(t, err)
+	rho, err := randUnit(pk0.N)
+	require.NoError(t, err)
+	rhoY, err := randUnit(pk1.N)
+	require.NoError(t, err)
+	D := new(big.Int).Exp(C, x.BigInt(), pk0.N2)
+	D.Mul(D, encrypt(pk0, y, rho))
+	D.Mod(D, pk0.N2)
+	st := &affgStatement{pk0: pk0, pk1: pk1, C: C, D: D, Y: encrypt(pk1, y, rhoY), X: curve.ScalarBaseMult(x)}
+
+	proof, err := proveAffg(testSid, 2, curve, ped, st, &affgWitness{x: x.BigInt(), y: y, rho: rho, rhoY: rhoY})
+	require.NoError(t, err)
+	require.NoError(t, proof.verify(testSid, 2, curve, ped, st))
+
+	// D decrypts to kx + y
+	d, err := decrypt(sk0, D)
+	require.NoError(t, err)
+	expected := new(big.Int).Mul(k, x.BigInt())
+	require.Equal(t, expected.Add(expected, y), d)
+
+	require.Error(t, proof.verify(testSid, 3, curve, ped, st))
+	wrongX := *st
+	wrongX.X = st.X.Double()
+	require.Error(t, proof.verify(testSid, 2, curve, ped, &wrongX))
+	wrongY := *st
+	wrongY.Y = encrypt(pk1, new(big.Int).Add(y, core.One), rhoY)
+	require.Error(t, proof.verify(testSid, 2, curve, ped, &wrongY))
+	wrongD := *st
+	wrongD.D = new(big.Int).Mod(new(big.Int).Mul(D, C), pk0.N2)
+	require.Error(t, proof.verify(testSid, 2, curve, ped, &wrongD))
+	proof.Z2 = new(big.Int).Lsh(core.One, lPrime+epsilon+1)
+	require.Error(t, proof.verify(testSid, 2, curve, ped, st))
+}
+
+func TestMulStarProof(t *testing.T) {
+	curve := curves.K256()
+	ped, _, _ := testPedersen(t, 1)
+	sk := testSecretKey(t, 2)
+	pk := &sk.PublicKey
+
+	// C = enc(k), D = C^x ρ^N
+	r, err := randUnit(pk.N)
+	require.NoError(t, err)
+	C := encrypt(pk, curve.Scalar.Random(crand.Reader).BigInt(), r)
+	x := curve.Scalar.Random(crand.Reader)
+	rho, err := randUnit(pk.N)
+	require.NoError(t, err)
+	D, err := expMul(C, x.BigInt(), rho, pk.N, pk.N2)
+	require.NoError(t, err)
+	X := curve.ScalarBaseMult(x)
+
+	proof, err := proveMulStar(testSid, 2, curve, pk, ped, C, D, X, x.BigInt(), rho)
+	require.NoError(t, err)
+	require.NoError(t, proof.Verify(testSid, 2, curve, pk, ped, C, D, X))
+	require.Error(t, proof.Verify(testSid, 1, curve, pk, ped, C, D, X))
+	require.Error(t, proof.Verify(testSid, 2, curve, pk, ped, C, D, X.Double()))
+	require.Error(t, proof.Verify(testSid, 2, curve, pk, ped, C, new(big.Int).Mod(new(big.Int).Mul(D, C), pk.N2), X))
+	proof.Bx = nil
+	require.Error(t, proof.Verify(testSid, 2, curve, pk, ped, C, D, X))
+}
+
+func TestDecProof(t *testing.T) {
+	curve := curves.K256()
+	ped, _, _ := testPedersen(t, 1)
+	sk := testSecretKey(t, 2)
+	pk := &sk.PublicKey
+	g := curve.ScalarBaseMult(curve.Scalar.Random(crand.Reader))
+
+	// A plaintext larger than q that is only known mod q in the exponent
+	y, err := randSigned(lPrime+8, core.One)
+	require.NoError(t, err)
+	rho, err := randUnit(pk.N)
+	require.NoError(t, err)
+	C := encrypt(pk, y, rho)
+	recovered, err := randomness(sk, C, y)
+	require.NoError(t, err)
+	require.Equal(t, rho, recovered)
+	Y := g.Mul(scalar(curve, y))
+
+	proof, err := proveDec(testSid, 2, curve, pk, ped, C, Y, g, y, rho)
+	require.NoError(t, err)
+	require.NoError(t, proof.Verify(testSid, 2, curve, pk, ped, C, Y, g))
+	require.Error(t, proof.Verify(testSid, 3, curve, pk, ped, C, Y, g))
+	require.Error(t, proof.Verify(testSid, 2, curve, pk, ped, C, Y.Add(g), g))
+	require.Error(t, proof.Verify(testSid, 2, curve, pk, ped, encrypt(pk, new(big.Int).Add(y, core.One), rho), Y, g))
+
+	// The plaintext must be in range
+	_, err = proveDec(testSid, 2, curve, pk, ped, C, Y, g, new(big.Int).Lsh(core.One, decBits+1), rho)
+	require.Error(t, err)
+	proof.Z1 = new(big.Int).Lsh(core.One, decMask+2)
+	require.Error(t, proof.Verify(testSid, 2, curve, pk, ped, C, Y, g))
+}
@@ -8,6 +8,7 @@ import (
 	"github.com/nerifnetwork/kryptology/pkg/core/curves"
 	"github.com/nerifnetwork/kryptology/pkg/core/protocol"
 	"github.com/nerifnetwork/kryptology/pkg/tecdsa/dkls/v1/dkg"
+	"github.com/nerifnetwork/kryptology/pkg/tecdsa/dkls/v1/export"
 	"github.com/nerifnetwork/kryptology/pkg/tecdsa/dkls/v1/refresh"
 	"github.com/nerifnetwork/kryptology/pkg/tecdsa/dkls/v1/sign"
 )
@@ -36,6 +37,20 @@ type BobSign struct {
 	*sign.Bob
 }
 
+// AlicePresign DKLS presign implementation that satisfies the protocol iterator interface.
+type AlicePresign struct {
+	protoStepper
+	*sign.Alice
+	presignature *sign.AlicePresignature
+}
+
+// BobPresign DKLS presign implementation that satisfies the protocol iterator interface.
+type BobPresign struct {
+	protoStepper
+	*sign.Bob
+	presignature *sign.BobPresignature
+}
+
 // AliceRefresh DKLS refresh implementation that satisfies the protocol iterator interface.
 type AliceRefresh struct {
 	protoStepper
@@ -48,19 +63,45 @@ type BobRefresh struct {
 	*refresh.Bob
 }
 
+// AliceExport DKLS key export implementation that satisfies the protocol iterator interface.
+type AliceExport struct {
+	protoStepper
+	*export.Alice
+}
+
+// BobExport DKLS key export implementation that satisfies the protocol iterator interface.
+type BobExport struct {
+	protoStepper
+	*export.Bob
+}
+
 var (
 	// Static type assertions
 	_ protocol.Iterator = &AliceDkg{}
 	_ protocol.Iterator = &BobDkg{}
 	_ protocol.Iterator = &AliceSign{}
 	_ protocol.Iterator = &BobSign{}
+	_ protocol.Iterator = &AlicePresign{}
+	_ protocol.Iterator = &BobPresign{}
 	_ protocol.Iterator = &AliceRefresh{}
 	_ protocol.Iterator = &BobRefresh{}
+	_ protocol.Iterator = &AliceExport{}
+	_ protocol.Iterator = &BobExport{}
 )
 
 // NewAliceDkg creates a new protocol that can compute a DKG as Alice
 func NewAliceDkg(curve *curves.Curve, version uint) *AliceDkg {
-	a := &AliceDkg{Alice: dkg.NewAlice(curve)}
+	return newAliceDkg(dkg.NewAlice(curve), version)
+}
+
+// NewAliceDkgWithKeyShare creates a new protocol that computes a DKG as Alice for an existing key, see
+// dealer.SplitKey. The key share must be deleted once DKG completes.
+func NewAliceDkgWithKeyShare(curve *curves.Curve, share *dkg.KeyShare, version uint) *AliceDkg {
+	return newAliceDkg(dkg.NewAliceWithKeyShare(curve, share), version)
+}
+
+func newAliceDkg(alice *dkg.Alice, version uint) *AliceDkg {
+	a := &AliceDkg{Alice: alice}
 	a.steps = []func(*protocol.Message) (*protocol.Message, error){
 		func(input *protocol.Message) (*protocol.Message, error) {
 			bobSeed, err := decodeDkgRound2Input(input)
@@ -136,7 +177,17 @@ func (a *AliceDkg) Result(version uint) (*protocol.Message, error) {
 
 // NewBobDkg Creates a new protocol that can compute a DKG as Bob.
 func NewBobDkg(curve *curves.Curve, version uint) *BobDkg {
-	b := &BobDkg{Bob: dkg.NewBob(curve)}
+	return newBobDkg(dkg.NewBob(curve), version)
+}
+
+// NewBobDkgWithKeyShare creates a new protocol that computes a DKG as Bob for an existing key, see
+// NewAliceDkgWithKeyShare.
+func NewBobDkgWithKeyShare(curve *curves.Curve, share *dkg.KeyShare, version uint) *BobDkg {
+	return newBobDkg(dkg.NewBobWithKeyShare(curve, share), version)
+}
+
+func newBobDkg(bob *dkg.Bob, version uint) *BobDkg {
+	b := &BobDkg{Bob: bob}
 	b.steps = []func(message *protocol.Message) (*protocol.Message, error){
 		func(*protocol.Message) (*protocol.Message, error) {
 			commitment, err := b.Round1GenerateRandomSeed()
@@ -292,6 +343,129 @@ func (b *BobSign) Result(version uint) (*protocol.Message, error) {
 	return encodeSignature(b.Bob.Signature, version)
 }
 
+// NewAlicePresign creates a new protocol that computes a presignature as Alice. It runs the rounds of AliceSign
+// without a message. Requires dkg state that was produced at the end of DKG.Output().
+func NewAlicePresign(curve *curves.Curve, dkgResultMessage *protocol.Message, version uint) (*AlicePresign, error) {
+	dkgResult, err := DecodeAliceDkgResult(dkgResultMessage)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	// The message hash is only given to AliceSignPresigned, so no hash function is needed
+	a := &AlicePresign{Alice: sign.NewAlice(curve, nil, dkgResult)}
+	a.steps = []func(message *protocol.Message) (*protocol.Message, error){
+		func(*protocol.Message) (*protocol.Message, error) {
+			aliceCommitment, err := a.Round1GenerateRandomSeed()
+			if err != nil {
+				return nil, err
+			}
+			return encodeSignRound1Output(aliceCommitment, version)
+		},
+		func(input *protocol.Message) (*protocol.Message, error) {
+			round2Output, err := decodeSignRound3Input(input)
+			if err != nil {
+				return nil, errors.WithStack(err)
+			}
+			round3Output, presignature, err := a.Round3Presign(round2Output)
+			if err != nil {
+				return nil, errors.WithStack(err)
+			}
+			a.presignature = presignature
+			return encodeSignRound3Output(round3Output, version)
+		},
+	}
+	return a, nil
+}
+
+// NewBobPresign creates a new protocol that computes a presignature as Bob. It runs the rounds of BobSign
+// without a message. Requires dkg state that was produced at the end of DKG.Output().
+func NewBobPresign(curve *curves.Curve, dkgResultMessage *protocol.Message, version uint) (*BobPresign, error) {
+	dkgResult, err := DecodeBobDkgResult(dkgResultMessage)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	b := &BobPresign{Bob: sign.NewBob(curve, nil, dkgResult)}
+	b.steps = []func(message *protocol.Message) (*protocol.Message, error){
+		func(input *protocol.Message) (*protocol.Message, error) {
+			commitment, err := decodeSignRound2Input(input)
+			if err != nil {
+				return nil, errors.WithStack(err)
+			}
+			round2Output, err := b.Round2Initialize(commitment)
+			if err != nil {
+				return nil, errors.WithStack(err)
+			}
+			return encodeSignRound2Output(round2Output, version)
+		},
+		func(input *protocol.Message) (*protocol.Message, error) {
+			round4Input, err := decodeSignRound4Input(input)
+			if err != nil {
+				return nil, errors.WithStack(err)
+			}
+			if b.presignature, err = b.Round4Presign(round4Input); err != nil {
+				return nil, errors.WithStack(err)
+			}
+			return nil, nil
+		},
+	}
+	return b, nil
+}
+
+// Result returns Alice's encoded presignature if the presign protocol completed successfully.
+// The presignature signs a single message hash with AliceSignPresigned.
+func (a *AlicePresign) Result(version uint) (*protocol.Message, error) {
+	if !a.complete() {
+		return nil, nil
+	}
+	if a.Alice == nil {
+		return nil, protocol.ErrNotInitialized
+	}
+	return EncodeAlicePresignature(a.presignature, version)
+}
+
+// Result returns Bob's encoded presignature if the presign protocol completed successfully.
+// The presignature completes a single signature with BobSignPresigned.
+func (b *BobPresign) Result(version uint) (*protocol.Message, error) {
+	if !b.complete() {
+		return nil, nil
+	}
+	if b.Bob == nil {
+		return nil, protocol.ErrNotInitialized
+	}
+	return EncodeBobPresignature(b.presignature, version)
+}
+
+// AliceSignPresigned signs the message hash digest with Alice's encoded presignature and returns the message for Bob.
+// The presignature must be deleted before the message is sent, it cannot be used again.
+func AliceSignPresigned(presignatureMessage *protocol.Message, digest []byte, version uint) (*protocol.Message, error) {
+	presignature, err := DecodeAlicePresignature(presignatureMessage)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	output, err := presignature.Sign(digest)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return encodePresignOutput(output, version)
+}
+
+// BobSignPresigned completes the signature of the message hash digest with Bob's encoded presignature and the message
+// of AliceSignPresigned. It returns the encoded signature, see DecodeSignature. The presignature must be deleted.
+func BobSignPresigned(presignatureMessage *protocol.Message, digest []byte, aliceMessage *protocol.Message, version uint) (*protocol.Message, error) {
+	presignature, err := DecodeBobPresignature(presignatureMessage)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	input, err := decodePresignOutput(aliceMessage)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	signature, err := presignature.Sign(digest, input)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return encodeSignature(signature, version)
+}
+
 // NewAliceRefresh creates a new protocol that can compute a key refresh as Alice
 func NewAliceRefresh(curve *curves.Curve, dkgResultMessage *protocol.Message, version uint) (*AliceRefresh, error) {
 	dkgResult, err := DecodeAliceDkgResult(dkgResultMessage)
@@ -414,3 +588,77 @@ func (b *BobRefresh) Result(version uint) (*protocol.Message, error) {
 	result := b.Output()
 	return EncodeBobDkgOutput(result, version)
 }
+
+// NewAliceExport creates a new protocol that exports the joint secret key as Alice. Both parties learn the secret key.
+func NewAliceExport(curve *curves.Curve, dkgResultMessage *protocol.Message, version uint) (*AliceExport, error) {
+	dkgResult, err := DecodeAliceDkgResult(dkgResultMessage)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	a := &AliceExport{Alice: export.NewAlice(curve, dkgResult)}
+	a.steps = []func(*protocol.Message) (*protocol.Message, error){
+		func(*protocol.Message) (*protocol.Message, error) {
+			aliceSecretKeyShare, err := a.Round1RevealShare()
+			if err != nil {
+				return nil, err
+			}
+			return encodeExportRound1Output(aliceSecretKeyShare, version)
+		},
+		func(input *protocol.Message) (*protocol.Message, error) {
+			bobSecretKeyShare, err := decodeExportRound3Input(input)
+			if err != nil {
+				return nil, errors.WithStack(err)
+			}
+			if err = a.Round3Reconstruct(bobSecretKeyShare); err != nil {
+				return nil, errors.WithStack(err)
+			}
+			return nil, nil
+		},
+	}
+	return a, nil
+}
+
+// NewBobExport creates a new protocol that exports the joint secret key as Bob. Both parties learn the secret key.
+func NewBobExport(curve *curves.Curve, dkgResultMessage *protocol.Message, version uint) (*BobExport, error) {
+	dkgResult, err := DecodeBobDkgResult(dkgResultMessage)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	b := &BobExport{Bob: export.NewBob(curve, dkgResult)}
+	b.steps = []func(*protocol.Message) (*protocol.Message, error){
+		func(input *protocol.Message) (*protocol.Message, error) {
+			aliceSecretKeyShare, err := decodeExportRound2Input(input)
+			if err != nil {
+				return nil, errors.WithStack(err)
+			}
+			bobSecretKeyShare, err := b.Round2Reconstruct(aliceSecretKeyShare)
+			if err != nil {
+				return nil, errors.WithStack(err)
+			}
+			return encodeExportRound2Output(bobSecretKeyShare, version)
+		},
+	}
+	return b, nil
+}
+
+// Result returns the exported secret key if the export protocol completed successfully, see DecodeExportedKey.
+func (a *AliceExport) Result(version uint) (*protocol.Message, error) {
+	if !a.complete() {
+		return nil, nil
+	}
+	if a.Alice == nil {
+		return nil, protocol.ErrNotInitialized
+	}
+	return encodeExportedKey(a.Output(), version)
+}
+
+// Result returns the exported secret key if the export protocol completed successfully, see DecodeExportedKey.
+func (b *BobExport) Result(version uint) (*protocol.Message, error) {
+	if !b.complete() {
+		return nil, nil
+	}
+	if b.Bob == nil {
+		return nil, protocol.ErrNotInitialized
+	}
+	return encodeExportedKey(b.Output(), version)
+}
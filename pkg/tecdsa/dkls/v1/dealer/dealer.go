@@ -18,6 +18,7 @@ import (
 	"github.com/nerifnetwork/kryptology/pkg/core/curves"
 	"github.com/nerifnetwork/kryptology/pkg/ot/base/simplest"
 	"github.com/nerifnetwork/kryptology/pkg/ot/extension/kos"
+	"github.com/nerifnetwork/kryptology/pkg/tecdsa/bip32"
 	"github.com/nerifnetwork/kryptology/pkg/tecdsa/dkls/v1/dkg"
 )
 
@@ -30,15 +31,91 @@ func GenerateAndDeal(curve *curves.Curve) (*dkg.AliceOutput, *dkg.BobOutput, err
 	if err != nil {
 		return nil, nil, errors.Wrap(err, "couldn't produce OT results")
 	}
+	chainCode, err := bip32.NewChainCode()
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "couldn't produce chain code")
+	}
 	alice := &dkg.AliceOutput{
 		PublicKey:      publicKey,
 		SecretKeyShare: aliceSecretShare,
 		SeedOtResult:   aliceOTOutput,
+		ChainCode:      chainCode,
 	}
 	bob := &dkg.BobOutput{
 		PublicKey:      publicKey,
 		SecretKeyShare: bobSecretShare,
 		SeedOtResult:   bobOTOutput,
+		ChainCode:      chainCode,
+	}
+	return alice, bob, nil
+}
+
+// ImportAndDeal splits an existing secret key into key material for alice and bob, like GenerateAndDeal.
+// The chain code of the key is kept, a nil chain code is replaced by a random one.
+// The caller holds the whole key, so it must delete the key and the outputs once they are handed over.
+// SplitKey followed by DKG with the key shares avoids dealing the seed OT results.
+func ImportAndDeal(curve *curves.Curve, secretKey curves.Scalar, chainCode []byte) (*dkg.AliceOutput, *dkg.BobOutput, error) {
+	if chainCode == nil {
+		var err error
+		if chainCode, err = bip32.NewChainCode(); err != nil {
+			return nil, nil, errors.Wrap(err, "couldn't produce chain code")
+		}
+	}
+	aliceShare, bobShare, err := SplitKey(curve, secretKey, chainCode)
+	if err != nil {
+		return nil, nil, err
+	}
+	aliceOTOutput, bobOTOutput, err := produceOTResults(curve)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "couldn't produce OT results")
+	}
+	alice := &dkg.AliceOutput{
+		PublicKey:      aliceShare.PublicKey,
+		SecretKeyShare: aliceShare.SecretKeyShare,
+		SeedOtResult:   aliceOTOutput,
+		ChainCode:      chainCode,
+	}
+	bob := &dkg.BobOutput{
+		PublicKey:      bobShare.PublicKey,
+		SecretKeyShare: bobShare.SecretKeyShare,
+		SeedOtResult:   bobOTOutput,
+		ChainCode:      chainCode,
+	}
+	return alice, bob, nil
+}
+
+// SplitKey splits an existing secret key into shares for alice and bob, whose product is the secret key.
+// Alice and Bob run DKG with their shares, see dkg.NewAliceWithKeyShare, which also runs the seed OT between them.
+// The key holder must delete the key and the shares once they are handed over. When chainCode is nil,
+// the DKG agrees on a new chain code.
+func SplitKey(curve *curves.Curve, secretKey curves.Scalar, chainCode []byte) (*dkg.KeyShare, *dkg.KeyShare, error) {
+	if curve == nil || secretKey == nil {
+		return nil, nil, errors.New("curve and secret key cannot be nil")
+	}
+	if secretKey.IsZero() {
+		return nil, nil, errors.New("secret key cannot be zero")
+	}
+	if chainCode != nil && len(chainCode) != bip32.ChainCodeSize {
+		return nil, nil, errors.Errorf("chain code must be %d bytes", bip32.ChainCodeSize)
+	}
+	aliceSecretShare := curve.Scalar.Random(rand.Reader)
+	for aliceSecretShare.IsZero() {
+		aliceSecretShare = curve.Scalar.Random(rand.Reader)
+	}
+	bobSecretShare := secretKey.Div(aliceSecretShare)
+	publicKey := curve.ScalarBaseMult(secretKey)
+	if bobSecretShare == nil || publicKey == nil {
+		return nil, nil, errors.Errorf("secret key is not a scalar of %s", curve.Name)
+	}
+	alice := &dkg.KeyShare{
+		PublicKey:      publicKey,
+		SecretKeyShare: aliceSecretShare,
+		ChainCode:      chainCode,
+	}
+	bob := &dkg.KeyShare{
+		PublicKey:      publicKey,
+		SecretKeyShare: bobSecretShare,
+		ChainCode:      chainCode,
 	}
 	return alice, bob, nil
 }
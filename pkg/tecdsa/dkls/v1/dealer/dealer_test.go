@@ -1,12 +1,14 @@
 package dealer_test
 
 import (
+	"crypto/rand"
 	"testing"
 
 	"github.com/stretchr/testify/require"
 	"golang.org/x/crypto/sha3"
 
 	"github.com/nerifnetwork/kryptology/pkg/core/curves"
+	"github.com/nerifnetwork/kryptology/pkg/tecdsa/bip32"
 	"github.com/nerifnetwork/kryptology/pkg/tecdsa/dkls/v1/dealer"
 	"github.com/nerifnetwork/kryptology/pkg/tecdsa/dkls/v1/sign"
 )
@@ -47,3 +49,61 @@ func Test_DealerGeneratesDifferentResultsEachTime(t *testing.T) {
 	require.NotEqualValues(t, aliceOutput1.SeedOtResult.RandomChoiceBits, aliceOutput2.SeedOtResult.RandomChoiceBits)
 	require.NotEqualValues(t, bobOutput1.SeedOtResult.OneTimePadEncryptionKeys, bobOutput2.SeedOtResult.OneTimePadEncryptionKeys)
 }
+
+func Test_DealerCanImportKeysThatSign(t *testing.T) {
+	for _, curve := range []*curves.Curve{curves.K256(), curves.P256()} {
+		secretKey := curve.Scalar.Random(rand.Reader)
+		chainCode, err := bip32.NewChainCode()
+		require.NoError(t, err)
+		aliceOutput, bobOutput, err := dealer.ImportAndDeal(curve, secretKey, chainCode)
+		require.NoError(t, err)
+		require.True(t, curve.ScalarBaseMult(secretKey).Equal(aliceOutput.PublicKey))
+		require.True(t, aliceOutput.PublicKey.Equal(bobOutput.PublicKey))
+		require.Equal(t, 0, secretKey.Cmp(aliceOutput.SecretKeyShare.Mul(bobOutput.SecretKeyShare)))
+		require.Equal(t, chainCode, aliceOutput.ChainCode)
+		require.Equal(t, chainCode, bobOutput.ChainCode)
+
+		alice := sign.NewAlice(curve, sha3.New256(), aliceOutput)
+		bob := sign.NewBob(curve, sha3.New256(), bobOutput)
+		message := []byte("A message.")
+		seed, err := alice.Round1GenerateRandomSeed()
+		require.NoError(t, err)
+		round3Output, err := bob.Round2Initialize(seed)
+		require.NoError(t, err)
+		round4Output, err := alice.Round3Sign(message, round3Output)
+		require.NoError(t, err)
+		err = bob.Round4Final(message, round4Output)
+		require.NoError(t, err, "curve: %s", curve.Name)
+	}
+
+	// Without a chain code a random one is dealt
+	aliceOutput, bobOutput, err := dealer.ImportAndDeal(curves.K256(), curves.K256().Scalar.Random(rand.Reader), nil)
+	require.NoError(t, err)
+	require.Len(t, aliceOutput.ChainCode, bip32.ChainCodeSize)
+	require.Equal(t, aliceOutput.ChainCode, bobOutput.ChainCode)
+}
+
+func Test_DealerSplitKey(t *testing.T) {
+	curve := curves.K256()
+	secretKey := curve.Scalar.Random(rand.Reader)
+	aliceShare, bobShare, err := dealer.SplitKey(curve, secretKey, nil)
+	require.NoError(t, err)
+	require.Equal(t, 0, secretKey.Cmp(aliceShare.SecretKeyShare.Mul(bobShare.SecretKeyShare)))
+	require.True(t, curve.ScalarBaseMult(secretKey).Equal(aliceShare.PublicKey))
+	require.True(t, aliceShare.PublicKey.Equal(bobShare.PublicKey))
+	require.Nil(t, aliceShare.ChainCode)
+
+	// Every split is different
+	otherShare, _, err := dealer.SplitKey(curve, secretKey, nil)
+	require.NoError(t, err)
+	require.NotEqual(t, 0, aliceShare.SecretKeyShare.Cmp(otherShare.SecretKeyShare))
+
+	_, _, err = dealer.SplitKey(curve, nil, nil)
+	require.Error(t, err)
+	_, _, err = dealer.SplitKey(curve, curve.Scalar.Zero(), nil)
+	require.Error(t, err)
+	_, _, err = dealer.SplitKey(curve, secretKey, []byte{1, 2, 3})
+	require.Error(t, err)
+	_, _, err = dealer.SplitKey(curve, curves.P256().Scalar.Random(rand.Reader), nil)
+	require.Error(t, err)
+}
@@ -0,0 +1,69 @@
+//
+// Copyright Coinbase, Inc. All Rights Reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package dkg
+
+import (
+	"github.com/pkg/errors"
+
+	"github.com/nerifnetwork/kryptology/pkg/core/curves"
+	"github.com/nerifnetwork/kryptology/pkg/tecdsa/bip32"
+)
+
+// Derive returns Alice's output for the non-hardened BIP-32 child of the joint public key at path.
+// The secret key is the product of the two secret key shares, so the additive tweak of the path cannot be folded
+// into one share. It is kept in `Tweak` instead and signing adds it to the key. Deriving needs no interaction,
+// Bob derives his output for the same path on his own.
+func (output *AliceOutput) Derive(path []uint32) (*AliceOutput, error) {
+	if output == nil {
+		return nil, errors.New("alice output cannot be nil")
+	}
+	derivation, tweak, err := derive(output.PublicKey, output.ChainCode, output.Tweak, path)
+	if err != nil {
+		return nil, err
+	}
+	return &AliceOutput{
+		PublicKey:      derivation.PublicKey,
+		SecretKeyShare: output.SecretKeyShare,
+		SeedOtResult:   output.SeedOtResult,
+		ChainCode:      derivation.ChainCode,
+		Tweak:          tweak,
+	}, nil
+}
+
+// Derive returns Bob's output for the non-hardened BIP-32 child of the joint public key at path.
+// See AliceOutput.Derive.
+func (output *BobOutput) Derive(path []uint32) (*BobOutput, error) {
+	if output == nil {
+		return nil, errors.New("bob output cannot be nil")
+	}
+	derivation, tweak, err := derive(output.PublicKey, output.ChainCode, output.Tweak, path)
+	if err != nil {
+		return nil, err
+	}
+	return &BobOutput{
+		PublicKey:      derivation.PublicKey,
+		SecretKeyShare: output.SecretKeyShare,
+		SeedOtResult:   output.SeedOtResult,
+		ChainCode:      derivation.ChainCode,
+		Tweak:          tweak,
+	}, nil
+}
+
+// derive derives the child at path and adds its tweak to the tweak of the output, if any
+func derive(publicKey curves.Point, chainCode []byte, tweak curves.Scalar, path []uint32) (*bip32.Derivation, curves.Scalar, error) {
+	if len(chainCode) == 0 {
+		return nil, nil, errors.New("the output has no chain code")
+	}
+	derivation, err := bip32.Derive(publicKey, chainCode, path)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "deriving bip32 child key")
+	}
+	if tweak == nil {
+		return derivation, derivation.Tweak, nil
+	}
+	return derivation, tweak.Add(derivation.Tweak), nil
+}
@@ -0,0 +1,64 @@
+//
+// Copyright Coinbase, Inc. All Rights Reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package dkg
+
+import (
+	"crypto/rand"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/nerifnetwork/kryptology/pkg/core/curves"
+	"github.com/nerifnetwork/kryptology/pkg/tecdsa/bip32"
+)
+
+func TestDerive(t *testing.T) {
+	curve := curves.K256()
+	secretKeyShareA := curve.Scalar.Random(rand.Reader)
+	secretKeyShareB := curve.Scalar.Random(rand.Reader)
+	publicKey := curve.ScalarBaseMult(secretKeyShareA.Mul(secretKeyShareB))
+	chainCode, err := bip32.NewChainCode()
+	require.NoError(t, err)
+	alice := &AliceOutput{PublicKey: publicKey, SecretKeyShare: secretKeyShareA, ChainCode: chainCode}
+	bob := &BobOutput{PublicKey: publicKey, SecretKeyShare: secretKeyShareB, ChainCode: chainCode}
+
+	aliceChild, err := alice.Derive([]uint32{44, 0})
+	require.NoError(t, err)
+	bobChild, err := bob.Derive([]uint32{44, 0})
+	require.NoError(t, err)
+	require.True(t, aliceChild.PublicKey.Equal(bobChild.PublicKey))
+	require.Equal(t, aliceChild.ChainCode, bobChild.ChainCode)
+	require.Equal(t, 0, aliceChild.Tweak.Cmp(bobChild.Tweak))
+	require.Equal(t, 0, aliceChild.SecretKeyShare.Cmp(secretKeyShareA))
+
+	// The secret key of the child is the product of the shares plus the tweak
+	childKey := secretKeyShareA.Mul(secretKeyShareB).Add(aliceChild.Tweak)
+	require.True(t, curve.ScalarBaseMult(childKey).Equal(aliceChild.PublicKey))
+
+	// Deriving a derived output continues the path
+	grandChild, err := aliceChild.Derive([]uint32{7})
+	require.NoError(t, err)
+	expected, err := alice.Derive([]uint32{44, 0, 7})
+	require.NoError(t, err)
+	require.True(t, expected.PublicKey.Equal(grandChild.PublicKey))
+	require.Equal(t, 0, expected.Tweak.Cmp(grandChild.Tweak))
+	require.True(t, curve.ScalarBaseMult(secretKeyShareA.Mul(secretKeyShareB).Add(grandChild.Tweak)).Equal(grandChild.PublicKey))
+}
+
+func TestDeriveInvalidArguments(t *testing.T) {
+	curve := curves.K256()
+	var alice *AliceOutput
+	_, err := alice.Derive([]uint32{0})
+	require.Error(t, err)
+
+	alice = &AliceOutput{PublicKey: curve.Point.Generator(), SecretKeyShare: curve.Scalar.One()}
+	_, err = alice.Derive([]uint32{0})
+	require.Error(t, err)
+	bob := &BobOutput{PublicKey: curve.Point.Generator(), SecretKeyShare: curve.Scalar.One(), ChainCode: make([]byte, bip32.ChainCodeSize)}
+	_, err = bob.Derive([]uint32{bip32.HardenedOffset})
+	require.Error(t, err)
+}
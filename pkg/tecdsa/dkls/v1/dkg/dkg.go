@@ -18,6 +18,7 @@ import (
 	"github.com/nerifnetwork/kryptology/pkg/core/curves"
 	"github.com/nerifnetwork/kryptology/pkg/ot/base/simplest"
 	"github.com/nerifnetwork/kryptology/pkg/ot/extension/kos"
+	"github.com/nerifnetwork/kryptology/pkg/tecdsa/bip32"
 	"github.com/nerifnetwork/kryptology/pkg/zkp/schnorr"
 )
 
@@ -36,6 +37,14 @@ type AliceOutput struct {
 	// This output must be kept secret. Although, if it is lost the users can run another OT protocol and obtain
 	// new values to replace it.
 	SeedOtResult *simplest.ReceiverOutput
+
+	// ChainCode is the BIP-32 chain code of the joint public key, agreed on during DKG.
+	// This value is public.
+	ChainCode []byte
+
+	// Tweak is the sum of the BIP-32 tweaks of the path when this output was derived from a parent output, nil
+	// otherwise. The secret key is then the product of the secret key shares plus Tweak. This value is public.
+	Tweak curves.Scalar
 }
 
 // BobOutput is the result of running DKG for Bob. It contains both the public and secret values that are needed
@@ -53,6 +62,27 @@ type BobOutput struct {
 	// This output must be kept secret. Although, if it is lost the users can run another OT protocol and obtain
 	// new values to replace it.
 	SeedOtResult *simplest.SenderOutput
+
+	// ChainCode is the BIP-32 chain code of the joint public key, agreed on during DKG.
+	// This value is public.
+	ChainCode []byte
+
+	// Tweak is the sum of the BIP-32 tweaks of the path when this output was derived from a parent output, nil
+	// otherwise. The secret key is then the product of the secret key shares plus Tweak. This value is public.
+	Tweak curves.Scalar
+}
+
+// KeyShare is a share of an existing secret key, see NewAliceWithKeyShare and NewBobWithKeyShare.
+type KeyShare struct {
+	// PublicKey is the public key of the secret key.
+	PublicKey curves.Point
+
+	// SecretKeyShare is the share of the secret key. The secret key is the product of Alice's and Bob's shares.
+	// This value must be kept secret.
+	SecretKeyShare curves.Scalar
+
+	// ChainCode is the BIP-32 chain code of the key. When it is nil, the DKG agrees on a new one.
+	ChainCode []byte
 }
 
 // Alice struct encoding Alice's state during one execution of the overall signing algorithm.
@@ -73,6 +103,12 @@ type Alice struct {
 	// publicKey is the joint public key of Alice and Bob.
 	publicKey curves.Point
 
+	// chainCode is the BIP-32 chain code of the joint public key.
+	chainCode []byte
+
+	// importedKey is the share of an existing key Alice uses instead of a random one, nil otherwise.
+	importedKey *KeyShare
+
 	curve *curves.Curve
 
 	transcript *merlin.Transcript
@@ -98,6 +134,12 @@ type Bob struct {
 	// 32-byte transcript salt which will be used for Alice's schnorr proof
 	aliceSalt [simplest.DigestSize]byte
 
+	// chainCode is the BIP-32 chain code of the joint public key.
+	chainCode []byte
+
+	// importedKey is the share of an existing key Bob uses instead of a random one, nil otherwise.
+	importedKey *KeyShare
+
 	curve *curves.Curve
 
 	transcript *merlin.Transcript
@@ -129,6 +171,24 @@ func NewBob(curve *curves.Curve) *Bob {
 	}
 }
 
+// NewAliceWithKeyShare creates a party that runs DKG with Alice's share of an existing key instead of a random one.
+// The seed OT runs as usual, and DKG fails unless the shares of Alice and Bob are the shares of the public key
+// and both have the same chain code.
+// The holder of the key splits it with `dealer.SplitKey` and deletes it once both shares are handed over.
+func NewAliceWithKeyShare(curve *curves.Curve, share *KeyShare) *Alice {
+	alice := NewAlice(curve)
+	alice.importedKey = share
+	return alice
+}
+
+// NewBobWithKeyShare creates a party that runs DKG with Bob's share of an existing key instead of a random one.
+// See NewAliceWithKeyShare.
+func NewBobWithKeyShare(curve *curves.Curve, share *KeyShare) *Bob {
+	bob := NewBob(curve)
+	bob.importedKey = share
+	return bob
+}
+
 // Round1GenerateRandomSeed Bob flips random coins, and sends these to Alice
 // in this round, Bob flips 32 random bytes and sends them to Alice.
 // note that this is not _explicitly_ given as part of the protocol in https://eprint.iacr.org/2018/499.pdf, Protocol 1).
@@ -155,12 +215,24 @@ func (alice *Alice) Round2CommitToProof(bobSeed [simplest.DigestSize]byte) (*Rou
 	var err error
 	uniqueSessionId := [simplest.DigestSize]byte{} // note: will use and re-use this below for sub-session IDs.
 	copy(uniqueSessionId[:], alice.transcript.ExtractBytes([]byte("salt for simplest OT"), simplest.DigestSize))
+	// The schnorr proofs of both parties are bound to the chain code of an imported key, so they only
+	// verify when alice and bob import the same one. A DKG of a random key keeps the transcript it always had.
+	if alice.importedKey != nil {
+		alice.transcript.AppendMessage([]byte("imported chain code"), alice.importedKey.ChainCode)
+	}
 	alice.receiver, err = simplest.NewReceiver(alice.curve, kos.Kappa, uniqueSessionId)
 	if err != nil {
 		return nil, errors.Wrap(err, "alice constructing new seed OT receiver in Alice DKG round 1")
 	}
 
-	alice.secretKeyShare = alice.curve.Scalar.Random(rand.Reader)
+	if alice.importedKey != nil {
+		if err = checkKeyShare(alice.importedKey); err != nil {
+			return nil, errors.Wrap(err, "alice's key share in DKG round 1")
+		}
+		alice.secretKeyShare = alice.importedKey.SecretKeyShare
+	} else {
+		alice.secretKeyShare = alice.curve.Scalar.Random(rand.Reader)
+	}
 	copy(uniqueSessionId[:], alice.transcript.ExtractBytes([]byte("salt for alice schnorr"), simplest.DigestSize))
 	alice.prover = schnorr.NewProver(alice.curve, nil, uniqueSessionId[:])
 	var commitment schnorr.Commitment
@@ -184,14 +256,28 @@ func (bob *Bob) Round3SchnorrProve(round2Output *Round2Output) (*schnorr.Proof,
 	var err error
 	uniqueSessionId := [simplest.DigestSize]byte{} // note: will use and re-use this below for sub-session IDs.
 	copy(uniqueSessionId[:], bob.transcript.ExtractBytes([]byte("salt for simplest OT"), simplest.DigestSize))
+	if bob.importedKey != nil {
+		bob.transcript.AppendMessage([]byte("imported chain code"), bob.importedKey.ChainCode)
+	}
 	bob.sender, err = simplest.NewSender(bob.curve, kos.Kappa, uniqueSessionId)
 	if err != nil {
 		return nil, errors.Wrap(err, "bob constructing new OT sender in DKG round 2")
 	}
 	// extract alice's salt in the right order; we won't use this until she reveals her proof and we verify it below
 	copy(bob.aliceSalt[:], bob.transcript.ExtractBytes([]byte("salt for alice schnorr"), simplest.DigestSize))
-	bob.secretKeyShare = bob.curve.Scalar.Random(rand.Reader)
+	if bob.importedKey != nil {
+		if err = checkKeyShare(bob.importedKey); err != nil {
+			return nil, errors.Wrap(err, "bob's key share in DKG round 2")
+		}
+		bob.secretKeyShare = bob.importedKey.SecretKeyShare
+	} else {
+		bob.secretKeyShare = bob.curve.Scalar.Random(rand.Reader)
+	}
 	copy(uniqueSessionId[:], bob.transcript.ExtractBytes([]byte("salt for bob schnorr"), simplest.DigestSize))
+	bob.chainCode = bob.transcript.ExtractBytes([]byte("bip32 chain code"), bip32.ChainCodeSize)
+	if bob.importedKey != nil && bob.importedKey.ChainCode != nil {
+		bob.chainCode = bob.importedKey.ChainCode
+	}
 	bob.prover = schnorr.NewProver(bob.curve, nil, uniqueSessionId[:])
 	proof, err := bob.prover.Prove(bob.secretKeyShare)
 	if err != nil {
@@ -205,10 +291,22 @@ func (alice *Alice) Round4VerifyAndReveal(proof *schnorr.Proof) (*schnorr.Proof,
 	var err error
 	uniqueSessionId := [simplest.DigestSize]byte{}
 	copy(uniqueSessionId[:], alice.transcript.ExtractBytes([]byte("salt for bob schnorr"), simplest.DigestSize))
+	alice.chainCode = alice.transcript.ExtractBytes([]byte("bip32 chain code"), bip32.ChainCodeSize)
 	if err = schnorr.Verify(proof, alice.curve, nil, uniqueSessionId[:]); err != nil {
+		if alice.importedKey != nil {
+			return nil, errors.Wrap(err, "alice's verification of Bob's schnorr proof failed in DKG round 3, bob may not import the key or import another chain code")
+		}
 		return nil, errors.Wrap(err, "alice's verification of Bob's schnorr proof failed in DKG round 3")
 	}
 	alice.publicKey = proof.Statement.Mul(alice.secretKeyShare)
+	if alice.importedKey != nil {
+		if !alice.publicKey.Equal(alice.importedKey.PublicKey) {
+			return nil, errors.New("the key shares of alice and bob are not shares of the imported key")
+		}
+		if alice.importedKey.ChainCode != nil {
+			alice.chainCode = alice.importedKey.ChainCode
+		}
+	}
 	return alice.proof, nil
 }
 
@@ -219,6 +317,9 @@ func (bob *Bob) Round5DecommitmentAndStartOt(proof *schnorr.Proof) (*schnorr.Pro
 		return nil, errors.Wrap(err, "decommit + verify failed in bob's DKG round 4")
 	}
 	bob.publicKey = proof.Statement.Mul(bob.secretKeyShare)
+	if bob.importedKey != nil && !bob.publicKey.Equal(bob.importedKey.PublicKey) {
+		return nil, errors.New("the key shares of alice and bob are not shares of the imported key")
+	}
 	seedOTRound1Output, err := bob.sender.Round1ComputeAndZkpToPublicKey()
 	if err != nil {
 		return nil, errors.Wrap(err, "bob computing round 1 of seed  OT within DKG round 4")
@@ -258,6 +359,7 @@ func (alice *Alice) Output() *AliceOutput {
 		PublicKey:      alice.publicKey,
 		SecretKeyShare: alice.secretKeyShare,
 		SeedOtResult:   alice.receiver.Output,
+		ChainCode:      alice.chainCode,
 	}
 }
 
@@ -268,5 +370,20 @@ func (bob *Bob) Output() *BobOutput {
 		PublicKey:      bob.publicKey,
 		SecretKeyShare: bob.secretKeyShare,
 		SeedOtResult:   bob.sender.Output,
+		ChainCode:      bob.chainCode,
+	}
+}
+
+// checkKeyShare checks that an imported key share is complete
+func checkKeyShare(share *KeyShare) error {
+	if share.PublicKey == nil || share.SecretKeyShare == nil {
+		return errors.New("key share cannot be nil")
+	}
+	if share.SecretKeyShare.IsZero() {
+		return errors.New("key share cannot be zero")
+	}
+	if share.ChainCode != nil && len(share.ChainCode) != bip32.ChainCodeSize {
+		return errors.Errorf("chain code must be %d bytes", bip32.ChainCodeSize)
 	}
+	return nil
 }
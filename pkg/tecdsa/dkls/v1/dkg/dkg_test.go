@@ -7,13 +7,17 @@
 package dkg
 
 import (
+	crand "crypto/rand"
 	"fmt"
 	"testing"
 
+	"github.com/gtank/merlin"
 	"github.com/stretchr/testify/require"
 
 	"github.com/nerifnetwork/kryptology/pkg/core/curves"
+	"github.com/nerifnetwork/kryptology/pkg/ot/base/simplest"
 	"github.com/nerifnetwork/kryptology/pkg/ot/extension/kos"
+	"github.com/nerifnetwork/kryptology/pkg/tecdsa/bip32"
 )
 
 func TestDkg(t *testing.T) {
@@ -66,10 +70,147 @@ func TestDkg(t *testing.T) {
 			computedPublicKeyB := pkB.Mul(alice.Output().SecretKeyShare)
 			require.True(tt, computedPublicKeyB.Equal(alice.Output().PublicKey))
 			require.True(tt, computedPublicKeyB.Equal(bob.Output().PublicKey))
+
+			require.Len(tt, alice.Output().ChainCode, bip32.ChainCodeSize)
+			require.Equal(tt, alice.Output().ChainCode, bob.Output().ChainCode)
 		})
 	}
 }
 
+// runDkg runs all rounds of DKG and returns the first error
+func runDkg(alice *Alice, bob *Bob) error {
+	seed, err := bob.Round1GenerateRandomSeed()
+	if err != nil {
+		return err
+	}
+	round2Output, err := alice.Round2CommitToProof(seed)
+	if err != nil {
+		return err
+	}
+	proof, err := bob.Round3SchnorrProve(round2Output)
+	if err != nil {
+		return err
+	}
+	if proof, err = alice.Round4VerifyAndReveal(proof); err != nil {
+		return err
+	}
+	if proof, err = bob.Round5DecommitmentAndStartOt(proof); err != nil {
+		return err
+	}
+	compressedReceiversMaskedChoice, err := alice.Round6DkgRound2Ot(proof)
+	if err != nil {
+		return err
+	}
+	challenge, err := bob.Round7DkgRound3Ot(compressedReceiversMaskedChoice)
+	if err != nil {
+		return err
+	}
+	challengeResponse, err := alice.Round8DkgRound4Ot(challenge)
+	if err != nil {
+		return err
+	}
+	challengeOpenings, err := bob.Round9DkgRound5Ot(challengeResponse)
+	if err != nil {
+		return err
+	}
+	return alice.Round10DkgRound6Ot(challengeOpenings)
+}
+
+func TestDkgWithKeyShare(t *testing.T) {
+	for _, curve := range []*curves.Curve{curves.K256(), curves.P256()} {
+		secretKey := curve.Scalar.Random(crand.Reader)
+		publicKey := curve.ScalarBaseMult(secretKey)
+		aliceShare := curve.Scalar.Random(crand.Reader)
+		bobShare := secretKey.Div(aliceShare)
+		chainCode, err := bip32.NewChainCode()
+		require.NoError(t, err)
+
+		// The chain code of the key is kept
+		alice := NewAliceWithKeyShare(curve, &KeyShare{PublicKey: publicKey, SecretKeyShare: aliceShare, ChainCode: chainCode})
+		bob := NewBobWithKeyShare(curve, &KeyShare{PublicKey: publicKey, SecretKeyShare: bobShare, ChainCode: chainCode})
+		require.NoError(t, runDkg(alice, bob))
+		require.True(t, publicKey.Equal(alice.Output().PublicKey))
+		require.True(t, publicKey.Equal(bob.Output().PublicKey))
+		require.Equal(t, 0, aliceShare.Cmp(alice.Output().SecretKeyShare))
+		require.Equal(t, 0, bobShare.Cmp(bob.Output().SecretKeyShare))
+		require.Equal(t, chainCode, alice.Output().ChainCode)
+		require.Equal(t, chainCode, bob.Output().ChainCode)
+
+		// Without a chain code DKG agrees on a new one
+		alice = NewAliceWithKeyShare(curve, &KeyShare{PublicKey: publicKey, SecretKeyShare: aliceShare})
+		bob = NewBobWithKeyShare(curve, &KeyShare{PublicKey: publicKey, SecretKeyShare: bobShare})
+		require.NoError(t, runDkg(alice, bob))
+		require.True(t, publicKey.Equal(alice.Output().PublicKey))
+		require.Len(t, alice.Output().ChainCode, bip32.ChainCodeSize)
+		require.Equal(t, alice.Output().ChainCode, bob.Output().ChainCode)
+	}
+}
+
+func TestDkgWithKeyShareMismatch(t *testing.T) {
+	curve := curves.K256()
+	secretKey := curve.Scalar.Random(crand.Reader)
+	publicKey := curve.ScalarBaseMult(secretKey)
+	aliceShare := curve.Scalar.Random(crand.Reader)
+	bobShare := secretKey.Div(aliceShare)
+
+	// A share of another key
+	alice := NewAliceWithKeyShare(curve, &KeyShare{PublicKey: publicKey, SecretKeyShare: aliceShare})
+	bob := NewBobWithKeyShare(curve, &KeyShare{PublicKey: publicKey, SecretKeyShare: bobShare.Add(curve.Scalar.One())})
+	require.Error(t, runDkg(alice, bob))
+
+	// A random share
+	alice = NewAliceWithKeyShare(curve, &KeyShare{PublicKey: publicKey, SecretKeyShare: aliceShare})
+	require.Error(t, runDkg(alice, NewBob(curve)))
+	bob = NewBobWithKeyShare(curve, &KeyShare{PublicKey: publicKey, SecretKeyShare: bobShare})
+	require.Error(t, runDkg(NewAlice(curve), bob))
+
+	// Incomplete shares
+	alice = NewAliceWithKeyShare(curve, &KeyShare{PublicKey: publicKey, SecretKeyShare: curve.Scalar.Zero()})
+	require.Error(t, runDkg(alice, NewBob(curve)))
+	bob = NewBobWithKeyShare(curve, &KeyShare{PublicKey: publicKey, SecretKeyShare: bobShare, ChainCode: []byte{1}})
+	require.Error(t, runDkg(NewAlice(curve), bob))
+
+	// Different chain codes of the same key
+	chainCode, err := bip32.NewChainCode()
+	require.NoError(t, err)
+	otherChainCode, err := bip32.NewChainCode()
+	require.NoError(t, err)
+	alice = NewAliceWithKeyShare(curve, &KeyShare{PublicKey: publicKey, SecretKeyShare: aliceShare, ChainCode: chainCode})
+	bob = NewBobWithKeyShare(curve, &KeyShare{PublicKey: publicKey, SecretKeyShare: bobShare, ChainCode: otherChainCode})
+	require.Error(t, runDkg(alice, bob))
+	alice = NewAliceWithKeyShare(curve, &KeyShare{PublicKey: publicKey, SecretKeyShare: aliceShare, ChainCode: chainCode})
+	bob = NewBobWithKeyShare(curve, &KeyShare{PublicKey: publicKey, SecretKeyShare: bobShare})
+	require.Error(t, runDkg(alice, bob))
+}
+
+// TestDkgTranscript pins the transcript of a DKG of a random key, peers of earlier releases derive the same salts
+// and chain code from it
+func TestDkgTranscript(t *testing.T) {
+	curve := curves.K256()
+	alice := NewAlice(curve)
+	bob := NewBob(curve)
+	bobSeed, err := bob.Round1GenerateRandomSeed()
+	require.NoError(t, err)
+	round2Output, err := alice.Round2CommitToProof(bobSeed)
+	require.NoError(t, err)
+	proof, err := bob.Round3SchnorrProve(round2Output)
+	require.NoError(t, err)
+	_, err = alice.Round4VerifyAndReveal(proof)
+	require.NoError(t, err)
+
+	// the transcript of the baseline protocol
+	transcript := merlin.NewTranscript("Coinbase_DKLs_DKG")
+	transcript.AppendMessage([]byte("session_id_bob"), bobSeed[:])
+	transcript.AppendMessage([]byte("session_id_alice"), round2Output.Seed[:])
+	transcript.ExtractBytes([]byte("salt for simplest OT"), simplest.DigestSize)
+	aliceSalt := transcript.ExtractBytes([]byte("salt for alice schnorr"), simplest.DigestSize)
+	transcript.ExtractBytes([]byte("salt for bob schnorr"), simplest.DigestSize)
+	chainCode := transcript.ExtractBytes([]byte("bip32 chain code"), bip32.ChainCodeSize)
+	require.Equal(t, aliceSalt, bob.aliceSalt[:])
+	require.Equal(t, chainCode, bob.chainCode)
+	require.Equal(t, chainCode, alice.chainCode)
+}
+
 func BenchmarkDkg(b *testing.B) {
 	if testing.Short() {
 		b.SkipNow()
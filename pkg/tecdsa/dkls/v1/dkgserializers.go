@@ -35,8 +35,15 @@ func registerTypes() {
 }
 
 func encodeDkgRound1Output(commitment [32]byte, version uint) (*protocol.Message, error) {
-	if version != protocol.Version1 {
-		return nil, errors.New("only version 1 is supported")
+	if err := versionIsSupported(version); err != nil {
+		return nil, errors.Wrap(err, "version error")
+	}
+	if version == protocol.Version2 {
+		payload, err := marshalWire(&wireCommitment{Commitment: commitment}, nil)
+		if err != nil {
+			return nil, err
+		}
+		return newDkgProtocolMessage(payload, "1", version), nil
 	}
 	registerTypes()
 	buf := bytes.NewBuffer([]byte{})
@@ -48,8 +55,15 @@ func encodeDkgRound1Output(commitment [32]byte, version uint) (*protocol.Message
 }
 
 func decodeDkgRound2Input(m *protocol.Message) ([32]byte, error) {
-	if m.Version != protocol.Version1 {
-		return [32]byte{}, errors.New("only version 1 is supported")
+	if err := versionIsSupported(m.Version); err != nil {
+		return [32]byte{}, errors.Wrap(err, "version error")
+	}
+	if m.Version == protocol.Version2 {
+		decoded := new(wireCommitment)
+		if err := unmarshalWire(m, decoded); err != nil {
+			return [32]byte{}, err
+		}
+		return decoded.Commitment, nil
 	}
 	buf := bytes.NewBuffer(m.Payloads[payloadKey])
 	dec := gob.NewDecoder(buf)
@@ -61,8 +75,15 @@ func decodeDkgRound2Input(m *protocol.Message) ([32]byte, error) {
 }
 
 func encodeDkgRound2Output(output *dkg.Round2Output, version uint) (*protocol.Message, error) {
-	if version != protocol.Version1 {
-		return nil, errors.New("only version 1 is supported")
+	if err := versionIsSupported(version); err != nil {
+		return nil, errors.Wrap(err, "version error")
+	}
+	if version == protocol.Version2 {
+		payload, err := marshalWire(toWireDkgRound2Output(output))
+		if err != nil {
+			return nil, err
+		}
+		return newDkgProtocolMessage(payload, "2", version), nil
 	}
 	buf := bytes.NewBuffer([]byte{})
 	enc := gob.NewEncoder(buf)
@@ -73,8 +94,15 @@ func encodeDkgRound2Output(output *dkg.Round2Output, version uint) (*protocol.Me
 }
 
 func decodeDkgRound3Input(m *protocol.Message) (*dkg.Round2Output, error) {
-	if m.Version != protocol.Version1 {
-		return nil, errors.New("only version 1 is supported")
+	if err := versionIsSupported(m.Version); err != nil {
+		return nil, errors.Wrap(err, "version error")
+	}
+	if m.Version == protocol.Version2 {
+		decoded := new(wireDkgRound2Output)
+		if err := unmarshalWire(m, decoded); err != nil {
+			return nil, err
+		}
+		return decoded.output(), nil
 	}
 	buf := bytes.NewBuffer(m.Payloads[payloadKey])
 	dec := gob.NewDecoder(buf)
@@ -86,8 +114,15 @@ func decodeDkgRound3Input(m *protocol.Message) (*dkg.Round2Output, error) {
 }
 
 func encodeDkgRound3Output(proof *schnorr.Proof, version uint) (*protocol.Message, error) {
-	if version != protocol.Version1 {
-		return nil, errors.New("only version 1 is supported")
+	if err := versionIsSupported(version); err != nil {
+		return nil, errors.Wrap(err, "version error")
+	}
+	if version == protocol.Version2 {
+		payload, err := marshalWire(toWireProof(proof))
+		if err != nil {
+			return nil, err
+		}
+		return newDkgProtocolMessage(payload, "3", version), nil
 	}
 	buf := bytes.NewBuffer([]byte{})
 	enc := gob.NewEncoder(buf)
@@ -98,8 +133,15 @@ func encodeDkgRound3Output(proof *schnorr.Proof, version uint) (*protocol.Messag
 }
 
 func decodeDkgRound4Input(m *protocol.Message) (*schnorr.Proof, error) {
-	if m.Version != protocol.Version1 {
-		return nil, errors.New("only version 1 is supported")
+	if err := versionIsSupported(m.Version); err != nil {
+		return nil, errors.Wrap(err, "version error")
+	}
+	if m.Version == protocol.Version2 {
+		decoded := new(wireProof)
+		if err := unmarshalWire(m, decoded); err != nil {
+			return nil, err
+		}
+		return decoded.proof()
 	}
 	buf := bytes.NewBuffer(m.Payloads[payloadKey])
 	dec := gob.NewDecoder(buf)
@@ -111,8 +153,15 @@ func decodeDkgRound4Input(m *protocol.Message) (*schnorr.Proof, error) {
 }
 
 func encodeDkgRound4Output(proof *schnorr.Proof, version uint) (*protocol.Message, error) {
-	if version != protocol.Version1 {
-		return nil, errors.New("only version 1 is supported")
+	if err := versionIsSupported(version); err != nil {
+		return nil, errors.Wrap(err, "version error")
+	}
+	if version == protocol.Version2 {
+		payload, err := marshalWire(toWireProof(proof))
+		if err != nil {
+			return nil, err
+		}
+		return newDkgProtocolMessage(payload, "4", version), nil
 	}
 	buf := bytes.NewBuffer([]byte{})
 	enc := gob.NewEncoder(buf)
@@ -123,8 +172,15 @@ func encodeDkgRound4Output(proof *schnorr.Proof, version uint) (*protocol.Messag
 }
 
 func decodeDkgRound5Input(m *protocol.Message) (*schnorr.Proof, error) {
-	if m.Version != protocol.Version1 {
-		return nil, errors.New("only version 1 is supported")
+	if err := versionIsSupported(m.Version); err != nil {
+		return nil, errors.Wrap(err, "version error")
+	}
+	if m.Version == protocol.Version2 {
+		decoded := new(wireProof)
+		if err := unmarshalWire(m, decoded); err != nil {
+			return nil, err
+		}
+		return decoded.proof()
 	}
 	buf := bytes.NewBuffer(m.Payloads[payloadKey])
 	dec := gob.NewDecoder(buf)
@@ -136,8 +192,15 @@ func decodeDkgRound5Input(m *protocol.Message) (*schnorr.Proof, error) {
 }
 
 func encodeDkgRound5Output(proof *schnorr.Proof, version uint) (*protocol.Message, error) {
-	if version != protocol.Version1 {
-		return nil, errors.New("only version 1 is supported")
+	if err := versionIsSupported(version); err != nil {
+		return nil, errors.Wrap(err, "version error")
+	}
+	if version == protocol.Version2 {
+		payload, err := marshalWire(toWireProof(proof))
+		if err != nil {
+			return nil, err
+		}
+		return newDkgProtocolMessage(payload, "5", version), nil
 	}
 	buf := bytes.NewBuffer([]byte{})
 	enc := gob.NewEncoder(buf)
@@ -148,8 +211,15 @@ func encodeDkgRound5Output(proof *schnorr.Proof, version uint) (*protocol.Messag
 }
 
 func decodeDkgRound6Input(m *protocol.Message) (*schnorr.Proof, error) {
-	if m.Version != protocol.Version1 {
-		return nil, errors.New("only version 1 is supported")
+	if err := versionIsSupported(m.Version); err != nil {
+		return nil, errors.Wrap(err, "version error")
+	}
+	if m.Version == protocol.Version2 {
+		decoded := new(wireProof)
+		if err := unmarshalWire(m, decoded); err != nil {
+			return nil, err
+		}
+		return decoded.proof()
 	}
 	buf := bytes.NewBuffer(m.Payloads[payloadKey])
 	dec := gob.NewDecoder(buf)
@@ -161,8 +231,15 @@ func decodeDkgRound6Input(m *protocol.Message) (*schnorr.Proof, error) {
 }
 
 func encodeDkgRound6Output(choices []simplest.ReceiversMaskedChoices, version uint) (*protocol.Message, error) {
-	if version != protocol.Version1 {
-		return nil, errors.New("only version 1 is supported")
+	if err := versionIsSupported(version); err != nil {
+		return nil, errors.Wrap(err, "version error")
+	}
+	if version == protocol.Version2 {
+		payload, err := marshalWire(&wireOtMaskedChoices{Choices: choices}, nil)
+		if err != nil {
+			return nil, err
+		}
+		return newDkgProtocolMessage(payload, "6", version), nil
 	}
 	buf := bytes.NewBuffer([]byte{})
 	enc := gob.NewEncoder(buf)
@@ -173,8 +250,15 @@ func encodeDkgRound6Output(choices []simplest.ReceiversMaskedChoices, version ui
 }
 
 func decodeDkgRound7Input(m *protocol.Message) ([]simplest.ReceiversMaskedChoices, error) {
-	if m.Version != protocol.Version1 {
-		return nil, errors.New("only version 1 is supported")
+	if err := versionIsSupported(m.Version); err != nil {
+		return nil, errors.Wrap(err, "version error")
+	}
+	if m.Version == protocol.Version2 {
+		decoded := new(wireOtMaskedChoices)
+		if err := unmarshalWire(m, decoded); err != nil {
+			return nil, err
+		}
+		return decoded.Choices, nil
 	}
 	buf := bytes.NewBuffer(m.Payloads[payloadKey])
 	dec := gob.NewDecoder(buf)
@@ -186,8 +270,15 @@ func decodeDkgRound7Input(m *protocol.Message) ([]simplest.ReceiversMaskedChoice
 }
 
 func encodeDkgRound7Output(challenge []simplest.OtChallenge, version uint) (*protocol.Message, error) {
-	if version != protocol.Version1 {
-		return nil, errors.New("only version 1 is supported")
+	if err := versionIsSupported(version); err != nil {
+		return nil, errors.Wrap(err, "version error")
+	}
+	if version == protocol.Version2 {
+		payload, err := marshalWire(&wireOtChallenges{Challenges: challenge}, nil)
+		if err != nil {
+			return nil, err
+		}
+		return newDkgProtocolMessage(payload, "7", version), nil
 	}
 	buf := bytes.NewBuffer([]byte{})
 	enc := gob.NewEncoder(buf)
@@ -198,8 +289,15 @@ func encodeDkgRound7Output(challenge []simplest.OtChallenge, version uint) (*pro
 }
 
 func decodeDkgRound8Input(m *protocol.Message) ([]simplest.OtChallenge, error) {
-	if m.Version != protocol.Version1 {
-		return nil, errors.New("only version 1 is supported")
+	if err := versionIsSupported(m.Version); err != nil {
+		return nil, errors.Wrap(err, "version error")
+	}
+	if m.Version == protocol.Version2 {
+		decoded := new(wireOtChallenges)
+		if err := unmarshalWire(m, decoded); err != nil {
+			return nil, err
+		}
+		return decoded.Challenges, nil
 	}
 	buf := bytes.NewBuffer(m.Payloads[payloadKey])
 	dec := gob.NewDecoder(buf)
@@ -211,8 +309,15 @@ func decodeDkgRound8Input(m *protocol.Message) ([]simplest.OtChallenge, error) {
 }
 
 func encodeDkgRound8Output(responses []simplest.OtChallengeResponse, version uint) (*protocol.Message, error) {
-	if version != protocol.Version1 {
-		return nil, errors.New("only version 1 is supported")
+	if err := versionIsSupported(version); err != nil {
+		return nil, errors.Wrap(err, "version error")
+	}
+	if version == protocol.Version2 {
+		payload, err := marshalWire(&wireOtChallengeResponses{Responses: responses}, nil)
+		if err != nil {
+			return nil, err
+		}
+		return newDkgProtocolMessage(payload, "8", version), nil
 	}
 	buf := bytes.NewBuffer([]byte{})
 	enc := gob.NewEncoder(buf)
@@ -223,8 +328,15 @@ func encodeDkgRound8Output(responses []simplest.OtChallengeResponse, version uin
 }
 
 func decodeDkgRound9Input(m *protocol.Message) ([]simplest.OtChallengeResponse, error) {
-	if m.Version != protocol.Version1 {
-		return nil, errors.New("only version 1 is supported")
+	if err := versionIsSupported(m.Version); err != nil {
+		return nil, errors.Wrap(err, "version error")
+	}
+	if m.Version == protocol.Version2 {
+		decoded := new(wireOtChallengeResponses)
+		if err := unmarshalWire(m, decoded); err != nil {
+			return nil, err
+		}
+		return decoded.Responses, nil
 	}
 	buf := bytes.NewBuffer(m.Payloads[payloadKey])
 	dec := gob.NewDecoder(buf)
@@ -236,8 +348,15 @@ func decodeDkgRound9Input(m *protocol.Message) ([]simplest.OtChallengeResponse,
 }
 
 func encodeDkgRound9Output(opening []simplest.ChallengeOpening, version uint) (*protocol.Message, error) {
-	if version != protocol.Version1 {
-		return nil, errors.New("only version 1 is supported")
+	if err := versionIsSupported(version); err != nil {
+		return nil, errors.Wrap(err, "version error")
+	}
+	if version == protocol.Version2 {
+		payload, err := marshalWire(&wireOtChallengeOpenings{Openings: opening}, nil)
+		if err != nil {
+			return nil, err
+		}
+		return newDkgProtocolMessage(payload, "9", version), nil
 	}
 	buf := bytes.NewBuffer([]byte{})
 	enc := gob.NewEncoder(buf)
@@ -248,8 +367,15 @@ func encodeDkgRound9Output(opening []simplest.ChallengeOpening, version uint) (*
 }
 
 func decodeDkgRound10Input(m *protocol.Message) ([]simplest.ChallengeOpening, error) {
-	if m.Version != protocol.Version1 {
-		return nil, errors.New("only version 1 is supported")
+	if err := versionIsSupported(m.Version); err != nil {
+		return nil, errors.Wrap(err, "version error")
+	}
+	if m.Version == protocol.Version2 {
+		decoded := new(wireOtChallengeOpenings)
+		if err := unmarshalWire(m, decoded); err != nil {
+			return nil, err
+		}
+		return decoded.Openings, nil
 	}
 	buf := bytes.NewBuffer(m.Payloads[payloadKey])
 	dec := gob.NewDecoder(buf)
@@ -262,8 +388,15 @@ func decodeDkgRound10Input(m *protocol.Message) ([]simplest.ChallengeOpening, er
 
 // EncodeAliceDkgOutput serializes Alice DKG output based on the protocol version.
 func EncodeAliceDkgOutput(result *dkg.AliceOutput, version uint) (*protocol.Message, error) {
-	if version != protocol.Version1 {
-		return nil, errors.New("only version 1 is supported")
+	if err := versionIsSupported(version); err != nil {
+		return nil, errors.Wrap(err, "version error")
+	}
+	if version == protocol.Version2 {
+		payload, err := marshalWire(toWireAliceOutput(result))
+		if err != nil {
+			return nil, err
+		}
+		return newDkgProtocolMessage(payload, "alice-output", version), nil
 	}
 	registerTypes()
 	buf := bytes.NewBuffer([]byte{})
@@ -276,8 +409,15 @@ func EncodeAliceDkgOutput(result *dkg.AliceOutput, version uint) (*protocol.Mess
 
 // DecodeAliceDkgResult deserializes Alice DKG output.
 func DecodeAliceDkgResult(m *protocol.Message) (*dkg.AliceOutput, error) {
-	if m.Version != protocol.Version1 {
-		return nil, errors.New("only version 1 is supported")
+	if err := versionIsSupported(m.Version); err != nil {
+		return nil, errors.Wrap(err, "version error")
+	}
+	if m.Version == protocol.Version2 {
+		decoded := new(wireAliceOutput)
+		if err := unmarshalWire(m, decoded); err != nil {
+			return nil, err
+		}
+		return decoded.output()
 	}
 	registerTypes()
 	buf := bytes.NewBuffer(m.Payloads[payloadKey])
@@ -291,8 +431,15 @@ func DecodeAliceDkgResult(m *protocol.Message) (*dkg.AliceOutput, error) {
 
 // EncodeBobDkgOutput serializes Bob DKG output based on the protocol version.
 func EncodeBobDkgOutput(result *dkg.BobOutput, version uint) (*protocol.Message, error) {
-	if version != protocol.Version1 {
-		return nil, errors.New("only version 1 is supported")
+	if err := versionIsSupported(version); err != nil {
+		return nil, errors.Wrap(err, "version error")
+	}
+	if version == protocol.Version2 {
+		payload, err := marshalWire(toWireBobOutput(result))
+		if err != nil {
+			return nil, err
+		}
+		return newDkgProtocolMessage(payload, "bob-output", version), nil
 	}
 	registerTypes()
 	buf := bytes.NewBuffer([]byte{})
@@ -305,8 +452,15 @@ func EncodeBobDkgOutput(result *dkg.BobOutput, version uint) (*protocol.Message,
 
 // DecodeBobDkgResult deserializes Bob DKG output.
 func DecodeBobDkgResult(m *protocol.Message) (*dkg.BobOutput, error) {
-	if m.Version != protocol.Version1 {
-		return nil, errors.New("only version 1 is supported")
+	if err := versionIsSupported(m.Version); err != nil {
+		return nil, errors.Wrap(err, "version error")
+	}
+	if m.Version == protocol.Version2 {
+		decoded := new(wireBobOutput)
+		if err := unmarshalWire(m, decoded); err != nil {
+			return nil, err
+		}
+		return decoded.output()
 	}
 	buf := bytes.NewBuffer(m.Payloads[payloadKey])
 	dec := gob.NewDecoder(buf)
@@ -317,6 +471,49 @@ func DecodeBobDkgResult(m *protocol.Message) (*dkg.BobOutput, error) {
 	return decoded, nil
 }
 
+// EncodeKeyShare serializes the share of an existing key that the key holder hands to Alice or Bob.
+func EncodeKeyShare(share *dkg.KeyShare, version uint) (*protocol.Message, error) {
+	if err := versionIsSupported(version); err != nil {
+		return nil, errors.Wrap(err, "version error")
+	}
+	if version == protocol.Version2 {
+		payload, err := marshalWire(toWireKeyShare(share))
+		if err != nil {
+			return nil, err
+		}
+		return newDkgProtocolMessage(payload, "key-share", version), nil
+	}
+	registerTypes()
+	buf := bytes.NewBuffer([]byte{})
+	enc := gob.NewEncoder(buf)
+	if err := enc.Encode(share); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return newDkgProtocolMessage(buf.Bytes(), "key-share", version), nil
+}
+
+// DecodeKeyShare deserializes the share of an existing key.
+func DecodeKeyShare(m *protocol.Message) (*dkg.KeyShare, error) {
+	if err := versionIsSupported(m.Version); err != nil {
+		return nil, errors.Wrap(err, "version error")
+	}
+	if m.Version == protocol.Version2 {
+		decoded := new(wireKeyShare)
+		if err := unmarshalWire(m, decoded); err != nil {
+			return nil, err
+		}
+		return decoded.keyShare()
+	}
+	registerTypes()
+	buf := bytes.NewBuffer(m.Payloads[payloadKey])
+	dec := gob.NewDecoder(buf)
+	decoded := new(dkg.KeyShare)
+	if err := dec.Decode(&decoded); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return decoded, nil
+}
+
 // ConvertAliceDkgOutputToV1 converts the V0 output to V1 output.
 // The V0 version of DKls `gob` encoded entire `Alice` object and returned it as DKG state and returned this state and
 // the public key to the caller as the serialized version of DKG.
@@ -0,0 +1,408 @@
+//
+// Copyright Coinbase, Inc. All Rights Reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package export
+
+import (
+	"crypto/rand"
+
+	"github.com/pkg/errors"
+
+	"github.com/nerifnetwork/kryptology/pkg/core/curves"
+	"github.com/nerifnetwork/kryptology/pkg/tecdsa/dkls/v1/dkg"
+	"github.com/nerifnetwork/kryptology/pkg/verenc/elgamal"
+)
+
+const (
+	// chunkBits is the size of the chunks of the share, Recover solves a discrete log of this size per chunk.
+	chunkBits = 16
+	// numChunks chunks cover the 256 bit scalars of the curves of DKLs.
+	numChunks = 16
+)
+
+// Backup is a secret key share encrypted to a recovery key. With the backups of Alice and Bob, the holder of the
+// recovery decryption key recovers the secret key of PublicKey, see Recover. Backups are public.
+type Backup struct {
+	// PublicKey is the joint public key of the output.
+	PublicKey curves.Point
+
+	// ChainCode and Tweak are the BIP-32 chain code and tweak of the output.
+	ChainCode []byte
+	Tweak     curves.Scalar
+
+	// PublicShare is sk_i * G for the backed up secret key share sk_i.
+	PublicShare curves.Point
+
+	// CipherText is the chunked El-Gamal encryption of sk_i to the recovery key.
+	CipherText *CipherText
+
+	// Proof proves that every chunk of CipherText is in range and that the chunks encrypt the discrete log of
+	// PublicShare, so Recover only decrypts proven data.
+	Proof *ShareProof
+}
+
+// CipherText encrypts sk_i = sum_j 2^(16 j) m_j with 16 bit chunks m_j. Chunk j is C1_j = r_j * G and
+// C2_j = m_j * G + r_j * Q, where Q is the recovery key. C2_j is not sent, it is the sum of 2^k B_{j,k} of the
+// Pedersen commitments B_{j,k} = b_{j,k} * G + s_{j,k} * Q to the bits of m_j, with r_j = sum_k 2^k s_{j,k}.
+type CipherText struct {
+	C1   []curves.Point
+	Bits []curves.Point
+}
+
+// ShareProof proves with a single challenge C that
+//   - each B_{j,k} commits to 0 or 1, B_{j,k} = s * Q or B_{j,k} - G = s * Q. BitC holds the challenges of the first
+//     case, the second case has C - BitC, and BitS0 and BitS1 hold the responses of both cases.
+//   - the prover knows m_j and r_j with C1_j = r_j * G and C2_j = m_j * G + r_j * Q. R and M hold the responses.
+//   - sum_j 2^(16 j) m_j * G = PublicShare, checked with the responses M.
+type ShareProof struct {
+	C     curves.Scalar
+	R, M  []curves.Scalar
+	BitC  []curves.Scalar
+	BitS0 []curves.Scalar
+	BitS1 []curves.Scalar
+}
+
+// NewAliceBackup encrypts Alice's secret key share to recoveryKey and proves that the encryption matches her public
+// share. uniqueSessionId binds the proof, the verifier needs the same value.
+func NewAliceBackup(output *dkg.AliceOutput, recoveryKey *elgamal.EncryptionKey, uniqueSessionId []byte) (*Backup, error) {
+	if output == nil {
+		return nil, errors.New("alice output cannot be nil")
+	}
+	return newBackup(output.PublicKey, output.ChainCode, output.Tweak, output.SecretKeyShare, recoveryKey, uniqueSessionId)
+}
+
+// NewBobBackup encrypts Bob's secret key share to recoveryKey, see NewAliceBackup.
+func NewBobBackup(output *dkg.BobOutput, recoveryKey *elgamal.EncryptionKey, uniqueSessionId []byte) (*Backup, error) {
+	if output == nil {
+		return nil, errors.New("bob output cannot be nil")
+	}
+	return newBackup(output.PublicKey, output.ChainCode, output.Tweak, output.SecretKeyShare, recoveryKey, uniqueSessionId)
+}
+
+func newBackup(publicKey curves.Point, chainCode []byte, tweak, secretKeyShare curves.Scalar, recoveryKey *elgamal.EncryptionKey, uniqueSessionId []byte) (*Backup, error) {
+	if publicKey == nil || secretKeyShare == nil {
+		return nil, errors.New("public key and secret key share cannot be nil")
+	}
+	if recoveryKey == nil || recoveryKey.Value == nil {
+		return nil, errors.New("recovery key cannot be nil")
+	}
+	if recoveryKey.Value.CurveName() != publicKey.CurveName() {
+		return nil, errors.New("the recovery key is on a different curve")
+	}
+	share := secretKeyShare.BigInt()
+	if share.BitLen() > chunkBits*numChunks {
+		return nil, errors.New("the secret key share does not fit the chunks")
+	}
+	g := publicKey.Generator()
+	q := recoveryKey.Value
+	zero := secretKeyShare.Zero()
+	backup := &Backup{
+		PublicKey:   publicKey,
+		ChainCode:   chainCode,
+		Tweak:       tweak,
+		PublicShare: g.Mul(secretKeyShare),
+		CipherText: &CipherText{
+			C1:   make([]curves.Point, numChunks),
+			Bits: make([]curves.Point, numChunks*chunkBits),
+		},
+		Proof: &ShareProof{
+			R:     make([]curves.Scalar, numChunks),
+			M:     make([]curves.Scalar, numChunks),
+			BitC:  make([]curves.Scalar, numChunks*chunkBits),
+			BitS0: make([]curves.Scalar, numChunks*chunkBits),
+			BitS1: make([]curves.Scalar, numChunks*chunkBits),
+		},
+	}
+	commitments := &shareCommitments{
+		bits0:  make([]curves.Point, numChunks*chunkBits),
+		bits1:  make([]curves.Point, numChunks*chunkBits),
+		chunk1: make([]curves.Point, numChunks),
+		chunk2: make([]curves.Point, numChunks),
+	}
+
+	// the bits b, blindings s and nonce w of the real case of each bit
+	bits := make([]bool, numChunks*chunkBits)
+	blindings := make([]curves.Scalar, numChunks*chunkBits)
+	bitNonces := make([]curves.Scalar, numChunks*chunkBits)
+	// the chunks m_j, r_j and their nonces
+	chunks := make([]curves.Scalar, numChunks)
+	randomness := make([]curves.Scalar, numChunks)
+	chunkNonces := make([]curves.Scalar, numChunks)
+	messageNonces := make([]curves.Scalar, numChunks)
+	sumMessageNonces := zero
+	weight := secretKeyShare.One()
+	for j := 0; j < numChunks; j++ {
+		chunks[j] = zero
+		randomness[j] = zero
+		power := secretKeyShare.One()
+		for k := 0; k < chunkBits; k++ {
+			i := j*chunkBits + k
+			bits[i] = share.Bit(i) == 1
+			blindings[i] = secretKeyShare.Random(rand.Reader)
+			bitNonces[i] = secretKeyShare.Random(rand.Reader)
+			commitment := q.Mul(blindings[i])
+			if bits[i] {
+				commitment = commitment.Add(g)
+				chunks[j] = chunks[j].Add(power)
+			}
+			backup.CipherText.Bits[i] = commitment
+			randomness[j] = randomness[j].Add(power.Mul(blindings[i]))
+
+			// simulate the other case of the bit, T = s * Q - c * (B - b * G) with a random c and s
+			fakeC, fakeS := secretKeyShare.Random(rand.Reader), secretKeyShare.Random(rand.Reader)
+			if bits[i] {
+				backup.Proof.BitC[i], backup.Proof.BitS0[i] = fakeC, fakeS
+				commitments.bits0[i] = q.Mul(fakeS).Sub(commitment.Mul(fakeC))
+				commitments.bits1[i] = q.Mul(bitNonces[i])
+			} else {
+				backup.Proof.BitS1[i] = fakeS
+				commitments.bits0[i] = q.Mul(bitNonces[i])
+				commitments.bits1[i] = q.Mul(fakeS).Sub(commitment.Sub(g).Mul(fakeC))
+				// the challenge of the case 1 is kept in BitC until the challenge is known
+				backup.Proof.BitC[i] = fakeC
+			}
+			power = power.Double()
+		}
+		backup.CipherText.C1[j] = g.Mul(randomness[j])
+
+		// T1_j = a_j * G, T2_j = c_j * G + a_j * Q
+		chunkNonces[j] = secretKeyShare.Random(rand.Reader)
+		messageNonces[j] = secretKeyShare.Random(rand.Reader)
+		commitments.chunk1[j] = g.Mul(chunkNonces[j])
+		commitments.chunk2[j] = g.Mul(messageNonces[j]).Add(q.Mul(chunkNonces[j]))
+		sumMessageNonces = sumMessageNonces.Add(weight.Mul(messageNonces[j]))
+		weight = weight.Mul(power)
+	}
+	commitments.share = g.Mul(sumMessageNonces)
+
+	c := backup.challenge(recoveryKey, uniqueSessionId, commitments)
+	backup.Proof.C = c
+	for i := range bits {
+		if bits[i] {
+			// c1 = c - c0, s1 = w + c1 * s
+			backup.Proof.BitS1[i] = bitNonces[i].Add(c.Sub(backup.Proof.BitC[i]).Mul(blindings[i]))
+		} else {
+			// c0 = c - c1, s0 = w + c0 * s
+			backup.Proof.BitC[i] = c.Sub(backup.Proof.BitC[i])
+			backup.Proof.BitS0[i] = bitNonces[i].Add(backup.Proof.BitC[i].Mul(blindings[i]))
+		}
+	}
+	for j := 0; j < numChunks; j++ {
+		backup.Proof.R[j] = chunkNonces[j].Add(c.Mul(randomness[j]))
+		backup.Proof.M[j] = messageNonces[j].Add(c.Mul(chunks[j]))
+	}
+	return backup, nil
+}
+
+// shareCommitments are the commitments of the share proof, the verifier recomputes them from the responses
+type shareCommitments struct {
+	bits0, bits1   []curves.Point
+	chunk1, chunk2 []curves.Point
+	share          curves.Point
+}
+
+// Verify verifies that the backup encrypts the discrete log of PublicShare to recoveryKey in chunks of 16 bits. It
+// does not need any secret, but it cannot tell whether PublicShare is a share of PublicKey, see VerifyWithShare.
+func (backup *Backup) Verify(recoveryKey *elgamal.EncryptionKey, uniqueSessionId []byte) error {
+	if backup == nil || backup.PublicKey == nil || backup.PublicShare == nil {
+		return errors.New("backup cannot be nil")
+	}
+	if recoveryKey == nil || recoveryKey.Value == nil {
+		return errors.New("recovery key cannot be nil")
+	}
+	curveName := backup.PublicKey.CurveName()
+	if recoveryKey.Value.CurveName() != curveName || backup.PublicShare.CurveName() != curveName {
+		return errors.New("the backup and the recovery key are on different curves")
+	}
+	cipherText, proof := backup.CipherText, backup.Proof
+	if cipherText == nil || len(cipherText.C1) != numChunks || len(cipherText.Bits) != numChunks*chunkBits {
+		return errors.New("invalid backup ciphertext")
+	}
+	if proof == nil || proof.C == nil || len(proof.R) != numChunks || len(proof.M) != numChunks ||
+		len(proof.BitC) != numChunks*chunkBits || len(proof.BitS0) != numChunks*chunkBits || len(proof.BitS1) != numChunks*chunkBits {
+		return errors.New("invalid backup proof")
+	}
+	for _, point := range append(append([]curves.Point{}, cipherText.C1...), cipherText.Bits...) {
+		if point == nil || point.CurveName() != curveName {
+			return errors.New("invalid backup ciphertext")
+		}
+	}
+	for _, scalars := range [][]curves.Scalar{proof.R, proof.M, proof.BitC, proof.BitS0, proof.BitS1} {
+		for _, scalar := range scalars {
+			if scalar == nil {
+				return errors.New("invalid backup proof")
+			}
+		}
+	}
+	if backup.PublicShare.IsIdentity() {
+		return errors.New("the public share cannot be the identity")
+	}
+
+	g := backup.PublicKey.Generator()
+	q := recoveryKey.Value
+	c := proof.C
+	commitments := &shareCommitments{
+		bits0:  make([]curves.Point, numChunks*chunkBits),
+		bits1:  make([]curves.Point, numChunks*chunkBits),
+		chunk1: make([]curves.Point, numChunks),
+		chunk2: make([]curves.Point, numChunks),
+	}
+	sumMessages := c.Zero()
+	weight := c.One()
+	for j := 0; j < numChunks; j++ {
+		c2 := chunkCipherText(cipherText, j)
+		for k := 0; k < chunkBits; k++ {
+			i := j*chunkBits + k
+			// T0 = s0 * Q - c0 * B, T1 = s1 * Q - (c - c0) * (B - G)
+			commitment := cipherText.Bits[i]
+			commitments.bits0[i] = q.Mul(proof.BitS0[i]).Sub(commitment.Mul(proof.BitC[i]))
+			commitments.bits1[i] = q.Mul(proof.BitS1[i]).Sub(commitment.Sub(g).Mul(c.Sub(proof.BitC[i])))
+		}
+		// T1_j = z_r * G - c * C1_j, T2_j = z_m * G + z_r * Q - c * C2_j
+		commitments.chunk1[j] = g.Mul(proof.R[j]).Sub(cipherText.C1[j].Mul(c))
+		commitments.chunk2[j] = g.Mul(proof.M[j]).Add(q.Mul(proof.R[j])).Sub(c2.Mul(c))
+		sumMessages = sumMessages.Add(weight.Mul(proof.M[j]))
+		weight = weight.Mul(c.New(1 << chunkBits))
+	}
+	// T = sum_j 2^(16 j) z_m * G - c * PublicShare
+	commitments.share = g.Mul(sumMessages).Sub(backup.PublicShare.Mul(c))
+	if backup.challenge(recoveryKey, uniqueSessionId, commitments).Cmp(c) != 0 {
+		return errors.New("the backup does not encrypt the secret key share of its public share")
+	}
+	return nil
+}
+
+// VerifyWithShare verifies the backup of the other party with the own secret key share. In addition to Verify, it
+// checks that the other party's public share times secretKeyShare is the joint public key without the tweak.
+func (backup *Backup) VerifyWithShare(recoveryKey *elgamal.EncryptionKey, uniqueSessionId []byte, secretKeyShare curves.Scalar) error {
+	if err := backup.Verify(recoveryKey, uniqueSessionId); err != nil {
+		return err
+	}
+	if secretKeyShare == nil {
+		return errors.New("secret key share cannot be nil")
+	}
+	publicKey := backup.PublicKey
+	if backup.Tweak != nil {
+		publicKey = publicKey.Sub(publicKey.Generator().Mul(backup.Tweak))
+	}
+	if !backup.PublicShare.Mul(secretKeyShare).Equal(publicKey) {
+		return errors.New("the backup is not a share of the public key")
+	}
+	return nil
+}
+
+// Recover verifies and decrypts the backups of Alice and Bob with the recovery decryption key and returns the secret
+// key of their public key. uniqueSessionId is the one the backups were made with. The order of the backups does not
+// matter.
+func Recover(decryptionKey *elgamal.DecryptionKey, uniqueSessionId []byte, aliceBackup, bobBackup *Backup) (curves.Scalar, error) {
+	if decryptionKey == nil {
+		return nil, errors.New("decryption key cannot be nil")
+	}
+	if aliceBackup == nil || bobBackup == nil || aliceBackup.PublicKey == nil || bobBackup.PublicKey == nil {
+		return nil, errors.New("backups cannot be nil")
+	}
+	if !aliceBackup.PublicKey.Equal(bobBackup.PublicKey) {
+		return nil, errors.New("the backups are of different public keys")
+	}
+	curve := curves.GetCurveByName(aliceBackup.PublicKey.CurveName())
+	if curve == nil {
+		return nil, errors.Errorf("unknown curve %s", aliceBackup.PublicKey.CurveName())
+	}
+	aliceSecretKeyShare, err := decrypt(decryptionKey, uniqueSessionId, aliceBackup)
+	if err != nil {
+		return nil, errors.Wrap(err, "decrypting alice's backup")
+	}
+	bobSecretKeyShare, err := decrypt(decryptionKey, uniqueSessionId, bobBackup)
+	if err != nil {
+		return nil, errors.Wrap(err, "decrypting bob's backup")
+	}
+	return Reconstruct(curve, aliceBackup.PublicKey, aliceBackup.Tweak, aliceSecretKeyShare, bobSecretKeyShare)
+}
+
+// decrypt verifies the backup and returns its secret key share. The proof shows that each chunk is below 2^16, so
+// the discrete logs of the decrypted chunks are found and their sum is the discrete log of the public share.
+func decrypt(decryptionKey *elgamal.DecryptionKey, uniqueSessionId []byte, backup *Backup) (curves.Scalar, error) {
+	if err := backup.Verify(decryptionKey.EncryptionKey(), uniqueSessionId); err != nil {
+		return nil, err
+	}
+	g := backup.PublicKey.Generator()
+	one := backup.Proof.C.One()
+	babySteps := babySteps(g)
+	secretKeyShare := one.Zero()
+	weight := one
+	for j := 0; j < numChunks; j++ {
+		// M_j = C2_j - x * C1_j = m_j * G
+		message := decryptionKey.Decrypt(&elgamal.HomomorphicCipherText{
+			C1: backup.CipherText.C1[j],
+			C2: chunkCipherText(backup.CipherText, j),
+		})
+		chunk, err := discreteLog(g, babySteps, message)
+		if err != nil {
+			return nil, err
+		}
+		secretKeyShare = secretKeyShare.Add(weight.Mul(one.New(chunk)))
+		weight = weight.Mul(one.New(1 << chunkBits))
+	}
+	if !g.Mul(secretKeyShare).Equal(backup.PublicShare) {
+		return nil, errors.New("the secret key share does not match the public share")
+	}
+	return secretKeyShare, nil
+}
+
+// chunkCipherText returns C2_j = sum_k 2^k B_{j,k}, with doublings only
+func chunkCipherText(cipherText *CipherText, j int) curves.Point {
+	c2 := cipherText.Bits[j*chunkBits+chunkBits-1]
+	for k := chunkBits - 2; k >= 0; k-- {
+		c2 = c2.Double().Add(cipherText.Bits[j*chunkBits+k])
+	}
+	return c2
+}
+
+// babySteps maps i * G to i for 0 < i < 2^8
+func babySteps(g curves.Point) map[string]int {
+	steps := make(map[string]int, 1<<(chunkBits/2))
+	point := g
+	for i := 1; i < 1<<(chunkBits/2); i++ {
+		steps[string(point.ToAffineCompressed())] = i
+		point = point.Add(g)
+	}
+	return steps
+}
+
+// discreteLog returns m < 2^16 with M = m * G by baby-step giant-step
+func discreteLog(g curves.Point, babySteps map[string]int, message curves.Point) (int, error) {
+	giantStep := g.Mul(g.Scalar().New(1 << (chunkBits / 2)))
+	point := message
+	for i := 0; i < 1<<(chunkBits/2); i++ {
+		if point.IsIdentity() {
+			return i << (chunkBits / 2), nil
+		}
+		if step, ok := babySteps[string(point.ToAffineCompressed())]; ok {
+			return i<<(chunkBits/2) + step, nil
+		}
+		point = point.Sub(giantStep)
+	}
+	return 0, errors.New("the chunk is out of range")
+}
+
+// challenge computes the Fiat-Shamir challenge of the share proof
+func (backup *Backup) challenge(recoveryKey *elgamal.EncryptionKey, uniqueSessionId []byte, commitments *shareCommitments) curves.Scalar {
+	var bytes []byte
+	bytes = append(bytes, []byte("dkls backup share proof")...)
+	bytes = append(bytes, uniqueSessionId...)
+	points := []curves.Point{backup.PublicKey, backup.PublicShare, recoveryKey.Value}
+	points = append(points, backup.CipherText.C1...)
+	points = append(points, backup.CipherText.Bits...)
+	points = append(points, commitments.bits0...)
+	points = append(points, commitments.bits1...)
+	points = append(points, commitments.chunk1...)
+	points = append(points, commitments.chunk2...)
+	points = append(points, commitments.share)
+	for _, point := range points {
+		bytes = append(bytes, point.ToAffineCompressed()...)
+	}
+	return backup.PublicShare.Scalar().Hash(bytes)
+}
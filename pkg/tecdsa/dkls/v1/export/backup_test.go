@@ -0,0 +1,142 @@
+//
+// Copyright Coinbase, Inc. All Rights Reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package export_test
+
+import (
+	"crypto/rand"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/nerifnetwork/kryptology/pkg/core/curves"
+	"github.com/nerifnetwork/kryptology/pkg/tecdsa/dkls/v1/dealer"
+	"github.com/nerifnetwork/kryptology/pkg/tecdsa/dkls/v1/export"
+	"github.com/nerifnetwork/kryptology/pkg/verenc/elgamal"
+)
+
+func TestBackupRecover(t *testing.T) {
+	sessionId := []byte("backup session")
+	for _, curve := range []*curves.Curve{curves.K256(), curves.P256()} {
+		secretKey := curve.Scalar.Random(rand.Reader)
+		aliceOutput, bobOutput, err := dealer.ImportAndDeal(curve, secretKey, nil)
+		require.NoError(t, err)
+		recoveryKey, decryptionKey, err := elgamal.NewKeys(curve)
+		require.NoError(t, err)
+
+		aliceBackup, err := export.NewAliceBackup(aliceOutput, recoveryKey, sessionId)
+		require.NoError(t, err)
+		bobBackup, err := export.NewBobBackup(bobOutput, recoveryKey, sessionId)
+		require.NoError(t, err)
+
+		// Anyone verifies the encryption, each party verifies the other party's share
+		require.NoError(t, aliceBackup.Verify(recoveryKey, sessionId))
+		require.NoError(t, bobBackup.Verify(recoveryKey, sessionId))
+		require.NoError(t, aliceBackup.VerifyWithShare(recoveryKey, sessionId, bobOutput.SecretKeyShare))
+		require.NoError(t, bobBackup.VerifyWithShare(recoveryKey, sessionId, aliceOutput.SecretKeyShare))
+
+		recovered, err := export.Recover(decryptionKey, sessionId, aliceBackup, bobBackup)
+		require.NoError(t, err)
+		require.Equal(t, 0, secretKey.Cmp(recovered))
+		recovered, err = export.Recover(decryptionKey, sessionId, bobBackup, aliceBackup)
+		require.NoError(t, err)
+		require.Equal(t, 0, secretKey.Cmp(recovered))
+	}
+}
+
+func TestBackupDerived(t *testing.T) {
+	curve := curves.K256()
+	sessionId := []byte("backup session")
+	aliceOutput, bobOutput, err := dealer.ImportAndDeal(curve, curve.Scalar.Random(rand.Reader), nil)
+	require.NoError(t, err)
+	path := []uint32{7, 0}
+	aliceOutput, err = aliceOutput.Derive(path)
+	require.NoError(t, err)
+	bobOutput, err = bobOutput.Derive(path)
+	require.NoError(t, err)
+	recoveryKey, decryptionKey, err := elgamal.NewKeys(curve)
+	require.NoError(t, err)
+
+	aliceBackup, err := export.NewAliceBackup(aliceOutput, recoveryKey, sessionId)
+	require.NoError(t, err)
+	bobBackup, err := export.NewBobBackup(bobOutput, recoveryKey, sessionId)
+	require.NoError(t, err)
+	require.NoError(t, aliceBackup.VerifyWithShare(recoveryKey, sessionId, bobOutput.SecretKeyShare))
+	require.NoError(t, bobBackup.VerifyWithShare(recoveryKey, sessionId, aliceOutput.SecretKeyShare))
+	recovered, err := export.Recover(decryptionKey, sessionId, aliceBackup, bobBackup)
+	require.NoError(t, err)
+	require.True(t, curve.ScalarBaseMult(recovered).Equal(aliceOutput.PublicKey))
+}
+
+func TestBackupRejected(t *testing.T) {
+	curve := curves.K256()
+	sessionId := []byte("backup session")
+	aliceOutput, bobOutput, err := dealer.ImportAndDeal(curve, curve.Scalar.Random(rand.Reader), nil)
+	require.NoError(t, err)
+	recoveryKey, decryptionKey, err := elgamal.NewKeys(curve)
+	require.NoError(t, err)
+	otherRecoveryKey, _, err := elgamal.NewKeys(curve)
+	require.NoError(t, err)
+
+	aliceBackup, err := export.NewAliceBackup(aliceOutput, recoveryKey, sessionId)
+	require.NoError(t, err)
+	bobBackup, err := export.NewBobBackup(bobOutput, recoveryKey, sessionId)
+	require.NoError(t, err)
+
+	// Wrong session or recovery key
+	require.Error(t, aliceBackup.Verify(recoveryKey, []byte("other session")))
+	require.Error(t, aliceBackup.Verify(otherRecoveryKey, sessionId))
+	_, err = export.Recover(decryptionKey, []byte("other session"), aliceBackup, bobBackup)
+	require.Error(t, err)
+
+	// A backup that encrypts a different share than its public share
+	forged := *aliceBackup
+	forged.PublicShare = curve.ScalarBaseMult(curve.Scalar.Random(rand.Reader))
+	require.Error(t, forged.Verify(recoveryKey, sessionId))
+	_, err = export.Recover(decryptionKey, sessionId, &forged, bobBackup)
+	require.Error(t, err)
+
+	// A ciphertext that is changed after the proof, the proof covers every chunk
+	tampered := *aliceBackup
+	tampered.CipherText = &export.CipherText{
+		C1:   append([]curves.Point{}, aliceBackup.CipherText.C1...),
+		Bits: append([]curves.Point{}, aliceBackup.CipherText.Bits...),
+	}
+	tampered.CipherText.C1[3] = tampered.CipherText.C1[3].Add(curve.Point.Generator())
+	require.Error(t, tampered.Verify(recoveryKey, sessionId))
+	_, err = export.Recover(decryptionKey, sessionId, &tampered, bobBackup)
+	require.Error(t, err)
+
+	// A bit commitment to 2, which would move its chunk out of range
+	tampered.CipherText.C1[3] = aliceBackup.CipherText.C1[3]
+	tampered.CipherText.Bits[0] = tampered.CipherText.Bits[0].Add(curve.Point.Generator())
+	require.Error(t, tampered.Verify(recoveryKey, sessionId))
+	_, err = export.Recover(decryptionKey, sessionId, &tampered, bobBackup)
+	require.Error(t, err)
+
+	// A proof with missing chunks
+	truncated := *aliceBackup
+	truncated.CipherText = &export.CipherText{C1: aliceBackup.CipherText.C1[1:], Bits: aliceBackup.CipherText.Bits}
+	require.Error(t, truncated.Verify(recoveryKey, sessionId))
+
+	// A backup of a share of another key
+	otherOutput, _, err := dealer.ImportAndDeal(curve, curve.Scalar.Random(rand.Reader), nil)
+	require.NoError(t, err)
+	otherBackup, err := export.NewAliceBackup(otherOutput, recoveryKey, sessionId)
+	require.NoError(t, err)
+	otherBackup.PublicKey = aliceBackup.PublicKey
+	require.Error(t, otherBackup.VerifyWithShare(recoveryKey, sessionId, bobOutput.SecretKeyShare))
+	_, err = export.Recover(decryptionKey, sessionId, otherBackup, bobBackup)
+	require.Error(t, err)
+
+	// A recovery key on another curve
+	p256Key, _, err := elgamal.NewKeys(curves.P256())
+	require.NoError(t, err)
+	_, err = export.NewAliceBackup(aliceOutput, p256Key, sessionId)
+	require.Error(t, err)
+	_, err = export.NewAliceBackup(aliceOutput, nil, sessionId)
+	require.Error(t, err)
+}
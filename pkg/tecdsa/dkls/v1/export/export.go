@@ -0,0 +1,125 @@
+//
+// Copyright Coinbase, Inc. All Rights Reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+// Package export reconstructs the secret key of a DKLs 2-of-2 key when both parties consent, and backs up the secret
+// key shares to a recovery key for disaster recovery.
+//
+// The export protocol is defined as follows:
+//  1. alice sends her secret key share sk_A to bob.
+//  2. bob computes sk = sk_A * sk_B + t, where t is the BIP-32 tweak of the key, and checks that sk * G is the joint
+//     public key. only then he sends his secret key share sk_B to alice.
+//  3. alice computes sk in the same way and runs the same check.
+//
+// After the export both parties know the secret key, the key shares must be considered compromised.
+package export
+
+import (
+	"github.com/pkg/errors"
+
+	"github.com/nerifnetwork/kryptology/pkg/core/curves"
+	"github.com/nerifnetwork/kryptology/pkg/tecdsa/dkls/v1/dkg"
+)
+
+// Alice struct encoding Alice's state during the key export.
+type Alice struct {
+	output *dkg.AliceOutput
+
+	curve *curves.Curve
+
+	secretKey curves.Scalar
+}
+
+// Bob struct encoding Bob's state during the key export.
+type Bob struct {
+	output *dkg.BobOutput
+
+	curve *curves.Curve
+
+	secretKey curves.Scalar
+}
+
+// NewAlice creates a party that can participate in the export of the joint secret key.
+func NewAlice(curve *curves.Curve, output *dkg.AliceOutput) *Alice {
+	return &Alice{
+		output: output,
+		curve:  curve,
+	}
+}
+
+// NewBob creates a party that can participate in the export of the joint secret key.
+func NewBob(curve *curves.Curve, output *dkg.BobOutput) *Bob {
+	return &Bob{
+		output: output,
+		curve:  curve,
+	}
+}
+
+// Round1RevealShare returns Alice's secret key share, which she sends to Bob.
+func (alice *Alice) Round1RevealShare() (curves.Scalar, error) {
+	if alice.output == nil || alice.output.SecretKeyShare == nil {
+		return nil, errors.New("alice output cannot be nil")
+	}
+	return alice.output.SecretKeyShare, nil
+}
+
+// Round2Reconstruct reconstructs the secret key from Alice's secret key share and checks it against the joint public
+// key. Only if it matches, it returns Bob's secret key share, which he sends to Alice.
+func (bob *Bob) Round2Reconstruct(aliceSecretKeyShare curves.Scalar) (curves.Scalar, error) {
+	if bob.output == nil || bob.output.SecretKeyShare == nil {
+		return nil, errors.New("bob output cannot be nil")
+	}
+	secretKey, err := Reconstruct(bob.curve, bob.output.PublicKey, bob.output.Tweak, aliceSecretKeyShare, bob.output.SecretKeyShare)
+	if err != nil {
+		return nil, errors.Wrap(err, "alice's secret key share")
+	}
+	bob.secretKey = secretKey
+	return bob.output.SecretKeyShare, nil
+}
+
+// Round3Reconstruct reconstructs the secret key from Bob's secret key share and checks it against the joint public key.
+func (alice *Alice) Round3Reconstruct(bobSecretKeyShare curves.Scalar) error {
+	if alice.output == nil || alice.output.SecretKeyShare == nil {
+		return errors.New("alice output cannot be nil")
+	}
+	secretKey, err := Reconstruct(alice.curve, alice.output.PublicKey, alice.output.Tweak, alice.output.SecretKeyShare, bobSecretKeyShare)
+	if err != nil {
+		return errors.Wrap(err, "bob's secret key share")
+	}
+	alice.secretKey = secretKey
+	return nil
+}
+
+// Output returns the exported secret key, nil until the export completed.
+func (alice *Alice) Output() curves.Scalar {
+	return alice.secretKey
+}
+
+// Output returns the exported secret key, nil until the export completed.
+func (bob *Bob) Output() curves.Scalar {
+	return bob.secretKey
+}
+
+// Reconstruct returns the secret key sk = aliceSecretKeyShare * bobSecretKeyShare + tweak of publicKey. A nil tweak
+// is zero. It returns an error if sk * G is not publicKey.
+func Reconstruct(curve *curves.Curve, publicKey curves.Point, tweak, aliceSecretKeyShare, bobSecretKeyShare curves.Scalar) (curves.Scalar, error) {
+	if curve == nil || publicKey == nil {
+		return nil, errors.New("curve and public key cannot be nil")
+	}
+	if aliceSecretKeyShare == nil || bobSecretKeyShare == nil {
+		return nil, errors.New("secret key shares cannot be nil")
+	}
+	secretKey := aliceSecretKeyShare.Mul(bobSecretKeyShare)
+	if secretKey == nil {
+		return nil, errors.New("secret key shares are not scalars of the curve")
+	}
+	if tweak != nil {
+		secretKey = secretKey.Add(tweak)
+	}
+	if secretKey.IsZero() || !curve.ScalarBaseMult(secretKey).Equal(publicKey) {
+		return nil, errors.New("the reconstructed secret key does not match the public key")
+	}
+	return secretKey, nil
+}
@@ -0,0 +1,80 @@
+//
+// Copyright Coinbase, Inc. All Rights Reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package export_test
+
+import (
+	"crypto/rand"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/nerifnetwork/kryptology/pkg/core/curves"
+	"github.com/nerifnetwork/kryptology/pkg/tecdsa/dkls/v1/dealer"
+	"github.com/nerifnetwork/kryptology/pkg/tecdsa/dkls/v1/export"
+)
+
+func TestExport(t *testing.T) {
+	for _, curve := range []*curves.Curve{curves.K256(), curves.P256()} {
+		secretKey := curve.Scalar.Random(rand.Reader)
+		aliceOutput, bobOutput, err := dealer.ImportAndDeal(curve, secretKey, nil)
+		require.NoError(t, err)
+
+		alice := export.NewAlice(curve, aliceOutput)
+		bob := export.NewBob(curve, bobOutput)
+		aliceShare, err := alice.Round1RevealShare()
+		require.NoError(t, err)
+		bobShare, err := bob.Round2Reconstruct(aliceShare)
+		require.NoError(t, err)
+		require.NoError(t, alice.Round3Reconstruct(bobShare))
+		require.Equal(t, 0, secretKey.Cmp(alice.Output()))
+		require.Equal(t, 0, secretKey.Cmp(bob.Output()))
+	}
+}
+
+func TestExportDerived(t *testing.T) {
+	curve := curves.K256()
+	aliceOutput, bobOutput, err := dealer.ImportAndDeal(curve, curve.Scalar.Random(rand.Reader), nil)
+	require.NoError(t, err)
+	path := []uint32{0, 1, 2}
+	aliceOutput, err = aliceOutput.Derive(path)
+	require.NoError(t, err)
+	bobOutput, err = bobOutput.Derive(path)
+	require.NoError(t, err)
+
+	alice := export.NewAlice(curve, aliceOutput)
+	bob := export.NewBob(curve, bobOutput)
+	aliceShare, err := alice.Round1RevealShare()
+	require.NoError(t, err)
+	bobShare, err := bob.Round2Reconstruct(aliceShare)
+	require.NoError(t, err)
+	require.NoError(t, alice.Round3Reconstruct(bobShare))
+	require.True(t, curve.ScalarBaseMult(alice.Output()).Equal(aliceOutput.PublicKey))
+}
+
+func TestExportWrongShare(t *testing.T) {
+	curve := curves.K256()
+	aliceOutput, bobOutput, err := dealer.ImportAndDeal(curve, curve.Scalar.Random(rand.Reader), nil)
+	require.NoError(t, err)
+
+	// Bob does not reveal his share for a wrong share of Alice
+	bob := export.NewBob(curve, bobOutput)
+	bobShare, err := bob.Round2Reconstruct(curve.Scalar.Random(rand.Reader))
+	require.Error(t, err)
+	require.Nil(t, bobShare)
+	require.Nil(t, bob.Output())
+	_, err = bob.Round2Reconstruct(nil)
+	require.Error(t, err)
+
+	alice := export.NewAlice(curve, aliceOutput)
+	require.Error(t, alice.Round3Reconstruct(curve.Scalar.Random(rand.Reader)))
+	require.Nil(t, alice.Output())
+
+	_, err = export.NewAlice(curve, nil).Round1RevealShare()
+	require.Error(t, err)
+	_, err = export.Reconstruct(curve, aliceOutput.PublicKey, nil, aliceOutput.SecretKeyShare, curves.P256().Scalar.One())
+	require.Error(t, err)
+}
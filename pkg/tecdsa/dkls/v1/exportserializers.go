@@ -0,0 +1,142 @@
+//
+// Copyright Coinbase, Inc. All Rights Reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package v1
+
+import (
+	"bytes"
+	"encoding/gob"
+
+	"github.com/pkg/errors"
+
+	"github.com/nerifnetwork/kryptology/pkg/core/curves"
+	"github.com/nerifnetwork/kryptology/pkg/core/protocol"
+	"github.com/nerifnetwork/kryptology/pkg/tecdsa/dkls/v1/export"
+)
+
+func newExportProtocolMessage(payload []byte, round string, version uint) *protocol.Message {
+	return &protocol.Message{
+		Protocol: protocol.Dkls18Export,
+		Version:  version,
+		Payloads: map[string][]byte{payloadKey: payload},
+		Metadata: map[string]string{"round": round},
+	}
+}
+
+func encodeExportScalar(scalar curves.Scalar, round string, version uint) (*protocol.Message, error) {
+	if err := versionIsSupported(version); err != nil {
+		return nil, errors.Wrap(err, "version error")
+	}
+	if version == protocol.Version2 {
+		payload, err := marshalWire(toWireScalar(scalar))
+		if err != nil {
+			return nil, err
+		}
+		return newExportProtocolMessage(payload, round, version), nil
+	}
+	registerTypes()
+	buf := bytes.NewBuffer([]byte{})
+	enc := gob.NewEncoder(buf)
+	if err := enc.Encode(&scalar); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return newExportProtocolMessage(buf.Bytes(), round, version), nil
+}
+
+func decodeExportScalar(m *protocol.Message, round string) (curves.Scalar, error) {
+	if err := versionIsSupported(m.Version); err != nil {
+		return nil, errors.Wrap(err, "version error")
+	}
+	if m.Metadata["round"] != round {
+		return nil, errors.Errorf("expected export message of round %s", round)
+	}
+	if m.Version == protocol.Version2 {
+		decoded := new(wireScalar)
+		if err := unmarshalWire(m, decoded); err != nil {
+			return nil, err
+		}
+		return decoded.scalar()
+	}
+	registerTypes()
+	buf := bytes.NewBuffer(m.Payloads[payloadKey])
+	dec := gob.NewDecoder(buf)
+	decoded := new(curves.Scalar)
+	if err := dec.Decode(decoded); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return *decoded, nil
+}
+
+func encodeExportRound1Output(aliceSecretKeyShare curves.Scalar, version uint) (*protocol.Message, error) {
+	return encodeExportScalar(aliceSecretKeyShare, "1", version)
+}
+
+func decodeExportRound2Input(m *protocol.Message) (curves.Scalar, error) {
+	return decodeExportScalar(m, "1")
+}
+
+func encodeExportRound2Output(bobSecretKeyShare curves.Scalar, version uint) (*protocol.Message, error) {
+	return encodeExportScalar(bobSecretKeyShare, "2", version)
+}
+
+func decodeExportRound3Input(m *protocol.Message) (curves.Scalar, error) {
+	return decodeExportScalar(m, "2")
+}
+
+func encodeExportedKey(secretKey curves.Scalar, version uint) (*protocol.Message, error) {
+	return encodeExportScalar(secretKey, "secret-key", version)
+}
+
+// DecodeExportedKey deserializes the secret key that the export protocol returns.
+func DecodeExportedKey(m *protocol.Message) (curves.Scalar, error) {
+	return decodeExportScalar(m, "secret-key")
+}
+
+// EncodeBackup serializes the backup of a secret key share.
+func EncodeBackup(backup *export.Backup, version uint) (*protocol.Message, error) {
+	if err := versionIsSupported(version); err != nil {
+		return nil, errors.Wrap(err, "version error")
+	}
+	if version == protocol.Version2 {
+		payload, err := marshalWire(toWireBackup(backup))
+		if err != nil {
+			return nil, err
+		}
+		return newExportProtocolMessage(payload, "backup", version), nil
+	}
+	registerTypes()
+	buf := bytes.NewBuffer([]byte{})
+	enc := gob.NewEncoder(buf)
+	if err := enc.Encode(backup); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return newExportProtocolMessage(buf.Bytes(), "backup", version), nil
+}
+
+// DecodeBackup deserializes the backup of a secret key share.
+func DecodeBackup(m *protocol.Message) (*export.Backup, error) {
+	if err := versionIsSupported(m.Version); err != nil {
+		return nil, errors.Wrap(err, "version error")
+	}
+	if m.Metadata["round"] != "backup" {
+		return nil, errors.New("not a backup")
+	}
+	if m.Version == protocol.Version2 {
+		decoded := new(wireBackup)
+		if err := unmarshalWire(m, decoded); err != nil {
+			return nil, err
+		}
+		return decoded.backup()
+	}
+	registerTypes()
+	buf := bytes.NewBuffer(m.Payloads[payloadKey])
+	dec := gob.NewDecoder(buf)
+	decoded := new(export.Backup)
+	if err := dec.Decode(decoded); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return decoded, nil
+}
@@ -3,9 +3,25 @@
 package v1
 
 import (
+	"github.com/pkg/errors"
+
 	"github.com/nerifnetwork/kryptology/pkg/core/protocol"
 )
 
+// SupportedVersions are the versions of the serialization of the messages of this package. Version1 payloads are gob
+// encoded and can only be read by Go peers, Version2 payloads are BARE encoded as described in wire.bare. The version
+// should be negotiated with protocol.NegotiateVersion and SupportedVersions.
+var SupportedVersions = []uint{protocol.Version1, protocol.Version2}
+
+func versionIsSupported(messageVersion uint) error {
+	for _, v := range SupportedVersions {
+		if v == messageVersion {
+			return nil
+		}
+	}
+	return errors.Errorf("unsupported version %d", messageVersion)
+}
+
 // Basic protocol interface implementation that calls the next step func in a pre-defined list
 type protoStepper struct {
 	steps []func(input *protocol.Message) (*protocol.Message, error)
@@ -18,6 +34,11 @@ func (p *protoStepper) Next(input *protocol.Message) (*protocol.Message, error)
 		return nil, protocol.ErrProtocolFinished
 	}
 
+	// Only the first step runs without the message of the other party
+	if p.step > 0 && input == nil {
+		return nil, errors.New("input message cannot be nil")
+	}
+
 	// Run the current protocol step and report any errors
 	output, err := p.steps[p.step](input)
 	if err != nil {
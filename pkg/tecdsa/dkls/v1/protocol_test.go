@@ -8,6 +8,7 @@ package v1
 
 import (
 	"bytes"
+	crand "crypto/rand"
 	"crypto/sha256"
 	"encoding/json"
 	"fmt"
@@ -24,7 +25,10 @@ import (
 	"github.com/nerifnetwork/kryptology/pkg/core/protocol"
 	"github.com/nerifnetwork/kryptology/pkg/ot/extension/kos"
 	v0 "github.com/nerifnetwork/kryptology/pkg/tecdsa/dkls/v0"
+	"github.com/nerifnetwork/kryptology/pkg/tecdsa/dkls/v1/dealer"
 	"github.com/nerifnetwork/kryptology/pkg/tecdsa/dkls/v1/dkg"
+	"github.com/nerifnetwork/kryptology/pkg/tecdsa/dkls/v1/export"
+	"github.com/nerifnetwork/kryptology/pkg/verenc/elgamal"
 )
 
 // For DKG bob starts first. For refresh and sign, Alice starts first.
@@ -105,6 +109,100 @@ func TestDkgProto(t *testing.T) {
 	}
 }
 
+// Import > DKG > Sign
+func TestDkgWithKeyShareProto(t *testing.T) {
+	for _, curve := range []*curves.Curve{curves.K256(), curves.P256()} {
+		secretKey := curve.Scalar.Random(crand.Reader)
+		aliceShare, bobShare, err := dealer.SplitKey(curve, secretKey, nil)
+		require.NoError(t, err)
+
+		// The key holder sends each party its share
+		aliceShareMessage, err := EncodeKeyShare(aliceShare, protocol.Version1)
+		require.NoError(t, err)
+		bobShareMessage, err := EncodeKeyShare(bobShare, protocol.Version1)
+		require.NoError(t, err)
+		aliceShare, err = DecodeKeyShare(aliceShareMessage)
+		require.NoError(t, err)
+		bobShare, err = DecodeKeyShare(bobShareMessage)
+		require.NoError(t, err)
+
+		alice := NewAliceDkgWithKeyShare(curve, aliceShare, protocol.Version1)
+		bob := NewBobDkgWithKeyShare(curve, bobShare, protocol.Version1)
+		aErr, bErr := runIteratedProtocol(bob, alice)
+		require.ErrorIs(t, aErr, protocol.ErrProtocolFinished)
+		require.ErrorIs(t, bErr, protocol.ErrProtocolFinished)
+
+		aliceDkgResultMessage, err := alice.Result(protocol.Version1)
+		require.NoError(t, err)
+		bobDkgResultMessage, err := bob.Result(protocol.Version1)
+		require.NoError(t, err)
+		aliceResult, err := DecodeAliceDkgResult(aliceDkgResultMessage)
+		require.NoError(t, err)
+		require.True(t, curve.ScalarBaseMult(secretKey).Equal(aliceResult.PublicKey))
+
+		signV1(t, curve, aliceDkgResultMessage, bobDkgResultMessage)
+	}
+}
+
+// DKG > Export and DKG > Backup > Recover
+func TestExportProto(t *testing.T) {
+	for _, curve := range []*curves.Curve{curves.K256(), curves.P256()} {
+		aliceDkg := NewAliceDkg(curve, protocol.Version1)
+		bobDkg := NewBobDkg(curve, protocol.Version1)
+		aErr, bErr := runIteratedProtocol(bobDkg, aliceDkg)
+		require.ErrorIs(t, aErr, protocol.ErrProtocolFinished)
+		require.ErrorIs(t, bErr, protocol.ErrProtocolFinished)
+		aliceDkgResultMessage, err := aliceDkg.Result(protocol.Version1)
+		require.NoError(t, err)
+		bobDkgResultMessage, err := bobDkg.Result(protocol.Version1)
+		require.NoError(t, err)
+		publicKey := aliceDkg.Output().PublicKey
+
+		aliceExport, err := NewAliceExport(curve, aliceDkgResultMessage, protocol.Version1)
+		require.NoError(t, err)
+		bobExport, err := NewBobExport(curve, bobDkgResultMessage, protocol.Version1)
+		require.NoError(t, err)
+		aErr, bErr = runIteratedProtocol(aliceExport, bobExport)
+		require.ErrorIs(t, aErr, protocol.ErrProtocolFinished)
+		require.ErrorIs(t, bErr, protocol.ErrProtocolFinished)
+		aliceKeyMessage, err := aliceExport.Result(protocol.Version1)
+		require.NoError(t, err)
+		bobKeyMessage, err := bobExport.Result(protocol.Version1)
+		require.NoError(t, err)
+		aliceKey, err := DecodeExportedKey(aliceKeyMessage)
+		require.NoError(t, err)
+		bobKey, err := DecodeExportedKey(bobKeyMessage)
+		require.NoError(t, err)
+		require.True(t, curve.ScalarBaseMult(aliceKey).Equal(publicKey))
+		require.Equal(t, 0, aliceKey.Cmp(bobKey))
+
+		// Backups are encoded, exchanged and verified by the other party
+		sessionId := []byte("backup of the dkg output")
+		recoveryKey, decryptionKey, err := elgamal.NewKeys(curve)
+		require.NoError(t, err)
+		aliceBackup, err := export.NewAliceBackup(aliceDkg.Output(), recoveryKey, sessionId)
+		require.NoError(t, err)
+		bobBackup, err := export.NewBobBackup(bobDkg.Output(), recoveryKey, sessionId)
+		require.NoError(t, err)
+		aliceBackupMessage, err := EncodeBackup(aliceBackup, protocol.Version1)
+		require.NoError(t, err)
+		bobBackupMessage, err := EncodeBackup(bobBackup, protocol.Version1)
+		require.NoError(t, err)
+		aliceBackup, err = DecodeBackup(aliceBackupMessage)
+		require.NoError(t, err)
+		bobBackup, err = DecodeBackup(bobBackupMessage)
+		require.NoError(t, err)
+		require.NoError(t, aliceBackup.VerifyWithShare(recoveryKey, sessionId, bobDkg.Output().SecretKeyShare))
+		require.NoError(t, bobBackup.VerifyWithShare(recoveryKey, sessionId, aliceDkg.Output().SecretKeyShare))
+		_, err = DecodeBackup(aliceKeyMessage)
+		require.Error(t, err)
+
+		recovered, err := export.Recover(decryptionKey, sessionId, aliceBackup, bobBackup)
+		require.NoError(t, err)
+		require.Equal(t, 0, aliceKey.Cmp(recovered))
+	}
+}
+
 // DKG > Refresh > Sign
 func TestRefreshProto(t *testing.T) {
 	t.Parallel()
@@ -245,6 +343,67 @@ func TestDkgSignProto(t *testing.T) {
 	})
 }
 
+func TestPresignProto(t *testing.T) {
+	curve := curves.K256()
+	aliceDkg := NewAliceDkg(curve, protocol.Version1)
+	bobDkg := NewBobDkg(curve, protocol.Version1)
+	aErr, bErr := runIteratedProtocol(bobDkg, aliceDkg)
+	require.ErrorIs(t, aErr, protocol.ErrProtocolFinished)
+	require.ErrorIs(t, bErr, protocol.ErrProtocolFinished)
+	aliceDkgResultMessage, err := aliceDkg.Result(protocol.Version1)
+	require.NoError(t, err)
+	bobDkgResultMessage, err := bobDkg.Result(protocol.Version1)
+	require.NoError(t, err)
+	publicKey, err := curves.NewScalarBaseMult(btcec.S256(), new(big.Int))
+	require.NoError(t, err)
+	uncompressed := aliceDkg.Output().PublicKey.ToAffineUncompressed()
+	publicKey.X = new(big.Int).SetBytes(uncompressed[1:33])
+	publicKey.Y = new(big.Int).SetBytes(uncompressed[33:])
+
+	// Presign ahead of time
+	alicePresignatures := make([]*protocol.Message, 2)
+	bobPresignatures := make([]*protocol.Message, 2)
+	for i := range alicePresignatures {
+		alicePresign, err := NewAlicePresign(curve, aliceDkgResultMessage, protocol.Version1)
+		require.NoError(t, err)
+		bobPresign, err := NewBobPresign(curve, bobDkgResultMessage, protocol.Version1)
+		require.NoError(t, err)
+		result, err := alicePresign.Result(protocol.Version1)
+		require.NoError(t, err)
+		require.Nil(t, result)
+		aErr, bErr = runIteratedProtocol(alicePresign, bobPresign)
+		require.ErrorIs(t, aErr, protocol.ErrProtocolFinished)
+		require.ErrorIs(t, bErr, protocol.ErrProtocolFinished)
+		alicePresignatures[i], err = alicePresign.Result(protocol.Version1)
+		require.NoError(t, err)
+		bobPresignatures[i], err = bobPresign.Result(protocol.Version1)
+		require.NoError(t, err)
+	}
+
+	// Sign one message hash with each pair
+	for i := range alicePresignatures {
+		digest := sha3.Sum256([]byte(fmt.Sprintf("message %d", i)))
+		aliceMessage, err := AliceSignPresigned(alicePresignatures[i], digest[:], protocol.Version1)
+		require.NoError(t, err)
+		signatureMessage, err := BobSignPresigned(bobPresignatures[i], digest[:], aliceMessage, protocol.Version1)
+		require.NoError(t, err)
+		signature, err := DecodeSignature(signatureMessage)
+		require.NoError(t, err)
+		require.True(t, curves.VerifyEcdsa(publicKey, digest[:], signature))
+	}
+
+	// The presignatures of Alice and Bob are not interchangeable
+	digest := sha3.Sum256([]byte("message"))
+	_, err = AliceSignPresigned(bobPresignatures[0], digest[:], protocol.Version1)
+	require.Error(t, err)
+	aliceMessage, err := AliceSignPresigned(alicePresignatures[0], digest[:], protocol.Version1)
+	require.NoError(t, err)
+	_, err = BobSignPresigned(aliceMessage, digest[:], aliceMessage, protocol.Version1)
+	require.Error(t, err)
+	_, err = BobSignPresigned(bobPresignatures[1], digest[:], aliceMessage, protocol.Version1)
+	require.Error(t, err)
+}
+
 // Decode > NewDklsSign > Sign > Output
 // NOTE: this cold-start test ensures backwards compatibility with durable,
 // encoding DKG state that may exist within production systems like test
@@ -421,12 +580,17 @@ func convertV0AndSignV1(params *v0.Params, aliceBytes []byte, bobBytes []byte, t
 }
 
 func signV1(t *testing.T, curve *curves.Curve, aliceDkgResultMessage *protocol.Message, bobDkgResultMessage *protocol.Message) {
+	t.Helper()
+	signWithVersion(t, curve, aliceDkgResultMessage, bobDkgResultMessage, protocol.Version1)
+}
+
+func signWithVersion(t *testing.T, curve *curves.Curve, aliceDkgResultMessage *protocol.Message, bobDkgResultMessage *protocol.Message, version uint) {
 	t.Helper()
 	// New DklsSign
 	msg := []byte("As soon as you trust yourself, you will know how to live.")
-	aliceSign, err := NewAliceSign(curve, sha3.New256(), msg, aliceDkgResultMessage, protocol.Version1)
+	aliceSign, err := NewAliceSign(curve, sha3.New256(), msg, aliceDkgResultMessage, version)
 	require.NoError(t, err)
-	bobSign, err := NewBobSign(curve, sha3.New256(), msg, bobDkgResultMessage, protocol.Version1)
+	bobSign, err := NewBobSign(curve, sha3.New256(), msg, bobDkgResultMessage, version)
 	require.NoError(t, err)
 
 	// Sign
@@ -444,8 +608,9 @@ func signV1(t *testing.T, curve *curves.Curve, aliceDkgResultMessage *protocol.M
 	// Output
 	var result *curves.EcdsaSignature
 	t.Run("bob produces result of correct type", func(t *testing.T) {
-		resultMessage, err := bobSign.Result(protocol.Version1)
+		resultMessage, err := bobSign.Result(version)
 		require.NoError(t, err)
+		require.Equal(t, version, resultMessage.Version)
 		result, err = DecodeSignature(resultMessage)
 		require.NoError(t, err)
 	})
@@ -482,22 +647,27 @@ func signV1(t *testing.T, curve *curves.Curve, aliceDkgResultMessage *protocol.M
 
 func refreshV1(t *testing.T, curve *curves.Curve, aliceDkgResultMessage, bobDkgResultMessage *protocol.Message) (aliceRefreshResultMessage, bobRefreshResultMessage *protocol.Message) {
 	t.Helper()
-	aliceRefresh, err := NewAliceRefresh(curve, aliceDkgResultMessage, protocol.Version1)
+	return refreshWithVersion(t, curve, aliceDkgResultMessage, bobDkgResultMessage, protocol.Version1)
+}
+
+func refreshWithVersion(t *testing.T, curve *curves.Curve, aliceDkgResultMessage, bobDkgResultMessage *protocol.Message, version uint) (aliceRefreshResultMessage, bobRefreshResultMessage *protocol.Message) {
+	t.Helper()
+	aliceRefresh, err := NewAliceRefresh(curve, aliceDkgResultMessage, version)
 	require.NoError(t, err)
-	bobRefresh, err := NewBobRefresh(curve, bobDkgResultMessage, protocol.Version1)
+	bobRefresh, err := NewBobRefresh(curve, bobDkgResultMessage, version)
 	require.NoError(t, err)
 
 	aErr, bErr := runIteratedProtocol(aliceRefresh, bobRefresh)
 	require.ErrorIs(t, aErr, protocol.ErrProtocolFinished)
 	require.ErrorIs(t, bErr, protocol.ErrProtocolFinished)
 
-	aliceRefreshResultMessage, err = aliceRefresh.Result(protocol.Version1)
+	aliceRefreshResultMessage, err = aliceRefresh.Result(version)
 	require.NoError(t, err)
 	require.NotNil(t, aliceRefreshResultMessage)
 	_, err = DecodeAliceRefreshResult(aliceRefreshResultMessage)
 	require.NoError(t, err)
 
-	bobRefreshResultMessage, err = bobRefresh.Result(protocol.Version1)
+	bobRefreshResultMessage, err = bobRefresh.Result(version)
 	require.NoError(t, err)
 	require.NotNil(t, bobRefreshResultMessage)
 	_, err = DecodeBobRefreshResult(bobRefreshResultMessage)
@@ -505,3 +675,11 @@ func refreshV1(t *testing.T, curve *curves.Curve, aliceDkgResultMessage, bobDkgR
 
 	return aliceRefreshResultMessage, bobRefreshResultMessage
 }
+
+func TestNilInputRejected(t *testing.T) {
+	bob := NewBobDkg(curves.K256(), protocol.Version1)
+	_, err := bob.Next(nil)
+	require.NoError(t, err)
+	_, err = bob.Next(nil)
+	require.Error(t, err)
+}
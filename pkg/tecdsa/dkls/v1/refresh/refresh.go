@@ -38,6 +38,10 @@ type Alice struct {
 	// publicKey is the joint public key of Alice and Bob.
 	publicKey curves.Point
 
+	// chainCode and tweak are the BIP-32 chain code and tweak of the key, which the refresh keeps.
+	chainCode []byte
+	tweak     curves.Scalar
+
 	curve *curves.Curve
 
 	transcript *merlin.Transcript
@@ -55,6 +59,10 @@ type Bob struct {
 	// publicKey is the joint public key of Alice and Bob.
 	publicKey curves.Point
 
+	// chainCode and tweak are the BIP-32 chain code and tweak of the key, which the refresh keeps.
+	chainCode []byte
+	tweak     curves.Scalar
+
 	curve *curves.Curve
 
 	transcript *merlin.Transcript
@@ -71,6 +79,8 @@ func NewAlice(curve *curves.Curve, dkgOutput *dkg.AliceOutput) *Alice {
 		curve:          curve,
 		secretKeyShare: dkgOutput.SecretKeyShare,
 		publicKey:      dkgOutput.PublicKey,
+		chainCode:      dkgOutput.ChainCode,
+		tweak:          dkgOutput.Tweak,
 		transcript:     merlin.NewTranscript("Coinbase_DKLs_Refresh"),
 	}
 }
@@ -81,6 +91,8 @@ func NewBob(curve *curves.Curve, dkgOutput *dkg.BobOutput) *Bob {
 		curve:          curve,
 		secretKeyShare: dkgOutput.SecretKeyShare,
 		publicKey:      dkgOutput.PublicKey,
+		chainCode:      dkgOutput.ChainCode,
+		tweak:          dkgOutput.Tweak,
 		transcript:     merlin.NewTranscript("Coinbase_DKLs_Refresh"),
 	}
 }
@@ -165,6 +177,8 @@ func (alice *Alice) Output() *dkg.AliceOutput {
 		PublicKey:      alice.publicKey,
 		SecretKeyShare: alice.secretKeyShare,
 		SeedOtResult:   alice.receiver.Output,
+		ChainCode:      alice.chainCode,
+		Tweak:          alice.tweak,
 	}
 }
 
@@ -173,5 +187,7 @@ func (bob *Bob) Output() *dkg.BobOutput {
 		PublicKey:      bob.publicKey,
 		SecretKeyShare: bob.secretKeyShare,
 		SeedOtResult:   bob.sender.Output,
+		ChainCode:      bob.chainCode,
+		Tweak:          bob.tweak,
 	}
 }
@@ -22,17 +22,17 @@ func newRefreshProtocolMessage(payload []byte, round string, version uint) *prot
 	}
 }
 
-func versionIsSupported(messageVersion uint) error {
-	if messageVersion < uint(protocol.Version1) {
-		return errors.New("only version 1 is supported.")
-	}
-	return nil
-}
-
 func encodeRefreshRound1Output(seed curves.Scalar, version uint) (*protocol.Message, error) {
 	if err := versionIsSupported(version); err != nil {
 		return nil, errors.Wrap(err, "version error")
 	}
+	if version == protocol.Version2 {
+		payload, err := marshalWire(toWireScalar(seed))
+		if err != nil {
+			return nil, err
+		}
+		return newRefreshProtocolMessage(payload, "1", version), nil
+	}
 	registerTypes()
 	buf := bytes.NewBuffer([]byte{})
 	enc := gob.NewEncoder(buf)
@@ -46,6 +46,13 @@ func decodeRefreshRound2Input(m *protocol.Message) (curves.Scalar, error) {
 	if err := versionIsSupported(m.Version); err != nil {
 		return nil, errors.Wrap(err, "version error")
 	}
+	if m.Version == protocol.Version2 {
+		decoded := new(wireScalar)
+		if err := unmarshalWire(m, decoded); err != nil {
+			return nil, err
+		}
+		return decoded.scalar()
+	}
 	buf := bytes.NewBuffer(m.Payloads[payloadKey])
 	dec := gob.NewDecoder(buf)
 	decoded := new(curves.Scalar)
@@ -59,6 +66,13 @@ func encodeRefreshRound2Output(output *refresh.RefreshRound2Output, version uint
 	if err := versionIsSupported(version); err != nil {
 		return nil, errors.Wrap(err, "version error")
 	}
+	if version == protocol.Version2 {
+		payload, err := marshalWire(toWireRefreshRound2Output(output))
+		if err != nil {
+			return nil, err
+		}
+		return newRefreshProtocolMessage(payload, "2", version), nil
+	}
 	buf := bytes.NewBuffer([]byte{})
 	enc := gob.NewEncoder(buf)
 	if err := enc.Encode(output); err != nil {
@@ -71,6 +85,13 @@ func decodeRefreshRound3Input(m *protocol.Message) (*refresh.RefreshRound2Output
 	if err := versionIsSupported(m.Version); err != nil {
 		return nil, errors.Wrap(err, "version error")
 	}
+	if m.Version == protocol.Version2 {
+		decoded := new(wireRefreshRound2Output)
+		if err := unmarshalWire(m, decoded); err != nil {
+			return nil, err
+		}
+		return decoded.output()
+	}
 	buf := bytes.NewBuffer(m.Payloads[payloadKey])
 	dec := gob.NewDecoder(buf)
 	decoded := new(refresh.RefreshRound2Output)
@@ -84,6 +105,13 @@ func encodeRefreshRound3Output(choices []simplest.ReceiversMaskedChoices, versio
 	if err := versionIsSupported(version); err != nil {
 		return nil, errors.Wrap(err, "version error")
 	}
+	if version == protocol.Version2 {
+		payload, err := marshalWire(&wireOtMaskedChoices{Choices: choices}, nil)
+		if err != nil {
+			return nil, err
+		}
+		return newRefreshProtocolMessage(payload, "3", version), nil
+	}
 	buf := bytes.NewBuffer([]byte{})
 	enc := gob.NewEncoder(buf)
 	if err := enc.Encode(choices); err != nil {
@@ -96,6 +124,13 @@ func decodeRefreshRound4Input(m *protocol.Message) ([]simplest.ReceiversMaskedCh
 	if err := versionIsSupported(m.Version); err != nil {
 		return nil, errors.Wrap(err, "version error")
 	}
+	if m.Version == protocol.Version2 {
+		decoded := new(wireOtMaskedChoices)
+		if err := unmarshalWire(m, decoded); err != nil {
+			return nil, err
+		}
+		return decoded.Choices, nil
+	}
 	buf := bytes.NewBuffer(m.Payloads[payloadKey])
 	dec := gob.NewDecoder(buf)
 	decoded := []simplest.ReceiversMaskedChoices{}
@@ -109,6 +144,13 @@ func encodeRefreshRound4Output(challenge []simplest.OtChallenge, version uint) (
 	if err := versionIsSupported(version); err != nil {
 		return nil, errors.Wrap(err, "version error")
 	}
+	if version == protocol.Version2 {
+		payload, err := marshalWire(&wireOtChallenges{Challenges: challenge}, nil)
+		if err != nil {
+			return nil, err
+		}
+		return newRefreshProtocolMessage(payload, "4", version), nil
+	}
 	buf := bytes.NewBuffer([]byte{})
 	enc := gob.NewEncoder(buf)
 	if err := enc.Encode(challenge); err != nil {
@@ -121,6 +163,13 @@ func decodeRefreshRound5Input(m *protocol.Message) ([]simplest.OtChallenge, erro
 	if err := versionIsSupported(m.Version); err != nil {
 		return nil, errors.Wrap(err, "version error")
 	}
+	if m.Version == protocol.Version2 {
+		decoded := new(wireOtChallenges)
+		if err := unmarshalWire(m, decoded); err != nil {
+			return nil, err
+		}
+		return decoded.Challenges, nil
+	}
 	buf := bytes.NewBuffer(m.Payloads[payloadKey])
 	dec := gob.NewDecoder(buf)
 	decoded := []simplest.OtChallenge{}
@@ -134,6 +183,13 @@ func encodeRefreshRound5Output(responses []simplest.OtChallengeResponse, version
 	if err := versionIsSupported(version); err != nil {
 		return nil, errors.Wrap(err, "version error")
 	}
+	if version == protocol.Version2 {
+		payload, err := marshalWire(&wireOtChallengeResponses{Responses: responses}, nil)
+		if err != nil {
+			return nil, err
+		}
+		return newRefreshProtocolMessage(payload, "5", version), nil
+	}
 	buf := bytes.NewBuffer([]byte{})
 	enc := gob.NewEncoder(buf)
 	if err := enc.Encode(responses); err != nil {
@@ -146,6 +202,13 @@ func decodeRefreshRound6Input(m *protocol.Message) ([]simplest.OtChallengeRespon
 	if err := versionIsSupported(m.Version); err != nil {
 		return nil, errors.Wrap(err, "version error")
 	}
+	if m.Version == protocol.Version2 {
+		decoded := new(wireOtChallengeResponses)
+		if err := unmarshalWire(m, decoded); err != nil {
+			return nil, err
+		}
+		return decoded.Responses, nil
+	}
 	buf := bytes.NewBuffer(m.Payloads[payloadKey])
 	dec := gob.NewDecoder(buf)
 	decoded := []simplest.OtChallengeResponse{}
@@ -159,6 +222,13 @@ func encodeRefreshRound6Output(opening []simplest.ChallengeOpening, version uint
 	if err := versionIsSupported(version); err != nil {
 		return nil, errors.Wrap(err, "version error")
 	}
+	if version == protocol.Version2 {
+		payload, err := marshalWire(&wireOtChallengeOpenings{Openings: opening}, nil)
+		if err != nil {
+			return nil, err
+		}
+		return newRefreshProtocolMessage(payload, "6", version), nil
+	}
 	buf := bytes.NewBuffer([]byte{})
 	enc := gob.NewEncoder(buf)
 	if err := enc.Encode(opening); err != nil {
@@ -171,6 +241,13 @@ func decodeRefreshRound7Input(m *protocol.Message) ([]simplest.ChallengeOpening,
 	if err := versionIsSupported(m.Version); err != nil {
 		return nil, errors.Wrap(err, "version error")
 	}
+	if m.Version == protocol.Version2 {
+		decoded := new(wireOtChallengeOpenings)
+		if err := unmarshalWire(m, decoded); err != nil {
+			return nil, err
+		}
+		return decoded.Openings, nil
+	}
 	buf := bytes.NewBuffer(m.Payloads[payloadKey])
 	dec := gob.NewDecoder(buf)
 	decoded := []simplest.ChallengeOpening{}
@@ -185,6 +262,13 @@ func EncodeAliceRefreshOutput(result *dkg.AliceOutput, version uint) (*protocol.
 	if err := versionIsSupported(version); err != nil {
 		return nil, errors.Wrap(err, "version error")
 	}
+	if version == protocol.Version2 {
+		payload, err := marshalWire(toWireAliceOutput(result))
+		if err != nil {
+			return nil, err
+		}
+		return newRefreshProtocolMessage(payload, "alice-output", version), nil
+	}
 	registerTypes()
 	buf := bytes.NewBuffer([]byte{})
 	enc := gob.NewEncoder(buf)
@@ -199,6 +283,13 @@ func DecodeAliceRefreshResult(m *protocol.Message) (*dkg.AliceOutput, error) {
 	if err := versionIsSupported(m.Version); err != nil {
 		return nil, errors.Wrap(err, "version error")
 	}
+	if m.Version == protocol.Version2 {
+		decoded := new(wireAliceOutput)
+		if err := unmarshalWire(m, decoded); err != nil {
+			return nil, err
+		}
+		return decoded.output()
+	}
 	registerTypes()
 	buf := bytes.NewBuffer(m.Payloads[payloadKey])
 	dec := gob.NewDecoder(buf)
@@ -214,6 +305,13 @@ func EncodeBobRefreshOutput(result *dkg.BobOutput, version uint) (*protocol.Mess
 	if err := versionIsSupported(version); err != nil {
 		return nil, errors.Wrap(err, "version error")
 	}
+	if version == protocol.Version2 {
+		payload, err := marshalWire(toWireBobOutput(result))
+		if err != nil {
+			return nil, err
+		}
+		return newRefreshProtocolMessage(payload, "bob-output", version), nil
+	}
 	registerTypes()
 	buf := bytes.NewBuffer([]byte{})
 	enc := gob.NewEncoder(buf)
@@ -228,6 +326,13 @@ func DecodeBobRefreshResult(m *protocol.Message) (*dkg.BobOutput, error) {
 	if err := versionIsSupported(m.Version); err != nil {
 		return nil, errors.Wrap(err, "version error")
 	}
+	if m.Version == protocol.Version2 {
+		decoded := new(wireBobOutput)
+		if err := unmarshalWire(m, decoded); err != nil {
+			return nil, err
+		}
+		return decoded.output()
+	}
 	buf := bytes.NewBuffer(m.Payloads[payloadKey])
 	dec := gob.NewDecoder(buf)
 	decoded := new(dkg.BobOutput)
@@ -265,3 +265,13 @@ func (receiver *MultiplyReceiver) Round3Multiply(round2Output *MultiplyRound2Out
 	}
 	return nil
 }
+
+// OutputAdditiveShare returns the sender's additive share of the product, it is set by Round2Multiply.
+func (sender *MultiplySender) OutputAdditiveShare() curves.Scalar {
+	return sender.outputAdditiveShare
+}
+
+// OutputAdditiveShare returns the receiver's additive share of the product, it is set once Round3Multiply succeeds.
+func (receiver *MultiplyReceiver) OutputAdditiveShare() curves.Scalar {
+	return receiver.outputAdditiveShare
+}
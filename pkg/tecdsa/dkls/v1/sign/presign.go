@@ -0,0 +1,186 @@
+//
+// Copyright Coinbase, Inc. All Rights Reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package sign
+
+import (
+	"crypto/ecdsa"
+	"fmt"
+	"math/big"
+
+	"github.com/pkg/errors"
+
+	"github.com/nerifnetwork/kryptology/pkg/core/curves"
+)
+
+// AlicePresignature is Alice's share of a signature whose instance key R is fixed but whose message is not.
+// It is the result of Round3Presign and signs exactly one message hash. Signing two hashes with the same
+// presignature reveals the secret key, so stored copies must be deleted before the presignature is used.
+type AlicePresignature struct {
+	// R is the instance key of the signature. Alice's and Bob's presignatures of the same run have the same R.
+	R curves.Point
+
+	// RX is the X coordinate of R.
+	RX curves.Scalar
+
+	// Tweak is the BIP-32 tweak of a derived key, zero otherwise.
+	Tweak curves.Scalar
+
+	// PhiShare is Alice's output of the multiplication (phi + 1/kA) * (1/kB).
+	PhiShare curves.Scalar
+
+	// SecretShare is Alice's output of the multiplication skA/kA * skB/kB.
+	SecretShare curves.Scalar
+
+	// HashGamma2 masks Alice's share of the signature.
+	HashGamma2 curves.Scalar
+}
+
+// BobPresignature is Bob's share of a signature whose instance key R is fixed but whose message is not.
+// It is the result of Round4Presign and completes exactly one signature. See AlicePresignature.
+type BobPresignature struct {
+	// R is the instance key of the signature.
+	R curves.Point
+
+	// RX is the X coordinate of R, the R of the signature.
+	RX curves.Scalar
+
+	// V is the recovery id of the signature before it is normalized.
+	V int
+
+	// PublicKey is the public key that verifies the signature.
+	PublicKey curves.Point
+
+	// Tweak is the BIP-32 tweak of a derived key, zero otherwise.
+	Tweak curves.Scalar
+
+	// Theta is Bob's output of the multiplication (phi + 1/kA) * (1/kB) without phi.
+	Theta curves.Scalar
+
+	// SecretShare is Bob's output of the multiplication skA/kA * skB/kB.
+	SecretShare curves.Scalar
+
+	// HashGamma2 unmasks Alice's share of the signature.
+	HashGamma2 curves.Scalar
+}
+
+// PresignOutput is the message Alice sends to Bob to sign a message hash with a pair of presignatures.
+type PresignOutput struct {
+	// R identifies the presignatures.
+	R curves.Point
+
+	// EtaSig is the Eta_{Sig} from the paper.
+	EtaSig curves.Scalar
+}
+
+// Sign computes Alice's share of the signature of digest, the hash of the message, and erases the presignature.
+func (presignature *AlicePresignature) Sign(digest []byte) (*PresignOutput, error) {
+	if presignature == nil || presignature.R == nil {
+		return nil, errors.New("presignature cannot be nil")
+	}
+	if presignature.PhiShare == nil || presignature.SecretShare == nil || presignature.HashGamma2 == nil {
+		return nil, errors.New("presignature has already been used")
+	}
+	curve, err := presignatureCurve(presignature.R)
+	if err != nil {
+		return nil, err
+	}
+	hOfMAsInteger, err := curve.Scalar.SetBytes(digest)
+	if err != nil {
+		return nil, errors.Wrap(err, "setting hOfMAsInteger scalar from bytes")
+	}
+	rX := presignature.RX
+	// The secret key of a derived key is sk + t and s = (h(m) + rX.t + rX.sk) / k, so rX.t is added to the digest.
+	if presignature.Tweak != nil {
+		hOfMAsInteger = hOfMAsInteger.Add(rX.Mul(presignature.Tweak))
+	}
+	sigA := hOfMAsInteger.Mul(presignature.PhiShare).Add(rX.Mul(presignature.SecretShare))
+	output := &PresignOutput{
+		R:      presignature.R,
+		EtaSig: presignature.HashGamma2.Add(sigA),
+	}
+	presignature.erase()
+	return output, nil
+}
+
+// Sign completes the signature of digest, the hash of the message, with Alice's output, verifies it
+// and erases the presignature.
+func (presignature *BobPresignature) Sign(digest []byte, input *PresignOutput) (*curves.EcdsaSignature, error) {
+	if presignature == nil || presignature.R == nil || presignature.PublicKey == nil {
+		return nil, errors.New("presignature cannot be nil")
+	}
+	if input == nil || input.R == nil || input.EtaSig == nil {
+		return nil, errors.New("presign output cannot be nil")
+	}
+	if presignature.Theta == nil || presignature.SecretShare == nil || presignature.HashGamma2 == nil {
+		return nil, errors.New("presignature has already been used")
+	}
+	if !input.R.Equal(presignature.R) {
+		return nil, errors.New("presign output is for a different presignature")
+	}
+	curve, err := presignatureCurve(presignature.R)
+	if err != nil {
+		return nil, err
+	}
+	digestScalar, err := curve.Scalar.SetBytes(digest)
+	if err != nil {
+		return nil, errors.Wrap(err, "setting digest scalar from bytes")
+	}
+	capitalR := presignature.RX
+	if presignature.Tweak != nil {
+		digestScalar = digestScalar.Add(capitalR.Mul(presignature.Tweak))
+	}
+	sigB := digestScalar.Mul(presignature.Theta).Add(capitalR.Mul(presignature.SecretShare))
+	scalarS := sigB.Add(input.EtaSig.Sub(presignature.HashGamma2))
+	presignature.erase()
+
+	signature := &curves.EcdsaSignature{
+		R: capitalR.BigInt(),
+		S: scalarS.BigInt(),
+		V: presignature.V,
+	}
+	if signature.S.Bit(255) == 1 {
+		signature.S = scalarS.Neg().BigInt()
+		signature.V ^= 1
+	}
+	// now verify the signature
+	unCompressedAffinePublicKey := presignature.PublicKey.ToAffineUncompressed()
+	if len(unCompressedAffinePublicKey) != 65 {
+		return nil, errors.New("the uncompressed form must have exactly 65 bytes")
+	}
+	x := new(big.Int).SetBytes(unCompressedAffinePublicKey[1:33])
+	y := new(big.Int).SetBytes(unCompressedAffinePublicKey[33:])
+	ellipticCurve, err := curve.ToEllipticCurve()
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid curve")
+	}
+	if !ecdsa.Verify(&ecdsa.PublicKey{Curve: ellipticCurve, X: x, Y: y}, digest, signature.R, signature.S) {
+		return nil, fmt.Errorf("final signature failed to verify")
+	}
+	return signature, nil
+}
+
+// erase removes the secret values, so the presignature cannot sign again
+func (presignature *AlicePresignature) erase() {
+	presignature.PhiShare = nil
+	presignature.SecretShare = nil
+	presignature.HashGamma2 = nil
+}
+
+// erase removes the secret values, so the presignature cannot sign again
+func (presignature *BobPresignature) erase() {
+	presignature.Theta = nil
+	presignature.SecretShare = nil
+	presignature.HashGamma2 = nil
+}
+
+func presignatureCurve(r curves.Point) (*curves.Curve, error) {
+	curve := curves.GetCurveByName(r.CurveName())
+	if curve == nil {
+		return nil, fmt.Errorf("unknown curve %s", r.CurveName())
+	}
+	return curve, nil
+}
@@ -0,0 +1,151 @@
+//
+// Copyright Coinbase, Inc. All Rights Reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package sign
+
+import (
+	"crypto/ecdsa"
+	"crypto/rand"
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/sha3"
+
+	"github.com/nerifnetwork/kryptology/pkg/core/curves"
+	"github.com/nerifnetwork/kryptology/pkg/ot/base/simplest"
+	"github.com/nerifnetwork/kryptology/pkg/ot/extension/kos"
+	"github.com/nerifnetwork/kryptology/pkg/ot/ottest"
+	"github.com/nerifnetwork/kryptology/pkg/tecdsa/dkls/v1/dkg"
+)
+
+func testOutputs(t *testing.T, curve *curves.Curve) (*dkg.AliceOutput, *dkg.BobOutput) {
+	hashKeySeed := [simplest.DigestSize]byte{}
+	_, err := rand.Read(hashKeySeed[:])
+	require.NoError(t, err)
+	baseOtSenderOutput, baseOtReceiverOutput, err := ottest.RunSimplestOT(curve, kos.Kappa, hashKeySeed)
+	require.NoError(t, err)
+	secretKeyShareA := curve.Scalar.Random(rand.Reader)
+	secretKeyShareB := curve.Scalar.Random(rand.Reader)
+	publicKey := curve.ScalarBaseMult(secretKeyShareA.Mul(secretKeyShareB))
+	return &dkg.AliceOutput{SeedOtResult: baseOtReceiverOutput, SecretKeyShare: secretKeyShareA, PublicKey: publicKey},
+		&dkg.BobOutput{SeedOtResult: baseOtSenderOutput, SecretKeyShare: secretKeyShareB, PublicKey: publicKey}
+}
+
+// verify checks the signature with crypto/ecdsa
+func verify(t *testing.T, curve *curves.Curve, publicKey curves.Point, digest []byte, signature *curves.EcdsaSignature) {
+	ellipticCurve, err := curve.ToEllipticCurve()
+	require.NoError(t, err)
+	uncompressed := publicKey.ToAffineUncompressed()
+	pk := &ecdsa.PublicKey{
+		Curve: ellipticCurve,
+		X:     new(big.Int).SetBytes(uncompressed[1:33]),
+		Y:     new(big.Int).SetBytes(uncompressed[33:]),
+	}
+	require.True(t, ecdsa.Verify(pk, digest, signature.R, signature.S), "curve: %s", curve.Name)
+}
+
+func presign(t *testing.T, curve *curves.Curve, aliceOutput *dkg.AliceOutput, bobOutput *dkg.BobOutput) (*AlicePresignature, *BobPresignature) {
+	alice := NewAlice(curve, sha3.New256(), aliceOutput)
+	bob := NewBob(curve, sha3.New256(), bobOutput)
+	seed, err := alice.Round1GenerateRandomSeed()
+	require.NoError(t, err)
+	round2Output, err := bob.Round2Initialize(seed)
+	require.NoError(t, err)
+	round3Output, alicePresignature, err := alice.Round3Presign(round2Output)
+	require.NoError(t, err)
+	require.Nil(t, round3Output.EtaSig)
+	bobPresignature, err := bob.Round4Presign(round3Output)
+	require.NoError(t, err)
+	require.True(t, alicePresignature.R.Equal(bobPresignature.R))
+	return alicePresignature, bobPresignature
+}
+
+func TestPresign(t *testing.T) {
+	for _, curve := range []*curves.Curve{curves.K256(), curves.P256()} {
+		aliceOutput, bobOutput := testOutputs(t, curve)
+		alicePresignatures := make([]*AlicePresignature, 3)
+		bobPresignatures := make([]*BobPresignature, 3)
+		for i := range alicePresignatures {
+			alicePresignatures[i], bobPresignatures[i] = presign(t, curve, aliceOutput, bobOutput)
+		}
+
+		// Sign in a different order than the presignatures were made
+		for _, i := range []int{2, 0, 1} {
+			digest := sha3.Sum256([]byte{byte(i)})
+			output, err := alicePresignatures[i].Sign(digest[:])
+			require.NoError(t, err)
+			signature, err := bobPresignatures[i].Sign(digest[:], output)
+			require.NoError(t, err, "curve: %s", curve.Name)
+			verify(t, curve, aliceOutput.PublicKey, digest[:], signature)
+		}
+	}
+}
+
+func TestPresignDerivedKey(t *testing.T) {
+	curve := curves.K256()
+	aliceOutput, bobOutput := testOutputs(t, curve)
+	aliceOutput.ChainCode = make([]byte, 32)
+	bobOutput.ChainCode = make([]byte, 32)
+	aliceChild, err := aliceOutput.Derive([]uint32{1, 2})
+	require.NoError(t, err)
+	bobChild, err := bobOutput.Derive([]uint32{1, 2})
+	require.NoError(t, err)
+
+	alicePresignature, bobPresignature := presign(t, curve, aliceChild, bobChild)
+	digest := sha3.Sum256([]byte("A message for a derived key."))
+	output, err := alicePresignature.Sign(digest[:])
+	require.NoError(t, err)
+	signature, err := bobPresignature.Sign(digest[:], output)
+	require.NoError(t, err)
+	verify(t, curve, aliceChild.PublicKey, digest[:], signature)
+}
+
+func TestPresignSingleUse(t *testing.T) {
+	curve := curves.K256()
+	aliceOutput, bobOutput := testOutputs(t, curve)
+	alicePresignature, bobPresignature := presign(t, curve, aliceOutput, bobOutput)
+	digest := sha3.Sum256([]byte("A message."))
+	output, err := alicePresignature.Sign(digest[:])
+	require.NoError(t, err)
+	_, err = alicePresignature.Sign(digest[:])
+	require.Error(t, err)
+	_, err = bobPresignature.Sign(digest[:], output)
+	require.NoError(t, err)
+	_, err = bobPresignature.Sign(digest[:], output)
+	require.Error(t, err)
+}
+
+func TestPresignMismatch(t *testing.T) {
+	curve := curves.K256()
+	aliceOutput, bobOutput := testOutputs(t, curve)
+	alicePresignature, _ := presign(t, curve, aliceOutput, bobOutput)
+	_, bobPresignature := presign(t, curve, aliceOutput, bobOutput)
+	digest := sha3.Sum256([]byte("A message."))
+	output, err := alicePresignature.Sign(digest[:])
+	require.NoError(t, err)
+	_, err = bobPresignature.Sign(digest[:], output)
+	require.Error(t, err)
+
+	// Alice's output for another hash does not verify
+	alicePresignature, bobPresignature = presign(t, curve, aliceOutput, bobOutput)
+	output, err = alicePresignature.Sign(digest[:])
+	require.NoError(t, err)
+	other := sha3.Sum256([]byte("Another message."))
+	_, err = bobPresignature.Sign(other[:], output)
+	require.Error(t, err)
+
+	// The full rounds need EtaSig
+	alice := NewAlice(curve, sha3.New256(), aliceOutput)
+	bob := NewBob(curve, sha3.New256(), bobOutput)
+	seed, err := alice.Round1GenerateRandomSeed()
+	require.NoError(t, err)
+	round2Output, err := bob.Round2Initialize(seed)
+	require.NoError(t, err)
+	round3Output, _, err := alice.Round3Presign(round2Output)
+	require.NoError(t, err)
+	require.Error(t, bob.Round4Final([]byte("A message."), round3Output))
+}
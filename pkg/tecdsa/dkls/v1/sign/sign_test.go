@@ -17,6 +17,7 @@ import (
 	"github.com/nerifnetwork/kryptology/pkg/ot/base/simplest"
 	"github.com/nerifnetwork/kryptology/pkg/ot/extension/kos"
 	"github.com/nerifnetwork/kryptology/pkg/ot/ottest"
+	"github.com/nerifnetwork/kryptology/pkg/tecdsa/bip32"
 	"github.com/nerifnetwork/kryptology/pkg/tecdsa/dkls/v1/dkg"
 )
 
@@ -52,6 +53,45 @@ func TestSign(t *testing.T) {
 	}
 }
 
+func TestSignDerivedKey(t *testing.T) {
+	for _, curve := range []*curves.Curve{curves.K256(), curves.P256()} {
+		hashKeySeed := [simplest.DigestSize]byte{}
+		_, err := rand.Read(hashKeySeed[:])
+		require.NoError(t, err)
+		baseOtSenderOutput, baseOtReceiverOutput, err := ottest.RunSimplestOT(curve, kos.Kappa, hashKeySeed)
+		require.NoError(t, err)
+
+		chainCode, err := bip32.NewChainCode()
+		require.NoError(t, err)
+		secretKeyShareA := curve.Scalar.Random(rand.Reader)
+		secretKeyShareB := curve.Scalar.Random(rand.Reader)
+		publicKey := curve.ScalarBaseMult(secretKeyShareA.Mul(secretKeyShareB))
+		aliceOutput := &dkg.AliceOutput{SeedOtResult: baseOtReceiverOutput, SecretKeyShare: secretKeyShareA, PublicKey: publicKey, ChainCode: chainCode}
+		bobOutput := &dkg.BobOutput{SeedOtResult: baseOtSenderOutput, SecretKeyShare: secretKeyShareB, PublicKey: publicKey, ChainCode: chainCode}
+
+		for _, path := range [][]uint32{{0}, {44, 0, 7}} {
+			aliceChild, err := aliceOutput.Derive(path)
+			require.NoError(t, err)
+			bobChild, err := bobOutput.Derive(path)
+			require.NoError(t, err)
+			alice := NewAlice(curve, sha3.New256(), aliceChild)
+			bob := NewBob(curve, sha3.New256(), bobChild)
+
+			message := []byte("A message for a derived key.")
+			seed, err := alice.Round1GenerateRandomSeed()
+			require.NoError(t, err)
+			round3Output, err := bob.Round2Initialize(seed)
+			require.NoError(t, err)
+			round4Output, err := alice.Round3Sign(message, round3Output)
+			require.NoError(t, err)
+			// Round4Final verifies the signature against the child public key
+			err = bob.Round4Final(message, round4Output)
+			require.NoError(t, err, "curve: %s", curve.Name)
+			require.False(t, aliceChild.PublicKey.Equal(publicKey))
+		}
+	}
+}
+
 func BenchmarkSign(b *testing.B) {
 	curve := curves.K256()
 	hashKeySeed := [simplest.DigestSize]byte{}
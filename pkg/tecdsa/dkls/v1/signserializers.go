@@ -21,8 +21,15 @@ func newSignProtocolMessage(payload []byte, round string, version uint) *protoco
 }
 
 func encodeSignRound1Output(commitment [32]byte, version uint) (*protocol.Message, error) {
-	if version != protocol.Version1 {
-		return nil, errors.New("only version 1 is supported")
+	if err := versionIsSupported(version); err != nil {
+		return nil, errors.Wrap(err, "version error")
+	}
+	if version == protocol.Version2 {
+		payload, err := marshalWire(&wireCommitment{Commitment: commitment}, nil)
+		if err != nil {
+			return nil, err
+		}
+		return newSignProtocolMessage(payload, "1", version), nil
 	}
 	buf := bytes.NewBuffer([]byte{})
 	enc := gob.NewEncoder(buf)
@@ -33,8 +40,15 @@ func encodeSignRound1Output(commitment [32]byte, version uint) (*protocol.Messag
 }
 
 func decodeSignRound2Input(m *protocol.Message) ([32]byte, error) {
-	if m.Version != protocol.Version1 {
-		return [32]byte{}, errors.New("only version 1 is supported")
+	if err := versionIsSupported(m.Version); err != nil {
+		return [32]byte{}, errors.Wrap(err, "version error")
+	}
+	if m.Version == protocol.Version2 {
+		decoded := new(wireCommitment)
+		if err := unmarshalWire(m, decoded); err != nil {
+			return [32]byte{}, err
+		}
+		return decoded.Commitment, nil
 	}
 	buf := bytes.NewBuffer(m.Payloads[payloadKey])
 	dec := gob.NewDecoder(buf)
@@ -46,8 +60,15 @@ func decodeSignRound2Input(m *protocol.Message) ([32]byte, error) {
 }
 
 func encodeSignRound2Output(output *sign.SignRound2Output, version uint) (*protocol.Message, error) {
-	if version != protocol.Version1 {
-		return nil, errors.New("only version 1 is supported")
+	if err := versionIsSupported(version); err != nil {
+		return nil, errors.Wrap(err, "version error")
+	}
+	if version == protocol.Version2 {
+		payload, err := marshalWire(toWireSignRound2Output(output))
+		if err != nil {
+			return nil, err
+		}
+		return newSignProtocolMessage(payload, "2", version), nil
 	}
 	buf := bytes.NewBuffer([]byte{})
 	enc := gob.NewEncoder(buf)
@@ -58,8 +79,15 @@ func encodeSignRound2Output(output *sign.SignRound2Output, version uint) (*proto
 }
 
 func decodeSignRound3Input(m *protocol.Message) (*sign.SignRound2Output, error) {
-	if m.Version != protocol.Version1 {
-		return nil, errors.New("only version 1 is supported")
+	if err := versionIsSupported(m.Version); err != nil {
+		return nil, errors.Wrap(err, "version error")
+	}
+	if m.Version == protocol.Version2 {
+		decoded := new(wireSignRound2Output)
+		if err := unmarshalWire(m, decoded); err != nil {
+			return nil, err
+		}
+		return decoded.output()
 	}
 	buf := bytes.NewBuffer(m.Payloads[payloadKey])
 	dec := gob.NewDecoder(buf)
@@ -71,8 +99,15 @@ func decodeSignRound3Input(m *protocol.Message) (*sign.SignRound2Output, error)
 }
 
 func encodeSignRound3Output(output *sign.SignRound3Output, version uint) (*protocol.Message, error) {
-	if version != protocol.Version1 {
-		return nil, errors.New("only version 1 is supported")
+	if err := versionIsSupported(version); err != nil {
+		return nil, errors.Wrap(err, "version error")
+	}
+	if version == protocol.Version2 {
+		payload, err := marshalWire(toWireSignRound3Output(output))
+		if err != nil {
+			return nil, err
+		}
+		return newSignProtocolMessage(payload, "3", version), nil
 	}
 	buf := bytes.NewBuffer([]byte{})
 	enc := gob.NewEncoder(buf)
@@ -83,8 +118,15 @@ func encodeSignRound3Output(output *sign.SignRound3Output, version uint) (*proto
 }
 
 func decodeSignRound4Input(m *protocol.Message) (*sign.SignRound3Output, error) {
-	if m.Version != protocol.Version1 {
-		return nil, errors.New("only version 1 is supported")
+	if err := versionIsSupported(m.Version); err != nil {
+		return nil, errors.Wrap(err, "version error")
+	}
+	if m.Version == protocol.Version2 {
+		decoded := new(wireSignRound3Output)
+		if err := unmarshalWire(m, decoded); err != nil {
+			return nil, err
+		}
+		return decoded.output()
 	}
 	buf := bytes.NewBuffer(m.Payloads[payloadKey])
 	dec := gob.NewDecoder(buf)
@@ -96,8 +138,15 @@ func decodeSignRound4Input(m *protocol.Message) (*sign.SignRound3Output, error)
 }
 
 func encodeSignature(signature *curves.EcdsaSignature, version uint) (*protocol.Message, error) {
-	if version != protocol.Version1 {
-		return nil, errors.New("only version 1 is supported")
+	if err := versionIsSupported(version); err != nil {
+		return nil, errors.Wrap(err, "version error")
+	}
+	if version == protocol.Version2 {
+		payload, err := marshalWire(toWireSignature(signature))
+		if err != nil {
+			return nil, err
+		}
+		return newSignProtocolMessage(payload, "signature", version), nil
 	}
 	buf := bytes.NewBuffer([]byte{})
 	enc := gob.NewEncoder(buf)
@@ -109,8 +158,15 @@ func encodeSignature(signature *curves.EcdsaSignature, version uint) (*protocol.
 
 // DecodeSignature serializes the signature.
 func DecodeSignature(m *protocol.Message) (*curves.EcdsaSignature, error) {
-	if m.Version != protocol.Version1 {
-		return nil, errors.New("only version 1 is supported")
+	if err := versionIsSupported(m.Version); err != nil {
+		return nil, errors.Wrap(err, "version error")
+	}
+	if m.Version == protocol.Version2 {
+		decoded := new(wireSignature)
+		if err := unmarshalWire(m, decoded); err != nil {
+			return nil, err
+		}
+		return decoded.signature(), nil
 	}
 	buf := bytes.NewBuffer(m.Payloads[payloadKey])
 	dec := gob.NewDecoder(buf)
@@ -120,3 +176,134 @@ func DecodeSignature(m *protocol.Message) (*curves.EcdsaSignature, error) {
 	}
 	return decoded, nil
 }
+
+// EncodeAlicePresignature serializes Alice's presignature.
+func EncodeAlicePresignature(presignature *sign.AlicePresignature, version uint) (*protocol.Message, error) {
+	if err := versionIsSupported(version); err != nil {
+		return nil, errors.Wrap(err, "version error")
+	}
+	if version == protocol.Version2 {
+		payload, err := marshalWire(toWireAlicePresignature(presignature))
+		if err != nil {
+			return nil, err
+		}
+		return newSignProtocolMessage(payload, "alice-presignature", version), nil
+	}
+	registerTypes()
+	buf := bytes.NewBuffer([]byte{})
+	enc := gob.NewEncoder(buf)
+	if err := enc.Encode(presignature); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return newSignProtocolMessage(buf.Bytes(), "alice-presignature", version), nil
+}
+
+// DecodeAlicePresignature deserializes Alice's presignature.
+func DecodeAlicePresignature(m *protocol.Message) (*sign.AlicePresignature, error) {
+	if err := versionIsSupported(m.Version); err != nil {
+		return nil, errors.Wrap(err, "version error")
+	}
+	if m.Metadata["round"] != "alice-presignature" {
+		return nil, errors.New("not an alice presignature")
+	}
+	if m.Version == protocol.Version2 {
+		decoded := new(wireAlicePresignature)
+		if err := unmarshalWire(m, decoded); err != nil {
+			return nil, err
+		}
+		return decoded.presignature()
+	}
+	registerTypes()
+	buf := bytes.NewBuffer(m.Payloads[payloadKey])
+	dec := gob.NewDecoder(buf)
+	decoded := new(sign.AlicePresignature)
+	if err := dec.Decode(&decoded); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return decoded, nil
+}
+
+// EncodeBobPresignature serializes Bob's presignature.
+func EncodeBobPresignature(presignature *sign.BobPresignature, version uint) (*protocol.Message, error) {
+	if err := versionIsSupported(version); err != nil {
+		return nil, errors.Wrap(err, "version error")
+	}
+	if version == protocol.Version2 {
+		payload, err := marshalWire(toWireBobPresignature(presignature))
+		if err != nil {
+			return nil, err
+		}
+		return newSignProtocolMessage(payload, "bob-presignature", version), nil
+	}
+	registerTypes()
+	buf := bytes.NewBuffer([]byte{})
+	enc := gob.NewEncoder(buf)
+	if err := enc.Encode(presignature); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return newSignProtocolMessage(buf.Bytes(), "bob-presignature", version), nil
+}
+
+// DecodeBobPresignature deserializes Bob's presignature.
+func DecodeBobPresignature(m *protocol.Message) (*sign.BobPresignature, error) {
+	if err := versionIsSupported(m.Version); err != nil {
+		return nil, errors.Wrap(err, "version error")
+	}
+	if m.Metadata["round"] != "bob-presignature" {
+		return nil, errors.New("not a bob presignature")
+	}
+	if m.Version == protocol.Version2 {
+		decoded := new(wireBobPresignature)
+		if err := unmarshalWire(m, decoded); err != nil {
+			return nil, err
+		}
+		return decoded.presignature()
+	}
+	registerTypes()
+	buf := bytes.NewBuffer(m.Payloads[payloadKey])
+	dec := gob.NewDecoder(buf)
+	decoded := new(sign.BobPresignature)
+	if err := dec.Decode(&decoded); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return decoded, nil
+}
+
+func encodePresignOutput(output *sign.PresignOutput, version uint) (*protocol.Message, error) {
+	if err := versionIsSupported(version); err != nil {
+		return nil, errors.Wrap(err, "version error")
+	}
+	if version == protocol.Version2 {
+		payload, err := marshalWire(toWirePresignOutput(output))
+		if err != nil {
+			return nil, err
+		}
+		return newSignProtocolMessage(payload, "presign-output", version), nil
+	}
+	buf := bytes.NewBuffer([]byte{})
+	enc := gob.NewEncoder(buf)
+	if err := enc.Encode(output); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return newSignProtocolMessage(buf.Bytes(), "presign-output", version), nil
+}
+
+func decodePresignOutput(m *protocol.Message) (*sign.PresignOutput, error) {
+	if err := versionIsSupported(m.Version); err != nil {
+		return nil, errors.Wrap(err, "version error")
+	}
+	if m.Version == protocol.Version2 {
+		decoded := new(wirePresignOutput)
+		if err := unmarshalWire(m, decoded); err != nil {
+			return nil, err
+		}
+		return decoded.output()
+	}
+	buf := bytes.NewBuffer(m.Payloads[payloadKey])
+	dec := gob.NewDecoder(buf)
+	decoded := &sign.PresignOutput{}
+	if err := dec.Decode(&decoded); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return decoded, nil
+}
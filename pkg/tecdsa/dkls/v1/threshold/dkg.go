@@ -0,0 +1,217 @@
+//
+// Copyright Coinbase, Inc. All Rights Reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package threshold
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/binary"
+	"fmt"
+
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/sha3"
+
+	"github.com/nerifnetwork/kryptology/internal"
+	"github.com/nerifnetwork/kryptology/pkg/core/curves"
+	"github.com/nerifnetwork/kryptology/pkg/ot/base/simplest"
+	"github.com/nerifnetwork/kryptology/pkg/sharing"
+	"github.com/nerifnetwork/kryptology/pkg/zkp/schnorr"
+)
+
+// Dkg is a participant of the distributed key generation. Every participant Feldman shares a random part of the key,
+// commits to the Feldman commitments before they are revealed and proves knowledge of its part with a schnorr proof.
+// In the same rounds every pair of participants runs the seed OTs that signing needs.
+type Dkg struct {
+	*participant
+
+	// polynomial shares the part of the key of this participant
+	polynomial  *sharing.Polynomial
+	commitments []curves.Point
+	salt        [simplest.DigestSize]byte
+
+	// hashes are the round 1 commitments of the other participants
+	hashes map[uint32][]byte
+}
+
+// DkgRound1Bcast contains the seed of a participant and its commitment to the Feldman commitments.
+type DkgRound1Bcast struct {
+	// Seed is the random value used to derive the joint unique session id.
+	Seed [simplest.DigestSize]byte
+
+	// Commitment is the hash of the Feldman commitments and the salt.
+	Commitment []byte
+}
+
+// DkgRound2Bcast reveals the Feldman commitments of a participant and proves knowledge of its part of the key.
+type DkgRound2Bcast struct {
+	Commitments []curves.Point
+	Salt        [simplest.DigestSize]byte
+	Proof       *schnorr.Proof
+}
+
+// DkgRound2P2PSend contains the Shamir share of the recipient and starts the seed OT in which the recipient
+// is the receiver. It must be sent over a private channel.
+type DkgRound2P2PSend struct {
+	Share  curves.Scalar
+	SeedOt *schnorr.Proof
+}
+
+// NewDkg creates a participant of the DKG. The session id must be unique and known to all participants,
+// ids are the identifiers of all participants including id. Any threshold of the participants can sign.
+func NewDkg(curve *curves.Curve, sid []byte, id, threshold uint32, ids []uint32) (*Dkg, error) {
+	p, err := newParticipant(curve, sid, id, threshold, ids)
+	if err != nil {
+		return nil, err
+	}
+	return &Dkg{participant: p}, nil
+}
+
+// Round1 samples the part of the key of this participant and commits to its Feldman commitments.
+func (d *Dkg) Round1() (*DkgRound1Bcast, error) {
+	if d == nil || d.participant == nil {
+		return nil, internal.ErrNilArguments
+	}
+	if d.round != 1 {
+		return nil, internal.ErrInvalidRound
+	}
+	if _, err := rand.Read(d.seed[:]); err != nil {
+		return nil, errors.Wrap(err, "generating random seed in dkg round 1")
+	}
+	if _, err := rand.Read(d.salt[:]); err != nil {
+		return nil, errors.Wrap(err, "generating random salt in dkg round 1")
+	}
+	d.polynomial = new(sharing.Polynomial).Init(d.curve.Scalar.Random(rand.Reader), d.threshold, rand.Reader)
+	d.commitments = feldmanCommitments(d.curve, d.polynomial)
+	d.round = 2
+	return &DkgRound1Bcast{
+		Seed:       d.seed,
+		Commitment: dkgCommitment(d.sid, d.id, d.commitments, d.salt),
+	}, nil
+}
+
+// Round2 reveals the Feldman commitments, proves knowledge of the part of the key and sends the Shamir shares.
+// It also starts the seed OTs in which this participant is the sender.
+func (d *Dkg) Round2(in map[uint32]*DkgRound1Bcast) (*DkgRound2Bcast, map[uint32]*DkgRound2P2PSend, error) {
+	if d == nil || d.participant == nil {
+		return nil, nil, internal.ErrNilArguments
+	}
+	if d.round != 2 {
+		return nil, nil, internal.ErrInvalidRound
+	}
+	seeds := make(map[uint32][simplest.DigestSize]byte, len(d.ids))
+	d.hashes = make(map[uint32][]byte, len(d.ids)-1)
+	for _, j := range d.others() {
+		if in[j] == nil || len(in[j].Commitment) != simplest.DigestSize {
+			return nil, nil, fmt.Errorf("participant %d sent an invalid commitment", j)
+		}
+		seeds[j] = in[j].Seed
+		d.hashes[j] = in[j].Commitment
+	}
+	seeds[d.id] = d.seed
+	seedOts, err := d.startSeedOts(seeds)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	uniqueSessionId := pairSessionId(d.sessionId, "key share proof", d.id, d.id)
+	proof, err := schnorr.NewProver(d.curve, nil, uniqueSessionId[:]).Prove(d.polynomial.Coefficients[0])
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "proving knowledge of the key share in dkg round 2")
+	}
+	p2p := make(map[uint32]*DkgRound2P2PSend, len(d.ids)-1)
+	for _, j := range d.others() {
+		p2p[j] = &DkgRound2P2PSend{
+			Share:  d.polynomial.Evaluate(d.curve.Scalar.New(int(j))),
+			SeedOt: seedOts[j],
+		}
+	}
+	d.round = 3
+	return &DkgRound2Bcast{
+		Commitments: d.commitments,
+		Salt:        d.salt,
+		Proof:       proof,
+	}, p2p, nil
+}
+
+// Round3 verifies the Feldman commitments, the proofs and the shares of the other participants and computes the
+// share of the key. It answers the seed OTs in which this participant is the receiver.
+func (d *Dkg) Round3(bcast map[uint32]*DkgRound2Bcast, p2p map[uint32]*DkgRound2P2PSend) (map[uint32]*Round3P2PSend, error) {
+	if d == nil || d.participant == nil {
+		return nil, internal.ErrNilArguments
+	}
+	if d.round != 3 {
+		return nil, internal.ErrInvalidRound
+	}
+	d.secretKeyShare = d.polynomial.Evaluate(d.curve.Scalar.New(int(d.id)))
+	d.publicKey = d.commitments[0]
+	d.publicShares = make(map[uint32]curves.Point, len(d.ids))
+	for _, k := range d.ids {
+		d.publicShares[k] = evaluate(d.curve, d.commitments, k)
+	}
+	seedOts := make(map[uint32]*schnorr.Proof, len(d.ids)-1)
+	for _, j := range d.others() {
+		if err := d.verifyShare(j, bcast[j], p2p[j]); err != nil {
+			return nil, err
+		}
+		commitments := bcast[j].Commitments
+		d.secretKeyShare = d.secretKeyShare.Add(p2p[j].Share)
+		d.publicKey = d.publicKey.Add(commitments[0])
+		for _, k := range d.ids {
+			d.publicShares[k] = d.publicShares[k].Add(evaluate(d.curve, commitments, k))
+		}
+		seedOts[j] = p2p[j].SeedOt
+	}
+	if d.publicKey.IsIdentity() {
+		return nil, fmt.Errorf("the public key is the identity")
+	}
+	out, err := d.answerSeedOts(seedOts)
+	if err != nil {
+		return nil, err
+	}
+	d.round = 4
+	return out, nil
+}
+
+// verifyShare checks the round 2 messages of participant j against its round 1 commitment
+func (d *Dkg) verifyShare(j uint32, bcast *DkgRound2Bcast, p2p *DkgRound2P2PSend) error {
+	if bcast == nil || len(bcast.Commitments) != int(d.threshold) {
+		return fmt.Errorf("participant %d sent invalid feldman commitments", j)
+	}
+	for _, c := range bcast.Commitments {
+		if c == nil || !c.IsOnCurve() {
+			return fmt.Errorf("participant %d sent invalid feldman commitments", j)
+		}
+	}
+	if subtle.ConstantTimeCompare(d.hashes[j], dkgCommitment(d.sid, j, bcast.Commitments, bcast.Salt)) != 1 {
+		return fmt.Errorf("participant %d did not open its commitment", j)
+	}
+	proof := bcast.Proof
+	if proof == nil || proof.C == nil || proof.S == nil || proof.Statement == nil || !proof.Statement.Equal(bcast.Commitments[0]) {
+		return fmt.Errorf("participant %d sent an invalid proof", j)
+	}
+	uniqueSessionId := pairSessionId(d.sessionId, "key share proof", j, j)
+	if err := schnorr.Verify(proof, d.curve, nil, uniqueSessionId[:]); err != nil {
+		return errors.Wrapf(err, "participant %d sent an invalid proof", j)
+	}
+	if p2p == nil || p2p.Share == nil || !d.curve.ScalarBaseMult(p2p.Share).Equal(evaluate(d.curve, bcast.Commitments, d.id)) {
+		return fmt.Errorf("participant %d sent an invalid share", j)
+	}
+	return nil
+}
+
+// dkgCommitment hashes the Feldman commitments of a participant with the salt
+func dkgCommitment(sid []byte, id uint32, commitments []curves.Point, salt [simplest.DigestSize]byte) []byte {
+	hash := sha3.New256()
+	_, _ = hash.Write([]byte("Coinbase_DKLs_Threshold_DKG"))
+	_, _ = hash.Write(sid)
+	_ = binary.Write(hash, binary.BigEndian, id)
+	for _, c := range commitments {
+		_, _ = hash.Write(c.ToAffineCompressed())
+	}
+	_, _ = hash.Write(salt[:])
+	return hash.Sum(nil)
+}
@@ -0,0 +1,240 @@
+//
+// Copyright Coinbase, Inc. All Rights Reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package threshold
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/nerifnetwork/kryptology/internal"
+	"github.com/nerifnetwork/kryptology/pkg/core/curves"
+	"github.com/nerifnetwork/kryptology/pkg/sharing"
+)
+
+var testSid = []byte("dkls threshold test session")
+
+func testIds(n uint32) []uint32 {
+	ids := make([]uint32, n)
+	for i := range ids {
+		ids[i] = uint32(i + 1)
+	}
+	return ids
+}
+
+// dkgRound2 runs the DKG up to round 2 and returns the messages of round 2
+func dkgRound2(t *testing.T, curve *curves.Curve, threshold, n uint32) (map[uint32]*Dkg, map[uint32]*DkgRound2Bcast, map[uint32]map[uint32]*DkgRound2P2PSend) {
+	ids := testIds(n)
+	participants := make(map[uint32]*Dkg, n)
+	r1 := make(map[uint32]*DkgRound1Bcast, n)
+	for _, id := range ids {
+		d, err := NewDkg(curve, testSid, id, threshold, ids)
+		require.NoError(t, err)
+		participants[id] = d
+		r1[id], err = d.Round1()
+		require.NoError(t, err)
+	}
+	r2 := make(map[uint32]*DkgRound2Bcast, n)
+	p2p := make(map[uint32]map[uint32]*DkgRound2P2PSend, n)
+	for id, d := range participants {
+		bcast, out, err := d.Round2(r1)
+		require.NoError(t, err)
+		r2[id] = bcast
+		for j, msg := range out {
+			if p2p[j] == nil {
+				p2p[j] = make(map[uint32]*DkgRound2P2PSend, n)
+			}
+			p2p[j][id] = msg
+		}
+	}
+	return participants, r2, p2p
+}
+
+// runSeedOts runs rounds 4 to 6 of the DKG or the refresh and returns the outputs
+func runSeedOts(t *testing.T, participants map[uint32]*participant, r3 map[uint32]map[uint32]*Round3P2PSend) map[uint32]*Output {
+	r4 := make(map[uint32]map[uint32]*Round4P2PSend)
+	for id, p := range participants {
+		out, err := p.Round4(r3[id])
+		require.NoError(t, err)
+		for j, msg := range out {
+			if r4[j] == nil {
+				r4[j] = make(map[uint32]*Round4P2PSend)
+			}
+			r4[j][id] = msg
+		}
+	}
+	r5 := make(map[uint32]map[uint32]*Round5P2PSend)
+	for id, p := range participants {
+		out, err := p.Round5(r4[id])
+		require.NoError(t, err)
+		for j, msg := range out {
+			if r5[j] == nil {
+				r5[j] = make(map[uint32]*Round5P2PSend)
+			}
+			r5[j][id] = msg
+		}
+	}
+	r6 := make(map[uint32]map[uint32]*Round6P2PSend)
+	for id, p := range participants {
+		out, err := p.Round6(r5[id])
+		require.NoError(t, err)
+		for j, msg := range out {
+			if r6[j] == nil {
+				r6[j] = make(map[uint32]*Round6P2PSend)
+			}
+			r6[j][id] = msg
+		}
+	}
+	outputs := make(map[uint32]*Output, len(participants))
+	for id, p := range participants {
+		output, err := p.Output(r6[id])
+		require.NoError(t, err)
+		outputs[id] = output
+	}
+	return outputs
+}
+
+// runDkg returns the outputs of a DKG among n participants
+func runDkg(t *testing.T, curve *curves.Curve, threshold, n uint32) map[uint32]*Output {
+	dkgs, r2, p2p := dkgRound2(t, curve, threshold, n)
+	participants := make(map[uint32]*participant, n)
+	r3 := make(map[uint32]map[uint32]*Round3P2PSend, n)
+	for id, d := range dkgs {
+		out, err := d.Round3(r2, p2p[id])
+		require.NoError(t, err)
+		participants[id] = d.participant
+		for j, msg := range out {
+			if r3[j] == nil {
+				r3[j] = make(map[uint32]*Round3P2PSend, n)
+			}
+			r3[j][id] = msg
+		}
+	}
+	return runSeedOts(t, participants, r3)
+}
+
+// combine interpolates the secret key from the shares of the ids
+func combine(t *testing.T, curve *curves.Curve, outputs map[uint32]*Output, ids []uint32) curves.Scalar {
+	shamir, err := sharing.NewShamir(outputs[ids[0]].Threshold, uint32(len(outputs)), curve)
+	require.NoError(t, err)
+	shares := make([]*sharing.ShamirShare, len(ids))
+	for i, id := range ids {
+		shares[i] = &sharing.ShamirShare{Id: id, Value: outputs[id].SecretKeyShare.Bytes()}
+	}
+	secret, err := shamir.Combine(shares...)
+	require.NoError(t, err)
+	return secret
+}
+
+func TestDkg(t *testing.T) {
+	for _, curve := range []*curves.Curve{curves.K256(), curves.P256()} {
+		outputs := runDkg(t, curve, 2, 3)
+		publicKey := outputs[1].PublicKey
+		for id, output := range outputs {
+			require.Equal(t, id, output.Id)
+			require.Equal(t, uint32(2), output.Threshold)
+			require.True(t, publicKey.Equal(output.PublicKey))
+			require.Len(t, output.SeedOtSenders, 2)
+			require.Len(t, output.SeedOtReceivers, 2)
+			for j, publicShare := range output.PublicShares {
+				require.True(t, publicShare.Equal(curve.ScalarBaseMult(outputs[j].SecretKeyShare)))
+			}
+			// The seed OTs of every pair are correlated: the receiver gets one of the two pads of the sender
+			for j, receiverOutput := range output.SeedOtReceivers {
+				senderOutput := outputs[j].SeedOtSenders[id]
+				for k, bit := range receiverOutput.RandomChoiceBits {
+					require.Equal(t, senderOutput.OneTimePadEncryptionKeys[k][bit], receiverOutput.OneTimePadDecryptionKey[k])
+				}
+			}
+		}
+		for _, ids := range [][]uint32{{1, 2}, {1, 3}, {2, 3}} {
+			require.True(t, publicKey.Equal(curve.ScalarBaseMult(combine(t, curve, outputs, ids))))
+		}
+	}
+}
+
+func TestDkgInvalidArguments(t *testing.T) {
+	curve := curves.K256()
+	ids := testIds(3)
+	_, err := NewDkg(nil, testSid, 1, 2, ids)
+	require.Error(t, err)
+	_, err = NewDkg(curves.ED25519(), testSid, 1, 2, ids)
+	require.Error(t, err)
+	_, err = NewDkg(curve, nil, 1, 2, ids)
+	require.Error(t, err)
+	_, err = NewDkg(curve, testSid, 4, 2, ids)
+	require.Error(t, err)
+	_, err = NewDkg(curve, testSid, 1, 1, ids)
+	require.Error(t, err)
+	_, err = NewDkg(curve, testSid, 1, 4, ids)
+	require.Error(t, err)
+	_, err = NewDkg(curve, testSid, 1, 2, []uint32{1, 2, 2})
+	require.Error(t, err)
+	_, err = NewDkg(curve, testSid, 1, 2, []uint32{0, 1, 2})
+	require.Error(t, err)
+
+	d, err := NewDkg(curve, testSid, 1, 2, ids)
+	require.NoError(t, err)
+	_, _, err = d.Round2(nil)
+	require.Equal(t, internal.ErrInvalidRound, err)
+	_, err = d.Round3(nil, nil)
+	require.Equal(t, internal.ErrInvalidRound, err)
+	_, err = d.Round4(nil)
+	require.Equal(t, internal.ErrInvalidRound, err)
+	_, err = d.Output(nil)
+	require.Equal(t, internal.ErrInvalidRound, err)
+}
+
+func TestDkgInvalidDecommitment(t *testing.T) {
+	curve := curves.K256()
+	participants, r2, p2p := dkgRound2(t, curve, 2, 3)
+
+	// Participant 2 changes its commitments after round 1
+	r2[2] = &DkgRound2Bcast{
+		Commitments: []curves.Point{r2[2].Commitments[0], r2[2].Commitments[1].Double()},
+		Salt:        r2[2].Salt,
+		Proof:       r2[2].Proof,
+	}
+	_, err := participants[1].Round3(r2, p2p[1])
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "participant 2")
+}
+
+func TestDkgInvalidProof(t *testing.T) {
+	curve := curves.K256()
+	participants, r2, p2p := dkgRound2(t, curve, 2, 3)
+
+	// Participant 3 uses the proof of participant 2
+	r2[3] = &DkgRound2Bcast{Commitments: r2[3].Commitments, Salt: r2[3].Salt, Proof: r2[2].Proof}
+	_, err := participants[1].Round3(r2, p2p[1])
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "participant 3")
+}
+
+func TestDkgInvalidShare(t *testing.T) {
+	curve := curves.K256()
+	participants, r2, p2p := dkgRound2(t, curve, 2, 3)
+
+	// Participant 3 sends a wrong share to participant 1 only
+	p2p[1][3] = &DkgRound2P2PSend{Share: p2p[1][3].Share.Add(curve.Scalar.One()), SeedOt: p2p[1][3].SeedOt}
+	_, err := participants[1].Round3(r2, p2p[1])
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "participant 3")
+	_, err = participants[2].Round3(r2, p2p[2])
+	require.NoError(t, err)
+}
+
+func TestDkgInvalidSeedOt(t *testing.T) {
+	curve := curves.K256()
+	participants, r2, p2p := dkgRound2(t, curve, 2, 3)
+
+	// Participant 2 starts the seed OT with participant 1 with the key it uses with participant 3
+	p2p[1][2] = &DkgRound2P2PSend{Share: p2p[1][2].Share, SeedOt: p2p[3][2].SeedOt}
+	_, err := participants[1].Round3(r2, p2p[1])
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "participant 2")
+}
@@ -0,0 +1,370 @@
+//
+// Copyright Coinbase, Inc. All Rights Reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+// Package threshold implements t-of-n threshold ECDSA for any number of participants following
+// [DKLs19](https://eprint.iacr.org/2019/523.pdf) and the three round signing of [DKLs23](https://eprint.iacr.org/2023/765.pdf).
+// The key is Shamir shared among the participants. Every pair of participants runs the verified simplest OT in
+// both directions during the DKG and the refresh, and the signers extend these seed OTs with KOS in the
+// OT-based multiplication of the two-party DKLs sign package. No Paillier encryption is involved.
+package threshold
+
+import (
+	"encoding/binary"
+	"fmt"
+	"sort"
+
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/sha3"
+
+	"github.com/nerifnetwork/kryptology/internal"
+	"github.com/nerifnetwork/kryptology/pkg/core/curves"
+	"github.com/nerifnetwork/kryptology/pkg/ot/base/simplest"
+	"github.com/nerifnetwork/kryptology/pkg/ot/extension/kos"
+	"github.com/nerifnetwork/kryptology/pkg/sharing"
+	"github.com/nerifnetwork/kryptology/pkg/zkp/schnorr"
+)
+
+// Output is the result of the DKG and of a refresh for a participant. It contains both the public and the secret
+// values that are needed for signing.
+type Output struct {
+	// Id is the identifier of the participant.
+	Id uint32
+
+	// Threshold is the number of participants that is needed to sign.
+	Threshold uint32
+
+	// PublicKey is the joint public key of all participants.
+	// This value is public.
+	PublicKey curves.Point
+
+	// SecretKeyShare is the Shamir share of the secret key of the participant.
+	// This output must be kept secret. If the shares of too many participants are lost, the users will lose access
+	// and cannot create signatures.
+	SecretKeyShare curves.Scalar
+
+	// PublicShares are the public shares of all participants, including this participant, keyed by id.
+	PublicShares map[uint32]curves.Point
+
+	// SeedOtSenders are the outputs of the seed OTs in which the participant was the sender and SeedOtReceivers
+	// those in which it was the receiver, keyed by the id of the other participant.
+	// These outputs must be kept secret. Although, if they are lost the participants can run a refresh to replace them.
+	SeedOtSenders   map[uint32]*simplest.SenderOutput
+	SeedOtReceivers map[uint32]*simplest.ReceiverOutput
+}
+
+// Round3P2PSend contains the masked choices of the seed OT in which the recipient is the sender.
+type Round3P2PSend struct {
+	Choices []simplest.ReceiversMaskedChoices
+}
+
+// Round4P2PSend contains the challenges of the seed OT in which the recipient is the receiver.
+type Round4P2PSend struct {
+	Challenges []simplest.OtChallenge
+}
+
+// Round5P2PSend contains the responses to the challenges of the seed OT in which the recipient is the sender.
+type Round5P2PSend struct {
+	Responses []simplest.OtChallengeResponse
+}
+
+// Round6P2PSend contains the openings of the challenges of the seed OT in which the recipient is the receiver.
+type Round6P2PSend struct {
+	Openings []simplest.ChallengeOpening
+}
+
+// participant holds the state that the DKG and the refresh have in common: the new share of the participant
+// and the seed OTs with every other participant, which are run in rounds 2 to 6 of both protocols.
+type participant struct {
+	curve     *curves.Curve
+	id        uint32
+	ids       []uint32
+	threshold uint32
+	round     uint
+	sid       []byte
+
+	// seed is the random value of this participant used to derive the unique session id
+	seed [simplest.DigestSize]byte
+
+	// sessionId is derived from sid and the seeds of all participants in round 2
+	sessionId []byte
+
+	otSenders   map[uint32]*simplest.Sender
+	otReceivers map[uint32]*simplest.Receiver
+
+	secretKeyShare curves.Scalar
+	publicKey      curves.Point
+	publicShares   map[uint32]curves.Point
+}
+
+func newParticipant(curve *curves.Curve, sid []byte, id, threshold uint32, ids []uint32) (*participant, error) {
+	if curve == nil || len(sid) == 0 {
+		return nil, internal.ErrNilArguments
+	}
+	if _, err := curve.ToEllipticCurve(); err != nil {
+		return nil, errors.Wrap(err, "dkls needs an ecdsa curve")
+	}
+	ids, err := sortIds(ids)
+	if err != nil {
+		return nil, err
+	}
+	if !contains(ids, id) {
+		return nil, fmt.Errorf("participant %d is not in the list of participants", id)
+	}
+	if threshold < 2 || threshold > uint32(len(ids)) {
+		return nil, fmt.Errorf("invalid threshold %d for %d participants", threshold, len(ids))
+	}
+	return &participant{
+		curve:     curve,
+		id:        id,
+		ids:       ids,
+		threshold: threshold,
+		round:     1,
+		sid:       sid,
+	}, nil
+}
+
+// others returns the ids of all other participants in ascending order
+func (p *participant) others() []uint32 {
+	return without(p.ids, p.id)
+}
+
+// startSeedOts derives the unique session id from the seeds of all participants and starts the seed OTs
+// with every other participant in the role of the sender.
+func (p *participant) startSeedOts(seeds map[uint32][simplest.DigestSize]byte) (map[uint32]*schnorr.Proof, error) {
+	p.sessionId = sessionId(p.sid, p.ids, seeds)
+	p.otSenders = make(map[uint32]*simplest.Sender, len(p.ids)-1)
+	p.otReceivers = make(map[uint32]*simplest.Receiver, len(p.ids)-1)
+	proofs := make(map[uint32]*schnorr.Proof, len(p.ids)-1)
+	for _, j := range p.others() {
+		var err error
+		if p.otSenders[j], err = simplest.NewSender(p.curve, kos.Kappa, pairSessionId(p.sessionId, "seed ot", p.id, j)); err != nil {
+			return nil, errors.Wrapf(err, "creating the seed ot sender for participant %d", j)
+		}
+		if p.otReceivers[j], err = simplest.NewReceiver(p.curve, kos.Kappa, pairSessionId(p.sessionId, "seed ot", j, p.id)); err != nil {
+			return nil, errors.Wrapf(err, "creating the seed ot receiver for participant %d", j)
+		}
+		if proofs[j], err = p.otSenders[j].Round1ComputeAndZkpToPublicKey(); err != nil {
+			return nil, errors.Wrapf(err, "starting the seed ot with participant %d", j)
+		}
+	}
+	return proofs, nil
+}
+
+// answerSeedOts verifies the public keys of the seed OT senders and returns the masked choices
+func (p *participant) answerSeedOts(proofs map[uint32]*schnorr.Proof) (map[uint32]*Round3P2PSend, error) {
+	out := make(map[uint32]*Round3P2PSend, len(p.ids)-1)
+	for _, j := range p.others() {
+		proof := proofs[j]
+		if proof == nil || proof.C == nil || proof.S == nil || proof.Statement == nil {
+			return nil, fmt.Errorf("participant %d sent no seed ot public key", j)
+		}
+		choices, err := p.otReceivers[j].Round2VerifySchnorrAndPadTransfer(proof)
+		if err != nil {
+			return nil, errors.Wrapf(err, "seed ot with participant %d", j)
+		}
+		out[j] = &Round3P2PSend{Choices: choices}
+	}
+	return out, nil
+}
+
+// Round4 computes the challenges of the seed OTs in which this participant is the sender.
+func (p *participant) Round4(in map[uint32]*Round3P2PSend) (map[uint32]*Round4P2PSend, error) {
+	if p == nil || p.curve == nil {
+		return nil, internal.ErrNilArguments
+	}
+	if p.round != 4 {
+		return nil, internal.ErrInvalidRound
+	}
+	out := make(map[uint32]*Round4P2PSend, len(p.ids)-1)
+	for _, j := range p.others() {
+		if in[j] == nil || len(in[j].Choices) != kos.Kappa {
+			return nil, fmt.Errorf("participant %d sent invalid seed ot choices", j)
+		}
+		challenges, err := p.otSenders[j].Round3PadTransfer(in[j].Choices)
+		if err != nil {
+			return nil, errors.Wrapf(err, "seed ot with participant %d", j)
+		}
+		out[j] = &Round4P2PSend{Challenges: challenges}
+	}
+	p.round = 5
+	return out, nil
+}
+
+// Round5 responds to the challenges of the seed OTs in which this participant is the receiver.
+func (p *participant) Round5(in map[uint32]*Round4P2PSend) (map[uint32]*Round5P2PSend, error) {
+	if p == nil || p.curve == nil {
+		return nil, internal.ErrNilArguments
+	}
+	if p.round != 5 {
+		return nil, internal.ErrInvalidRound
+	}
+	out := make(map[uint32]*Round5P2PSend, len(p.ids)-1)
+	for _, j := range p.others() {
+		if in[j] == nil || len(in[j].Challenges) != kos.Kappa {
+			return nil, fmt.Errorf("participant %d sent invalid seed ot challenges", j)
+		}
+		responses, err := p.otReceivers[j].Round4RespondToChallenge(in[j].Challenges)
+		if err != nil {
+			return nil, errors.Wrapf(err, "seed ot with participant %d", j)
+		}
+		out[j] = &Round5P2PSend{Responses: responses}
+	}
+	p.round = 6
+	return out, nil
+}
+
+// Round6 verifies the responses of the seed OTs in which this participant is the sender and opens the challenges.
+func (p *participant) Round6(in map[uint32]*Round5P2PSend) (map[uint32]*Round6P2PSend, error) {
+	if p == nil || p.curve == nil {
+		return nil, internal.ErrNilArguments
+	}
+	if p.round != 6 {
+		return nil, internal.ErrInvalidRound
+	}
+	out := make(map[uint32]*Round6P2PSend, len(p.ids)-1)
+	for _, j := range p.others() {
+		if in[j] == nil || len(in[j].Responses) != kos.Kappa {
+			return nil, fmt.Errorf("participant %d sent invalid seed ot responses", j)
+		}
+		openings, err := p.otSenders[j].Round5Verify(in[j].Responses)
+		if err != nil {
+			return nil, errors.Wrapf(err, "seed ot with participant %d", j)
+		}
+		out[j] = &Round6P2PSend{Openings: openings}
+	}
+	p.round = 7
+	return out, nil
+}
+
+// Output verifies the openings of the seed OTs in which this participant is the receiver
+// and returns the result of the protocol.
+func (p *participant) Output(in map[uint32]*Round6P2PSend) (*Output, error) {
+	if p == nil || p.curve == nil {
+		return nil, internal.ErrNilArguments
+	}
+	if p.round != 7 {
+		return nil, internal.ErrInvalidRound
+	}
+	output := &Output{
+		Id:              p.id,
+		Threshold:       p.threshold,
+		PublicKey:       p.publicKey,
+		SecretKeyShare:  p.secretKeyShare,
+		PublicShares:    p.publicShares,
+		SeedOtSenders:   make(map[uint32]*simplest.SenderOutput, len(p.ids)-1),
+		SeedOtReceivers: make(map[uint32]*simplest.ReceiverOutput, len(p.ids)-1),
+	}
+	for _, j := range p.others() {
+		if in[j] == nil || len(in[j].Openings) != kos.Kappa {
+			return nil, fmt.Errorf("participant %d sent invalid seed ot openings", j)
+		}
+		if err := p.otReceivers[j].Round6Verify(in[j].Openings); err != nil {
+			return nil, errors.Wrapf(err, "seed ot with participant %d", j)
+		}
+		output.SeedOtSenders[j] = p.otSenders[j].Output
+		output.SeedOtReceivers[j] = p.otReceivers[j].Output
+	}
+	p.round = 8
+	return output, nil
+}
+
+// sessionId hashes the session id of the caller with the seeds of all participants, it is unique as long
+// as one participant is honest
+func sessionId(sid []byte, ids []uint32, seeds map[uint32][simplest.DigestSize]byte) []byte {
+	hash := sha3.New256()
+	_, _ = hash.Write([]byte("Coinbase_DKLs_Threshold"))
+	_, _ = hash.Write(sid)
+	for _, id := range ids {
+		seed := seeds[id]
+		_ = binary.Write(hash, binary.BigEndian, id)
+		_, _ = hash.Write(seed[:])
+	}
+	return hash.Sum(nil)
+}
+
+// pairSessionId derives the unique session id of a sub-protocol between two participants
+func pairSessionId(sessionId []byte, label string, from, to uint32) [simplest.DigestSize]byte {
+	hash := sha3.New256()
+	_, _ = hash.Write(sessionId)
+	_, _ = hash.Write([]byte(label))
+	_ = binary.Write(hash, binary.BigEndian, from)
+	_ = binary.Write(hash, binary.BigEndian, to)
+	result := [simplest.DigestSize]byte{}
+	copy(result[:], hash.Sum(nil))
+	return result
+}
+
+// feldmanCommitments returns the Feldman commitments g^{a_k} to the coefficients of the polynomial
+func feldmanCommitments(curve *curves.Curve, polynomial *sharing.Polynomial) []curves.Point {
+	commitments := make([]curves.Point, len(polynomial.Coefficients))
+	for i, coefficient := range polynomial.Coefficients {
+		commitments[i] = curve.ScalarBaseMult(coefficient)
+	}
+	return commitments
+}
+
+// evaluate returns the Feldman commitment g^{f(x)} of the polynomial f with the commitments
+func evaluate(curve *curves.Curve, commitments []curves.Point, x uint32) curves.Point {
+	scalar := curve.Scalar.New(int(x))
+	result := commitments[len(commitments)-1]
+	for i := len(commitments) - 2; i >= 0; i-- {
+		result = result.Mul(scalar).Add(commitments[i])
+	}
+	return result
+}
+
+// lagrange returns the Lagrange coefficient of id at zero for the ids
+func lagrange(curve *curves.Curve, id uint32, ids []uint32) (curves.Scalar, error) {
+	xi := curve.Scalar.New(int(id))
+	num := curve.Scalar.One()
+	den := curve.Scalar.One()
+	for _, j := range ids {
+		if j == id {
+			continue
+		}
+		xj := curve.Scalar.New(int(j))
+		num = num.Mul(xj)
+		den = den.Mul(xj.Sub(xi))
+	}
+	if den.IsZero() {
+		return nil, fmt.Errorf("divide by zero")
+	}
+	return num.Div(den), nil
+}
+
+// sortIds returns a sorted copy of the ids and checks that they are distinct and not zero
+func sortIds(ids []uint32) ([]uint32, error) {
+	sorted := append([]uint32{}, ids...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	for i, id := range sorted {
+		if id == 0 {
+			return nil, fmt.Errorf("invalid participant id %d", id)
+		}
+		if i > 0 && sorted[i-1] == id {
+			return nil, fmt.Errorf("duplicate participant id %d", id)
+		}
+	}
+	return sorted, nil
+}
+
+func contains(ids []uint32, id uint32) bool {
+	for _, i := range ids {
+		if i == id {
+			return true
+		}
+	}
+	return false
+}
+
+func without(ids []uint32, id uint32) []uint32 {
+	result := make([]uint32, 0, len(ids))
+	for _, i := range ids {
+		if i != id {
+			result = append(result, i)
+		}
+	}
+	return result
+}
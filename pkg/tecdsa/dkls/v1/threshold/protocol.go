@@ -0,0 +1,589 @@
+//
+// Copyright Coinbase, Inc. All Rights Reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package threshold
+
+import (
+	"encoding/gob"
+	"fmt"
+	"hash"
+	"sort"
+
+	"github.com/nerifnetwork/kryptology/pkg/core/curves"
+	"github.com/nerifnetwork/kryptology/pkg/core/protocol"
+)
+
+// SupportedVersions are the message versions understood by the iterators of this package
+var SupportedVersions = []uint{protocol.Version1}
+
+const payloadKey = "result"
+
+// DkgIterator runs the DKG through the protocol.Iterator interface. The inputs of each round
+// are the outputs of all participants combined with protocol.Route.
+type DkgIterator struct {
+	protocol.Stepper
+	*Dkg
+	version uint
+	output  *Output
+}
+
+// RefreshIterator runs the key refresh through the protocol.Iterator interface. The inputs of each round
+// are the outputs of all participants combined with protocol.Route.
+type RefreshIterator struct {
+	protocol.Stepper
+	*Refresh
+	version uint
+	output  *Output
+}
+
+// SignIterator runs the signing through the protocol.Iterator interface. The inputs of each round
+// are the outputs of all signers combined with protocol.Route.
+type SignIterator struct {
+	protocol.Stepper
+	*Signer
+	version uint
+}
+
+var (
+	_ protocol.Iterator = &DkgIterator{}
+	_ protocol.Iterator = &RefreshIterator{}
+	_ protocol.Iterator = &SignIterator{}
+)
+
+// NewDkgIterator creates a DKG participant that satisfies the protocol iterator interface.
+// The version should be negotiated with protocol.NegotiateVersion and SupportedVersions.
+func NewDkgIterator(curve *curves.Curve, sid []byte, id, threshold uint32, ids []uint32, version uint) (*DkgIterator, error) {
+	if err := protocol.CheckVersion(version, SupportedVersions); err != nil {
+		return nil, err
+	}
+	dkg, err := NewDkg(curve, sid, id, threshold, ids)
+	if err != nil {
+		return nil, err
+	}
+	d := &DkgIterator{Dkg: dkg, version: version}
+	name := protocol.DklsThresholdDkg
+	d.Steps = []func(*protocol.Message) (*protocol.Message, error){
+		func(*protocol.Message) (*protocol.Message, error) {
+			bcast, err := d.Round1()
+			if err != nil {
+				return nil, err
+			}
+			return newMessage(name, version, "1", bcast)
+		},
+		func(input *protocol.Message) (*protocol.Message, error) {
+			in := make(map[uint32]*DkgRound1Bcast)
+			err := decodeInput(input, name, version, "1", func(id uint32, data []byte) error {
+				in[id] = new(DkgRound1Bcast)
+				return protocol.DecodePayload(data, in[id])
+			}, nil)
+			if err != nil {
+				return nil, err
+			}
+			bcast, p2p, err := d.Round2(in)
+			if err != nil {
+				return nil, err
+			}
+			output, err := newMessage(name, version, "2", bcast)
+			if err != nil {
+				return nil, err
+			}
+			for recipient, msg := range p2p {
+				if err = addDirect(output, recipient, msg); err != nil {
+					return nil, err
+				}
+			}
+			return output, nil
+		},
+		func(input *protocol.Message) (*protocol.Message, error) {
+			bcast := make(map[uint32]*DkgRound2Bcast)
+			p2p := make(map[uint32]*DkgRound2P2PSend)
+			err := decodeInput(input, name, version, "2",
+				func(id uint32, data []byte) error {
+					bcast[id] = new(DkgRound2Bcast)
+					return protocol.DecodePayload(data, bcast[id])
+				},
+				func(id uint32, data []byte) error {
+					p2p[id] = new(DkgRound2P2PSend)
+					return protocol.DecodePayload(data, p2p[id])
+				})
+			if err != nil {
+				return nil, err
+			}
+			out, err := d.Round3(bcast, p2p)
+			if err != nil {
+				return nil, err
+			}
+			output := newDirectMessage(name, version, "3")
+			for recipient, msg := range out {
+				if err = addDirect(output, recipient, msg); err != nil {
+					return nil, err
+				}
+			}
+			return output, nil
+		},
+	}
+	d.Steps = append(d.Steps, seedOtSteps(d.participant, name, version, func(output *Output) { d.output = output })...)
+	return d, nil
+}
+
+// Result returns the encoded Output that can be used to create a SignIterator or a RefreshIterator.
+// Returns nil if the DKG has not completed.
+func (d *DkgIterator) Result(version uint) (*protocol.Message, error) {
+	if !d.Complete() {
+		return nil, nil
+	}
+	if d.Dkg == nil || d.output == nil {
+		return nil, protocol.ErrNotInitialized
+	}
+	return EncodeOutput(d.output, version)
+}
+
+// NewRefreshIterator creates a key refresh participant that satisfies the protocol iterator interface
+// from the result of a DkgIterator or a RefreshIterator.
+func NewRefreshIterator(dkgResult *protocol.Message, sid []byte, version uint) (*RefreshIterator, error) {
+	if err := protocol.CheckVersion(version, SupportedVersions); err != nil {
+		return nil, err
+	}
+	output, err := DecodeOutput(dkgResult)
+	if err != nil {
+		return nil, err
+	}
+	refresh, err := NewRefresh(output, sid)
+	if err != nil {
+		return nil, err
+	}
+	r := &RefreshIterator{Refresh: refresh, version: version}
+	name := protocol.DklsThresholdRefresh
+	r.Steps = []func(*protocol.Message) (*protocol.Message, error){
+		func(*protocol.Message) (*protocol.Message, error) {
+			bcast, err := r.Round1()
+			if err != nil {
+				return nil, err
+			}
+			return newMessage(name, version, "1", bcast)
+		},
+		func(input *protocol.Message) (*protocol.Message, error) {
+			in := make(map[uint32]*RefreshRound1Bcast)
+			err := decodeInput(input, name, version, "1", func(id uint32, data []byte) error {
+				in[id] = new(RefreshRound1Bcast)
+				return protocol.DecodePayload(data, in[id])
+			}, nil)
+			if err != nil {
+				return nil, err
+			}
+			bcast, p2p, err := r.Round2(in)
+			if err != nil {
+				return nil, err
+			}
+			output, err := newMessage(name, version, "2", bcast)
+			if err != nil {
+				return nil, err
+			}
+			for recipient, msg := range p2p {
+				if err = addDirect(output, recipient, msg); err != nil {
+					return nil, err
+				}
+			}
+			return output, nil
+		},
+		func(input *protocol.Message) (*protocol.Message, error) {
+			bcast := make(map[uint32]*RefreshRound2Bcast)
+			p2p := make(map[uint32]*RefreshRound2P2PSend)
+			err := decodeInput(input, name, version, "2",
+				func(id uint32, data []byte) error {
+					bcast[id] = new(RefreshRound2Bcast)
+					return protocol.DecodePayload(data, bcast[id])
+				},
+				func(id uint32, data []byte) error {
+					p2p[id] = new(RefreshRound2P2PSend)
+					return protocol.DecodePayload(data, p2p[id])
+				})
+			if err != nil {
+				return nil, err
+			}
+			out, err := r.Round3(bcast, p2p)
+			if err != nil {
+				return nil, err
+			}
+			output := newDirectMessage(name, version, "3")
+			for recipient, msg := range out {
+				if err = addDirect(output, recipient, msg); err != nil {
+					return nil, err
+				}
+			}
+			return output, nil
+		},
+	}
+	r.Steps = append(r.Steps, seedOtSteps(r.participant, name, version, func(output *Output) { r.output = output })...)
+	return r, nil
+}
+
+// Result returns the encoded Output that can be used to create a SignIterator or another RefreshIterator.
+// Returns nil if the refresh has not completed.
+func (r *RefreshIterator) Result(version uint) (*protocol.Message, error) {
+	if !r.Complete() {
+		return nil, nil
+	}
+	if r.Refresh == nil || r.output == nil {
+		return nil, protocol.ErrNotInitialized
+	}
+	return EncodeOutput(r.output, version)
+}
+
+// NewSignIterator creates a signer that satisfies the protocol iterator interface from the result of a
+// DkgIterator or a RefreshIterator. The signers are the ids of all signers, all of them compute the signature.
+func NewSignIterator(hash hash.Hash, message []byte, dkgResult *protocol.Message, signers []uint32, version uint) (*SignIterator, error) {
+	if err := protocol.CheckVersion(version, SupportedVersions); err != nil {
+		return nil, err
+	}
+	output, err := DecodeOutput(dkgResult)
+	if err != nil {
+		return nil, err
+	}
+	signer, err := NewSigner(output, hash, message, signers)
+	if err != nil {
+		return nil, err
+	}
+	s := &SignIterator{Signer: signer, version: version}
+	name := protocol.DklsThresholdSign
+	s.Steps = []func(*protocol.Message) (*protocol.Message, error){
+		func(*protocol.Message) (*protocol.Message, error) {
+			bcast, err := s.Round1()
+			if err != nil {
+				return nil, err
+			}
+			return newMessage(name, version, "1", bcast)
+		},
+		func(input *protocol.Message) (*protocol.Message, error) {
+			in := make(map[uint32]*SignRound1Bcast)
+			err := decodeInput(input, name, version, "1", func(id uint32, data []byte) error {
+				in[id] = new(SignRound1Bcast)
+				return protocol.DecodePayload(data, in[id])
+			}, nil)
+			if err != nil {
+				return nil, err
+			}
+			out, err := s.Round2(in)
+			if err != nil {
+				return nil, err
+			}
+			output := newDirectMessage(name, version, "2")
+			for recipient, msg := range out {
+				if err = addDirect(output, recipient, msg); err != nil {
+					return nil, err
+				}
+			}
+			return output, nil
+		},
+		func(input *protocol.Message) (*protocol.Message, error) {
+			in := make(map[uint32]*SignRound2P2PSend)
+			err := decodeInput(input, name, version, "2", nil, func(id uint32, data []byte) error {
+				in[id] = new(SignRound2P2PSend)
+				return protocol.DecodePayload(data, in[id])
+			})
+			if err != nil {
+				return nil, err
+			}
+			bcast, p2p, err := s.Round3(in)
+			if err != nil {
+				return nil, err
+			}
+			output, err := newMessage(name, version, "3", bcast)
+			if err != nil {
+				return nil, err
+			}
+			for recipient, msg := range p2p {
+				if err = addDirect(output, recipient, msg); err != nil {
+					return nil, err
+				}
+			}
+			return output, nil
+		},
+		func(input *protocol.Message) (*protocol.Message, error) {
+			bcast := make(map[uint32]*SignRound3Bcast)
+			p2p := make(map[uint32]*SignRound3P2PSend)
+			err := decodeInput(input, name, version, "3",
+				func(id uint32, data []byte) error {
+					bcast[id] = new(SignRound3Bcast)
+					return protocol.DecodePayload(data, bcast[id])
+				},
+				func(id uint32, data []byte) error {
+					p2p[id] = new(SignRound3P2PSend)
+					return protocol.DecodePayload(data, p2p[id])
+				})
+			if err != nil {
+				return nil, err
+			}
+			out, err := s.Round4(bcast, p2p)
+			if err != nil {
+				return nil, err
+			}
+			return newMessage(name, version, "4", out)
+		},
+		func(input *protocol.Message) (*protocol.Message, error) {
+			in := make(map[uint32]*SignRound4Bcast)
+			err := decodeInput(input, name, version, "4", func(id uint32, data []byte) error {
+				in[id] = new(SignRound4Bcast)
+				return protocol.DecodePayload(data, in[id])
+			}, nil)
+			if err != nil {
+				return nil, err
+			}
+			_, err = s.Output(in)
+			return nil, err
+		},
+	}
+	return s, nil
+}
+
+// Result returns the signature as a *curves.EcdsaSignature once the signing has completed.
+// Returns nil if the signing has not completed.
+func (s *SignIterator) Result(version uint) (*protocol.Message, error) {
+	if !s.Complete() {
+		return nil, nil
+	}
+	if s.Signer == nil || s.Signature == nil {
+		return nil, protocol.ErrNotInitialized
+	}
+	if err := protocol.CheckVersion(version, SupportedVersions); err != nil {
+		return nil, err
+	}
+	payload, err := protocol.EncodePayload(s.Signature)
+	if err != nil {
+		return nil, err
+	}
+	return &protocol.Message{
+		Protocol: protocol.DklsThresholdSign,
+		Version:  version,
+		Payloads: map[string][]byte{payloadKey: payload},
+		Metadata: map[string]string{protocol.RoundKey: payloadKey},
+	}, nil
+}
+
+// EncodeOutput encodes the output of the DKG or a refresh as the result of a DkgIterator.
+func EncodeOutput(output *Output, version uint) (*protocol.Message, error) {
+	if err := protocol.CheckVersion(version, SupportedVersions); err != nil {
+		return nil, err
+	}
+	payload, err := protocol.EncodePayload(output)
+	if err != nil {
+		return nil, err
+	}
+	return &protocol.Message{
+		Protocol: protocol.DklsThresholdDkg,
+		Version:  version,
+		Payloads: map[string][]byte{payloadKey: payload},
+		Metadata: map[string]string{protocol.RoundKey: payloadKey},
+	}, nil
+}
+
+// DecodeOutput decodes the result of a DkgIterator or a RefreshIterator.
+func DecodeOutput(m *protocol.Message) (*Output, error) {
+	if m == nil {
+		return nil, fmt.Errorf("message cannot be nil")
+	}
+	if m.Protocol != protocol.DklsThresholdDkg {
+		return nil, fmt.Errorf("expected a %s message", protocol.DklsThresholdDkg)
+	}
+	if err := protocol.CheckVersion(m.Version, SupportedVersions); err != nil {
+		return nil, err
+	}
+	output := new(Output)
+	if err := protocol.DecodePayload(m.Payloads[payloadKey], output); err != nil {
+		return nil, err
+	}
+	if output.PublicKey == nil || output.SecretKeyShare == nil || len(output.PublicShares) == 0 {
+		return nil, fmt.Errorf("incomplete dkg result")
+	}
+	return output, nil
+}
+
+// DecodeSignature decodes the result of a SignIterator.
+func DecodeSignature(m *protocol.Message) (*curves.EcdsaSignature, error) {
+	if m == nil {
+		return nil, fmt.Errorf("message cannot be nil")
+	}
+	if m.Protocol != protocol.DklsThresholdSign {
+		return nil, fmt.Errorf("expected a %s message", protocol.DklsThresholdSign)
+	}
+	if err := protocol.CheckVersion(m.Version, SupportedVersions); err != nil {
+		return nil, err
+	}
+	signature := new(curves.EcdsaSignature)
+	if err := protocol.DecodePayload(m.Payloads[payloadKey], signature); err != nil {
+		return nil, err
+	}
+	return signature, nil
+}
+
+// seedOtSteps are the steps of the DKG and the refresh that finish the seed OTs, the last one outputs the result
+func seedOtSteps(p *participant, name string, version uint, done func(*Output)) []func(*protocol.Message) (*protocol.Message, error) {
+	return []func(*protocol.Message) (*protocol.Message, error){
+		func(input *protocol.Message) (*protocol.Message, error) {
+			in := make(map[uint32]*Round3P2PSend)
+			err := decodeInput(input, name, version, "3", nil, func(id uint32, data []byte) error {
+				in[id] = new(Round3P2PSend)
+				return protocol.DecodePayload(data, in[id])
+			})
+			if err != nil {
+				return nil, err
+			}
+			out, err := p.Round4(in)
+			if err != nil {
+				return nil, err
+			}
+			output := newDirectMessage(name, version, "4")
+			for recipient, msg := range out {
+				if err = addDirect(output, recipient, msg); err != nil {
+					return nil, err
+				}
+			}
+			return output, nil
+		},
+		func(input *protocol.Message) (*protocol.Message, error) {
+			in := make(map[uint32]*Round4P2PSend)
+			err := decodeInput(input, name, version, "4", nil, func(id uint32, data []byte) error {
+				in[id] = new(Round4P2PSend)
+				return protocol.DecodePayload(data, in[id])
+			})
+			if err != nil {
+				return nil, err
+			}
+			out, err := p.Round5(in)
+			if err != nil {
+				return nil, err
+			}
+			output := newDirectMessage(name, version, "5")
+			for recipient, msg := range out {
+				if err = addDirect(output, recipient, msg); err != nil {
+					return nil, err
+				}
+			}
+			return output, nil
+		},
+		func(input *protocol.Message) (*protocol.Message, error) {
+			in := make(map[uint32]*Round5P2PSend)
+			err := decodeInput(input, name, version, "5", nil, func(id uint32, data []byte) error {
+				in[id] = new(Round5P2PSend)
+				return protocol.DecodePayload(data, in[id])
+			})
+			if err != nil {
+				return nil, err
+			}
+			out, err := p.Round6(in)
+			if err != nil {
+				return nil, err
+			}
+			output := newDirectMessage(name, version, "6")
+			for recipient, msg := range out {
+				if err = addDirect(output, recipient, msg); err != nil {
+					return nil, err
+				}
+			}
+			return output, nil
+		},
+		func(input *protocol.Message) (*protocol.Message, error) {
+			in := make(map[uint32]*Round6P2PSend)
+			err := decodeInput(input, name, version, "6", nil, func(id uint32, data []byte) error {
+				in[id] = new(Round6P2PSend)
+				return protocol.DecodePayload(data, in[id])
+			})
+			if err != nil {
+				return nil, err
+			}
+			output, err := p.Output(in)
+			if err != nil {
+				return nil, err
+			}
+			done(output)
+			return nil, nil
+		},
+	}
+}
+
+func init() {
+	gob.Register(&curves.ScalarK256{})
+	gob.Register(&curves.PointK256{})
+	gob.Register(&curves.ScalarP256{})
+	gob.Register(&curves.PointP256{})
+}
+
+// newMessage creates the output of a round with the broadcast of the participant
+func newMessage(name string, version uint, round string, broadcast interface{}) (*protocol.Message, error) {
+	payload, err := protocol.EncodePayload(broadcast)
+	if err != nil {
+		return nil, err
+	}
+	return &protocol.Message{
+		Protocol: name,
+		Version:  version,
+		Payloads: map[string][]byte{protocol.BroadcastKey: payload},
+		Metadata: map[string]string{protocol.RoundKey: round},
+	}, nil
+}
+
+// newDirectMessage creates the output of a round without a broadcast, addDirect adds its messages
+func newDirectMessage(name string, version uint, round string) *protocol.Message {
+	return &protocol.Message{
+		Protocol: name,
+		Version:  version,
+		Payloads: make(map[string][]byte),
+		Metadata: map[string]string{protocol.RoundKey: round},
+	}
+}
+
+// addDirect adds the message for a single recipient to the output of a round
+func addDirect(output *protocol.Message, recipient uint32, msg interface{}) error {
+	payload, err := protocol.EncodePayload(msg)
+	if err != nil {
+		return err
+	}
+	output.Payloads[protocol.RecipientKey(recipient)] = payload
+	return nil
+}
+
+// decodeInput checks the routed input of a round and decodes its payloads in order of sender id
+func decodeInput(input *protocol.Message, name string, version uint, round string, broadcast, direct func(uint32, []byte) error) error {
+	if input == nil {
+		return fmt.Errorf("message cannot be nil")
+	}
+	if input.Protocol != name || input.Version != version {
+		return fmt.Errorf("expected %s version %d", name, version)
+	}
+	if input.Metadata[protocol.RoundKey] != round {
+		return fmt.Errorf("expected the messages of round %s", round)
+	}
+	broadcasts, p2p, err := protocol.Inbound(input)
+	if err != nil {
+		return err
+	}
+	if broadcast == nil && len(broadcasts) > 0 {
+		return fmt.Errorf("unexpected broadcast in round %s", round)
+	}
+	if direct == nil && len(p2p) > 0 {
+		return fmt.Errorf("unexpected direct message in round %s", round)
+	}
+	for _, id := range sortedIds(broadcasts) {
+		if err = broadcast(id, broadcasts[id]); err != nil {
+			return fmt.Errorf("invalid broadcast from participant %d: %v", id, err)
+		}
+	}
+	for _, id := range sortedIds(p2p) {
+		if err = direct(id, p2p[id]); err != nil {
+			return fmt.Errorf("invalid message from participant %d: %v", id, err)
+		}
+	}
+	return nil
+}
+
+func sortedIds(payloads map[uint32][]byte) []uint32 {
+	ids := make([]uint32, 0, len(payloads))
+	for id := range payloads {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return ids
+}
@@ -0,0 +1,178 @@
+//
+// Copyright Coinbase, Inc. All Rights Reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package threshold
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/sha3"
+
+	"github.com/nerifnetwork/kryptology/pkg/core/curves"
+	"github.com/nerifnetwork/kryptology/pkg/core/protocol"
+)
+
+// runIterators routes the outputs of every round to all participants until the last round, which has no output
+func runIterators(t *testing.T, iterators map[uint32]protocol.Iterator) {
+	inputs := make(map[uint32]*protocol.Message, len(iterators))
+	for {
+		outputs := make(map[uint32]*protocol.Message, len(iterators))
+		for id, it := range iterators {
+			output, err := it.Next(inputs[id])
+			require.NoError(t, err)
+			if output != nil {
+				outputs[id] = output
+			}
+		}
+		if len(outputs) == 0 {
+			return
+		}
+		require.Len(t, outputs, len(iterators))
+		for id := range iterators {
+			var err error
+			inputs[id], err = protocol.Route(id, outputs)
+			require.NoError(t, err)
+		}
+	}
+}
+
+func runDkgIterators(t *testing.T, curve *curves.Curve, threshold, n uint32) map[uint32]*protocol.Message {
+	version, err := protocol.NegotiateVersion(SupportedVersions, []uint{protocol.Version0, protocol.Version1})
+	require.NoError(t, err)
+	dkgs := make(map[uint32]*DkgIterator, n)
+	iterators := make(map[uint32]protocol.Iterator, n)
+	for _, id := range testIds(n) {
+		dkgs[id], err = NewDkgIterator(curve, testSid, id, threshold, testIds(n), version)
+		require.NoError(t, err)
+		result, err := dkgs[id].Result(version)
+		require.NoError(t, err)
+		require.Nil(t, result)
+		iterators[id] = dkgs[id]
+	}
+	runIterators(t, iterators)
+	results := make(map[uint32]*protocol.Message, n)
+	for id, it := range dkgs {
+		_, err = it.Next(nil)
+		require.Equal(t, protocol.ErrProtocolFinished, err)
+		results[id], err = it.Result(version)
+		require.NoError(t, err)
+		require.NotNil(t, results[id])
+	}
+	return results
+}
+
+func runSignIterators(t *testing.T, results map[uint32]*protocol.Message, ids []uint32) *curves.EcdsaSignature {
+	signs := make(map[uint32]*SignIterator, len(ids))
+	iterators := make(map[uint32]protocol.Iterator, len(ids))
+	for _, id := range ids {
+		var err error
+		signs[id], err = NewSignIterator(sha3.New256(), testMessage, results[id], ids, protocol.Version1)
+		require.NoError(t, err)
+		iterators[id] = signs[id]
+	}
+	runIterators(t, iterators)
+	var signature *curves.EcdsaSignature
+	for _, it := range signs {
+		result, err := it.Result(protocol.Version1)
+		require.NoError(t, err)
+		decoded, err := DecodeSignature(result)
+		require.NoError(t, err)
+		if signature != nil {
+			require.Equal(t, signature, decoded)
+		}
+		signature = decoded
+	}
+	return signature
+}
+
+func TestIterators(t *testing.T) {
+	curve := curves.K256()
+	results := runDkgIterators(t, curve, 2, 3)
+	outputs := make(map[uint32]*Output, len(results))
+	for id, result := range results {
+		var err error
+		outputs[id], err = DecodeOutput(result)
+		require.NoError(t, err)
+		require.Equal(t, id, outputs[id].Id)
+	}
+	publicKey := outputs[1].PublicKey
+	require.True(t, publicKey.Equal(curve.ScalarBaseMult(combine(t, curve, outputs, []uint32{2, 3}))))
+	verify(t, publicKey, runSignIterators(t, results, []uint32{1, 3}))
+
+	refreshes := make(map[uint32]*RefreshIterator, len(results))
+	iterators := make(map[uint32]protocol.Iterator, len(results))
+	for id, result := range results {
+		var err error
+		refreshes[id], err = NewRefreshIterator(result, testRefreshSid, protocol.Version1)
+		require.NoError(t, err)
+		iterators[id] = refreshes[id]
+	}
+	runIterators(t, iterators)
+	for id, it := range refreshes {
+		var err error
+		results[id], err = it.Result(protocol.Version1)
+		require.NoError(t, err)
+		refreshed, err := DecodeOutput(results[id])
+		require.NoError(t, err)
+		require.True(t, publicKey.Equal(refreshed.PublicKey))
+	}
+	verify(t, publicKey, runSignIterators(t, results, []uint32{1, 2, 3}))
+}
+
+func TestIterators3Of5(t *testing.T) {
+	results := runDkgIterators(t, curves.P256(), 3, 5)
+	output, err := DecodeOutput(results[4])
+	require.NoError(t, err)
+	verify(t, output.PublicKey, runSignIterators(t, results, []uint32{2, 4, 5}))
+}
+
+func TestIteratorsBadInput(t *testing.T) {
+	curve := curves.K256()
+	ids := testIds(2)
+	_, err := NewDkgIterator(curve, testSid, 1, 2, ids, protocol.Version0)
+	require.Error(t, err)
+	_, err = NewDkgIterator(curve, testSid, 1, 3, ids, protocol.Version1)
+	require.Error(t, err)
+
+	iterators := make(map[uint32]*DkgIterator, 2)
+	outputs := make(map[uint32]*protocol.Message, 2)
+	for _, id := range ids {
+		iterators[id], err = NewDkgIterator(curve, testSid, id, 2, ids, protocol.Version1)
+		require.NoError(t, err)
+		outputs[id], err = iterators[id].Next(nil)
+		require.NoError(t, err)
+	}
+	input, err := protocol.Route(1, outputs)
+	require.NoError(t, err)
+
+	_, err = iterators[1].Next(nil)
+	require.Error(t, err)
+	wrongRound := *input
+	wrongRound.Metadata = map[string]string{protocol.RoundKey: "2"}
+	_, err = iterators[1].Next(&wrongRound)
+	require.Error(t, err)
+	wrongVersion := *input
+	wrongVersion.Version = protocol.Version0
+	_, err = iterators[1].Next(&wrongVersion)
+	require.Error(t, err)
+	wrongProtocol := *input
+	wrongProtocol.Protocol = protocol.DklsThresholdSign
+	_, err = iterators[1].Next(&wrongProtocol)
+	require.Error(t, err)
+
+	_, err = iterators[1].Next(input)
+	require.NoError(t, err)
+
+	_, err = DecodeOutput(input)
+	require.Error(t, err)
+	_, err = DecodeSignature(input)
+	require.Error(t, err)
+	_, err = NewSignIterator(sha3.New256(), testMessage, input, ids, protocol.Version1)
+	require.Error(t, err)
+	_, err = NewRefreshIterator(nil, testRefreshSid, protocol.Version1)
+	require.Error(t, err)
+}
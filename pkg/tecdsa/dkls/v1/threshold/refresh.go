@@ -0,0 +1,166 @@
+//
+// Copyright Coinbase, Inc. All Rights Reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package threshold
+
+import (
+	"crypto/rand"
+	"fmt"
+
+	"github.com/pkg/errors"
+
+	"github.com/nerifnetwork/kryptology/internal"
+	"github.com/nerifnetwork/kryptology/pkg/core/curves"
+	"github.com/nerifnetwork/kryptology/pkg/ot/base/simplest"
+	"github.com/nerifnetwork/kryptology/pkg/sharing"
+	"github.com/nerifnetwork/kryptology/pkg/zkp/schnorr"
+)
+
+// Refresh is a participant of the key refresh. Every participant Feldman shares zero and adds the shares of all
+// participants to its share of the key, so the public key stays the same while the old shares become useless.
+// Every pair of participants runs new seed OTs as in the DKG.
+type Refresh struct {
+	*participant
+
+	// polynomial shares zero
+	polynomial *sharing.Polynomial
+	old        *Output
+}
+
+// RefreshRound1Bcast contains the seed of a participant.
+type RefreshRound1Bcast struct {
+	// Seed is the random value used to derive the joint unique session id.
+	Seed [simplest.DigestSize]byte
+}
+
+// RefreshRound2Bcast contains the Feldman commitments of a participant to the coefficients of its polynomial,
+// except the constant term which is zero.
+type RefreshRound2Bcast struct {
+	Commitments []curves.Point
+}
+
+// RefreshRound2P2PSend contains the share of zero of the recipient and starts the seed OT in which the recipient
+// is the receiver. It must be sent over a private channel.
+type RefreshRound2P2PSend struct {
+	Share  curves.Scalar
+	SeedOt *schnorr.Proof
+}
+
+// NewRefresh creates a participant of the key refresh from the output of the DKG or of a previous refresh.
+// The session id must be unique and known to all participants. All participants of the DKG must take part.
+func NewRefresh(output *Output, sid []byte) (*Refresh, error) {
+	if output == nil || output.PublicKey == nil || output.SecretKeyShare == nil {
+		return nil, internal.ErrNilArguments
+	}
+	curve := curves.GetCurveByName(output.PublicKey.CurveName())
+	if curve == nil {
+		return nil, fmt.Errorf("unknown curve %s", output.PublicKey.CurveName())
+	}
+	ids := make([]uint32, 0, len(output.PublicShares))
+	for id, share := range output.PublicShares {
+		if share == nil {
+			return nil, fmt.Errorf("missing public share of participant %d", id)
+		}
+		ids = append(ids, id)
+	}
+	p, err := newParticipant(curve, sid, output.Id, output.Threshold, ids)
+	if err != nil {
+		return nil, err
+	}
+	return &Refresh{participant: p, old: output}, nil
+}
+
+// Round1 samples the polynomial that shares zero and the seed of this participant.
+func (r *Refresh) Round1() (*RefreshRound1Bcast, error) {
+	if r == nil || r.participant == nil {
+		return nil, internal.ErrNilArguments
+	}
+	if r.round != 1 {
+		return nil, internal.ErrInvalidRound
+	}
+	if _, err := rand.Read(r.seed[:]); err != nil {
+		return nil, errors.Wrap(err, "generating random seed in refresh round 1")
+	}
+	r.polynomial = new(sharing.Polynomial).Init(r.curve.Scalar.Zero(), r.threshold, rand.Reader)
+	r.round = 2
+	return &RefreshRound1Bcast{Seed: r.seed}, nil
+}
+
+// Round2 sends the Feldman commitments and the shares of zero. It also starts the seed OTs in which this
+// participant is the sender.
+func (r *Refresh) Round2(in map[uint32]*RefreshRound1Bcast) (*RefreshRound2Bcast, map[uint32]*RefreshRound2P2PSend, error) {
+	if r == nil || r.participant == nil {
+		return nil, nil, internal.ErrNilArguments
+	}
+	if r.round != 2 {
+		return nil, nil, internal.ErrInvalidRound
+	}
+	seeds := make(map[uint32][simplest.DigestSize]byte, len(r.ids))
+	for _, j := range r.others() {
+		if in[j] == nil {
+			return nil, nil, fmt.Errorf("missing seed of participant %d", j)
+		}
+		seeds[j] = in[j].Seed
+	}
+	seeds[r.id] = r.seed
+	seedOts, err := r.startSeedOts(seeds)
+	if err != nil {
+		return nil, nil, err
+	}
+	p2p := make(map[uint32]*RefreshRound2P2PSend, len(r.ids)-1)
+	for _, j := range r.others() {
+		p2p[j] = &RefreshRound2P2PSend{
+			Share:  r.polynomial.Evaluate(r.curve.Scalar.New(int(j))),
+			SeedOt: seedOts[j],
+		}
+	}
+	r.round = 3
+	return &RefreshRound2Bcast{Commitments: feldmanCommitments(r.curve, r.polynomial)[1:]}, p2p, nil
+}
+
+// Round3 verifies the shares of zero of the other participants and adds them to the share of the key.
+// It answers the seed OTs in which this participant is the receiver.
+func (r *Refresh) Round3(bcast map[uint32]*RefreshRound2Bcast, p2p map[uint32]*RefreshRound2P2PSend) (map[uint32]*Round3P2PSend, error) {
+	if r == nil || r.participant == nil {
+		return nil, internal.ErrNilArguments
+	}
+	if r.round != 3 {
+		return nil, internal.ErrInvalidRound
+	}
+	r.secretKeyShare = r.old.SecretKeyShare.Add(r.polynomial.Evaluate(r.curve.Scalar.New(int(r.id))))
+	r.publicKey = r.old.PublicKey
+	own := feldmanCommitments(r.curve, r.polynomial)
+	r.publicShares = make(map[uint32]curves.Point, len(r.ids))
+	for _, k := range r.ids {
+		r.publicShares[k] = r.old.PublicShares[k].Add(evaluate(r.curve, own, k))
+	}
+	seedOts := make(map[uint32]*schnorr.Proof, len(r.ids)-1)
+	for _, j := range r.others() {
+		if bcast[j] == nil || len(bcast[j].Commitments) != int(r.threshold)-1 {
+			return nil, fmt.Errorf("participant %d sent invalid feldman commitments", j)
+		}
+		for _, c := range bcast[j].Commitments {
+			if c == nil || !c.IsOnCurve() {
+				return nil, fmt.Errorf("participant %d sent invalid feldman commitments", j)
+			}
+		}
+		commitments := append([]curves.Point{r.curve.Point.Identity()}, bcast[j].Commitments...)
+		if p2p[j] == nil || p2p[j].Share == nil || !r.curve.ScalarBaseMult(p2p[j].Share).Equal(evaluate(r.curve, commitments, r.id)) {
+			return nil, fmt.Errorf("participant %d sent an invalid share", j)
+		}
+		r.secretKeyShare = r.secretKeyShare.Add(p2p[j].Share)
+		for _, k := range r.ids {
+			r.publicShares[k] = r.publicShares[k].Add(evaluate(r.curve, commitments, k))
+		}
+		seedOts[j] = p2p[j].SeedOt
+	}
+	out, err := r.answerSeedOts(seedOts)
+	if err != nil {
+		return nil, err
+	}
+	r.round = 4
+	return out, nil
+}
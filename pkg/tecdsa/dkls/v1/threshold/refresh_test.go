@@ -0,0 +1,125 @@
+//
+// Copyright Coinbase, Inc. All Rights Reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package threshold
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/nerifnetwork/kryptology/internal"
+	"github.com/nerifnetwork/kryptology/pkg/core/curves"
+)
+
+var testRefreshSid = []byte("dkls threshold test refresh session")
+
+// refreshRound2 runs the refresh up to round 2 and returns the messages of round 2
+func refreshRound2(t *testing.T, outputs map[uint32]*Output) (map[uint32]*Refresh, map[uint32]*RefreshRound2Bcast, map[uint32]map[uint32]*RefreshRound2P2PSend) {
+	participants := make(map[uint32]*Refresh, len(outputs))
+	r1 := make(map[uint32]*RefreshRound1Bcast, len(outputs))
+	for id, output := range outputs {
+		r, err := NewRefresh(output, testRefreshSid)
+		require.NoError(t, err)
+		participants[id] = r
+		r1[id], err = r.Round1()
+		require.NoError(t, err)
+	}
+	r2 := make(map[uint32]*RefreshRound2Bcast, len(outputs))
+	p2p := make(map[uint32]map[uint32]*RefreshRound2P2PSend, len(outputs))
+	for id, r := range participants {
+		bcast, out, err := r.Round2(r1)
+		require.NoError(t, err)
+		r2[id] = bcast
+		for j, msg := range out {
+			if p2p[j] == nil {
+				p2p[j] = make(map[uint32]*RefreshRound2P2PSend, len(outputs))
+			}
+			p2p[j][id] = msg
+		}
+	}
+	return participants, r2, p2p
+}
+
+// runRefresh returns the refreshed outputs
+func runRefresh(t *testing.T, outputs map[uint32]*Output) map[uint32]*Output {
+	refreshes, r2, p2p := refreshRound2(t, outputs)
+	participants := make(map[uint32]*participant, len(outputs))
+	r3 := make(map[uint32]map[uint32]*Round3P2PSend, len(outputs))
+	for id, r := range refreshes {
+		out, err := r.Round3(r2, p2p[id])
+		require.NoError(t, err)
+		participants[id] = r.participant
+		for j, msg := range out {
+			if r3[j] == nil {
+				r3[j] = make(map[uint32]*Round3P2PSend, len(outputs))
+			}
+			r3[j][id] = msg
+		}
+	}
+	return runSeedOts(t, participants, r3)
+}
+
+func TestRefresh(t *testing.T) {
+	curve := curves.K256()
+	outputs := runDkg(t, curve, 2, 3)
+	refreshed := runRefresh(t, outputs)
+	publicKey := outputs[1].PublicKey
+	for id, output := range refreshed {
+		require.True(t, publicKey.Equal(output.PublicKey))
+		require.False(t, output.SecretKeyShare.Cmp(outputs[id].SecretKeyShare) == 0)
+		for j, publicShare := range output.PublicShares {
+			require.True(t, publicShare.Equal(curve.ScalarBaseMult(refreshed[j].SecretKeyShare)))
+		}
+		for j, receiverOutput := range output.SeedOtReceivers {
+			require.NotEqual(t, outputs[id].SeedOtReceivers[j].OneTimePadDecryptionKey, receiverOutput.OneTimePadDecryptionKey)
+		}
+	}
+	require.True(t, publicKey.Equal(curve.ScalarBaseMult(combine(t, curve, refreshed, []uint32{1, 3}))))
+
+	// The refreshed shares sign, also when refreshed again
+	for _, signature := range runSign(t, refreshed, []uint32{2, 3}) {
+		verify(t, publicKey, signature)
+	}
+	refreshed = runRefresh(t, refreshed)
+	for _, signature := range runSign(t, refreshed, []uint32{1, 2, 3}) {
+		verify(t, publicKey, signature)
+	}
+}
+
+func TestRefreshInvalidArguments(t *testing.T) {
+	outputs := runDkg(t, curves.K256(), 2, 3)
+	_, err := NewRefresh(nil, testRefreshSid)
+	require.Error(t, err)
+	_, err = NewRefresh(outputs[1], nil)
+	require.Error(t, err)
+
+	r, err := NewRefresh(outputs[1], testRefreshSid)
+	require.NoError(t, err)
+	_, _, err = r.Round2(nil)
+	require.Equal(t, internal.ErrInvalidRound, err)
+	_, err = r.Round3(nil, nil)
+	require.Equal(t, internal.ErrInvalidRound, err)
+}
+
+func TestRefreshInvalidShare(t *testing.T) {
+	curve := curves.K256()
+	outputs := runDkg(t, curve, 2, 3)
+	participants, r2, p2p := refreshRound2(t, outputs)
+
+	// Participant 2 shares a non-zero value, which would change the key
+	r2[2] = &RefreshRound2Bcast{Commitments: r2[2].Commitments}
+	p2p[1][2] = &RefreshRound2P2PSend{Share: p2p[1][2].Share.Add(curve.Scalar.One()), SeedOt: p2p[1][2].SeedOt}
+	_, err := participants[1].Round3(r2, p2p[1])
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "participant 2")
+
+	// Participant 3 sends too many commitments
+	r2[3] = &RefreshRound2Bcast{Commitments: append(r2[3].Commitments, curve.Point.Generator())}
+	_, err = participants[2].Round3(r2, p2p[2])
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "participant 3")
+}
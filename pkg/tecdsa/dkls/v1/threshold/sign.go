@@ -0,0 +1,467 @@
+//
+// Copyright Coinbase, Inc. All Rights Reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package threshold
+
+import (
+	"crypto/ecdsa"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/binary"
+	"fmt"
+	"hash"
+	"math/big"
+
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/sha3"
+
+	"github.com/nerifnetwork/kryptology/internal"
+	"github.com/nerifnetwork/kryptology/pkg/core/curves"
+	"github.com/nerifnetwork/kryptology/pkg/ot/base/simplest"
+	"github.com/nerifnetwork/kryptology/pkg/ot/extension/kos"
+	"github.com/nerifnetwork/kryptology/pkg/tecdsa/dkls/v1/sign"
+)
+
+// multiplicationCount is the number of multiplications between every ordered pair of signers:
+// the share of the instance key and the share of the secret key of one signer with the mask of the other.
+const multiplicationCount = 2
+
+// Signer is a participant of the signing. The signers jointly sample the instance key r = Σ r_i with R = r.G,
+// and a mask φ = Σ φ_i. Every ordered pair of signers i, j multiplies r_i and λ_i.x_i of signer i with φ_j
+// of signer j, which gives every signer additive shares u_i of r.φ and v_i of x.φ. The signature is
+// s = Σ w_i / Σ u_i with w_i = m.φ_i + R_x.v_i. See protocol 3.6 of [DKLs23](https://eprint.iacr.org/2023/765.pdf).
+type Signer struct {
+	// Signature is the resulting digital signature and is the output of this protocol.
+	Signature *curves.EcdsaSignature
+
+	curve   *curves.Curve
+	id      uint32
+	signers []uint32
+	hash    hash.Hash
+	message []byte
+	output  *Output
+	round   uint
+
+	seed [simplest.DigestSize]byte
+	salt [simplest.DigestSize]byte
+	// sessionId is derived from the seeds of all signers in round 2
+	sessionId []byte
+
+	// keyShare is the additive share λ_i.x_i of the secret key
+	keyShare curves.Scalar
+	// r is the share of the instance key with R = r.G, phi the share of the mask
+	r, phi curves.Scalar
+	bigR   curves.Point
+
+	hashes    map[uint32][]byte
+	receivers map[uint32][multiplicationCount]*sign.MultiplyReceiver
+	// u and v are the additive shares of r.φ and x.φ
+	u, v curves.Scalar
+	// instanceKey is the joint R
+	instanceKey curves.Point
+	// digest is the hash of the message
+	digest []byte
+}
+
+// SignRound1Bcast contains the seed of a signer and its commitment to its share of the instance key.
+type SignRound1Bcast struct {
+	// Seed is the random value used to derive the joint unique session id.
+	Seed [simplest.DigestSize]byte
+
+	// Commitment is the hash of R_i and the salt.
+	Commitment []byte
+}
+
+// SignRound2P2PSend starts the multiplications in which the sender is the receiver of the multiplication.
+type SignRound2P2PSend struct {
+	// KosRound1Outputs are the outputs of the first round of OT extension for both multiplications.
+	KosRound1Outputs [multiplicationCount]*kos.Round1Output
+}
+
+// SignRound3Bcast opens the commitment of a signer to its share of the instance key.
+type SignRound3Bcast struct {
+	R    curves.Point
+	Salt [simplest.DigestSize]byte
+}
+
+// SignRound3P2PSend answers the multiplications in which the recipient is the receiver of the multiplication.
+type SignRound3P2PSend struct {
+	// MultiplyRound2Outputs are the outputs of the second round of both multiplications.
+	MultiplyRound2Outputs [multiplicationCount]*sign.MultiplyRound2Output
+
+	// Gamma are the points c.G of the sender's output shares c of both multiplications,
+	// the recipient checks them against R_i and the public share of the sender.
+	Gamma [multiplicationCount]curves.Point
+}
+
+// SignRound4Bcast contains the shares of the numerator and the denominator of the signature.
+type SignRound4Bcast struct {
+	// W is the share m.φ_i + R_x.v_i of the numerator.
+	W curves.Scalar
+
+	// U is the share of r.φ.
+	U curves.Scalar
+}
+
+// NewSigner creates a signer from the output of the DKG or a refresh. The signers are the ids of all signers
+// including the id of the output, at least the threshold of the DKG. All signers must use the same message.
+func NewSigner(output *Output, hash hash.Hash, message []byte, signers []uint32) (*Signer, error) {
+	if output == nil || output.PublicKey == nil || output.SecretKeyShare == nil || hash == nil {
+		return nil, internal.ErrNilArguments
+	}
+	curve := curves.GetCurveByName(output.PublicKey.CurveName())
+	if curve == nil {
+		return nil, fmt.Errorf("unknown curve %s", output.PublicKey.CurveName())
+	}
+	signers, err := sortIds(signers)
+	if err != nil {
+		return nil, err
+	}
+	if !contains(signers, output.Id) {
+		return nil, fmt.Errorf("participant %d is not a signer", output.Id)
+	}
+	if len(signers) < int(output.Threshold) {
+		return nil, fmt.Errorf("need at least %d signers", output.Threshold)
+	}
+	for _, j := range signers {
+		if output.PublicShares[j] == nil {
+			return nil, fmt.Errorf("missing public share of signer %d", j)
+		}
+		if j != output.Id && (output.SeedOtSenders[j] == nil || output.SeedOtReceivers[j] == nil) {
+			return nil, fmt.Errorf("missing seed ot with signer %d", j)
+		}
+	}
+	lambda, err := lagrange(curve, output.Id, signers)
+	if err != nil {
+		return nil, err
+	}
+	return &Signer{
+		curve:    curve,
+		id:       output.Id,
+		signers:  signers,
+		hash:     hash,
+		message:  message,
+		output:   output,
+		keyShare: lambda.Mul(output.SecretKeyShare),
+		round:    1,
+	}, nil
+}
+
+// Round1 samples the shares of the instance key and of the mask and commits to R_i.
+func (s *Signer) Round1() (*SignRound1Bcast, error) {
+	if s == nil || s.curve == nil {
+		return nil, internal.ErrNilArguments
+	}
+	if s.round != 1 {
+		return nil, internal.ErrInvalidRound
+	}
+	if _, err := rand.Read(s.seed[:]); err != nil {
+		return nil, errors.Wrap(err, "generating random seed in sign round 1")
+	}
+	if _, err := rand.Read(s.salt[:]); err != nil {
+		return nil, errors.Wrap(err, "generating random salt in sign round 1")
+	}
+	s.r = s.curve.Scalar.Random(rand.Reader)
+	s.phi = s.curve.Scalar.Random(rand.Reader)
+	s.bigR = s.curve.ScalarBaseMult(s.r)
+	s.round = 2
+	return &SignRound1Bcast{
+		Seed:       s.seed,
+		Commitment: signCommitment(s.id, s.bigR, s.salt),
+	}, nil
+}
+
+// Round2 derives the unique session id and starts the multiplications with every other signer
+// in which this signer is the receiver with input φ_i.
+func (s *Signer) Round2(in map[uint32]*SignRound1Bcast) (map[uint32]*SignRound2P2PSend, error) {
+	if s == nil || s.curve == nil {
+		return nil, internal.ErrNilArguments
+	}
+	if s.round != 2 {
+		return nil, internal.ErrInvalidRound
+	}
+	seeds := make(map[uint32][simplest.DigestSize]byte, len(s.signers))
+	s.hashes = make(map[uint32][]byte, len(s.signers)-1)
+	for _, j := range s.others() {
+		if in[j] == nil || len(in[j].Commitment) != simplest.DigestSize {
+			return nil, fmt.Errorf("signer %d sent an invalid commitment", j)
+		}
+		seeds[j] = in[j].Seed
+		s.hashes[j] = in[j].Commitment
+	}
+	seeds[s.id] = s.seed
+	s.sessionId = sessionId(s.output.PublicKey.ToAffineCompressed(), s.signers, seeds)
+
+	s.receivers = make(map[uint32][multiplicationCount]*sign.MultiplyReceiver, len(s.signers)-1)
+	out := make(map[uint32]*SignRound2P2PSend, len(s.signers)-1)
+	for _, j := range s.others() {
+		receivers := [multiplicationCount]*sign.MultiplyReceiver{}
+		msg := &SignRound2P2PSend{}
+		for k := 0; k < multiplicationCount; k++ {
+			var err error
+			receivers[k], err = sign.NewMultiplyReceiver(s.output.SeedOtSenders[j], s.curve, multiplySessionId(s.sessionId, k, j, s.id))
+			if err != nil {
+				return nil, errors.Wrapf(err, "creating multiply receiver %d for signer %d", k, j)
+			}
+			if msg.KosRound1Outputs[k], err = receivers[k].Round1Initialize(s.phi); err != nil {
+				return nil, errors.Wrapf(err, "multiply %d with signer %d", k, j)
+			}
+		}
+		s.receivers[j] = receivers
+		out[j] = msg
+	}
+	s.round = 3
+	return out, nil
+}
+
+// Round3 opens R_i and answers the multiplications with every other signer in which this signer is the sender
+// with inputs r_i and λ_i.x_i.
+func (s *Signer) Round3(in map[uint32]*SignRound2P2PSend) (*SignRound3Bcast, map[uint32]*SignRound3P2PSend, error) {
+	if s == nil || s.curve == nil {
+		return nil, nil, internal.ErrNilArguments
+	}
+	if s.round != 3 {
+		return nil, nil, internal.ErrInvalidRound
+	}
+	inputs := [multiplicationCount]curves.Scalar{s.r, s.keyShare}
+	s.u = s.r.Mul(s.phi)
+	s.v = s.keyShare.Mul(s.phi)
+	out := make(map[uint32]*SignRound3P2PSend, len(s.signers)-1)
+	for _, j := range s.others() {
+		if in[j] == nil {
+			return nil, nil, fmt.Errorf("missing message from signer %d", j)
+		}
+		msg := &SignRound3P2PSend{}
+		for k := 0; k < multiplicationCount; k++ {
+			if in[j].KosRound1Outputs[k] == nil {
+				return nil, nil, fmt.Errorf("signer %d sent an invalid multiplication", j)
+			}
+			sender, err := sign.NewMultiplySender(s.output.SeedOtReceivers[j], s.curve, multiplySessionId(s.sessionId, k, s.id, j))
+			if err != nil {
+				return nil, nil, errors.Wrapf(err, "creating multiply sender %d for signer %d", k, j)
+			}
+			if msg.MultiplyRound2Outputs[k], err = sender.Round2Multiply(inputs[k], in[j].KosRound1Outputs[k]); err != nil {
+				return nil, nil, errors.Wrapf(err, "multiply %d with signer %d", k, j)
+			}
+			c := sender.OutputAdditiveShare()
+			msg.Gamma[k] = s.curve.ScalarBaseMult(c)
+			if k == 0 {
+				s.u = s.u.Add(c)
+			} else {
+				s.v = s.v.Add(c)
+			}
+		}
+		out[j] = msg
+	}
+	s.round = 4
+	return &SignRound3Bcast{R: s.bigR, Salt: s.salt}, out, nil
+}
+
+// Round4 finishes the multiplications in which this signer is the receiver and checks them against R_j and the
+// public share of the other signer, then returns the shares of the numerator and the denominator of the signature.
+func (s *Signer) Round4(bcast map[uint32]*SignRound3Bcast, p2p map[uint32]*SignRound3P2PSend) (*SignRound4Bcast, error) {
+	if s == nil || s.curve == nil {
+		return nil, internal.ErrNilArguments
+	}
+	if s.round != 4 {
+		return nil, internal.ErrInvalidRound
+	}
+	s.instanceKey = s.bigR
+	for _, j := range s.others() {
+		if bcast[j] == nil || bcast[j].R == nil || !bcast[j].R.IsOnCurve() {
+			return nil, fmt.Errorf("signer %d sent an invalid instance key share", j)
+		}
+		if subtle.ConstantTimeCompare(s.hashes[j], signCommitment(j, bcast[j].R, bcast[j].Salt)) != 1 {
+			return nil, fmt.Errorf("signer %d did not open its commitment", j)
+		}
+		if p2p[j] == nil {
+			return nil, fmt.Errorf("missing message from signer %d", j)
+		}
+		lambda, err := lagrange(s.curve, j, s.signers)
+		if err != nil {
+			return nil, err
+		}
+		// The sender's share c and our share d add up to α.φ_i, so c.G = φ_i.A - d.G with A = α.G
+		statements := [multiplicationCount]curves.Point{bcast[j].R, s.output.PublicShares[j].Mul(lambda)}
+		for k := 0; k < multiplicationCount; k++ {
+			if !validMultiply(p2p[j].MultiplyRound2Outputs[k]) || p2p[j].Gamma[k] == nil {
+				return nil, fmt.Errorf("signer %d sent an invalid multiplication", j)
+			}
+			if err = s.receivers[j][k].Round3Multiply(p2p[j].MultiplyRound2Outputs[k]); err != nil {
+				return nil, errors.Wrapf(err, "multiply %d with signer %d", k, j)
+			}
+			d := s.receivers[j][k].OutputAdditiveShare()
+			if !statements[k].Mul(s.phi).Sub(s.curve.ScalarBaseMult(d)).Equal(p2p[j].Gamma[k]) {
+				return nil, fmt.Errorf("signer %d failed the consistency check of multiplication %d", j, k)
+			}
+			if k == 0 {
+				s.u = s.u.Add(d)
+			} else {
+				s.v = s.v.Add(d)
+			}
+		}
+		s.instanceKey = s.instanceKey.Add(bcast[j].R)
+	}
+	if s.instanceKey.IsIdentity() {
+		return nil, fmt.Errorf("the instance key is the identity")
+	}
+	x, _, err := coordinates(s.instanceKey)
+	if err != nil {
+		return nil, err
+	}
+	rx, err := s.scalar(x)
+	if err != nil {
+		return nil, err
+	}
+	if _, err = s.hash.Write(s.message); err != nil {
+		return nil, errors.Wrap(err, "writing message to hash in sign round 4")
+	}
+	s.digest = s.hash.Sum(nil)
+	q, err := s.order()
+	if err != nil {
+		return nil, err
+	}
+	m, err := s.scalar(truncate(s.digest, q))
+	if err != nil {
+		return nil, err
+	}
+	s.round = 5
+	return &SignRound4Bcast{
+		W: m.Mul(s.phi).Add(rx.Mul(s.v)),
+		U: s.u,
+	}, nil
+}
+
+// Output combines the shares of all signers into the signature and verifies it.
+func (s *Signer) Output(in map[uint32]*SignRound4Bcast) (*curves.EcdsaSignature, error) {
+	if s == nil || s.curve == nil {
+		return nil, internal.ErrNilArguments
+	}
+	if s.round != 5 {
+		return nil, internal.ErrInvalidRound
+	}
+	w, u := s.curve.Scalar.Zero(), s.curve.Scalar.Zero()
+	for _, j := range s.signers {
+		if in[j] == nil || in[j].W == nil || in[j].U == nil {
+			return nil, fmt.Errorf("missing shares of signer %d", j)
+		}
+		w = w.Add(in[j].W)
+		u = u.Add(in[j].U)
+	}
+	if u.IsZero() {
+		return nil, fmt.Errorf("the denominator of the signature is zero")
+	}
+	q, err := s.order()
+	if err != nil {
+		return nil, err
+	}
+	rx, ry, err := coordinates(s.instanceKey)
+	if err != nil {
+		return nil, err
+	}
+	signature := &curves.EcdsaSignature{
+		V: int(ry.Bit(0)),
+		R: rx.Mod(rx, q),
+		S: w.Div(u).BigInt(),
+	}
+	// Normalize the signature to a "low S" form, negating s flips the parity of R
+	if signature.S.Cmp(new(big.Int).Rsh(q, 1)) == 1 {
+		signature.S.Sub(q, signature.S)
+		signature.V ^= 1
+	}
+
+	ellipticCurve, err := s.curve.ToEllipticCurve()
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid curve")
+	}
+	x, y, err := coordinates(s.output.PublicKey)
+	if err != nil {
+		return nil, err
+	}
+	if !ecdsa.Verify(&ecdsa.PublicKey{Curve: ellipticCurve, X: x, Y: y}, s.digest, signature.R, signature.S) {
+		return nil, fmt.Errorf("final signature failed to verify")
+	}
+	s.Signature = signature
+	s.round = 6
+	return signature, nil
+}
+
+// others returns the ids of all other signers in ascending order
+func (s *Signer) others() []uint32 {
+	return without(s.signers, s.id)
+}
+
+// coordinates returns the affine coordinates of the point
+func coordinates(point curves.Point) (*big.Int, *big.Int, error) {
+	uncompressed := point.ToAffineUncompressed()
+	if len(uncompressed) != 65 {
+		return nil, nil, errors.New("the uncompressed form must have exactly 65 bytes")
+	}
+	return new(big.Int).SetBytes(uncompressed[1:33]), new(big.Int).SetBytes(uncompressed[33:]), nil
+}
+
+// scalar reduces the integer mod q
+func (s *Signer) scalar(x *big.Int) (curves.Scalar, error) {
+	q, err := s.order()
+	if err != nil {
+		return nil, err
+	}
+	result, err := s.curve.Scalar.SetBigInt(new(big.Int).Mod(x, q))
+	if err != nil {
+		return nil, errors.Wrap(err, "setting scalar from big int")
+	}
+	return result, nil
+}
+
+// truncate converts the hash to an integer like ECDSA, keeping the leftmost bits of the hash
+func truncate(digest []byte, q *big.Int) *big.Int {
+	m := new(big.Int).SetBytes(digest)
+	if excess := len(digest)*8 - q.BitLen(); excess > 0 {
+		m.Rsh(m, uint(excess))
+	}
+	return m
+}
+
+func (s *Signer) order() (*big.Int, error) {
+	ellipticCurve, err := s.curve.ToEllipticCurve()
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid curve")
+	}
+	return ellipticCurve.Params().N, nil
+}
+
+// validMultiply checks that a multiplication message has all its values
+func validMultiply(m *sign.MultiplyRound2Output) bool {
+	if m == nil || m.COTRound2Output == nil || m.U == nil {
+		return false
+	}
+	for j := 0; j < kos.L; j++ {
+		if m.R[j] == nil {
+			return false
+		}
+		for k := 0; k < kos.OtWidth; k++ {
+			if m.COTRound2Output.Tau[j][k] == nil {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// multiplySessionId derives the unique session id of multiplication k in which from is the sender
+func multiplySessionId(sessionId []byte, k int, from, to uint32) [simplest.DigestSize]byte {
+	return pairSessionId(sessionId, fmt.Sprintf("multiply %d", k), from, to)
+}
+
+// signCommitment hashes the share of the instance key of a signer with the salt
+func signCommitment(id uint32, bigR curves.Point, salt [simplest.DigestSize]byte) []byte {
+	hash := sha3.New256()
+	_, _ = hash.Write([]byte("Coinbase_DKLs_Threshold_Sign"))
+	_ = binary.Write(hash, binary.BigEndian, id)
+	_, _ = hash.Write(bigR.ToAffineCompressed())
+	_, _ = hash.Write(salt[:])
+	return hash.Sum(nil)
+}
@@ -0,0 +1,186 @@
+//
+// Copyright Coinbase, Inc. All Rights Reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package threshold
+
+import (
+	"crypto/ecdsa"
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/sha3"
+
+	"github.com/nerifnetwork/kryptology/internal"
+	"github.com/nerifnetwork/kryptology/pkg/core/curves"
+)
+
+var testMessage = []byte("A message to be signed by a threshold of the participants")
+
+// signRound3 runs signing up to round 3 and returns the messages of round 3
+func signRound3(t *testing.T, outputs map[uint32]*Output, ids []uint32) (map[uint32]*Signer, map[uint32]*SignRound3Bcast, map[uint32]map[uint32]*SignRound3P2PSend) {
+	signers := make(map[uint32]*Signer, len(ids))
+	r1 := make(map[uint32]*SignRound1Bcast, len(ids))
+	for _, id := range ids {
+		s, err := NewSigner(outputs[id], sha3.New256(), testMessage, ids)
+		require.NoError(t, err)
+		signers[id] = s
+		r1[id], err = s.Round1()
+		require.NoError(t, err)
+	}
+	r2 := make(map[uint32]map[uint32]*SignRound2P2PSend, len(ids))
+	for id, s := range signers {
+		out, err := s.Round2(r1)
+		require.NoError(t, err)
+		for j, msg := range out {
+			if r2[j] == nil {
+				r2[j] = make(map[uint32]*SignRound2P2PSend, len(ids))
+			}
+			r2[j][id] = msg
+		}
+	}
+	r3 := make(map[uint32]*SignRound3Bcast, len(ids))
+	p2p := make(map[uint32]map[uint32]*SignRound3P2PSend, len(ids))
+	for id, s := range signers {
+		bcast, out, err := s.Round3(r2[id])
+		require.NoError(t, err)
+		r3[id] = bcast
+		for j, msg := range out {
+			if p2p[j] == nil {
+				p2p[j] = make(map[uint32]*SignRound3P2PSend, len(ids))
+			}
+			p2p[j][id] = msg
+		}
+	}
+	return signers, r3, p2p
+}
+
+// runSign returns the signatures computed by every signer
+func runSign(t *testing.T, outputs map[uint32]*Output, ids []uint32) map[uint32]*curves.EcdsaSignature {
+	signers, r3, p2p := signRound3(t, outputs, ids)
+	r4 := make(map[uint32]*SignRound4Bcast, len(ids))
+	for id, s := range signers {
+		var err error
+		r4[id], err = s.Round4(r3, p2p[id])
+		require.NoError(t, err)
+	}
+	signatures := make(map[uint32]*curves.EcdsaSignature, len(ids))
+	for id, s := range signers {
+		signature, err := s.Output(r4)
+		require.NoError(t, err)
+		require.Equal(t, signature, s.Signature)
+		signatures[id] = signature
+	}
+	return signatures
+}
+
+// verify checks the signature against the public key with the standard library
+func verify(t *testing.T, publicKey curves.Point, signature *curves.EcdsaSignature) {
+	curve := curves.GetCurveByName(publicKey.CurveName())
+	ellipticCurve, err := curve.ToEllipticCurve()
+	require.NoError(t, err)
+	x, y, err := coordinates(publicKey)
+	require.NoError(t, err)
+	digest := sha3.Sum256(testMessage)
+	require.True(t, ecdsa.Verify(&ecdsa.PublicKey{Curve: ellipticCurve, X: x, Y: y}, digest[:], signature.R, signature.S))
+	require.True(t, signature.S.Cmp(new(big.Int).Rsh(ellipticCurve.Params().N, 1)) <= 0)
+}
+
+func TestSign(t *testing.T) {
+	for _, curve := range []*curves.Curve{curves.K256(), curves.P256()} {
+		outputs := runDkg(t, curve, 2, 3)
+		for _, ids := range [][]uint32{{1, 2}, {1, 3}, {2, 3}, {1, 2, 3}} {
+			signatures := runSign(t, outputs, ids)
+			for _, signature := range signatures {
+				require.Equal(t, signatures[ids[0]], signature)
+				verify(t, outputs[1].PublicKey, signature)
+			}
+		}
+	}
+}
+
+func TestSign3Of5(t *testing.T) {
+	outputs := runDkg(t, curves.K256(), 3, 5)
+	for _, ids := range [][]uint32{{1, 2, 3}, {1, 3, 5}, {2, 4, 5}, {1, 2, 3, 4, 5}} {
+		signatures := runSign(t, outputs, ids)
+		for _, signature := range signatures {
+			verify(t, outputs[1].PublicKey, signature)
+		}
+	}
+}
+
+func TestSignInvalidArguments(t *testing.T) {
+	outputs := runDkg(t, curves.K256(), 3, 5)
+	_, err := NewSigner(nil, sha3.New256(), testMessage, []uint32{1, 2, 3})
+	require.Error(t, err)
+	_, err = NewSigner(outputs[1], nil, testMessage, []uint32{1, 2, 3})
+	require.Error(t, err)
+	_, err = NewSigner(outputs[1], sha3.New256(), testMessage, []uint32{1, 2})
+	require.Error(t, err)
+	_, err = NewSigner(outputs[1], sha3.New256(), testMessage, []uint32{2, 3, 4})
+	require.Error(t, err)
+	_, err = NewSigner(outputs[1], sha3.New256(), testMessage, []uint32{1, 2, 6})
+	require.Error(t, err)
+	_, err = NewSigner(outputs[1], sha3.New256(), testMessage, []uint32{1, 2, 2})
+	require.Error(t, err)
+
+	s, err := NewSigner(outputs[1], sha3.New256(), testMessage, []uint32{1, 2, 3})
+	require.NoError(t, err)
+	_, err = s.Round2(nil)
+	require.Equal(t, internal.ErrInvalidRound, err)
+	_, _, err = s.Round3(nil)
+	require.Equal(t, internal.ErrInvalidRound, err)
+	_, err = s.Round4(nil, nil)
+	require.Equal(t, internal.ErrInvalidRound, err)
+	_, err = s.Output(nil)
+	require.Equal(t, internal.ErrInvalidRound, err)
+}
+
+func TestSignInvalidInstanceKey(t *testing.T) {
+	curve := curves.K256()
+	outputs := runDkg(t, curve, 2, 3)
+	signers, r3, p2p := signRound3(t, outputs, []uint32{1, 2, 3})
+
+	// Signer 2 changes R_2 after round 1
+	r3[2] = &SignRound3Bcast{R: r3[2].R.Add(curve.Point.Generator()), Salt: r3[2].Salt}
+	_, err := signers[1].Round4(r3, p2p[1])
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "signer 2")
+}
+
+func TestSignInvalidConsistencyCheck(t *testing.T) {
+	curve := curves.K256()
+	outputs := runDkg(t, curve, 2, 3)
+	signers, r3, p2p := signRound3(t, outputs, []uint32{1, 2, 3})
+
+	// Signer 3 uses a different input in the multiplication with the key share of signer 1
+	msg := *p2p[1][3]
+	msg.Gamma[1] = msg.Gamma[1].Add(curve.Point.Generator())
+	p2p[1][3] = &msg
+	_, err := signers[1].Round4(r3, p2p[1])
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "signer 3")
+	_, err = signers[2].Round4(r3, p2p[2])
+	require.NoError(t, err)
+}
+
+func TestSignInvalidShares(t *testing.T) {
+	curve := curves.K256()
+	outputs := runDkg(t, curve, 2, 3)
+	signers, r3, p2p := signRound3(t, outputs, []uint32{1, 2})
+	r4 := make(map[uint32]*SignRound4Bcast, 2)
+	for id, s := range signers {
+		var err error
+		r4[id], err = s.Round4(r3, p2p[id])
+		require.NoError(t, err)
+	}
+
+	// Signer 2 sends a wrong share of the numerator, so the signature does not verify
+	r4[2] = &SignRound4Bcast{W: r4[2].W.Add(curve.Scalar.One()), U: r4[2].U}
+	_, err := signers[1].Output(r4)
+	require.Error(t, err)
+	require.Nil(t, signers[1].Signature)
+}
@@ -0,0 +1,811 @@
+//
+// Copyright Coinbase, Inc. All Rights Reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package v1
+
+import (
+	"bytes"
+	"math/big"
+
+	"git.sr.ht/~sircmpwn/go-bare"
+	"github.com/pkg/errors"
+
+	"github.com/nerifnetwork/kryptology/pkg/core/curves"
+	"github.com/nerifnetwork/kryptology/pkg/core/protocol"
+	"github.com/nerifnetwork/kryptology/pkg/ot/base/simplest"
+	"github.com/nerifnetwork/kryptology/pkg/ot/extension/kos"
+	"github.com/nerifnetwork/kryptology/pkg/tecdsa/dkls/v1/dkg"
+	"github.com/nerifnetwork/kryptology/pkg/tecdsa/dkls/v1/export"
+	"github.com/nerifnetwork/kryptology/pkg/tecdsa/dkls/v1/refresh"
+	"github.com/nerifnetwork/kryptology/pkg/tecdsa/dkls/v1/sign"
+	"github.com/nerifnetwork/kryptology/pkg/zkp/schnorr"
+)
+
+// The payloads of Version2 messages are BARE messages (https://baremessages.org) of the wire types below, so that
+// peers in any language can decode them. The schema is in wire.bare. The encoding is canonical: points are
+// compressed, scalars are big-endian and of the fixed size of the curve, and every payload that contains points or
+// scalars names its curve once.
+
+// wireSchnorrProof is a schnorr.Proof
+type wireSchnorrProof struct {
+	C         []byte `bare:"c"`
+	S         []byte `bare:"s"`
+	Statement []byte `bare:"statement"`
+}
+
+// wireCommitment is the commitment of round 1 of DKG and signing
+type wireCommitment struct {
+	Commitment [32]byte `bare:"commitment"`
+}
+
+// wireScalar is a scalar of round 1 of refresh and of the export
+type wireScalar struct {
+	Curve string `bare:"curve"`
+	Value []byte `bare:"value"`
+}
+
+// wireProof is the schnorr proof of rounds 3 to 5 of DKG
+type wireProof struct {
+	Curve string           `bare:"curve"`
+	Proof wireSchnorrProof `bare:"proof"`
+}
+
+type wireDkgRound2Output struct {
+	Seed       [simplest.DigestSize]byte `bare:"seed"`
+	Commitment []byte                    `bare:"commitment"`
+}
+
+type wireOtMaskedChoices struct {
+	Choices [][]byte `bare:"choices"`
+}
+
+type wireOtChallenges struct {
+	Challenges [][simplest.DigestSize]byte `bare:"challenges"`
+}
+
+type wireOtChallengeResponses struct {
+	Responses [][simplest.DigestSize]byte `bare:"responses"`
+}
+
+type wireOtChallengeOpenings struct {
+	Openings [][2][simplest.DigestSize]byte `bare:"openings"`
+}
+
+// wireOtReceiverOutput is a simplest.ReceiverOutput, the unpacked choice bits are not encoded
+type wireOtReceiverOutput struct {
+	PackedRandomChoiceBits   []byte                      `bare:"packedRandomChoiceBits"`
+	OneTimePadDecryptionKeys [][simplest.DigestSize]byte `bare:"oneTimePadDecryptionKeys"`
+}
+
+type wireOtSenderOutput struct {
+	OneTimePadEncryptionKeys [][2][simplest.DigestSize]byte `bare:"oneTimePadEncryptionKeys"`
+}
+
+type wireAliceOutput struct {
+	Curve          string               `bare:"curve"`
+	PublicKey      []byte               `bare:"publicKey"`
+	SecretKeyShare []byte               `bare:"secretKeyShare"`
+	SeedOtResult   wireOtReceiverOutput `bare:"seedOtResult"`
+	ChainCode      []byte               `bare:"chainCode"`
+	Tweak          *[]byte              `bare:"tweak"`
+}
+
+type wireBobOutput struct {
+	Curve          string             `bare:"curve"`
+	PublicKey      []byte             `bare:"publicKey"`
+	SecretKeyShare []byte             `bare:"secretKeyShare"`
+	SeedOtResult   wireOtSenderOutput `bare:"seedOtResult"`
+	ChainCode      []byte             `bare:"chainCode"`
+	Tweak          *[]byte            `bare:"tweak"`
+}
+
+type wireKeyShare struct {
+	Curve          string `bare:"curve"`
+	PublicKey      []byte `bare:"publicKey"`
+	SecretKeyShare []byte `bare:"secretKeyShare"`
+	ChainCode      []byte `bare:"chainCode"`
+}
+
+type wireKosRound1Output struct {
+	U      [kos.Kappa][]byte         `bare:"u"`
+	WPrime [simplest.DigestSize]byte `bare:"wPrime"`
+	VPrime [simplest.DigestSize]byte `bare:"vPrime"`
+}
+
+type wireSignRound2Output struct {
+	Curve            string                    `bare:"curve"`
+	KosRound1Outputs []wireKosRound1Output     `bare:"kosRound1Outputs"`
+	DB               []byte                    `bare:"db"`
+	Seed             [simplest.DigestSize]byte `bare:"seed"`
+}
+
+type wireMultiplyRound2Output struct {
+	Tau [kos.L][kos.OtWidth][]byte `bare:"tau"`
+	R   [kos.L][]byte              `bare:"r"`
+	U   []byte                     `bare:"u"`
+}
+
+type wireSignRound3Output struct {
+	Curve                 string                     `bare:"curve"`
+	MultiplyRound2Outputs []wireMultiplyRound2Output `bare:"multiplyRound2Outputs"`
+	RSchnorrProof         wireSchnorrProof           `bare:"rSchnorrProof"`
+	RPrime                []byte                     `bare:"rPrime"`
+	EtaPhi                []byte                     `bare:"etaPhi"`
+	EtaSig                *[]byte                    `bare:"etaSig"`
+}
+
+// wireSignature is a curves.EcdsaSignature, R and S are big-endian without leading zeros
+type wireSignature struct {
+	V int    `bare:"v"`
+	R []byte `bare:"r"`
+	S []byte `bare:"s"`
+}
+
+type wireAlicePresignature struct {
+	Curve       string  `bare:"curve"`
+	R           []byte  `bare:"r"`
+	RX          []byte  `bare:"rX"`
+	Tweak       *[]byte `bare:"tweak"`
+	PhiShare    []byte  `bare:"phiShare"`
+	SecretShare []byte  `bare:"secretShare"`
+	HashGamma2  []byte  `bare:"hashGamma2"`
+}
+
+type wireBobPresignature struct {
+	Curve       string  `bare:"curve"`
+	R           []byte  `bare:"r"`
+	RX          []byte  `bare:"rX"`
+	V           int     `bare:"v"`
+	PublicKey   []byte  `bare:"publicKey"`
+	Tweak       *[]byte `bare:"tweak"`
+	Theta       []byte  `bare:"theta"`
+	SecretShare []byte  `bare:"secretShare"`
+	HashGamma2  []byte  `bare:"hashGamma2"`
+}
+
+type wirePresignOutput struct {
+	Curve  string `bare:"curve"`
+	R      []byte `bare:"r"`
+	EtaSig []byte `bare:"etaSig"`
+}
+
+type wireRefreshRound2Output struct {
+	Curve              string           `bare:"curve"`
+	SeedOTRound1Output wireSchnorrProof `bare:"seedOtRound1Output"`
+	BobMultiplier      []byte           `bare:"bobMultiplier"`
+}
+
+// wireCipherText is an export.CipherText
+type wireCipherText struct {
+	C1   [][]byte `bare:"c1"`
+	Bits [][]byte `bare:"bits"`
+}
+
+// wireShareProof is an export.ShareProof
+type wireShareProof struct {
+	C     []byte   `bare:"c"`
+	R     [][]byte `bare:"r"`
+	M     [][]byte `bare:"m"`
+	BitC  [][]byte `bare:"bitC"`
+	BitS0 [][]byte `bare:"bitS0"`
+	BitS1 [][]byte `bare:"bitS1"`
+}
+
+type wireBackup struct {
+	Curve       string         `bare:"curve"`
+	PublicKey   []byte         `bare:"publicKey"`
+	ChainCode   []byte         `bare:"chainCode"`
+	Tweak       *[]byte        `bare:"tweak"`
+	PublicShare []byte         `bare:"publicShare"`
+	CipherText  wireCipherText `bare:"cipherText"`
+	Proof       wireShareProof `bare:"proof"`
+}
+
+// marshalWire encodes the wire form of a payload, the error is the one of its conversion
+func marshalWire(value interface{}, err error) ([]byte, error) {
+	if err != nil {
+		return nil, err
+	}
+	return bare.Marshal(value)
+}
+
+// unmarshalWire decodes the payload of m into the wire type value. Unlike bare.Unmarshal, it rejects trailing bytes.
+func unmarshalWire(m *protocol.Message, value interface{}) error {
+	payload := m.Payloads[payloadKey]
+	if len(payload) == 0 {
+		return errors.New("empty payload")
+	}
+	reader := bytes.NewReader(payload)
+	if err := bare.UnmarshalBareReader(bare.NewReader(reader), value); err != nil {
+		return errors.Wrap(err, "decoding payload")
+	}
+	if reader.Len() != 0 {
+		return errors.New("payload has trailing bytes")
+	}
+	return nil
+}
+
+// wireWriter converts points and scalars to their wire form. It takes the curve from the first value, all others
+// must be on the same curve. The first error sticks, so the conversions are checked once.
+type wireWriter struct {
+	curve string
+	err   error
+}
+
+func (w *wireWriter) checkCurve(name string) {
+	if w.curve == "" {
+		w.curve = name
+	} else if w.curve != name && w.err == nil {
+		w.err = errors.Errorf("expected a value of curve %s, got %s", w.curve, name)
+	}
+}
+
+func (w *wireWriter) point(p curves.Point) []byte {
+	if p == nil {
+		if w.err == nil {
+			w.err = errors.New("point cannot be nil")
+		}
+		return nil
+	}
+	w.checkCurve(p.CurveName())
+	return p.ToAffineCompressed()
+}
+
+func (w *wireWriter) scalar(s curves.Scalar) []byte {
+	if s == nil {
+		if w.err == nil {
+			w.err = errors.New("scalar cannot be nil")
+		}
+		return nil
+	}
+	w.checkCurve(s.Point().CurveName())
+	return s.Bytes()
+}
+
+func (w *wireWriter) points(points []curves.Point) [][]byte {
+	out := make([][]byte, len(points))
+	for i, p := range points {
+		out[i] = w.point(p)
+	}
+	return out
+}
+
+func (w *wireWriter) scalars(scalars []curves.Scalar) [][]byte {
+	out := make([][]byte, len(scalars))
+	for i, s := range scalars {
+		out[i] = w.scalar(s)
+	}
+	return out
+}
+
+func (w *wireWriter) optionalScalar(s curves.Scalar) *[]byte {
+	if s == nil {
+		return nil
+	}
+	b := w.scalar(s)
+	return &b
+}
+
+func (w *wireWriter) schnorrProof(proof *schnorr.Proof) wireSchnorrProof {
+	if proof == nil {
+		if w.err == nil {
+			w.err = errors.New("schnorr proof cannot be nil")
+		}
+		return wireSchnorrProof{}
+	}
+	return wireSchnorrProof{
+		C:         w.scalar(proof.C),
+		S:         w.scalar(proof.S),
+		Statement: w.point(proof.Statement),
+	}
+}
+
+// wireReader converts the wire form of points and scalars of a curve back. The first error sticks.
+type wireReader struct {
+	curve *curves.Curve
+	err   error
+}
+
+func newWireReader(curveName string) *wireReader {
+	r := &wireReader{curve: curves.GetCurveByName(curveName)}
+	if r.curve == nil {
+		r.err = errors.Errorf("unknown curve %q", curveName)
+	}
+	return r
+}
+
+func (r *wireReader) point(b []byte) curves.Point {
+	if r.err != nil {
+		return nil
+	}
+	p, err := r.curve.Point.FromAffineCompressed(b)
+	if err != nil {
+		r.err = errors.Wrap(err, "decoding point")
+		return nil
+	}
+	return p
+}
+
+func (r *wireReader) scalar(b []byte) curves.Scalar {
+	if r.err != nil {
+		return nil
+	}
+	s, err := r.curve.Scalar.SetBytes(b)
+	if err != nil {
+		r.err = errors.Wrap(err, "decoding scalar")
+		return nil
+	}
+	return s
+}
+
+func (r *wireReader) points(b [][]byte) []curves.Point {
+	out := make([]curves.Point, len(b))
+	for i := range b {
+		out[i] = r.point(b[i])
+	}
+	return out
+}
+
+func (r *wireReader) scalars(b [][]byte) []curves.Scalar {
+	out := make([]curves.Scalar, len(b))
+	for i := range b {
+		out[i] = r.scalar(b[i])
+	}
+	return out
+}
+
+func (r *wireReader) optionalScalar(b *[]byte) curves.Scalar {
+	if b == nil {
+		return nil
+	}
+	return r.scalar(*b)
+}
+
+func (r *wireReader) schnorrProof(proof wireSchnorrProof) *schnorr.Proof {
+	return &schnorr.Proof{
+		C:         r.scalar(proof.C),
+		S:         r.scalar(proof.S),
+		Statement: r.point(proof.Statement),
+	}
+}
+
+// chainCode decodes an empty chain code as nil, the chain code of outputs that have none
+func chainCode(b []byte) []byte {
+	if len(b) == 0 {
+		return nil
+	}
+	return b
+}
+
+func toWireScalar(s curves.Scalar) (*wireScalar, error) {
+	w := new(wireWriter)
+	value := w.scalar(s)
+	return &wireScalar{Curve: w.curve, Value: value}, w.err
+}
+
+func (m *wireScalar) scalar() (curves.Scalar, error) {
+	r := newWireReader(m.Curve)
+	s := r.scalar(m.Value)
+	return s, r.err
+}
+
+func toWireProof(proof *schnorr.Proof) (*wireProof, error) {
+	w := new(wireWriter)
+	p := w.schnorrProof(proof)
+	return &wireProof{Curve: w.curve, Proof: p}, w.err
+}
+
+func (m *wireProof) proof() (*schnorr.Proof, error) {
+	r := newWireReader(m.Curve)
+	p := r.schnorrProof(m.Proof)
+	return p, r.err
+}
+
+func toWireDkgRound2Output(output *dkg.Round2Output) (*wireDkgRound2Output, error) {
+	if output == nil {
+		return nil, errors.New("round 2 output cannot be nil")
+	}
+	return &wireDkgRound2Output{Seed: output.Seed, Commitment: output.Commitment}, nil
+}
+
+func (m *wireDkgRound2Output) output() *dkg.Round2Output {
+	return &dkg.Round2Output{Seed: m.Seed, Commitment: m.Commitment}
+}
+
+func toWireReceiverOutput(output *simplest.ReceiverOutput) (wireOtReceiverOutput, error) {
+	if output == nil {
+		return wireOtReceiverOutput{}, errors.New("seed OT result cannot be nil")
+	}
+	return wireOtReceiverOutput{
+		PackedRandomChoiceBits:   output.PackedRandomChoiceBits,
+		OneTimePadDecryptionKeys: output.OneTimePadDecryptionKey,
+	}, nil
+}
+
+func (m *wireOtReceiverOutput) receiverOutput() (*simplest.ReceiverOutput, error) {
+	batchSize := len(m.OneTimePadDecryptionKeys)
+	if len(m.PackedRandomChoiceBits) != (batchSize+7)/8 {
+		return nil, errors.New("the choice bits do not match the seed OT batch size")
+	}
+	output := &simplest.ReceiverOutput{
+		PackedRandomChoiceBits:  m.PackedRandomChoiceBits,
+		RandomChoiceBits:        make([]int, batchSize),
+		OneTimePadDecryptionKey: m.OneTimePadDecryptionKeys,
+	}
+	for i := range output.RandomChoiceBits {
+		output.RandomChoiceBits[i] = int(simplest.ExtractBitFromByteVector(output.PackedRandomChoiceBits, i))
+	}
+	return output, nil
+}
+
+func toWireAliceOutput(output *dkg.AliceOutput) (*wireAliceOutput, error) {
+	if output == nil {
+		return nil, errors.New("alice output cannot be nil")
+	}
+	seedOtResult, err := toWireReceiverOutput(output.SeedOtResult)
+	if err != nil {
+		return nil, err
+	}
+	w := new(wireWriter)
+	m := &wireAliceOutput{
+		PublicKey:      w.point(output.PublicKey),
+		SecretKeyShare: w.scalar(output.SecretKeyShare),
+		SeedOtResult:   seedOtResult,
+		ChainCode:      output.ChainCode,
+		Tweak:          w.optionalScalar(output.Tweak),
+	}
+	m.Curve = w.curve
+	return m, w.err
+}
+
+func (m *wireAliceOutput) output() (*dkg.AliceOutput, error) {
+	seedOtResult, err := m.SeedOtResult.receiverOutput()
+	if err != nil {
+		return nil, err
+	}
+	r := newWireReader(m.Curve)
+	output := &dkg.AliceOutput{
+		PublicKey:      r.point(m.PublicKey),
+		SecretKeyShare: r.scalar(m.SecretKeyShare),
+		SeedOtResult:   seedOtResult,
+		ChainCode:      chainCode(m.ChainCode),
+		Tweak:          r.optionalScalar(m.Tweak),
+	}
+	return output, r.err
+}
+
+func toWireBobOutput(output *dkg.BobOutput) (*wireBobOutput, error) {
+	if output == nil {
+		return nil, errors.New("bob output cannot be nil")
+	}
+	if output.SeedOtResult == nil {
+		return nil, errors.New("seed OT result cannot be nil")
+	}
+	w := new(wireWriter)
+	m := &wireBobOutput{
+		PublicKey:      w.point(output.PublicKey),
+		SecretKeyShare: w.scalar(output.SecretKeyShare),
+		SeedOtResult:   wireOtSenderOutput{OneTimePadEncryptionKeys: output.SeedOtResult.OneTimePadEncryptionKeys},
+		ChainCode:      output.ChainCode,
+		Tweak:          w.optionalScalar(output.Tweak),
+	}
+	m.Curve = w.curve
+	return m, w.err
+}
+
+func (m *wireBobOutput) output() (*dkg.BobOutput, error) {
+	r := newWireReader(m.Curve)
+	output := &dkg.BobOutput{
+		PublicKey:      r.point(m.PublicKey),
+		SecretKeyShare: r.scalar(m.SecretKeyShare),
+		SeedOtResult:   &simplest.SenderOutput{OneTimePadEncryptionKeys: m.SeedOtResult.OneTimePadEncryptionKeys},
+		ChainCode:      chainCode(m.ChainCode),
+		Tweak:          r.optionalScalar(m.Tweak),
+	}
+	return output, r.err
+}
+
+func toWireKeyShare(share *dkg.KeyShare) (*wireKeyShare, error) {
+	if share == nil {
+		return nil, errors.New("key share cannot be nil")
+	}
+	w := new(wireWriter)
+	m := &wireKeyShare{
+		PublicKey:      w.point(share.PublicKey),
+		SecretKeyShare: w.scalar(share.SecretKeyShare),
+		ChainCode:      share.ChainCode,
+	}
+	m.Curve = w.curve
+	return m, w.err
+}
+
+func (m *wireKeyShare) keyShare() (*dkg.KeyShare, error) {
+	r := newWireReader(m.Curve)
+	share := &dkg.KeyShare{
+		PublicKey:      r.point(m.PublicKey),
+		SecretKeyShare: r.scalar(m.SecretKeyShare),
+		ChainCode:      chainCode(m.ChainCode),
+	}
+	return share, r.err
+}
+
+func toWireSignRound2Output(output *sign.SignRound2Output) (*wireSignRound2Output, error) {
+	if output == nil {
+		return nil, errors.New("round 2 output cannot be nil")
+	}
+	w := new(wireWriter)
+	m := &wireSignRound2Output{
+		KosRound1Outputs: make([]wireKosRound1Output, len(output.KosRound1Outputs)),
+		DB:               w.point(output.DB),
+		Seed:             output.Seed,
+	}
+	for i, kosOutput := range output.KosRound1Outputs {
+		if kosOutput == nil {
+			return nil, errors.New("cOT round 1 output cannot be nil")
+		}
+		for j := range kosOutput.U {
+			m.KosRound1Outputs[i].U[j] = kosOutput.U[j][:]
+		}
+		m.KosRound1Outputs[i].WPrime = kosOutput.WPrime
+		m.KosRound1Outputs[i].VPrime = kosOutput.VPrime
+	}
+	m.Curve = w.curve
+	return m, w.err
+}
+
+func (m *wireSignRound2Output) output() (*sign.SignRound2Output, error) {
+	r := newWireReader(m.Curve)
+	output := &sign.SignRound2Output{
+		DB:   r.point(m.DB),
+		Seed: m.Seed,
+	}
+	if len(m.KosRound1Outputs) != len(output.KosRound1Outputs) {
+		return nil, errors.Errorf("expected %d cOT round 1 outputs", len(output.KosRound1Outputs))
+	}
+	for i := range output.KosRound1Outputs {
+		kosOutput := &kos.Round1Output{
+			WPrime: m.KosRound1Outputs[i].WPrime,
+			VPrime: m.KosRound1Outputs[i].VPrime,
+		}
+		for j, u := range m.KosRound1Outputs[i].U {
+			if len(u) != len(kosOutput.U[j]) {
+				return nil, errors.New("cOT round 1 output has the wrong size")
+			}
+			copy(kosOutput.U[j][:], u)
+		}
+		output.KosRound1Outputs[i] = kosOutput
+	}
+	return output, r.err
+}
+
+func toWireSignRound3Output(output *sign.SignRound3Output) (*wireSignRound3Output, error) {
+	if output == nil {
+		return nil, errors.New("round 3 output cannot be nil")
+	}
+	w := new(wireWriter)
+	m := &wireSignRound3Output{
+		MultiplyRound2Outputs: make([]wireMultiplyRound2Output, len(output.MultiplyRound2Outputs)),
+		RSchnorrProof:         w.schnorrProof(output.RSchnorrProof),
+		RPrime:                w.point(output.RPrime),
+		EtaPhi:                w.scalar(output.EtaPhi),
+		EtaSig:                w.optionalScalar(output.EtaSig),
+	}
+	for i, multiplyOutput := range output.MultiplyRound2Outputs {
+		if multiplyOutput == nil || multiplyOutput.COTRound2Output == nil {
+			return nil, errors.New("multiply round 2 output cannot be nil")
+		}
+		for j := range multiplyOutput.COTRound2Output.Tau {
+			for k := range multiplyOutput.COTRound2Output.Tau[j] {
+				m.MultiplyRound2Outputs[i].Tau[j][k] = w.scalar(multiplyOutput.COTRound2Output.Tau[j][k])
+			}
+			m.MultiplyRound2Outputs[i].R[j] = w.scalar(multiplyOutput.R[j])
+		}
+		m.MultiplyRound2Outputs[i].U = w.scalar(multiplyOutput.U)
+	}
+	m.Curve = w.curve
+	return m, w.err
+}
+
+func (m *wireSignRound3Output) output() (*sign.SignRound3Output, error) {
+	r := newWireReader(m.Curve)
+	output := &sign.SignRound3Output{
+		RSchnorrProof: r.schnorrProof(m.RSchnorrProof),
+		RPrime:        r.point(m.RPrime),
+		EtaPhi:        r.scalar(m.EtaPhi),
+		EtaSig:        r.optionalScalar(m.EtaSig),
+	}
+	if len(m.MultiplyRound2Outputs) != len(output.MultiplyRound2Outputs) {
+		return nil, errors.Errorf("expected %d multiply round 2 outputs", len(output.MultiplyRound2Outputs))
+	}
+	for i := range output.MultiplyRound2Outputs {
+		multiplyOutput := &sign.MultiplyRound2Output{COTRound2Output: new(kos.Round2Output)}
+		for j := range multiplyOutput.COTRound2Output.Tau {
+			for k := range multiplyOutput.COTRound2Output.Tau[j] {
+				multiplyOutput.COTRound2Output.Tau[j][k] = r.scalar(m.MultiplyRound2Outputs[i].Tau[j][k])
+			}
+			multiplyOutput.R[j] = r.scalar(m.MultiplyRound2Outputs[i].R[j])
+		}
+		multiplyOutput.U = r.scalar(m.MultiplyRound2Outputs[i].U)
+		output.MultiplyRound2Outputs[i] = multiplyOutput
+	}
+	return output, r.err
+}
+
+func toWireSignature(signature *curves.EcdsaSignature) (*wireSignature, error) {
+	if signature == nil || signature.R == nil || signature.S == nil {
+		return nil, errors.New("signature cannot be nil")
+	}
+	if signature.R.Sign() < 0 || signature.S.Sign() < 0 {
+		return nil, errors.New("signature cannot be negative")
+	}
+	return &wireSignature{V: signature.V, R: signature.R.Bytes(), S: signature.S.Bytes()}, nil
+}
+
+func (m *wireSignature) signature() *curves.EcdsaSignature {
+	return &curves.EcdsaSignature{
+		V: m.V,
+		R: new(big.Int).SetBytes(m.R),
+		S: new(big.Int).SetBytes(m.S),
+	}
+}
+
+func toWireAlicePresignature(presignature *sign.AlicePresignature) (*wireAlicePresignature, error) {
+	if presignature == nil {
+		return nil, errors.New("presignature cannot be nil")
+	}
+	w := new(wireWriter)
+	m := &wireAlicePresignature{
+		R:           w.point(presignature.R),
+		RX:          w.scalar(presignature.RX),
+		Tweak:       w.optionalScalar(presignature.Tweak),
+		PhiShare:    w.scalar(presignature.PhiShare),
+		SecretShare: w.scalar(presignature.SecretShare),
+		HashGamma2:  w.scalar(presignature.HashGamma2),
+	}
+	m.Curve = w.curve
+	return m, w.err
+}
+
+func (m *wireAlicePresignature) presignature() (*sign.AlicePresignature, error) {
+	r := newWireReader(m.Curve)
+	presignature := &sign.AlicePresignature{
+		R:           r.point(m.R),
+		RX:          r.scalar(m.RX),
+		Tweak:       r.optionalScalar(m.Tweak),
+		PhiShare:    r.scalar(m.PhiShare),
+		SecretShare: r.scalar(m.SecretShare),
+		HashGamma2:  r.scalar(m.HashGamma2),
+	}
+	return presignature, r.err
+}
+
+func toWireBobPresignature(presignature *sign.BobPresignature) (*wireBobPresignature, error) {
+	if presignature == nil {
+		return nil, errors.New("presignature cannot be nil")
+	}
+	w := new(wireWriter)
+	m := &wireBobPresignature{
+		R:           w.point(presignature.R),
+		RX:          w.scalar(presignature.RX),
+		V:           presignature.V,
+		PublicKey:   w.point(presignature.PublicKey),
+		Tweak:       w.optionalScalar(presignature.Tweak),
+		Theta:       w.scalar(presignature.Theta),
+		SecretShare: w.scalar(presignature.SecretShare),
+		HashGamma2:  w.scalar(presignature.HashGamma2),
+	}
+	m.Curve = w.curve
+	return m, w.err
+}
+
+func (m *wireBobPresignature) presignature() (*sign.BobPresignature, error) {
+	r := newWireReader(m.Curve)
+	presignature := &sign.BobPresignature{
+		R:           r.point(m.R),
+		RX:          r.scalar(m.RX),
+		V:           m.V,
+		PublicKey:   r.point(m.PublicKey),
+		Tweak:       r.optionalScalar(m.Tweak),
+		Theta:       r.scalar(m.Theta),
+		SecretShare: r.scalar(m.SecretShare),
+		HashGamma2:  r.scalar(m.HashGamma2),
+	}
+	return presignature, r.err
+}
+
+func toWirePresignOutput(output *sign.PresignOutput) (*wirePresignOutput, error) {
+	if output == nil {
+		return nil, errors.New("presign output cannot be nil")
+	}
+	w := new(wireWriter)
+	m := &wirePresignOutput{
+		R:      w.point(output.R),
+		EtaSig: w.scalar(output.EtaSig),
+	}
+	m.Curve = w.curve
+	return m, w.err
+}
+
+func (m *wirePresignOutput) output() (*sign.PresignOutput, error) {
+	r := newWireReader(m.Curve)
+	output := &sign.PresignOutput{
+		R:      r.point(m.R),
+		EtaSig: r.scalar(m.EtaSig),
+	}
+	return output, r.err
+}
+
+func toWireRefreshRound2Output(output *refresh.RefreshRound2Output) (*wireRefreshRound2Output, error) {
+	if output == nil {
+		return nil, errors.New("round 2 output cannot be nil")
+	}
+	w := new(wireWriter)
+	m := &wireRefreshRound2Output{
+		SeedOTRound1Output: w.schnorrProof(output.SeedOTRound1Output),
+		BobMultiplier:      w.scalar(output.BobMultiplier),
+	}
+	m.Curve = w.curve
+	return m, w.err
+}
+
+func (m *wireRefreshRound2Output) output() (*refresh.RefreshRound2Output, error) {
+	r := newWireReader(m.Curve)
+	output := &refresh.RefreshRound2Output{
+		SeedOTRound1Output: r.schnorrProof(m.SeedOTRound1Output),
+		BobMultiplier:      r.scalar(m.BobMultiplier),
+	}
+	return output, r.err
+}
+
+func toWireBackup(backup *export.Backup) (*wireBackup, error) {
+	if backup == nil || backup.CipherText == nil || backup.Proof == nil {
+		return nil, errors.New("backup cannot be nil")
+	}
+	w := new(wireWriter)
+	m := &wireBackup{
+		PublicKey:   w.point(backup.PublicKey),
+		ChainCode:   backup.ChainCode,
+		Tweak:       w.optionalScalar(backup.Tweak),
+		PublicShare: w.point(backup.PublicShare),
+		CipherText: wireCipherText{
+			C1:   w.points(backup.CipherText.C1),
+			Bits: w.points(backup.CipherText.Bits),
+		},
+		Proof: wireShareProof{
+			C:     w.scalar(backup.Proof.C),
+			R:     w.scalars(backup.Proof.R),
+			M:     w.scalars(backup.Proof.M),
+			BitC:  w.scalars(backup.Proof.BitC),
+			BitS0: w.scalars(backup.Proof.BitS0),
+			BitS1: w.scalars(backup.Proof.BitS1),
+		},
+	}
+	m.Curve = w.curve
+	return m, w.err
+}
+
+func (m *wireBackup) backup() (*export.Backup, error) {
+	r := newWireReader(m.Curve)
+	backup := &export.Backup{
+		PublicKey:   r.point(m.PublicKey),
+		ChainCode:   chainCode(m.ChainCode),
+		Tweak:       r.optionalScalar(m.Tweak),
+		PublicShare: r.point(m.PublicShare),
+		CipherText: &export.CipherText{
+			C1:   r.points(m.CipherText.C1),
+			Bits: r.points(m.CipherText.Bits),
+		},
+		Proof: &export.ShareProof{
+			C:     r.scalar(m.Proof.C),
+			R:     r.scalars(m.Proof.R),
+			M:     r.scalars(m.Proof.M),
+			BitC:  r.scalars(m.Proof.BitC),
+			BitS0: r.scalars(m.Proof.BitS0),
+			BitS1: r.scalars(m.Proof.BitS1),
+		},
+	}
+	return backup, r.err
+}
@@ -0,0 +1,336 @@
+//
+// Copyright Coinbase, Inc. All Rights Reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package v1
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"reflect"
+	"testing"
+
+	"git.sr.ht/~sircmpwn/go-bare"
+	"git.sr.ht/~sircmpwn/go-bare/schema"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/sha3"
+
+	"github.com/nerifnetwork/kryptology/pkg/core/curves"
+	"github.com/nerifnetwork/kryptology/pkg/core/protocol"
+	"github.com/nerifnetwork/kryptology/pkg/tecdsa/dkls/v1/dkg"
+	"github.com/nerifnetwork/kryptology/pkg/tecdsa/dkls/v1/export"
+	"github.com/nerifnetwork/kryptology/pkg/verenc/elgamal"
+)
+
+// The schema in wire.bare must describe the wire types exactly, it is what peers in other languages implement.
+func TestWireSchema(t *testing.T) {
+	file, err := os.Open("wire.bare")
+	require.NoError(t, err)
+	defer file.Close()
+	schemaTypes, err := schema.Parse(file)
+	require.NoError(t, err)
+	types := map[string]schema.Type{}
+	for _, schemaType := range schemaTypes {
+		userType, ok := schemaType.(*schema.UserDefinedType)
+		require.True(t, ok, schemaType.Name())
+		types[userType.Name()] = userType.Type()
+	}
+
+	wireTypes := map[string]interface{}{
+		"SchnorrProof":         wireSchnorrProof{},
+		"Commitment":           wireCommitment{},
+		"Scalar":               wireScalar{},
+		"Proof":                wireProof{},
+		"DkgRound2Output":      wireDkgRound2Output{},
+		"OtMaskedChoices":      wireOtMaskedChoices{},
+		"OtChallenges":         wireOtChallenges{},
+		"OtChallengeResponses": wireOtChallengeResponses{},
+		"OtChallengeOpenings":  wireOtChallengeOpenings{},
+		"OtReceiverOutput":     wireOtReceiverOutput{},
+		"OtSenderOutput":       wireOtSenderOutput{},
+		"AliceOutput":          wireAliceOutput{},
+		"BobOutput":            wireBobOutput{},
+		"KeyShare":             wireKeyShare{},
+		"KosRound1Output":      wireKosRound1Output{},
+		"SignRound2Output":     wireSignRound2Output{},
+		"MultiplyRound2Output": wireMultiplyRound2Output{},
+		"SignRound3Output":     wireSignRound3Output{},
+		"Signature":            wireSignature{},
+		"AlicePresignature":    wireAlicePresignature{},
+		"BobPresignature":      wireBobPresignature{},
+		"PresignOutput":        wirePresignOutput{},
+		"RefreshRound2Output":  wireRefreshRound2Output{},
+		"CipherText":           wireCipherText{},
+		"ShareProof":           wireShareProof{},
+		"Backup":               wireBackup{},
+	}
+	require.Len(t, types, len(wireTypes))
+	for name, value := range wireTypes {
+		schemaType, ok := types[name]
+		require.True(t, ok, "%s is missing in wire.bare", name)
+		requireSchemaMatches(t, types, schemaType, reflect.TypeOf(value), name)
+	}
+}
+
+func requireSchemaMatches(t *testing.T, types map[string]schema.Type, expected schema.Type, actual reflect.Type, path string) {
+	t.Helper()
+	switch expected := expected.(type) {
+	case *schema.NamedUserType:
+		userType, ok := types[expected.Name()]
+		require.True(t, ok, "%s: unknown type %s", path, expected.Name())
+		requireSchemaMatches(t, types, userType, actual, path)
+	case *schema.PrimitiveType:
+		kinds := map[schema.TypeKind]reflect.Kind{schema.INT: reflect.Int, schema.Bool: reflect.Bool, schema.String: reflect.String}
+		kind, ok := kinds[expected.Kind()]
+		require.True(t, ok, "%s: unexpected type %s", path, expected.Kind())
+		require.Equal(t, kind, actual.Kind(), path)
+	case *schema.DataType:
+		if expected.Kind() == schema.DataSlice {
+			require.Equal(t, reflect.TypeOf([]byte{}), actual, path)
+		} else {
+			require.Equal(t, reflect.Array, actual.Kind(), path)
+			require.Equal(t, reflect.Uint8, actual.Elem().Kind(), path)
+			require.Equal(t, int(expected.Length()), actual.Len(), path)
+		}
+	case *schema.ArrayType:
+		if expected.Kind() == schema.Slice {
+			require.Equal(t, reflect.Slice, actual.Kind(), path)
+		} else {
+			require.Equal(t, reflect.Array, actual.Kind(), path)
+			require.Equal(t, int(expected.Length()), actual.Len(), path)
+		}
+		requireSchemaMatches(t, types, expected.Member(), actual.Elem(), path+"[]")
+	case *schema.OptionalType:
+		require.Equal(t, reflect.Ptr, actual.Kind(), path)
+		requireSchemaMatches(t, types, expected.Subtype(), actual.Elem(), path)
+	case *schema.StructType:
+		require.Equal(t, reflect.Struct, actual.Kind(), path)
+		require.Equal(t, len(expected.Fields()), actual.NumField(), path)
+		for i, field := range expected.Fields() {
+			require.Equal(t, field.Name(), actual.Field(i).Tag.Get("bare"), path)
+			requireSchemaMatches(t, types, field.Type(), actual.Field(i).Type, path+"."+field.Name())
+		}
+	default:
+		t.Fatalf("%s: unexpected type %s", path, expected.Kind())
+	}
+}
+
+// DKG > Sign > Refresh > Sign, Presign and Export, all in Version2
+func TestVersion2Proto(t *testing.T) {
+	for _, curve := range []*curves.Curve{curves.K256(), curves.P256()} {
+		t.Run(curve.Name, func(t *testing.T) {
+			aliceDkg := NewAliceDkg(curve, protocol.Version2)
+			bobDkg := NewBobDkg(curve, protocol.Version2)
+			aErr, bErr := runIteratedProtocol(bobDkg, aliceDkg)
+			require.ErrorIs(t, aErr, protocol.ErrProtocolFinished)
+			require.ErrorIs(t, bErr, protocol.ErrProtocolFinished)
+			aliceDkgResultMessage, err := aliceDkg.Result(protocol.Version2)
+			require.NoError(t, err)
+			require.Equal(t, uint(protocol.Version2), aliceDkgResultMessage.Version)
+			bobDkgResultMessage, err := bobDkg.Result(protocol.Version2)
+			require.NoError(t, err)
+
+			signWithVersion(t, curve, aliceDkgResultMessage, bobDkgResultMessage, protocol.Version2)
+			aliceRefreshResultMessage, bobRefreshResultMessage := refreshWithVersion(t, curve, aliceDkgResultMessage, bobDkgResultMessage, protocol.Version2)
+			signWithVersion(t, curve, aliceRefreshResultMessage, bobRefreshResultMessage, protocol.Version2)
+
+			alicePresign, err := NewAlicePresign(curve, aliceDkgResultMessage, protocol.Version2)
+			require.NoError(t, err)
+			bobPresign, err := NewBobPresign(curve, bobDkgResultMessage, protocol.Version2)
+			require.NoError(t, err)
+			aErr, bErr = runIteratedProtocol(alicePresign, bobPresign)
+			require.ErrorIs(t, aErr, protocol.ErrProtocolFinished)
+			require.ErrorIs(t, bErr, protocol.ErrProtocolFinished)
+			alicePresignature, err := alicePresign.Result(protocol.Version2)
+			require.NoError(t, err)
+			bobPresignature, err := bobPresign.Result(protocol.Version2)
+			require.NoError(t, err)
+			digest := sha3.Sum256([]byte("message"))
+			aliceMessage, err := AliceSignPresigned(alicePresignature, digest[:], protocol.Version2)
+			require.NoError(t, err)
+			signatureMessage, err := BobSignPresigned(bobPresignature, digest[:], aliceMessage, protocol.Version2)
+			require.NoError(t, err)
+			signature, err := DecodeSignature(signatureMessage)
+			require.NoError(t, err)
+			require.True(t, curves.VerifyEcdsa(ecdsaPublicKey(t, curve, aliceDkg.Output().PublicKey), digest[:], signature))
+
+			aliceExport, err := NewAliceExport(curve, aliceDkgResultMessage, protocol.Version2)
+			require.NoError(t, err)
+			bobExport, err := NewBobExport(curve, bobDkgResultMessage, protocol.Version2)
+			require.NoError(t, err)
+			aErr, bErr = runIteratedProtocol(aliceExport, bobExport)
+			require.ErrorIs(t, aErr, protocol.ErrProtocolFinished)
+			require.ErrorIs(t, bErr, protocol.ErrProtocolFinished)
+			keyMessage, err := bobExport.Result(protocol.Version2)
+			require.NoError(t, err)
+			secretKey, err := DecodeExportedKey(keyMessage)
+			require.NoError(t, err)
+			require.True(t, curve.ScalarBaseMult(secretKey).Equal(aliceDkg.Output().PublicKey))
+
+			sessionId := []byte("backup of the dkg output")
+			recoveryKey, decryptionKey, err := elgamal.NewKeys(curve)
+			require.NoError(t, err)
+			backup, err := export.NewAliceBackup(aliceDkg.Output(), recoveryKey, sessionId)
+			require.NoError(t, err)
+			backupMessage, err := EncodeBackup(backup, protocol.Version2)
+			require.NoError(t, err)
+			aliceBackup, err := DecodeBackup(backupMessage)
+			require.NoError(t, err)
+			require.NoError(t, aliceBackup.VerifyWithShare(recoveryKey, sessionId, bobDkg.Output().SecretKeyShare))
+			bobBackup, err := export.NewBobBackup(bobDkg.Output(), recoveryKey, sessionId)
+			require.NoError(t, err)
+			recovered, err := export.Recover(decryptionKey, sessionId, aliceBackup, bobBackup)
+			require.NoError(t, err)
+			require.Equal(t, 0, secretKey.Cmp(recovered))
+		})
+	}
+}
+
+func ecdsaPublicKey(t *testing.T, curve *curves.Curve, publicKey curves.Point) *curves.EcPoint {
+	t.Helper()
+	ecCurve, err := curve.ToEllipticCurve()
+	require.NoError(t, err)
+	uncompressed := publicKey.ToAffineUncompressed()
+	return &curves.EcPoint{
+		Curve: ecCurve,
+		X:     new(big.Int).SetBytes(uncompressed[1:33]),
+		Y:     new(big.Int).SetBytes(uncompressed[33:]),
+	}
+}
+
+// Decoding and encoding a Version2 payload gives the same bytes
+func TestVersion2Canonical(t *testing.T) {
+	curve := curves.K256()
+	aliceDkg := NewAliceDkg(curve, protocol.Version2)
+	bobDkg := NewBobDkg(curve, protocol.Version2)
+	aErr, bErr := runIteratedProtocol(bobDkg, aliceDkg)
+	require.ErrorIs(t, aErr, protocol.ErrProtocolFinished)
+	require.ErrorIs(t, bErr, protocol.ErrProtocolFinished)
+
+	aliceOutput := aliceDkg.Output()
+	aliceOutput.ChainCode = []byte("chain code of the derived output")
+	aliceOutput.Tweak = curve.Scalar.New(42)
+	aliceMessage, err := EncodeAliceDkgOutput(aliceOutput, protocol.Version2)
+	require.NoError(t, err)
+	decodedAlice, err := DecodeAliceDkgResult(aliceMessage)
+	require.NoError(t, err)
+	require.Equal(t, aliceOutput.SeedOtResult, decodedAlice.SeedOtResult)
+	require.Equal(t, aliceOutput.ChainCode, decodedAlice.ChainCode)
+	require.Equal(t, 0, aliceOutput.Tweak.Cmp(decodedAlice.Tweak))
+	reencoded, err := EncodeAliceDkgOutput(decodedAlice, protocol.Version2)
+	require.NoError(t, err)
+	require.Equal(t, aliceMessage.Payloads, reencoded.Payloads)
+
+	bobMessage, err := EncodeBobDkgOutput(bobDkg.Output(), protocol.Version2)
+	require.NoError(t, err)
+	decodedBob, err := DecodeBobDkgResult(bobMessage)
+	require.NoError(t, err)
+	require.Equal(t, bobDkg.Output().ChainCode, decodedBob.ChainCode)
+	require.Nil(t, decodedBob.Tweak)
+	require.Equal(t, bobDkg.Output().SeedOtResult, decodedBob.SeedOtResult)
+	reencoded, err = EncodeBobDkgOutput(decodedBob, protocol.Version2)
+	require.NoError(t, err)
+	require.Equal(t, bobMessage.Payloads, reencoded.Payloads)
+
+	// Scalars are big-endian and of the size of the curve
+	scalarMessage, err := encodeRefreshRound1Output(curve.Scalar.One(), protocol.Version2)
+	require.NoError(t, err)
+	expected := append([]byte{9}, "secp256k1"...)
+	expected = append(expected, 32)
+	expected = append(expected, make([]byte, 31)...)
+	expected = append(expected, 1)
+	require.Equal(t, expected, scalarMessage.Payloads[payloadKey])
+}
+
+func TestVersion2Rejected(t *testing.T) {
+	curve := curves.K256()
+	message, err := encodeExportRound1Output(curve.Scalar.New(3), protocol.Version2)
+	require.NoError(t, err)
+	_, err = decodeExportRound2Input(message)
+	require.NoError(t, err)
+
+	t.Run("trailing bytes", func(t *testing.T) {
+		trailing := &protocol.Message{
+			Protocol: message.Protocol,
+			Version:  message.Version,
+			Payloads: map[string][]byte{payloadKey: append(message.Payloads[payloadKey], 0)},
+			Metadata: message.Metadata,
+		}
+		_, err := decodeExportRound2Input(trailing)
+		require.Error(t, err)
+	})
+	t.Run("empty payload", func(t *testing.T) {
+		empty := &protocol.Message{Protocol: message.Protocol, Version: message.Version, Metadata: message.Metadata}
+		_, err := decodeExportRound2Input(empty)
+		require.Error(t, err)
+	})
+	t.Run("unknown curve", func(t *testing.T) {
+		payload, err := bare.Marshal(&wireScalar{Curve: "curve25519", Value: curve.Scalar.New(3).Bytes()})
+		require.NoError(t, err)
+		unknown := newExportProtocolMessage(payload, "1", protocol.Version2)
+		_, err = decodeExportRound2Input(unknown)
+		require.Error(t, err)
+	})
+	t.Run("invalid point", func(t *testing.T) {
+		payload, err := bare.Marshal(&wireProof{
+			Curve: curve.Name,
+			Proof: wireSchnorrProof{C: curve.Scalar.One().Bytes(), S: curve.Scalar.One().Bytes(), Statement: make([]byte, 33)},
+		})
+		require.NoError(t, err)
+		_, err = decodeDkgRound4Input(newDkgProtocolMessage(payload, "3", protocol.Version2))
+		require.Error(t, err)
+	})
+	t.Run("points of different curves", func(t *testing.T) {
+		_, err := EncodeKeyShare(&dkg.KeyShare{
+			PublicKey:      curves.P256().Point.Generator(),
+			SecretKeyShare: curve.Scalar.One(),
+		}, protocol.Version2)
+		require.Error(t, err)
+	})
+	t.Run("unsupported version", func(t *testing.T) {
+		_, err := encodeExportRound1Output(curve.Scalar.New(3), protocol.Version1+50)
+		require.Error(t, err)
+		unsupported := newExportProtocolMessage(message.Payloads[payloadKey], "1", protocol.Version0)
+		_, err = decodeExportRound2Input(unsupported)
+		require.Error(t, err)
+	})
+}
+
+// Decode > Encode Version2 > Sign
+// The gob encoded outputs of Version1 stay readable, they can be signed with in Version2 and converted to it.
+func TestSignColdStartVersion2(t *testing.T) {
+	aliceDkg, err := ioutil.ReadFile("testdata/alice-dkls-v1-dkg.bin")
+	require.NoError(t, err)
+	bobDkg, err := ioutil.ReadFile("testdata/bob-dkls-v1-dkg.bin")
+	require.NoError(t, err)
+	aliceDkgMessage := &protocol.Message{}
+	require.NoError(t, json.Unmarshal(aliceDkg, aliceDkgMessage))
+	bobDkgMessage := &protocol.Message{}
+	require.NoError(t, json.Unmarshal(bobDkg, bobDkgMessage))
+	require.Equal(t, uint(protocol.Version1), aliceDkgMessage.Version)
+
+	signWithVersion(t, curves.K256(), aliceDkgMessage, bobDkgMessage, protocol.Version2)
+
+	aliceOutput, err := DecodeAliceDkgResult(aliceDkgMessage)
+	require.NoError(t, err)
+	bobOutput, err := DecodeBobDkgResult(bobDkgMessage)
+	require.NoError(t, err)
+	aliceDkgMessage, err = EncodeAliceDkgOutput(aliceOutput, protocol.Version2)
+	require.NoError(t, err)
+	bobDkgMessage, err = EncodeBobDkgOutput(bobOutput, protocol.Version2)
+	require.NoError(t, err)
+	signWithVersion(t, curves.K256(), aliceDkgMessage, bobDkgMessage, protocol.Version2)
+}
+
+func TestNegotiateVersion(t *testing.T) {
+	version, err := protocol.NegotiateVersion(SupportedVersions, []uint{protocol.Version1})
+	require.NoError(t, err)
+	require.Equal(t, uint(protocol.Version1), version)
+	version, err = protocol.NegotiateVersion(SupportedVersions, SupportedVersions)
+	require.NoError(t, err)
+	require.Equal(t, uint(protocol.Version2), version)
+}
@@ -30,6 +30,8 @@ type ParticipantData struct {
 	KeyGenType     KeyGenType
 	PublicShares   map[uint32]*PublicShare
 	PublicKeys     map[uint32]*paillier.PublicKey
+	// ChainCode is the BIP-32 chain code of the key, see Derive
+	ChainCode []byte
 }
 
 type ParticipantDataJson struct {
@@ -42,6 +44,7 @@ type ParticipantDataJson struct {
 	ParticipantParams map[uint32]*ProofParams
 	PublicShares      map[uint32]*PublicShare
 	PublicKeys        map[uint32]*paillier.PublicKey
+	ChainCode         []byte `json:",omitempty"`
 }
 
 func (pd ParticipantData) MarshalJSON() ([]byte, error) {
@@ -52,6 +55,7 @@ func (pd ParticipantData) MarshalJSON() ([]byte, error) {
 		EcdsaPublicKey: pd.EcdsaPublicKey,
 		PublicShares:   pd.PublicShares,
 		PublicKeys:     pd.PublicKeys,
+		ChainCode:      pd.ChainCode,
 	}
 	if pd.KeyGenType.IsTrustedDealer() {
 		data.DealerParams = pd.KeyGenType.GetProofParams(0)
@@ -81,6 +85,7 @@ func (pd *ParticipantData) UnmarshalJSON(bytes []byte) error {
 	pd.ShamirShare = data.ShamirShare
 	pd.PublicShares = data.PublicShares
 	pd.EcdsaPublicKey = data.EcdsaPublicKey
+	pd.ChainCode = data.ChainCode
 	return nil
 }
 
@@ -0,0 +1,79 @@
+//
+// Copyright Coinbase, Inc. All Rights Reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package dealer
+
+import (
+	"fmt"
+
+	"github.com/nerifnetwork/kryptology/internal"
+	"github.com/nerifnetwork/kryptology/pkg/core/curves"
+	v1 "github.com/nerifnetwork/kryptology/pkg/sharing/v1"
+	"github.com/nerifnetwork/kryptology/pkg/tecdsa/bip32"
+)
+
+// Derive returns the participant data for the non-hardened BIP-32 child of the ECDSA public key at path.
+// The tweak t of the path is added to the Shamir share and tG to the public key and every public share.
+// The shares then lie on the polynomial of the child secret key, which is the constant term, and since the
+// Lagrange coefficients of any set of signers sum up to one the signers need no further interaction.
+// Every participant derives its own data for the same path.
+func (pd *ParticipantData) Derive(path []uint32) (*ParticipantData, error) {
+	if pd == nil || pd.ShamirShare == nil || pd.ShamirShare.Value == nil ||
+		pd.EcdsaPublicKey == nil || pd.EcdsaPublicKey.Curve == nil {
+		return nil, internal.ErrNilArguments
+	}
+	if len(pd.ChainCode) == 0 {
+		return nil, fmt.Errorf("participant data has no chain code")
+	}
+	curve := curves.GetCurveByName(pd.EcdsaPublicKey.Curve.Params().Name)
+	if curve == nil {
+		return nil, fmt.Errorf("unsupported curve %s", pd.EcdsaPublicKey.Curve.Params().Name)
+	}
+	publicKey, err := curve.Point.Set(pd.EcdsaPublicKey.X, pd.EcdsaPublicKey.Y)
+	if err != nil {
+		return nil, err
+	}
+	derivation, err := bip32.Derive(publicKey, pd.ChainCode, path)
+	if err != nil {
+		return nil, err
+	}
+
+	tweak := derivation.Tweak.BigInt()
+	tweakPoint, err := curves.NewScalarBaseMult(pd.EcdsaPublicKey.Curve, tweak)
+	if err != nil {
+		return nil, err
+	}
+	ecdsaPublicKey, err := pd.EcdsaPublicKey.Add(tweakPoint)
+	if err != nil {
+		return nil, err
+	}
+	publicShares := make(map[uint32]*PublicShare, len(pd.PublicShares))
+	for id, share := range pd.PublicShares {
+		if share == nil || share.Point == nil {
+			return nil, fmt.Errorf("missing public share for participant %d", id)
+		}
+		point, err := share.Point.Add(tweakPoint)
+		if err != nil {
+			return nil, err
+		}
+		publicShares[id] = &PublicShare{point}
+	}
+	field := pd.ShamirShare.Value.Modulus
+
+	return &ParticipantData{
+		Id:        pd.Id,
+		SecretKey: pd.SecretKey,
+		ShamirShare: &v1.ShamirShare{
+			Identifier: pd.ShamirShare.Identifier,
+			Value:      pd.ShamirShare.Value.Add(field.NewElement(tweak)),
+		},
+		EcdsaPublicKey: ecdsaPublicKey,
+		KeyGenType:     pd.KeyGenType,
+		PublicShares:   publicShares,
+		PublicKeys:     pd.PublicKeys,
+		ChainCode:      derivation.ChainCode,
+	}, nil
+}
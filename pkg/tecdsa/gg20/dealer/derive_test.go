@@ -0,0 +1,116 @@
+//
+// Copyright Coinbase, Inc. All Rights Reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package dealer
+
+import (
+	"crypto/elliptic"
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcec"
+	"github.com/stretchr/testify/require"
+
+	"github.com/nerifnetwork/kryptology/pkg/core/curves"
+	v1 "github.com/nerifnetwork/kryptology/pkg/sharing/v1"
+	"github.com/nerifnetwork/kryptology/pkg/tecdsa/bip32"
+)
+
+func newTestParticipantData(t *testing.T, curve elliptic.Curve, ikm *big.Int) map[uint32]*ParticipantData {
+	pk, sharesMap, err := NewDealerShares(curve, 2, 3, ikm)
+	require.NoError(t, err)
+	publicShares, err := PreparePublicShares(sharesMap)
+	require.NoError(t, err)
+	chainCode, err := bip32.NewChainCode()
+	require.NoError(t, err)
+	data := make(map[uint32]*ParticipantData, len(sharesMap))
+	for id, share := range sharesMap {
+		data[id] = &ParticipantData{
+			Id:             id,
+			ShamirShare:    share.ShamirShare,
+			EcdsaPublicKey: pk,
+			PublicShares:   publicShares,
+			ChainCode:      chainCode,
+		}
+	}
+	return data
+}
+
+func TestDerive(t *testing.T) {
+	for _, curve := range []elliptic.Curve{btcec.S256(), elliptic.P256()} {
+		ikm, err := NewSecret(curve)
+		require.NoError(t, err)
+		data := newTestParticipantData(t, curve, ikm)
+
+		derived := make(map[uint32]*ParticipantData, len(data))
+		for id, pd := range data {
+			derived[id], err = pd.Derive([]uint32{44, 0, 7})
+			require.NoError(t, err)
+			require.False(t, derived[id].EcdsaPublicKey.Equals(pd.EcdsaPublicKey))
+		}
+		for _, pd := range derived {
+			require.Equal(t, derived[1].ChainCode, pd.ChainCode)
+			require.True(t, derived[1].EcdsaPublicKey.Equals(pd.EcdsaPublicKey))
+		}
+
+		// The public shares match the derived shares
+		for id, pd := range derived {
+			point, err := curves.NewScalarBaseMult(curve, pd.ShamirShare.Value.BigInt())
+			require.NoError(t, err)
+			require.True(t, point.Equals(derived[1].PublicShares[id].Point))
+		}
+
+		// Any two derived shares combine to the child secret key
+		combiner, err := v1.NewShamir(2, 3, curves.NewField(curve.Params().N))
+		require.NoError(t, err)
+		for _, ids := range [][]uint32{{1, 2}, {1, 3}, {2, 3}} {
+			sk, err := combiner.Combine(derived[ids[0]].ShamirShare, derived[ids[1]].ShamirShare)
+			require.NoError(t, err)
+			x, y := curve.ScalarBaseMult(sk)
+			require.Equal(t, x, derived[1].EcdsaPublicKey.X)
+			require.Equal(t, y, derived[1].EcdsaPublicKey.Y)
+		}
+
+		// The child key is the one of BIP-32
+		publicKey, err := curves.GetCurveByName(curve.Params().Name).Point.Set(data[1].EcdsaPublicKey.X, data[1].EcdsaPublicKey.Y)
+		require.NoError(t, err)
+		derivation, err := bip32.Derive(publicKey, data[1].ChainCode, []uint32{44, 0, 7})
+		require.NoError(t, err)
+		require.Equal(t, derivation.PublicKey.ToAffineUncompressed()[1:33], derived[1].EcdsaPublicKey.X.FillBytes(make([]byte, 32)))
+		require.Equal(t, derivation.ChainCode, derived[1].ChainCode)
+
+		// Deriving a derived key continues the path
+		grandChild, err := derived[2].Derive([]uint32{1})
+		require.NoError(t, err)
+		expected, err := data[2].Derive([]uint32{44, 0, 7, 1})
+		require.NoError(t, err)
+		require.True(t, expected.EcdsaPublicKey.Equals(grandChild.EcdsaPublicKey))
+		require.Equal(t, expected.ShamirShare.Value.BigInt(), grandChild.ShamirShare.Value.BigInt())
+	}
+}
+
+func TestDeriveInvalidArguments(t *testing.T) {
+	data := newTestParticipantData(t, btcec.S256(), nil)
+	var pd *ParticipantData
+	_, err := pd.Derive([]uint32{0})
+	require.Error(t, err)
+	_, err = data[1].Derive([]uint32{bip32.HardenedOffset})
+	require.Error(t, err)
+	data[1].ChainCode = nil
+	_, err = data[1].Derive([]uint32{0})
+	require.Error(t, err)
+}
+
+func TestParticipantDataChainCodeJson(t *testing.T) {
+	data := newTestParticipantData(t, btcec.S256(), nil)
+	data[1].KeyGenType = TrustedDealerKeyGenType{}
+	bytes, err := json.Marshal(data[1])
+	require.NoError(t, err)
+	decoded := new(ParticipantData)
+	require.NoError(t, json.Unmarshal(bytes, decoded))
+	require.Equal(t, data[1].ChainCode, decoded.ChainCode)
+}
@@ -0,0 +1,506 @@
+//
+// Copyright Coinbase, Inc. All Rights Reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package participant
+
+import (
+	"fmt"
+	"math/big"
+	"sort"
+
+	"github.com/nerifnetwork/kryptology/internal"
+	"github.com/nerifnetwork/kryptology/pkg/core"
+	"github.com/nerifnetwork/kryptology/pkg/core/curves"
+	"github.com/nerifnetwork/kryptology/pkg/paillier"
+)
+
+// abortRound is the round of a signer that has revealed its ephemeral values.
+// It cannot sign anymore and can only build the abort evidence.
+const abortRound = 8
+
+// AbortReveal is broadcast by every cosigner when SignRound6Full or SignPresign finds V != g
+// or ∏ S_j != y, which happens when a cosigner cheated in the MtA exchanges or broadcast a wrong
+// δ_i or S_i. It reveals the ephemeral values of the signing attempt, which is why the signer cannot
+// continue afterwards. The reveal is refused once s_i has been computed, k_i and s_i together
+// give away the share w_i. SignOutput needs no reveal, it checks every s_j against \bar{R}_j and S_j.
+// [spec] §4.2, §4.3: identifiable abort
+type AbortReveal struct {
+	// K and KNonce open the round 1 ciphertext c_i = Enc(k_i; KNonce)
+	K, KNonce *big.Int
+	// Gamma opens the round 1 commitment to Γ_i = g^{γ_i}
+	Gamma *big.Int
+	// Mta opens the MtA exchanges with each cosigner
+	Mta map[uint32]*MtaReveal
+	// Signature is the signature of the cosigner on the reveal under its additive public share
+	Signature *curves.EcdsaSignature
+}
+
+// MtaReveal opens the MtA exchanges of a cosigner i with another cosigner j
+type MtaReveal struct {
+	// Alpha is the decryption of AlphaCiphertext, the response of j to c_i with γ_j,
+	// and AlphaNonce its Paillier nonce
+	AlphaCiphertext, Alpha, AlphaNonce *big.Int
+	// Mu is the decryption of MuCiphertext, the response of j to c_i with w_j,
+	// and MuNonce its Paillier nonce
+	MuCiphertext, Mu, MuNonce *big.Int
+	// ResponseSignature is the signature of j on AlphaCiphertext and MuCiphertext
+	ResponseSignature *curves.EcdsaSignature
+	// BetaTick is the mask β' of the response of i to c_j with γ_i
+	BetaTick *big.Int
+	// Nu is g^{ν'} for the mask ν' of the response of i to c_j with w_i.
+	// ν' itself stays secret since together with the Mu of j it reveals w_i.
+	Nu *curves.EcPoint
+}
+
+// AbortTranscript holds what a cosigner broadcast during the failed signing and its reveal
+type AbortTranscript struct {
+	PaillierKey *paillier.PublicKey
+	// PublicShare is the additive public share W_i of the cosigner
+	PublicShare *curves.EcPoint
+	// Ciphertext is c_i from round 1
+	Ciphertext *big.Int
+	// Gamma is Γ_i opened in round 4
+	Gamma *curves.EcPoint
+	// Delta is δ_i and T the commitment to σ_i from round 3
+	Delta *big.Int
+	T     *curves.EcPoint
+	// Rbar is \bar{R}_i and S is S_i = R^{σ_i} from round 5
+	Rbar, S *curves.EcPoint
+	// The signatures of the cosigner on Ciphertext, Delta and T, Gamma, and Rbar and S under PublicShare
+	CiphertextSignature, DeltaSignature, GammaSignature, RbarSignature *curves.EcdsaSignature
+	Reveal                                                             *AbortReveal
+}
+
+// AbortEvidence is the record of a failed signing that identifies the cosigners who
+// misbehaved. It only holds public values and can be checked offline by anyone.
+//
+// Every value is signed by the cosigner that sent it under its additive public share, and
+// the shares must add up to PublicKey. The verifier must check that PublicKey is the key of
+// the signing group, otherwise the evidence proves nothing.
+type AbortEvidence struct {
+	PublicKey *curves.EcPoint
+	Cosigners map[uint32]*AbortTranscript
+}
+
+// Fault names a cosigner whose broadcasts or reveal are inconsistent and the check it failed
+type Fault struct {
+	Culprit uint32
+	Reason  string
+}
+
+// SignAbortReveal reveals the ephemeral values of this signer after SignRound6Full or
+// SignPresign failed with V != g or ∏ S_j != y. The reveal must be broadcast to all cosigners.
+func (signer *Signer) SignAbortReveal() (*AbortReveal, error) {
+	if signer == nil || signer.Curve == nil || signer.state == nil {
+		return nil, internal.ErrNilArguments
+	}
+	// Only the V != g and ∏ S_j != y failures are revealed, s_i is never computed after them
+	if signer.Round != 6 || !signer.state.revealable || signer.state.si != nil {
+		return nil, internal.ErrInvalidRound
+	}
+
+	q := signer.Curve.Params().N
+	reveal := &AbortReveal{
+		K:      signer.state.ki,
+		KNonce: signer.state.ri,
+		Gamma:  signer.state.gammai,
+		Mta:    make(map[uint32]*MtaReveal, len(signer.state.cosigners)),
+	}
+	for j := range signer.state.cosigners {
+		if j == signer.Id {
+			continue
+		}
+		alpha, alphaNonce, err := signer.SecretKey.DecryptWithNonce(signer.state.alphaCtj[j])
+		if err != nil {
+			return nil, err
+		}
+		mu, muNonce, err := signer.SecretKey.DecryptWithNonce(signer.state.muCtj[j])
+		if err != nil {
+			return nil, err
+		}
+		nu, err := curves.NewScalarBaseMult(signer.Curve, new(big.Int).Mod(signer.state.vuTickj[j], q))
+		if err != nil {
+			return nil, err
+		}
+		reveal.Mta[j] = &MtaReveal{
+			AlphaCiphertext:   signer.state.alphaCtj[j],
+			Alpha:             alpha,
+			AlphaNonce:        alphaNonce,
+			MuCiphertext:      signer.state.muCtj[j],
+			Mu:                mu,
+			MuNonce:           muNonce,
+			ResponseSignature: signer.state.signatures(j).response,
+			BetaTick:          signer.state.betaTickj[j],
+			Nu:                nu,
+		}
+	}
+	var err error
+	reveal.Signature, err = signer.signTranscript(reveal.digest(signer.Id, signer.state.ci))
+	if err != nil {
+		return nil, err
+	}
+
+	signer.state.reveal = reveal
+	signer.Round = abortRound
+	return reveal, nil
+}
+
+// AbortEvidence combines the broadcasts this signer received during the failed signing
+// with the reveals of all the other cosigners into the evidence of the abort.
+func (signer *Signer) AbortEvidence(reveals map[uint32]*AbortReveal) (*AbortEvidence, error) {
+	if signer == nil || signer.Curve == nil || signer.state == nil {
+		return nil, internal.ErrNilArguments
+	}
+	if err := signer.verifyStateMap(abortRound, reveals); err != nil {
+		return nil, err
+	}
+
+	evidence := &AbortEvidence{
+		PublicKey: signer.PublicKey,
+		Cosigners: make(map[uint32]*AbortTranscript, len(reveals)+1),
+	}
+	own := signer.state.signatures(signer.Id)
+	evidence.Cosigners[signer.Id] = &AbortTranscript{
+		PaillierKey:         &signer.SecretKey.PublicKey,
+		PublicShare:         signer.PublicSharesMap[signer.Id].Point,
+		Ciphertext:          signer.state.ci,
+		Gamma:               signer.state.Gammai,
+		Delta:               signer.state.deltai,
+		T:                   signer.state.Ti,
+		Rbar:                signer.state.Rbari,
+		S:                   signer.state.Si,
+		CiphertextSignature: own.ciphertext,
+		DeltaSignature:      own.delta,
+		GammaSignature:      own.gamma,
+		RbarSignature:       own.rbar,
+		Reveal:              signer.state.reveal,
+	}
+	for j, reveal := range reveals {
+		if j == signer.Id {
+			continue
+		}
+		if reveal == nil {
+			return nil, fmt.Errorf("reveal of cosigner %d cannot be nil", j)
+		}
+		// The reveal must be signed before it goes into the evidence
+		if err := reveal.validate(); err != nil {
+			return nil, fmt.Errorf("invalid reveal of cosigner %d: %v", j, err)
+		}
+		digest := reveal.digest(j, signer.state.cj[j])
+		if err := verifyTranscript(signer.PublicSharesMap[j].Point, digest, reveal.Signature); err != nil {
+			return nil, fmt.Errorf("invalid reveal of cosigner %d: %v", j, err)
+		}
+		signatures := signer.state.signatures(j)
+		evidence.Cosigners[j] = &AbortTranscript{
+			PaillierKey:         signer.state.pks[j],
+			PublicShare:         signer.PublicSharesMap[j].Point,
+			Ciphertext:          signer.state.cj[j],
+			Gamma:               signer.state.Gammaj[j],
+			Delta:               signer.state.deltaj[j],
+			T:                   signer.state.Tj[j],
+			Rbar:                signer.state.Rbarj[j],
+			S:                   signer.state.Sj[j],
+			CiphertextSignature: signatures.ciphertext,
+			DeltaSignature:      signatures.delta,
+			GammaSignature:      signatures.gamma,
+			RbarSignature:       signatures.rbar,
+			Reveal:              reveal,
+		}
+	}
+	return evidence, nil
+}
+
+// digest returns the transcript digest of the reveal of the sender with the round 1 ciphertext ci
+func (m *AbortReveal) digest(sender uint32, ci *big.Int) []byte {
+	w := new(wireWriter)
+	m.writeBody(w)
+	return transcriptDigest(m, sender, 0, ci, nil, w.buf.Bytes())
+}
+
+// Verify checks the signatures of the transcripts, the reveals against the broadcasts and
+// the MtA exchanges against the reveals of both sides, and returns the faults of the cosigners
+// that misbehaved sorted by id. An honest cosigner never has a fault. An error means the
+// evidence is incomplete or was not signed by the cosigners.
+func (e *AbortEvidence) Verify() ([]*Fault, error) {
+	if e == nil || e.PublicKey == nil || e.PublicKey.Curve == nil {
+		return nil, internal.ErrNilArguments
+	}
+	curve := e.PublicKey.Curve
+	q := curve.Params().N
+	ids, err := e.check()
+	if err != nil {
+		return nil, err
+	}
+	if err = e.verifySignatures(ids); err != nil {
+		return nil, err
+	}
+
+	faults := make(map[uint32]string, len(ids))
+	fault := func(id uint32, format string, args ...interface{}) {
+		if _, ok := faults[id]; !ok {
+			faults[id] = fmt.Sprintf(format, args...)
+		}
+	}
+
+	// R = (∏ Γ_j)^{δ^{-1}} from the broadcast values
+	var R *curves.EcPoint
+	gammas := make([]*curves.EcPoint, 0, len(ids))
+	delta := new(big.Int)
+	for _, id := range ids {
+		gammas = append(gammas, e.Cosigners[id].Gamma)
+		delta.Add(delta, e.Cosigners[id].Delta)
+	}
+	if deltaInv := new(big.Int).ModInverse(delta.Mod(delta, q), q); deltaInv != nil {
+		gamma, err := sumPoints(gammas...)
+		if err != nil {
+			return nil, err
+		}
+		if R, err = gamma.ScalarMult(deltaInv); err != nil {
+			return nil, err
+		}
+	}
+
+	// 1. The reveal of every cosigner must open its own broadcasts
+	for _, i := range ids {
+		t := e.Cosigners[i]
+		reveal := t.Reveal
+		if core.In(reveal.K, q) != nil || !opens(t.PaillierKey, t.Ciphertext, reveal.K, reveal.KNonce) {
+			fault(i, "k_i does not open c_i")
+		}
+		if Gamma, err := curves.NewScalarBaseMult(curve, reveal.Gamma); err != nil || !Gamma.Equals(t.Gamma) {
+			fault(i, "γ_i does not open Γ_i")
+		}
+		for _, j := range ids {
+			if j == i {
+				continue
+			}
+			mta := reveal.Mta[j]
+			if !opens(t.PaillierKey, mta.AlphaCiphertext, mta.Alpha, mta.AlphaNonce) ||
+				!opens(t.PaillierKey, mta.MuCiphertext, mta.Mu, mta.MuNonce) {
+				fault(i, "invalid decryption of the MtA responses of cosigner %d", j)
+			}
+		}
+		if R != nil {
+			if Rbar, err := R.ScalarMult(reveal.K); err != nil || !Rbar.Equals(t.Rbar) {
+				fault(i, "\\bar{R}_i is not R^{k_i}")
+			}
+		}
+	}
+
+	// 2. The MtA responses received by i must match the reveal of their sender j
+	for _, i := range ids {
+		for _, j := range ids {
+			if i == j || faults[i] != "" || faults[j] != "" {
+				continue
+			}
+			ti, tj := e.Cosigners[i], e.Cosigners[j]
+			mta := ti.Reveal.Mta[j]
+
+			// α_ij = k_i γ_j + β'_ji mod N_i
+			alpha := new(big.Int).Mul(ti.Reveal.K, tj.Reveal.Gamma)
+			alpha.Add(alpha, tj.Reveal.Mta[i].BetaTick)
+			alpha.Mod(alpha, ti.PaillierKey.N)
+			if alpha.Cmp(mta.Alpha) != 0 {
+				fault(j, "MtA response to cosigner %d does not match γ_j", i)
+				continue
+			}
+
+			// g^{μ_ij} = W_j^{k_i} g^{ν'_ji}
+			lhs, err := curves.NewScalarBaseMult(curve, mta.Mu)
+			if err != nil {
+				return nil, err
+			}
+			kW, err := tj.PublicShare.ScalarMult(ti.Reveal.K)
+			if err != nil {
+				return nil, err
+			}
+			rhs, err := sumPoints(kW, tj.Reveal.Mta[i].Nu)
+			if err != nil || !lhs.Equals(rhs) {
+				fault(j, "MtA response to cosigner %d does not match w_j", i)
+			}
+		}
+	}
+
+	// 3. δ_i must match what i revealed
+	for _, i := range ids {
+		if faults[i] != "" {
+			continue
+		}
+		t := e.Cosigners[i]
+
+		// δ_i = k_i γ_i + Σ_j α_ij - β'_ij mod q
+		d := new(big.Int).Mul(t.Reveal.K, t.Reveal.Gamma)
+		for _, j := range ids {
+			if j == i {
+				continue
+			}
+			d.Add(d, t.Reveal.Mta[j].Alpha)
+			d.Sub(d, t.Reveal.Mta[j].BetaTick)
+		}
+		if d.Mod(d, q).Cmp(new(big.Int).Mod(t.Delta, q)) != 0 {
+			fault(i, "δ_i does not match the revealed values")
+		}
+	}
+
+	// 4. S_i must be R^{σ_i}. Once the reveals are consistent R = g^{k^{-1}} for k = Σ_j k_j,
+	// so S_i^k must be g^{σ_i} = W_i^{k_i} ∏_j g^{μ_ij} g^{-ν'_ij}
+	// [spec] §4.3
+	if len(faults) == 0 {
+		k := new(big.Int)
+		for _, i := range ids {
+			k.Add(k, e.Cosigners[i].Reveal.K)
+		}
+		for _, i := range ids {
+			t := e.Cosigners[i]
+			gSigma, err := t.PublicShare.ScalarMult(t.Reveal.K)
+			if err != nil {
+				return nil, err
+			}
+			for _, j := range ids {
+				if j == i {
+					continue
+				}
+				mta := t.Reveal.Mta[j]
+				gMu, err := curves.NewScalarBaseMult(curve, mta.Mu)
+				if err != nil {
+					return nil, err
+				}
+				nuInv, err := mta.Nu.Neg()
+				if err != nil {
+					return nil, err
+				}
+				if gSigma, err = sumPoints(gSigma, gMu, nuInv); err != nil {
+					return nil, err
+				}
+			}
+			if Sk, err := t.S.ScalarMult(k); err != nil || !Sk.Equals(gSigma) {
+				fault(i, "S_i is not R^{σ_i}")
+			}
+		}
+	}
+
+	result := make([]*Fault, 0, len(faults))
+	for id, reason := range faults {
+		result = append(result, &Fault{Culprit: id, Reason: reason})
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Culprit < result[j].Culprit })
+	return result, nil
+}
+
+// verifySignatures checks that the public shares add up to the public key and that every
+// value of the evidence is signed by the cosigner that sent it
+func (e *AbortEvidence) verifySignatures(ids []uint32) error {
+	shares := make([]*curves.EcPoint, 0, len(ids))
+	for _, id := range ids {
+		shares = append(shares, e.Cosigners[id].PublicShare)
+	}
+	sum, err := sumPoints(shares...)
+	if err != nil {
+		return err
+	}
+	if !sum.Equals(e.PublicKey) {
+		return fmt.Errorf("public shares do not add up to the public key")
+	}
+
+	for _, i := range ids {
+		t := e.Cosigners[i]
+		signed := []struct {
+			name      string
+			digest    []byte
+			signature *curves.EcdsaSignature
+		}{
+			{"c_i", transcriptDigest(&Round1Bcast{}, i, 0, t.Ciphertext, nil), t.CiphertextSignature},
+			{"δ_i", transcriptDigest(&Round3Bcast{}, i, 0, t.Ciphertext, nil, t.Delta.Bytes(), t.T.Bytes()), t.DeltaSignature},
+			{"Γ_i", transcriptDigest(&Round4Bcast{}, i, 0, t.Ciphertext, nil, t.Gamma.Bytes()), t.GammaSignature},
+			{"\\bar{R}_i", transcriptDigest(&Round5Bcast{}, i, 0, t.Ciphertext, nil, t.Rbar.Bytes(), t.S.Bytes()), t.RbarSignature},
+			{"the reveal", t.Reveal.digest(i, t.Ciphertext), t.Reveal.Signature},
+		}
+		for _, v := range signed {
+			if err := verifyTranscript(t.PublicShare, v.digest, v.signature); err != nil {
+				return fmt.Errorf("%s of cosigner %d: %v", v.name, i, err)
+			}
+		}
+		// The MtA responses received by i are signed by their sender j
+		for _, j := range ids {
+			if j == i {
+				continue
+			}
+			mta := t.Reveal.Mta[j]
+			digest := transcriptDigest(&Round2P2PSend{}, j, i, e.Cosigners[j].Ciphertext, t.Ciphertext,
+				mta.AlphaCiphertext.Bytes(), mta.MuCiphertext.Bytes())
+			if err := verifyTranscript(e.Cosigners[j].PublicShare, digest, mta.ResponseSignature); err != nil {
+				return fmt.Errorf("MtA responses of cosigner %d to cosigner %d: %v", j, i, err)
+			}
+		}
+	}
+	return nil
+}
+
+// check ensures the evidence is complete and returns the sorted cosigner ids
+func (e *AbortEvidence) check() ([]uint32, error) {
+	if len(e.Cosigners) < 2 {
+		return nil, fmt.Errorf("evidence needs at least two cosigners")
+	}
+	ids := make([]uint32, 0, len(e.Cosigners))
+	for id := range e.Cosigners {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+
+	for _, id := range ids {
+		t := e.Cosigners[id]
+		if t == nil || t.PaillierKey == nil || t.PublicShare == nil || t.Ciphertext == nil ||
+			t.Gamma == nil || t.Delta == nil || t.T == nil || t.Rbar == nil || t.S == nil || t.Reveal == nil {
+			return nil, fmt.Errorf("incomplete transcript of cosigner %d", id)
+		}
+		if t.PaillierKey.N == nil || t.PaillierKey.N2 == nil {
+			return nil, fmt.Errorf("invalid paillier key of cosigner %d", id)
+		}
+		r := t.Reveal
+		if r.K == nil || r.KNonce == nil || r.Gamma == nil || len(r.Mta) != len(ids)-1 {
+			return nil, fmt.Errorf("incomplete reveal of cosigner %d", id)
+		}
+		for _, j := range ids {
+			if j == id {
+				continue
+			}
+			mta := r.Mta[j]
+			if mta == nil || mta.AlphaCiphertext == nil || mta.Alpha == nil || mta.AlphaNonce == nil ||
+				mta.MuCiphertext == nil || mta.Mu == nil || mta.MuNonce == nil || checkSignature(mta.ResponseSignature) != nil ||
+				mta.BetaTick == nil || mta.Nu == nil {
+				return nil, fmt.Errorf("incomplete MtA reveal of cosigner %d for cosigner %d", id, j)
+			}
+		}
+	}
+	return ids, nil
+}
+
+// opens reports whether c = Enc_pk(m; r)
+func opens(pk *paillier.PublicKey, c, m, r *big.Int) bool {
+	ct, err := pk.EncryptWithNonce(m, r)
+	return err == nil && (*big.Int)(ct).Cmp(c) == 0
+}
+
+func sumPoints(points ...*curves.EcPoint) (*curves.EcPoint, error) {
+	var sum *curves.EcPoint
+	for _, p := range points {
+		if p == nil {
+			return nil, internal.ErrNilArguments
+		}
+		if sum == nil {
+			sum = p
+			continue
+		}
+		var err error
+		if sum, err = sum.Add(p); err != nil {
+			return nil, err
+		}
+	}
+	if sum == nil {
+		return nil, fmt.Errorf("no points to add")
+	}
+	return sum, nil
+}
@@ -0,0 +1,524 @@
+//
+// Copyright Coinbase, Inc. All Rights Reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package participant
+
+import (
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcec"
+	"github.com/stretchr/testify/require"
+
+	"github.com/nerifnetwork/kryptology/pkg/tecdsa/gg20/proof"
+)
+
+// signingTamper changes the outputs of the signers before they are delivered
+type signingTamper struct {
+	round2 func(id uint32, p2p map[uint32]*Round2P2PSend)
+	round3 func(id uint32, bcast *Round3Bcast)
+	round5 func(id uint32, bcast *Round5Bcast)
+	round6 func(id uint32, bcast *Round6FullBcast)
+	// wire is called with every message before it is delivered
+	wire func(value interface{})
+}
+
+func (tamper signingTamper) deliver(values ...interface{}) {
+	if tamper.wire == nil {
+		return
+	}
+	for _, v := range values {
+		tamper.wire(v)
+	}
+}
+
+// runSigningRounds1To5 runs signing rounds 1 to 5 between the signers and
+// returns the round 6 inputs of each signer
+func runSigningRounds1To5(t *testing.T, signers map[uint32]*Signer, tamper signingTamper) (map[uint32]map[uint32]*Round5Bcast, map[uint32]map[uint32]*Round5P2PSend) {
+	var distributed bool
+	for _, s := range signers {
+		distributed = !s.state.keyGenType.IsTrustedDealer()
+	}
+
+	r1Bcast := make(map[uint32]*Round1Bcast, len(signers))
+	r1P2P := make(map[uint32]map[uint32]*Round1P2PSend, len(signers))
+	for id, s := range signers {
+		var err error
+		r1Bcast[id], r1P2P[id], _, err = s.SignRound1()
+		require.NoError(t, err)
+		tamper.deliver(r1Bcast[id])
+		for _, m := range r1P2P[id] {
+			tamper.deliver(m)
+		}
+	}
+
+	r2P2P := make(map[uint32]map[uint32]*Round2P2PSend, len(signers))
+	for id, s := range signers {
+		bcast := make(map[uint32]*Round1Bcast)
+		var p2p map[uint32]*Round1P2PSend
+		if distributed {
+			p2p = make(map[uint32]*Round1P2PSend)
+		}
+		for j := range signers {
+			if j != id {
+				bcast[j] = r1Bcast[j]
+				if distributed {
+					p2p[j] = r1P2P[j][id]
+				}
+			}
+		}
+		var err error
+		r2P2P[id], _, err = s.SignRound2(bcast, p2p)
+		require.NoError(t, err)
+		if tamper.round2 != nil {
+			tamper.round2(id, r2P2P[id])
+		}
+		for _, m := range r2P2P[id] {
+			tamper.deliver(m)
+		}
+	}
+
+	r3Bcast := make(map[uint32]*Round3Bcast, len(signers))
+	for id, s := range signers {
+		in := make(map[uint32]*Round2P2PSend)
+		for j := range signers {
+			if j != id {
+				in[j] = r2P2P[j][id]
+			}
+		}
+		var err error
+		r3Bcast[id], _, err = s.SignRound3(in)
+		require.NoError(t, err)
+		if tamper.round3 != nil {
+			tamper.round3(id, r3Bcast[id])
+		}
+		tamper.deliver(r3Bcast[id])
+	}
+
+	r4Bcast := make(map[uint32]*Round4Bcast, len(signers))
+	for id, s := range signers {
+		in := make(map[uint32]*Round3Bcast)
+		for j := range signers {
+			if j != id {
+				in[j] = r3Bcast[j]
+			}
+		}
+		var err error
+		r4Bcast[id], _, err = s.SignRound4(in)
+		require.NoError(t, err)
+		tamper.deliver(r4Bcast[id])
+	}
+
+	r5Bcast := make(map[uint32]*Round5Bcast, len(signers))
+	r5P2P := make(map[uint32]map[uint32]*Round5P2PSend, len(signers))
+	for id, s := range signers {
+		in := make(map[uint32]*Round4Bcast)
+		for j := range signers {
+			if j != id {
+				in[j] = r4Bcast[j]
+			}
+		}
+		var err error
+		r5Bcast[id], r5P2P[id], _, err = s.SignRound5(in)
+		require.NoError(t, err)
+		if tamper.round5 != nil {
+			tamper.round5(id, r5Bcast[id])
+		}
+		tamper.deliver(r5Bcast[id])
+		for _, m := range r5P2P[id] {
+			tamper.deliver(m)
+		}
+	}
+
+	inBcast := make(map[uint32]map[uint32]*Round5Bcast, len(signers))
+	inP2P := make(map[uint32]map[uint32]*Round5P2PSend, len(signers))
+	for id := range signers {
+		inBcast[id] = make(map[uint32]*Round5Bcast)
+		if distributed {
+			inP2P[id] = make(map[uint32]*Round5P2PSend)
+		}
+		for j := range signers {
+			if j != id {
+				inBcast[id][j] = r5Bcast[j]
+				if distributed {
+					inP2P[id][j] = r5P2P[j][id]
+				}
+			}
+		}
+	}
+	return inBcast, inP2P
+}
+
+// runTamperedSigning runs all signing rounds between the signers and returns
+// the error of each signer in round 6 or in the output round
+func runTamperedSigning(t *testing.T, signers map[uint32]*Signer, hash []byte, tamper signingTamper) map[uint32]error {
+	inBcast, inP2P := runSigningRounds1To5(t, signers, tamper)
+
+	errs := make(map[uint32]error, len(signers))
+	r6Bcast := make(map[uint32]*Round6FullBcast, len(signers))
+	for id, s := range signers {
+		r6Bcast[id], _, errs[id] = s.SignRound6Full(hash, inBcast[id], inP2P[id])
+		if errs[id] == nil && tamper.round6 != nil {
+			tamper.round6(id, r6Bcast[id])
+		}
+		if errs[id] == nil {
+			tamper.deliver(r6Bcast[id])
+		}
+	}
+	if len(r6Bcast) != len(signers) {
+		return errs
+	}
+	for id, s := range signers {
+		if errs[id] != nil {
+			continue
+		}
+		in := make(map[uint32]*Round6FullBcast)
+		for j := range signers {
+			if j != id {
+				in[j] = r6Bcast[j]
+			}
+		}
+		_, _, errs[id] = s.SignOutput(in)
+	}
+	return errs
+}
+
+// abortEvidence exchanges the reveals of all signers and returns the evidence built by the signer with the id
+func abortEvidence(t *testing.T, signers map[uint32]*Signer, id uint32) *AbortEvidence {
+	reveals := make(map[uint32]*AbortReveal, len(signers))
+	for j, s := range signers {
+		var err error
+		reveals[j], err = s.SignAbortReveal()
+		require.NoError(t, err)
+	}
+	delete(reveals, id)
+	evidence, err := signers[id].AbortEvidence(reveals)
+	require.NoError(t, err)
+	return evidence
+}
+
+func requireCulprits(t *testing.T, evidence *AbortEvidence, culprits ...uint32) []*Fault {
+	faults, err := evidence.Verify()
+	require.NoError(t, err)
+	ids := make([]uint32, len(faults))
+	for i, f := range faults {
+		ids[i] = f.Culprit
+	}
+	require.Equal(t, culprits, ids, "faults %v", faults)
+	return faults
+}
+
+var abortTestHash = []byte{
+	0x73, 0xf0, 0x9a, 0x91, 0x30, 0x4c, 0x8b, 0xd8, 0x7e, 0x04, 0x27, 0x8a, 0x86, 0x1e, 0x58, 0x42,
+	0x0c, 0x81, 0x83, 0xab, 0x27, 0x43, 0x41, 0x28, 0x46, 0x79, 0x09, 0x0f, 0x13, 0x6b, 0x6a, 0x9d,
+}
+
+// cheatDelta makes the cheater broadcast δ_i + 1 and use it itself, so that every
+// cosigner derives the same R and signing fails with V != g
+func cheatDelta(t *testing.T, signers map[uint32]*Signer, cheater uint32) func(id uint32, bcast *Round3Bcast) {
+	return func(id uint32, bcast *Round3Bcast) {
+		if id != cheater {
+			return
+		}
+		s := signers[id]
+		s.state.deltai = new(big.Int).Add(bcast.DeltaElement, big.NewInt(1))
+		bcast.DeltaElement = s.state.deltai
+		var err error
+		bcast.Signature, err = s.signTranscript(transcriptDigest(bcast, id, 0, s.state.ci, nil, bcast.DeltaElement.Bytes(), bcast.T.Bytes()))
+		require.NoError(t, err)
+		s.state.signatures(id).delta = bcast.Signature
+	}
+}
+
+// resignReveal signs a changed reveal again as the cosigner
+func resignReveal(t *testing.T, s *Signer, reveal *AbortReveal) {
+	var err error
+	reveal.Signature, err = s.signTranscript(reveal.digest(s.Id, s.state.ci))
+	require.NoError(t, err)
+}
+
+func TestAbortIdentifiesWrongDelta(t *testing.T) {
+	for _, useDistributed := range []bool{false, true} {
+		_, signers := setupSignersMap(t, btcec.S256(), 3, 5, false, k256Verifier, useDistributed)
+		errs := runTamperedSigning(t, signers, abortTestHash, signingTamper{
+			round3: cheatDelta(t, signers, 2),
+		})
+		for _, err := range errs {
+			require.ErrorContains(t, err, "v != g")
+		}
+
+		evidence := abortEvidence(t, signers, 1)
+		faults := requireCulprits(t, evidence, 2)
+		require.Contains(t, faults[0].Reason, "δ_i")
+
+		// The evidence can be checked offline
+		data, err := json.Marshal(evidence)
+		require.NoError(t, err)
+		decoded := new(AbortEvidence)
+		require.NoError(t, json.Unmarshal(data, decoded))
+		requireCulprits(t, decoded, 2)
+	}
+}
+
+// cheatSigma makes the cheater use σ_i + 1 from round 3 on, so that its commitment T_i
+// and S_i are consistent but the S_j do not add up to the public key
+func cheatSigma(t *testing.T, signers map[uint32]*Signer, cheater uint32) func(id uint32, bcast *Round3Bcast) {
+	return func(id uint32, bcast *Round3Bcast) {
+		if id != cheater {
+			return
+		}
+		s := signers[id]
+		s.state.sigmai = new(big.Int).Add(s.state.sigmai, big.NewInt(1))
+		var err error
+		s.state.Ti, err = commitSigma(s.Curve, s.state.sigmai, s.state.li)
+		require.NoError(t, err)
+		bcast.T = s.state.Ti
+		bcast.Signature, err = s.signTranscript(transcriptDigest(bcast, id, 0, s.state.ci, nil, bcast.DeltaElement.Bytes(), bcast.T.Bytes()))
+		require.NoError(t, err)
+		s.state.signatures(id).delta = bcast.Signature
+	}
+}
+
+func TestAbortRevealRefusedAfterS(t *testing.T) {
+	_, signers := setupSignersMap(t, btcec.S256(), 3, 5, false, k256Verifier, false)
+	errs := runTamperedSigning(t, signers, abortTestHash, signingTamper{
+		round6: func(id uint32, bcast *Round6FullBcast) {
+			if id == 3 {
+				bcast.SElement = new(big.Int).Add(bcast.SElement, big.NewInt(1))
+			}
+		},
+	})
+	// Signer 3 uses its own s_3, the others find that s_3 is wrong
+	require.NoError(t, errs[3])
+	require.ErrorContains(t, errs[1], "3")
+	require.ErrorContains(t, errs[2], "3")
+
+	// k_i and s_i together reveal w_i, the nonces stay secret once s_i is known
+	for _, s := range signers {
+		_, err := s.SignAbortReveal()
+		require.Error(t, err)
+	}
+}
+
+func TestAbortIdentifiesCheatingMta(t *testing.T) {
+	_, signers := setupSignersMap(t, btcec.S256(), 3, 5, false, k256Verifier, false)
+	cheater := signers[2]
+	errs := runTamperedSigning(t, signers, abortTestHash, signingTamper{
+		// Signer 2 answers the MtA of signer 1 with γ_2 + 1 instead of γ_2
+		round2: func(id uint32, p2p map[uint32]*Round2P2PSend) {
+			if id != 2 {
+				return
+			}
+			rpp := proof.ResponseProofParams{
+				Curve:        cheater.Curve,
+				DealerParams: cheater.state.keyGenType.GetProofParams(1),
+				Pk:           cheater.state.pks[1],
+				C1:           cheater.state.cj[1],
+				SmallB:       new(big.Int).Add(cheater.state.gammai, big.NewInt(1)),
+			}
+			response, err := rpp.Prove()
+			require.NoError(t, err)
+			response.Beta = nil
+			response.BetaTick = nil
+			p2p[1].Proof2 = response
+			digest := transcriptDigest(p2p[1], 2, 1, cheater.state.ci, cheater.state.cj[1],
+				response.C2.Bytes(), p2p[1].Proof3.C2.Bytes())
+			p2p[1].Signature, err = cheater.signTranscript(digest)
+			require.NoError(t, err)
+		},
+	})
+	for _, err := range errs {
+		require.ErrorContains(t, err, "v != g")
+	}
+
+	faults := requireCulprits(t, abortEvidence(t, signers, 3), 2)
+	require.Contains(t, faults[0].Reason, "cosigner 1")
+}
+
+func TestAbortIdentifiesFalseReveal(t *testing.T) {
+	_, signers := setupSignersMap(t, btcec.S256(), 3, 5, false, k256Verifier, false)
+	errs := runTamperedSigning(t, signers, abortTestHash, signingTamper{
+		round3: cheatDelta(t, signers, 3),
+	})
+	for _, err := range errs {
+		require.Error(t, err)
+	}
+
+	reveals := make(map[uint32]*AbortReveal, len(signers))
+	for j, s := range signers {
+		if j != 1 {
+			var err error
+			reveals[j], err = s.SignAbortReveal()
+			require.NoError(t, err)
+		}
+	}
+	_, err := signers[1].SignAbortReveal()
+	require.NoError(t, err)
+
+	// Signer 2 lies about k_2 and the mask of its response to signer 1
+	reveal := reveals[2]
+	reveal.K = new(big.Int).Add(reveal.K, big.NewInt(1))
+	resignReveal(t, signers[2], reveal)
+	evidence, err := signers[1].AbortEvidence(reveals)
+	require.NoError(t, err)
+	faults := requireCulprits(t, evidence, 2, 3)
+	require.Contains(t, faults[0].Reason, "k_i")
+
+	reveal.K = new(big.Int).Sub(reveal.K, big.NewInt(1))
+	reveal.Mta[1].BetaTick = new(big.Int).Add(reveal.Mta[1].BetaTick, big.NewInt(1))
+	resignReveal(t, signers[2], reveal)
+	evidence, err = signers[1].AbortEvidence(reveals)
+	require.NoError(t, err)
+	faults = requireCulprits(t, evidence, 2, 3)
+	require.Contains(t, faults[0].Reason, "cosigner 1")
+
+	// Incomplete evidence cannot be checked
+	delete(reveal.Mta, 1)
+	_, err = evidence.Verify()
+	require.Error(t, err)
+	delete(evidence.Cosigners, 2)
+	delete(evidence.Cosigners, 3)
+	_, err = evidence.Verify()
+	require.Error(t, err)
+}
+
+func TestAbortEvidenceForged(t *testing.T) {
+	_, signers := setupSignersMap(t, btcec.S256(), 3, 5, false, k256Verifier, false)
+	runTamperedSigning(t, signers, abortTestHash, signingTamper{
+		round3: cheatDelta(t, signers, 3),
+	})
+	evidence := abortEvidence(t, signers, 3)
+	requireCulprits(t, evidence, 3)
+
+	// The cheater cannot blame an honest cosigner for values it did not sign
+	delta := evidence.Cosigners[1].Delta
+	evidence.Cosigners[1].Delta = new(big.Int).Add(delta, big.NewInt(1))
+	_, err := evidence.Verify()
+	require.ErrorContains(t, err, "signature")
+	evidence.Cosigners[1].Delta = delta
+
+	alpha := evidence.Cosigners[3].Reveal.Mta[2].AlphaCiphertext
+	evidence.Cosigners[3].Reveal.Mta[2].AlphaCiphertext = new(big.Int).Add(alpha, big.NewInt(1))
+	_, err = evidence.Verify()
+	require.ErrorContains(t, err, "signature")
+	evidence.Cosigners[3].Reveal.Mta[2].AlphaCiphertext = alpha
+
+	// Nor replace its public share with a key of its choice
+	share := evidence.Cosigners[2].PublicShare
+	evidence.Cosigners[2].PublicShare = evidence.Cosigners[3].PublicShare
+	_, err = evidence.Verify()
+	require.ErrorContains(t, err, "public key")
+	evidence.Cosigners[2].PublicShare = share
+	requireCulprits(t, evidence, 3)
+
+	// An unsigned reveal is not accepted into the evidence
+	reveals := map[uint32]*AbortReveal{1: evidence.Cosigners[1].Reveal, 2: evidence.Cosigners[2].Reveal}
+	reveals[2].Signature = reveals[1].Signature
+	_, err = signers[3].AbortEvidence(reveals)
+	require.Error(t, err)
+}
+
+func TestAbortRevealRounds(t *testing.T) {
+	_, signers := setupSignersMap(t, btcec.S256(), 3, 5, false, k256Verifier, false)
+	_, err := signers[1].SignAbortReveal()
+	require.Error(t, err)
+	_, err = signers[1].AbortEvidence(nil)
+	require.Error(t, err)
+
+	errs := runTamperedSigning(t, signers, abortTestHash, signingTamper{
+		round3: cheatDelta(t, signers, 1),
+	})
+	require.Error(t, errs[2])
+	_, err = signers[2].SignAbortReveal()
+	require.NoError(t, err)
+
+	// The nonce was revealed, signing cannot go on
+	_, _, err = signers[2].SignRound6Full(abortTestHash, nil, nil)
+	require.Error(t, err)
+	_, err = signers[2].SignAbortReveal()
+	require.Error(t, err)
+
+	// Every cosigner must reveal
+	reveal, err := signers[1].SignAbortReveal()
+	require.NoError(t, err)
+	_, err = signers[2].AbortEvidence(map[uint32]*AbortReveal{1: reveal})
+	require.Error(t, err)
+}
+
+func TestSigningRejectsUnsignedMessages(t *testing.T) {
+	_, signers := setupSignersMap(t, btcec.S256(), 3, 5, false, k256Verifier, false)
+	bcast := make(map[uint32]*Round1Bcast, len(signers))
+	for id, s := range signers {
+		var err error
+		bcast[id], _, _, err = s.SignRound1()
+		require.NoError(t, err)
+	}
+
+	// A ciphertext that its sender did not sign cannot end up in the abort evidence
+	forged := *bcast[1]
+	forged.Signature = bcast[3].Signature
+	_, failed, err := signers[2].SignRound2(map[uint32]*Round1Bcast{1: &forged, 3: bcast[3]}, nil)
+	require.Error(t, err)
+	require.Equal(t, []uint32{1}, failed)
+}
+
+func TestSignOutputIdentifiesWrongShare(t *testing.T) {
+	for _, useDistributed := range []bool{false, true} {
+		_, signers := setupSignersMap(t, btcec.S256(), 3, 5, false, k256Verifier, useDistributed)
+		inBcast, inP2P := runSigningRounds1To5(t, signers, signingTamper{})
+		r6Bcast := make(map[uint32]*Round6FullBcast, len(signers))
+		for id, s := range signers {
+			var err error
+			r6Bcast[id], _, err = s.SignRound6Full(abortTestHash, inBcast[id], inP2P[id])
+			require.NoError(t, err)
+		}
+		// Signer 2 broadcasts a wrong s_2, signer 3 an s_3 for another hash
+		r6Bcast[2].SElement = new(big.Int).Add(r6Bcast[2].SElement, big.NewInt(1))
+		r6Bcast[3].SElement = new(big.Int).Add(r6Bcast[3].SElement, signers[3].state.ki)
+
+		_, failed, err := signers[1].SignOutput(map[uint32]*Round6FullBcast{2: r6Bcast[2], 3: r6Bcast[3]})
+		require.Error(t, err)
+		require.ElementsMatch(t, []uint32{2, 3}, failed)
+	}
+}
+
+func TestAbortIdentifiesWrongS(t *testing.T) {
+	for _, useDistributed := range []bool{false, true} {
+		_, signers := setupSignersMap(t, btcec.S256(), 3, 5, false, k256Verifier, useDistributed)
+		errs := runTamperedSigning(t, signers, abortTestHash, signingTamper{
+			round3: cheatSigma(t, signers, 3),
+		})
+		for _, err := range errs {
+			require.ErrorContains(t, err, "S != y")
+		}
+
+		faults := requireCulprits(t, abortEvidence(t, signers, 1), 3)
+		require.Contains(t, faults[0].Reason, "S_i")
+	}
+}
+
+func TestSignRound6RejectsInconsistentS(t *testing.T) {
+	_, signers := setupSignersMap(t, btcec.S256(), 3, 5, false, k256Verifier, false)
+	inBcast, inP2P := runSigningRounds1To5(t, signers, signingTamper{
+		// Signer 2 broadcasts an S_2 that does not hide the σ_2 of T_2
+		round5: func(id uint32, bcast *Round5Bcast) {
+			if id != 2 {
+				return
+			}
+			s := signers[id]
+			var err error
+			bcast.S, err = bcast.S.Add(s.state.R)
+			require.NoError(t, err)
+			digest := transcriptDigest(bcast, id, 0, s.state.ci, nil, bcast.Rbar.Bytes(), bcast.S.Bytes())
+			bcast.Signature, err = s.signTranscript(digest)
+			require.NoError(t, err)
+		},
+	})
+	_, failed, err := signers[1].SignRound6Full(abortTestHash, inBcast[1], inP2P[1])
+	require.Error(t, err)
+	require.Equal(t, []uint32{2}, failed)
+}
@@ -0,0 +1,112 @@
+//
+// Copyright Coinbase, Inc. All Rights Reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package participant
+
+import (
+	"crypto/elliptic"
+	"encoding/binary"
+	"io"
+	"math/big"
+	"sort"
+
+	"github.com/nerifnetwork/kryptology/internal"
+	"github.com/nerifnetwork/kryptology/pkg/core/curves"
+	"github.com/nerifnetwork/kryptology/pkg/dkg/channel"
+	v1 "github.com/nerifnetwork/kryptology/pkg/sharing/v1"
+)
+
+// SealDkgRound2P2PSend encrypts each share of DkgRound2 to its recipient
+func (dp *DkgParticipant) SealDkgRound2P2PSend(ch *channel.Channel, p2p map[uint32]*DkgRound2P2PSend, reader io.Reader) (map[uint32]*channel.Envelope, error) {
+	if ch == nil || p2p == nil {
+		return nil, internal.ErrNilArguments
+	}
+	shares := make(map[uint32]*v1.ShamirShare, len(p2p))
+	for id, msg := range p2p {
+		shares[id] = nil
+		if msg != nil {
+			shares[id] = msg.Xij
+		}
+	}
+	return sealShares(ch, dp.Id, shares, reader)
+}
+
+// OpenDkgRound2P2PSend decrypts the shares sent to this participant, keyed by sender,
+// and returns the sorted senders whose share could not be opened.
+// DkgRound3 also expects the participant's share to itself, which never goes through the channel.
+func (dp *DkgParticipant) OpenDkgRound2P2PSend(ch *channel.Channel, envelopes map[uint32]*channel.Envelope) (map[uint32]*DkgRound2P2PSend, []uint32, error) {
+	shares, failed, err := openShares(dp.Curve, ch, envelopes)
+	if err != nil {
+		return nil, nil, err
+	}
+	p2p := make(map[uint32]*DkgRound2P2PSend, len(shares))
+	for id, share := range shares {
+		p2p[id] = &DkgRound2P2PSend{Xij: share}
+	}
+	return p2p, failed, nil
+}
+
+// SealRefreshRound2P2PSend encrypts each share of RefreshRound2 to its recipient
+func (rp *RefreshParticipant) SealRefreshRound2P2PSend(ch *channel.Channel, p2p map[uint32]*RefreshRound2P2PSend, reader io.Reader) (map[uint32]*channel.Envelope, error) {
+	if ch == nil || p2p == nil {
+		return nil, internal.ErrNilArguments
+	}
+	shares := make(map[uint32]*v1.ShamirShare, len(p2p))
+	for id, msg := range p2p {
+		shares[id] = nil
+		if msg != nil {
+			shares[id] = msg.Xij
+		}
+	}
+	return sealShares(ch, rp.Id, shares, reader)
+}
+
+// OpenRefreshRound2P2PSend decrypts the shares sent to this participant, keyed by sender,
+// and returns the sorted senders whose share could not be opened.
+// RefreshRound3 also expects the participant's share to itself, which never goes through the channel.
+func (rp *RefreshParticipant) OpenRefreshRound2P2PSend(ch *channel.Channel, envelopes map[uint32]*channel.Envelope) (map[uint32]*RefreshRound2P2PSend, []uint32, error) {
+	shares, failed, err := openShares(rp.Curve, ch, envelopes)
+	if err != nil {
+		return nil, nil, err
+	}
+	p2p := make(map[uint32]*RefreshRound2P2PSend, len(shares))
+	for id, share := range shares {
+		p2p[id] = &RefreshRound2P2PSend{Xij: share}
+	}
+	return p2p, failed, nil
+}
+
+func sealShares(ch *channel.Channel, self uint32, shares map[uint32]*v1.ShamirShare, reader io.Reader) (map[uint32]*channel.Envelope, error) {
+	messages := make(map[uint32][]byte, len(shares))
+	for id, share := range shares {
+		if id == self {
+			continue
+		}
+		if share == nil {
+			return nil, internal.ErrNilArguments
+		}
+		messages[id] = share.Bytes()
+	}
+	return ch.SealAll(messages, reader)
+}
+
+func openShares(curve elliptic.Curve, ch *channel.Channel, envelopes map[uint32]*channel.Envelope) (map[uint32]*v1.ShamirShare, []uint32, error) {
+	if ch == nil || envelopes == nil {
+		return nil, nil, internal.ErrNilArguments
+	}
+	messages, failed := ch.OpenAll(envelopes)
+	field := curves.NewField(curve.Params().N)
+	shares := make(map[uint32]*v1.ShamirShare, len(messages))
+	for id, msg := range messages {
+		if len(msg) < 5 || !field.IsValid(new(big.Int).SetBytes(msg[4:])) {
+			failed = append(failed, id)
+			continue
+		}
+		shares[id] = v1.NewShamirShare(binary.BigEndian.Uint32(msg[:4]), msg[4:], field)
+	}
+	sort.Slice(failed, func(i, j int) bool { return failed[i] < failed[j] })
+	return shares, failed, nil
+}
@@ -0,0 +1,70 @@
+//
+// Copyright Coinbase, Inc. All Rights Reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package participant
+
+import (
+	crand "crypto/rand"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcec"
+	"github.com/stretchr/testify/require"
+
+	"github.com/nerifnetwork/kryptology/pkg/core/curves"
+	"github.com/nerifnetwork/kryptology/pkg/dkg/channel"
+)
+
+func TestDkgRound2P2PSendChannel(t *testing.T) {
+	total := 3
+	dkgParticipants, dpOutputs := setupDkgRound2Params(btcec.S256(), 2, total)
+
+	channels := make(map[uint32]*channel.Channel, total)
+	keys := make(map[uint32]*channel.StaticKey, total)
+	public := make(map[uint32]curves.Point, total)
+	for id := range dkgParticipants {
+		key, err := channel.NewStaticKey(curves.P256(), crand.Reader)
+		require.NoError(t, err)
+		keys[id] = key
+		public[id] = key.Public
+	}
+	for id := range dkgParticipants {
+		ch, err := channel.NewChannel([]byte("session"), id, keys[id], public)
+		require.NoError(t, err)
+		channels[id] = ch
+	}
+
+	decommitments := make(map[uint32]*DkgRound2Bcast, total)
+	envelopes := make(map[uint32]*channel.Envelope, total)
+	for id, p := range dkgParticipants {
+		bcast, p2p, _, err := p.DkgRound2(dpOutputs)
+		require.NoError(t, err)
+		decommitments[id] = bcast
+		sealed, err := p.SealDkgRound2P2PSend(channels[id], p2p, crand.Reader)
+		require.NoError(t, err)
+		require.Len(t, sealed, total-1)
+		if id != 1 {
+			envelopes[id] = sealed[1]
+		}
+	}
+
+	p1 := dkgParticipants[1]
+	p2p, failed, err := p1.OpenDkgRound2P2PSend(channels[1], envelopes)
+	require.NoError(t, err)
+	require.Empty(t, failed)
+	for id := range envelopes {
+		require.Equal(t, dkgParticipants[id].State.X[0].Bytes(), p2p[id].Xij.Bytes())
+	}
+	p2p[1] = &DkgRound2P2PSend{p1.State.X[0]}
+	_, failedParticipantIds, err := p1.DkgRound3(decommitments, p2p)
+	require.Nil(t, failedParticipantIds)
+	require.NoError(t, err)
+
+	// the envelopes sealed for participant 1 don't open for participant 2
+	opened, failed, err := dkgParticipants[2].OpenDkgRound2P2PSend(channels[2], envelopes)
+	require.NoError(t, err)
+	require.Empty(t, opened)
+	require.Len(t, failed, len(envelopes))
+}
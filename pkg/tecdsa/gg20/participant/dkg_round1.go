@@ -7,6 +7,7 @@
 package participant
 
 import (
+	"crypto/elliptic"
 	"fmt"
 	"math/big"
 	"runtime"
@@ -15,6 +16,7 @@ import (
 	"github.com/nerifnetwork/kryptology/pkg/core"
 	"github.com/nerifnetwork/kryptology/pkg/paillier"
 	v1 "github.com/nerifnetwork/kryptology/pkg/sharing/v1"
+	"github.com/nerifnetwork/kryptology/pkg/tecdsa/gg20/dealer"
 	"github.com/nerifnetwork/kryptology/pkg/tecdsa/gg20/proof"
 )
 
@@ -25,6 +27,7 @@ type DkgRound1Bcast struct {
 	Pki              *paillier.PublicKey
 	H1i, H2i, Ni     *big.Int
 	Proof1i, Proof2i *proof.CdlProof
+	ModProof         *paillier.ModProof
 }
 
 // DkgRound1 performs round 1 distributed key generation operation
@@ -78,12 +81,79 @@ func (dp *DkgParticipant) DkgRound1(threshold, total uint32) (*DkgRound1Bcast, e
 		return nil, err
 	}
 
+	// Prove that pki is a Paillier-Blum modulus
+	// [CGGMP21] fig 16
+	modProof, err := newModProof(dp.Curve, ski, dp.Id)
+	if err != nil {
+		return nil, err
+	}
+
 	// Step 5-6: Choose 1024-bit safe primes Pi, Qi, Pi=2pi+1, Qi=2qi+1 where Pi, Qi, pi, qi are primes
+	Pi, Qi, err := genSafePrimes()
+	if err != nil {
+		return nil, err
+	}
+
+	// Step 7-14: Compute tildeNi, h1i, h2i and the proofs that they are well formed
+	params, proof1, proof2, err := newCdlProofParams(dp.Curve, Pi, Qi)
+	if err != nil {
+		return nil, err
+	}
+	tildeNi, h1i, h2i := params.N, params.H1, params.H2
+
+	// Step 16:
+	// Store Di, ski, pki, tildeNi, h1i, h2i, [vi0...vit], [xi1,...xin] locally
+	dp.State.D = Di
+	dp.State.Sk = ski
+	dp.State.Pk = pki
+	dp.State.N = tildeNi
+	dp.State.H1 = h1i
+	dp.State.H2 = h2i
+	dp.State.V = V
+	dp.State.X = X
+	dp.State.Threshold = threshold
+	dp.State.Limit = total
+
+	// used in Round 2
+	dp.Round = 2
+
+	// Step 15: EchoBroadcast Ci, pki, tildeNi, h1i, h2i, proof1, proof2, modProof
+	return &DkgRound1Bcast{
+		dp.Id, Ci, pki, h1i, h2i, tildeNi, proof1, proof2, modProof,
+	}, nil
+}
+
+// newModProof proves that the paillier modulus of participant id is a Paillier-Blum modulus
+func newModProof(curve elliptic.Curve, sk *paillier.SecretKey, id uint32) (*paillier.ModProof, error) {
+	params := &paillier.ModProofParams{
+		Curve:     curve,
+		SecretKey: sk,
+		Pi:        id,
+	}
+	return params.Prove()
+}
+
+// newFacProof proves that the paillier modulus of participant id has no small factors
+// to the holder of the proof params
+func newFacProof(curve elliptic.Curve, sk *paillier.SecretKey, id uint32, params *dealer.ProofParams) (*paillier.FacProof, error) {
+	facParams := &paillier.FacProofParams{
+		Curve:     curve,
+		SecretKey: sk,
+		Pi:        id,
+		NTilde:    params.N,
+		H1:        params.H1,
+		H2:        params.H2,
+	}
+	return facParams.Prove()
+}
+
+// genSafePrimes chooses two distinct safe primes of paillier.PaillierPrimeBits bits
+func genSafePrimes() (*big.Int, *big.Int, error) {
 	values := make(chan *big.Int, 2)
 	errors := make(chan error, 2)
 
-	var Pi, Qi *big.Int
-	for Pi == Qi {
+	var p, q *big.Int
+	for p == q {
 		for range []int{1, 2} {
 			go func() {
 				value, err := core.GenerateSafePrimeParallel(paillier.PaillierPrimeBits, runtime.NumCPU())
@@ -94,96 +164,86 @@ func (dp *DkgParticipant) DkgRound1(threshold, total uint32) (*DkgRound1Bcast, e
 
 		for _, err := range []error{<-errors, <-errors} {
 			if err != nil {
-				return nil, err
+				return nil, nil, err
 			}
 		}
 
-		Pi, Qi = <-values, <-values
+		p, q = <-values, <-values
 	}
+	return p, q, nil
+}
 
-	// Step 7: Compute tildeNi = Pi*Qi
-	tildeNi := new(big.Int).Mul(Pi, Qi)
+// newCdlProofParams computes the proof params tildeN = PQ, h1, h2 from the safe primes P, Q
+// and proves that h1 and h2 generate the same group
+// [spec] fig 5: DistKeyGenRound1 steps 7-14
+func newCdlProofParams(curve elliptic.Curve, P, Q *big.Int) (*dealer.ProofParams, *proof.CdlProof, *proof.CdlProof, error) {
+	// Step 7: Compute tildeN = P*Q
+	tildeN := new(big.Int).Mul(P, Q)
 
-	// Step 8-9: Sample f, alpha from Z_tildeNi*
-	f, err := core.Rand(tildeNi)
+	// Step 8-9: Sample f, alpha from Z_tildeN*
+	f, err := core.Rand(tildeN)
 	if err != nil {
-		return nil, err
+		return nil, nil, nil, err
 	}
 
-	alpha, err := core.Rand(tildeNi)
+	alpha, err := core.Rand(tildeN)
 	if err != nil {
-		return nil, err
+		return nil, nil, nil, err
 	}
 
-	// Step 10: Compute beta = alpha^-1 mod pi*qi
-	// Compute pi = (Pi-1)/2, qi = (Qi-1)/2
-	pi := new(big.Int).Rsh(Pi, 1)
-	qi := new(big.Int).Rsh(Qi, 1)
+	// Step 10: Compute beta = alpha^-1 mod p*q
+	// Compute p = (P-1)/2, q = (Q-1)/2
+	p := new(big.Int).Rsh(P, 1)
+	q := new(big.Int).Rsh(Q, 1)
 
-	// Compute pi*qi
-	pq := new(big.Int).Mul(pi, qi)
+	// Compute p*q
+	pq := new(big.Int).Mul(p, q)
 
 	// Compute beta
 	beta := new(big.Int).ModInverse(alpha, pq)
+	if beta == nil {
+		return nil, nil, nil, fmt.Errorf("alpha is not invertible")
+	}
 
-	// Step 11-12: h1i = f^2 mod tildeNi, h2i = h1i^alpha mod tildeNi
-	h1i, err := core.Mul(f, f, tildeNi)
+	// Step 11-12: h1 = f^2 mod tildeN, h2 = h1^alpha mod tildeN
+	h1, err := core.Mul(f, f, tildeN)
 	if err != nil {
-		return nil, err
+		return nil, nil, nil, err
 	}
-	h2i := new(big.Int).Exp(h1i, alpha, tildeNi)
+	h2 := new(big.Int).Exp(h1, alpha, tildeN)
 
 	// Step 13-14:
 	cdlParams1 := proof.CdlProofParams{
-		Curve:   dp.Curve,
-		Pi:      pi,
-		Qi:      qi,
-		H1:      h1i,
-		H2:      h2i,
+		Curve:   curve,
+		Pi:      p,
+		Qi:      q,
+		H1:      h1,
+		H2:      h2,
 		ScalarX: alpha,
-		N:       tildeNi,
+		N:       tildeN,
 	}
 
 	cdlParams2 := proof.CdlProofParams{
-		Curve:   dp.Curve,
-		Pi:      pi,
-		Qi:      qi,
-		H1:      h2i,
-		H2:      h1i,
+		Curve:   curve,
+		Pi:      p,
+		Qi:      q,
+		H1:      h2,
+		H2:      h1,
 		ScalarX: beta,
-		N:       tildeNi,
+		N:       tildeN,
 	}
 
-	// proof1 <- ProveCompositeDL(g, q, pi, qi, h1i, h2i, alpha, tildeNi)
+	// proof1 <- ProveCompositeDL(g, q, p, q, h1, h2, alpha, tildeN)
 	proof1, err := cdlParams1.Prove()
 	if err != nil {
-		return nil, err
+		return nil, nil, nil, err
 	}
 
-	// proof2 <- ProveCompositeDl(g, q, pi, qi, h2i, h1i, beta, tildeNi)
+	// proof2 <- ProveCompositeDl(g, q, p, q, h2, h1, beta, tildeN)
 	proof2, err := cdlParams2.Prove()
 	if err != nil {
-		return nil, err
+		return nil, nil, nil, err
 	}
 
-	// Step 16:
-	// Store Di, ski, pki, tildeNi, h1i, h2i, [vi0...vit], [xi1,...xin] locally
-	dp.State.D = Di
-	dp.State.Sk = ski
-	dp.State.Pk = pki
-	dp.State.N = tildeNi
-	dp.State.H1 = h1i
-	dp.State.H2 = h2i
-	dp.State.V = V
-	dp.State.X = X
-	dp.State.Threshold = threshold
-	dp.State.Limit = total
-
-	// used in Round 2
-	dp.Round = 2
-
-	// Step 15: EchoBroadcast Ci, pki, tildeNi, h1i, h2i, proof1, proof2
-	return &DkgRound1Bcast{
-		dp.Id, Ci, pki, h1i, h2i, tildeNi, proof1, proof2,
-	}, nil
+	return &dealer.ProofParams{N: tildeN, H1: h1, H2: h2}, proof1, proof2, nil
 }
@@ -7,6 +7,7 @@
 package participant
 
 import (
+	"crypto/elliptic"
 	"fmt"
 
 	"github.com/nerifnetwork/kryptology/internal"
@@ -20,6 +21,8 @@ import (
 // DkgRound2Bcast contains value that will be echo broadcast to all other players.
 type DkgRound2Bcast struct {
 	Di *core.Witness
+	// FacProofs prove to each player Pj that the paillier modulus has no small factors
+	FacProofs map[uint32]*paillier.FacProof
 }
 
 // DkgRound2P2PSend contains value that will be P2PSend to all other player Pj
@@ -59,53 +62,18 @@ func (dp *DkgParticipant) DkgRound2(inBcast map[uint32]*DkgRound1Bcast) (*DkgRou
 
 	// Initiate P2P channel to other parties
 	p2PSend := make(map[uint32]*DkgRound2P2PSend)
-
-	// Initiate two CdlVerifyParams that will be used in CDL verification.
-	cdlParams1 := proof.CdlVerifyParams{
-		Curve: dp.Curve,
-	}
-	cdlParams2 := proof.CdlVerifyParams{
-		Curve: dp.Curve,
-	}
+	facProofs := make(map[uint32]*paillier.FacProof)
 
 	dp.State.OtherParticipantData = make(map[uint32]*DkgParticipantCommitment)
 
 	// For j = [1...n]
-	expKeySize := 2 * paillier.PaillierPrimeBits
 	for id, param := range inBcast {
 		// If i = j, Continue
 		if id == dp.Id {
 			continue
 		}
 
-		// Mitigate possible attack from
-		// https://eprint.iacr.org/2021/1621.pdf
-		// by checking that paillier keys are the correct size
-		// See section 5
-		bitlen := param.Pki.N.BitLen()
-		if bitlen != expKeySize &&
-			bitlen != expKeySize-1 {
-			failedParticipantIds = append(failedParticipantIds, id)
-			failedParticipantErrors = append(failedParticipantErrors, fmt.Errorf("invalid paillier keys"))
-			continue
-		}
-
-		// If VerifyCompositeDL(pi_1j^CDL, g, q, h1j, h2j, tildeN_j) = False, Abort
-		cdlParams1.H1 = param.H1i
-		cdlParams1.H2 = param.H2i
-		cdlParams1.N = param.Ni
-		if err := param.Proof1i.Verify(&cdlParams1); err != nil {
-			failedParticipantIds = append(failedParticipantIds, id)
-			failedParticipantErrors = append(failedParticipantErrors, err)
-			continue
-		}
-
-		// If VerifyCompositeDL(pi_2j^CDL, g, q, h2j, h1j, tildeN_j) = False, Abort
-		// Note the position of h1j and h2j, they are reversed in the second verification!
-		cdlParams2.H1 = param.H2i
-		cdlParams2.H2 = param.H1i
-		cdlParams2.N = param.Ni
-		if err := param.Proof2i.Verify(&cdlParams2); err != nil {
+		if err := verifyPaillierParams(dp.Curve, id, param); err != nil {
 			failedParticipantIds = append(failedParticipantIds, id)
 			failedParticipantErrors = append(failedParticipantErrors, err)
 			continue
@@ -132,6 +100,14 @@ func (dp *DkgParticipant) DkgRound2(inBcast map[uint32]*DkgRound1Bcast) (*DkgRou
 				H2: param.H2i,
 			},
 		}
+
+		// Prove to player Pj that pki has no small factors using the proof params of Pj
+		// [CGGMP21] fig 28
+		facProof, err := newFacProof(dp.Curve, dp.State.Sk, dp.Id, dp.State.OtherParticipantData[id].ProofParams)
+		if err != nil {
+			return nil, nil, failedParticipantIds, err
+		}
+		facProofs[id] = facProof
 	}
 
 	if len(failedParticipantIds) != 0 {
@@ -143,6 +119,55 @@ func (dp *DkgParticipant) DkgRound2(inBcast map[uint32]*DkgRound1Bcast) (*DkgRou
 
 	// EchoBroadcast Di to all other players. Also return it with P2PSend
 	return &DkgRound2Bcast{
-		Di: dp.State.D,
+		Di:        dp.State.D,
+		FacProofs: facProofs,
 	}, p2PSend, failedParticipantIds, nil
 }
+
+// verifyPaillierParams checks the size and the Paillier-Blum modulus proof of the paillier key
+// and the proofs of the proof params of participant j
+// [spec] fig 5: DistKeyGenRound2 steps 3-4
+func verifyPaillierParams(curve elliptic.Curve, j uint32, param *DkgRound1Bcast) error {
+	// Mitigate possible attack from
+	// https://eprint.iacr.org/2021/1621.pdf
+	// by checking that paillier keys are the correct size
+	// See section 5
+	expKeySize := 2 * paillier.PaillierPrimeBits
+	bitlen := param.Pki.N.BitLen()
+	if bitlen != expKeySize &&
+		bitlen != expKeySize-1 {
+		return fmt.Errorf("invalid paillier keys")
+	}
+
+	// If the paillier key is not a Paillier-Blum modulus, Abort
+	// [CGGMP21] fig 16
+	modParams := paillier.ModVerifyParams{
+		Curve:     curve,
+		PublicKey: param.Pki,
+		Pi:        j,
+	}
+	if err := param.ModProof.Verify(&modParams); err != nil {
+		return err
+	}
+
+	// If VerifyCompositeDL(pi_1j^CDL, g, q, h1j, h2j, tildeN_j) = False, Abort
+	cdlParams1 := proof.CdlVerifyParams{
+		Curve: curve,
+		H1:    param.H1i,
+		H2:    param.H2i,
+		N:     param.Ni,
+	}
+	if err := param.Proof1i.Verify(&cdlParams1); err != nil {
+		return err
+	}
+
+	// If VerifyCompositeDL(pi_2j^CDL, g, q, h2j, h1j, tildeN_j) = False, Abort
+	// Note the position of h1j and h2j, they are reversed in the second verification!
+	cdlParams2 := proof.CdlVerifyParams{
+		Curve: curve,
+		H1:    param.H2i,
+		H2:    param.H1i,
+		N:     param.Ni,
+	}
+	return param.Proof2i.Verify(&cdlParams2)
+}
@@ -8,14 +8,18 @@ package participant
 
 import (
 	"crypto/elliptic"
+	"crypto/sha256"
+	"encoding/binary"
 	"fmt"
 	"math/big"
+	"sort"
 
 	"github.com/nerifnetwork/kryptology/internal"
 	"github.com/nerifnetwork/kryptology/pkg/core"
 	"github.com/nerifnetwork/kryptology/pkg/core/curves"
 	"github.com/nerifnetwork/kryptology/pkg/paillier"
 	v1 "github.com/nerifnetwork/kryptology/pkg/sharing/v1"
+	"github.com/nerifnetwork/kryptology/pkg/tecdsa/gg20/dealer"
 )
 
 type DkgRound3Bcast struct {
@@ -55,6 +59,18 @@ func (dp *DkgParticipant) DkgRound3(inBcast map[uint32]*DkgRound2Bcast, inP2P ma
 			continue
 		}
 
+		// If the paillier key of Pj has small factors, Abort
+		// [CGGMP21] fig 28
+		if err := verifyFacProof(dp.Curve, j, dp.State.OtherParticipantData[j].PublicKey, wit.FacProofs[dp.Id], &dealer.ProofParams{
+			N:  dp.State.N,
+			H1: dp.State.H1,
+			H2: dp.State.H2,
+		}); err != nil {
+			failedParticipantIds = append(failedParticipantIds, j)
+			failedParticipantErrors = append(failedParticipantErrors, err)
+			continue
+		}
+
 		// 4. Compute [vj0, . . . , vjt] ←Open(Cj , Dj )
 		if ok, err := core.Open(dp.State.OtherParticipantData[j].Commitment, *wit.Di); !ok {
 			if err != nil {
@@ -191,10 +207,52 @@ func (dp *DkgParticipant) DkgRound3(inBcast map[uint32]*DkgRound2Bcast, inP2P ma
 	dp.State.Y = y
 	dp.State.ShamirShare = xi
 	dp.State.PublicShares = publicShares
+	dp.State.ChainCode = dkgChainCode(dp.Id, dp.State.D, inBcast)
 
 	return &DkgRound3Bcast{psfProof}, failedParticipantIds, nil
 }
 
+// dkgChainCode derives the BIP-32 chain code of the key from the commitment randomness of all participants.
+// The randomness is committed in round 1 and only opened in round 2, so no participant can choose the chain code.
+func dkgChainCode(id uint32, own *core.Witness, inBcast map[uint32]*DkgRound2Bcast) []byte {
+	witnesses := make(map[uint32]*core.Witness, len(inBcast)+1)
+	for j, wit := range inBcast {
+		if wit != nil && wit.Di != nil {
+			witnesses[j] = wit.Di
+		}
+	}
+	if own != nil {
+		witnesses[id] = own
+	}
+	ids := make([]uint32, 0, len(witnesses))
+	for j := range witnesses {
+		ids = append(ids, j)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+
+	h := sha256.New()
+	_, _ = h.Write([]byte("gg20 dkg bip32 chain code"))
+	for _, j := range ids {
+		_ = binary.Write(h, binary.BigEndian, j)
+		_, _ = h.Write(witnesses[j].R[:])
+	}
+	return h.Sum(nil)
+}
+
+// verifyFacProof checks the proof of participant j that its paillier key has no small factors
+// against the proof params of this participant
+func verifyFacProof(curve elliptic.Curve, j uint32, pk *paillier.PublicKey, facProof *paillier.FacProof, params *dealer.ProofParams) error {
+	facParams := &paillier.FacVerifyParams{
+		Curve:     curve,
+		PublicKey: pk,
+		Pi:        j,
+		NTilde:    params.N,
+		H1:        params.H1,
+		H2:        params.H2,
+	}
+	return facProof.Verify(facParams)
+}
+
 // unmarshalFeldmanVerifiers converts a byte sequence into
 // a number of feldman verifiers
 func unmarshalFeldmanVerifiers(curve elliptic.Curve, msg []byte, verifierSize, threshold int) ([]*v1.ShareVerifier, error) {
@@ -23,6 +23,8 @@ type DkgResult struct {
 	EcdsaPublicKey  *curves.EcPoint
 	PublicShares    []*curves.EcPoint
 	ParticipantData map[uint32]*DkgParticipantData
+	// ChainCode is the BIP-32 chain code of the key, see dealer.ParticipantData.Derive
+	ChainCode []byte
 }
 
 type DkgParticipantData struct {
@@ -111,5 +113,6 @@ func (dp *DkgParticipant) DkgRound4(inBcast map[uint32]*DkgRound3Bcast) (*DkgRes
 		EcdsaPublicKey:  dp.State.Y,
 		PublicShares:    dp.State.PublicShares,
 		ParticipantData: participantData,
+		ChainCode:       dp.State.ChainCode,
 	}, failedParticipantIds, nil
 }
@@ -8,7 +8,9 @@ package participant
 
 import (
 	"crypto/elliptic"
+	"fmt"
 	"math/big"
+	"sync"
 	"testing"
 
 	"github.com/btcsuite/btcd/btcec"
@@ -19,7 +21,7 @@ import (
 	"github.com/nerifnetwork/kryptology/pkg/core/curves"
 	"github.com/nerifnetwork/kryptology/pkg/paillier"
 	v1 "github.com/nerifnetwork/kryptology/pkg/sharing/v1"
-	"github.com/nerifnetwork/kryptology/pkg/tecdsa/gg20/dealer"
+	"github.com/nerifnetwork/kryptology/pkg/tecdsa/bip32"
 	"github.com/nerifnetwork/kryptology/pkg/tecdsa/gg20/proof"
 )
 
@@ -38,7 +40,7 @@ func setupDkgRound3ParticipantMap(curve elliptic.Curve, t, n int) map[uint32]*Dk
 		sk, _ := paillier.NewSecretKey(testPrimes[prime1Idx], testPrimes[prime1Idx+1])
 		id := uint32(i + 1)
 		pIds[id].PublicKey = &sk.PublicKey
-		pIds[id].ProofParams = &dealer.ProofParams{}
+		pIds[id].ProofParams = dealerParams
 		participants[id] = &DkgParticipant{
 			Curve: curve,
 			Id:    id,
@@ -46,6 +48,9 @@ func setupDkgRound3ParticipantMap(curve elliptic.Curve, t, n int) map[uint32]*Dk
 			State: &DkgState{
 				Sk:                   sk,
 				Pk:                   &sk.PublicKey,
+				N:                    dealerParams.N,
+				H1:                   dealerParams.H1,
+				H2:                   dealerParams.H2,
 				Threshold:            uint32(t),
 				Limit:                uint32(n),
 				X:                    x,
@@ -69,6 +74,14 @@ func setupDkgRound3Commitments(t *testing.T, participants map[uint32]*DkgPartici
 		commitments[id], decommitments[id], err = commitVerifiers(participants[id].State.V)
 		p.State.OtherParticipantData[id].Commitment = commitments[id]
 		require.NoError(t, err)
+
+		decommitments[id].FacProofs = make(map[uint32]*paillier.FacProof, playerCnt)
+		for j := range participants {
+			if j != id {
+				decommitments[id].FacProofs[j], err = newFacProof(p.Curve, p.State.Sk, id, dealerParams)
+				require.NoError(t, err)
+			}
+		}
 	}
 
 	return decommitments
@@ -255,6 +268,34 @@ func TestDkgRound3InvalidShares(t *testing.T) {
 	require.Nil(t, res2)
 }
 
+func TestDkgRound3InvalidFacProofs(t *testing.T) {
+	// Setup
+	curve := btcec.S256()
+	playerCnt := 3
+	playerMin := 2
+	participants := setupDkgRound3ParticipantMap(curve, playerMin, playerCnt)
+	decommitments := setupDkgRound3Commitments(t, participants, playerCnt)
+	shares := map[uint32]*DkgRound2P2PSend{
+		1: {participants[1].State.X[0]},
+		2: {participants[2].State.X[0]},
+		3: {participants[3].State.X[0]},
+	}
+
+	// 2nd participant replays the proof of the 3rd participant
+	decommitments[2].FacProofs[1] = decommitments[3].FacProofs[1]
+	res, failedParticipantIds, err := participants[1].DkgRound3(decommitments, shares)
+	require.Equal(t, []uint32{2}, failedParticipantIds)
+	require.Error(t, err)
+	require.Nil(t, res)
+
+	// 2nd participant sends no proof to the 1st participant
+	delete(decommitments[2].FacProofs, 1)
+	res, failedParticipantIds, err = participants[1].DkgRound3(decommitments, shares)
+	require.Equal(t, []uint32{2}, failedParticipantIds)
+	require.Error(t, err)
+	require.Nil(t, res)
+}
+
 func TestDkgRound1Works(t *testing.T) {
 	if testing.Short() {
 		t.Skip("skipping test in short mode.")
@@ -373,6 +414,37 @@ func TestDkgRound1RepeatCall(t *testing.T) {
 /*
 Tests for DKG Round 2
 */
+// The proofs of the round 1 broadcasts are over the fixed test moduli. They are slow to
+// create, so they are created once and shared by every test that sets up round 2.
+var testRound1Proofs = struct {
+	sync.Mutex
+	cdl map[string]*proof.CdlProof
+	mod map[string]*paillier.ModProof
+}{
+	cdl: make(map[string]*proof.CdlProof),
+	mod: make(map[string]*paillier.ModProof),
+}
+
+func testCdlProof(name string, params proof.CdlProofParams) *proof.CdlProof {
+	testRound1Proofs.Lock()
+	defer testRound1Proofs.Unlock()
+	key := params.Curve.Params().Name + name
+	if testRound1Proofs.cdl[key] == nil {
+		testRound1Proofs.cdl[key], _ = params.Prove()
+	}
+	return testRound1Proofs.cdl[key]
+}
+
+func testModProof(curve elliptic.Curve, sk *paillier.SecretKey, id uint32) *paillier.ModProof {
+	testRound1Proofs.Lock()
+	defer testRound1Proofs.Unlock()
+	key := fmt.Sprintf("%s/%d/%s", curve.Params().Name, id, sk.N)
+	if testRound1Proofs.mod[key] == nil {
+		testRound1Proofs.mod[key], _ = newModProof(curve, sk, id)
+	}
+	return testRound1Proofs.mod[key]
+}
+
 // Setup DKG Round2 parameters for 3 parties.
 func setupDkgRound2Params(curve elliptic.Curve, threshold, total int) (map[uint32]*DkgParticipant, map[uint32]*DkgRound1Bcast) {
 	dkgParticipants := make(map[uint32]*DkgParticipant, total)
@@ -431,8 +503,8 @@ func setupDkgRound2Params(curve elliptic.Curve, threshold, total int) (map[uint3
 		N:       N1,
 	}
 
-	proof11, _ := cdlParams11.Prove()
-	proof12, _ := cdlParams12.Prove()
+	proof11 := testCdlProof("11", cdlParams11)
+	proof12 := testCdlProof("12", cdlParams12)
 
 	dpOutputs[1] = &DkgRound1Bcast{
 		Identifier: uint32(1),
@@ -450,7 +522,7 @@ func setupDkgRound2Params(curve elliptic.Curve, threshold, total int) (map[uint3
 	v2, x2, _ := feldman.Split(u2.Bytes())
 	c2, d2, _ := commitVerifiers(v2)
 	sk2, _ := paillier.NewSecretKey(testPrimes[1], testPrimes[2])
-	pk2 := &sk1.PublicKey
+	pk2 := &sk2.PublicKey
 	p2 := tt.B10("80835583194913519255227175491027534116323858942133804957873380964125769952153722872767395985807182088879137751973737195611736535673308039044731251312798604370125595953965575058201727000947562582634872106737658889028847062612351664088766417932577222262750095599375976992110180518474405571076375447630197402683")
 	q2 := tt.B10("69210064501097857725048553909160987839131798505582280831015356795143548214286337962509303315200340351978784326318311835136991907096681964505529171491850250428938993794552587102107113717157628550696163063380650545271668730646004879534943159254419759086140189236571989463830596906364636114340509119890275606391")
 	N2 := tt.B10("22378543707615306836182422597086174059399103708656614542204375941627776221585838908498318709084953151230965854544463050621828248405138252834708522307450445556723033074493621883193914559018381068514705602518576848505473239373873163089017151419401575132561803936035907582328999663685768377599926812681660506565947765011705274480316011005378618472210395471633060565141691029027315925435485138015621760349367644867362058194502904616776430082646477391555516713467661564007827039040130177584810996845324575709856975050152799234612346180661626644267859546152830340382918108278013986195106643466879089206972260629605087406361")
@@ -497,8 +569,8 @@ func setupDkgRound2Params(curve elliptic.Curve, threshold, total int) (map[uint3
 		N:       N2,
 	}
 
-	proof21, _ := cdlParams21.Prove()
-	proof22, _ := cdlParams22.Prove()
+	proof21 := testCdlProof("21", cdlParams21)
+	proof22 := testCdlProof("22", cdlParams22)
 
 	dpOutputs[2] = &DkgRound1Bcast{
 		Identifier: uint32(2),
@@ -516,7 +588,7 @@ func setupDkgRound2Params(curve elliptic.Curve, threshold, total int) (map[uint3
 	v3, x3, _ := feldman.Split(u3.Bytes())
 	c3, d3, _ := commitVerifiers(v3)
 	sk3, _ := paillier.NewSecretKey(testPrimes[2], testPrimes[3])
-	pk3 := &sk1.PublicKey
+	pk3 := &sk3.PublicKey
 	p3 := tt.B10("82762378445241041785597416507146490924447650731139897784752935952615188889635739493260515391609009395512012510709694417682987650969620502923818755809286104031313081465573622419369705445472375093283434921189190872945878407680529264737835792880592698668922695556861850401038676208972765968600517704143107930939")
 	q3 := tt.B10("69984280963080064677800727110502274842099368563604158420066520063922637818395160287343267480348823052616167329961701614168083898925612364204117254926526013551734867336492773207372540967720859662663438793769658958121285041708356176137310878638419197674131049677486047662703715583824220317748400146581518805651")
 	N3 := tt.B10("23168262185138042086985251479966140367473901735462054883904158802899264898691745453544888260128845593494180271821344835446388187526575974414101963091834292189789300379762157838980851815082897653013412610189597921830744654589452349113217726909094907934886602336832701591827947839296008075387481044145203853001040451482685799793720746884491540403980953810998121611944634910546812395244563473672734584566589265151370354163653553724356278901340380622401163641562474726319566531912584399552348312976911765268861745938301120268844120841005241592287730934409073936996169212424570979999552686559812737873042697608781537218337")
@@ -563,8 +635,8 @@ func setupDkgRound2Params(curve elliptic.Curve, threshold, total int) (map[uint3
 		N:       N3,
 	}
 
-	proof31, _ := cdlParams31.Prove()
-	proof32, _ := cdlParams32.Prove()
+	proof31 := testCdlProof("31", cdlParams31)
+	proof32 := testCdlProof("32", cdlParams32)
 
 	dpOutputs[3] = &DkgRound1Bcast{
 		Identifier: uint32(3),
@@ -577,6 +649,10 @@ func setupDkgRound2Params(curve elliptic.Curve, threshold, total int) (map[uint3
 		Proof2i:    proof32,
 	}
 
+	for id, out := range dpOutputs {
+		out.ModProof = testModProof(curve, dkgParticipants[id].State.Sk, id)
+	}
+
 	return dkgParticipants, dpOutputs
 }
 
@@ -607,6 +683,13 @@ func TestDkgRound2Works(t *testing.T) {
 		t.Errorf("DkgRound2P2PSend should have length 2, found: %d", len(p2psend))
 	}
 
+	// Check the no small factor proofs for the other players
+	require.Len(t, bcast.FacProofs, 2)
+	for id, facProof := range bcast.FacProofs {
+		pd := participant.State.OtherParticipantData[id]
+		require.NoError(t, verifyFacProof(curve, participant.Id, participant.State.Pk, facProof, pd.ProofParams))
+	}
+
 	// Check participant
 	if participant.Round != 3 {
 		t.Errorf("dkg round should be 3, found: %d", participant.Round)
@@ -647,6 +730,28 @@ func TestDkgRound2Tampered(t *testing.T) {
 	_, _, failedParticipantIds, err = participant.DkgRound2(dpOutputs)
 	require.NotNil(t, failedParticipantIds)
 	require.Error(t, err)
+
+	// Restore player 2
+	dpOutputs[2].H1i.Sub(dpOutputs[2].H1i, core.One)
+	participant.Round = 2
+
+	if testing.Short() {
+		// Every call verifies the proofs of all players
+		return
+	}
+
+	// Player 3 replays the Paillier-Blum modulus proof of player 2
+	dpOutputs[3].ModProof = dpOutputs[2].ModProof
+	_, _, failedParticipantIds, err = participant.DkgRound2(dpOutputs)
+	require.Equal(t, []uint32{3}, failedParticipantIds)
+	require.Error(t, err)
+
+	// Player 3 has no Paillier-Blum modulus proof
+	participant.Round = 2
+	dpOutputs[3].ModProof = nil
+	_, _, failedParticipantIds, err = participant.DkgRound2(dpOutputs)
+	require.Equal(t, []uint32{3}, failedParticipantIds)
+	require.Error(t, err)
 }
 
 // Test repeat call of DKG round 2
@@ -985,9 +1090,9 @@ func TestDkgFullRoundsWorks(t *testing.T) {
 	// Run Dkg Round 3
 	decommitments := make(map[uint32]*DkgRound2Bcast, total)
 	dkgR3Out := make(map[uint32]*DkgRound3Bcast)
-	decommitments[1] = &DkgRound2Bcast{dkgR2Bcast[1].Di}
-	decommitments[2] = &DkgRound2Bcast{dkgR2Bcast[2].Di}
-	decommitments[3] = &DkgRound2Bcast{dkgR2Bcast[3].Di}
+	decommitments[1] = &DkgRound2Bcast{dkgR2Bcast[1].Di, dkgR2Bcast[1].FacProofs}
+	decommitments[2] = &DkgRound2Bcast{dkgR2Bcast[2].Di, dkgR2Bcast[2].FacProofs}
+	decommitments[3] = &DkgRound2Bcast{dkgR2Bcast[3].Di, dkgR2Bcast[3].FacProofs}
 
 	dkgR3Out[1], failedParticipantIds, err = dkgParticipants[1].DkgRound3(decommitments, map[uint32]*DkgRound2P2PSend{
 		1: {dkgParticipants[1].State.X[0]},
@@ -1047,6 +1152,11 @@ func TestDkgFullRoundsWorks(t *testing.T) {
 	require.Equal(t, dkgParticipants[1].State.Y, dkgParticipants[2].State.Y)
 	require.Equal(t, dkgParticipants[1].State.Y, dkgParticipants[3].State.Y)
 
+	// Check every participant has the same chain code
+	require.Len(t, dkgR4Out[1].ChainCode, bip32.ChainCodeSize)
+	require.Equal(t, dkgR4Out[1].ChainCode, dkgR4Out[2].ChainCode)
+	require.Equal(t, dkgR4Out[1].ChainCode, dkgR4Out[3].ChainCode)
+
 	// Testing validity of paillier public key and secret key
 	// Check every participant receives equal paillier public keys from other parties
 	require.Equal(t, dkgParticipants[1].State.OtherParticipantData[2].PublicKey, dkgParticipants[3].State.OtherParticipantData[2].PublicKey)
@@ -164,22 +164,45 @@ type state struct {
 	betaj map[uint32]*big.Int
 	vuj   map[uint32]*big.Int
 	pks   map[uint32]*paillier.PublicKey
+	// Masks of the MtA responses, kept for the abort reveal
+	betaTickj map[uint32]*big.Int
+	vuTickj   map[uint32]*big.Int
 
 	// Round 3 variables
 	deltai *big.Int
 	sigmai *big.Int
+	// Opening l_i and commitment T_i = g^{σ_i} h^{l_i}
+	li *big.Int
+	Ti *curves.EcPoint
+	// MtA responses received from the cosigners, kept for the abort reveal
+	alphaCtj map[uint32]paillier.Ciphertext
+	muCtj    map[uint32]paillier.Ciphertext
 
 	// Round 4 variables
-	delta *big.Int
+	delta  *big.Int
+	deltaj map[uint32]*big.Int
+	Tj     map[uint32]*curves.EcPoint
 
 	// Round 5 variables
-	r     *big.Int
-	Rbari *curves.EcPoint
-	Rbark *curves.EcPoint
-	R     *curves.EcPoint
+	r      *big.Int
+	Rbari  *curves.EcPoint
+	Si     *curves.EcPoint
+	R      *curves.EcPoint
+	Gammaj map[uint32]*curves.EcPoint
 
 	// Round 6 variables
-	si *big.Int
+	si    *big.Int
+	Rbarj map[uint32]*curves.EcPoint
+	Sj    map[uint32]*curves.EcPoint
+
+	// Set when V != g or ∏ S_j != y in round 6, the only failures after which the ephemeral
+	// values can be revealed
+	revealable bool
+
+	// Transcript signatures of this signer and its cosigners, kept for the abort evidence
+	transcripts map[uint32]*transcriptSignatures
+	// Abort reveal of this signer
+	reveal *AbortReveal
 }
 
 // convertToAdditive takes all the publicShares and changes them to their additive form
@@ -350,6 +373,8 @@ type DkgState struct {
 	ShamirShare *v1.ShamirShare
 	// X1,...,Xn returned from Round 3
 	PublicShares []*curves.EcPoint
+	// BIP-32 chain code returned from Round 3
+	ChainCode []byte
 }
 
 // Check DKG round number is valid
@@ -0,0 +1,223 @@
+//
+// Copyright Coinbase, Inc. All Rights Reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package participant
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"math/big"
+	"sort"
+
+	"github.com/nerifnetwork/kryptology/internal"
+	"github.com/nerifnetwork/kryptology/pkg/core"
+	"github.com/nerifnetwork/kryptology/pkg/core/curves"
+)
+
+// presignRound is the round of a signer that has exported its presignature.
+// The signing values now belong to the presignature, the signer cannot use them anymore.
+const presignRound = 9
+
+// Presignature is the output of signing rounds 1-5 and the offline part of round 6 for a fixed set of cosigners.
+// It signs a single message hash with SignOnline, after which the cosigners exchange one
+// broadcast and combine the signature with Output.
+//
+// A presignature must never sign two messages, doing so reveals the secret key.
+// SignOnline erases the secret values of the presignature it is given, but any copy
+// made by serializing it stays usable. Callers that store presignatures must delete
+// the stored copy before calling SignOnline. Id is the same at every cosigner and
+// can be used to record the presignatures that were consumed.
+type Presignature struct {
+	// Id identifies the presignature across the cosigners
+	Id []byte
+	// SignerId is the id of the cosigner that holds the presignature
+	SignerId uint32
+	// Cosigners are the ids of all cosigners including SignerId, in ascending order
+	Cosigners []uint32
+	PublicKey *curves.EcPoint
+	// R is the signing nonce point R = g^{k^{-1}}
+	R *curves.EcPoint
+	// K and Sigma are the secret k_i and σ_i of the signer, nil once used
+	K, Sigma *big.Int
+	// Rbar are \bar{R}_j = R^{k_j} and BigS are S_j = R^{σ_j} of all cosigners,
+	// they identify the cosigners of invalid shares s_j in Output
+	Rbar, BigS map[uint32]*curves.EcPoint
+	// MsgHash and S are set by SignOnline
+	MsgHash []byte
+	S       *big.Int
+}
+
+// SignPresign performs the offline part of round 6 and exports the result as a presignature.
+// It takes the same inputs as SignRound6Full. Afterwards the signer cannot sign or reveal
+// the values for an abort anymore since they belong to the presignature.
+// [spec] §6.fig 6.SignRound6Offline
+func (signer *Signer) SignPresign(inBcast map[uint32]*Round5Bcast, inP2P map[uint32]*Round5P2PSend) (*Presignature, []uint32, error) {
+	if err := signer.verifyStateMap(6, inBcast); err != nil {
+		return nil, nil, err
+	}
+	if !signer.state.keyGenType.IsTrustedDealer() {
+		if err := signer.verifyStateMap(6, inP2P); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	failedCosignerIds, err := signer.signRound6Offline(inBcast, inP2P)
+	if err != nil {
+		return nil, failedCosignerIds, err
+	}
+
+	cosigners := make([]uint32, 0, len(signer.state.cosigners)+1)
+	cosigners = append(cosigners, signer.Id)
+	for id := range signer.state.cosigners {
+		if id != signer.Id {
+			cosigners = append(cosigners, id)
+		}
+	}
+	sort.Slice(cosigners, func(i, j int) bool { return cosigners[i] < cosigners[j] })
+
+	rbar := make(map[uint32]*curves.EcPoint, len(cosigners))
+	bigS := make(map[uint32]*curves.EcPoint, len(cosigners))
+	rbar[signer.Id] = signer.state.Rbari
+	bigS[signer.Id] = signer.state.Si
+	for j := range signer.state.Rbarj {
+		rbar[j] = signer.state.Rbarj[j]
+		bigS[j] = signer.state.Sj[j]
+	}
+
+	presig := &Presignature{
+		Id:        presignatureId(signer.PublicKey, signer.state.R, cosigners),
+		SignerId:  signer.Id,
+		Cosigners: cosigners,
+		PublicKey: signer.PublicKey,
+		R:         signer.state.R,
+		K:         signer.state.ki,
+		Sigma:     signer.state.sigmai,
+		Rbar:      rbar,
+		BigS:      bigS,
+	}
+
+	// The signer must not use k_i or σ_i again
+	signer.state.ki = nil
+	signer.state.sigmai = nil
+	signer.Round = presignRound
+	return presig, nil, nil
+}
+
+// SignOnline signs the hash with the presignature and returns s_i, which must be
+// broadcast to all cosigners. The presignature can only be used once.
+// [spec] §6.fig 6.SignRound6Online
+func SignOnline(presig *Presignature, hash []byte) (*Round6FullBcast, error) {
+	if presig == nil || presig.PublicKey == nil || presig.R == nil || len(hash) == 0 {
+		return nil, internal.ErrNilArguments
+	}
+	if presig.K == nil || presig.Sigma == nil || presig.S != nil {
+		return nil, fmt.Errorf("presignature has already been used")
+	}
+	q := presig.PublicKey.Curve.Params().N
+
+	// 7. Compute m = H(M) ∈ Z_q
+	m := new(big.Int).SetBytes(hash)
+	if err := core.In(m, q); err != nil {
+		return nil, err
+	}
+
+	// 8. Compute s_i = m k_i + r σ_i mod q
+	mk, err := core.Mul(m, presig.K, q)
+	if err != nil {
+		return nil, err
+	}
+	rSigma, err := core.Mul(presig.R.X, presig.Sigma, q)
+	if err != nil {
+		return nil, err
+	}
+	si, err := core.Add(mk, rSigma, q)
+	if err != nil {
+		return nil, err
+	}
+
+	presig.K = nil
+	presig.Sigma = nil
+	presig.MsgHash = hash
+	presig.S = si
+
+	// 9. Broadcast s_i to all other players
+	return &Round6FullBcast{si}, nil
+}
+
+// Output combines the s_j of the other cosigners with the s_i of SignOnline into the
+// signature of the hash and verifies it. Every s_j is checked against \bar{R}_j and S_j
+// first, the cosigners of invalid shares are returned.
+// [spec] §5.fig 5, §4.3
+func (presig *Presignature) Output(in map[uint32]*Round6FullBcast) (*curves.EcdsaSignature, []uint32, error) {
+	if presig == nil || presig.PublicKey == nil || presig.R == nil {
+		return nil, nil, internal.ErrNilArguments
+	}
+	if presig.S == nil {
+		return nil, nil, fmt.Errorf("presignature has not signed yet")
+	}
+	if len(in) != len(presig.Cosigners)-1 {
+		return nil, nil, internal.ErrIncorrectCount
+	}
+	q := presig.PublicKey.Curve.Params().N
+	m := new(big.Int).SetBytes(presig.MsgHash)
+
+	var failedCosignerIds []uint32
+	var failedCosignerErrors []error
+	var err error
+	s := new(big.Int).Set(presig.S)
+	for _, j := range presig.Cosigners {
+		if j == presig.SignerId {
+			continue
+		}
+		sj, ok := in[j]
+		if !ok || sj == nil {
+			return nil, nil, fmt.Errorf("missing input from cosigner id=%v", j)
+		}
+		if presig.Rbar[j] == nil || presig.BigS[j] == nil {
+			return nil, nil, fmt.Errorf("invalid presignature")
+		}
+		if err := verifySignatureShare(presig.R, presig.Rbar[j], presig.BigS[j], m, presig.R.X, sj.SElement); err != nil {
+			failedCosignerIds = append(failedCosignerIds, j)
+			failedCosignerErrors = append(failedCosignerErrors, err)
+			continue
+		}
+		s, err = core.Add(s, sj.SElement, q)
+		if err != nil {
+			failedCosignerIds = append(failedCosignerIds, j)
+			failedCosignerErrors = append(failedCosignerErrors, err)
+		}
+	}
+	if len(failedCosignerIds) != 0 {
+		return nil, failedCosignerIds, makeCosignerError(failedCosignerIds, failedCosignerErrors)
+	}
+
+	sOld := new(big.Int).Set(s)
+	s = normalizeS(q, s)
+	v := int(presig.R.Y.Bit(0))
+	if sOld.Cmp(s) != 0 {
+		v ^= 1
+	}
+
+	sigma := &curves.EcdsaSignature{V: v, R: presig.R.X, S: s}
+	if !curves.VerifyEcdsa(presig.PublicKey, presig.MsgHash, sigma) {
+		return nil, nil, fmt.Errorf("signature is not valid")
+	}
+	return sigma, nil, nil
+}
+
+// presignatureId binds the presignature to the key, the nonce and the cosigner set
+func presignatureId(publicKey, r *curves.EcPoint, cosigners []uint32) []byte {
+	h := sha256.New()
+	_, _ = h.Write(publicKey.Bytes())
+	_, _ = h.Write(r.Bytes())
+	var id [4]byte
+	for _, c := range cosigners {
+		binary.BigEndian.PutUint32(id[:], c)
+		_, _ = h.Write(id[:])
+	}
+	return h.Sum(nil)
+}
@@ -0,0 +1,184 @@
+//
+// Copyright Coinbase, Inc. All Rights Reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package participant
+
+import (
+	"crypto/elliptic"
+	"crypto/sha256"
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcec"
+	"github.com/stretchr/testify/require"
+
+	"github.com/nerifnetwork/kryptology/pkg/core/curves"
+)
+
+func presign(t *testing.T, curve elliptic.Curve, verify curves.EcdsaVerify, useDistributed bool) (*curves.EcPoint, map[uint32]*Signer, map[uint32]*Presignature) {
+	pk, signers := setupSignersMap(t, curve, 3, 5, false, verify, useDistributed)
+	inBcast, inP2P := runSigningRounds1To5(t, signers, signingTamper{})
+	presigs := make(map[uint32]*Presignature, len(signers))
+	for id, s := range signers {
+		var err error
+		var failed []uint32
+		presigs[id], failed, err = s.SignPresign(inBcast[id], inP2P[id])
+		require.NoError(t, err)
+		require.Nil(t, failed)
+	}
+	return pk, signers, presigs
+}
+
+// signOnline runs the online round with the presignatures and returns the signature of every signer
+func signOnline(t *testing.T, presigs map[uint32]*Presignature, hash []byte) map[uint32]*curves.EcdsaSignature {
+	bcast := make(map[uint32]*Round6FullBcast, len(presigs))
+	for id, p := range presigs {
+		var err error
+		bcast[id], err = SignOnline(p, hash)
+		require.NoError(t, err)
+	}
+	sigs := make(map[uint32]*curves.EcdsaSignature, len(presigs))
+	for id, p := range presigs {
+		in := make(map[uint32]*Round6FullBcast)
+		for j := range presigs {
+			if j != id {
+				in[j] = bcast[j]
+			}
+		}
+		var err error
+		var failed []uint32
+		sigs[id], failed, err = p.Output(in)
+		require.NoError(t, err)
+		require.Nil(t, failed)
+	}
+	return sigs
+}
+
+func TestPresignatureSigns(t *testing.T) {
+	for _, curve := range []elliptic.Curve{btcec.S256(), elliptic.P256()} {
+		verify := ecdsaVerifier
+		if curve == btcec.S256() {
+			verify = k256Verifier
+		}
+		pk, _, presigs := presign(t, curve, verify, false)
+		for _, p := range presigs {
+			require.Equal(t, presigs[1].Id, p.Id)
+			require.Equal(t, []uint32{1, 2, 3}, p.Cosigners)
+		}
+
+		hash := sha256.Sum256([]byte("presigned transfer"))
+		sigs := signOnline(t, presigs, hash[:])
+		for _, sig := range sigs {
+			require.Equal(t, sigs[1], sig)
+			require.True(t, verify(pk, hash[:], sig))
+		}
+	}
+}
+
+func TestPresignatureSerialization(t *testing.T) {
+	_, _, presigs := presign(t, btcec.S256(), k256Verifier, false)
+	for id, p := range presigs {
+		data, err := json.Marshal(p)
+		require.NoError(t, err)
+		decoded := new(Presignature)
+		require.NoError(t, json.Unmarshal(data, decoded))
+		require.Equal(t, p.Id, decoded.Id)
+		require.True(t, p.R.Equals(decoded.R))
+		require.Len(t, decoded.Rbar, len(p.Cosigners))
+		require.Len(t, decoded.BigS, len(p.Cosigners))
+		for _, j := range p.Cosigners {
+			require.True(t, p.Rbar[j].Equals(decoded.Rbar[j]))
+			require.True(t, p.BigS[j].Equals(decoded.BigS[j]))
+		}
+		presigs[id] = decoded
+	}
+	hash := sha256.Sum256([]byte("stored presignature"))
+	signOnline(t, presigs, hash[:])
+}
+
+func TestPresignatureSingleUse(t *testing.T) {
+	_, signers, presigs := presign(t, btcec.S256(), k256Verifier, false)
+	hash := sha256.Sum256([]byte("first"))
+	signOnline(t, presigs, hash[:])
+
+	// The presignature cannot sign a second message
+	other := sha256.Sum256([]byte("second"))
+	for _, p := range presigs {
+		require.Nil(t, p.K)
+		require.Nil(t, p.Sigma)
+		_, err := SignOnline(p, other[:])
+		require.Error(t, err)
+		_, err = SignOnline(p, hash[:])
+		require.Error(t, err)
+	}
+
+	// Nor can the signer that exported it
+	for _, s := range signers {
+		_, _, err := s.SignRound6Full(other[:], nil, nil)
+		require.Error(t, err)
+		_, err = s.SignAbortReveal()
+		require.Error(t, err)
+	}
+}
+
+func TestPresignatureBoundToCosigners(t *testing.T) {
+	_, _, presigs := presign(t, btcec.S256(), k256Verifier, true)
+	hash := sha256.Sum256([]byte("transfer"))
+	bcast := make(map[uint32]*Round6FullBcast, len(presigs))
+	for id, p := range presigs {
+		var err error
+		bcast[id], err = SignOnline(p, hash[:])
+		require.NoError(t, err)
+	}
+
+	_, _, err := presigs[1].Output(map[uint32]*Round6FullBcast{2: bcast[2]})
+	require.Error(t, err)
+	_, _, err = presigs[1].Output(map[uint32]*Round6FullBcast{2: bcast[2], 4: bcast[3]})
+	require.Error(t, err)
+	_, failed, err := presigs[1].Output(map[uint32]*Round6FullBcast{2: bcast[2], 3: bcast[2]})
+	require.Error(t, err)
+	require.Equal(t, []uint32{3}, failed)
+	sig, _, err := presigs[1].Output(map[uint32]*Round6FullBcast{2: bcast[2], 3: bcast[3]})
+	require.NoError(t, err)
+	require.NotNil(t, sig)
+}
+
+func TestPresignatureIdentifiesWrongShare(t *testing.T) {
+	_, _, presigs := presign(t, btcec.S256(), k256Verifier, false)
+	hash := sha256.Sum256([]byte("transfer"))
+	bcast := make(map[uint32]*Round6FullBcast, len(presigs))
+	for id, p := range presigs {
+		var err error
+		bcast[id], err = SignOnline(p, hash[:])
+		require.NoError(t, err)
+	}
+
+	// Signer 2 broadcasts a wrong s_2
+	bcast[2].SElement = new(big.Int).Add(bcast[2].SElement, big.NewInt(1))
+	_, failed, err := presigs[1].Output(map[uint32]*Round6FullBcast{2: bcast[2], 3: bcast[3]})
+	require.Error(t, err)
+	require.Equal(t, []uint32{2}, failed)
+	_, failed, err = presigs[3].Output(map[uint32]*Round6FullBcast{1: bcast[1], 2: bcast[2]})
+	require.Error(t, err)
+	require.Equal(t, []uint32{2}, failed)
+
+	// A presignature without the points of a cosigner cannot check its share
+	delete(presigs[1].BigS, 3)
+	_, _, err = presigs[1].Output(map[uint32]*Round6FullBcast{2: bcast[2], 3: bcast[3]})
+	require.Error(t, err)
+}
+
+func TestPresignatureInvalidArguments(t *testing.T) {
+	_, err := SignOnline(nil, []byte{1})
+	require.Error(t, err)
+	_, _, err = (&Presignature{}).Output(nil)
+	require.Error(t, err)
+
+	_, signers := setupSignersMap(t, btcec.S256(), 3, 5, false, k256Verifier, false)
+	_, _, err = signers[1].SignPresign(nil, nil)
+	require.Error(t, err)
+}
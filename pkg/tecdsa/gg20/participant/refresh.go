@@ -0,0 +1,552 @@
+//
+// Copyright Coinbase, Inc. All Rights Reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package participant
+
+import (
+	"crypto/elliptic"
+	"fmt"
+	"math/big"
+	"runtime"
+
+	"github.com/nerifnetwork/kryptology/internal"
+	"github.com/nerifnetwork/kryptology/pkg/core"
+	"github.com/nerifnetwork/kryptology/pkg/core/curves"
+	"github.com/nerifnetwork/kryptology/pkg/paillier"
+	v1 "github.com/nerifnetwork/kryptology/pkg/sharing/v1"
+	"github.com/nerifnetwork/kryptology/pkg/tecdsa/gg20/dealer"
+	"github.com/nerifnetwork/kryptology/pkg/tecdsa/gg20/proof"
+)
+
+// RefreshParticipant is a holder of a GG20 key share that re-randomizes its share and rotates
+// its paillier key and proof params together with all other holders of the key.
+// The ECDSA public key stays the same and the shares from before the refresh cannot be
+// combined with the refreshed shares.
+type RefreshParticipant struct {
+	Curve elliptic.Curve
+	State *RefreshState
+	Id    uint32
+	Round uint
+}
+
+// RefreshState encapsulates all the values used in the refresh rounds state machine
+type RefreshState struct {
+	// The key share before the refresh
+	Y            *curves.EcPoint
+	ShamirShare  *v1.ShamirShare
+	PublicShares map[uint32]*dealer.PublicShare
+	ChainCode    []byte
+	Threshold    uint32
+	Limit        uint32
+	// Round 1 variables
+	D           *core.Witness
+	Sk          *paillier.SecretKey
+	ProofParams *dealer.ProofParams
+	// This participants verifiers and shares of zero from FeldmanShare
+	V []*v1.ShareVerifier
+	X []*v1.ShamirShare
+	// Commitments, paillier public keys and proof params received from other participants
+	OtherParticipantData map[uint32]*DkgParticipantCommitment
+	// The refreshed key share returned from Round 3
+	NewShamirShare  *v1.ShamirShare
+	NewPublicShares map[uint32]*dealer.PublicShare
+}
+
+// RefreshRound1Bcast contains values to be broadcast to all players after the completion of refresh round 1
+type RefreshRound1Bcast struct {
+	Identifier       uint32
+	Ci               core.Commitment
+	Pki              *paillier.PublicKey
+	H1i, H2i, Ni     *big.Int
+	Proof1i, Proof2i *proof.CdlProof
+	ModProof         *paillier.ModProof
+}
+
+// RefreshRound2Bcast contains value that will be echo broadcast to all other players.
+type RefreshRound2Bcast struct {
+	Di *core.Witness
+	// FacProofs prove to each player Pj that the new paillier modulus has no small factors
+	FacProofs map[uint32]*paillier.FacProof
+}
+
+// RefreshRound2P2PSend contains value that will be P2PSend to all other player Pj
+type RefreshRound2P2PSend struct {
+	Xij *v1.ShamirShare
+}
+
+// RefreshRound3Bcast contains the proof that the new paillier key is square-free
+type RefreshRound3Bcast struct {
+	PsfProof paillier.PsfProof
+}
+
+// NewRefreshParticipant creates a participant that refreshes the key share in info.
+// Threshold is the number of shares required to sign. The refresh is n-of-n, not t-of-n:
+// every holder of the key must take part and the rounds fail on inputs from a subset.
+// A holder that is left out would neither get a new share nor rotate its paillier key,
+// and the old shares of the left out holders would still combine to the secret key.
+func NewRefreshParticipant(info *dealer.ParticipantData, threshold uint32) (*RefreshParticipant, error) {
+	if info == nil || info.ShamirShare == nil || info.ShamirShare.Value == nil ||
+		info.EcdsaPublicKey == nil || info.EcdsaPublicKey.Curve == nil || info.PublicShares == nil {
+		return nil, internal.ErrNilArguments
+	}
+	curve := info.EcdsaPublicKey.Curve
+	limit := uint32(len(info.PublicShares))
+	if _, err := v1.NewFeldman(threshold, limit, curve); err != nil {
+		return nil, err
+	}
+
+	// The shares are the evaluations of the polynomial at 1,...,n
+	for id := uint32(1); id <= limit; id++ {
+		if share, ok := info.PublicShares[id]; !ok || share == nil || share.Point == nil {
+			return nil, fmt.Errorf("missing public share for participant %d", id)
+		}
+	}
+	if info.Id == 0 || info.Id > limit || info.ShamirShare.Identifier != info.Id {
+		return nil, fmt.Errorf("invalid participant id")
+	}
+
+	// Make sure the share is the one the other participants expect
+	point, err := curves.NewScalarBaseMult(curve, info.ShamirShare.Value.BigInt())
+	if err != nil {
+		return nil, err
+	}
+	if !point.Equals(info.PublicShares[info.Id].Point) {
+		return nil, fmt.Errorf("share does not match its public share")
+	}
+
+	return &RefreshParticipant{
+		Curve: curve,
+		State: &RefreshState{
+			Y:            info.EcdsaPublicKey,
+			ShamirShare:  info.ShamirShare,
+			PublicShares: info.PublicShares,
+			ChainCode:    info.ChainCode,
+			Threshold:    threshold,
+			Limit:        limit,
+		},
+		Id:    info.Id,
+		Round: 1,
+	}, nil
+}
+
+// RefreshRound1 shares zero to all participants and generates a new paillier key and new proof params.
+// It is the same as DistKeyGenRound1 with ui = 0.
+func (rp *RefreshParticipant) RefreshRound1() (*RefreshRound1Bcast, error) {
+	if rp == nil || rp.Curve == nil || rp.State == nil {
+		return nil, internal.ErrNilArguments
+	}
+	if err := rp.verifyRefreshRound(1); err != nil {
+		return nil, err
+	}
+
+	// ski, pki := PaillierKeyGen(1^k) (generate a 2048-bit Paillier key pair
+	_, ski, err := paillier.NewKeysParallel(runtime.NumCPU())
+	if err != nil {
+		return nil, err
+	}
+
+	// Choose 1024-bit safe primes Pi, Qi for the new proof params
+	Pi, Qi, err := genSafePrimes()
+	if err != nil {
+		return nil, err
+	}
+	return rp.refreshRound1(ski, Pi, Qi)
+}
+
+// refreshRound1 performs RefreshRound1 with the given paillier key and proof param primes
+func (rp *RefreshParticipant) refreshRound1(ski *paillier.SecretKey, Pi, Qi *big.Int) (*RefreshRound1Bcast, error) {
+	// Compute [vi0,...,vit], [xi1,...,xin] <- FeldmanShare(g, 0, t, q, [p1...pn])
+	feldman, err := v1.NewFeldman(rp.State.Threshold, rp.State.Limit, rp.Curve)
+	if err != nil {
+		return nil, err
+	}
+	V, X, err := feldman.Split([]byte{0})
+	if err != nil {
+		return nil, err
+	}
+
+	var byteV []byte
+	for i := 0; i < len(V); i++ {
+		byteV = append(byteV, V[i].Bytes()...)
+	}
+
+	// [Ci, Di] = Commit([vi0...vit])
+	Ci, Di, err := core.Commit(byteV)
+	if err != nil {
+		return nil, err
+	}
+
+	// Prove that pki is a Paillier-Blum modulus
+	modProof, err := newModProof(rp.Curve, ski, rp.Id)
+	if err != nil {
+		return nil, err
+	}
+
+	// Compute tildeNi, h1i, h2i and the proofs that they are well formed
+	params, proof1, proof2, err := newCdlProofParams(rp.Curve, Pi, Qi)
+	if err != nil {
+		return nil, err
+	}
+
+	rp.State.D = Di
+	rp.State.Sk = ski
+	rp.State.ProofParams = params
+	rp.State.V = V
+	rp.State.X = X
+	rp.Round = 2
+
+	// EchoBroadcast Ci, pki, tildeNi, h1i, h2i, proof1, proof2, modProof
+	return &RefreshRound1Bcast{
+		rp.Id, Ci, &ski.PublicKey, params.H1, params.H2, params.N, proof1, proof2, modProof,
+	}, nil
+}
+
+// RefreshRound2 verifies the new paillier keys and proof params of the other participants
+// and sends each of them its share of zero. It is the same as DistKeyGenRound2.
+func (rp *RefreshParticipant) RefreshRound2(inBcast map[uint32]*RefreshRound1Bcast) (*RefreshRound2Bcast, map[uint32]*RefreshRound2P2PSend, []uint32, error) {
+	var failedParticipantIds []uint32
+	var failedParticipantErrors []error
+	if rp == nil || rp.Curve == nil || rp.State == nil {
+		return nil, nil, failedParticipantIds, internal.ErrNilArguments
+	}
+	if err := rp.verifyRefreshRound(2); err != nil {
+		return nil, nil, failedParticipantIds, err
+	}
+	if err := rp.verifyParticipants(inBcast); err != nil {
+		return nil, nil, failedParticipantIds, err
+	}
+
+	p2PSend := make(map[uint32]*RefreshRound2P2PSend)
+	facProofs := make(map[uint32]*paillier.FacProof)
+	rp.State.OtherParticipantData = make(map[uint32]*DkgParticipantCommitment)
+
+	// For j = [1...n]
+	for id, param := range inBcast {
+		// If i = j, Continue
+		if id == rp.Id {
+			continue
+		}
+		if param.Identifier != id {
+			failedParticipantIds = append(failedParticipantIds, id)
+			failedParticipantErrors = append(failedParticipantErrors, fmt.Errorf("invalid identifier"))
+			continue
+		}
+		if err := verifyPaillierParams(rp.Curve, id, (*DkgRound1Bcast)(param)); err != nil {
+			failedParticipantIds = append(failedParticipantIds, id)
+			failedParticipantErrors = append(failedParticipantErrors, err)
+			continue
+		}
+
+		// P2PSend xij to player Pj
+		p2PSend[id] = &RefreshRound2P2PSend{
+			Xij: rp.State.X[id-1],
+		}
+
+		rp.State.OtherParticipantData[id] = &DkgParticipantCommitment{
+			PublicKey:  param.Pki,
+			Commitment: param.Ci,
+			ProofParams: &dealer.ProofParams{
+				N:  param.Ni,
+				H1: param.H1i,
+				H2: param.H2i,
+			},
+		}
+
+		// Prove to player Pj that pki has no small factors using the new proof params of Pj
+		facProof, err := newFacProof(rp.Curve, rp.State.Sk, rp.Id, rp.State.OtherParticipantData[id].ProofParams)
+		if err != nil {
+			return nil, nil, failedParticipantIds, err
+		}
+		facProofs[id] = facProof
+	}
+
+	if len(failedParticipantIds) != 0 {
+		return nil, nil, failedParticipantIds, makeParticipantsError(failedParticipantIds, failedParticipantErrors)
+	}
+
+	rp.Round = 3
+
+	// EchoBroadcast Di to all other players. Also return it with P2PSend
+	return &RefreshRound2Bcast{
+		Di:        rp.State.D,
+		FacProofs: facProofs,
+	}, p2PSend, failedParticipantIds, nil
+}
+
+// RefreshRound3 verifies the shares of zero from the other participants and adds them to the share
+// of this participant, then proves that the new paillier key is square-free.
+// The public key does not change because every participant shared zero.
+func (rp *RefreshParticipant) RefreshRound3(inBcast map[uint32]*RefreshRound2Bcast, inP2P map[uint32]*RefreshRound2P2PSend) (*RefreshRound3Bcast, []uint32, error) {
+	var failedParticipantIds []uint32
+	var failedParticipantErrors []error
+	if rp == nil || rp.Curve == nil || rp.State == nil {
+		return nil, failedParticipantIds, internal.ErrNilArguments
+	}
+	if err := rp.verifyRefreshRound(3); err != nil {
+		return nil, failedParticipantIds, err
+	}
+	if err := rp.verifyParticipants(inBcast); err != nil {
+		return nil, failedParticipantIds, err
+	}
+	if err := rp.verifyParticipants(inP2P); err != nil {
+		return nil, failedParticipantIds, err
+	}
+
+	verifierSize := internal.CalcFieldSize(rp.Curve) * 2
+	feldman, err := v1.NewFeldman(rp.State.Threshold, rp.State.Limit, rp.Curve)
+	if err != nil {
+		return nil, failedParticipantIds, err
+	}
+	verifiers := make(map[uint32][]*v1.ShareVerifier, len(inBcast))
+	verifiers[rp.Id] = rp.State.V
+
+	// Set xi = xi + xii
+	xi := rp.State.ShamirShare.Value.Add(rp.State.X[rp.Id-1].Value)
+
+	// for j = [1,...,n]
+	for j, wit := range inBcast {
+		if j == rp.Id {
+			continue
+		}
+
+		// If the new paillier key of Pj has small factors, Abort
+		if err := verifyFacProof(rp.Curve, j, rp.State.OtherParticipantData[j].PublicKey, wit.FacProofs[rp.Id], rp.State.ProofParams); err != nil {
+			failedParticipantIds = append(failedParticipantIds, j)
+			failedParticipantErrors = append(failedParticipantErrors, err)
+			continue
+		}
+
+		// Compute [vj0, . . . , vjt] ←Open(Cj , Dj )
+		if ok, err := core.Open(rp.State.OtherParticipantData[j].Commitment, *wit.Di); !ok {
+			if err == nil {
+				err = fmt.Errorf("invalid witness for participant")
+			}
+			failedParticipantIds = append(failedParticipantIds, j)
+			failedParticipantErrors = append(failedParticipantErrors, err)
+			continue
+		}
+		verifiers[j], err = unmarshalFeldmanVerifiers(rp.Curve, wit.Di.Msg, verifierSize, int(rp.State.Threshold))
+		if err != nil {
+			failedParticipantIds = append(failedParticipantIds, j)
+			failedParticipantErrors = append(failedParticipantErrors, err)
+			continue
+		}
+
+		// If vj0 != 1, Pj did not share zero and would change the public key
+		if !verifiers[j][0].IsIdentity() {
+			failedParticipantIds = append(failedParticipantIds, j)
+			failedParticipantErrors = append(failedParticipantErrors, fmt.Errorf("participant did not share zero"))
+			continue
+		}
+
+		// If FeldmanVerify(g, q, xji, pi, [vj0, . . . , vjt]) = False, Abort
+		xji := inP2P[j].Xij
+		if xji.Identifier != rp.Id {
+			failedParticipantIds = append(failedParticipantIds, j)
+			failedParticipantErrors = append(failedParticipantErrors, fmt.Errorf("share has the wrong identifier"))
+			continue
+		}
+		if ok, err := feldman.Verify(xji, verifiers[j]); !ok {
+			if err == nil {
+				err = fmt.Errorf("invalid share for participant")
+			}
+			failedParticipantIds = append(failedParticipantIds, j)
+			failedParticipantErrors = append(failedParticipantErrors, err)
+			continue
+		}
+
+		// Compute xi = xi + xji mod q
+		xi = xi.Add(xji.Value)
+	}
+
+	if len(failedParticipantIds) != 0 {
+		return nil, failedParticipantIds, makeParticipantsError(failedParticipantIds, failedParticipantErrors)
+	}
+
+	publicShares, err := rp.refreshPublicShares(verifiers)
+	if err != nil {
+		return nil, failedParticipantIds, err
+	}
+
+	// Sanity check that the new share matches its public share
+	point, err := curves.NewScalarBaseMult(rp.Curve, xi.BigInt())
+	if err != nil {
+		return nil, failedParticipantIds, err
+	}
+	if !point.Equals(publicShares[rp.Id].Point) {
+		return nil, failedParticipantIds, fmt.Errorf("refreshed share does not match its public share")
+	}
+
+	// Compute πPSF = ProvePSF(ski.N, ski.φ(N), y, g, q, pi)
+	psfParams := paillier.PsfProofParams{
+		Curve:     rp.Curve,
+		SecretKey: rp.State.Sk,
+		Pi:        rp.Id,
+		Y:         rp.State.Y,
+	}
+	psfProof, err := psfParams.Prove()
+	if err != nil {
+		return nil, failedParticipantIds, err
+	}
+
+	rp.Round = 4
+	rp.State.NewShamirShare = &v1.ShamirShare{
+		Identifier: rp.Id,
+		Value:      xi,
+	}
+	rp.State.NewPublicShares = publicShares
+
+	return &RefreshRound3Bcast{psfProof}, failedParticipantIds, nil
+}
+
+// RefreshRound4 verifies the square-free proofs of the new paillier keys and returns the
+// refreshed participant data. The data from before the refresh must be deleted.
+func (rp *RefreshParticipant) RefreshRound4(inBcast map[uint32]*RefreshRound3Bcast) (*dealer.ParticipantData, []uint32, error) {
+	var failedParticipantIds []uint32
+	var failedParticipantErrors []error
+	if rp == nil || rp.Curve == nil || rp.State == nil {
+		return nil, failedParticipantIds, internal.ErrNilArguments
+	}
+	if err := rp.verifyRefreshRound(4); err != nil {
+		return nil, failedParticipantIds, err
+	}
+	if err := rp.verifyParticipants(inBcast); err != nil {
+		return nil, failedParticipantIds, err
+	}
+
+	verifyPsfParams := paillier.PsfVerifyParams{
+		Curve: rp.Curve,
+		Y:     rp.State.Y,
+	}
+	// for j = [1,...,n]
+	for id, p := range inBcast {
+		if id == rp.Id {
+			continue
+		}
+		verifyPsfParams.PublicKey = rp.State.OtherParticipantData[id].PublicKey
+		verifyPsfParams.Pi = id
+		// if VerifyPSF(\pi_j, pk_j.N, y, g, q, pj) = false, abort
+		if err := p.PsfProof.Verify(&verifyPsfParams); err != nil {
+			failedParticipantIds = append(failedParticipantIds, id)
+			failedParticipantErrors = append(failedParticipantErrors, err)
+			continue
+		}
+	}
+
+	if len(failedParticipantIds) != 0 {
+		return nil, failedParticipantIds, makeParticipantsError(failedParticipantIds, failedParticipantErrors)
+	}
+
+	// Every participant now has its own proof params
+	proofParams := make(map[uint32]*dealer.ProofParams, rp.State.Limit)
+	publicKeys := make(map[uint32]*paillier.PublicKey, rp.State.Limit)
+	for id, data := range rp.State.OtherParticipantData {
+		proofParams[id] = data.ProofParams
+		publicKeys[id] = data.PublicKey
+	}
+	proofParams[rp.Id] = rp.State.ProofParams
+	publicKeys[rp.Id] = &rp.State.Sk.PublicKey
+
+	return &dealer.ParticipantData{
+		Id:             rp.Id,
+		SecretKey:      rp.State.Sk,
+		ShamirShare:    rp.State.NewShamirShare,
+		EcdsaPublicKey: rp.State.Y,
+		KeyGenType: dealer.DistributedKeyGenType{
+			ProofParams: proofParams,
+		},
+		PublicShares: rp.State.NewPublicShares,
+		PublicKeys:   publicKeys,
+		ChainCode:    rp.State.ChainCode,
+	}, failedParticipantIds, nil
+}
+
+// refreshPublicShares adds the public shares of zero to the public shares of all participants.
+// Since vj0 = 1 for every j, Xk' = Xk · ∏_j ∏_{l=1}^{t-1} vjl^{k^l}
+func (rp *RefreshParticipant) refreshPublicShares(verifiers map[uint32][]*v1.ShareVerifier) (map[uint32]*dealer.PublicShare, error) {
+	q := rp.Curve.Params().N
+
+	// Compute vl = ∏_j vjl
+	v := make([]*curves.EcPoint, rp.State.Threshold)
+	for l := 1; l < len(v); l++ {
+		var err error
+		v[l], err = curves.NewScalarBaseMult(rp.Curve, big.NewInt(0))
+		if err != nil {
+			return nil, err
+		}
+		for _, verifier := range verifiers {
+			v[l], err = v[l].Add(verifier[l])
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	publicShares := make(map[uint32]*dealer.PublicShare, rp.State.Limit)
+	for k := uint32(1); k <= rp.State.Limit; k++ {
+		x := rp.State.PublicShares[k].Point
+		pk := big.NewInt(int64(k))
+		ck := big.NewInt(1)
+		for l := 1; l < len(v); l++ {
+			// ck = k^l mod q
+			ck = new(big.Int).Mod(new(big.Int).Mul(ck, pk), q)
+			t, err := v[l].ScalarMult(ck)
+			if err != nil {
+				return nil, err
+			}
+			x, err = x.Add(t)
+			if err != nil {
+				return nil, err
+			}
+		}
+		publicShares[k] = &dealer.PublicShare{Point: x}
+	}
+	return publicShares, nil
+}
+
+// verifyParticipants checks that in has a non-nil input from each other participant of the refresh
+func (rp *RefreshParticipant) verifyParticipants(in interface{}) error {
+	switch m := in.(type) {
+	case map[uint32]*RefreshRound1Bcast:
+		return rp.verifyParticipantIds(len(m), func(id uint32) bool { return m[id] != nil && m[id].Pki != nil })
+	case map[uint32]*RefreshRound2Bcast:
+		return rp.verifyParticipantIds(len(m), func(id uint32) bool { return m[id] != nil && m[id].Di != nil })
+	case map[uint32]*RefreshRound2P2PSend:
+		return rp.verifyParticipantIds(len(m), func(id uint32) bool { return m[id] != nil && m[id].Xij != nil })
+	case map[uint32]*RefreshRound3Bcast:
+		return rp.verifyParticipantIds(len(m), func(id uint32) bool { return m[id] != nil })
+	default:
+		return fmt.Errorf("unexpected input %T", in)
+	}
+}
+
+func (rp *RefreshParticipant) verifyParticipantIds(cnt int, has func(id uint32) bool) error {
+	others := 0
+	for id := uint32(1); id <= rp.State.Limit; id++ {
+		if id == rp.Id {
+			if has(id) {
+				cnt--
+			}
+			continue
+		}
+		if !has(id) {
+			return fmt.Errorf("missing input from participant id=%v, all holders of the key must take part in the refresh", id)
+		}
+		others++
+	}
+	if cnt != others {
+		return internal.ErrIncorrectCount
+	}
+	return nil
+}
+
+// Check refresh round number is valid
+func (rp *RefreshParticipant) verifyRefreshRound(round uint) error {
+	if rp.Round != round {
+		return internal.ErrInvalidRound
+	}
+	return nil
+}
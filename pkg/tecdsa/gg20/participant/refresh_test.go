@@ -0,0 +1,406 @@
+//
+// Copyright Coinbase, Inc. All Rights Reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package participant
+
+import (
+	"crypto/elliptic"
+	crand "crypto/rand"
+	"math/big"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcec"
+	"github.com/stretchr/testify/require"
+
+	"github.com/nerifnetwork/kryptology/pkg/core"
+	"github.com/nerifnetwork/kryptology/pkg/core/curves"
+	"github.com/nerifnetwork/kryptology/pkg/dkg/channel"
+	"github.com/nerifnetwork/kryptology/pkg/paillier"
+	v1 "github.com/nerifnetwork/kryptology/pkg/sharing/v1"
+	"github.com/nerifnetwork/kryptology/pkg/tecdsa/bip32"
+	"github.com/nerifnetwork/kryptology/pkg/tecdsa/gg20/dealer"
+)
+
+type refreshTamper struct {
+	round1 func(rp *RefreshParticipant, bcast *RefreshRound1Bcast)
+	round2 func(id uint32, bcast *RefreshRound2Bcast, p2p map[uint32]*RefreshRound2P2PSend)
+	wire   func(value interface{})
+}
+
+func (tamper refreshTamper) deliver(value interface{}) {
+	if tamper.wire != nil {
+		tamper.wire(value)
+	}
+}
+
+// setupRefreshData creates the trusted dealer participant data of all participants
+func setupRefreshData(t *testing.T, curve elliptic.Curve, threshold, total int) map[uint32]*dealer.ParticipantData {
+	pk, sharesMap, err := dealer.NewDealerShares(curve, uint32(threshold), uint32(total), nil)
+	require.NoError(t, err)
+	pubSharesMap, err := dealer.PreparePublicShares(sharesMap)
+	require.NoError(t, err)
+
+	secretKeys := make(map[uint32]*paillier.SecretKey, total)
+	publicKeys := make(map[uint32]*paillier.PublicKey, total)
+	for i, k := range genPrimesArray(total) {
+		id := uint32(i + 1)
+		secretKeys[id], err = paillier.NewSecretKey(k.p, k.q)
+		require.NoError(t, err)
+		publicKeys[id] = &secretKeys[id].PublicKey
+	}
+	chainCode, err := bip32.NewChainCode()
+	require.NoError(t, err)
+
+	data := make(map[uint32]*dealer.ParticipantData, total)
+	for id, share := range sharesMap {
+		data[id] = &dealer.ParticipantData{
+			Id:             id,
+			SecretKey:      secretKeys[id],
+			ShamirShare:    share.ShamirShare,
+			EcdsaPublicKey: pk,
+			KeyGenType:     dealer.TrustedDealerKeyGenType{ProofParams: dealerParams},
+			PublicShares:   pubSharesMap,
+			PublicKeys:     publicKeys,
+			ChainCode:      chainCode,
+		}
+	}
+	return data
+}
+
+// refreshPrimes pairs up the distinct test primes whose product has the size of a paillier key
+func refreshPrimes() []struct{ p, q *big.Int } {
+	var pairs []struct{ p, q *big.Int }
+	used := make([]bool, len(testPrimes))
+	// Start at the end, genPrimesArray uses the first primes for the keys before the refresh
+	for i := len(testPrimes) - 1; i >= 0; i-- {
+		for j := i - 1; j >= 0 && !used[i]; j-- {
+			bits := new(big.Int).Mul(testPrimes[i], testPrimes[j]).BitLen()
+			if !used[j] && bits >= 2*paillier.PaillierPrimeBits-1 && bits <= 2*paillier.PaillierPrimeBits {
+				used[i], used[j] = true, true
+				pairs = append(pairs, struct{ p, q *big.Int }{testPrimes[i], testPrimes[j]})
+			}
+		}
+	}
+	return pairs
+}
+
+// runRefresh runs the refresh rounds with the test primes. It returns the refreshed data
+// or the errors of the round that failed.
+func runRefresh(t *testing.T, data map[uint32]*dealer.ParticipantData, threshold uint32, tamper refreshTamper) (map[uint32]*dealer.ParticipantData, map[uint32]error) {
+	if testing.Short() {
+		// Every refresh proves and verifies the new paillier keys and proof params
+		t.Skip("skipping refresh in short mode.")
+	}
+	primes := refreshPrimes()
+	require.True(t, len(primes) >= 2*len(data))
+
+	participants := make(map[uint32]*RefreshParticipant, len(data))
+	r1Bcast := make(map[uint32]*RefreshRound1Bcast, len(data))
+	for id, info := range data {
+		var err error
+		participants[id], err = NewRefreshParticipant(info, threshold)
+		require.NoError(t, err)
+		keyPrimes, paramPrimes := primes[2*id-2], primes[2*id-1]
+		sk, err := paillier.NewSecretKey(keyPrimes.p, keyPrimes.q)
+		require.NoError(t, err)
+		r1Bcast[id], err = participants[id].refreshRound1(sk, paramPrimes.p, paramPrimes.q)
+		require.NoError(t, err)
+		if tamper.round1 != nil {
+			tamper.round1(participants[id], r1Bcast[id])
+		}
+		tamper.deliver(r1Bcast[id])
+	}
+
+	r2Bcast := make(map[uint32]*RefreshRound2Bcast, len(data))
+	r2P2P := make(map[uint32]map[uint32]*RefreshRound2P2PSend, len(data))
+	for id, rp := range participants {
+		var err error
+		r2Bcast[id], r2P2P[id], _, err = rp.RefreshRound2(r1Bcast)
+		require.NoError(t, err)
+		if tamper.round2 != nil {
+			tamper.round2(id, r2Bcast[id], r2P2P[id])
+		}
+		tamper.deliver(r2Bcast[id])
+		for _, p2p := range r2P2P[id] {
+			tamper.deliver(p2p)
+		}
+	}
+
+	errs := make(map[uint32]error, len(data))
+	r3Bcast := make(map[uint32]*RefreshRound3Bcast, len(data))
+	for id, rp := range participants {
+		inP2P := make(map[uint32]*RefreshRound2P2PSend, len(data)-1)
+		for j := range participants {
+			if j != id {
+				inP2P[j] = r2P2P[j][id]
+			}
+		}
+		r3Bcast[id], _, errs[id] = rp.RefreshRound3(r2Bcast, inP2P)
+		if errs[id] == nil {
+			tamper.deliver(r3Bcast[id])
+		}
+	}
+	for _, err := range errs {
+		if err != nil {
+			return nil, errs
+		}
+	}
+
+	refreshed := make(map[uint32]*dealer.ParticipantData, len(data))
+	for id, rp := range participants {
+		refreshed[id], _, errs[id] = rp.RefreshRound4(r3Bcast)
+		require.NoError(t, errs[id])
+	}
+	return refreshed, errs
+}
+
+// combineShares returns the secret of the shares with the ids
+func combineShares(t *testing.T, curve elliptic.Curve, threshold int, data map[uint32]*dealer.ParticipantData, ids ...uint32) *big.Int {
+	shamir, err := v1.NewShamir(threshold, len(data), curves.NewField(curve.Params().N))
+	require.NoError(t, err)
+	shares := make([]*v1.ShamirShare, len(ids))
+	for i, id := range ids {
+		shares[i] = data[id].ShamirShare
+	}
+	secret, err := shamir.Combine(shares...)
+	require.NoError(t, err)
+	return new(big.Int).SetBytes(secret)
+}
+
+func TestRefreshWorks(t *testing.T) {
+	for _, test := range []struct {
+		curve            elliptic.Curve
+		threshold, total int
+	}{
+		{btcec.S256(), 3, 5},
+		{elliptic.P256(), 2, 3},
+	} {
+		curve := test.curve
+		data := setupRefreshData(t, curve, test.threshold, test.total)
+		refreshed, _ := runRefresh(t, data, uint32(test.threshold), refreshTamper{})
+
+		// Any set of threshold shares has the same secret as before
+		first := make([]uint32, test.threshold)
+		last := make([]uint32, test.threshold)
+		for i := range first {
+			first[i] = uint32(i + 1)
+			last[i] = uint32(test.total - i)
+		}
+		secret := combineShares(t, curve, test.threshold, data, first...)
+		require.Equal(t, secret, combineShares(t, curve, test.threshold, refreshed, first...))
+		require.Equal(t, secret, combineShares(t, curve, test.threshold, refreshed, last...))
+
+		// The old shares do not combine with the new ones
+		mixed := make(map[uint32]*dealer.ParticipantData, len(data))
+		for id, info := range data {
+			mixed[id] = info
+		}
+		mixed[1] = refreshed[1]
+		require.NotEqual(t, secret, combineShares(t, curve, test.threshold, mixed, first...))
+
+		for id, info := range refreshed {
+			// The public key stays the same, the share and the paillier key change
+			require.True(t, info.EcdsaPublicKey.Equals(data[id].EcdsaPublicKey))
+			require.NotEqual(t, data[id].ShamirShare.Value.BigInt(), info.ShamirShare.Value.BigInt())
+			require.NotEqual(t, data[id].SecretKey.N, info.SecretKey.N)
+			require.False(t, info.KeyGenType.IsTrustedDealer())
+			require.Equal(t, &info.SecretKey.PublicKey, info.PublicKeys[id])
+
+			// Everyone agrees on the new public values
+			require.Equal(t, refreshed[1].PublicKeys, info.PublicKeys)
+			require.Equal(t, refreshed[1].KeyGenType, info.KeyGenType)
+			require.Equal(t, data[id].ChainCode, info.ChainCode)
+			for j, share := range info.PublicShares {
+				require.True(t, share.Point.Equals(refreshed[1].PublicShares[j].Point))
+				point, err := curves.NewScalarBaseMult(curve, refreshed[j].ShamirShare.Value.BigInt())
+				require.NoError(t, err)
+				require.True(t, point.Equals(share.Point))
+			}
+		}
+	}
+}
+
+func TestRefreshedSharesSign(t *testing.T) {
+	data := setupRefreshData(t, btcec.S256(), 3, 5)
+	refreshed, _ := runRefresh(t, data, 3, refreshTamper{})
+
+	// Sign twice with different cosigners
+	for _, cosigners := range [][]uint32{{1, 2, 3}, {2, 4, 5}} {
+		signers := make(map[uint32]*Signer, len(cosigners))
+		for _, id := range cosigners {
+			var err error
+			signers[id], err = NewSigner(refreshed[id], cosigners)
+			require.NoError(t, err)
+			signers[id].state.verify = k256Verifier
+		}
+		errs := runTamperedSigning(t, signers, abortTestHash, signingTamper{})
+		for id, err := range errs {
+			require.NoError(t, err, "signer %d", id)
+		}
+	}
+}
+
+func TestDerivedSharesSign(t *testing.T) {
+	data := setupRefreshData(t, btcec.S256(), 3, 5)
+	cosigners := []uint32{1, 3, 5}
+	signers := make(map[uint32]*Signer, len(cosigners))
+	for _, id := range cosigners {
+		derived, err := data[id].Derive([]uint32{44, 0, 7})
+		require.NoError(t, err)
+		require.False(t, derived.EcdsaPublicKey.Equals(data[id].EcdsaPublicKey))
+		signers[id], err = NewSigner(derived, cosigners)
+		require.NoError(t, err)
+		// The signature is verified against the derived public key
+		signers[id].state.verify = k256Verifier
+	}
+	errs := runTamperedSigning(t, signers, abortTestHash, signingTamper{})
+	for id, err := range errs {
+		require.NoError(t, err, "signer %d", id)
+	}
+}
+
+func TestRefreshRejectsNonZeroShare(t *testing.T) {
+	data := setupRefreshData(t, btcec.S256(), 2, 3)
+	_, errs := runRefresh(t, data, 2, refreshTamper{
+		round1: func(rp *RefreshParticipant, bcast *RefreshRound1Bcast) {
+			if rp.Id != 2 {
+				return
+			}
+			// Share 1 instead of 0, which would change the public key
+			feldman, err := v1.NewFeldman(2, 3, rp.Curve)
+			require.NoError(t, err)
+			rp.State.V, rp.State.X, err = feldman.Split([]byte{1})
+			require.NoError(t, err)
+			bcast.Ci, rp.State.D, err = core.Commit(append(rp.State.V[0].Bytes(), rp.State.V[1].Bytes()...))
+			require.NoError(t, err)
+		},
+	})
+	for id, err := range errs {
+		if id == 2 {
+			continue
+		}
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "participant 2")
+		require.Contains(t, err.Error(), "did not share zero")
+	}
+}
+
+func TestRefreshRejectsInvalidShare(t *testing.T) {
+	data := setupRefreshData(t, btcec.S256(), 2, 3)
+	_, errs := runRefresh(t, data, 2, refreshTamper{
+		round2: func(id uint32, _ *RefreshRound2Bcast, p2p map[uint32]*RefreshRound2P2PSend) {
+			if id == 3 {
+				p2p[1].Xij = p2p[1].Xij.Add(p2p[1].Xij)
+			}
+		},
+	})
+	require.Error(t, errs[1])
+	require.Contains(t, errs[1].Error(), "participant 3")
+	require.NoError(t, errs[2])
+	require.NoError(t, errs[3])
+}
+
+func TestRefreshRejectsInvalidFacProof(t *testing.T) {
+	data := setupRefreshData(t, btcec.S256(), 2, 3)
+	_, errs := runRefresh(t, data, 2, refreshTamper{
+		round2: func(id uint32, bcast *RefreshRound2Bcast, _ map[uint32]*RefreshRound2P2PSend) {
+			if id == 3 {
+				// The proof for participant 2 uses the proof params of participant 2
+				bcast.FacProofs[1] = bcast.FacProofs[2]
+			}
+		},
+	})
+	require.Error(t, errs[1])
+	require.Contains(t, errs[1].Error(), "participant 3")
+	require.NoError(t, errs[2])
+	require.NoError(t, errs[3])
+}
+
+func TestRefreshTransport(t *testing.T) {
+	curve := btcec.S256()
+	data := setupRefreshData(t, curve, 2, 3)
+
+	keys := make(map[uint32]*channel.StaticKey, len(data))
+	public := make(map[uint32]curves.Point, len(data))
+	for id := range data {
+		key, err := channel.NewStaticKey(curves.P256(), crand.Reader)
+		require.NoError(t, err)
+		keys[id] = key
+		public[id] = key.Public
+	}
+	channels := make(map[uint32]*channel.Channel, len(data))
+	for id := range data {
+		ch, err := channel.NewChannel([]byte("refresh"), id, keys[id], public)
+		require.NoError(t, err)
+		channels[id] = ch
+	}
+
+	// Send the shares through the channels and all messages through the wire codecs
+	refreshed, _ := runRefresh(t, data, 2, refreshTamper{
+		round2: func(id uint32, _ *RefreshRound2Bcast, p2p map[uint32]*RefreshRound2P2PSend) {
+			sender := &RefreshParticipant{Curve: curve, Id: id}
+			sealed, err := sender.SealRefreshRound2P2PSend(channels[id], p2p, crand.Reader)
+			require.NoError(t, err)
+			for j := range p2p {
+				recipient := &RefreshParticipant{Curve: curve, Id: j}
+				opened, failed, err := recipient.OpenRefreshRound2P2PSend(channels[j], map[uint32]*channel.Envelope{id: sealed[j]})
+				require.NoError(t, err)
+				require.Empty(t, failed)
+				require.Equal(t, p2p[j].Xij.Bytes(), opened[id].Xij.Bytes())
+				p2p[j] = opened[id]
+			}
+		},
+		wire: wireTransport(t),
+	})
+	require.Equal(t,
+		combineShares(t, curve, 2, data, 1, 2),
+		combineShares(t, curve, 2, refreshed, 2, 3))
+}
+
+func TestRefreshInvalidArguments(t *testing.T) {
+	data := setupRefreshData(t, btcec.S256(), 2, 3)
+	_, err := NewRefreshParticipant(nil, 2)
+	require.Error(t, err)
+	_, err = NewRefreshParticipant(data[1], 4)
+	require.Error(t, err)
+
+	// The share must match its public share
+	wrong := *data[1]
+	wrong.ShamirShare = data[1].ShamirShare.Add(data[1].ShamirShare)
+	_, err = NewRefreshParticipant(&wrong, 2)
+	require.Error(t, err)
+
+	// All holders of the key take part
+	rp, err := NewRefreshParticipant(data[1], 2)
+	require.NoError(t, err)
+	_, _, _, err = rp.RefreshRound2(nil)
+	require.Error(t, err)
+	rp.Round = 2
+	_, _, _, err = rp.RefreshRound2(map[uint32]*RefreshRound1Bcast{2: {}})
+	require.Error(t, err)
+	_, _, err = rp.RefreshRound3(nil, nil)
+	require.Error(t, err)
+}
+
+func TestRefreshRejectsThresholdSubset(t *testing.T) {
+	data := setupRefreshData(t, btcec.S256(), 2, 3)
+	primes := refreshPrimes()
+	r1Bcast := make(map[uint32]*RefreshRound1Bcast, 2)
+	participants := make(map[uint32]*RefreshParticipant, 2)
+	for _, id := range []uint32{1, 2} {
+		var err error
+		participants[id], err = NewRefreshParticipant(data[id], 2)
+		require.NoError(t, err)
+		sk, err := paillier.NewSecretKey(primes[2*id-2].p, primes[2*id-2].q)
+		require.NoError(t, err)
+		r1Bcast[id], err = participants[id].refreshRound1(sk, primes[2*id-1].p, primes[2*id-1].q)
+		require.NoError(t, err)
+	}
+
+	// Two shares are enough to sign but not to refresh, participant 3 must take part
+	_, _, _, err := participants[1].RefreshRound2(r1Bcast)
+	require.ErrorContains(t, err, "all holders of the key")
+}
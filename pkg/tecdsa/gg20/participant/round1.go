@@ -22,6 +22,8 @@ type Round1Bcast struct {
 	C          core.Commitment
 	Ctxt       *big.Int
 	Proof      *proof.Range1Proof
+	// Signature is the signature of the sender on Ctxt under its additive public share
+	Signature *curves.EcdsaSignature
 }
 
 type Round1P2PSend struct {
@@ -90,6 +92,10 @@ func (signer *Signer) SignRound1() (*Round1Bcast, map[uint32]*Round1P2PSend, []u
 		C:          Ci,
 		Ctxt:       ctxt,
 	}
+	bcast.Signature, err = signer.signTranscript(transcriptDigest(&bcast, signer.Id, 0, ctxt, nil))
+	if err != nil {
+		return nil, nil, nil, err
+	}
 	p2p := make(map[uint32]*Round1P2PSend)
 
 	if signer.state.keyGenType.IsTrustedDealer() {
@@ -138,6 +144,7 @@ func (signer *Signer) SignRound1() (*Round1Bcast, map[uint32]*Round1P2PSend, []u
 	signer.state.Di = Di
 	signer.state.ci = ctxt
 	signer.state.ri = r
+	signer.state.signatures(signer.Id).ciphertext = bcast.Signature
 
 	// (figure 7) 7. Broadcast (C_i, c_i, \pi^{Range1}_i)
 	// (figure 8) 9. P2PSend(\pi^{Range1}_ij)
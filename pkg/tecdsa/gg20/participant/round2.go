@@ -10,6 +10,7 @@ import (
 	"math/big"
 
 	"github.com/nerifnetwork/kryptology/pkg/core"
+	"github.com/nerifnetwork/kryptology/pkg/core/curves"
 	"github.com/nerifnetwork/kryptology/pkg/paillier"
 	"github.com/nerifnetwork/kryptology/pkg/tecdsa/gg20/proof"
 )
@@ -17,6 +18,9 @@ import (
 // Round2P2PSend is all the values that need to be sent to each player
 type Round2P2PSend struct {
 	Proof2, Proof3 *proof.ResponseProof
+	// Signature is the signature of the sender on the ciphertexts of Proof2 and Proof3
+	// under its additive public share
+	Signature *curves.EcdsaSignature
 }
 
 // SignRound2 performs round 2 signing operations for a single signer
@@ -42,6 +46,8 @@ func (signer *Signer) SignRound2(inBcast map[uint32]*Round1Bcast, inP2P map[uint
 	signer.state.vuj = make(map[uint32]*big.Int, cnt)
 	signer.state.cj = make(map[uint32]paillier.Ciphertext, cnt)
 	signer.state.Cj = make(map[uint32]core.Commitment, cnt)
+	signer.state.betaTickj = make(map[uint32]*big.Int, cnt)
+	signer.state.vuTickj = make(map[uint32]*big.Int, cnt)
 
 	// This is outside the loop for efficiency since the only changing value is the
 	// params ciphertext
@@ -61,6 +67,14 @@ func (signer *Signer) SignRound2(inBcast map[uint32]*Round1Bcast, inP2P map[uint
 			continue
 		}
 
+		// The ciphertext goes into the abort evidence and must be signed by its sender
+		digest := transcriptDigest(param, j, 0, param.Ctxt, nil)
+		if err := verifyTranscript(signer.PublicSharesMap[j].Point, digest, param.Signature); err != nil {
+			failedCosignerIds = append(failedCosignerIds, j)
+			failedCosignerErrors = append(failedCosignerErrors, err)
+			continue
+		}
+
 		// 3. if MtAVerifyRange(\pi_j^{Range1}, g, q, N~, h1, h2, c_j) == False then Abort
 		pp.Pk = signer.state.pks[j]
 		pp.C = param.Ctxt
@@ -106,16 +120,25 @@ func (signer *Signer) SignRound2(inBcast map[uint32]*Round1Bcast, inP2P map[uint
 		signer.state.betaj[j] = proofGamma.Beta
 		signer.state.vuj[j] = proofW.Beta
 		signer.state.Cj[j] = param.C
+		signer.state.betaTickj[j] = proofGamma.BetaTick
+		signer.state.vuTickj[j] = proofW.BetaTick
+		signer.state.signatures(j).ciphertext = param.Signature
 
 		// Beta and vu are not sent to other signers
 		proofGamma.Beta = nil
 		proofW.Beta = nil
+		proofGamma.BetaTick = nil
+		proofW.BetaTick = nil
 
 		// 6. P2PSend(c^{gamma}_{ji}, c_^{W}_{ji}, \pi^{Range2}_{ji}, \pi^{Range3}_{ji})
 		p2PSend[j] = &Round2P2PSend{
 			Proof2: proofGamma,
 			Proof3: proofW,
 		}
+		digest = transcriptDigest(p2PSend[j], signer.Id, j, signer.state.ci, param.Ctxt, proofGamma.C2.Bytes(), proofW.C2.Bytes())
+		if p2PSend[j].Signature, err = signer.signTranscript(digest); err != nil {
+			return nil, nil, err
+		}
 	}
 
 	if len(failedCosignerIds) != 0 {
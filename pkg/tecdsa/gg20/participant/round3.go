@@ -7,10 +7,13 @@
 package participant
 
 import (
+	"crypto/elliptic"
 	"fmt"
 	"math/big"
 
 	"github.com/nerifnetwork/kryptology/pkg/core"
+	"github.com/nerifnetwork/kryptology/pkg/core/curves"
+	"github.com/nerifnetwork/kryptology/pkg/paillier"
 	"github.com/nerifnetwork/kryptology/pkg/tecdsa/gg20/proof"
 )
 
@@ -26,6 +29,11 @@ type Round3Bcast struct {
 	// they won't be accessible to `BetterRound3Bcast`. So the json.Marhsal uses the default methods
 	// and since the two fields of `big.int` are not exported, the data of `BetterRound3Bcast`
 	// won't actually be serialized and its deserialization results in nil.
+
+	// T is the commitment T_i = g^{σ_i} h^{l_i} to σ_i from [spec] §4.3
+	T *curves.EcPoint
+	// Signature is the signature of the sender on DeltaElement and T under its additive public share
+	Signature *curves.EcdsaSignature
 }
 
 // SignRound3 performs the round 3 signing operation according to
@@ -59,18 +67,28 @@ func (s *Signer) SignRound3(inP2P map[uint32]*Round2P2PSend) (*Round3Bcast, []ui
 		C1:           s.state.ci,
 	}
 
+	alphaCtj := make(map[uint32]paillier.Ciphertext, len(inP2P))
+	muCtj := make(map[uint32]paillier.Ciphertext, len(inP2P))
 	for j, value := range inP2P {
 		// 4. if i == j Continue
 		if j == s.Id {
 			continue
 		}
 
-		if value == nil {
+		if value == nil || value.Proof2 == nil || value.Proof3 == nil {
 			failedCosignerIds = append(failedCosignerIds, j)
 			failedCosignerErrors = append(failedCosignerErrors, fmt.Errorf("P2P message for participant %v cannot be nil", j))
 			continue
 		}
 
+		// The MtA ciphertexts go into the abort evidence and must be signed by their sender
+		digest := transcriptDigest(value, j, s.Id, s.state.cj[j], s.state.ci, intBytes(value.Proof2.C2), intBytes(value.Proof3.C2))
+		if err := verifyTranscript(s.PublicSharesMap[j].Point, digest, value.Signature); err != nil {
+			failedCosignerIds = append(failedCosignerIds, j)
+			failedCosignerErrors = append(failedCosignerErrors, err)
+			continue
+		}
+
 		// 5. Compute α_ij = MtAFinalize(g,q,sk_i,pk_i,N~,h1,h2,c_i,c_ij,π_ij)
 		alphaij, err := value.Proof2.Finalize(verifyParams)
 
@@ -119,20 +137,62 @@ func (s *Signer) SignRound3(inP2P map[uint32]*Round2P2PSend) (*Round3Bcast, []ui
 			failedCosignerErrors = append(failedCosignerErrors, err)
 			continue
 		}
+		alphaCtj[j] = value.Proof2.C2
+		muCtj[j] = value.Proof3.C2
+		s.state.signatures(j).response = value.Signature
 	}
 
 	if len(failedCosignerIds) != 0 {
 		return nil, failedCosignerIds, makeCosignerError(failedCosignerIds, failedCosignerErrors)
 	}
 
+	// Commit to σ_i with T_i = g^{σ_i} h^{l_i}, S_i = R^{σ_i} is proven consistent with it in round 5
+	// [spec] §4.3
+	li, err := core.Rand(s.Curve.Params().N)
+	if err != nil {
+		return nil, nil, err
+	}
+	Ti, err := commitSigma(s.Curve, sigmai, li)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	bcast := &Round3Bcast{DeltaElement: deltai, T: Ti}
+	bcast.Signature, err = s.signTranscript(transcriptDigest(bcast, s.Id, 0, s.state.ci, nil, deltai.Bytes(), Ti.Bytes()))
+	if err != nil {
+		return nil, nil, err
+	}
+
 	// 12. Return δ_i, σ_i
 	// Store \delta_i, \sigma_i for future rounds
 	s.state.deltai = deltai
 	s.state.sigmai = sigmai
+	s.state.li = li
+	s.state.Ti = Ti
+	s.state.alphaCtj = alphaCtj
+	s.state.muCtj = muCtj
+	s.state.signatures(s.Id).delta = bcast.Signature
 
 	// Increment the round counter
 	s.Round = 4
 
 	// 11. Broadcast δ_i to all other players
-	return &Round3Bcast{deltai}, nil, nil
+	return bcast, nil, nil
+}
+
+// commitSigma returns T = g^σ h^l
+func commitSigma(curve elliptic.Curve, sigma, l *big.Int) (*curves.EcPoint, error) {
+	h, err := proof.StGenerator(curve)
+	if err != nil {
+		return nil, err
+	}
+	gSigma, err := curves.NewScalarBaseMult(curve, sigma)
+	if err != nil {
+		return nil, err
+	}
+	hl, err := h.ScalarMult(l)
+	if err != nil {
+		return nil, err
+	}
+	return gSigma.Add(hl)
 }
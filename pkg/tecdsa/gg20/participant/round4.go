@@ -7,15 +7,19 @@
 package participant
 
 import (
+	"fmt"
 	"math/big"
 
 	"github.com/nerifnetwork/kryptology/pkg/core"
+	"github.com/nerifnetwork/kryptology/pkg/core/curves"
 )
 
 // Round4Bcast are the values to be broadcast to the other players at the conclusion
 // of signing round 4
 type Round4Bcast struct {
 	Witness *core.Witness
+	// Signature is the signature of the sender on Γ_i under its additive public share
+	Signature *curves.EcdsaSignature
 }
 
 // SignRound4 performs the round 4 signing operation. It takes input
@@ -34,6 +38,8 @@ func (s *Signer) SignRound4(inBcast map[uint32]*Round3Bcast) (*Round4Bcast, []ui
 
 	// 1. Set δ = δ_i
 	delta := new(big.Int).Set(s.state.deltai)
+	deltas := make(map[uint32]*big.Int, len(inBcast))
+	ts := make(map[uint32]*curves.EcPoint, len(inBcast))
 
 	// 2. For j=[1,...,t+1]
 	for j, deltaj := range inBcast {
@@ -41,6 +47,24 @@ func (s *Signer) SignRound4(inBcast map[uint32]*Round3Bcast) (*Round4Bcast, []ui
 		if j == s.Id {
 			continue
 		}
+		if deltaj == nil || deltaj.DeltaElement == nil {
+			failedCosignerIds = append(failedCosignerIds, j)
+			failedCosignerErrors = append(failedCosignerErrors, fmt.Errorf("input delta cannot be nil"))
+			continue
+		}
+		if err := checkPoint(deltaj.T); err != nil {
+			failedCosignerIds = append(failedCosignerIds, j)
+			failedCosignerErrors = append(failedCosignerErrors, fmt.Errorf("invalid T_j: %v", err))
+			continue
+		}
+
+		// δ_j and T_j go into the abort evidence and must be signed by their sender
+		digest := transcriptDigest(deltaj, j, 0, s.state.cj[j], nil, deltaj.DeltaElement.Bytes(), pointBytes(deltaj.T))
+		if err := verifyTranscript(s.PublicSharesMap[j].Point, digest, deltaj.Signature); err != nil {
+			failedCosignerIds = append(failedCosignerIds, j)
+			failedCosignerErrors = append(failedCosignerErrors, err)
+			continue
+		}
 
 		// 4. Compute δ = δ + δ_j mod q
 		delta, err = core.Add(delta, deltaj.DeltaElement, s.Curve.Params().N)
@@ -49,21 +73,31 @@ func (s *Signer) SignRound4(inBcast map[uint32]*Round3Bcast) (*Round4Bcast, []ui
 			failedCosignerErrors = append(failedCosignerErrors, err)
 			continue
 		}
+		deltas[j] = deltaj.DeltaElement
+		ts[j] = deltaj.T
+		s.state.signatures(j).delta = deltaj.Signature
 	}
 
 	if len(failedCosignerIds) != 0 {
 		return nil, failedCosignerIds, makeCosignerError(failedCosignerIds, failedCosignerErrors)
 	}
 
+	bcast := &Round4Bcast{Witness: s.state.Di}
+	bcast.Signature, err = s.signTranscript(transcriptDigest(bcast, s.Id, 0, s.state.ci, nil, s.state.Gammai.Bytes()))
+	if err != nil {
+		return nil, nil, err
+	}
+
 	// 6. Return δ
 	// Store δ as signer state
 	s.state.delta = delta
+	s.state.deltaj = deltas
+	s.state.Tj = ts
+	s.state.signatures(s.Id).gamma = bcast.Signature
 
 	// Increment our round counter on success
 	s.Round = 5
 
 	// 5. Broadcast D_i to all other players
-	return &Round4Bcast{
-		s.state.Di,
-	}, nil, nil
+	return bcast, nil, nil
 }
@@ -19,6 +19,11 @@ import (
 type Round5Bcast struct {
 	Rbar  *curves.EcPoint
 	Proof *proof.PdlProof
+	// S is S_i = R^{σ_i} and SProof its consistency with T_i from [spec] §4.3
+	S      *curves.EcPoint
+	SProof *proof.StProof
+	// Signature is the signature of the sender on Rbar and S under its additive public share
+	Signature *curves.EcdsaSignature
 }
 
 // Round5P2PSend are the values sent to each participant at the conclusion of
@@ -42,6 +47,7 @@ func (signer *Signer) SignRound5(inBcast map[uint32]*Round4Bcast) (*Round5Bcast,
 
 	// 1. Compute R = g^{γ_i} in G
 	R := signer.state.Gammai
+	gammas := make(map[uint32]*curves.EcPoint, len(inBcast))
 
 	// 2. For j = [1,...,t+1]
 	for j, d := range inBcast {
@@ -77,6 +83,14 @@ func (signer *Signer) SignRound5(inBcast map[uint32]*Round4Bcast) (*Round5Bcast,
 			continue
 		}
 
+		// Γ_j goes into the abort evidence and must be signed by its sender
+		digest := transcriptDigest(d, j, 0, signer.state.cj[j], nil, Gammaj.Bytes())
+		if err := verifyTranscript(signer.PublicSharesMap[j].Point, digest, d.Signature); err != nil {
+			failedCosignerIds = append(failedCosignerIds, j)
+			failedCosignerErrors = append(failedCosignerErrors, err)
+			continue
+		}
+
 		// 6. Compute R = R · Γ_j in G
 		R, err = R.Add(Gammaj)
 		if err != nil {
@@ -84,6 +98,8 @@ func (signer *Signer) SignRound5(inBcast map[uint32]*Round4Bcast) (*Round5Bcast,
 			failedCosignerErrors = append(failedCosignerErrors, err)
 			continue
 		}
+		gammas[j] = Gammaj
+		signer.state.signatures(j).gamma = d.Signature
 	}
 
 	if len(failedCosignerIds) != 0 {
@@ -106,13 +122,35 @@ func (signer *Signer) SignRound5(inBcast map[uint32]*Round4Bcast) (*Round5Bcast,
 		return nil, nil, nil, err
 	}
 
-	// Sanity check
-	Rbark, err := R.ScalarMult(signer.state.sigmai)
+	// Compute S_i = R^{σ_i} and prove that it hides the σ_i of T_i
+	// [spec] §4.3
+	Si, err := R.ScalarMult(signer.state.sigmai)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	h, err := proof.StGenerator(signer.Curve)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	stParams := proof.StProofParams{
+		Curve: signer.Curve,
+		Sigma: signer.state.sigmai,
+		L:     signer.state.li,
+		H:     h,
+		R:     R,
+		T:     signer.state.Ti,
+		S:     Si,
+	}
+	sProof, err := stParams.Prove()
 	if err != nil {
 		return nil, nil, nil, err
 	}
 
-	bcast := &Round5Bcast{Rbari, nil}
+	bcast := &Round5Bcast{Rbar: Rbari, S: Si, SProof: sProof}
+	bcast.Signature, err = signer.signTranscript(transcriptDigest(bcast, signer.Id, 0, signer.state.ci, nil, Rbari.Bytes(), Si.Bytes()))
+	if err != nil {
+		return nil, nil, nil, err
+	}
 	p2p := make(map[uint32]*Round5P2PSend)
 	pdlParams := proof.PdlProofParams{
 		Curve:   signer.Curve,
@@ -170,7 +208,9 @@ func (signer *Signer) SignRound5(inBcast map[uint32]*Round4Bcast) (*Round5Bcast,
 
 	// 12. Set \overline{R}_i
 	signer.state.Rbari = Rbari
-	signer.state.Rbark = Rbark
+	signer.state.Si = Si
+	signer.state.Gammaj = gammas
+	signer.state.signatures(signer.Id).rbar = bcast.Signature
 
 	signer.Round = 6
 
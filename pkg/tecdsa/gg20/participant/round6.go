@@ -10,6 +10,7 @@ import (
 	"fmt"
 	"math/big"
 
+	"github.com/nerifnetwork/kryptology/internal"
 	"github.com/nerifnetwork/kryptology/pkg/core"
 	"github.com/nerifnetwork/kryptology/pkg/core/curves"
 	"github.com/nerifnetwork/kryptology/pkg/tecdsa/gg20/proof"
@@ -65,10 +66,24 @@ func (signer *Signer) signRound6Offline(inBcast map[uint32]*Round5Bcast, inP2P m
 	var failedCosignerIds []uint32
 	var failedCosignerErrors []error
 
-	var err error
-
-	// 1. Set V = \bar{R}_i
+	// 1. Set V = \bar{R}_i and S = S_i
 	v := signer.state.Rbari
+	S := signer.state.Si
+
+	h, err := proof.StGenerator(signer.Curve)
+	if err != nil {
+		return nil, err
+	}
+
+	// Keep the \bar{R}_j and S_j for the abort evidence and the output round
+	signer.state.Rbarj = make(map[uint32]*curves.EcPoint, len(inBcast))
+	signer.state.Sj = make(map[uint32]*curves.EcPoint, len(inBcast))
+	for j, value := range inBcast {
+		if j != signer.Id && value != nil {
+			signer.state.Rbarj[j] = value.Rbar
+			signer.state.Sj[j] = value.S
+		}
+	}
 
 	// 2. For j=[1,...,t+1]
 	for j, value := range inBcast {
@@ -76,6 +91,20 @@ func (signer *Signer) signRound6Offline(inBcast map[uint32]*Round5Bcast, inP2P m
 		if j == signer.Id {
 			continue
 		}
+		if value == nil || value.Rbar == nil || value.S == nil || value.SProof == nil {
+			failedCosignerIds = append(failedCosignerIds, j)
+			failedCosignerErrors = append(failedCosignerErrors, fmt.Errorf("input \\bar{R}_j and S_j cannot be nil"))
+			continue
+		}
+
+		// \bar{R}_j and S_j go into the abort evidence and must be signed by their sender
+		digest := transcriptDigest(value, j, 0, signer.state.cj[j], nil, pointBytes(value.Rbar), pointBytes(value.S))
+		if err := verifyTranscript(signer.PublicSharesMap[j].Point, digest, value.Signature); err != nil {
+			failedCosignerIds = append(failedCosignerIds, j)
+			failedCosignerErrors = append(failedCosignerErrors, err)
+			continue
+		}
+		signer.state.signatures(j).rbar = value.Signature
 
 		// 4. TrustedDealer - If VerifyPDL(πkCONSIST,g,q,R,pkj,N,h1,h2,cj,Rj) = False, Abort
 		// 4. DKG - If VerifyPDL(πkCONSIST_j,g,q,R,pkj,Nj,h1j,h2j,cj,Rj) = False, Abort
@@ -102,6 +131,21 @@ func (signer *Signer) signRound6Offline(inBcast map[uint32]*Round5Bcast, inP2P m
 			}
 		}
 
+		// S_j must hide the σ_j committed to in T_j
+		// [spec] §4.3
+		stParams := &proof.StVerifyParams{
+			Curve: signer.Curve,
+			H:     h,
+			R:     signer.state.R,
+			T:     signer.state.Tj[j],
+			S:     value.S,
+		}
+		if err := value.SProof.Verify(stParams); err != nil {
+			failedCosignerIds = append(failedCosignerIds, j)
+			failedCosignerErrors = append(failedCosignerErrors, err)
+			continue
+		}
+
 		// 5. Compute V = V · R_j in G
 		v, err = v.Add(value.Rbar)
 		if err != nil {
@@ -109,6 +153,14 @@ func (signer *Signer) signRound6Offline(inBcast map[uint32]*Round5Bcast, inP2P m
 			failedCosignerErrors = append(failedCosignerErrors, err)
 			continue
 		}
+
+		// Compute S = S · S_j in G
+		S, err = S.Add(value.S)
+		if err != nil {
+			failedCosignerIds = append(failedCosignerIds, j)
+			failedCosignerErrors = append(failedCosignerErrors, err)
+			continue
+		}
 	}
 
 	if len(failedCosignerIds) != 0 {
@@ -116,9 +168,19 @@ func (signer *Signer) signRound6Offline(inBcast map[uint32]*Round5Bcast, inP2P m
 	}
 
 	// 6 If V != g, Abort
+	// Nobody can be blamed yet, the cosigners reveal their ephemeral values with SignAbortReveal
 	if !v.IsBasePoint() {
+		signer.state.revealable = true
 		return nil, fmt.Errorf("v != g")
 	}
+
+	// If S != y, Abort
+	// [spec] §4.3, the proofs bind each S_j to σ_j but the MtA shares behind σ_j are only
+	// checked once the cosigners reveal them with SignAbortReveal
+	if !S.Equals(signer.PublicKey) {
+		signer.state.revealable = true
+		return nil, fmt.Errorf("S != y")
+	}
 	// 7. return r, k, \sigma,
 	// These are already stored
 	return nil, nil
@@ -174,6 +236,7 @@ func (signer *Signer) SignOutput(in map[uint32]*Round6FullBcast) (*curves.EcdsaS
 	}
 	// 1. Set s = s_i
 	s := new(big.Int).Set(signer.state.si)
+	m := new(big.Int).SetBytes(signer.state.msgHash)
 
 	// 2. For j = [1,...,t+1]
 	for j, sj := range in {
@@ -181,6 +244,19 @@ func (signer *Signer) SignOutput(in map[uint32]*Round6FullBcast) (*curves.EcdsaS
 		if j == signer.Id {
 			continue
 		}
+		if sj == nil {
+			failedCosignerIds = append(failedCosignerIds, j)
+			failedCosignerErrors = append(failedCosignerErrors, fmt.Errorf("input s_j cannot be nil"))
+			continue
+		}
+
+		// s_j must match the \bar{R}_j and S_j of the cosigner
+		// [spec] §4.3
+		if err := verifySignatureShare(signer.state.R, signer.state.Rbarj[j], signer.state.Sj[j], m, signer.state.r, sj.SElement); err != nil {
+			failedCosignerIds = append(failedCosignerIds, j)
+			failedCosignerErrors = append(failedCosignerErrors, err)
+			continue
+		}
 
 		// 4. Compute s = s + s_j mod q
 		s, err = core.Add(s, sj.SElement, signer.Curve.Params().N)
@@ -215,7 +291,41 @@ func (signer *Signer) SignOutput(in map[uint32]*Round6FullBcast) (*curves.EcdsaS
 	return sigma, nil, nil
 }
 
+// verifySignatureShare checks that s_j = m k_j + r σ_j for \bar{R}_j = R^{k_j} and S_j = R^{σ_j},
+// that is R^{s_j} = \bar{R}_j^m S_j^r
+// [spec] §4.3
+func verifySignatureShare(R, Rbarj, Sj *curves.EcPoint, m, r, sj *big.Int) error {
+	if R == nil || Rbarj == nil || Sj == nil || sj == nil {
+		return internal.ErrNilArguments
+	}
+	lhs, err := R.ScalarMult(sj)
+	if err != nil {
+		return err
+	}
+	rbarM, err := Rbarj.ScalarMult(m)
+	if err != nil {
+		return err
+	}
+	sR, err := Sj.ScalarMult(r)
+	if err != nil {
+		return err
+	}
+	rhs, err := rbarM.Add(sR)
+	if err != nil {
+		return err
+	}
+	if !lhs.Equals(rhs) {
+		return fmt.Errorf("s_j does not match \\bar{R}_j and S_j")
+	}
+	return nil
+}
+
 func (signer Signer) normalizeS(s *big.Int) *big.Int {
+	return normalizeS(signer.Curve.Params().N, s)
+}
+
+// normalizeS returns the low S form of s for the group order q
+func normalizeS(q, s *big.Int) *big.Int {
 	// Normalize the signature to a "low S" form. In ECDSA, signatures are
 	// of the form (r, s) where r and s are numbers lying in some finite
 	// field. The verification equation will pass for (r, s) iff it passes
@@ -235,14 +345,14 @@ func (signer Signer) normalizeS(s *big.Int) *big.Int {
 	// lies in the lower half of its range.
 	// See <https://en.bitcoin.it/wiki/BIP_0062#Low_S_values_in_signatures>
 	qDiv2 := new(big.Int)
-	qDiv2 = qDiv2.Div(signer.Curve.Params().N, core.Two)
+	qDiv2 = qDiv2.Div(q, core.Two)
 
 	// Check whether a scalar is higher than the group order divided
 	// by 2. If true, we negate s.
 	// Not constant time, it would be better to conditionally negate with check in constant time
 	// but since `s` is a public value anyway, this is allowed to be variable time
 	if s.Cmp(qDiv2) == 1 {
-		return new(big.Int).Sub(signer.Curve.Params().N, s)
+		return new(big.Int).Sub(q, s)
 	}
 	return new(big.Int).Set(s)
 }
@@ -396,9 +396,13 @@ func TestSignRound3(t *testing.T) {
 				s.state.betaj[j] = core.One
 				s.state.vuj[j] = core.One
 				p2p[j] = &Round2P2PSend{
-					proof.ResponseProofMock(th13een),
-					proof.ResponseProofMock(th13een),
+					Proof2: proof.ResponseProofMock(th13een),
+					Proof3: proof.ResponseProofMock(th13een),
 				}
+				digest := transcriptDigest(p2p[j], j, s.Id, nil, nil, intBytes(p2p[j].Proof2.C2), intBytes(p2p[j].Proof3.C2))
+				var err error
+				p2p[j].Signature, err = signers[j].signTranscript(digest)
+				require.NoError(t, err)
 			}
 
 			// Test that invalid signing rounds states are rejected
@@ -461,7 +465,10 @@ func TestSignRound4(t *testing.T) {
 			if s.Id == j {
 				continue
 			}
-			ones[j] = &Round3Bcast{core.One}
+			ones[j] = &Round3Bcast{DeltaElement: core.One, T: s.PublicKey}
+			var err error
+			ones[j].Signature, err = signers[j].signTranscript(transcriptDigest(ones[j], j, 0, nil, nil, core.One.Bytes(), s.PublicKey.Bytes()))
+			require.NoError(t, err)
 			s.state.cosigners[j] = true
 		}
 		s.state.ki = core.One
@@ -469,6 +476,7 @@ func TestSignRound4(t *testing.T) {
 		s.state.deltai = core.One
 		s.state.sigmai = core.One
 		s.state.Di = &core.Witness{}
+		s.state.Gammai = s.PublicKey
 
 		// Test that invalid signing rounds states are rejected
 		invalid := []uint{0, 1, 2, 3, 5, 6}
@@ -782,10 +790,10 @@ func fullroundstest3Signers(t *testing.T, curve elliptic.Curve, msg []byte, veri
 		require.Nil(t, failedCosignerIds)
 		require.NoError(t, err)
 
-		Rbark, err := signers[1].state.Rbark.Add(signers[2].state.Rbark)
+		Rbark, err := signers[1].state.Si.Add(signers[2].state.Si)
 		require.NoError(t, err)
 
-		Rbark, err = Rbark.Add(signers[3].state.Rbark)
+		Rbark, err = Rbark.Add(signers[3].state.Si)
 		require.NoError(t, err)
 
 		Rbark.Y, err = core.Neg(Rbark.Y, curve.Params().P)
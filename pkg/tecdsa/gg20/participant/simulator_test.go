@@ -0,0 +1,328 @@
+//
+// Copyright Coinbase, Inc. All Rights Reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package participant
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/sha256"
+	"fmt"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/nerifnetwork/kryptology/pkg/core/curves"
+	"github.com/nerifnetwork/kryptology/pkg/core/protocol"
+	"github.com/nerifnetwork/kryptology/pkg/core/protocol/simulator"
+)
+
+// signerIterator runs the signing rounds of a Signer as a protocol.Iterator
+type signerIterator struct {
+	protocol.Stepper
+	signer    *Signer
+	hash      []byte
+	signature *curves.EcdsaSignature
+}
+
+func newSignerIterator(signer *Signer, hash []byte) *signerIterator {
+	it := &signerIterator{signer: signer, hash: hash}
+	it.Steps = []func(*protocol.Message) (*protocol.Message, error){
+		it.round1, it.round2, it.round3, it.round4, it.round5, it.round6, it.output,
+	}
+	return it
+}
+
+func (it *signerIterator) Result(version uint) (*protocol.Message, error) {
+	if it.signature == nil {
+		return nil, fmt.Errorf("signing has not finished")
+	}
+	return &protocol.Message{
+		Protocol: protocol.Gg20Sign,
+		Version:  version,
+		Payloads: map[string][]byte{"r": it.signature.R.Bytes(), "s": it.signature.S.Bytes()},
+	}, nil
+}
+
+func (it *signerIterator) round1(*protocol.Message) (*protocol.Message, error) {
+	bcast, p2p, failed, err := it.signer.SignRound1()
+	if err != nil {
+		return nil, failedError(err, failed)
+	}
+	direct := make(map[uint32]interface{}, len(p2p))
+	for id, v := range p2p {
+		direct[id] = v
+	}
+	return wrapSignRound("1", bcast, direct)
+}
+
+func (it *signerIterator) round2(input *protocol.Message) (*protocol.Message, error) {
+	inBcast := make(map[uint32]*Round1Bcast)
+	inP2P := make(map[uint32]*Round1P2PSend)
+	err := it.unwrap(input, func(id uint32, data []byte) error {
+		inBcast[id] = new(Round1Bcast)
+		return decodeSignPayload(data, inBcast[id])
+	}, func(id uint32, data []byte) error {
+		inP2P[id] = new(Round1P2PSend)
+		return decodeSignPayload(data, inP2P[id])
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(inP2P) == 0 {
+		inP2P = nil
+	}
+	p2p, failed, err := it.signer.SignRound2(inBcast, inP2P)
+	if err != nil {
+		return nil, failedError(err, failed)
+	}
+	direct := make(map[uint32]interface{}, len(p2p))
+	for id, v := range p2p {
+		direct[id] = v
+	}
+	return wrapSignRound("2", nil, direct)
+}
+
+func (it *signerIterator) round3(input *protocol.Message) (*protocol.Message, error) {
+	inP2P := make(map[uint32]*Round2P2PSend)
+	err := it.unwrap(input, nil, func(id uint32, data []byte) error {
+		inP2P[id] = new(Round2P2PSend)
+		return decodeSignPayload(data, inP2P[id])
+	})
+	if err != nil {
+		return nil, err
+	}
+	bcast, failed, err := it.signer.SignRound3(inP2P)
+	if err != nil {
+		return nil, failedError(err, failed)
+	}
+	return wrapSignRound("3", bcast, nil)
+}
+
+func (it *signerIterator) round4(input *protocol.Message) (*protocol.Message, error) {
+	inBcast := make(map[uint32]*Round3Bcast)
+	err := it.unwrap(input, func(id uint32, data []byte) error {
+		inBcast[id] = new(Round3Bcast)
+		return decodeSignPayload(data, inBcast[id])
+	}, nil)
+	if err != nil {
+		return nil, err
+	}
+	bcast, failed, err := it.signer.SignRound4(inBcast)
+	if err != nil {
+		return nil, failedError(err, failed)
+	}
+	return wrapSignRound("4", bcast, nil)
+}
+
+func (it *signerIterator) round5(input *protocol.Message) (*protocol.Message, error) {
+	inBcast := make(map[uint32]*Round4Bcast)
+	err := it.unwrap(input, func(id uint32, data []byte) error {
+		inBcast[id] = new(Round4Bcast)
+		return decodeSignPayload(data, inBcast[id])
+	}, nil)
+	if err != nil {
+		return nil, err
+	}
+	bcast, p2p, failed, err := it.signer.SignRound5(inBcast)
+	if err != nil {
+		return nil, failedError(err, failed)
+	}
+	direct := make(map[uint32]interface{}, len(p2p))
+	for id, v := range p2p {
+		direct[id] = v
+	}
+	return wrapSignRound("5", bcast, direct)
+}
+
+func (it *signerIterator) round6(input *protocol.Message) (*protocol.Message, error) {
+	inBcast := make(map[uint32]*Round5Bcast)
+	inP2P := make(map[uint32]*Round5P2PSend)
+	err := it.unwrap(input, func(id uint32, data []byte) error {
+		inBcast[id] = new(Round5Bcast)
+		return decodeSignPayload(data, inBcast[id])
+	}, func(id uint32, data []byte) error {
+		inP2P[id] = new(Round5P2PSend)
+		return decodeSignPayload(data, inP2P[id])
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(inP2P) == 0 {
+		inP2P = nil
+	}
+	bcast, failed, err := it.signer.SignRound6Full(it.hash, inBcast, inP2P)
+	if err != nil {
+		return nil, failedError(err, failed)
+	}
+	return wrapSignRound("6", bcast, nil)
+}
+
+func (it *signerIterator) output(input *protocol.Message) (*protocol.Message, error) {
+	in := make(map[uint32]*Round6FullBcast)
+	err := it.unwrap(input, func(id uint32, data []byte) error {
+		in[id] = new(Round6FullBcast)
+		return decodeSignPayload(data, in[id])
+	}, nil)
+	if err != nil {
+		return nil, err
+	}
+	signature, failed, err := it.signer.SignOutput(in)
+	if err != nil {
+		return nil, failedError(err, failed)
+	}
+	it.signature = signature
+	return nil, nil
+}
+
+// unwrap decodes the payloads routed to the signer, skipping its own broadcast
+func (it *signerIterator) unwrap(input *protocol.Message, bcast, direct func(uint32, []byte) error) error {
+	broadcasts, p2p, err := protocol.Inbound(input)
+	if err != nil {
+		return err
+	}
+	for id, data := range broadcasts {
+		if id == it.signer.Id || bcast == nil {
+			continue
+		}
+		if err := bcast(id, data); err != nil {
+			return fmt.Errorf("participant %d: %v", id, err)
+		}
+	}
+	for id, data := range p2p {
+		if direct == nil {
+			continue
+		}
+		if err := direct(id, data); err != nil {
+			return fmt.Errorf("participant %d: %v", id, err)
+		}
+	}
+	return nil
+}
+
+// wrapSignRound puts the binary payloads of the round messages into a single output
+func wrapSignRound(round string, bcast interface{}, p2p map[uint32]interface{}) (*protocol.Message, error) {
+	output := &protocol.Message{
+		Protocol: protocol.Gg20Sign,
+		Version:  protocol.Version1,
+		Payloads: make(map[string][]byte, len(p2p)+1),
+		Metadata: map[string]string{protocol.RoundKey: round},
+	}
+	if bcast != nil {
+		m, err := EncodeMessage(bcast, EncodingBinary, protocol.Version1)
+		if err != nil {
+			return nil, err
+		}
+		output.Payloads[protocol.BroadcastKey] = m.Payloads[payloadKey]
+	}
+	for id, v := range p2p {
+		m, err := EncodeMessage(v, EncodingBinary, protocol.Version1)
+		if err != nil {
+			return nil, err
+		}
+		output.Payloads[protocol.RecipientKey(id)] = m.Payloads[payloadKey]
+	}
+	return output, nil
+}
+
+func decodeSignPayload(data []byte, value interface{}) error {
+	v, err := asWireValue(value)
+	if err != nil {
+		return err
+	}
+	name, round := v.wireName()
+	return DecodeMessage(&protocol.Message{
+		Protocol: name,
+		Version:  protocol.Version1,
+		Payloads: map[string][]byte{payloadKey: data},
+		Metadata: map[string]string{protocol.RoundKey: round, EncodingKey: EncodingBinary},
+	}, value)
+}
+
+func failedError(err error, failed []uint32) error {
+	if len(failed) == 0 {
+		return err
+	}
+	return fmt.Errorf("%v: participants %v", err, failed)
+}
+
+func TestSignThroughSimulator(t *testing.T) {
+	curve := elliptic.P256()
+	hash := sha256.Sum256([]byte("message"))
+	for _, useDistributed := range []bool{false, true} {
+		if useDistributed && testing.Short() {
+			continue
+		}
+		pk, signers := setupSignersMap(t, curve, 3, 5, false, ecdsaVerifier, useDistributed)
+
+		parties := make([]*simulator.Party, 0, len(signers))
+		iterators := make(map[uint32]*signerIterator, len(signers))
+		for id, signer := range signers {
+			iterators[id] = newSignerIterator(signer, hash[:])
+			parties = append(parties, &simulator.Party{Id: id, Iterator: iterators[id]})
+		}
+		network, err := simulator.NewNetwork(simulator.Config{
+			MaxDelay:  5 * time.Millisecond,
+			Duplicate: 0.3,
+			Reorder:   0.3,
+			Timeout:   2 * time.Second,
+			Seed:      11,
+		})
+		require.NoError(t, err)
+		outcomes, err := network.Run(parties...)
+		require.NoError(t, err)
+
+		for id, it := range iterators {
+			require.NoError(t, outcomes[id].Err, "participant %d", id)
+			require.NotNil(t, it.signature)
+			require.True(t, ecdsa.Verify(&ecdsa.PublicKey{Curve: curve, X: pk.X, Y: pk.Y}, hash[:], it.signature.R, it.signature.S))
+		}
+	}
+}
+
+func TestSignThroughSimulatorIdentifiesTamperedShare(t *testing.T) {
+	curve := elliptic.P256()
+	hash := sha256.Sum256([]byte("message"))
+	_, signers := setupSignersMap(t, curve, 3, 5, false, ecdsaVerifier, false)
+
+	parties := make([]*simulator.Party, 0, len(signers))
+	for id, signer := range signers {
+		parties = append(parties, &simulator.Party{Id: id, Iterator: newSignerIterator(signer, hash[:])})
+	}
+	network, err := simulator.NewNetwork(simulator.Config{
+		MaxDelay: 2 * time.Millisecond,
+		Timeout:  30 * time.Second,
+		Byzantine: map[uint32]simulator.Tamper{
+			// Participant 3 sends a wrong signature share in round 6
+			3: func(step int, recipient uint32, output *protocol.Message) *protocol.Message {
+				if step != 5 {
+					return output
+				}
+				bcast := new(Round6FullBcast)
+				if err := decodeSignPayload(output.Payloads[protocol.BroadcastKey], bcast); err != nil {
+					return output
+				}
+				bcast.SElement.Add(bcast.SElement, big.NewInt(1)).Mod(bcast.SElement, curve.Params().N)
+				tampered, err := wrapSignRound("6", bcast, nil)
+				if err != nil {
+					return output
+				}
+				return tampered
+			},
+		},
+	})
+	require.NoError(t, err)
+	outcomes, err := network.Run(parties...)
+	require.NoError(t, err)
+
+	require.NoError(t, outcomes[3].Err)
+	for _, id := range []uint32{1, 2} {
+		require.Error(t, outcomes[id].Err, "participant %d", id)
+		require.Contains(t, outcomes[id].Err.Error(), "participants [3]")
+	}
+}
@@ -0,0 +1,95 @@
+//
+// Copyright Coinbase, Inc. All Rights Reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package participant
+
+import (
+	"crypto/ecdsa"
+	crand "crypto/rand"
+	"crypto/sha256"
+	"fmt"
+	"math/big"
+
+	"github.com/nerifnetwork/kryptology/pkg/core/curves"
+)
+
+// The signing messages that end up in the abort evidence are signed with ECDSA under the
+// additive public share W_i of their sender. This binds each value of the evidence to the
+// cosigner that sent it, so the evidence cannot be forged by whoever assembles it.
+
+// transcriptSignatures are the signatures of a cosigner on its signing messages
+type transcriptSignatures struct {
+	ciphertext, response, delta, gamma, rbar *curves.EcdsaSignature
+}
+
+// transcriptDigest hashes the values of a signing message of the sender to the receiver,
+// 0 for a broadcast. ci and cj are the round 1 ciphertexts of the sender and the receiver,
+// they bind the message to the signing session.
+func transcriptDigest(m wireValue, sender, receiver uint32, ci, cj *big.Int, values ...[]byte) []byte {
+	name, round := m.wireName()
+	w := new(wireWriter)
+	w.bytes([]byte(name))
+	w.bytes([]byte(round))
+	w.uint32(sender)
+	w.uint32(receiver)
+	w.bytes(intBytes(ci))
+	w.bytes(intBytes(cj))
+	for _, v := range values {
+		w.bytes(v)
+	}
+	digest := sha256.Sum256(w.buf.Bytes())
+	return digest[:]
+}
+
+// signTranscript signs a digest with the additive share w_i of the signer
+func (signer *Signer) signTranscript(digest []byte) (*curves.EcdsaSignature, error) {
+	share := signer.PublicSharesMap[signer.Id].Point
+	sk := &ecdsa.PrivateKey{
+		PublicKey: ecdsa.PublicKey{Curve: signer.Curve, X: share.X, Y: share.Y},
+		D:         signer.ShamirShare.Value.BigInt(),
+	}
+	r, s, err := ecdsa.Sign(crand.Reader, sk, digest)
+	if err != nil {
+		return nil, err
+	}
+	return &curves.EcdsaSignature{R: r, S: s}, nil
+}
+
+// verifyTranscript checks a signature on a digest under the additive public share W_j of its sender
+func verifyTranscript(share *curves.EcPoint, digest []byte, signature *curves.EcdsaSignature) error {
+	if share == nil || signature == nil || signature.R == nil || signature.S == nil {
+		return fmt.Errorf("missing transcript signature")
+	}
+	if !curves.VerifyEcdsa(share, digest, signature) {
+		return fmt.Errorf("invalid transcript signature")
+	}
+	return nil
+}
+
+// signatures returns the transcript signatures kept for the cosigner
+func (s *state) signatures(id uint32) *transcriptSignatures {
+	if s.transcripts == nil {
+		s.transcripts = make(map[uint32]*transcriptSignatures)
+	}
+	if s.transcripts[id] == nil {
+		s.transcripts[id] = new(transcriptSignatures)
+	}
+	return s.transcripts[id]
+}
+
+func intBytes(v *big.Int) []byte {
+	if v == nil {
+		return nil
+	}
+	return v.Bytes()
+}
+
+func pointBytes(p *curves.EcPoint) []byte {
+	if p == nil || p.Curve == nil || p.X == nil || p.Y == nil {
+		return nil
+	}
+	return p.Bytes()
+}
@@ -0,0 +1,1219 @@
+//
+// Copyright Coinbase, Inc. All Rights Reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package participant
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"reflect"
+	"sort"
+
+	"github.com/nerifnetwork/kryptology/internal"
+	"github.com/nerifnetwork/kryptology/pkg/core"
+	"github.com/nerifnetwork/kryptology/pkg/core/curves"
+	"github.com/nerifnetwork/kryptology/pkg/core/protocol"
+	"github.com/nerifnetwork/kryptology/pkg/paillier"
+	v1 "github.com/nerifnetwork/kryptology/pkg/sharing/v1"
+	"github.com/nerifnetwork/kryptology/pkg/tecdsa/bip32"
+	"github.com/nerifnetwork/kryptology/pkg/tecdsa/gg20/dealer"
+	"github.com/nerifnetwork/kryptology/pkg/tecdsa/gg20/proof"
+)
+
+const (
+	// EncodingKey is the metadata key of the payload encoding of a wire message
+	EncodingKey = "encoding"
+	// EncodingBinary is the length prefixed binary encoding of the wire messages
+	EncodingBinary = "binary"
+	// EncodingJSON is the JSON encoding of the wire messages
+	EncodingJSON = "json"
+
+	payloadKey = "payload"
+)
+
+// SupportedVersions are the versions of the wire messages understood by DecodeMessage
+var SupportedVersions = []uint{protocol.Version1}
+
+// wireValue is implemented by the messages that EncodeMessage accepts
+type wireValue interface {
+	// wireName returns the protocol and the round of the message
+	wireName() (string, string)
+	// validate checks that the decoded message is complete and well formed
+	validate() error
+	writeTo(w *wireWriter)
+	readFrom(r *wireReader)
+}
+
+var (
+	_ wireValue = (*Round1Bcast)(nil)
+	_ wireValue = (*Round1P2PSend)(nil)
+	_ wireValue = (*Round2P2PSend)(nil)
+	_ wireValue = (*Round3Bcast)(nil)
+	_ wireValue = (*Round4Bcast)(nil)
+	_ wireValue = (*Round5Bcast)(nil)
+	_ wireValue = (*Round5P2PSend)(nil)
+	_ wireValue = (*Round6FullBcast)(nil)
+	_ wireValue = (*AbortReveal)(nil)
+	_ wireValue = (*DkgRound1Bcast)(nil)
+	_ wireValue = (*DkgRound2Bcast)(nil)
+	_ wireValue = (*DkgRound2P2PSend)(nil)
+	_ wireValue = (*DkgRound3Bcast)(nil)
+	_ wireValue = (*DkgResult)(nil)
+	_ wireValue = (*RefreshRound1Bcast)(nil)
+	_ wireValue = (*RefreshRound2Bcast)(nil)
+	_ wireValue = (*RefreshRound2P2PSend)(nil)
+	_ wireValue = (*RefreshRound3Bcast)(nil)
+)
+
+// EncodeMessage wraps a signing, DKG or refresh round message in a protocol.Message. The value must be
+// a pointer to one of the round types of this package, e.g. *Round1Bcast or *DkgResult.
+// The encoding is EncodingBinary or EncodingJSON.
+func EncodeMessage(value interface{}, encoding string, version uint) (*protocol.Message, error) {
+	v, err := asWireValue(value)
+	if err != nil {
+		return nil, err
+	}
+	if err := protocol.CheckVersion(version, SupportedVersions); err != nil {
+		return nil, err
+	}
+	if err := v.validate(); err != nil {
+		return nil, fmt.Errorf("invalid %T: %v", value, err)
+	}
+
+	var payload []byte
+	switch encoding {
+	case EncodingBinary:
+		w := new(wireWriter)
+		v.writeTo(w)
+		if w.err != nil {
+			return nil, w.err
+		}
+		payload = w.buf.Bytes()
+	case EncodingJSON:
+		if payload, err = json.Marshal(value); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("unsupported encoding %q", encoding)
+	}
+
+	name, round := v.wireName()
+	return &protocol.Message{
+		Protocol: name,
+		Version:  version,
+		Payloads: map[string][]byte{payloadKey: payload},
+		Metadata: map[string]string{
+			protocol.RoundKey: round,
+			EncodingKey:       encoding,
+		},
+	}, nil
+}
+
+// DecodeMessage decodes a message created by EncodeMessage into value, which must be a pointer
+// to the round type of the message. It fails unless the message is of the expected protocol,
+// round and version and the decoded value is complete and well formed.
+func DecodeMessage(m *protocol.Message, value interface{}) error {
+	v, err := asWireValue(value)
+	if err != nil {
+		return err
+	}
+	if m == nil {
+		return internal.ErrNilArguments
+	}
+	if err := protocol.CheckVersion(m.Version, SupportedVersions); err != nil {
+		return err
+	}
+	name, round := v.wireName()
+	if m.Protocol != name || m.Metadata[protocol.RoundKey] != round {
+		return fmt.Errorf("expected a %s %s message, got %s %s", name, round, m.Protocol, m.Metadata[protocol.RoundKey])
+	}
+	if len(m.Payloads) != 1 {
+		return fmt.Errorf("expected a single payload")
+	}
+	payload, ok := m.Payloads[payloadKey]
+	if !ok || len(payload) == 0 {
+		return fmt.Errorf("empty payload")
+	}
+
+	switch m.Metadata[EncodingKey] {
+	case EncodingBinary:
+		r := &wireReader{data: payload}
+		v.readFrom(r)
+		if r.err == nil && len(r.data) != 0 {
+			r.err = fmt.Errorf("%d trailing bytes", len(r.data))
+		}
+		if r.err != nil {
+			return fmt.Errorf("couldn't decode %T: %v", value, r.err)
+		}
+	case EncodingJSON:
+		dec := json.NewDecoder(bytes.NewReader(payload))
+		dec.DisallowUnknownFields()
+		if err := dec.Decode(value); err != nil {
+			return fmt.Errorf("couldn't decode %T: %v", value, err)
+		}
+		if dec.More() {
+			return fmt.Errorf("couldn't decode %T: trailing data", value)
+		}
+	default:
+		return fmt.Errorf("unsupported encoding %q", m.Metadata[EncodingKey])
+	}
+	if err := v.validate(); err != nil {
+		return fmt.Errorf("invalid %T: %v", value, err)
+	}
+	return nil
+}
+
+func asWireValue(value interface{}) (wireValue, error) {
+	v, ok := value.(wireValue)
+	if !ok {
+		return nil, fmt.Errorf("unsupported message type %T", value)
+	}
+	if reflect.ValueOf(v).IsNil() {
+		return nil, internal.ErrNilArguments
+	}
+	return v, nil
+}
+
+func (*Round1Bcast) wireName() (string, string)      { return protocol.Gg20Sign, "1-bcast" }
+func (*Round1P2PSend) wireName() (string, string)    { return protocol.Gg20Sign, "1-p2p" }
+func (*Round2P2PSend) wireName() (string, string)    { return protocol.Gg20Sign, "2-p2p" }
+func (*Round3Bcast) wireName() (string, string)      { return protocol.Gg20Sign, "3-bcast" }
+func (*Round4Bcast) wireName() (string, string)      { return protocol.Gg20Sign, "4-bcast" }
+func (*Round5Bcast) wireName() (string, string)      { return protocol.Gg20Sign, "5-bcast" }
+func (*Round5P2PSend) wireName() (string, string)    { return protocol.Gg20Sign, "5-p2p" }
+func (*Round6FullBcast) wireName() (string, string)  { return protocol.Gg20Sign, "6-bcast" }
+func (*AbortReveal) wireName() (string, string)      { return protocol.Gg20Sign, "abort" }
+func (*DkgRound1Bcast) wireName() (string, string)   { return protocol.Gg20Dkg, "1-bcast" }
+func (*DkgRound2Bcast) wireName() (string, string)   { return protocol.Gg20Dkg, "2-bcast" }
+func (*DkgRound2P2PSend) wireName() (string, string) { return protocol.Gg20Dkg, "2-p2p" }
+func (*DkgRound3Bcast) wireName() (string, string)   { return protocol.Gg20Dkg, "3-bcast" }
+func (*DkgResult) wireName() (string, string)        { return protocol.Gg20Dkg, "result" }
+
+func (*RefreshRound1Bcast) wireName() (string, string)   { return protocol.Gg20Refresh, "1-bcast" }
+func (*RefreshRound2Bcast) wireName() (string, string)   { return protocol.Gg20Refresh, "2-bcast" }
+func (*RefreshRound2P2PSend) wireName() (string, string) { return protocol.Gg20Refresh, "2-p2p" }
+func (*RefreshRound3Bcast) wireName() (string, string)   { return protocol.Gg20Refresh, "3-bcast" }
+
+// Validation
+
+func (m *Round1Bcast) validate() error {
+	if len(m.C) != core.Size {
+		return fmt.Errorf("invalid commitment")
+	}
+	if err := checkPositive(m.Ctxt); err != nil {
+		return err
+	}
+	if err := checkSignature(m.Signature); err != nil {
+		return err
+	}
+	// The range proof is sent point to point with distributed key generation
+	if m.Proof != nil {
+		return checkRange1Proof(m.Proof)
+	}
+	return nil
+}
+
+func (m *Round1P2PSend) validate() error {
+	return checkRange1Proof(m.Range1Proof)
+}
+
+func (m *Round2P2PSend) validate() error {
+	if err := checkResponseProof(m.Proof2); err != nil {
+		return err
+	}
+	if err := checkResponseProof(m.Proof3); err != nil {
+		return err
+	}
+	return checkSignature(m.Signature)
+}
+
+func (m *Round3Bcast) validate() error {
+	if err := checkNonNegative(m.DeltaElement); err != nil {
+		return err
+	}
+	if err := checkPoint(m.T); err != nil {
+		return err
+	}
+	return checkSignature(m.Signature)
+}
+
+func (m *Round4Bcast) validate() error {
+	if err := checkWitness(m.Witness); err != nil {
+		return err
+	}
+	return checkSignature(m.Signature)
+}
+
+func (m *Round5Bcast) validate() error {
+	if err := checkPoint(m.Rbar); err != nil {
+		return err
+	}
+	if err := checkPoint(m.S); err != nil {
+		return err
+	}
+	if err := checkStProof(m.SProof); err != nil {
+		return err
+	}
+	if err := checkSignature(m.Signature); err != nil {
+		return err
+	}
+	// The proof is sent point to point with distributed key generation
+	if m.Proof != nil {
+		return checkPdlProof(m.Proof)
+	}
+	return nil
+}
+
+func (m *Round5P2PSend) validate() error {
+	return checkPdlProof(m.PdlProof)
+}
+
+func (m *Round6FullBcast) validate() error {
+	return checkNonNegative(m.SElement)
+}
+
+func (m *AbortReveal) validate() error {
+	if err := checkNonNegative(m.K, m.KNonce, m.Gamma); err != nil {
+		return err
+	}
+	if err := checkSignature(m.Signature); err != nil {
+		return err
+	}
+	if len(m.Mta) == 0 {
+		return fmt.Errorf("missing MtA reveals")
+	}
+	for _, mta := range m.Mta {
+		if mta == nil {
+			return internal.ErrNilArguments
+		}
+		if err := checkNonNegative(mta.AlphaCiphertext, mta.Alpha, mta.AlphaNonce,
+			mta.MuCiphertext, mta.Mu, mta.MuNonce, mta.BetaTick); err != nil {
+			return err
+		}
+		if err := checkPoint(mta.Nu); err != nil {
+			return err
+		}
+		if err := checkSignature(mta.ResponseSignature); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m *DkgRound1Bcast) validate() error {
+	if len(m.Ci) != core.Size {
+		return fmt.Errorf("invalid commitment")
+	}
+	if err := checkPaillierKey(m.Pki); err != nil {
+		return err
+	}
+	if err := checkPositive(m.H1i, m.H2i, m.Ni); err != nil {
+		return err
+	}
+	if err := checkCdlProof(m.Proof1i); err != nil {
+		return err
+	}
+	if err := checkCdlProof(m.Proof2i); err != nil {
+		return err
+	}
+	return checkModProof(m.ModProof)
+}
+
+func (m *DkgRound2Bcast) validate() error {
+	if err := checkWitness(m.Di); err != nil {
+		return err
+	}
+	if len(m.FacProofs) == 0 {
+		return fmt.Errorf("missing no small factor proofs")
+	}
+	for _, p := range m.FacProofs {
+		if err := checkFacProof(p); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m *DkgRound2P2PSend) validate() error {
+	return checkShamirShare(m.Xij)
+}
+
+func (m *DkgRound3Bcast) validate() error {
+	if len(m.PsfProof) == 0 {
+		return fmt.Errorf("empty square-free proof")
+	}
+	return checkNonNegative(m.PsfProof...)
+}
+
+// The refresh rounds have the same contents as the DKG rounds
+
+func (m *RefreshRound1Bcast) validate() error   { return (*DkgRound1Bcast)(m).validate() }
+func (m *RefreshRound2Bcast) validate() error   { return (*DkgRound2Bcast)(m).validate() }
+func (m *RefreshRound2P2PSend) validate() error { return (*DkgRound2P2PSend)(m).validate() }
+func (m *RefreshRound3Bcast) validate() error   { return (*DkgRound3Bcast)(m).validate() }
+
+func (m *DkgResult) validate() error {
+	if m.SecretKey == nil {
+		return internal.ErrNilArguments
+	}
+	if err := checkPaillierKey(&m.SecretKey.PublicKey); err != nil {
+		return err
+	}
+	if err := checkPositive(m.SecretKey.Lambda, m.SecretKey.Totient, m.SecretKey.U); err != nil {
+		return err
+	}
+	if err := checkShamirShare(m.ShamirShare); err != nil {
+		return err
+	}
+	if err := checkPoint(m.EcdsaPublicKey); err != nil {
+		return err
+	}
+	if len(m.PublicShares) == 0 {
+		return fmt.Errorf("missing public shares")
+	}
+	for _, p := range m.PublicShares {
+		if err := checkPoint(p); err != nil {
+			return err
+		}
+	}
+	if len(m.ParticipantData) == 0 {
+		return fmt.Errorf("missing participant data")
+	}
+	for _, pd := range m.ParticipantData {
+		if pd == nil || pd.ProofParams == nil {
+			return internal.ErrNilArguments
+		}
+		if err := checkPaillierKey(pd.PublicKey); err != nil {
+			return err
+		}
+		if err := checkPositive(pd.ProofParams.N, pd.ProofParams.H1, pd.ProofParams.H2); err != nil {
+			return err
+		}
+	}
+	// Results from before chain codes were added have none
+	if len(m.ChainCode) != 0 && len(m.ChainCode) != bip32.ChainCodeSize {
+		return fmt.Errorf("invalid chain code")
+	}
+	return nil
+}
+
+func checkNonNegative(values ...*big.Int) error {
+	for _, v := range values {
+		if v == nil {
+			return internal.ErrNilArguments
+		}
+		if v.Sign() < 0 {
+			return fmt.Errorf("value cannot be negative")
+		}
+	}
+	return nil
+}
+
+func checkPositive(values ...*big.Int) error {
+	for _, v := range values {
+		if v == nil {
+			return internal.ErrNilArguments
+		}
+		if v.Sign() <= 0 {
+			return internal.ErrZeroValue
+		}
+	}
+	return nil
+}
+
+func checkPoint(p *curves.EcPoint) error {
+	if p == nil || p.Curve == nil || p.X == nil || p.Y == nil {
+		return internal.ErrNilArguments
+	}
+	if !p.IsOnCurve() {
+		return internal.ErrNotOnCurve
+	}
+	return nil
+}
+
+func checkSignature(s *curves.EcdsaSignature) error {
+	if s == nil {
+		return fmt.Errorf("missing signature")
+	}
+	return checkPositive(s.R, s.S)
+}
+
+func checkWitness(w *core.Witness) error {
+	if w == nil || len(w.Msg) == 0 {
+		return fmt.Errorf("invalid witness")
+	}
+	return nil
+}
+
+func checkPaillierKey(pk *paillier.PublicKey) error {
+	if pk == nil {
+		return internal.ErrNilArguments
+	}
+	if err := checkPositive(pk.N, pk.N2); err != nil {
+		return err
+	}
+	if new(big.Int).Mul(pk.N, pk.N).Cmp(pk.N2) != 0 {
+		return fmt.Errorf("invalid paillier public key")
+	}
+	return nil
+}
+
+func checkShamirShare(s *v1.ShamirShare) error {
+	if s == nil || s.Value == nil || s.Value.Modulus == nil || s.Value.Modulus.Int == nil {
+		return internal.ErrNilArguments
+	}
+	if s.Identifier == 0 {
+		return fmt.Errorf("invalid share identifier")
+	}
+	if !s.Value.Modulus.IsValid(s.Value.Value) {
+		return fmt.Errorf("share value is not in the field")
+	}
+	return nil
+}
+
+func checkRange1Proof(p *proof.Range1Proof) error {
+	if p == nil {
+		return internal.ErrNilArguments
+	}
+	return checkNonNegative(p.Z, p.E, p.S, p.S1, p.S2)
+}
+
+func checkPdlProof(p *proof.PdlProof) error {
+	if p == nil {
+		return internal.ErrNilArguments
+	}
+	return checkNonNegative(p.Z, p.E, p.S, p.S1, p.S2)
+}
+
+func checkStProof(p *proof.StProof) error {
+	if p == nil {
+		return internal.ErrNilArguments
+	}
+	return checkNonNegative(p.E, p.Z1, p.Z2)
+}
+
+func checkCdlProof(p *proof.CdlProof) error {
+	if p == nil {
+		return internal.ErrNilArguments
+	}
+	if len(p.U) == 0 || len(p.U) != len(p.S) {
+		return fmt.Errorf("invalid composite dlog proof")
+	}
+	if err := checkNonNegative(p.U...); err != nil {
+		return err
+	}
+	return checkNonNegative(p.S...)
+}
+
+func checkModProof(p *paillier.ModProof) error {
+	if p == nil {
+		return internal.ErrNilArguments
+	}
+	if len(p.X) != paillier.ModProofIterations ||
+		len(p.A) != len(p.X) ||
+		len(p.B) != len(p.X) ||
+		len(p.Z) != len(p.X) {
+		return fmt.Errorf("invalid paillier-blum modulus proof")
+	}
+	if err := checkPositive(p.W); err != nil {
+		return err
+	}
+	if err := checkNonNegative(p.X...); err != nil {
+		return err
+	}
+	return checkNonNegative(p.Z...)
+}
+
+func checkFacProof(p *paillier.FacProof) error {
+	if p == nil {
+		return internal.ErrNilArguments
+	}
+	if err := checkNonNegative(p.P, p.Q, p.A, p.B, p.T); err != nil {
+		return err
+	}
+	// The responses are signed
+	if core.AnyNil(p.Sigma, p.Z1, p.Z2, p.W1, p.W2, p.V) {
+		return internal.ErrNilArguments
+	}
+	return nil
+}
+
+func checkResponseProof(p *proof.ResponseProof) error {
+	if p == nil || p.R2proof == nil {
+		return internal.ErrNilArguments
+	}
+	// β and β' are secrets of the responder
+	if p.Beta != nil || p.BetaTick != nil {
+		return fmt.Errorf("response proof must not contain the secret mask")
+	}
+	r := p.R2proof
+	return checkNonNegative(p.C2, r.Z, r.E, r.S, r.S1, r.S2, r.T, r.T1, r.T2)
+}
+
+// Binary encoding
+
+func (m *Round1Bcast) writeTo(w *wireWriter) {
+	w.uint32(m.Identifier)
+	w.bytes(m.C)
+	w.bigInt(m.Ctxt)
+	w.bool(m.Proof != nil)
+	if m.Proof != nil {
+		writeRange1Proof(w, m.Proof)
+	}
+	writeSignature(w, m.Signature)
+}
+
+func (m *Round1Bcast) readFrom(r *wireReader) {
+	m.Identifier = r.uint32()
+	m.C = r.bytes()
+	m.Ctxt = r.bigInt()
+	m.Proof = nil
+	if r.bool() {
+		m.Proof = readRange1Proof(r)
+	}
+	m.Signature = readSignature(r)
+}
+
+func (m *Round1P2PSend) writeTo(w *wireWriter) {
+	writeRange1Proof(w, m.Range1Proof)
+}
+
+func (m *Round1P2PSend) readFrom(r *wireReader) {
+	m.Range1Proof = readRange1Proof(r)
+}
+
+func (m *Round2P2PSend) writeTo(w *wireWriter) {
+	writeResponseProof(w, m.Proof2)
+	writeResponseProof(w, m.Proof3)
+	writeSignature(w, m.Signature)
+}
+
+func (m *Round2P2PSend) readFrom(r *wireReader) {
+	m.Proof2 = readResponseProof(r)
+	m.Proof3 = readResponseProof(r)
+	m.Signature = readSignature(r)
+}
+
+func (m *Round3Bcast) writeTo(w *wireWriter) {
+	w.bigInt(m.DeltaElement)
+	w.point(m.T)
+	writeSignature(w, m.Signature)
+}
+
+func (m *Round3Bcast) readFrom(r *wireReader) {
+	m.DeltaElement = r.bigInt()
+	m.T = r.point()
+	m.Signature = readSignature(r)
+}
+
+func (m *Round4Bcast) writeTo(w *wireWriter) {
+	writeWitness(w, m.Witness)
+	writeSignature(w, m.Signature)
+}
+
+func (m *Round4Bcast) readFrom(r *wireReader) {
+	m.Witness = readWitness(r)
+	m.Signature = readSignature(r)
+}
+
+func (m *Round5Bcast) writeTo(w *wireWriter) {
+	w.point(m.Rbar)
+	w.bool(m.Proof != nil)
+	if m.Proof != nil {
+		writePdlProof(w, m.Proof)
+	}
+	w.point(m.S)
+	writeStProof(w, m.SProof)
+	writeSignature(w, m.Signature)
+}
+
+func (m *Round5Bcast) readFrom(r *wireReader) {
+	m.Rbar = r.point()
+	m.Proof = nil
+	if r.bool() {
+		m.Proof = readPdlProof(r)
+	}
+	m.S = r.point()
+	m.SProof = readStProof(r)
+	m.Signature = readSignature(r)
+}
+
+func (m *Round5P2PSend) writeTo(w *wireWriter) {
+	writePdlProof(w, m.PdlProof)
+}
+
+func (m *Round5P2PSend) readFrom(r *wireReader) {
+	m.PdlProof = readPdlProof(r)
+}
+
+func (m *Round6FullBcast) writeTo(w *wireWriter) {
+	w.bigInt(m.SElement)
+}
+
+func (m *Round6FullBcast) readFrom(r *wireReader) {
+	m.SElement = r.bigInt()
+}
+
+func (m *AbortReveal) writeTo(w *wireWriter) {
+	m.writeBody(w)
+	writeSignature(w, m.Signature)
+}
+
+// writeBody writes the reveal without its signature
+func (m *AbortReveal) writeBody(w *wireWriter) {
+	w.bigInt(m.K)
+	w.bigInt(m.KNonce)
+	w.bigInt(m.Gamma)
+	ids := make([]uint32, 0, len(m.Mta))
+	for id := range m.Mta {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	w.uint32(uint32(len(ids)))
+	for _, id := range ids {
+		mta := m.Mta[id]
+		w.uint32(id)
+		w.bigInt(mta.AlphaCiphertext)
+		w.bigInt(mta.Alpha)
+		w.bigInt(mta.AlphaNonce)
+		w.bigInt(mta.MuCiphertext)
+		w.bigInt(mta.Mu)
+		w.bigInt(mta.MuNonce)
+		writeSignature(w, mta.ResponseSignature)
+		w.bigInt(mta.BetaTick)
+		w.point(mta.Nu)
+	}
+}
+
+func (m *AbortReveal) readFrom(r *wireReader) {
+	m.K = r.bigInt()
+	m.KNonce = r.bigInt()
+	m.Gamma = r.bigInt()
+	n := r.count()
+	m.Mta = make(map[uint32]*MtaReveal, n)
+	for i := 0; i < n && r.err == nil; i++ {
+		id := r.uint32()
+		m.Mta[id] = &MtaReveal{
+			AlphaCiphertext:   r.bigInt(),
+			Alpha:             r.bigInt(),
+			AlphaNonce:        r.bigInt(),
+			MuCiphertext:      r.bigInt(),
+			Mu:                r.bigInt(),
+			MuNonce:           r.bigInt(),
+			ResponseSignature: readSignature(r),
+			BetaTick:          r.bigInt(),
+			Nu:                r.point(),
+		}
+	}
+	r.unique(len(m.Mta), n)
+	m.Signature = readSignature(r)
+}
+
+func (m *DkgRound1Bcast) writeTo(w *wireWriter) {
+	w.uint32(m.Identifier)
+	w.bytes(m.Ci)
+	w.bigInt(m.Pki.N)
+	w.bigInt(m.H1i)
+	w.bigInt(m.H2i)
+	w.bigInt(m.Ni)
+	writeCdlProof(w, m.Proof1i)
+	writeCdlProof(w, m.Proof2i)
+	writeModProof(w, m.ModProof)
+}
+
+func (m *DkgRound1Bcast) readFrom(r *wireReader) {
+	m.Identifier = r.uint32()
+	m.Ci = r.bytes()
+	m.Pki = readPaillierKey(r)
+	m.H1i = r.bigInt()
+	m.H2i = r.bigInt()
+	m.Ni = r.bigInt()
+	m.Proof1i = readCdlProof(r)
+	m.Proof2i = readCdlProof(r)
+	m.ModProof = readModProof(r)
+}
+
+func (m *DkgRound2Bcast) writeTo(w *wireWriter) {
+	writeWitness(w, m.Di)
+	ids := make([]uint32, 0, len(m.FacProofs))
+	for id := range m.FacProofs {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	w.uint32(uint32(len(ids)))
+	for _, id := range ids {
+		w.uint32(id)
+		writeFacProof(w, m.FacProofs[id])
+	}
+}
+
+func (m *DkgRound2Bcast) readFrom(r *wireReader) {
+	m.Di = readWitness(r)
+	n := r.count()
+	m.FacProofs = make(map[uint32]*paillier.FacProof, n)
+	for i := 0; i < n && r.err == nil; i++ {
+		id := r.uint32()
+		m.FacProofs[id] = readFacProof(r)
+	}
+	r.unique(len(m.FacProofs), n)
+}
+
+func (m *DkgRound2P2PSend) writeTo(w *wireWriter) {
+	writeShamirShare(w, m.Xij)
+}
+
+func (m *DkgRound2P2PSend) readFrom(r *wireReader) {
+	m.Xij = readShamirShare(r)
+}
+
+func (m *DkgRound3Bcast) writeTo(w *wireWriter) {
+	w.bigInts(m.PsfProof)
+}
+
+func (m *DkgRound3Bcast) readFrom(r *wireReader) {
+	m.PsfProof = r.bigInts()
+}
+
+func (m *RefreshRound1Bcast) writeTo(w *wireWriter)    { (*DkgRound1Bcast)(m).writeTo(w) }
+func (m *RefreshRound1Bcast) readFrom(r *wireReader)   { (*DkgRound1Bcast)(m).readFrom(r) }
+func (m *RefreshRound2Bcast) writeTo(w *wireWriter)    { (*DkgRound2Bcast)(m).writeTo(w) }
+func (m *RefreshRound2Bcast) readFrom(r *wireReader)   { (*DkgRound2Bcast)(m).readFrom(r) }
+func (m *RefreshRound2P2PSend) writeTo(w *wireWriter)  { (*DkgRound2P2PSend)(m).writeTo(w) }
+func (m *RefreshRound2P2PSend) readFrom(r *wireReader) { (*DkgRound2P2PSend)(m).readFrom(r) }
+func (m *RefreshRound3Bcast) writeTo(w *wireWriter)    { (*DkgRound3Bcast)(m).writeTo(w) }
+func (m *RefreshRound3Bcast) readFrom(r *wireReader)   { (*DkgRound3Bcast)(m).readFrom(r) }
+
+func (m *DkgResult) writeTo(w *wireWriter) {
+	w.bigInt(m.SecretKey.N)
+	w.bigInt(m.SecretKey.Lambda)
+	w.bigInt(m.SecretKey.Totient)
+	w.bigInt(m.SecretKey.U)
+	writeShamirShare(w, m.ShamirShare)
+	w.point(m.EcdsaPublicKey)
+	w.uint32(uint32(len(m.PublicShares)))
+	for _, p := range m.PublicShares {
+		w.point(p)
+	}
+	ids := make([]uint32, 0, len(m.ParticipantData))
+	for id := range m.ParticipantData {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	w.uint32(uint32(len(ids)))
+	for _, id := range ids {
+		pd := m.ParticipantData[id]
+		w.uint32(id)
+		w.bigInt(pd.PublicKey.N)
+		w.bigInt(pd.ProofParams.N)
+		w.bigInt(pd.ProofParams.H1)
+		w.bigInt(pd.ProofParams.H2)
+	}
+	w.bytes(m.ChainCode)
+}
+
+func (m *DkgResult) readFrom(r *wireReader) {
+	pk := readPaillierKey(r)
+	m.SecretKey = &paillier.SecretKey{
+		PublicKey: *pk,
+		Lambda:    r.bigInt(),
+		Totient:   r.bigInt(),
+		U:         r.bigInt(),
+	}
+	m.ShamirShare = readShamirShare(r)
+	m.EcdsaPublicKey = r.point()
+	n := r.count()
+	m.PublicShares = make([]*curves.EcPoint, 0, n)
+	for i := 0; i < n && r.err == nil; i++ {
+		m.PublicShares = append(m.PublicShares, r.point())
+	}
+	n = r.count()
+	m.ParticipantData = make(map[uint32]*DkgParticipantData, n)
+	for i := 0; i < n && r.err == nil; i++ {
+		id := r.uint32()
+		m.ParticipantData[id] = &DkgParticipantData{
+			PublicKey: readPaillierKey(r),
+			ProofParams: &dealer.ProofParams{
+				N:  r.bigInt(),
+				H1: r.bigInt(),
+				H2: r.bigInt(),
+			},
+		}
+	}
+	r.unique(len(m.ParticipantData), n)
+	// Results encoded before chain codes were added end here
+	if r.err == nil && len(r.data) != 0 {
+		m.ChainCode = r.bytes()
+	}
+}
+
+func writeRange1Proof(w *wireWriter, p *proof.Range1Proof) {
+	w.bigInt(p.Z)
+	w.bigInt(p.E)
+	w.bigInt(p.S)
+	w.bigInt(p.S1)
+	w.bigInt(p.S2)
+}
+
+func readRange1Proof(r *wireReader) *proof.Range1Proof {
+	return &proof.Range1Proof{
+		Z:  r.bigInt(),
+		E:  r.bigInt(),
+		S:  r.bigInt(),
+		S1: r.bigInt(),
+		S2: r.bigInt(),
+	}
+}
+
+func writePdlProof(w *wireWriter, p *proof.PdlProof) {
+	w.bigInt(p.Z)
+	w.bigInt(p.E)
+	w.bigInt(p.S)
+	w.bigInt(p.S1)
+	w.bigInt(p.S2)
+}
+
+func readPdlProof(r *wireReader) *proof.PdlProof {
+	return &proof.PdlProof{
+		Z:  r.bigInt(),
+		E:  r.bigInt(),
+		S:  r.bigInt(),
+		S1: r.bigInt(),
+		S2: r.bigInt(),
+	}
+}
+
+func writeStProof(w *wireWriter, p *proof.StProof) {
+	w.bigInt(p.E)
+	w.bigInt(p.Z1)
+	w.bigInt(p.Z2)
+}
+
+func readStProof(r *wireReader) *proof.StProof {
+	return &proof.StProof{
+		E:  r.bigInt(),
+		Z1: r.bigInt(),
+		Z2: r.bigInt(),
+	}
+}
+
+func writeResponseProof(w *wireWriter, p *proof.ResponseProof) {
+	w.bigInt(p.C2)
+	w.bigInt(p.R2proof.Z)
+	w.bigInt(p.R2proof.E)
+	w.bigInt(p.R2proof.S)
+	w.bigInt(p.R2proof.S1)
+	w.bigInt(p.R2proof.S2)
+	w.bigInt(p.R2proof.T)
+	w.bigInt(p.R2proof.T1)
+	w.bigInt(p.R2proof.T2)
+}
+
+func readResponseProof(r *wireReader) *proof.ResponseProof {
+	return &proof.ResponseProof{
+		C2: r.bigInt(),
+		R2proof: &proof.Range2Proof{
+			Z:  r.bigInt(),
+			E:  r.bigInt(),
+			S:  r.bigInt(),
+			S1: r.bigInt(),
+			S2: r.bigInt(),
+			T:  r.bigInt(),
+			T1: r.bigInt(),
+			T2: r.bigInt(),
+		},
+	}
+}
+
+func writeCdlProof(w *wireWriter, p *proof.CdlProof) {
+	w.bigInts(p.U)
+	w.bigInts(p.S)
+}
+
+func readCdlProof(r *wireReader) *proof.CdlProof {
+	return &proof.CdlProof{
+		U: r.bigInts(),
+		S: r.bigInts(),
+	}
+}
+
+func writeModProof(w *wireWriter, p *paillier.ModProof) {
+	w.bigInt(p.W)
+	w.bigInts(p.X)
+	w.uint32(uint32(len(p.A)))
+	for i := range p.A {
+		w.bool(p.A[i])
+	}
+	w.uint32(uint32(len(p.B)))
+	for i := range p.B {
+		w.bool(p.B[i])
+	}
+	w.bigInts(p.Z)
+}
+
+func readModProof(r *wireReader) *paillier.ModProof {
+	p := &paillier.ModProof{
+		W: r.bigInt(),
+		X: r.bigInts(),
+	}
+	for _, bits := range []*[]bool{&p.A, &p.B} {
+		n := r.count()
+		*bits = make([]bool, 0, n)
+		for i := 0; i < n && r.err == nil; i++ {
+			*bits = append(*bits, r.bool())
+		}
+	}
+	p.Z = r.bigInts()
+	return p
+}
+
+func writeFacProof(w *wireWriter, p *paillier.FacProof) {
+	for _, v := range []*big.Int{p.P, p.Q, p.A, p.B, p.T} {
+		w.bigInt(v)
+	}
+	for _, v := range []*big.Int{p.Sigma, p.Z1, p.Z2, p.W1, p.W2, p.V} {
+		w.signedInt(v)
+	}
+}
+
+func readFacProof(r *wireReader) *paillier.FacProof {
+	return &paillier.FacProof{
+		P:     r.bigInt(),
+		Q:     r.bigInt(),
+		A:     r.bigInt(),
+		B:     r.bigInt(),
+		T:     r.bigInt(),
+		Sigma: r.signedInt(),
+		Z1:    r.signedInt(),
+		Z2:    r.signedInt(),
+		W1:    r.signedInt(),
+		W2:    r.signedInt(),
+		V:     r.signedInt(),
+	}
+}
+
+func writeSignature(w *wireWriter, s *curves.EcdsaSignature) {
+	w.bigInt(s.R)
+	w.bigInt(s.S)
+}
+
+func readSignature(r *wireReader) *curves.EcdsaSignature {
+	return &curves.EcdsaSignature{R: r.bigInt(), S: r.bigInt()}
+}
+
+func writeWitness(w *wireWriter, witness *core.Witness) {
+	w.bytes(witness.Msg)
+	w.fixed(witness.R[:])
+}
+
+func readWitness(r *wireReader) *core.Witness {
+	witness := &core.Witness{Msg: r.bytes()}
+	copy(witness.R[:], r.fixed(len(witness.R)))
+	return witness
+}
+
+func writeShamirShare(w *wireWriter, s *v1.ShamirShare) {
+	w.uint32(s.Identifier)
+	w.bigInt(s.Value.Modulus.Int)
+	w.bigInt(s.Value.Value)
+}
+
+func readShamirShare(r *wireReader) *v1.ShamirShare {
+	id := r.uint32()
+	modulus := r.bigInt()
+	value := r.bigInt()
+	if modulus == nil {
+		return &v1.ShamirShare{Identifier: id}
+	}
+	return &v1.ShamirShare{
+		Identifier: id,
+		Value:      &curves.Element{Modulus: curves.NewField(modulus), Value: value},
+	}
+}
+
+func readPaillierKey(r *wireReader) *paillier.PublicKey {
+	n := r.bigInt()
+	if n == nil {
+		return &paillier.PublicKey{}
+	}
+	return &paillier.PublicKey{N: n, N2: new(big.Int).Mul(n, n)}
+}
+
+// wireWriter writes the fields of a message. Variable length fields are prefixed
+// with their big-endian uint32 length.
+type wireWriter struct {
+	buf bytes.Buffer
+	err error
+}
+
+func (w *wireWriter) uint32(v uint32) {
+	var b [4]byte
+	binary.BigEndian.PutUint32(b[:], v)
+	w.buf.Write(b[:])
+}
+
+func (w *wireWriter) bool(v bool) {
+	if v {
+		w.buf.WriteByte(1)
+	} else {
+		w.buf.WriteByte(0)
+	}
+}
+
+func (w *wireWriter) fixed(b []byte) {
+	w.buf.Write(b)
+}
+
+func (w *wireWriter) bytes(b []byte) {
+	w.uint32(uint32(len(b)))
+	w.buf.Write(b)
+}
+
+// bigInt writes a non-negative integer, validation has ruled out negative ones
+func (w *wireWriter) bigInt(v *big.Int) {
+	w.bytes(v.Bytes())
+}
+
+// signedInt writes the sign of an integer followed by its absolute value
+func (w *wireWriter) signedInt(v *big.Int) {
+	w.bool(v.Sign() < 0)
+	w.bytes(v.Bytes())
+}
+
+func (w *wireWriter) bigInts(values []*big.Int) {
+	w.uint32(uint32(len(values)))
+	for _, v := range values {
+		w.bigInt(v)
+	}
+}
+
+func (w *wireWriter) point(p *curves.EcPoint) {
+	b, err := p.MarshalBinary()
+	if err != nil && w.err == nil {
+		w.err = err
+	}
+	w.fixed(b)
+}
+
+// wireReader reads the fields written by wireWriter. After the first error
+// every read returns a zero value and the error is kept.
+type wireReader struct {
+	data []byte
+	err  error
+}
+
+func (r *wireReader) fixed(n int) []byte {
+	if r.err != nil {
+		return nil
+	}
+	if n > len(r.data) {
+		r.err = fmt.Errorf("unexpected end of data")
+		return nil
+	}
+	b := r.data[:n]
+	r.data = r.data[n:]
+	return b
+}
+
+func (r *wireReader) uint32() uint32 {
+	b := r.fixed(4)
+	if b == nil {
+		return 0
+	}
+	return binary.BigEndian.Uint32(b)
+}
+
+func (r *wireReader) bool() bool {
+	b := r.fixed(1)
+	if b == nil {
+		return false
+	}
+	if b[0] > 1 {
+		r.err = fmt.Errorf("invalid boolean")
+		return false
+	}
+	return b[0] == 1
+}
+
+// count reads the number of elements that follow, each of which takes at least a byte
+func (r *wireReader) count() int {
+	n := r.uint32()
+	if r.err == nil && uint64(n) > uint64(len(r.data)) {
+		r.err = fmt.Errorf("invalid length %d", n)
+		return 0
+	}
+	return int(n)
+}
+
+func (r *wireReader) bytes() []byte {
+	n := r.count()
+	b := r.fixed(n)
+	if b == nil {
+		return nil
+	}
+	return append([]byte{}, b...)
+}
+
+func (r *wireReader) bigInt() *big.Int {
+	b := r.bytes()
+	if r.err != nil {
+		return nil
+	}
+	// Reject encodings with leading zeros so every integer has a single encoding
+	if len(b) > 0 && b[0] == 0 {
+		r.err = fmt.Errorf("non-canonical integer")
+		return nil
+	}
+	return new(big.Int).SetBytes(b)
+}
+
+func (r *wireReader) signedInt() *big.Int {
+	negative := r.bool()
+	v := r.bigInt()
+	if r.err != nil {
+		return nil
+	}
+	if negative {
+		// Reject negative zero so every integer has a single encoding
+		if v.Sign() == 0 {
+			r.err = fmt.Errorf("non-canonical integer")
+			return nil
+		}
+		v.Neg(v)
+	}
+	return v
+}
+
+func (r *wireReader) bigInts() []*big.Int {
+	n := r.count()
+	values := make([]*big.Int, 0, n)
+	for i := 0; i < n && r.err == nil; i++ {
+		values = append(values, r.bigInt())
+	}
+	return values
+}
+
+func (r *wireReader) point() *curves.EcPoint {
+	b := r.fixed(65)
+	if b == nil {
+		return nil
+	}
+	p := new(curves.EcPoint)
+	if err := p.UnmarshalBinary(b); err != nil {
+		r.err = err
+		return nil
+	}
+	return p
+}
+
+// unique fails if a map read with n entries has duplicate keys
+func (r *wireReader) unique(size, n int) {
+	if r.err == nil && size != n {
+		r.err = fmt.Errorf("duplicate entries")
+	}
+}
@@ -0,0 +1,203 @@
+//
+// Copyright Coinbase, Inc. All Rights Reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package participant
+
+import (
+	"math/big"
+	"reflect"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcec"
+	"github.com/stretchr/testify/require"
+
+	"github.com/nerifnetwork/kryptology/pkg/core/curves"
+	"github.com/nerifnetwork/kryptology/pkg/core/protocol"
+	"github.com/nerifnetwork/kryptology/pkg/tecdsa/bip32"
+	"github.com/nerifnetwork/kryptology/pkg/tecdsa/gg20/proof"
+)
+
+// roundTrip encodes the value and returns a decoded copy
+func roundTrip(t *testing.T, value interface{}, encoding string) interface{} {
+	m, err := EncodeMessage(value, encoding, protocol.Version1)
+	require.NoError(t, err)
+	decoded := reflect.New(reflect.TypeOf(value).Elem()).Interface()
+	require.NoError(t, DecodeMessage(m, decoded))
+	return decoded
+}
+
+// wireTransport replaces every message with its decoded copy, alternating the encodings
+func wireTransport(t *testing.T) func(value interface{}) {
+	encodings := []string{EncodingBinary, EncodingJSON}
+	i := 0
+	return func(value interface{}) {
+		decoded := roundTrip(t, value, encodings[i%len(encodings)])
+		i++
+		reflect.ValueOf(value).Elem().Set(reflect.ValueOf(decoded).Elem())
+	}
+}
+
+func TestWireSigning(t *testing.T) {
+	hash := []byte{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16}
+	for _, useDistributed := range []bool{false, true} {
+		_, signers := setupSignersMap(t, btcec.S256(), 3, 5, false, k256Verifier, useDistributed)
+		errs := runTamperedSigning(t, signers, hash, signingTamper{wire: wireTransport(t)})
+		for id, err := range errs {
+			require.NoError(t, err, "signer %d", id)
+		}
+	}
+}
+
+func TestWireAbortReveal(t *testing.T) {
+	_, signers := setupSignersMap(t, btcec.S256(), 3, 5, false, k256Verifier, false)
+	runTamperedSigning(t, signers, abortTestHash, signingTamper{
+		round3: cheatDelta(t, signers, 3),
+	})
+	reveals := make(map[uint32]*AbortReveal, len(signers))
+	for id, s := range signers {
+		reveal, err := s.SignAbortReveal()
+		require.NoError(t, err)
+		for _, encoding := range []string{EncodingBinary, EncodingJSON} {
+			decoded := roundTrip(t, reveal, encoding).(*AbortReveal)
+			require.Equal(t, reveal.K, decoded.K)
+			require.Equal(t, reveal.Signature, decoded.Signature)
+			require.Equal(t, len(reveal.Mta), len(decoded.Mta))
+			for j, mta := range reveal.Mta {
+				require.Equal(t, mta.BetaTick, decoded.Mta[j].BetaTick)
+				require.True(t, mta.Nu.Equals(decoded.Mta[j].Nu))
+				require.Equal(t, mta.ResponseSignature, decoded.Mta[j].ResponseSignature)
+			}
+			reveals[id] = decoded
+		}
+	}
+	delete(reveals, 1)
+	evidence, err := signers[1].AbortEvidence(reveals)
+	require.NoError(t, err)
+	requireCulprits(t, evidence, 3)
+}
+
+func TestWireDkg(t *testing.T) {
+	curve := btcec.S256()
+	participants, round1 := setupDkgRound2Params(curve, 2, 3)
+	bcast2, p2p2, _, err := participants[1].DkgRound2(round1)
+	require.NoError(t, err)
+
+	participants = setupDkgRound3ParticipantMap(curve, 2, 3)
+	decommitments := setupDkgRound3Commitments(t, participants, 3)
+	bcast3 := make(map[uint32]*DkgRound3Bcast, 3)
+	for i := uint32(1); i <= 3; i++ {
+		bcast3[i], _, err = participants[i].DkgRound3(decommitments, map[uint32]*DkgRound2P2PSend{
+			1: {participants[1].State.X[i-1]},
+			2: {participants[2].State.X[i-1]},
+			3: {participants[3].State.X[i-1]},
+		})
+		require.NoError(t, err)
+	}
+	result, _, err := participants[1].DkgRound4(map[uint32]*DkgRound3Bcast{2: bcast3[2], 3: bcast3[3]})
+	require.NoError(t, err)
+	// The fixture leaves out the proof params of the DKG
+	for _, pd := range result.ParticipantData {
+		pd.ProofParams = dealerParams
+	}
+
+	values := []interface{}{round1[2], bcast2, p2p2[2], bcast3[1], result}
+	for _, value := range values {
+		for _, encoding := range []string{EncodingBinary, EncodingJSON} {
+			require.Equal(t, value, roundTrip(t, value, encoding), "%T %s", value, encoding)
+		}
+	}
+
+	// Results encoded before chain codes were added still decode
+	require.Len(t, result.ChainCode, bip32.ChainCodeSize)
+	legacy := *result
+	legacy.ChainCode = nil
+	m, err := EncodeMessage(&legacy, EncodingBinary, protocol.Version1)
+	require.NoError(t, err)
+	m.Payloads[payloadKey] = m.Payloads[payloadKey][:len(m.Payloads[payloadKey])-4]
+	decoded := new(DkgResult)
+	require.NoError(t, DecodeMessage(m, decoded))
+	require.Nil(t, decoded.ChainCode)
+	legacy.ChainCode = result.ChainCode[1:]
+	_, err = EncodeMessage(&legacy, EncodingBinary, protocol.Version1)
+	require.Error(t, err)
+}
+
+func TestWireStrictDecode(t *testing.T) {
+	msg := &Round6FullBcast{big.NewInt(42)}
+	m, err := EncodeMessage(msg, EncodingBinary, protocol.Version1)
+	require.NoError(t, err)
+	require.Equal(t, protocol.Gg20Sign, m.Protocol)
+	require.NoError(t, DecodeMessage(m, new(Round6FullBcast)))
+
+	// Wrong round, version, protocol and encoding
+	require.Error(t, DecodeMessage(m, new(Round3Bcast)))
+	for _, change := range []func(*protocol.Message){
+		func(m *protocol.Message) { m.Version = protocol.Version0 },
+		func(m *protocol.Message) { m.Protocol = protocol.Gg20Dkg },
+		func(m *protocol.Message) { m.Metadata[EncodingKey] = "gob" },
+		func(m *protocol.Message) { m.Payloads["extra"] = []byte{1} },
+		// Truncated, trailing and non-canonical data
+		func(m *protocol.Message) { m.Payloads[payloadKey] = m.Payloads[payloadKey][:3] },
+		func(m *protocol.Message) { m.Payloads[payloadKey] = append(m.Payloads[payloadKey], 0) },
+		func(m *protocol.Message) { m.Payloads[payloadKey] = []byte{0, 0, 0, 2, 0, 42} },
+		func(m *protocol.Message) { m.Payloads[payloadKey] = []byte{0xff, 0xff, 0xff, 0xff} },
+	} {
+		bad, err := EncodeMessage(msg, EncodingBinary, protocol.Version1)
+		require.NoError(t, err)
+		change(bad)
+		require.Error(t, DecodeMessage(bad, new(Round6FullBcast)))
+	}
+
+	// Unknown, missing and trailing JSON fields
+	for _, payload := range []string{
+		`{"SElement":42,"Extra":1}`,
+		`{}`,
+		`{"SElement":-42}`,
+		`{"SElement":42}{}`,
+	} {
+		bad, err := EncodeMessage(msg, EncodingJSON, protocol.Version1)
+		require.NoError(t, err)
+		bad.Payloads[payloadKey] = []byte(payload)
+		require.Error(t, DecodeMessage(bad, new(Round6FullBcast)), payload)
+	}
+
+	// Point not on the curve
+	point, err := curves.NewScalarBaseMult(btcec.S256(), big.NewInt(7))
+	require.NoError(t, err)
+	signature := &curves.EcdsaSignature{R: big.NewInt(1), S: big.NewInt(1)}
+	stProof := &proof.StProof{E: big.NewInt(1), Z1: big.NewInt(1), Z2: big.NewInt(1)}
+	m, err = EncodeMessage(&Round5Bcast{Rbar: point, S: point, SProof: stProof, Signature: signature}, EncodingBinary, protocol.Version1)
+	require.NoError(t, err)
+	m.Payloads[payloadKey][64]++
+	require.Error(t, DecodeMessage(m, new(Round5Bcast)))
+
+	// Incomplete and secret values are not encoded
+	_, err = EncodeMessage(&Round6FullBcast{}, EncodingBinary, protocol.Version1)
+	require.Error(t, err)
+	response := &proof.ResponseProof{
+		R2proof: &proof.Range2Proof{Z: big.NewInt(1), E: big.NewInt(1), S: big.NewInt(1), S1: big.NewInt(1),
+			S2: big.NewInt(1), T: big.NewInt(1), T1: big.NewInt(1), T2: big.NewInt(1)},
+		C2:   big.NewInt(1),
+		Beta: big.NewInt(1),
+	}
+	_, err = EncodeMessage(&Round2P2PSend{Proof2: response, Proof3: response, Signature: signature}, EncodingJSON, protocol.Version1)
+	require.Error(t, err)
+	_, err = EncodeMessage(&Round5Bcast{Rbar: point, Signature: signature}, EncodingBinary, protocol.Version1)
+	require.Error(t, err)
+	// Nor are unsigned messages
+	_, err = EncodeMessage(&Round5Bcast{Rbar: point, S: point, SProof: stProof}, EncodingBinary, protocol.Version1)
+	require.Error(t, err)
+
+	_, err = EncodeMessage(msg, EncodingBinary, protocol.Version0)
+	require.Error(t, err)
+	_, err = EncodeMessage(msg, "xml", protocol.Version1)
+	require.Error(t, err)
+	_, err = EncodeMessage(&Presignature{}, EncodingBinary, protocol.Version1)
+	require.Error(t, err)
+	_, err = EncodeMessage((*Round6FullBcast)(nil), EncodingBinary, protocol.Version1)
+	require.Error(t, err)
+	require.Error(t, DecodeMessage(nil, new(Round6FullBcast)))
+}
@@ -51,6 +51,9 @@ type ResponseFinalizer interface {
 type ResponseProof struct {
 	R2proof  *Range2Proof
 	C2, Beta *big.Int
+	// BetaTick is the mask β' encrypted in C2, β = -β' mod q.
+	// Like Beta it is kept by the responder and not sent.
+	BetaTick *big.Int
 
 	mockResponse *big.Int
 }
@@ -193,6 +196,7 @@ func genResponseProof(rp ResponseProofParams, wc bool) (*ResponseProof, error) {
 		r2p,
 		c2,
 		beta,
+		betaTick,
 		nil,
 	}, nil
 }
@@ -8,6 +8,7 @@
 //   - proof of discrete logarithm (PDL) subprotocol from [spec] §8
 //   - multiplicative-to-additive (MtA) subprotocol from [spec] §7
 //   - proof of knowledge of a discrete log modulo a composite (fig 16), i.e., ProveCompositeDL and VerifyCompositeDL
+//   - proof that S_i = R^{σ_i} is consistent with the commitment T_i = g^{σ_i} h^{l_i} from [spec] §4.3
 package proof
 
 import (
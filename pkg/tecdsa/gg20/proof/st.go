@@ -0,0 +1,182 @@
+//
+// Copyright Coinbase, Inc. All Rights Reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package proof
+
+import (
+	"crypto/elliptic"
+	"fmt"
+	"math/big"
+
+	crypto "github.com/nerifnetwork/kryptology/pkg/core"
+	"github.com/nerifnetwork/kryptology/pkg/core/curves"
+)
+
+// stGeneratorDomain is hashed to the curve to get the generator h of the commitments T_i
+var stGeneratorDomain = []byte("kryptology gg20 sigma commitment generator")
+
+// StGenerator returns the generator h of the commitments T_i = g^{σ_i} h^{l_i} in
+// [spec] §4.3. It is hashed to the curve, so nobody knows its discrete log to the base g.
+func StGenerator(curve elliptic.Curve) (*curves.EcPoint, error) {
+	if curve == nil {
+		return nil, fmt.Errorf("curve cannot be nil")
+	}
+	c := curves.GetCurveByName(curve.Params().Name)
+	if c == nil {
+		return nil, fmt.Errorf("unsupported curve %s", curve.Params().Name)
+	}
+	h := c.Point.Hash(stGeneratorDomain).ToAffineUncompressed()
+	return curves.PointFromBytesUncompressed(curve, h[1:])
+}
+
+// StProofParams encapsulates the parameters for proving that S = R^σ and
+// T = g^σ h^l hide the same σ, as in
+// [spec] §4.3
+type StProofParams struct {
+	Curve      elliptic.Curve
+	Sigma, L   *big.Int
+	H, R, T, S *curves.EcPoint
+}
+
+// StProof is the proof generated in
+// [spec] §4.3
+type StProof struct {
+	E, Z1, Z2 *big.Int
+}
+
+// StVerifyParams encapsulates the parameters for verifying an StProof in
+// [spec] §4.3
+type StVerifyParams struct {
+	Curve      elliptic.Curve
+	H, R, T, S *curves.EcPoint
+}
+
+// Prove generates an StProof as specified in
+// [spec] §4.3
+func (p StProofParams) Prove() (*StProof, error) {
+	if p.Curve == nil || p.Sigma == nil || p.L == nil ||
+		p.H == nil || p.R == nil || p.T == nil || p.S == nil {
+		return nil, fmt.Errorf("invalid params")
+	}
+	q := p.Curve.Params().N
+
+	// 1. Choose a, b ∈ Z_q
+	a, err := crypto.Rand(q)
+	if err != nil {
+		return nil, err
+	}
+	b, err := crypto.Rand(q)
+	if err != nil {
+		return nil, err
+	}
+
+	// 2. Compute A = g^a h^b and B = R^a in G
+	A, err := pedersenPoint(p.Curve, p.H, a, b)
+	if err != nil {
+		return nil, err
+	}
+	B, err := p.R.ScalarMult(a)
+	if err != nil {
+		return nil, err
+	}
+
+	// 3. Compute e = H(g,q,h,R,T,S,A,B)
+	e, err := stChallenge(p.Curve, p.H, p.R, p.T, p.S, A, B)
+	if err != nil {
+		return nil, err
+	}
+
+	// 4. Compute z1 = a + eσ and z2 = b + el mod q
+	z1, err := schnorr(e, p.Sigma, a)
+	if err != nil {
+		return nil, err
+	}
+	z2, err := schnorr(e, p.L, b)
+	if err != nil {
+		return nil, err
+	}
+
+	return &StProof{
+		E:  e,
+		Z1: z1.Mod(z1, q),
+		Z2: z2.Mod(z2, q),
+	}, nil
+}
+
+// Verify checks the StProof as specified in
+// [spec] §4.3
+func (p StProof) Verify(pv *StVerifyParams) error {
+	if p.E == nil || p.Z1 == nil || p.Z2 == nil {
+		return fmt.Errorf("proof values cannot be nil")
+	}
+	if pv == nil || pv.Curve == nil || pv.H == nil || pv.R == nil || pv.T == nil || pv.S == nil {
+		return fmt.Errorf("proof verify params cannot be nil")
+	}
+	negE := new(big.Int).Neg(p.E)
+
+	// 1. Compute \hat{A} = g^{z1} h^{z2} T^{-e} in G
+	gh, err := pedersenPoint(pv.Curve, pv.H, p.Z1, p.Z2)
+	if err != nil {
+		return err
+	}
+	tNegE, err := pv.T.ScalarMult(negE)
+	if err != nil {
+		return err
+	}
+	aHat, err := gh.Add(tNegE)
+	if err != nil {
+		return err
+	}
+
+	// 2. Compute \hat{B} = R^{z1} S^{-e} in G
+	rz, err := pv.R.ScalarMult(p.Z1)
+	if err != nil {
+		return err
+	}
+	sNegE, err := pv.S.ScalarMult(negE)
+	if err != nil {
+		return err
+	}
+	bHat, err := rz.Add(sNegE)
+	if err != nil {
+		return err
+	}
+
+	// 3. Check e = H(g,q,h,R,T,S,\hat{A},\hat{B})
+	eHat, err := stChallenge(pv.Curve, pv.H, pv.R, pv.T, pv.S, aHat, bHat)
+	if err != nil {
+		return err
+	}
+	if !crypto.ConstantTimeEq(p.E, eHat) {
+		return fmt.Errorf("e != eHat")
+	}
+	return nil
+}
+
+// pedersenPoint computes g^a h^b in G
+func pedersenPoint(curve elliptic.Curve, h *curves.EcPoint, a, b *big.Int) (*curves.EcPoint, error) {
+	ga, err := curves.NewScalarBaseMult(curve, a)
+	if err != nil {
+		return nil, err
+	}
+	hb, err := h.ScalarMult(b)
+	if err != nil {
+		return nil, err
+	}
+	return ga.Add(hb)
+}
+
+func stChallenge(curve elliptic.Curve, points ...*curves.EcPoint) (*big.Int, error) {
+	values := []*big.Int{curve.Params().Gx, curve.Params().Gy, curve.Params().N}
+	for _, p := range points {
+		values = append(values, p.X, p.Y)
+	}
+	challenge, err := crypto.FiatShamir(values...)
+	if err != nil {
+		return nil, err
+	}
+	return new(big.Int).Mod(new(big.Int).SetBytes(challenge), curve.Params().N), nil
+}
@@ -0,0 +1,88 @@
+//
+// Copyright Coinbase, Inc. All Rights Reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package proof
+
+import (
+	"crypto/elliptic"
+	"math/big"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcec"
+	"github.com/stretchr/testify/require"
+
+	crypto "github.com/nerifnetwork/kryptology/pkg/core"
+	"github.com/nerifnetwork/kryptology/pkg/core/curves"
+)
+
+func makeStProofParams(t *testing.T, curve elliptic.Curve) *StProofParams {
+	t.Helper()
+	h, err := StGenerator(curve)
+	require.NoError(t, err)
+	k, err := crypto.Rand(curve.Params().N)
+	require.NoError(t, err)
+	R, err := curves.NewScalarBaseMult(curve, k)
+	require.NoError(t, err)
+	sigma, err := crypto.Rand(curve.Params().N)
+	require.NoError(t, err)
+	l, err := crypto.Rand(curve.Params().N)
+	require.NoError(t, err)
+	T, err := pedersenPoint(curve, h, sigma, l)
+	require.NoError(t, err)
+	S, err := R.ScalarMult(sigma)
+	require.NoError(t, err)
+	return &StProofParams{Curve: curve, Sigma: sigma, L: l, H: h, R: R, T: T, S: S}
+}
+
+func TestStProof(t *testing.T) {
+	for _, curve := range []elliptic.Curve{btcec.S256(), elliptic.P256()} {
+		params := makeStProofParams(t, curve)
+		pi, err := params.Prove()
+		require.NoError(t, err)
+		verify := &StVerifyParams{Curve: curve, H: params.H, R: params.R, T: params.T, S: params.S}
+		require.NoError(t, pi.Verify(verify))
+
+		// S must hide the σ of T
+		verify.S, err = params.S.Add(params.R)
+		require.NoError(t, err)
+		require.Error(t, pi.Verify(verify))
+
+		wrong := *params
+		wrong.S = verify.S
+		pi, err = wrong.Prove()
+		require.NoError(t, err)
+		require.Error(t, pi.Verify(verify))
+	}
+}
+
+func TestStProofTampered(t *testing.T) {
+	params := makeStProofParams(t, btcec.S256())
+	pi, err := params.Prove()
+	require.NoError(t, err)
+	verify := &StVerifyParams{Curve: params.Curve, H: params.H, R: params.R, T: params.T, S: params.S}
+	for _, v := range []**big.Int{&pi.E, &pi.Z1, &pi.Z2} {
+		old := *v
+		*v = new(big.Int).Add(old, big.NewInt(1))
+		require.Error(t, pi.Verify(verify))
+		*v = old
+	}
+	require.NoError(t, pi.Verify(verify))
+	require.Error(t, pi.Verify(nil))
+	require.Error(t, StProof{}.Verify(verify))
+}
+
+func TestStGenerator(t *testing.T) {
+	h, err := StGenerator(btcec.S256())
+	require.NoError(t, err)
+	require.True(t, h.IsOnCurve())
+	require.False(t, h.IsBasePoint())
+	again, err := StGenerator(btcec.S256())
+	require.NoError(t, err)
+	require.True(t, h.Equals(again))
+
+	_, err = StGenerator(elliptic.P224())
+	require.Error(t, err)
+}
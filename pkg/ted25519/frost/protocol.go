@@ -0,0 +1,231 @@
+//
+// Copyright Coinbase, Inc. All Rights Reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package frost
+
+import (
+	"fmt"
+
+	"github.com/nerifnetwork/kryptology/pkg/core/curves"
+	"github.com/nerifnetwork/kryptology/pkg/core/protocol"
+	"github.com/nerifnetwork/kryptology/pkg/dkg/frost"
+	"github.com/nerifnetwork/kryptology/pkg/sharing"
+)
+
+// SupportedVersions are the message versions understood by SignerIterator
+var SupportedVersions = []uint{protocol.Version1}
+
+// SignerIterator runs FROST signing through the protocol.Iterator interface.
+// The rounds are SignRound1, SignRound2 and SignRound3. The inputs of each round
+// are the outputs of all cosigners combined with protocol.Route.
+type SignerIterator struct {
+	protocol.Stepper
+	*Signer
+	version   uint
+	signature *Round3Bcast
+}
+
+var _ protocol.Iterator = &SignerIterator{}
+
+// NewSignerIterator creates a signer from the result of a frost.DkgIterator that
+// satisfies the protocol iterator interface. Exactly threshold cosigners, including
+// this signer, must take part.
+func NewSignerIterator(dkgResult *protocol.Message, cosigners []uint32, message []byte, challengeDeriver ChallengeDerive, version uint) (*SignerIterator, error) {
+	if err := protocol.CheckVersion(version, SupportedVersions); err != nil {
+		return nil, err
+	}
+	if len(message) == 0 || challengeDeriver == nil {
+		return nil, fmt.Errorf("message and challenge deriver cannot be empty")
+	}
+	result, err := frost.DecodeDkgResult(dkgResult)
+	if err != nil {
+		return nil, err
+	}
+	curve := curves.GetCurveByName(result.VerificationKey.CurveName())
+	if curve == nil {
+		return nil, fmt.Errorf("unsupported curve %s", result.VerificationKey.CurveName())
+	}
+	if uint32(len(cosigners)) != result.Threshold {
+		return nil, fmt.Errorf("expected %d cosigners", result.Threshold)
+	}
+	limit := result.Threshold
+	found := false
+	for _, id := range cosigners {
+		if id > limit {
+			limit = id
+		}
+		found = found || id == result.Id
+	}
+	if !found {
+		return nil, fmt.Errorf("signer %d is not a cosigner", result.Id)
+	}
+	for _, id := range cosigners {
+		if vk, ok := result.VkShares[id]; !ok || vk == nil {
+			return nil, fmt.Errorf("missing verification key share of cosigner %d", id)
+		}
+	}
+	scheme, err := sharing.NewShamir(result.Threshold, limit, curve)
+	if err != nil {
+		return nil, err
+	}
+	lCoeffs, err := scheme.LagrangeCoeffs(cosigners)
+	if err != nil {
+		return nil, err
+	}
+	info := &frost.DkgParticipant{
+		Id:              result.Id,
+		Curve:           curve,
+		SkShare:         result.SkShare,
+		VkShare:         result.VkShare,
+		VerificationKey: result.VerificationKey,
+	}
+	signer, err := NewSigner(info, result.Id, result.Threshold, lCoeffs, cosigners, challengeDeriver)
+	if err != nil {
+		return nil, err
+	}
+	frost.RegisterTypes(curve)
+
+	s := &SignerIterator{Signer: signer, version: version}
+	s.Steps = []func(*protocol.Message) (*protocol.Message, error){
+		func(*protocol.Message) (*protocol.Message, error) {
+			bcast, err := s.SignRound1()
+			if err != nil {
+				return nil, err
+			}
+			return s.newMessage("1", bcast)
+		},
+		func(input *protocol.Message) (*protocol.Message, error) {
+			round2Input := make(map[uint32]*Round1Bcast)
+			err := s.decodeInput(input, "1", func(id uint32, data []byte) error {
+				round2Input[id] = new(Round1Bcast)
+				return protocol.DecodePayload(data, round2Input[id])
+			})
+			if err != nil {
+				return nil, err
+			}
+			bcast, err := s.SignRound2(message, round2Input)
+			if err != nil {
+				return nil, err
+			}
+			return s.newMessage("2", bcast)
+		},
+		func(input *protocol.Message) (*protocol.Message, error) {
+			round3Input := make(map[uint32]*Round2Bcast)
+			err := s.decodeInput(input, "2", func(id uint32, data []byte) error {
+				round3Input[id] = new(Round2Bcast)
+				if err := protocol.DecodePayload(data, round3Input[id]); err != nil {
+					return err
+				}
+				// Vki is checked against the DKG output, a cosigner could otherwise
+				// send a key share that matches an invalid signature share
+				if round3Input[id].Vki == nil || !round3Input[id].Vki.Equal(result.VkShares[id]) {
+					return fmt.Errorf("verification key share does not match the dkg result")
+				}
+				return nil
+			})
+			if err != nil {
+				return nil, err
+			}
+			signature, err := s.SignRound3(round3Input)
+			if err != nil {
+				return nil, err
+			}
+			s.signature = signature
+			return nil, nil
+		},
+	}
+	return s, nil
+}
+
+// Result returns the encoded signature. Returns nil if signing has not completed.
+func (s *SignerIterator) Result(version uint) (*protocol.Message, error) {
+	if !s.Complete() {
+		return nil, nil
+	}
+	if s.signature == nil {
+		return nil, protocol.ErrNotInitialized
+	}
+	if err := protocol.CheckVersion(version, SupportedVersions); err != nil {
+		return nil, err
+	}
+	payload, err := protocol.EncodePayload(s.signature)
+	if err != nil {
+		return nil, err
+	}
+	return &protocol.Message{
+		Protocol: protocol.FrostSign,
+		Version:  version,
+		Payloads: map[string][]byte{"result": payload},
+		Metadata: map[string]string{protocol.RoundKey: "result"},
+	}, nil
+}
+
+// DecodeSignResult decodes the signature produced by a SignerIterator
+func DecodeSignResult(m *protocol.Message) (*Round3Bcast, error) {
+	if m == nil {
+		return nil, fmt.Errorf("message cannot be nil")
+	}
+	if m.Protocol != protocol.FrostSign {
+		return nil, fmt.Errorf("expected a %s message", protocol.FrostSign)
+	}
+	if err := protocol.CheckVersion(m.Version, SupportedVersions); err != nil {
+		return nil, err
+	}
+	result := new(Round3Bcast)
+	if err := protocol.DecodePayload(m.Payloads["result"], result); err != nil {
+		return nil, err
+	}
+	if result.R == nil || result.Z == nil || result.C == nil {
+		return nil, fmt.Errorf("incomplete signature")
+	}
+	return result, nil
+}
+
+func (s *SignerIterator) newMessage(round string, broadcast interface{}) (*protocol.Message, error) {
+	payload, err := protocol.EncodePayload(broadcast)
+	if err != nil {
+		return nil, err
+	}
+	return &protocol.Message{
+		Protocol: protocol.FrostSign,
+		Version:  s.version,
+		Payloads: map[string][]byte{protocol.BroadcastKey: payload},
+		Metadata: map[string]string{protocol.RoundKey: round},
+	}, nil
+}
+
+// decodeInput checks the routed input of a round and decodes the broadcast of every cosigner
+func (s *SignerIterator) decodeInput(input *protocol.Message, round string, broadcast func(uint32, []byte) error) error {
+	if input == nil {
+		return fmt.Errorf("message cannot be nil")
+	}
+	if input.Protocol != protocol.FrostSign || input.Version != s.version {
+		return fmt.Errorf("expected %s version %d", protocol.FrostSign, s.version)
+	}
+	if input.Metadata[protocol.RoundKey] != round {
+		return fmt.Errorf("expected the messages of round %s", round)
+	}
+	broadcasts, direct, err := protocol.Inbound(input)
+	if err != nil {
+		return err
+	}
+	if len(direct) > 0 {
+		return fmt.Errorf("unexpected direct message in round %s", round)
+	}
+	if len(broadcasts) != len(s.cosigners) {
+		return fmt.Errorf("expected messages from %d cosigners", len(s.cosigners))
+	}
+	for _, id := range s.cosigners {
+		data, ok := broadcasts[id]
+		if !ok {
+			return fmt.Errorf("missing broadcast from cosigner %d", id)
+		}
+		if err = broadcast(id, data); err != nil {
+			return fmt.Errorf("invalid broadcast from cosigner %d: %v", id, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,169 @@
+//
+// Copyright Coinbase, Inc. All Rights Reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package frost
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/nerifnetwork/kryptology/pkg/core/protocol"
+	dkg "github.com/nerifnetwork/kryptology/pkg/dkg/frost"
+)
+
+// runIterators routes the outputs of every round to all participants until they finish
+func runIterators(t *testing.T, iterators map[uint32]protocol.Iterator) {
+	inputs := make(map[uint32]*protocol.Message, len(iterators))
+	for {
+		outputs := make(map[uint32]*protocol.Message, len(iterators))
+		finished := 0
+		for id, it := range iterators {
+			output, err := it.Next(inputs[id])
+			if err == protocol.ErrProtocolFinished {
+				finished++
+				continue
+			}
+			require.NoError(t, err)
+			if output != nil {
+				outputs[id] = output
+			}
+		}
+		if finished == len(iterators) {
+			return
+		}
+		require.Equal(t, 0, finished)
+		for id := range iterators {
+			if len(outputs) == 0 {
+				inputs[id] = nil
+				continue
+			}
+			var err error
+			inputs[id], err = protocol.Route(id, outputs)
+			require.NoError(t, err)
+		}
+	}
+}
+
+func TestSignerIterator(t *testing.T) {
+	threshold := uint32(2)
+	dkgIterators := make(map[uint32]protocol.Iterator, 3)
+	for i := uint32(1); i <= 3; i++ {
+		var others []uint32
+		for j := uint32(1); j <= 3; j++ {
+			if j != i {
+				others = append(others, j)
+			}
+		}
+		it, err := dkg.NewDkgIterator(i, threshold, ctx, testCurve, protocol.Version1, others...)
+		require.NoError(t, err)
+		dkgIterators[i] = it
+	}
+	runIterators(t, dkgIterators)
+
+	msg := []byte("message")
+	cosigners := []uint32{1, 3}
+	signers := make(map[uint32]protocol.Iterator, len(cosigners))
+	for _, id := range cosigners {
+		result, err := dkgIterators[id].Result(protocol.Version1)
+		require.NoError(t, err)
+		signers[id], err = NewSignerIterator(result, cosigners, msg, &Ed25519ChallengeDeriver{}, protocol.Version1)
+		require.NoError(t, err)
+	}
+	runIterators(t, signers)
+
+	dkgResult, err := dkgIterators[1].Result(protocol.Version1)
+	require.NoError(t, err)
+	vk, err := dkg.DecodeDkgResult(dkgResult)
+	require.NoError(t, err)
+	for _, id := range cosigners {
+		result, err := signers[id].Result(protocol.Version1)
+		require.NoError(t, err)
+		signature, err := DecodeSignResult(result)
+		require.NoError(t, err)
+		ok, err := Verify(testCurve, &Ed25519ChallengeDeriver{}, vk.VerificationKey, msg, &Signature{signature.Z, signature.C})
+		require.NoError(t, err)
+		require.True(t, ok)
+	}
+}
+
+func TestSignerIteratorBadInput(t *testing.T) {
+	_, err := NewSignerIterator(nil, []uint32{1, 2}, []byte("message"), &Ed25519ChallengeDeriver{}, protocol.Version1)
+	require.Error(t, err)
+
+	dkgIterators := make(map[uint32]protocol.Iterator, 2)
+	for i := uint32(1); i <= 2; i++ {
+		it, err := dkg.NewDkgIterator(i, 2, ctx, testCurve, protocol.Version1, 3-i)
+		require.NoError(t, err)
+		dkgIterators[i] = it
+	}
+	runIterators(t, dkgIterators)
+	result, err := dkgIterators[1].Result(protocol.Version1)
+	require.NoError(t, err)
+
+	_, err = NewSignerIterator(result, []uint32{1, 2}, []byte("message"), &Ed25519ChallengeDeriver{}, protocol.Version0)
+	require.Error(t, err)
+	_, err = NewSignerIterator(result, []uint32{1}, []byte("message"), &Ed25519ChallengeDeriver{}, protocol.Version1)
+	require.Error(t, err)
+	_, err = NewSignerIterator(result, []uint32{2, 3}, []byte("message"), &Ed25519ChallengeDeriver{}, protocol.Version1)
+	require.Error(t, err)
+	_, err = NewSignerIterator(result, []uint32{1, 2}, nil, &Ed25519ChallengeDeriver{}, protocol.Version1)
+	require.Error(t, err)
+
+	signer, err := NewSignerIterator(result, []uint32{1, 2}, []byte("message"), &Ed25519ChallengeDeriver{}, protocol.Version1)
+	require.NoError(t, err)
+	output, err := signer.Next(nil)
+	require.NoError(t, err)
+	// Only the broadcast of signer 1 is routed
+	input, err := protocol.Route(1, map[uint32]*protocol.Message{1: output})
+	require.NoError(t, err)
+	_, err = signer.Next(input)
+	require.Error(t, err)
+}
+
+func TestSignerIteratorRejectsWrongVkShare(t *testing.T) {
+	dkgIterators := make(map[uint32]protocol.Iterator, 2)
+	for i := uint32(1); i <= 2; i++ {
+		it, err := dkg.NewDkgIterator(i, 2, ctx, testCurve, protocol.Version1, 3-i)
+		require.NoError(t, err)
+		dkgIterators[i] = it
+	}
+	runIterators(t, dkgIterators)
+
+	signers := make(map[uint32]protocol.Iterator, 2)
+	for id, it := range dkgIterators {
+		result, err := it.Result(protocol.Version1)
+		require.NoError(t, err)
+		signers[id], err = NewSignerIterator(result, []uint32{1, 2}, []byte("message"), &Ed25519ChallengeDeriver{}, protocol.Version1)
+		require.NoError(t, err)
+	}
+	outputs := make(map[uint32]*protocol.Message, 2)
+	for id, s := range signers {
+		out, err := s.Next(nil)
+		require.NoError(t, err)
+		outputs[id] = out
+	}
+	round2 := make(map[uint32]*protocol.Message, 2)
+	for id, s := range signers {
+		input, err := protocol.Route(id, outputs)
+		require.NoError(t, err)
+		round2[id], err = s.Next(input)
+		require.NoError(t, err)
+	}
+	outputs = round2
+
+	// Signer 2 claims another verification key share
+	bcast := new(Round2Bcast)
+	require.NoError(t, protocol.DecodePayload(outputs[2].Payloads[protocol.BroadcastKey], bcast))
+	bcast.Vki = bcast.Vki.Double()
+	payload, err := protocol.EncodePayload(bcast)
+	require.NoError(t, err)
+	outputs[2].Payloads[protocol.BroadcastKey] = payload
+	input, err := protocol.Route(1, outputs)
+	require.NoError(t, err)
+	_, err = signers[1].Next(input)
+	require.ErrorContains(t, err, "verification key share")
+}
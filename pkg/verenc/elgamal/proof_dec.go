@@ -0,0 +1,65 @@
+//
+// Copyright Coinbase, Inc. All Rights Reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package elgamal
+
+import (
+	crand "crypto/rand"
+	"fmt"
+
+	"github.com/nerifnetwork/kryptology/internal"
+	"github.com/nerifnetwork/kryptology/pkg/core/curves"
+)
+
+// ProofDecrypt proves that a point M is the El-Gamal decryption of a
+// homomorphic ciphertext, i.e. log_G(Q) = log_C1(C2 - M) for the encryption key Q
+type ProofDecrypt struct {
+	Challenge, Response curves.Scalar
+}
+
+// DecryptWithProof returns M = C2 - C1 * x like Decrypt and a proof that
+// M is correct, which anyone with the encryption key can check.
+// The domain binds the proof to a context, the verifier needs the same value.
+func (dk DecryptionKey) DecryptWithProof(cipherText *HomomorphicCipherText, domain []byte) (curves.Point, *ProofDecrypt, error) {
+	if cipherText == nil || cipherText.C1 == nil || cipherText.C2 == nil {
+		return nil, nil, internal.ErrNilArguments
+	}
+	ek := dk.EncryptionKey()
+	msg := cipherText.C2.Sub(cipherText.C1.Mul(dk.x))
+
+	w := dk.x.Random(crand.Reader)
+	a := ek.Value.Generator().Mul(w)
+	b := cipherText.C1.Mul(w)
+	challenge := decryptChallenge(domain, ek, cipherText, msg, a, b)
+	return msg, &ProofDecrypt{
+		Challenge: challenge,
+		Response:  w.Sub(challenge.Mul(dk.x)),
+	}, nil
+}
+
+// VerifyDecryption checks that msg is the decryption of cipherText under the
+// decryption key of ek
+func (ek EncryptionKey) VerifyDecryption(cipherText *HomomorphicCipherText, msg curves.Point, proof *ProofDecrypt, domain []byte) error {
+	if ek.Value == nil || cipherText == nil || cipherText.C1 == nil || cipherText.C2 == nil || msg == nil ||
+		proof == nil || proof.Challenge == nil || proof.Response == nil {
+		return internal.ErrNilArguments
+	}
+	// A = z * G + c * Q and B = z * C1 + c * (C2 - M)
+	a := ek.Value.Generator().Mul(proof.Response).Add(ek.Value.Mul(proof.Challenge))
+	b := cipherText.C1.Mul(proof.Response).Add(cipherText.C2.Sub(msg).Mul(proof.Challenge))
+	if decryptChallenge(domain, &ek, cipherText, msg, a, b).Cmp(proof.Challenge) != 0 {
+		return fmt.Errorf("invalid decryption proof")
+	}
+	return nil
+}
+
+func decryptChallenge(domain []byte, ek *EncryptionKey, cipherText *HomomorphicCipherText, msg, a, b curves.Point) curves.Scalar {
+	transcript := append([]byte("elgamal decryption"), domain...)
+	for _, p := range []curves.Point{ek.Value, cipherText.C1, cipherText.C2, msg, a, b} {
+		transcript = append(transcript, p.ToAffineCompressed()...)
+	}
+	return ek.Value.Scalar().Hash(transcript)
+}
@@ -0,0 +1,39 @@
+//
+// Copyright Coinbase, Inc. All Rights Reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package elgamal
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/nerifnetwork/kryptology/pkg/core/curves"
+)
+
+func TestDecryptWithProof(t *testing.T) {
+	for _, curve := range []*curves.Curve{curves.K256(), curves.ED25519()} {
+		ek, dk, err := NewKeys(curve)
+		require.NoError(t, err)
+		domain := []byte("decryption proof test")
+		cipherText, err := ek.HomomorphicEncrypt(curve.Scalar.New(42))
+		require.NoError(t, err)
+
+		msg, proof, err := dk.DecryptWithProof(cipherText, domain)
+		require.NoError(t, err)
+		require.True(t, msg.Equal(curve.ScalarBaseMult(curve.Scalar.New(42))))
+		require.NoError(t, ek.VerifyDecryption(cipherText, msg, proof, domain))
+
+		// wrong message, domain or key
+		require.Error(t, ek.VerifyDecryption(cipherText, msg.Double(), proof, domain))
+		require.Error(t, ek.VerifyDecryption(cipherText, msg, proof, []byte("other domain")))
+		other, _, err := NewKeys(curve)
+		require.NoError(t, err)
+		require.Error(t, other.VerifyDecryption(cipherText, msg, proof, domain))
+		_, _, err = dk.DecryptWithProof(nil, domain)
+		require.Error(t, err)
+	}
+}